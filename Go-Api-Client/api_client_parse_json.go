@@ -1,5 +1,17 @@
 package main
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"logging"
+	"telemetry"
+)
+
 /* type Page struct {
 	Name string `json:"page"`
 }
@@ -13,9 +25,95 @@ type Occurrence struct {
 	Words map[string]int `json:"words"`
 } */
 
+// commands are the subcommands, run as ./go-api-client <command> [url] until they
+// finish or Ctrl-C. Without a subcommand, the url in the first argument is parsed.
+var commands = map[string]func(ctx context.Context, url string) error{
+	// report [base-url] fetches /words and /occurrence concurrently
+	"report": func(ctx context.Context, baseURL string) error {
+		report, err := fetchReport(ctx, newClient(baseURL))
+		if err != nil {
+			return fmt.Errorf("failed to fetch the report: %w", err)
+		}
+		printReport(os.Stdout, report)
+		return nil
+	},
+	// stream [base-url] prints the words added to the test-server
+	"stream": func(ctx context.Context, baseURL string) error {
+		err := newClient(baseURL).StreamWords(ctx, func(word string) error {
+			fmt.Printf("Word: %s\n", word)
+			return nil
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return fmt.Errorf("stream failed: %w", err)
+		}
+		return nil
+	},
+	// ws [url] prints the words pushed over a WebSocket, typing a duration like
+	// 200ms changes the interval between the words
+	"ws": func(ctx context.Context, url string) error {
+		if err := watchWords(ctx, url, os.Stdin, os.Stdout); err != nil {
+			return fmt.Errorf("WebSocket failed: %w", err)
+		}
+		return nil
+	},
+	// events [url] prints the occurrence events, and reconnects without missing
+	// events when the connection drops
+	"events": func(ctx context.Context, url string) error {
+		if err := watchEvents(ctx, url, os.Stdout); err != nil {
+			return fmt.Errorf("events failed: %w", err)
+		}
+		return nil
+	},
+}
+
+// urlArgument returns the optional url after the subcommand, "" for the default
+func urlArgument() string {
+	if len(os.Args) > 2 {
+		return os.Args[2]
+	}
+	return ""
+}
+
 func main() {
+	// the requests are logged to stderr, LOG_LEVEL=warn hides them
+	logger, err := logging.Setup()
+	if err != nil {
+		logger.Error("Invalid logging configuration", "error", err)
+		os.Exit(1)
+	}
+	// exports the traces to the collector of OTEL_EXPORTER_OTLP_ENDPOINT, if set
+	shutdownTelemetry, err := telemetry.Setup(context.Background(), "go-api-client")
+	if err != nil {
+		logger.Error("Telemetry error", "error", err)
+		os.Exit(1)
+	}
+	// the spans are exported before exiting
+	flushTelemetry := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTelemetry(ctx)
+	}
 
-	getHTTPJsonMap()
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			err := command(ctx, urlArgument())
+			stop()
+			flushTelemetry()
+			if err != nil {
+				logger.Error("Command failed", "command", os.Args[1], "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	err = getHTTPJsonMap()
+	flushTelemetry()
+	if err != nil {
+		logger.Error("Request failed", "error", err)
+		os.Exit(1)
+	}
 	/* args := os.Args
 
 	if len(args) < 2 {
@@ -1,46 +1,28 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"net/url"
 	"os"
-	"strings"
-)
-
-type Page struct {
-	Name string `json:"page"`
-}
 
-type Response interface {
-	GetResponse() string
-}
-
-type Words struct {
-	Input string   `json:"input"`
-	Words []string `json:"words"`
-}
-
-func (w Words) GetResponse() string {
-	return fmt.Sprintf("Words: %s", strings.Join(w.Words, ", "))
-}
+	"telemetry"
+	"wordsclient"
+)
 
-type Occurrence struct {
-	Words map[string]int `json:"words"`
+// newClient creates a client logging its requests with the default logger, with the
+// X-Request-ID also logged by the test-server, and sending the trace context of its
+// requests
+func newClient(baseURL string) *wordsclient.Client {
+	return wordsclient.New(wordsclient.Options{
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{Transport: telemetry.Transport(nil)},
+		Logger:     slog.Default(),
+	})
 }
 
-func (o Occurrence) GetResponse() string {
-	words := []string{}
-	for word, occurrence := range o.Words {
-		words = append(words, fmt.Sprintf("%s: %d", word, occurrence))
-	}
-	return fmt.Sprintf("Words: %s", strings.Join(words, ", "))
-}
-
-func getHTTPJsonMap() {
+func getHTTPJsonMap() error {
 	args := os.Args
 
 	if len(args) < 2 {
@@ -48,74 +30,11 @@ func getHTTPJsonMap() {
 		os.Exit(1)
 	}
 
-	res, err := doRequest(args[1])
+	res, err := newClient("").Get(context.Background(), args[1])
 	if err != nil {
-		log.Fatalf("Failed to make HTTP request: %s\n", err)
-	}
-
-	if res == nil {
-		fmt.Printf("No response received.\n")
-		os.Exit(1)
+		return fmt.Errorf("failed to make HTTP request: %w", err)
 	}
 
 	fmt.Printf("Response: %s\n", res.GetResponse())
-}
-
-func doRequest(requestURL string) (Response, error) {
-
-	if _, err := url.ParseRequestURI(requestURL); err != nil {
-		fmt.Printf("URL is in invalid format: %s\n", err)
-		os.Exit(1)
-	}
-
-	response, err := http.Get(requestURL)
-
-	if err != nil {
-		return nil, fmt.Errorf("get error: %s", err)
-	}
-
-	defer response.Body.Close()
-
-	body, err := io.ReadAll(response.Body)
-
-	if err != nil {
-		return nil, fmt.Errorf("ReadAll error: %s", err)
-	}
-
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("invalid output (http code: %d): %s", response.StatusCode, string(body))
-	}
-
-	var page Page
-
-	err = json.Unmarshal(body, &page)
-	if err != nil {
-		return nil, fmt.Errorf("unmarshal error: %s", err)
-	}
-
-	switch page.Name {
-	// curl 'http://localhost:8080/words?input=word1'
-	// Raw return example: {"page":"words","input":"word3","words":["word1","word2","word2","word3","word3","word3","word3"]}
-	case "words":
-		var words Words
-		err = json.Unmarshal(body, &words)
-		if err != nil {
-			return nil, fmt.Errorf("unmarshal error for words: %s", err)
-		}
-
-		return words, nil
-
-	// curl 'http://localhost:8080/occurrence'
-	// Raw return example: {"page":"occurrence","words":{"word1":1,"word2":2,"word3":3}}
-	case "occurrence":
-		var occurrence Occurrence
-		err = json.Unmarshal(body, &occurrence)
-		if err != nil {
-			return nil, fmt.Errorf("unmarshal error for occurrence: %s", err)
-		}
-
-		return occurrence, nil
-	}
-
-	return nil, nil
+	return nil
 }
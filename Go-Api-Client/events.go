@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultEventsURL = "http://localhost:8080/events"
+
+// errNoReconnect stops the reconnecting: the server answered with an error status,
+// or with 204 No Content to tell the client to stop
+var errNoReconnect = errors.New("server doesn't want a reconnect")
+
+// event is a server-sent event
+type event struct {
+	id   string
+	name string
+	data string
+}
+
+// eventStream remembers what's needed to reconnect: the id of the last event and the
+// retry delay sent by the server
+type eventStream struct {
+	url         string
+	lastEventID string
+	retry       time.Duration
+}
+
+// watchEvents prints the occurrence events of the test-server until ctx is done. When
+// the connection drops it reconnects after the retry delay, sending the id of the last
+// event in the Last-Event-ID header, so the server sends the events missed in between.
+func watchEvents(ctx context.Context, url string, out io.Writer) error {
+	if url == "" {
+		url = defaultEventsURL
+	}
+	stream := &eventStream{url: url, retry: 3 * time.Second}
+	for {
+		err := stream.read(ctx, func(e event) error {
+			return printEvent(out, e)
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, errNoReconnect) {
+			return err
+		}
+		if err == nil {
+			err = io.EOF
+		}
+		fmt.Fprintf(out, "Disconnected (%s), reconnecting in %s\n", err, stream.retry)
+		select {
+		case <-time.After(stream.retry):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// read connects once and calls fn for every event, until the stream ends
+func (s *eventStream) read(ctx context.Context, fn func(e event) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errNoReconnect, err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: HTTP %d", errNoReconnect, res.StatusCode)
+	}
+
+	// the lines of an event end with an empty line, the fields are "name: value",
+	// and lines starting with a colon are comments
+	var current event
+	var data []string
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				current.data = strings.Join(data, "\n")
+				if err := fn(current); err != nil {
+					return fmt.Errorf("%w: %s", errNoReconnect, err)
+				}
+				if current.id != "" {
+					s.lastEventID = current.id
+				}
+			}
+			current, data = event{}, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			current.id = value
+		case "event":
+			current.name = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// printEvent prints a snapshot of all the counts, or the new count of a word
+func printEvent(out io.Writer, e event) error {
+	switch e.name {
+	case "snapshot":
+		var snapshot struct {
+			Words map[string]int `json:"words"`
+		}
+		if err := json.Unmarshal([]byte(e.data), &snapshot); err != nil {
+			return fmt.Errorf("can't decode snapshot %s: %w", e.id, err)
+		}
+		words := make([]string, 0, len(snapshot.Words))
+		for word := range snapshot.Words {
+			words = append(words, word)
+		}
+		sort.Strings(words)
+		fmt.Fprintf(out, "Snapshot %s: %d words\n", e.id, len(words))
+		for _, word := range words {
+			fmt.Fprintf(out, "  %s: %d\n", word, snapshot.Words[word])
+		}
+	case "occurrence":
+		var update struct {
+			Word  string `json:"word"`
+			Count int    `json:"count"`
+		}
+		if err := json.Unmarshal([]byte(e.data), &update); err != nil {
+			return fmt.Errorf("can't decode event %s: %w", e.id, err)
+		}
+		fmt.Fprintf(out, "Event %s: %s: %d\n", e.id, update.Word, update.Count)
+	}
+	return nil
+}
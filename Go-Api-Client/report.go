@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"wordsclient"
+)
+
+// WordReport joins a word of the words page with its count of the occurrence page
+type WordReport struct {
+	Word       string
+	Returned   int // how often /words returned the word
+	Occurrence int // the count of /occurrence, 0 if it doesn't know the word
+}
+
+// Report is the combined result of the words and occurrence pages
+type Report struct {
+	Words []WordReport
+}
+
+// result is sent on the channel by the goroutine fetching a page
+type result struct {
+	words      *wordsclient.Words
+	occurrence *wordsclient.Occurrence
+	err        error
+}
+
+// fetchReport fetches /words and /occurrence concurrently and merges them into a Report
+func fetchReport(ctx context.Context, client *wordsclient.Client) (Report, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	// stops the other request when one fails
+	defer cancel()
+
+	results := make(chan result, 2)
+	go func() {
+		words, err := client.Words(ctx, "")
+		results <- result{words: &words, err: err}
+	}()
+	go func() {
+		occurrence, err := client.Occurrence(ctx)
+		results <- result{occurrence: &occurrence, err: err}
+	}()
+
+	var (
+		words      wordsclient.Words
+		occurrence wordsclient.Occurrence
+	)
+	for i := 0; i < 2; i++ {
+		res := <-results
+		switch {
+		case res.err != nil:
+			return Report{}, res.err
+		case res.words != nil:
+			words = *res.words
+		case res.occurrence != nil:
+			occurrence = *res.occurrence
+		}
+	}
+	return mergeReport(words, occurrence), nil
+}
+
+// mergeReport joins the words with the occurrence counts. Words only one of the
+// pages knows are part of the report as well, with a count of 0 for the other page.
+func mergeReport(words wordsclient.Words, occurrence wordsclient.Occurrence) Report {
+	merged := map[string]*WordReport{}
+	get := func(word string) *WordReport {
+		if merged[word] == nil {
+			merged[word] = &WordReport{Word: word}
+		}
+		return merged[word]
+	}
+	for _, word := range words.Words {
+		get(word).Returned++
+	}
+	for word, count := range occurrence.Words {
+		get(word).Occurrence = count
+	}
+
+	report := Report{Words: make([]WordReport, 0, len(merged))}
+	for _, wordReport := range merged {
+		report.Words = append(report.Words, *wordReport)
+	}
+	sort.Slice(report.Words, func(i, j int) bool {
+		return report.Words[i].Word < report.Words[j].Word
+	})
+	return report
+}
+
+// printReport prints a table of the words, marking the words the pages disagree on
+func printReport(w io.Writer, report Report) {
+	fmt.Fprintf(w, "%-20s %8s %10s\n", "WORD", "RETURNED", "OCCURRENCE")
+	for _, word := range report.Words {
+		mismatch := ""
+		if word.Returned != word.Occurrence {
+			mismatch = " *"
+		}
+		fmt.Fprintf(w, "%-20s %8d %10d%s\n", word.Word, word.Returned, word.Occurrence, mismatch)
+	}
+	fmt.Fprintf(w, "%d words\n", len(report.Words))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const defaultWebSocketURL = "ws://localhost:8080/ws/words"
+
+// wsMessage is a word, the acknowledgement of a new interval or an error, sent by /ws/words
+type wsMessage struct {
+	Word     string `json:"word"`
+	Interval string `json:"interval"`
+	Error    string `json:"error"`
+}
+
+// watchWords prints the words pushed by the test-server over a WebSocket until ctx is
+// done. Every line read from in, like "200ms", is sent to the server to change the
+// interval between the words. Pings are answered by gorilla/websocket while reading.
+func watchWords(ctx context.Context, url string, in io.Reader, out io.Writer) error {
+	if url == "" {
+		url = defaultWebSocketURL
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return fmt.Errorf("can't connect to %s: %w", url, err)
+	}
+	defer conn.Close()
+
+	// one goroutine writes the commands, the loop below reads the messages
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			command := map[string]string{"interval": scanner.Text()}
+			if err := conn.WriteJSON(command); err != nil {
+				return
+			}
+		}
+	}()
+
+	// close the connection properly on Ctrl-C, the server answers with a close
+	// message which ends the read loop
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+			return
+		}
+		message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+		select {
+		case <-time.After(time.Second):
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) || ctx.Err() != nil {
+				return nil
+			}
+			if websocket.IsCloseError(err, websocket.CloseGoingAway) {
+				fmt.Fprintf(out, "Server closed the connection: %s\n", err)
+				return nil
+			}
+			return fmt.Errorf("connection closed: %w", err)
+		}
+		var message wsMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			return fmt.Errorf("can't decode message %q: %w", data, err)
+		}
+		switch {
+		case message.Error != "":
+			fmt.Fprintf(out, "Error: %s\n", message.Error)
+		case message.Interval != "":
+			fmt.Fprintf(out, "Interval: %s\n", message.Interval)
+		default:
+			fmt.Fprintf(out, "Word: %s\n", message.Word)
+		}
+	}
+}
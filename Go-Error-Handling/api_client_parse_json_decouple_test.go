@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"wordsclient"
+)
+
+// hugeBodySize is larger than any buffer of the client, so the body is read in many chunks
+const hugeBodySize = 8 << 20
+
+// newTestServer serves a page for every branch of the request and parse path
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/words", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"page":"words","input":"","words":["one","two"]}`)
+	})
+	mux.HandleFunc("/assignment1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"page":"assignment1","words":["one"],"percentages":{"one":0.5}}`)
+	})
+	mux.HandleFunc("/invalid-json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"page":"words","words":[`)
+	})
+	mux.HandleFunc("/wrong-page", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"page":"unknown"}`)
+	})
+	mux.HandleFunc("/wrong-type", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"page":"words","words":"one"}`)
+	})
+	mux.HandleFunc("/not-found", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "page not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/unavailable", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "try again later", http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		words := strings.Repeat(`"word",`, hugeBodySize/len(`"word",`))
+		fmt.Fprintf(w, `{"page":"words","words":[%s"last"]}`, words)
+	})
+	mux.HandleFunc("/huge-text", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), hugeBodySize))
+	})
+	mux.HandleFunc("/huge-error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(bytes.Repeat([]byte("x"), hugeBodySize))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+			fmt.Fprint(w, `{"page":"words","words":[]}`)
+		case <-r.Context().Done():
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// useTestClient replaces the client of getHTTPJsonMap with one that has a short
+// timeout and doesn't wait between retries
+func useTestClient(t *testing.T, timeout time.Duration) {
+	previous := client
+	client = wordsclient.New(wordsclient.Options{
+		HTTPClient: &http.Client{Timeout: timeout},
+		Retry:      wordsclient.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	})
+	t.Cleanup(func() { client = previous })
+}
+
+func TestGetHTTPJsonMap(t *testing.T) {
+	server := newTestServer(t)
+	useTestClient(t, time.Second)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/words", "Response: Words: one, two\n"},
+		{"/assignment1", "Response: Words: one, Percentages: one: 0.50\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := getHTTPJsonMap(&out, []string{"api-client", server.URL + test.path}); err != nil {
+			t.Errorf("%s: getHTTPJsonMap error: %s", test.path, err)
+			continue
+		}
+		if out.String() != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.path, test.expected, out.String())
+		}
+	}
+}
+
+func TestGetHTTPJsonMapUsage(t *testing.T) {
+	err := getHTTPJsonMap(&bytes.Buffer{}, []string{"api-client"})
+	if !errors.Is(err, errUsage) {
+		t.Errorf("Expected errUsage, got %v", err)
+	}
+}
+
+func TestGetHTTPJsonMapInvalidURL(t *testing.T) {
+	useTestClient(t, time.Second)
+
+	err := getHTTPJsonMap(&bytes.Buffer{}, []string{"api-client", "words"})
+	if !errors.Is(err, wordsclient.ErrInvalidURL) || !errors.Is(err, wordsclient.ErrValidation) {
+		t.Errorf("Expected ErrInvalidURL, got %v", err)
+	}
+}
+
+func TestGetHTTPJsonMapInvalidJSON(t *testing.T) {
+	server := newTestServer(t)
+	useTestClient(t, time.Second)
+
+	err := getHTTPJsonMap(&bytes.Buffer{}, []string{"api-client", server.URL + "/invalid-json"})
+	if !errors.Is(err, wordsclient.ErrDecode) {
+		t.Fatalf("Expected ErrDecode, got %v", err)
+	}
+	var reqErr wordsclient.RequestError
+	if !errors.As(err, &reqErr) || reqErr.HTTPCode != 200 || reqErr.Body != `{"page":"words","words":[` {
+		t.Errorf("Expected RequestError with the body, got %#v", err)
+	}
+}
+
+func TestGetHTTPJsonMapWrongType(t *testing.T) {
+	server := newTestServer(t)
+	useTestClient(t, time.Second)
+
+	err := getHTTPJsonMap(&bytes.Buffer{}, []string{"api-client", server.URL + "/wrong-type"})
+	if !errors.Is(err, wordsclient.ErrDecode) || errors.Is(err, wordsclient.ErrUnknownPage) {
+		t.Errorf("Expected ErrDecode but not ErrUnknownPage, got %v", err)
+	}
+}
+
+func TestGetHTTPJsonMapWrongPage(t *testing.T) {
+	server := newTestServer(t)
+	useTestClient(t, time.Second)
+
+	err := getHTTPJsonMap(&bytes.Buffer{}, []string{"api-client", server.URL + "/wrong-page"})
+	var unknownPage wordsclient.UnknownPageError
+	if !errors.As(err, &unknownPage) || unknownPage.Page != "unknown" {
+		t.Fatalf("Expected UnknownPageError for page unknown, got %#v", err)
+	}
+	if !errors.Is(err, wordsclient.ErrUnknownPage) || !errors.Is(err, wordsclient.ErrDecode) {
+		t.Errorf("Expected ErrUnknownPage and ErrDecode, got %v", err)
+	}
+}
+
+func TestGetHTTPJsonMapNon200(t *testing.T) {
+	server := newTestServer(t)
+	useTestClient(t, time.Second)
+
+	err := getHTTPJsonMap(&bytes.Buffer{}, []string{"api-client", server.URL + "/not-found"})
+	var reqErr wordsclient.RequestError
+	if !errors.As(err, &reqErr) || reqErr.HTTPCode != 404 || reqErr.Body != "page not found\n" {
+		t.Errorf("Expected RequestError with code 404 and the body, got %#v", err)
+	}
+	if !errors.Is(err, wordsclient.ErrHTTPStatus) {
+		t.Errorf("Expected ErrHTTPStatus, got %v", err)
+	}
+	var retryErr wordsclient.RetryError
+	if errors.As(err, &retryErr) {
+		t.Errorf("Expected a 404 not to be retried, got %s", err)
+	}
+}
+
+func TestGetHTTPJsonMapUnavailable(t *testing.T) {
+	server := newTestServer(t)
+	useTestClient(t, time.Second)
+
+	err := getHTTPJsonMap(&bytes.Buffer{}, []string{"api-client", server.URL + "/unavailable"})
+	var retryErr wordsclient.RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 3 {
+		t.Fatalf("Expected a RetryError with 3 attempts, got %v", err)
+	}
+	var reqErr wordsclient.RequestError
+	if !errors.As(err, &reqErr) || reqErr.HTTPCode != 503 || !errors.Is(err, wordsclient.ErrHTTPStatus) {
+		t.Errorf("Expected the last attempt to be a 503 RequestError, got %#v", err)
+	}
+}
+
+func TestGetHTTPJsonMapHugeBody(t *testing.T) {
+	server := newTestServer(t)
+	useTestClient(t, 10*time.Second)
+
+	var out bytes.Buffer
+	if err := getHTTPJsonMap(&out, []string{"api-client", server.URL + "/huge"}); err != nil {
+		t.Fatalf("getHTTPJsonMap error: %s", err)
+	}
+	if !strings.HasSuffix(out.String(), "word, last\n") {
+		t.Errorf("Expected the whole body to be parsed, got %d bytes of output", out.Len())
+	}
+
+	err := getHTTPJsonMap(&bytes.Buffer{}, []string{"api-client", server.URL + "/huge-text"})
+	var reqErr wordsclient.RequestError
+	if !errors.As(err, &reqErr) || !errors.Is(err, wordsclient.ErrDecode) {
+		t.Fatalf("Expected a decode RequestError, got %v", err)
+	}
+	if len(reqErr.Body) != wordsclient.MaxErrorBody {
+		t.Errorf("Expected the body of the error to be cut at %d bytes, got %d", wordsclient.MaxErrorBody, len(reqErr.Body))
+	}
+
+	err = getHTTPJsonMap(&bytes.Buffer{}, []string{"api-client", server.URL + "/huge-error"})
+	if !errors.As(err, &reqErr) || reqErr.HTTPCode != http.StatusInternalServerError {
+		t.Fatalf("Expected a 500 RequestError, got %v", err)
+	}
+	if len(reqErr.Body) != wordsclient.MaxErrorBody {
+		t.Errorf("Expected the body of the error to be cut at %d bytes, got %d", wordsclient.MaxErrorBody, len(reqErr.Body))
+	}
+}
+
+func TestGetHTTPJsonMapTimeout(t *testing.T) {
+	server := newTestServer(t)
+	useTestClient(t, 50*time.Millisecond)
+
+	start := time.Now()
+	err := getHTTPJsonMap(&bytes.Buffer{}, []string{"api-client", server.URL + "/slow"})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the client to give up after the timeout, took %s", elapsed)
+	}
+	if !errors.Is(err, wordsclient.ErrNetwork) {
+		t.Fatalf("Expected ErrNetwork, got %v", err)
+	}
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || !urlErr.Timeout() {
+		t.Errorf("Expected a *url.Error timeout, got %#v", err)
+	}
+	var retryErr wordsclient.RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 3 {
+		t.Errorf("Expected the timeouts to be retried 3 times, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"wordsclient"
+)
+
+// Assignment1 is the response of the assignment1 page of the test-server.
+// Registering it is all it takes for wordsclient to parse it.
+type Assignment1 struct {
+	Words       []string           `json:"words"`
+	Percentages map[string]float64 `json:"percentages"`
+}
+
+func (a Assignment1) GetResponse() string {
+	percentages := []string{}
+	for word, percentage := range a.Percentages {
+		percentages = append(percentages, fmt.Sprintf("%s: %.2f", word, percentage))
+	}
+	sort.Strings(percentages)
+	return fmt.Sprintf("Words: %s, Percentages: %s", strings.Join(a.Words, ", "), strings.Join(percentages, ", "))
+}
+
+func init() {
+	wordsclient.RegisterPage("assignment1", func() wordsclient.Response { return &Assignment1{} })
+}
@@ -1,5 +1,67 @@
 package main
 
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"wordsclient"
+)
+
+// errUsage is returned when the url argument is missing
+var errUsage = errors.New("usage: ./api-client-parse-json <url>")
+
+// Exit codes per error category
+const (
+	exitError       = 1
+	exitUnknownPage = 2
+	exitValidation  = 3
+	exitNetwork     = 4
+	exitHTTPStatus  = 5
+	exitDecode      = 6
+)
+
 func main() {
-	getHTTPJsonMap()
+	err := getHTTPJsonMap(os.Stdout, os.Args)
+	if err == nil {
+		return
+	}
+
+	// errors.As finds the typed errors with their details anywhere in the chain,
+	// errors.Is the sentinel category of the error
+	var (
+		reqErr      wordsclient.RequestError
+		unknownPage wordsclient.UnknownPageError
+		retryErr    wordsclient.RetryError
+	)
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Println(err)
+		os.Exit(exitError)
+	case errors.As(err, &unknownPage):
+		fmt.Printf("Error: unknown page %q (Body: %s)\n", unknownPage.Page, unknownPage.Body)
+		os.Exit(exitUnknownPage)
+	case errors.As(err, &retryErr):
+		// print the attempt history, one attempt per line
+		fmt.Printf("Error: %s failed %d times\n", os.Args[1], len(retryErr.Attempts))
+		for i, attempt := range retryErr.Attempts {
+			fmt.Printf("  attempt %d: %s\n", i+1, attempt)
+		}
+	case errors.As(err, &reqErr):
+		fmt.Printf("Error: %s (HTTP Code: %d, Body: %s)\n", err, reqErr.HTTPCode, reqErr.Body)
+	default:
+		fmt.Printf("Error: %s\n", err)
+	}
+
+	switch {
+	case errors.Is(err, wordsclient.ErrValidation):
+		os.Exit(exitValidation)
+	case errors.Is(err, wordsclient.ErrNetwork):
+		os.Exit(exitNetwork)
+	case errors.Is(err, wordsclient.ErrHTTPStatus):
+		os.Exit(exitHTTPStatus)
+	case errors.Is(err, wordsclient.ErrDecode):
+		os.Exit(exitDecode)
+	}
+	os.Exit(exitError)
 }
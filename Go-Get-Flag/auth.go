@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"wordsclient"
+)
+
+// tokenCache keeps the login tokens on disk, per base url, so the client doesn't
+// have to log in on every run
+type tokenCache struct {
+	path string
+}
+
+func newTokenCache() tokenCache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return tokenCache{path: filepath.Join(dir, "words-client", "tokens.json")}
+}
+
+func (t tokenCache) load() map[string]string {
+	tokens := map[string]string{}
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return tokens
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		// a corrupt cache only means we have to log in again
+		return map[string]string{}
+	}
+	return tokens
+}
+
+func (t tokenCache) get(baseURL string) string {
+	return t.load()[baseURL]
+}
+
+func (t tokenCache) set(baseURL, token string) error {
+	tokens := t.load()
+	if token == "" {
+		delete(tokens, baseURL)
+	} else {
+		tokens[baseURL] = token
+	}
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return fmt.Errorf("marshal error: %s", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o700); err != nil {
+		return fmt.Errorf("token cache error: %s", err)
+	}
+	// the tokens are credentials: only readable by the user
+	if err := os.WriteFile(t.path, data, 0o600); err != nil {
+		return fmt.Errorf("token cache error: %s", err)
+	}
+	return nil
+}
+
+// login gets a new token with the password and caches it
+func login(ctx context.Context, options globalOptions) (string, error) {
+	client := wordsclient.New(wordsclient.Options{
+		BaseURL:    options.baseURL,
+		HTTPClient: &http.Client{Transport: baseTransport(options), Timeout: options.timeout},
+	})
+	token, err := client.Login(ctx, options.password)
+	var reqErr wordsclient.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPCode >= 400 && reqErr.HTTPCode < 500 {
+		return "", authError{err: err}
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := newTokenCache().set(options.baseURL, token); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: token not cached: %s\n", err)
+	}
+	return token, nil
+}
+
+// newClient returns the client for the api. With a password, requests carry
+// a bearer token: the cached one, or a new one when fresh is set or none is cached.
+func newClient(ctx context.Context, options globalOptions, fresh bool) (*wordsclient.Client, error) {
+	httpClient := &http.Client{Transport: baseTransport(options), Timeout: options.timeout}
+	if options.password == "" {
+		return wordsclient.New(wordsclient.Options{BaseURL: options.baseURL, HTTPClient: httpClient}), nil
+	}
+
+	token := ""
+	if !fresh {
+		token = newTokenCache().get(options.baseURL)
+	}
+	if token == "" {
+		var err error
+		if token, err = login(ctx, options); err != nil {
+			return nil, err
+		}
+	}
+	httpClient.Transport = MyJWTTransport{
+		transport: httpClient.Transport,
+		token:     token,
+	}
+	return wordsclient.New(wordsclient.Options{BaseURL: options.baseURL, HTTPClient: httpClient}), nil
+}
+
+// isAuthError reports whether the server rejected the request for lack of a valid token.
+// The test-server replies 403 for a missing or invalid token.
+func isAuthError(err error) bool {
+	var reqErr wordsclient.RequestError
+	return errors.As(err, &reqErr) && (reqErr.HTTPCode == http.StatusUnauthorized || reqErr.HTTPCode == http.StatusForbidden)
+}
+
+// doAuthenticatedRequest sends the request with authentication: when the cached token is
+// rejected (it expired, or the test-server restarted with a new secret) it logs in again once
+func doAuthenticatedRequest(ctx context.Context, options globalOptions, apiReq wordsclient.Request) ([]byte, error) {
+	client, err := newClient(ctx, options, false)
+	if err != nil {
+		return nil, err
+	}
+	body, err := client.Do(ctx, apiReq)
+	if !isAuthError(err) {
+		return body, err
+	}
+
+	if options.password == "" {
+		var reqErr wordsclient.RequestError
+		errors.As(err, &reqErr)
+		reqErr.Err = fmt.Errorf("authentication required, log in with -password: %w", reqErr.Err)
+		return nil, reqErr
+	}
+	if client, err = newClient(ctx, options, true); err != nil {
+		return nil, err
+	}
+	return client.Do(ctx, apiReq)
+}
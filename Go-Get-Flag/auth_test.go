@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"wordsclient"
+)
+
+// authServer is the test-server login: /login hands out a new token for the
+// password, and the other paths reply 403 without the last token
+type authServer struct {
+	mu     sync.Mutex
+	token  string
+	logins int
+}
+
+func (a *authServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if r.URL.Path == "/login" {
+		var login wordsclient.LoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil || login.Password != "secret" {
+			http.Error(w, `{"error":"invalid password"}`, http.StatusUnauthorized)
+			return
+		}
+		a.logins++
+		a.token = fmt.Sprintf("token-%d", a.logins)
+		fmt.Fprintf(w, `{"token":%q}`, a.token)
+		return
+	}
+	if a.token == "" || r.Header.Get("Authorization") != "Bearer "+a.token {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	io.WriteString(w, `{"page":"words","input":"","words":["a"]}`)
+}
+
+// expire makes the server forget the token, like a restarted test-server
+func (a *authServer) expire() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = "expired"
+}
+
+func TestDoAuthenticatedRequest(t *testing.T) {
+	// the token cache is in the user cache dir
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	handler := &authServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	options := globalOptions{baseURL: server.URL, password: "secret", timeout: 5 * time.Second}
+	apiReq := wordsclient.Request{Method: http.MethodGet, URL: server.URL + "/words"}
+	steps := []struct {
+		name   string
+		change func()
+		logins int
+	}{
+		{name: "first login", change: func() {}, logins: 1},
+		{name: "cached token", change: func() {}, logins: 1},
+		{name: "rejected token", change: handler.expire, logins: 2},
+	}
+	for _, step := range steps {
+		step.change()
+		body, err := doAuthenticatedRequest(context.Background(), options, apiReq)
+		if err != nil {
+			t.Fatalf("%s: doAuthenticatedRequest error: %s", step.name, err)
+		}
+		if !strings.Contains(string(body), `"words":["a"]`) || handler.logins != step.logins {
+			t.Errorf("%s: expected the words after %d logins, got %s after %d", step.name, step.logins, body, handler.logins)
+		}
+	}
+	if token := newTokenCache().get(server.URL); token != "token-2" {
+		t.Errorf("Expected the new token in the cache, got %q", token)
+	}
+
+	// without a password the 403 says how to log in
+	options.password = ""
+	_, err := doAuthenticatedRequest(context.Background(), options, apiReq)
+	if err == nil || !strings.Contains(err.Error(), "authentication required, log in with -password") || exitCode(err) != exitAuth {
+		t.Errorf("Expected an authentication error, got %v", err)
+	}
+
+	// a wrong password is an auth error, not an HTTP error
+	options.password = "wrong"
+	handler.expire()
+	_, err = doAuthenticatedRequest(context.Background(), options, apiReq)
+	if err == nil || !strings.Contains(err.Error(), "login failed") || exitCode(err) != exitAuth {
+		t.Errorf("Expected a rejected password, got %v", err)
+	}
+}
+
+func TestTokenCache(t *testing.T) {
+	cache := tokenCache{path: filepath.Join(t.TempDir(), "words-client", "tokens.json")}
+	if err := cache.set("http://a", "token-a"); err != nil {
+		t.Fatalf("set error: %s", err)
+	}
+	if err := cache.set("http://b", "token-b"); err != nil {
+		t.Fatalf("set error: %s", err)
+	}
+	if err := cache.set("http://a", ""); err != nil {
+		t.Fatalf("set error: %s", err)
+	}
+	if a, b := cache.get("http://a"), cache.get("http://b"); a != "" || b != "token-b" {
+		t.Errorf("Expected only the token of b, got %q and %q", a, b)
+	}
+}
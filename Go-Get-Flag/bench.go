@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"wordsclient"
+)
+
+// benchOptions are the -bench, -n and -c flags
+type benchOptions struct {
+	enabled     bool
+	requests    int
+	concurrency int
+}
+
+// benchResult is the outcome of one request of the benchmark
+type benchResult struct {
+	duration time.Duration
+	status   string // the HTTP status code, or the exit category of the error without a response
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// benchmark sends the request options.bench.requests times with options.bench.concurrency
+// workers, hey-style, and prints the latency percentiles, the throughput and the status codes to w
+func benchmark(ctx context.Context, w io.Writer, options globalOptions, apiReq wordsclient.Request) error {
+	// log in once, the benchmark measures the requests rather than the logins
+	client, err := newClient(ctx, options, false)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	jobs := make(chan struct{})
+	results := make(chan benchResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < options.bench.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				requestStart := time.Now()
+				_, err := client.Do(ctx, apiReq)
+				result := benchResult{duration: time.Since(requestStart), status: "200"}
+				var reqErr wordsclient.RequestError
+				switch {
+				case errors.As(err, &reqErr):
+					result.status = strconv.Itoa(reqErr.HTTPCode)
+				case err != nil:
+					result.status = exitCategories[exitCode(err)]
+				}
+				results <- result
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := 0; i < options.bench.requests; i++ {
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				// Ctrl+C stops the benchmark, the requests so far are reported
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var durations []time.Duration
+	statuses := map[string]int{}
+	for result := range results {
+		durations = append(durations, result.duration)
+		statuses[result.status]++
+	}
+	printBenchmark(w, options.bench, time.Since(start), durations, statuses)
+	return nil
+}
+
+// printBenchmark prints the summary of the benchmark
+func printBenchmark(w io.Writer, bench benchOptions, total time.Duration, durations []time.Duration, statuses map[string]int) {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var sum time.Duration
+	for _, d := range durations {
+		sum += d
+	}
+
+	fmt.Fprintf(w, "%d requests in %s with %d workers: %.1f requests/s\n",
+		len(durations), total.Round(time.Millisecond), bench.concurrency, float64(len(durations))/total.Seconds())
+	if len(durations) == 0 {
+		return
+	}
+
+	round := func(d time.Duration) time.Duration { return d.Round(10 * time.Microsecond) }
+	fmt.Fprintln(w, "\nLatency:")
+	fmt.Fprintf(w, "  min   %s\n", round(durations[0]))
+	fmt.Fprintf(w, "  mean  %s\n", round(sum/time.Duration(len(durations))))
+	for _, p := range []float64{50, 90, 95, 99} {
+		fmt.Fprintf(w, "  p%-4g %s\n", p, round(percentile(durations, p)))
+	}
+	fmt.Fprintf(w, "  max   %s\n", round(durations[len(durations)-1]))
+
+	codes := make([]string, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	fmt.Fprintln(w, "\nStatus codes:")
+	for _, code := range codes {
+		// errors without a response are red, like the 4xx and 5xx
+		label := stdoutColors.red(code)
+		if status, err := strconv.Atoi(code); err == nil {
+			label = stdoutColors.status(status, code)
+		}
+		fmt.Fprintf(w, "  %s%s%d\n", label, spaces(8-len(code)), statuses[code])
+	}
+}
+
+// spaces pads the colored labels, whose escape codes would count in a %-8s
+func spaces(n int) string {
+	if n < 1 {
+		n = 1
+	}
+	return fmt.Sprintf("%*s", n, "")
+}
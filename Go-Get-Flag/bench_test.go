@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wordsclient"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{p: 0, want: 1},
+		{p: 10, want: 1},
+		{p: 50, want: 5},
+		{p: 90, want: 9},
+		{p: 95, want: 10},
+		{p: 100, want: 10},
+	}
+	for _, test := range tests {
+		if got := percentile(sorted, test.p); got != test.want {
+			t.Errorf("p%g: expected %d, got %d", test.p, test.want, got)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("Expected 0 without durations, got %d", got)
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	// every third request fails with a 500
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1)%3 == 0 {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"page":"words","input":"","words":["a"]}`)
+	}))
+	defer server.Close()
+
+	options := globalOptions{
+		baseURL: server.URL,
+		timeout: 5 * time.Second,
+		bench:   benchOptions{enabled: true, requests: 9, concurrency: 3},
+	}
+	var out bytes.Buffer
+	if err := benchmark(context.Background(), &out, options, wordsclient.Request{Method: http.MethodGet, URL: server.URL + "/words"}); err != nil {
+		t.Fatalf("benchmark error: %s", err)
+	}
+	if requests.Load() != 9 {
+		t.Errorf("Expected 9 requests, got %d", requests.Load())
+	}
+	for _, s := range []string{"9 requests in ", " with 3 workers: ", "\nLatency:\n  min   ", "  p99  ", "\nStatus codes:\n  200     6\n  500     3\n"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("Expected %q in\n%s", s, out.String())
+		}
+	}
+}
+
+func TestBenchmarkErrors(t *testing.T) {
+	// nothing listens on the port of a closed server
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	options := globalOptions{
+		baseURL: server.URL,
+		timeout: time.Second,
+		bench:   benchOptions{enabled: true, requests: 2, concurrency: 1},
+	}
+	var out bytes.Buffer
+	if err := benchmark(context.Background(), &out, options, wordsclient.Request{Method: http.MethodGet, URL: server.URL + "/words"}); err != nil {
+		t.Fatalf("benchmark error: %s", err)
+	}
+	// the requests without a response are counted by their exit category
+	if expected := "  " + exitCategories[exitNetwork] + " "; !strings.Contains(out.String(), expected) || !strings.Contains(out.String(), "2 requests in ") {
+		t.Errorf("Expected 2 requests with %q in\n%s", expected, out.String())
+	}
+}
+
+func TestBenchmarkCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ctrl+C after the third request
+		if requests.Add(1) == 3 {
+			cancel()
+		}
+		fmt.Fprint(w, `{"page":"words","input":"","words":["a"]}`)
+	}))
+	defer server.Close()
+
+	options := globalOptions{
+		baseURL: server.URL,
+		timeout: 5 * time.Second,
+		bench:   benchOptions{enabled: true, requests: 1000, concurrency: 1},
+	}
+	var out bytes.Buffer
+	if err := benchmark(ctx, &out, options, wordsclient.Request{Method: http.MethodGet, URL: server.URL + "/words"}); err != nil {
+		t.Fatalf("benchmark error: %s", err)
+	}
+	// the requests so far are reported, a request sent with ctx done already fails
+	// without reaching the server
+	var reported int
+	fmt.Sscanf(out.String(), "%d requests in ", &reported)
+	if got := requests.Load(); got > 4 || reported < 3 || reported > 4 {
+		t.Errorf("Expected the benchmark to stop after 3 requests, got %d\n%s", got, out.String())
+	}
+}
+
+func TestPrintBenchmarkEmpty(t *testing.T) {
+	var out bytes.Buffer
+	printBenchmark(&out, benchOptions{concurrency: 2}, time.Second, nil, map[string]int{})
+	if out.String() != "0 requests in 1s with 2 workers: 0.0 requests/s\n" {
+		t.Errorf("Expected only the summary, got %q", out.String())
+	}
+}
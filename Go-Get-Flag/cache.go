@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheOptions are the -cache-dir and -no-cache flags
+type cacheOptions struct {
+	dir string // the cache is off without a directory
+	// bypass ignores the cached responses, but still stores the new ones
+	bypass bool
+}
+
+// cacheEntry is a cached response, stored as json in the cache directory
+type cacheEntry struct {
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	Stored time.Time   `json:"stored"`
+	// Vary has the request headers named by the Vary header of the response, with
+	// their values: the entry is only used for requests with the same values
+	Vary map[string]string `json:"vary,omitempty"`
+}
+
+// cacheTransport is a private HTTP cache of GET responses on disk. A response is
+// served from the cache while it is fresh (Cache-Control max-age), and revalidated
+// with If-None-Match when it has an ETag: a 304 Not Modified reuses the cached body.
+// The responses are cached per Authorization header, so a token never gets the
+// response of another one.
+type cacheTransport struct {
+	transport http.RoundTripper
+	options   cacheOptions
+	log       io.Writer // cache hits are printed here with -v, nil otherwise
+	now       func() time.Time
+}
+
+// cacheControl parses the directives of a Cache-Control header, e.g. "max-age=60, no-store"
+func cacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+			}
+		}
+	}
+	return directives
+}
+
+// maxAge returns how long a response is fresh: 0 when it must be revalidated every time
+func maxAge(header http.Header) time.Duration {
+	directives := cacheControl(header)
+	if _, ok := directives["no-cache"]; ok {
+		return 0
+	}
+	seconds, err := strconv.Atoi(directives["max-age"])
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// isCacheable reports whether a response can be stored: a 200 that allows it, and
+// that is either fresh for a while or can be revalidated
+func isCacheable(response *http.Response) bool {
+	if response.StatusCode != http.StatusOK {
+		return false
+	}
+	if _, ok := cacheControl(response.Header)["no-store"]; ok {
+		return false
+	}
+	if _, ok := varyHeaders(response.Header)["*"]; ok {
+		return false
+	}
+	return maxAge(response.Header) > 0 || response.Header.Get("ETag") != ""
+}
+
+// cacheKey identifies the cached response of a request: the url and the credentials
+func cacheKey(req *http.Request) string {
+	return req.URL.String() + "\n" + req.Header.Get("Authorization")
+}
+
+// path is the file of the cached response of a key. The key is hashed, so the
+// credentials aren't written in the file name.
+func (c cacheTransport) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.options.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// varyHeaders returns the canonical names in the Vary header of a response
+func varyHeaders(header http.Header) map[string]bool {
+	names := map[string]bool{}
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names[http.CanonicalHeaderKey(name)] = true
+			}
+		}
+	}
+	return names
+}
+
+// varyValues returns the values of the request headers named by the Vary header
+func varyValues(req *http.Request, header http.Header) map[string]string {
+	values := map[string]string{}
+	for name := range varyHeaders(header) {
+		values[name] = req.Header.Get(name)
+	}
+	return values
+}
+
+func (c cacheTransport) load(req *http.Request) (cacheEntry, bool) {
+	var entry cacheEntry
+	data, err := os.ReadFile(c.path(cacheKey(req)))
+	if err != nil {
+		return entry, false
+	}
+	// a corrupt entry, or a hash collision, is a cache miss
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != req.URL.String() {
+		return entry, false
+	}
+	// so is a response for other values of the Vary headers, like another Accept
+	for name, value := range varyValues(req, entry.Header) {
+		if entry.Vary[name] != value {
+			return entry, false
+		}
+	}
+	return entry, true
+}
+
+func (c cacheTransport) store(req *http.Request, entry cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = os.MkdirAll(c.options.dir, 0o700)
+	}
+	if err == nil {
+		err = os.WriteFile(c.path(cacheKey(req)), data, 0o600)
+	}
+	if err != nil {
+		// the request succeeded, so a cache that can't be written is only a warning
+		fmt.Fprintf(os.Stderr, "Warning: response not cached: %s\n", err)
+	}
+}
+
+// response turns the entry back into a response of the request
+func (e cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+func (c cacheTransport) logf(format string, a ...interface{}) {
+	if c.log != nil {
+		fmt.Fprintf(c.log, "* cache: "+format+"\n", a...)
+	}
+}
+
+func (c cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return c.transport.RoundTrip(req)
+	}
+	requestURL := req.URL.String()
+
+	entry, cached := c.load(req)
+	if cached && !c.options.bypass {
+		age := c.now().Sub(entry.Stored)
+		if age < maxAge(entry.Header) {
+			c.logf("fresh response of %s (age %s)", requestURL, age.Round(time.Second))
+			return entry.response(req), nil
+		}
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-None-Match", etag)
+		}
+	}
+
+	response, err := c.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusNotModified && cached && req.Header.Get("If-None-Match") != "" {
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+		// the 304 carries the new freshness of the cached response
+		for key, values := range response.Header {
+			entry.Header[key] = values
+		}
+		entry.Stored = c.now()
+		c.store(req, entry)
+		c.logf("%s not modified, using the cached response", requestURL)
+		return entry.response(req), nil
+	}
+
+	if !isCacheable(response) {
+		return response, nil
+	}
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	c.store(req, cacheEntry{
+		URL:    requestURL,
+		Status: response.StatusCode,
+		Header: response.Header,
+		Body:   body,
+		Stored: c.now(),
+		Vary:   varyValues(req, response.Header),
+	})
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	return response, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// cacheServer replies with the body and the headers, and a 304 to a request with
+// the ETag in If-None-Match. It counts the requests and the 304 replies.
+type cacheServer struct {
+	header       http.Header
+	body         string
+	requests     int
+	notModifieds int
+}
+
+func (c *cacheServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.requests++
+	for key, values := range c.header {
+		w.Header()[key] = values
+	}
+	if etag := c.header.Get("ETag"); etag != "" && r.Header.Get("If-None-Match") == etag {
+		c.notModifieds++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	io.WriteString(w, c.body)
+}
+
+func TestCacheTransport(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		// age is the time between the two requests
+		age time.Duration
+		// second changes the second request
+		second       func(req *http.Request)
+		requests     int
+		notModifieds int
+	}{
+		{
+			name:     "fresh",
+			header:   http.Header{"Cache-Control": {"max-age=60"}},
+			age:      30 * time.Second,
+			requests: 1,
+		},
+		{
+			name:     "stale without etag",
+			header:   http.Header{"Cache-Control": {"max-age=60"}},
+			age:      2 * time.Minute,
+			requests: 2,
+		},
+		{
+			name:         "stale with etag",
+			header:       http.Header{"Cache-Control": {"max-age=60"}, "Etag": {`"v1"`}},
+			age:          2 * time.Minute,
+			requests:     2,
+			notModifieds: 1,
+		},
+		{
+			name:         "no-cache",
+			header:       http.Header{"Cache-Control": {"no-cache"}, "Etag": {`"v1"`}},
+			requests:     2,
+			notModifieds: 1,
+		},
+		{
+			name:     "no-store",
+			header:   http.Header{"Cache-Control": {"no-store, max-age=60"}},
+			requests: 2,
+		},
+		{
+			name:     "other token",
+			header:   http.Header{"Cache-Control": {"max-age=60"}},
+			second:   func(req *http.Request) { req.Header.Set("Authorization", "Bearer other") },
+			requests: 2,
+		},
+		{
+			name:     "same vary header",
+			header:   http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept"}},
+			second:   func(req *http.Request) { req.Header.Set("Accept", "application/json") },
+			requests: 1,
+		},
+		{
+			name:     "other vary header",
+			header:   http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept"}},
+			second:   func(req *http.Request) { req.Header.Set("Accept", "text/plain") },
+			requests: 2,
+		},
+		{
+			name:     "vary star",
+			header:   http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}},
+			requests: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := &cacheServer{header: test.header, body: `{"page":"words","words":["a"]}`}
+			server := httptest.NewServer(handler)
+			t.Cleanup(server.Close)
+
+			now := time.Now()
+			cache := cacheTransport{
+				transport: http.DefaultTransport,
+				options:   cacheOptions{dir: t.TempDir()},
+				now:       func() time.Time { return now },
+			}
+			client := &http.Client{Transport: cache}
+			get := func(change func(req *http.Request)) string {
+				req, err := http.NewRequest(http.MethodGet, server.URL+"/words", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header.Set("Authorization", "Bearer token")
+				req.Header.Set("Accept", "application/json")
+				if change != nil {
+					change(req)
+				}
+				res, err := client.Do(req)
+				if err != nil {
+					t.Fatalf("Do error: %s", err)
+				}
+				defer res.Body.Close()
+				body, err := io.ReadAll(res.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.StatusCode != http.StatusOK {
+					t.Errorf("Expected status 200, got %d", res.StatusCode)
+				}
+				return string(body)
+			}
+
+			get(nil)
+			now = now.Add(test.age)
+			if body := get(test.second); body != handler.body {
+				t.Errorf("Expected body %s, got %s", handler.body, body)
+			}
+			if handler.requests != test.requests || handler.notModifieds != test.notModifieds {
+				t.Errorf("Expected %d requests and %d 304s, got %d and %d", test.requests, test.notModifieds, handler.requests, handler.notModifieds)
+			}
+		})
+	}
+}
+
+func TestCacheTransportBypass(t *testing.T) {
+	handler := &cacheServer{header: http.Header{"Cache-Control": {"max-age=60"}}, body: "words"}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	for _, bypass := range []bool{false, true, false} {
+		cache := cacheTransport{
+			transport: http.DefaultTransport,
+			options:   cacheOptions{dir: dir, bypass: bypass},
+			now:       time.Now,
+		}
+		res, err := (&http.Client{Transport: cache}).Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		res.Body.Close()
+	}
+	// the first response is cached, -no-cache requests it again and the third one
+	// is served from the cache
+	if handler.requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", handler.requests)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+)
+
+// ANSI escape codes of the colors
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+// palette colors text with ANSI escape codes, or returns it as it is when disabled
+type palette struct {
+	enabled bool
+}
+
+// stdoutColors and stderrColors are set up by setupColors, once the flags are parsed.
+// They are disabled until then.
+var stdoutColors, stderrColors palette
+
+// setupColors enables the colors of stdout and stderr when they are terminals,
+// unless -no-color is set, NO_COLOR is set (https://no-color.org) or TERM is dumb
+func setupColors(noColor bool) {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return
+	}
+	stdoutColors = palette{enabled: isTerminal(os.Stdout)}
+	stderrColors = palette{enabled: isTerminal(os.Stderr)}
+}
+
+// isTerminal reports whether f is a terminal rather than a file or a pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p palette) paint(color, s string) string {
+	if !p.enabled {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func (p palette) red(s string) string    { return p.paint(colorRed, s) }
+func (p palette) green(s string) string  { return p.paint(colorGreen, s) }
+func (p palette) yellow(s string) string { return p.paint(colorYellow, s) }
+
+// count highlights a word count
+func (p palette) count(s string) string {
+	return p.paint(colorBold+colorCyan, s)
+}
+
+// status colors s by the class of the status code: green 2xx, yellow 3xx, red 4xx and 5xx
+func (p palette) status(code int, s string) string {
+	switch {
+	case code >= 200 && code < 300:
+		return p.green(s)
+	case code >= 300 && code < 400:
+		return p.yellow(s)
+	case code >= 400:
+		return p.red(s)
+	}
+	return s
+}
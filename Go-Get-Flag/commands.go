@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"wordsclient"
+)
+
+// command is a subcommand of the client, with its own FlagSet
+type command struct {
+	description string
+	arguments   string // positional arguments, shown in the usage
+	// setup defines the flags of the command and returns the function running it
+	setup func(flagSet *flag.FlagSet) runFunc
+}
+
+// runFunc runs a command once its flags are parsed
+type runFunc func(ctx context.Context, options globalOptions, flagSet *flag.FlagSet) error
+
+var commands = map[string]command{
+	"words":      {description: "add a word and list all words", setup: wordsCommand},
+	"occurrence": {description: "count how often every word was added", setup: occurrenceCommand},
+	"login":      {description: "log in with -password, cache the token and print it", setup: loginCommand},
+	"get":        {description: "request an url (or a path of the base url) and parse the response", arguments: "<url>", setup: getCommand},
+	"fetch":      {description: "get many urls concurrently and print a summary", setup: fetchCommand},
+}
+
+// sortedCommands returns the command names in alphabetical order
+func sortedCommands() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [global flags] <command> [command flags]\n\nCommands:\n", os.Args[0])
+	for _, name := range sortedCommands() {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-12s %s\n", name, commands[name].description)
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "\nGlobal flags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(flag.CommandLine.Output(), "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
+}
+
+// newFlagSet creates the FlagSet of a subcommand, exiting on -h like the global flags
+func newFlagSet(name, arguments string) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(name, flag.ExitOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintf(flagSet.Output(), "Usage: %s [global flags] %s [flags] %s\n", os.Args[0], name, arguments)
+		flagSet.PrintDefaults()
+	}
+	return flagSet
+}
+
+// curl 'http://localhost:8080/words?input=word1'
+func wordsCommand(flagSet *flag.FlagSet) runFunc {
+	input := flagSet.String("input", "", "word to add before listing the words")
+
+	return func(ctx context.Context, options globalOptions, flagSet *flag.FlagSet) error {
+		requestURL := options.baseURL + "/words"
+		if *input != "" {
+			requestURL += "?" + url.Values{"input": {*input}}.Encode()
+		}
+		return getAndPrint(ctx, options, wordsclient.Request{Method: http.MethodGet, URL: requestURL})
+	}
+}
+
+// curl 'http://localhost:8080/occurrence'
+func occurrenceCommand(flagSet *flag.FlagSet) runFunc {
+	return func(ctx context.Context, options globalOptions, flagSet *flag.FlagSet) error {
+		return getAndPrint(ctx, options, wordsclient.Request{Method: http.MethodGet, URL: options.baseURL + "/occurrence"})
+	}
+}
+
+func loginCommand(flagSet *flag.FlagSet) runFunc {
+	return func(ctx context.Context, options globalOptions, flagSet *flag.FlagSet) error {
+		if options.password == "" {
+			return newUsageError("login needs a password: %s -password <password> login", os.Args[0])
+		}
+		token, err := login(ctx, options)
+		if err != nil {
+			return err
+		}
+		fmt.Println(token)
+		return nil
+	}
+}
+
+func getCommand(flagSet *flag.FlagSet) runFunc {
+	method := flagSet.String("X", "", "request method (GET, or POST when there is data)")
+	data := flagSet.String("data", "", "request body, sent as application/json unless -H sets the Content-Type")
+	dataFile := flagSet.String("data-file", "", "file with the request body (- for stdin)")
+
+	return func(ctx context.Context, options globalOptions, flagSet *flag.FlagSet) error {
+		if flagSet.NArg() != 1 {
+			flagSet.Usage()
+			os.Exit(exitUsage)
+		}
+		requestURL := flagSet.Arg(0)
+		if strings.HasPrefix(requestURL, "/") {
+			requestURL = options.baseURL + requestURL
+		}
+
+		apiReq := wordsclient.Request{Method: strings.ToUpper(*method), URL: requestURL}
+		switch {
+		case *data != "" && *dataFile != "":
+			return newUsageError("use either -data or -data-file")
+		case *data != "":
+			apiReq.Body = []byte(*data)
+		case *dataFile == "-":
+			body, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("stdin read error: %s", err)
+			}
+			apiReq.Body = body
+		case *dataFile != "":
+			body, err := os.ReadFile(*dataFile)
+			if err != nil {
+				return fmt.Errorf("data file error: %s", err)
+			}
+			apiReq.Body = body
+		}
+		if apiReq.Method == "" {
+			apiReq.Method = http.MethodGet
+			if apiReq.Body != nil {
+				apiReq.Method = http.MethodPost
+			}
+		}
+		return getAndPrint(ctx, options, apiReq)
+	}
+}
+
+// getAndPrint sends the request and prints the response in the output format,
+// keeps sending it with -watch, or benchmarks it with -bench
+func getAndPrint(ctx context.Context, options globalOptions, apiReq wordsclient.Request) error {
+	if options.watch.enabled {
+		return watch(ctx, os.Stdout, options, apiReq)
+	}
+	if options.bench.enabled {
+		return benchmark(ctx, os.Stdout, options, apiReq)
+	}
+	body, err := doAuthenticatedRequest(ctx, options, apiReq)
+	if err != nil {
+		return err
+	}
+	if options.save.enabled() {
+		name, err := saveResponse(options, apiReq.URL, body)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("saved %s to %s\n", apiReq.URL, name)
+		return nil
+	}
+	return printResponse(os.Stdout, options, body, stdoutColors)
+}
+
+// printResponse writes the body, or the -query result, to w in the output format.
+// Responses of other pages than words and occurrence are printed as they are.
+func printResponse(w io.Writer, options globalOptions, body []byte, colors palette) error {
+	if options.query != nil {
+		value, err := applyQuery(body, options.query)
+		if err != nil {
+			return err
+		}
+		return renderQueryResult(w, value, options.output)
+	}
+
+	res, err := wordsclient.ParseResponse(body)
+	if options.output == outputRaw || errors.Is(err, wordsclient.ErrUnknownPage) {
+		_, err := fmt.Fprintln(w, strings.TrimRight(string(body), "\n"))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return render(w, res, options.output, colors)
+}
+
+// printError prints err to stderr, so it doesn't mix with the output
+func printError(err error) {
+	var reqErr wordsclient.RequestError
+	if errors.As(err, &reqErr) {
+		fmt.Fprintf(os.Stderr, "%s %s (HTTP Code: %s, Body: %s)\n", stderrColors.red("Error:"), reqErr.Err, stderrColors.status(reqErr.HTTPCode, strconv.Itoa(reqErr.HTTPCode)), reqErr.Body)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s %s\n", stderrColors.red("Error:"), err)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	// registered here, because the completion command reads the commands map itself
+	commands["completion"] = command{
+		description: "print the bash, zsh or fish completion script",
+		arguments:   "bash|zsh|fish",
+		setup:       completionCommand,
+	}
+}
+
+func completionCommand(flagSet *flag.FlagSet) runFunc {
+	return func(ctx context.Context, options globalOptions, flagSet *flag.FlagSet) error {
+		if flagSet.NArg() != 1 {
+			flagSet.Usage()
+			os.Exit(exitUsage)
+		}
+		program := filepath.Base(os.Args[0])
+		switch flagSet.Arg(0) {
+		case "bash":
+			return bashCompletion(os.Stdout, program)
+		case "zsh":
+			return zshCompletion(os.Stdout, program)
+		case "fish":
+			return fishCompletion(os.Stdout, program)
+		}
+		return newUsageError("no completion for shell %q: use bash, zsh or fish", flagSet.Arg(0))
+	}
+}
+
+// completionFlag is a flag as needed by the completion scripts
+type completionFlag struct {
+	name        string
+	description string
+	takesValue  bool
+}
+
+// flagsOf lists the flags of a FlagSet, in alphabetical order
+func flagsOf(flagSet *flag.FlagSet) []completionFlag {
+	var flags []completionFlag
+	flagSet.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:        f.Name,
+			description: strings.SplitN(f.Usage, "\n", 2)[0],
+			takesValue:  !ok || !boolFlag.IsBoolFlag(),
+		})
+	})
+	return flags
+}
+
+// commandFlags returns the flags of a command, by letting it define them on an empty FlagSet
+func commandFlags(name string) []completionFlag {
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	commands[name].setup(flagSet)
+	return flagsOf(flagSet)
+}
+
+// identifier turns the program name into a shell function name
+func identifier(program string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, program)
+}
+
+func bashCompletion(w io.Writer, program string) error {
+	function := "_" + identifier(program)
+	names := sortedCommands()
+
+	fmt.Fprintf(w, "# bash completion for %s, generated by '%s completion bash'\n", program, program)
+	fmt.Fprintf(w, "%s() {\n", function)
+	fmt.Fprintf(w, "  local cur=\"${COMP_WORDS[COMP_CWORD]}\" command=\"\" i opts\n")
+	fmt.Fprintf(w, "  for ((i=1; i<COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(w, "    case \"${COMP_WORDS[i]}\" in\n")
+	fmt.Fprintf(w, "      %s) command=\"${COMP_WORDS[i]}\"; break ;;\n", strings.Join(names, "|"))
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "  done\n")
+	fmt.Fprintf(w, "  case \"$command\" in\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "    %s) opts=%q ;;\n", name, bashWords(commandFlags(name), nil))
+	}
+	fmt.Fprintf(w, "    *) opts=%q ;;\n", bashWords(flagsOf(flag.CommandLine), names))
+	fmt.Fprintf(w, "  esac\n")
+	fmt.Fprintf(w, "  COMPREPLY=($(compgen -W \"$opts\" -- \"$cur\"))\n")
+	fmt.Fprintf(w, "}\n")
+	_, err := fmt.Fprintf(w, "complete -o default -F %s %s\n", function, program)
+	return err
+}
+
+func bashWords(flags []completionFlag, names []string) string {
+	var words []string
+	for _, f := range flags {
+		words = append(words, "-"+f.name)
+	}
+	return strings.Join(append(words, names...), " ")
+}
+
+// zshQuote quotes a "-flag:description" or "command:description" entry for _describe
+func zshQuote(name, description string) string {
+	entry := strings.ReplaceAll(name, ":", `\:`) + ":" + description
+	return "'" + strings.ReplaceAll(entry, "'", `'\''`) + "'"
+}
+
+func zshCompletion(w io.Writer, program string) error {
+	function := "_" + identifier(program)
+	names := sortedCommands()
+
+	fmt.Fprintf(w, "#compdef %s\n", program)
+	fmt.Fprintf(w, "# zsh completion for %s, generated by '%s completion zsh'\n", program, program)
+	fmt.Fprintf(w, "%s() {\n", function)
+	fmt.Fprintf(w, "  local command word\n")
+	fmt.Fprintf(w, "  local -a opts\n")
+	fmt.Fprintf(w, "  for word in ${words[2,CURRENT-1]}; do\n")
+	fmt.Fprintf(w, "    case $word in\n")
+	fmt.Fprintf(w, "      (%s) command=$word; break ;;\n", strings.Join(names, "|"))
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "  done\n")
+	fmt.Fprintf(w, "  case $command in\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "    (%s) opts=(", name)
+		for _, f := range commandFlags(name) {
+			fmt.Fprintf(w, " %s", zshQuote("-"+f.name, f.description))
+		}
+		fmt.Fprintf(w, " ) ;;\n")
+	}
+	fmt.Fprintf(w, "    (*) opts=(")
+	for _, f := range flagsOf(flag.CommandLine) {
+		fmt.Fprintf(w, " %s", zshQuote("-"+f.name, f.description))
+	}
+	for _, name := range names {
+		fmt.Fprintf(w, " %s", zshQuote(name, commands[name].description))
+	}
+	fmt.Fprintf(w, " ) ;;\n")
+	fmt.Fprintf(w, "  esac\n")
+	fmt.Fprintf(w, "  _describe 'command or flag' opts\n")
+	fmt.Fprintf(w, "}\n")
+	_, err := fmt.Fprintf(w, "compdef %s %s\n", function, program)
+	return err
+}
+
+// fishQuote quotes a string for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func fishCompletion(w io.Writer, program string) error {
+	names := sortedCommands()
+
+	fmt.Fprintf(w, "# fish completion for %s, generated by '%s completion fish'\n", program, program)
+	fmt.Fprintf(w, "complete -c %s -f\n", program)
+	for _, name := range names {
+		fmt.Fprintf(w, "complete -c %s -n __fish_use_subcommand -a %s -d %s\n", program, name, fishQuote(commands[name].description))
+	}
+	writeFlags := func(condition string, flags []completionFlag) {
+		for _, f := range flags {
+			required := ""
+			if f.takesValue {
+				required = " -r"
+			}
+			// -o: the flag package uses single-dash long options
+			fmt.Fprintf(w, "complete -c %s -n %s -o %s -d %s%s\n", program, fishQuote(condition), f.name, fishQuote(f.description), required)
+		}
+	}
+	writeFlags("__fish_use_subcommand", flagsOf(flag.CommandLine))
+	for _, name := range names {
+		writeFlags("__fish_seen_subcommand_from "+name, commandFlags(name))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScripts(t *testing.T) {
+	tests := []struct {
+		shell    string
+		generate func(w io.Writer, program string) error
+		contains []string
+	}{
+		{
+			shell:    "bash",
+			generate: bashCompletion,
+			contains: []string{
+				"_go_get_flag() {\n",
+				"      completion|fetch|get|login|occurrence|words) command=",
+				`    words) opts="-input" ;;`,
+				`    get) opts="-X -data -data-file" ;;`,
+				"complete -o default -F _go_get_flag go-get-flag\n",
+			},
+		},
+		{
+			shell:    "zsh",
+			generate: zshCompletion,
+			contains: []string{
+				"#compdef go-get-flag\n",
+				"    (words) opts=( '-input:word to add before listing the words' ) ;;\n",
+				"'login:log in with -password, cache the token and print it'",
+				"compdef _go_get_flag go-get-flag\n",
+			},
+		},
+		{
+			shell:    "fish",
+			generate: fishCompletion,
+			contains: []string{
+				"complete -c go-get-flag -f\n",
+				"complete -c go-get-flag -n __fish_use_subcommand -a occurrence -d 'count how often every word was added'\n",
+				"complete -c go-get-flag -n '__fish_seen_subcommand_from words' -o input -d 'word to add before listing the words' -r\n",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.shell, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := test.generate(&out, "go-get-flag"); err != nil {
+				t.Fatalf("completion error: %s", err)
+			}
+			for _, s := range test.contains {
+				if !strings.Contains(out.String(), s) {
+					t.Errorf("Expected %q in\n%s", s, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestFlagsOf(t *testing.T) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.Bool("insecure", false, "don't verify\nthe certificate")
+	flagSet.String("output", "text", "output format")
+	flagSet.BoolFunc("v", "verbose", func(string) error { return nil })
+
+	expected := []completionFlag{
+		{name: "insecure", description: "don't verify", takesValue: false},
+		{name: "output", description: "output format", takesValue: true},
+		{name: "v", description: "verbose", takesValue: false},
+	}
+	flags := flagsOf(flagSet)
+	if len(flags) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, flags)
+	}
+	for i := range expected {
+		if flags[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], flags[i])
+		}
+	}
+}
+
+func TestCompletionQuoting(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "identifier", got: identifier("./go-get.flag2"), want: "__go_get_flag2"},
+		{name: "zsh colon", got: zshQuote("-H", "header, e.g. X-Id: 1"), want: `'-H:header, e.g. X-Id: 1'`},
+		{name: "zsh name with a colon", got: zshQuote("a:b", "it's"), want: `'a\:b:it'\''s'`},
+		{name: "fish", got: fishQuote(`don't \ escape`), want: `'don\'t \\ escape'`},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, test.got)
+		}
+	}
+}
+
+func TestCompletionUnknownShell(t *testing.T) {
+	flagSet := flag.NewFlagSet("completion", flag.ContinueOnError)
+	run := completionCommand(flagSet)
+	if err := flagSet.Parse([]string{"tcsh"}); err != nil {
+		t.Fatal(err)
+	}
+	err := run(context.Background(), globalOptions{}, flagSet)
+	if err == nil || exitCode(err) != exitUsage || !strings.Contains(err.Error(), `no completion for shell "tcsh"`) {
+		t.Errorf("Expected a usage error, got %v", err)
+	}
+}
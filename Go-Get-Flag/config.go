@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config is the content of the config file, e.g. ~/.config/words-client.yaml:
+//
+//	baseURL: http://localhost:8080
+//	password: secret
+//	timeout: 10s
+//	output: table
+type config struct {
+	BaseURL  string        `yaml:"baseURL"`
+	Password string        `yaml:"password"`
+	Timeout  time.Duration `yaml:"timeout"`
+	Output   string        `yaml:"output"`
+}
+
+// defaultConfigPath is words-client.yaml in the user config directory
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "words-client.yaml")
+}
+
+// loadConfig reads the config file. A missing file is only an error when required.
+func loadConfig(path string, required bool) (config, error) {
+	var c config
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("config error: %s", err)
+	}
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("config %s error: %s", path, err)
+	}
+
+	if info, err := os.Stat(path); err == nil && c.Password != "" && info.Mode().Perm()&0o077 != 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %s contains a password but can be read by other users (chmod 600 it)\n", path)
+	}
+	return c, nil
+}
+
+// resolveOptions fills in the options that weren't set with a flag, in order of precedence:
+// flag > WORDS_* environment variable > config file > default. The flags are the ones
+// set on the command line of flagSet.
+func resolveOptions(options *globalOptions, configPath string, flagSet *flag.FlagSet) error {
+	setFlags := map[string]bool{}
+	flagSet.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	if !setFlags["config"] {
+		if path, ok := os.LookupEnv("WORDS_CONFIG"); ok {
+			configPath = path
+			setFlags["config"] = true
+		}
+	}
+	// an explicitly chosen config file must exist, the default one is optional
+	file, err := loadConfig(configPath, setFlags["config"])
+	if err != nil {
+		return err
+	}
+
+	resolve := func(name, env, fileValue string, target *string) {
+		if setFlags[name] {
+			return
+		}
+		if value, ok := os.LookupEnv(env); ok {
+			*target = value
+			return
+		}
+		if fileValue != "" {
+			*target = fileValue
+		}
+	}
+	resolve("base-url", "WORDS_BASE_URL", file.BaseURL, &options.baseURL)
+	resolve("password", "WORDS_PASSWORD", file.Password, &options.password)
+	resolve("output", "WORDS_OUTPUT", file.Output, &options.output)
+
+	if setFlags["timeout"] {
+		return nil
+	}
+	if value, ok := os.LookupEnv("WORDS_TIMEOUT"); ok {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("WORDS_TIMEOUT error: %s", err)
+		}
+		options.timeout = timeout
+	} else if file.Timeout != 0 {
+		options.timeout = file.Timeout
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// unsetEnv unsets the variable for the test, restoring it afterwards
+func unsetEnv(t *testing.T, key string) {
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func TestResolveOptions(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "words-client.yaml")
+	err := os.WriteFile(configFile, []byte("baseURL: http://file:8080\noutput: yaml\ntimeout: 10s\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		baseURL string
+		output  string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{
+			name:    "config file",
+			baseURL: "http://file:8080", output: "yaml", timeout: 10 * time.Second,
+		},
+		{
+			name:    "environment over config file",
+			env:     map[string]string{"WORDS_BASE_URL": "http://env:8080", "WORDS_TIMEOUT": "20s"},
+			baseURL: "http://env:8080", output: "yaml", timeout: 20 * time.Second,
+		},
+		{
+			name:    "flag over environment",
+			args:    []string{"-base-url", "http://flag:8080", "-timeout", "5s"},
+			env:     map[string]string{"WORDS_BASE_URL": "http://env:8080", "WORDS_TIMEOUT": "20s"},
+			baseURL: "http://flag:8080", output: "yaml", timeout: 5 * time.Second,
+		},
+		{
+			name:    "flag set to the default",
+			args:    []string{"-output", "text"},
+			baseURL: "http://file:8080", output: "text", timeout: 10 * time.Second,
+		},
+		{
+			name:    "config file of the environment",
+			env:     map[string]string{"WORDS_CONFIG": filepath.Join(t.TempDir(), "missing.yaml")},
+			wantErr: true,
+		},
+		{
+			name:    "missing config file of the flag",
+			args:    []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid timeout in the environment",
+			env:     map[string]string{"WORDS_TIMEOUT": "soon"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range []string{"WORDS_CONFIG", "WORDS_BASE_URL", "WORDS_PASSWORD", "WORDS_OUTPUT", "WORDS_TIMEOUT"} {
+				unsetEnv(t, key)
+			}
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			options := globalOptions{timeout: 30 * time.Second}
+			var configPath string
+			flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+			flagSet.StringVar(&options.baseURL, "base-url", "http://localhost:8080", "")
+			flagSet.StringVar(&options.password, "password", "", "")
+			flagSet.StringVar(&options.output, "output", outputText, "")
+			flagSet.DurationVar(&options.timeout, "timeout", options.timeout, "")
+			flagSet.StringVar(&configPath, "config", configFile, "")
+			if err := flagSet.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := resolveOptions(&options, configPath, flagSet)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got options %+v", options)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveOptions error: %s", err)
+			}
+			if options.baseURL != test.baseURL || options.output != test.output || options.timeout != test.timeout {
+				t.Errorf("Expected %s, %s and %s, got %s, %s and %s", test.baseURL, test.output, test.timeout, options.baseURL, options.output, options.timeout)
+			}
+		})
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	c, err := loadConfig(filepath.Join(t.TempDir(), "missing.yaml"), false)
+	if err != nil {
+		t.Fatalf("Expected the default config file to be optional, got %s", err)
+	}
+	if c != (config{}) {
+		t.Errorf("Expected an empty config, got %+v", c)
+	}
+}
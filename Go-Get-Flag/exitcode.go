@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"wordsclient"
+)
+
+// Exit codes of the client, so scripts can branch on the kind of failure.
+// exitUsage is also the exit code of the flag package for invalid flags.
+const (
+	exitOK      = 0
+	exitError   = 1 // any other error, e.g. reading a file
+	exitUsage   = 2 // invalid flags, arguments or configuration
+	exitNetwork = 3 // the request didn't get a response: connection refused, timeout, TLS...
+	exitHTTP    = 4 // the server replied with an error status
+	exitJSON    = 5 // the response isn't the expected JSON
+	exitAuth    = 6 // the server rejected the password or token (401 or 403)
+)
+
+// exitCategories names the exit codes in the json errors
+var exitCategories = map[int]string{
+	exitError:   "error",
+	exitUsage:   "usage",
+	exitNetwork: "network",
+	exitHTTP:    "http",
+	exitJSON:    "json",
+	exitAuth:    "auth",
+}
+
+// Error formats of -error-format
+const (
+	errorFormatText = "text"
+	errorFormatJSON = "json"
+)
+
+// usageError is an error in the flags, arguments or configuration, rather than in a request
+type usageError struct {
+	msg string
+}
+
+func (u usageError) Error() string {
+	return u.msg
+}
+
+func newUsageError(format string, a ...interface{}) error {
+	return usageError{msg: fmt.Sprintf(format, a...)}
+}
+
+// authError is a login rejected by the server. The test-server rejects a wrong
+// password with a 400, which would otherwise be an HTTP error.
+type authError struct {
+	err error
+}
+
+func (a authError) Error() string {
+	return a.err.Error()
+}
+
+func (a authError) Unwrap() error {
+	return a.err
+}
+
+// exitCode returns the exit code of the category of err
+func exitCode(err error) int {
+	var (
+		usageErr  usageError
+		authErr   authError
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr), errors.Is(err, wordsclient.ErrValidation):
+		return exitUsage
+	case errors.As(err, &authErr), isAuthError(err):
+		return exitAuth
+	case errors.Is(err, wordsclient.ErrDecode):
+		return exitJSON
+	case errors.Is(err, wordsclient.ErrHTTPStatus):
+		return exitHTTP
+	case errors.Is(err, wordsclient.ErrNetwork):
+		return exitNetwork
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		// the JSON is decoded outside of the wordsclient as well, e.g. for -query
+		return exitJSON
+	}
+	return exitError
+}
+
+// jsonError is an error printed with -error-format json
+type jsonError struct {
+	Error    string `json:"error"`
+	Category string `json:"category"`
+	ExitCode int    `json:"exitCode"`
+	HTTPCode int    `json:"httpCode,omitempty"`
+	Body     string `json:"body,omitempty"`
+}
+
+// exitWithError prints err in the error format and exits with the exit code of its category.
+// Errors go to stderr in both formats, json ones on a single line.
+func exitWithError(errorFormat string, err error) {
+	code := exitCode(err)
+	if errorFormat != errorFormatJSON {
+		printError(err)
+		os.Exit(code)
+	}
+
+	out := jsonError{Error: err.Error(), Category: exitCategories[code], ExitCode: code}
+	var reqErr wordsclient.RequestError
+	if errors.As(err, &reqErr) {
+		out.HTTPCode, out.Body = reqErr.HTTPCode, reqErr.Body
+	}
+	json.NewEncoder(os.Stderr).Encode(out)
+	os.Exit(code)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"wordsclient"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{name: "no error", err: nil, code: exitOK},
+		{name: "other error", err: errors.New("can't write the file"), code: exitError},
+		{name: "usage", err: newUsageError("unknown command: %s", "foo"), code: exitUsage},
+		{name: "validation", err: fmt.Errorf("get: %w", wordsclient.ErrInvalidURL), code: exitUsage},
+		{name: "network", err: fmt.Errorf("%w: connection refused", wordsclient.ErrNetwork), code: exitNetwork},
+		{
+			name: "http status",
+			err:  wordsclient.RequestError{HTTPCode: http.StatusInternalServerError, Err: wordsclient.ErrHTTPStatus},
+			code: exitHTTP,
+		},
+		{
+			name: "rejected token",
+			err:  wordsclient.RequestError{HTTPCode: http.StatusForbidden, Err: wordsclient.ErrHTTPStatus},
+			code: exitAuth,
+		},
+		{
+			name: "unauthorized",
+			err:  wordsclient.RequestError{HTTPCode: http.StatusUnauthorized, Err: wordsclient.ErrHTTPStatus},
+			code: exitAuth,
+		},
+		{
+			name: "rejected password",
+			err:  authError{err: wordsclient.RequestError{HTTPCode: http.StatusBadRequest, Err: wordsclient.ErrHTTPStatus}},
+			code: exitAuth,
+		},
+		{name: "decode", err: wordsclient.RequestError{HTTPCode: http.StatusOK, Err: wordsclient.ErrUnknownPage}, code: exitJSON},
+		{name: "json syntax", err: json.Unmarshal([]byte("{"), &struct{}{}), code: exitJSON},
+		{
+			name: "fetch",
+			err:  fetchError{failed: 2, total: 3, first: fmt.Errorf("%w: timeout", wordsclient.ErrNetwork)},
+			code: exitNetwork,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := exitCode(test.err); code != test.code {
+				t.Errorf("Expected exit code %d, got %d", test.code, code)
+			}
+		})
+	}
+}
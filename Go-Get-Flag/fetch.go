@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"wordsclient"
+)
+
+// urlFlags collects the repeatable -url flag
+type urlFlags []string
+
+func (u *urlFlags) String() string {
+	return strings.Join(*u, ", ")
+}
+
+func (u *urlFlags) Set(value string) error {
+	*u = append(*u, value)
+	return nil
+}
+
+// fetchResult is the outcome of one url of the fetch command
+type fetchResult struct {
+	index    int
+	url      string
+	output   []byte
+	err      error
+	duration time.Duration
+}
+
+// readURLs reads one url per line, skipping empty lines and # comments
+func readURLs(r io.Reader) ([]string, error) {
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, scanner.Err()
+}
+
+func fetchCommand(flagSet *flag.FlagSet) runFunc {
+	var urls urlFlags
+	flagSet.Var(&urls, "url", "url (or path of the base url) to get (repeatable)")
+	urlsFile := flagSet.String("urls-file", "", "file with one url per line (- for stdin)")
+	workers := flagSet.Int("workers", 4, "number of urls fetched at the same time")
+
+	return func(ctx context.Context, options globalOptions, flagSet *flag.FlagSet) error {
+		return runFetch(ctx, options, flagSet, urls, *urlsFile, *workers)
+	}
+}
+
+func runFetch(ctx context.Context, options globalOptions, flagSet *flag.FlagSet, urls urlFlags, urlsFile string, workers int) error {
+	if urlsFile != "" {
+		var (
+			fileURLs []string
+			err      error
+		)
+		if urlsFile == "-" {
+			fileURLs, err = readURLs(os.Stdin)
+		} else {
+			file, openErr := os.Open(urlsFile)
+			if openErr != nil {
+				return fmt.Errorf("urls file error: %s", openErr)
+			}
+			defer file.Close()
+			fileURLs, err = readURLs(file)
+		}
+		if err != nil {
+			return fmt.Errorf("urls file error: %s", err)
+		}
+		urls = append(urls, fileURLs...)
+	}
+	if len(urls) == 0 {
+		flagSet.Usage()
+		os.Exit(exitUsage)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	// log in once up front, instead of every worker logging in at the same time
+	if _, err := newClient(ctx, options, false); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	jobs := make(chan int)
+	results := make(chan fetchResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results <- fetch(ctx, options, index, urls[index])
+			}
+		}()
+	}
+	go func() {
+		for index := range urls {
+			jobs <- index
+		}
+		close(jobs)
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// results arrive in the order the fetches finish: print them in the order of the urls
+	ordered := make([]fetchResult, len(urls))
+	for result := range results {
+		ordered[result.index] = result
+	}
+
+	fetchErr := fetchError{total: len(urls)}
+	for _, result := range ordered {
+		header := fmt.Sprintf("== %s (%s)", result.url, result.duration.Round(time.Millisecond))
+		if result.err != nil {
+			fmt.Println(stdoutColors.red(header))
+			fetchErr.failed++
+			if fetchErr.first == nil {
+				fetchErr.first = result.err
+			}
+			printError(result.err)
+			continue
+		}
+		fmt.Println(stdoutColors.green(header))
+		os.Stdout.Write(result.output)
+	}
+	failed := fmt.Sprintf("%d failed", fetchErr.failed)
+	if fetchErr.failed > 0 {
+		failed = stdoutColors.red(failed)
+	}
+	fmt.Printf("\n%d urls fetched in %s: %d succeeded, %s\n", len(urls), time.Since(start).Round(time.Millisecond), len(urls)-fetchErr.failed, failed)
+	if fetchErr.failed > 0 {
+		return fetchErr
+	}
+	return nil
+}
+
+// fetchError reports the failed urls. It unwraps to the error of the first one,
+// which gives the exit code.
+type fetchError struct {
+	failed, total int
+	first         error
+}
+
+func (f fetchError) Error() string {
+	return fmt.Sprintf("%d of %d urls failed", f.failed, f.total)
+}
+
+func (f fetchError) Unwrap() error {
+	return f.first
+}
+
+// fetch gets one url and renders the response in the output format
+func fetch(ctx context.Context, options globalOptions, index int, requestURL string) fetchResult {
+	start := time.Now()
+	result := fetchResult{index: index, url: requestURL}
+	if strings.HasPrefix(requestURL, "/") {
+		requestURL = options.baseURL + requestURL
+	}
+
+	body, err := doAuthenticatedRequest(ctx, options, wordsclient.Request{Method: http.MethodGet, URL: requestURL})
+	switch {
+	case err == nil && options.save.enabled():
+		var name string
+		if name, err = saveResponse(options, requestURL, body); err == nil {
+			result.output = []byte(fmt.Sprintf("saved to %s\n", name))
+		}
+	case err == nil:
+		var output bytes.Buffer
+		err = printResponse(&output, options, body, stdoutColors)
+		result.output = output.Bytes()
+	}
+	result.err = err
+	result.duration = time.Since(start)
+	return result
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFetchServer replies to /words after a short delay and to other paths with a
+// 500. It records the paths requested and the most requests in flight at once.
+func newFetchServer(t *testing.T) (*httptest.Server, *sync.Map, *atomic.Int32) {
+	var (
+		paths             sync.Map
+		inFlight, highest atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := highest.Load()
+			if n <= max || highest.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		paths.Store(r.URL.RequestURI(), true)
+		time.Sleep(20 * time.Millisecond)
+		if r.URL.Path != "/words" {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"page":"words","input":"","words":["%s"]}`, r.URL.Query().Get("i"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &paths, &highest
+}
+
+func TestRunFetch(t *testing.T) {
+	tests := []struct {
+		name    string
+		urls    []string
+		workers int
+		failed  int
+	}{
+		{name: "one worker", urls: []string{"/words?i=1", "/words?i=2", "/words?i=3"}, workers: 1},
+		{name: "workers", urls: []string{"/words?i=1", "/words?i=2", "/words?i=3", "/words?i=4", "/words?i=5", "/words?i=6", "/words?i=7"}, workers: 3},
+		{name: "more workers than urls", urls: []string{"/words?i=1", "/words?i=2"}, workers: 8},
+		{name: "failed url", urls: []string{"/words?i=1", "/missing", "/words?i=2"}, workers: 2, failed: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, paths, highest := newFetchServer(t)
+			options := globalOptions{baseURL: server.URL, output: outputText, timeout: 5 * time.Second}
+			flagSet := flag.NewFlagSet("fetch", flag.ContinueOnError)
+
+			err := runFetch(context.Background(), options, flagSet, test.urls, "", test.workers)
+			var fetchErr fetchError
+			switch {
+			case test.failed == 0 && err != nil:
+				t.Fatalf("runFetch error: %s", err)
+			case test.failed > 0 && !errors.As(err, &fetchErr):
+				t.Fatalf("Expected a fetchError, got %v", err)
+			case test.failed > 0:
+				if fetchErr.failed != test.failed || fetchErr.total != len(test.urls) {
+					t.Errorf("Expected %d of %d failed, got %s", test.failed, len(test.urls), fetchErr)
+				}
+				if code := exitCode(err); code != exitHTTP {
+					t.Errorf("Expected exit code %d, got %d", exitHTTP, code)
+				}
+			}
+
+			for _, url := range test.urls {
+				if _, ok := paths.Load(url); !ok {
+					t.Errorf("%s wasn't requested", url)
+				}
+			}
+			expected := int32(min(test.workers, len(test.urls)))
+			if got := highest.Load(); got > expected || (len(test.urls) > 1 && test.workers > 1 && got < 2) {
+				t.Errorf("Expected at most %d requests at once, got %d", expected, got)
+			}
+		})
+	}
+}
+
+func TestReadURLs(t *testing.T) {
+	urls, err := readURLs(strings.NewReader("/words\n\n# comment\n  http://localhost:8080/occurrence  \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(urls) != "[/words http://localhost:8080/occurrence]" {
+		t.Errorf("Unexpected urls: %q", urls)
+	}
+}
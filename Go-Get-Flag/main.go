@@ -1,148 +1,190 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
-	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"os"
-	"strings"
-)
+	"os/signal"
+	"time"
 
-type Response interface {
-	GetResponse() string
-}
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 
-type Page struct {
-	Name string `json:"page"`
-}
-
-type Words struct {
-	Input string   `json:"input"`
-	Words []string `json:"words"`
-}
-
-func (w Words) GetResponse() string {
-	return fmt.Sprintf("Words: %s", strings.Join(w.Words, ", "))
-}
-
-type Occurrence struct {
-	Words map[string]int `json:"words"`
-}
+	"assignment-2-rate-limiting/pkg/ratelimiter"
+	"telemetry"
+)
 
-func (o Occurrence) GetResponse() string {
-	words := []string{}
-	for word, occurrence := range o.Words {
-		words = append(words, fmt.Sprintf("%s: %d", word, occurrence))
-	}
-	return fmt.Sprintf("Words: %s", strings.Join(words, ", "))
+// globalOptions are the flags shared by all subcommands
+type globalOptions struct {
+	baseURL  string
+	password string
+	output   string
+	// errorFormat is errorFormatText or errorFormatJSON
+	errorFormat string
+	timeout     time.Duration
+	// retries of GET requests failing with a network error or a 502, 503 or 504
+	retries      int
+	retryBackoff time.Duration
+	// headers are added to every request
+	headers headerFlags
+	// verbose is verboseOff, verboseHeaders (-v) or verboseBodies (-vv)
+	verbose int
+	watch   watchOptions
+	save    saveOptions
+	bench   benchOptions
+	cache   cacheOptions
+	// query is the parsed -query path, applied to the response before rendering
+	query []querySegment
+	// rateLimiter is shared by all clients, so -rate holds across the fetch workers; nil without -rate
+	rateLimiter *ratelimiter.TokenBucket
+	// transport is shared by all clients, so connections are reused, and set up with the networkOptions
+	transport *http.Transport
 }
 
 func main() {
 	var (
-		requestURL string
-		password   string
-		parsedURL  *url.URL
-		err        error
+		options    globalOptions
+		configPath string
+		noColor    bool
+		rate       float64
+		network    = networkOptions{resolve: resolveFlags{}}
+		tlsFlags   tlsOptions
 	)
-
-	flag.StringVar(&requestURL, "url", "", "url to access")
-	flag.StringVar(&password, "password", "", "use a password to access our api")
+	options.timeout = 30 * time.Second
+
+	flag.StringVar(&options.baseURL, "base-url", "http://localhost:8080", "base url of the test server")
+	flag.StringVar(&options.password, "password", "", "use a password to access our api")
+	flag.StringVar(&options.output, "output", outputText, "output format: text, json, yaml, table or raw")
+	flag.StringVar(&options.errorFormat, "error-format", errorFormatText, "error format: text, or json on stderr with the category and exit code")
+	flag.DurationVar(&options.timeout, "timeout", options.timeout, "timeout of every request, including reading the body (WORDS_TIMEOUT)")
+	flag.IntVar(&options.retries, "retries", 0, "number of retries of a request failing with a network error or a 502, 503 or 504")
+	flag.DurationVar(&options.retryBackoff, "retry-backoff", 500*time.Millisecond, "wait before the first retry, doubling after every retry")
+	flag.Var(&options.headers, "H", "header to add to every request, e.g. -H 'X-Request-Id: 1' (repeatable)")
+	flag.BoolFunc("v", "print requests, responses and timings to stderr", verbosity(&options.verbose, verboseHeaders))
+	flag.BoolFunc("vv", "like -v, including the (truncated) bodies", verbosity(&options.verbose, verboseBodies))
+	flag.Func("proxy", "proxy url: http://, https://, socks5:// or socks5h:// (default from HTTP_PROXY/HTTPS_PROXY)", func(value string) (err error) {
+		network.proxy, err = parseProxy(value)
+		return err
+	})
+	flag.Var(network.resolve, "resolve", "connect to addr for host:port, curl-style host:port:addr (repeatable)")
+	flag.StringVar(&network.unixSocket, "unix", "", "connect to this unix socket instead of the host of the url, e.g. /var/run/docker.sock")
+	flag.BoolVar(&network.http2, "http2", false, "only use HTTP/2: with prior knowledge (h2c) for http:// urls")
+	flag.BoolVar(&tlsFlags.insecure, "insecure", false, "don't verify the server certificate (only for test servers)")
+	flag.StringVar(&tlsFlags.caFile, "cacert", "", "PEM file with CA certificates to trust in addition to the system ones")
+	flag.StringVar(&tlsFlags.certFile, "cert", "", "PEM client certificate for mutual TLS (with -key)")
+	flag.StringVar(&tlsFlags.keyFile, "key", "", "PEM private key of the client certificate")
+	flag.Func("output-file", "write the response to a file instead of stdout, named by a template with {{.Host}}, {{.Path}}, {{.Timestamp}} and {{.Ext}}", func(value string) (err error) {
+		options.save.file, err = parseFileTemplate(value)
+		return err
+	})
+	flag.StringVar(&options.save.dir, "output-dir", "", "write the responses to files in this directory, e.g. with fetch (named "+defaultFileTemplate+" without -output-file)")
+	flag.Float64Var(&rate, "rate", 0, "maximum number of requests per second, e.g. to protect the test-server with fetch or -watch (0: no limit)")
+	flag.BoolVar(&options.watch.enabled, "watch", false, "repeat the request every -interval and print the changes, until Ctrl+C")
+	flag.DurationVar(&options.watch.interval, "interval", 5*time.Second, "interval of -watch")
+	flag.StringVar(&options.watch.until, "until", "", "watch until the response contains this text")
+	flag.BoolVar(&options.bench.enabled, "bench", false, "send the request -n times with -c workers and print the latency statistics")
+	flag.IntVar(&options.bench.requests, "n", 100, "number of requests of -bench")
+	flag.IntVar(&options.bench.concurrency, "c", 10, "number of concurrent workers of -bench")
+	flag.Func("query", "extract a value from the response, e.g. .words[0] or .words[\"word1\"]", func(value string) (err error) {
+		options.query, err = parseQuery(value)
+		return err
+	})
+	flag.StringVar(&options.cache.dir, "cache-dir", "", "cache GET responses in this directory, honoring Cache-Control and ETag")
+	flag.BoolVar(&options.cache.bypass, "no-cache", false, "don't use the cached responses of -cache-dir (new responses are still cached)")
+	flag.BoolVar(&noColor, "no-color", false, "don't color the output (also with the NO_COLOR environment variable, or when it isn't a terminal)")
+	flag.StringVar(&configPath, "config", defaultConfigPath(), "config file (WORDS_CONFIG)")
+	flag.Usage = usage
 
 	flag.Parse()
+	setupColors(noColor)
 
-	if requestURL == "" {
-		fmt.Println("Please provide a URL using -url flag")
-		os.Exit(1)
+	if options.errorFormat != errorFormatText && options.errorFormat != errorFormatJSON {
+		exitWithError(errorFormatText, newUsageError("unknown error format: %s", options.errorFormat))
 	}
-
-	if parsedURL, err = url.ParseRequestURI(requestURL); err != nil {
-		fmt.Printf("Validation error: URL is not valid: %s\n", err)
-		flag.Usage()
-		os.Exit(1)
+	if err := resolveOptions(&options, configPath, flag.CommandLine); err != nil {
+		exitWithError(options.errorFormat, newUsageError("configuration error: %s", err))
 	}
 
-	res, err := doRequest(parsedURL.String())
-	if err != nil {
-		if reqErr, ok := err.(RequestError); ok {
-			fmt.Printf("Error: %s (HTTP Code: %d, Body: %s)\n", reqErr.Err, reqErr.HTTPCode, reqErr.Body)
-			os.Exit(1)
-		}
+	if _, err := url.ParseRequestURI(options.baseURL); err != nil {
+		exitWithError(options.errorFormat, newUsageError("base URL is not valid: %s", err))
 	}
-
-	if res == nil {
-		fmt.Printf("No response received.\n")
-		os.Exit(1)
+	if !isValidOutput(options.output) {
+		exitWithError(options.errorFormat, newUsageError("unknown output format: %s", options.output))
 	}
 
-	fmt.Printf("Response: %s\n", res.GetResponse())
-}
-
-func doRequest(requestURL string) (Response, error) {
-
-	if _, err := url.ParseRequestURI(requestURL); err != nil {
-		fmt.Printf("URL is in invalid format: %s\n", err)
-		os.Exit(1)
+	if options.watch.until != "" {
+		options.watch.enabled = true
 	}
-
-	response, err := http.Get(requestURL)
-
-	if err != nil {
-		return nil, fmt.Errorf("get error: %s", err)
+	if options.watch.enabled && options.watch.interval <= 0 {
+		exitWithError(options.errorFormat, newUsageError("-interval must be positive"))
 	}
 
-	defer response.Body.Close()
+	if options.bench.enabled && options.watch.enabled {
+		exitWithError(options.errorFormat, newUsageError("use either -bench or -watch"))
+	}
+	if options.bench.enabled && (options.bench.requests < 1 || options.bench.concurrency < 1) {
+		exitWithError(options.errorFormat, newUsageError("-n and -c must be positive"))
+	}
 
-	body, err := io.ReadAll(response.Body)
+	if rate < 0 {
+		exitWithError(options.errorFormat, newUsageError("-rate can't be negative"))
+	}
+	if rate > 0 {
+		// a burst of 1 spreads the requests evenly over every second
+		options.rateLimiter = ratelimiter.NewTokenBucket(rate, 1)
+	}
 
+	tlsConfig, err := newTLSConfig(tlsFlags)
 	if err != nil {
-		return nil, fmt.Errorf("ReadAll error: %s", err)
+		exitWithError(options.errorFormat, newUsageError("configuration error: %s", err))
 	}
+	options.transport = newHTTPTransport(network, tlsConfig)
 
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("invalid output (http code: %d): %s", response.StatusCode, string(body))
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(exitUsage)
 	}
 
-	if !json.Valid(body) {
-		return nil, RequestError{
-			HTTPCode: response.StatusCode,
-			Body:     string(body),
-			Err:      "no valid json returned",
-		}
+	command, ok := commands[flag.Arg(0)]
+	if !ok {
+		usage()
+		exitWithError(options.errorFormat, newUsageError("unknown command: %s", flag.Arg(0)))
 	}
+	// Ctrl+C cancels the requests in flight instead of leaving the client hanging
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	var page Page
-
-	err = json.Unmarshal(body, &page)
+	// exports the traces to the collector of OTEL_EXPORTER_OTLP_ENDPOINT, if set
+	shutdownTelemetry, err := telemetry.Setup(ctx, "go-get-flag")
 	if err != nil {
-		return nil, fmt.Errorf("unmarshal error: %s", err)
+		exitWithError(options.errorFormat, newUsageError("telemetry error: %s", err))
 	}
 
-	switch page.Name {
-	// curl 'http://localhost:8080/words?input=word1'
-	// Raw return example: {"page":"words","input":"word3","words":["word1","word2","word2","word3","word3","word3","word3"]}
-	case "words":
-		var words Words
-		err = json.Unmarshal(body, &words)
-		if err != nil {
-			return nil, fmt.Errorf("unmarshal error for words: %s", err)
-		}
-
-		return words, nil
-
-	// curl 'http://localhost:8080/occurrence'
-	// Raw return example: {"page":"occurrence","words":{"word1":1,"word2":2,"word3":3}}
-	case "occurrence":
-		var occurrence Occurrence
-		err = json.Unmarshal(body, &occurrence)
-		if err != nil {
-			return nil, fmt.Errorf("unmarshal error for occurrence: %s", err)
-		}
-
-		return occurrence, nil
+	flagSet := newFlagSet(flag.Arg(0), command.arguments)
+	run := command.setup(flagSet)
+	flagSet.Parse(flag.Args()[1:])
+	err = runTraced(ctx, flag.Arg(0), func(ctx context.Context) error {
+		return run(ctx, options, flagSet)
+	})
+	// the spans are exported before exiting, also when the command failed
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdownTelemetry(shutdownCtx)
+	if err != nil {
+		exitWithError(options.errorFormat, err)
 	}
+}
 
-	return nil, nil
+// runTraced runs a command in a span, so all its requests are in the same trace
+func runTraced(ctx context.Context, command string, run func(ctx context.Context) error) error {
+	ctx, span := otel.Tracer("go-get-flag").Start(ctx, command)
+	defer span.End()
+	err := run(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// resolveFlags collects the repeatable curl-style -resolve host:port:addr flag
+type resolveFlags map[string]string
+
+func (r resolveFlags) String() string {
+	entries := make([]string, 0, len(r))
+	for hostPort, addr := range r {
+		entries = append(entries, hostPort+" -> "+addr)
+	}
+	return strings.Join(entries, ", ")
+}
+
+func (r resolveFlags) Set(value string) error {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return fmt.Errorf("%q is not in the host:port:addr format", value)
+	}
+	host, port := parts[0], parts[1]
+	addr := strings.TrimSuffix(strings.TrimPrefix(parts[2], "["), "]")
+	if net.ParseIP(addr) == nil {
+		return fmt.Errorf("%q is not an IP address", addr)
+	}
+	r[net.JoinHostPort(host, port)] = net.JoinHostPort(addr, port)
+	return nil
+}
+
+// parseProxy validates the -proxy url: http, https, socks5 or socks5h
+func parseProxy(value string) (*url.URL, error) {
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("proxy is not a valid url: %s", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy scheme %q is not http, https, socks5 or socks5h", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("proxy %q has no host", value)
+	}
+	return proxyURL, nil
+}
+
+// networkOptions are the flags changing how the client connects to the server
+type networkOptions struct {
+	proxy   *url.URL
+	resolve resolveFlags
+	// unixSocket replaces every connection, the host of the url is only sent in the Host header
+	unixSocket string
+	// http2 disables HTTP/1.1, and speaks HTTP/2 without TLS (h2c) to http:// urls
+	http2 bool
+}
+
+// newHTTPTransport returns a clone of http.DefaultTransport going through the -proxy
+// (the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables otherwise),
+// connecting to the -resolve addresses instead of looking up their hosts, or to the
+// -unix socket, and using the TLS config
+func newHTTPTransport(options networkOptions, tlsConfig *tls.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if options.proxy != nil {
+		transport.Proxy = http.ProxyURL(options.proxy)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	switch {
+	case options.unixSocket != "":
+		// a proxy would be connected to through the socket as well
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", options.unixSocket)
+		}
+	case len(options.resolve) > 0:
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if resolved, ok := options.resolve[addr]; ok {
+				addr = resolved
+			}
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+
+	if options.http2 {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = protocols
+	}
+	return transport
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+	"wordsclient"
+)
+
+// Supported -output formats
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+	outputRaw   = "raw"
+)
+
+func isValidOutput(output string) bool {
+	switch output {
+	case outputText, outputJSON, outputYAML, outputTable, outputRaw:
+		return true
+	}
+	return false
+}
+
+// render writes the parsed response to w in the output format, with the
+// word counts of the text and table formats highlighted by the palette.
+// Maps are written with sorted keys in every format.
+func render(w io.Writer, res wordsclient.Response, output string, colors palette) error {
+	switch output {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(res)
+	case outputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(res); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case outputTable:
+		return renderTable(w, res, colors)
+	}
+	text := res.GetResponse()
+	if occurrence, ok := res.(wordsclient.Occurrence); ok {
+		text = formatOccurrence(occurrence, colors)
+	}
+	_, err := fmt.Fprintf(w, "Response: %s\n", text)
+	return err
+}
+
+// formatOccurrence is the GetResponse of an occurrence with the counts highlighted
+func formatOccurrence(o wordsclient.Occurrence, colors palette) string {
+	words := []string{}
+	for word, occurrence := range o.Words {
+		words = append(words, fmt.Sprintf("%s: %s", word, colors.count(strconv.Itoa(occurrence))))
+	}
+	return fmt.Sprintf("Words: %s", strings.Join(words, ", "))
+}
+
+// renderTable writes the words, or the occurrence of every word, in aligned columns
+func renderTable(w io.Writer, res wordsclient.Response, colors palette) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	switch res := res.(type) {
+	case wordsclient.Words:
+		fmt.Fprintln(tw, "#\tWORD")
+		for i, word := range res.Words {
+			fmt.Fprintf(tw, "%d\t%s\n", i+1, word)
+		}
+	case wordsclient.Occurrence:
+		words := make([]string, 0, len(res.Words))
+		for word := range res.Words {
+			words = append(words, word)
+		}
+		sort.Strings(words)
+		fmt.Fprintln(tw, "WORD\tOCCURRENCE")
+		for _, word := range words {
+			// the count is the last column: its escape codes don't break the alignment
+			fmt.Fprintf(tw, "%s\t%s\n", word, colors.count(strconv.Itoa(res.Words[word])))
+		}
+	default:
+		return fmt.Errorf("no table format for %T", res)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// querySegment is one step of a -query path: an object key or an array index
+type querySegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func (q querySegment) String() string {
+	if q.isIndex {
+		return fmt.Sprintf("[%d]", q.index)
+	}
+	return "." + q.key
+}
+
+// parseQuery parses a jq-style path like .words[0], .words["word1"] or ["page"].
+// A negative index counts from the end of the array.
+func parseQuery(query string) ([]querySegment, error) {
+	if !strings.HasPrefix(query, ".") && !strings.HasPrefix(query, "[") {
+		return nil, fmt.Errorf("query %q must start with . or [", query)
+	}
+	var segments []querySegment
+	for i := 0; i < len(query); {
+		switch query[i] {
+		case '.':
+			i++
+			start := i
+			for i < len(query) && query[i] != '.' && query[i] != '[' {
+				i++
+			}
+			if i > start {
+				segments = append(segments, querySegment{key: query[start:i]})
+			} else if i < len(query) && query[i] == '.' {
+				return nil, fmt.Errorf("query %q has an empty key at position %d", query, start)
+			}
+		case '[':
+			end := strings.IndexByte(query[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("query %q has an unclosed [ at position %d", query, i)
+			}
+			inside := query[i+1 : i+end]
+			if unquoted, err := strconv.Unquote(inside); err == nil && strings.HasPrefix(inside, `"`) {
+				segments = append(segments, querySegment{key: unquoted})
+			} else if index, err := strconv.Atoi(inside); err == nil {
+				segments = append(segments, querySegment{index: index, isIndex: true})
+			} else {
+				return nil, fmt.Errorf("query %q: %s is not an index or a quoted key", query, query[i:i+end+1])
+			}
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("query %q: unexpected %q at position %d", query, query[i], i)
+		}
+	}
+	return segments, nil
+}
+
+// applyQuery extracts the value at the path from a JSON body
+func applyQuery(body []byte, segments []querySegment) (any, error) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// keep numbers as written instead of converting them to float64
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+
+	path := ""
+	for _, segment := range segments {
+		switch current := value.(type) {
+		case map[string]any:
+			if segment.isIndex {
+				return nil, fmt.Errorf("query error: %s is an object, not an array", pathOrRoot(path))
+			}
+			var ok bool
+			if value, ok = current[segment.key]; !ok {
+				return nil, fmt.Errorf("query error: %s has no key %q", pathOrRoot(path), segment.key)
+			}
+		case []any:
+			if !segment.isIndex {
+				return nil, fmt.Errorf("query error: %s is an array, not an object", pathOrRoot(path))
+			}
+			index := segment.index
+			if index < 0 {
+				index += len(current)
+			}
+			if index < 0 || index >= len(current) {
+				return nil, fmt.Errorf("query error: index %d out of range, %s has %d elements", segment.index, pathOrRoot(path), len(current))
+			}
+			value = current[index]
+		default:
+			return nil, fmt.Errorf("query error: %s is not an object or an array", pathOrRoot(path))
+		}
+		path += segment.String()
+	}
+	return value, nil
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+// renderQueryResult prints an extracted value. Strings and numbers are printed
+// as they are in the text, raw and table formats, like jq -r, so scripts can use them directly.
+func renderQueryResult(w io.Writer, value any, output string) error {
+	switch output {
+	case outputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(yamlNumbers(value)); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	switch value := value.(type) {
+	case string:
+		_, err := fmt.Fprintln(w, value)
+		return err
+	case json.Number:
+		_, err := fmt.Fprintln(w, value.String())
+		return err
+	}
+	return json.NewEncoder(w).Encode(value)
+}
+
+// yamlNumbers converts the json.Numbers in a decoded value to int64 or float64,
+// which the YAML encoder writes as numbers instead of strings
+func yamlNumbers(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value.String()
+	case map[string]any:
+		converted := make(map[string]any, len(value))
+		for key, v := range value {
+			converted[key] = yamlNumbers(v)
+		}
+		return converted
+	case []any:
+		converted := make([]any, len(value))
+		for i, v := range value {
+			converted[i] = yamlNumbers(v)
+		}
+		return converted
+	}
+	return value
+}
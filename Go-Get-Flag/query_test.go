@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		segments string
+		err      string
+	}{
+		{query: ".words[0]", segments: ".words[0]"},
+		{query: `.words["word1"]`, segments: ".words.word1"},
+		{query: `["page"]`, segments: ".page"},
+		{query: `.words["a.b"]`, segments: ".words.a.b"},
+		{query: ".list[-1].name", segments: ".list[-1].name"},
+		{query: ".", segments: ""},
+		{query: "words", err: "must start with . or ["},
+		{query: ".a..b", err: "has an empty key at position 3"},
+		{query: ".words[0", err: "has an unclosed [ at position 6"},
+		{query: ".words[first]", err: "[first] is not an index or a quoted key"},
+		{query: ".words[0]x", err: `unexpected 'x' at position 9`},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			segments, err := parseQuery(test.query)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("Expected an error with %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseQuery error: %s", err)
+			}
+			var path strings.Builder
+			for _, segment := range segments {
+				path.WriteString(segment.String())
+			}
+			if path.String() != test.segments {
+				t.Errorf("Expected %s, got %s", test.segments, path.String())
+			}
+		})
+	}
+}
+
+func TestApplyQuery(t *testing.T) {
+	body := []byte(`{"page":"occurrence","words":{"word1":2,"a.b":1},"list":[1,2.50,"x",{"name":"n"}]}`)
+	tests := []struct {
+		query string
+		value any
+		err   string
+	}{
+		{query: `.words["word1"]`, value: json.Number("2")},
+		{query: `.words["a.b"]`, value: json.Number("1")},
+		// numbers stay as they are written
+		{query: ".list[1]", value: json.Number("2.50")},
+		{query: ".list[-2]", value: "x"},
+		{query: ".list[3].name", value: "n"},
+		{query: ".page", value: "occurrence"},
+		{query: ".missing", err: `. has no key "missing"`},
+		{query: ".words[0]", err: ".words is an object, not an array"},
+		{query: ".list.name", err: ".list is an array, not an object"},
+		{query: ".list[4]", err: "index 4 out of range, .list has 4 elements"},
+		{query: ".list[-5]", err: "index -5 out of range"},
+		{query: ".page.name", err: ".page is not an object or an array"},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			segments, err := parseQuery(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			value, err := applyQuery(body, segments)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("Expected an error with %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyQuery error: %s", err)
+			}
+			if value != test.value {
+				t.Errorf("Expected %#v, got %#v", test.value, value)
+			}
+		})
+	}
+
+	if _, err := applyQuery([]byte("not json"), nil); err == nil || !strings.Contains(err.Error(), "unmarshal error") {
+		t.Errorf("Expected an unmarshal error, got %v", err)
+	}
+}
+
+func TestRenderQueryResult(t *testing.T) {
+	object := map[string]any{"count": json.Number("2"), "list": []any{json.Number("1"), json.Number("2.5"), "x"}}
+	tests := []struct {
+		name   string
+		value  any
+		output string
+		want   string
+	}{
+		{name: "text string", value: "word1", output: outputText, want: "word1\n"},
+		{name: "raw number", value: json.Number("2.50"), output: outputRaw, want: "2.50\n"},
+		{name: "table object", value: object, output: outputTable, want: `{"count":2,"list":[1,2.5,"x"]}` + "\n"},
+		{name: "json string", value: "word1", output: outputJSON, want: "\"word1\"\n"},
+		{name: "json object", value: object, output: outputJSON, want: "{\n  \"count\": 2,\n  \"list\": [\n    1,\n    2.5,\n    \"x\"\n  ]\n}\n"},
+		// the numbers aren't quoted strings in YAML
+		{name: "yaml object", value: object, output: outputYAML, want: "count: 2\nlist:\n  - 1\n  - 2.5\n  - x\n"},
+		{name: "yaml number", value: json.Number("1e3"), output: outputYAML, want: "1000\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := renderQueryResult(&out, test.value, test.output); err != nil {
+				t.Fatalf("renderQueryResult error: %s", err)
+			}
+			if out.String() != test.want {
+				t.Errorf("Expected %q, got %q", test.want, out.String())
+			}
+		})
+	}
+}
+
+func TestPrintResponseQuery(t *testing.T) {
+	segments, err := parseQuery(".words[1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := globalOptions{output: outputText, query: segments}
+	var out bytes.Buffer
+	if err := printResponse(&out, options, []byte(`{"page":"words","input":"b","words":["a","b"]}`), palette{}); err != nil {
+		t.Fatalf("printResponse error: %s", err)
+	}
+	if out.String() != "b\n" {
+		t.Errorf("Expected b, got %q", out.String())
+	}
+	options.query, _ = parseQuery(".words[2]")
+	if err := printResponse(&out, options, []byte(`{"page":"words","words":["a","b"]}`), palette{}); err == nil {
+		t.Errorf("Expected an out of range error, got %q", out.String())
+	}
+}
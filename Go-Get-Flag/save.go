@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// saveOptions are the -output-file and -output-dir flags
+type saveOptions struct {
+	// file is the template of the file name, relative to dir
+	file *template.Template
+	dir  string
+}
+
+// defaultFileTemplate names the files in -output-dir without -output-file, one directory per host
+const defaultFileTemplate = "{{.Host}}/{{.Path}}{{.Ext}}"
+
+// fileNameData are the variables of the -output-file template
+type fileNameData struct {
+	Host      string // host and port of the url, e.g. localhost_8080
+	Path      string // path of the url without the leading /, e.g. words, or index for /
+	Timestamp string // time of the response, e.g. 20240102T150405
+	Ext       string // extension of the output format, e.g. .json
+}
+
+func (s saveOptions) enabled() bool {
+	return s.file != nil || s.dir != ""
+}
+
+// parseFileTemplate parses the -output-file flag, and tries it out so unknown
+// variables are reported with the flags rather than after the request
+func parseFileTemplate(value string) (*template.Template, error) {
+	fileTemplate, err := template.New("output-file").Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := fileTemplate.Execute(io.Discard, fileNameData{}); err != nil {
+		return nil, err
+	}
+	return fileTemplate, nil
+}
+
+// outputExtension is the extension of the files written in the output format
+func outputExtension(output string) string {
+	switch output {
+	case outputJSON, outputRaw:
+		return ".json"
+	case outputYAML:
+		return ".yaml"
+	}
+	return ".txt"
+}
+
+// fileName executes the file name template for the url
+func (s saveOptions) fileName(requestURL, output string, now time.Time) (string, error) {
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("output file error: %s", err)
+	}
+	// path.Clean drops the .. elements, the files stay in the directory
+	urlPath := strings.TrimPrefix(path.Clean("/"+parsed.Path), "/")
+	if urlPath == "" {
+		urlPath = "index"
+	}
+	data := fileNameData{
+		Host:      strings.ReplaceAll(parsed.Host, ":", "_"),
+		Path:      urlPath,
+		Timestamp: now.Format("20060102T150405"),
+		Ext:       outputExtension(output),
+	}
+
+	fileTemplate := s.file
+	if fileTemplate == nil {
+		fileTemplate = template.Must(parseFileTemplate(defaultFileTemplate))
+	}
+	var name bytes.Buffer
+	if err := fileTemplate.Execute(&name, data); err != nil {
+		return "", fmt.Errorf("output file error: %s", err)
+	}
+	if name.Len() == 0 {
+		return "", fmt.Errorf("output file error: the template gives an empty name for %s", requestURL)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(name.String())), nil
+}
+
+// saveResponse writes the response of the url to its file, in the output format
+// without colors, and returns the name of the file
+func saveResponse(options globalOptions, requestURL string, body []byte) (string, error) {
+	name, err := options.save.fileName(requestURL, options.output, time.Now())
+	if err != nil {
+		return "", err
+	}
+	var output bytes.Buffer
+	if err := printResponse(&output, options, body, palette{}); err != nil {
+		return "", err
+	}
+	if dir := filepath.Dir(name); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("output file error: %s", err)
+		}
+	}
+	if err := os.WriteFile(name, output.Bytes(), 0o644); err != nil {
+		return "", fmt.Errorf("output file error: %s", err)
+	}
+	return name, nil
+}
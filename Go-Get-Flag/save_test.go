@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"wordsclient"
+)
+
+func TestFileName(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name     string
+		template string
+		url      string
+		output   string
+		want     string
+		err      string
+	}{
+		{name: "default", url: "http://localhost:8080/words?input=a", output: outputJSON, want: "out/localhost_8080/words.json"},
+		{name: "root", url: "http://localhost:8080/", output: outputYAML, want: "out/localhost_8080/index.yaml"},
+		{name: "text", url: "http://localhost:8080/api/occurrence", output: outputTable, want: "out/localhost_8080/api/occurrence.txt"},
+		// the files stay in the directory
+		{name: "dot dot", url: "http://localhost:8080/../../etc/passwd", output: outputRaw, want: "out/localhost_8080/etc/passwd.json"},
+		{name: "timestamp", template: "{{.Path}}-{{.Timestamp}}{{.Ext}}", url: "http://localhost:8080/words", output: outputText, want: "out/words-20240102T150405.txt"},
+		{name: "empty name", template: "{{if false}}x{{end}}", url: "http://localhost:8080/words", err: "the template gives an empty name"},
+		{name: "invalid url", url: "http://local host/", err: "output file error"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			save := saveOptions{dir: "out"}
+			if test.template != "" {
+				var err error
+				if save.file, err = parseFileTemplate(test.template); err != nil {
+					t.Fatal(err)
+				}
+			}
+			name, err := save.fileName(test.url, test.output, now)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("Expected an error with %q, got %s, %v", test.err, name, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fileName error: %s", err)
+			}
+			if name != filepath.FromSlash(test.want) {
+				t.Errorf("Expected %s, got %s", test.want, name)
+			}
+		})
+	}
+}
+
+func TestParseFileTemplate(t *testing.T) {
+	for _, value := range []string{"{{.Host", "{{.Hostname}}.json"} {
+		if _, err := parseFileTemplate(value); err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+}
+
+func TestSaveResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"page":"words","input":%q,"words":["a",%q]}`, r.URL.Query().Get("input"), r.URL.Query().Get("input"))
+	}))
+	defer server.Close()
+
+	// the colors of a terminal
+	stdoutColors = palette{enabled: true}
+	t.Cleanup(func() { stdoutColors = palette{} })
+
+	dir := t.TempDir()
+	fileTemplate, err := parseFileTemplate("{{.Path}}-{{.Host}}{{.Ext}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := globalOptions{
+		baseURL: server.URL,
+		output:  outputYAML,
+		timeout: 5 * time.Second,
+		save:    saveOptions{file: fileTemplate, dir: dir},
+	}
+	if err := getAndPrint(context.Background(), options, wordsclient.Request{Method: http.MethodGet, URL: server.URL + "/words?input=b"}); err != nil {
+		t.Fatalf("getAndPrint error: %s", err)
+	}
+
+	// the file has the output format, without the colors
+	name := filepath.Join(dir, "words-"+strings.ReplaceAll(strings.TrimPrefix(server.URL, "http://"), ":", "_")+".yaml")
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Expected the response in %s: %s", name, err)
+	}
+	if !strings.Contains(string(data), "input: b\n") || strings.Contains(string(data), "\033[") {
+		t.Errorf("Expected the yaml of the response, got\n%s", data)
+	}
+
+	// a file in the way of the directory
+	options.save = saveOptions{dir: name}
+	if _, err := saveResponse(options, server.URL+"/words", []byte(`{"page":"words","words":["a"]}`)); err == nil || !strings.Contains(err.Error(), "output file error") {
+		t.Errorf("Expected an output file error, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// tlsOptions are the -insecure, -cacert, -cert and -key flags
+type tlsOptions struct {
+	insecure bool
+	caFile   string
+	certFile string
+	keyFile  string
+}
+
+// newTLSConfig builds the TLS config of the transport: the system CAs plus -cacert,
+// and the client certificate for mutual TLS
+func newTLSConfig(options tlsOptions) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: options.insecure,
+	}
+
+	if options.caFile != "" {
+		pem, err := os.ReadFile(options.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("cacert error: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("cacert error: no PEM certificates found in %s", options.caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (options.certFile == "") != (options.keyFile == "") {
+		return nil, fmt.Errorf("-cert and -key must be used together")
+	}
+	if options.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(options.certFile, options.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate error: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"assignment-2-rate-limiting/pkg/ratelimiter"
+	"telemetry"
+	"wordsclient"
+)
+
+// baseTransport is the transport of every request: the shared http.Transport with verbose output,
+// the rate limit, retries, the response cache, the -H headers and the trace context
+func baseTransport(options globalOptions) http.RoundTripper {
+	var transport http.RoundTripper = options.transport
+	if options.transport == nil {
+		transport = http.DefaultTransport
+	}
+	if options.verbose > verboseOff {
+		// inside the retries, so every attempt is printed
+		transport = verboseTransport{
+			transport: transport,
+			level:     options.verbose,
+			out:       os.Stderr,
+		}
+	}
+	if options.rateLimiter != nil {
+		// inside the retries, so every attempt waits for its turn
+		transport = rateLimitTransport{
+			transport: transport,
+			bucket:    options.rateLimiter,
+		}
+	}
+	if options.retries > 0 {
+		transport = wordsclient.RetryPolicy{
+			MaxAttempts: options.retries + 1,
+			Backoff:     options.retryBackoff,
+		}.Transport(transport)
+	}
+	if options.cache.dir != "" {
+		// outside the retries and the verbose output: a cached response isn't requested at all
+		cache := cacheTransport{
+			transport: transport,
+			options:   options.cache,
+			now:       time.Now,
+		}
+		if options.verbose > verboseOff {
+			cache.log = os.Stderr
+		}
+		transport = cache
+	}
+	if len(options.headers) > 0 {
+		transport = headerTransport{
+			transport: transport,
+			headers:   options.headers,
+		}
+	}
+	// outermost, so a call is a single span, with the waits of the rate limit in it
+	return telemetry.Transport(transport)
+}
+
+// rateLimitTransport waits for a token of the bucket before sending every request
+type rateLimitTransport struct {
+	transport http.RoundTripper
+	bucket    *ratelimiter.TokenBucket
+}
+
+func (r rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := r.bucket.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return r.transport.RoundTrip(req)
+}
+
+type MyJWTTransport struct {
+	transport http.RoundTripper
+	token     string
+}
+
+func (m MyJWTTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if m.token != "" {
+		// a RoundTripper must not modify the request of the caller
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+m.token)
+	}
+	return m.transport.RoundTrip(req)
+}
+
+// headerFlags collects the repeatable -H 'Key: Value' flag
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	key, _, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("header %q is not in the 'Key: Value' format", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+// headerTransport sets the -H headers on every request
+type headerTransport struct {
+	transport http.RoundTripper
+	headers   headerFlags
+}
+
+func (h headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for _, header := range h.headers {
+		key, value, _ := strings.Cut(header, ":")
+		req.Header.Set(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+	return h.transport.RoundTrip(req)
+}
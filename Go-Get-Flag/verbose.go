@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Verbosity levels of -v and -vv
+const (
+	verboseOff = iota
+	verboseHeaders
+	verboseBodies
+)
+
+// maxVerboseBody is the number of body bytes printed with -vv
+const maxVerboseBody = 1024
+
+// sensitiveHeaders are printed as [REDACTED]
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// sensitiveFields matches the values of JSON fields holding secrets, like the login password and token
+var sensitiveFields = regexp.MustCompile(`("(?:password|token)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// verboseTransport prints every request and response, curl -v style, with a timing breakdown
+type verboseTransport struct {
+	transport http.RoundTripper
+	level     int
+	out       io.Writer
+}
+
+// timings are collected with httptrace while a request is sent
+type timings struct {
+	start, dnsStart, dnsDone, connectStart, connectDone, tlsStart, tlsDone, firstByte time.Time
+	reused                                                                            bool
+}
+
+func (t *timings) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.dnsDone = time.Now() },
+		ConnectStart:         func(string, string) { t.connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { t.connectDone = time.Now() },
+		TLSHandshakeStart:    func() { t.tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.tlsDone = time.Now() },
+		GotConn:              func(info httptrace.GotConnInfo) { t.reused = info.Reused },
+		GotFirstResponseByte: func() { t.firstByte = time.Now() },
+	}
+}
+
+func (t *timings) String() string {
+	phase := func(start, end time.Time) string {
+		if start.IsZero() || end.IsZero() {
+			return "-"
+		}
+		return end.Sub(start).Round(time.Microsecond).String()
+	}
+	connection := "new connection"
+	if t.reused {
+		connection = "reused connection"
+	}
+	return fmt.Sprintf("dns %s, connect %s, tls %s, ttfb %s (%s)",
+		phase(t.dnsStart, t.dnsDone), phase(t.connectStart, t.connectDone), phase(t.tlsStart, t.tlsDone), phase(t.start, t.firstByte), connection)
+}
+
+func (v verboseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t := &timings{start: time.Now()}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), t.trace()))
+
+	fmt.Fprintf(v.out, "> %s %s %s\n", req.Method, req.URL.RequestURI(), req.Proto)
+	fmt.Fprintf(v.out, "> Host: %s\n", req.URL.Host)
+	printHeaders(v.out, ">", req.Header)
+	fmt.Fprintln(v.out, ">")
+	if v.level >= verboseBodies && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			printBody(v.out, ">", data)
+		}
+	}
+
+	response, err := v.transport.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(v.out, "* %s failed after %s: %s\n", req.Method, time.Since(t.start).Round(time.Microsecond), err)
+		return nil, err
+	}
+
+	fmt.Fprintf(v.out, "< %s %s\n", response.Proto, stderrColors.status(response.StatusCode, response.Status))
+	printHeaders(v.out, "<", response.Header)
+	fmt.Fprintln(v.out, "<")
+	fmt.Fprintf(v.out, "* %s\n", t)
+	if v.level >= verboseBodies {
+		data, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		printBody(v.out, "<", data)
+		response.Body = io.NopCloser(bytes.NewReader(data))
+	}
+	return response, nil
+}
+
+// printHeaders prints the headers sorted by name, with the credentials redacted
+func printHeaders(out io.Writer, prefix string, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			if sensitiveHeaders[key] {
+				value = "[REDACTED]"
+			}
+			fmt.Fprintf(out, "%s %s: %s\n", prefix, key, value)
+		}
+	}
+}
+
+// printBody prints up to maxVerboseBody bytes of a body, with passwords and tokens redacted
+func printBody(out io.Writer, prefix string, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	truncated := ""
+	if len(data) > maxVerboseBody {
+		truncated = fmt.Sprintf(" [%d more bytes]", len(data)-maxVerboseBody)
+		data = data[:maxVerboseBody]
+	}
+	body := sensitiveFields.ReplaceAllString(string(data), `$1"[REDACTED]"`)
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		fmt.Fprintf(out, "%s %s\n", prefix, line)
+	}
+	if truncated != "" {
+		fmt.Fprintf(out, "%s%s\n", prefix, truncated)
+	}
+}
+
+// verbosity returns the flag.BoolFunc of a -v flag raising the level
+func verbosity(level *int, to int) func(string) error {
+	return func(string) error {
+		if *level < to {
+			*level = to
+		}
+		return nil
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrintHeadersRedacted(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{
+			name:   "authorization",
+			header: http.Header{"Authorization": {"Bearer secret-token"}, "Accept": {"application/json"}},
+			want:   "> Accept: application/json\n> Authorization: [REDACTED]\n",
+		},
+		{
+			name:   "proxy authorization",
+			header: http.Header{"Proxy-Authorization": {"Basic dXNlcjpwYXNz"}},
+			want:   "> Proxy-Authorization: [REDACTED]\n",
+		},
+		{
+			name:   "every cookie",
+			header: http.Header{"Set-Cookie": {"session=1", "theme=dark"}},
+			want:   "> Set-Cookie: [REDACTED]\n> Set-Cookie: [REDACTED]\n",
+		},
+		{
+			name:   "other headers",
+			header: http.Header{"X-Request-Id": {"1"}, "Content-Type": {"application/json"}},
+			want:   "> Content-Type: application/json\n> X-Request-Id: 1\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printHeaders(&out, ">", test.header)
+			if out.String() != test.want {
+				t.Errorf("Expected %q, got %q", test.want, out.String())
+			}
+		})
+	}
+}
+
+func TestPrintBodyRedacted(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "password", body: `{"password":"secret"}`, want: `> {"password":"[REDACTED]"}` + "\n"},
+		{name: "token", body: `{"token": "eyJhbGciOi.x.y", "page":"login"}`, want: `> {"token": "[REDACTED]", "page":"login"}` + "\n"},
+		{name: "escaped quote", body: `{"password":"se\"cret","user":"a"}`, want: `> {"password":"[REDACTED]","user":"a"}` + "\n"},
+		{name: "other fields", body: "{\"words\":[\"password\"]}\n", want: "> {\"words\":[\"password\"]}\n"},
+		{name: "empty", body: "", want: ""},
+		{name: "truncated", body: strings.Repeat("a", maxVerboseBody+10), want: "> " + strings.Repeat("a", maxVerboseBody) + "\n> [10 more bytes]\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printBody(&out, ">", []byte(test.body))
+			if out.String() != test.want {
+				t.Errorf("Expected %q, got %q", test.want, out.String())
+			}
+		})
+	}
+}
+
+func TestVerboseTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"token":"new-token"}`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		level    int
+		contains []string
+		excludes []string
+	}{
+		{
+			level:    verboseHeaders,
+			contains: []string{"> POST /login HTTP/1.1\n", "> Authorization: [REDACTED]\n", "< HTTP/1.1 200 OK\n", "< Set-Cookie: [REDACTED]\n", "* dns -, connect "},
+			excludes: []string{"password", "token", "Bearer old-token"},
+		},
+		{
+			level:    verboseBodies,
+			contains: []string{`> {"password":"[REDACTED]"}`, `< {"token":"[REDACTED]"}`},
+			excludes: []string{"secret", "new-token", "old-token"},
+		},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		client := &http.Client{Transport: verboseTransport{transport: http.DefaultTransport, level: test.level, out: &out}}
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/login", strings.NewReader(`{"password":"secret"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer old-token")
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do error: %s", err)
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		// the caller still reads the whole body, which -vv has read already
+		if err != nil || string(body) != `{"token":"new-token"}` {
+			t.Errorf("level %d: expected the body, got %q (%v)", test.level, body, err)
+		}
+
+		for _, s := range test.contains {
+			if !strings.Contains(out.String(), s) {
+				t.Errorf("level %d: expected %q in\n%s", test.level, s, out.String())
+			}
+		}
+		for _, s := range test.excludes {
+			if strings.Contains(out.String(), s) {
+				t.Errorf("level %d: expected no %q in\n%s", test.level, s, out.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"wordsclient"
+)
+
+// watchOptions are the -watch, -interval and -until flags
+type watchOptions struct {
+	enabled  bool
+	interval time.Duration
+	until    string
+}
+
+// watch sends the request every interval and prints the response to w whenever it changes,
+// until the response contains the -until text or Ctrl+C is pressed.
+// Failed requests are reported and retried at the next interval: poll until ready.
+func watch(ctx context.Context, w io.Writer, options globalOptions, apiReq wordsclient.Request) error {
+	ticker := time.NewTicker(options.watch.interval)
+	defer ticker.Stop()
+
+	var (
+		previous    []byte
+		previousErr string
+	)
+	for {
+		body, err := doAuthenticatedRequest(ctx, options, apiReq)
+		if ctx.Err() != nil {
+			// Ctrl+C ends the watch, it isn't an error
+			return nil
+		}
+		stamp := time.Now().Format("15:04:05")
+
+		switch {
+		case err != nil:
+			if err.Error() != previousErr {
+				fmt.Fprintf(os.Stderr, "[%s] ", stamp)
+				printError(err)
+			}
+			previousErr = err.Error()
+		case !bytes.Equal(body, previous):
+			fmt.Fprintf(w, "[%s] ", stamp)
+			if options.save.enabled() {
+				// with a {{.Timestamp}} in -output-file every change is kept in its own file
+				name, err := saveResponse(options, apiReq.URL, body)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "response saved to %s\n", name)
+			} else if err := printChange(w, options, previous, body); err != nil {
+				return err
+			}
+			previous, previousErr = body, ""
+		}
+
+		if err == nil && options.watch.until != "" && bytes.Contains(body, []byte(options.watch.until)) {
+			fmt.Fprintf(w, "[%s] response contains %q\n", stamp, options.watch.until)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// printChange prints the new response, or only what changed for occurrence counts
+func printChange(w io.Writer, options globalOptions, previous, body []byte) error {
+	if previous != nil && options.output == outputText {
+		oldRes, oldErr := wordsclient.ParseResponse(previous)
+		newRes, newErr := wordsclient.ParseResponse(body)
+		oldOccurrence, oldOk := oldRes.(wordsclient.Occurrence)
+		newOccurrence, newOk := newRes.(wordsclient.Occurrence)
+		if oldErr == nil && newErr == nil && oldOk && newOk {
+			fmt.Fprintln(w, "occurrence changed:")
+			printOccurrenceDiff(w, oldOccurrence, newOccurrence)
+			return nil
+		}
+	}
+	if previous != nil {
+		fmt.Fprintln(w, "response changed:")
+	} else {
+		fmt.Fprintln(w, "response:")
+	}
+	return printResponse(w, options, body, stdoutColors)
+}
+
+// printOccurrenceDiff prints the added (+), removed (-) and changed (~) counts, sorted by word
+func printOccurrenceDiff(w io.Writer, old, new wordsclient.Occurrence) {
+	words := map[string]bool{}
+	for word := range old.Words {
+		words[word] = true
+	}
+	for word := range new.Words {
+		words[word] = true
+	}
+	sorted := make([]string, 0, len(words))
+	for word := range words {
+		sorted = append(sorted, word)
+	}
+	sort.Strings(sorted)
+
+	for _, word := range sorted {
+		oldCount, inOld := old.Words[word]
+		newCount, inNew := new.Words[word]
+		switch {
+		case !inOld:
+			fmt.Fprintln(w, stdoutColors.green(fmt.Sprintf("  + %s: %d", word, newCount)))
+		case !inNew:
+			fmt.Fprintln(w, stdoutColors.red(fmt.Sprintf("  - %s: %d", word, oldCount)))
+		case oldCount != newCount:
+			fmt.Fprintln(w, stdoutColors.yellow(fmt.Sprintf("  ~ %s: %d -> %d (%+d)", word, oldCount, newCount, newCount-oldCount)))
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wordsclient"
+)
+
+// newOccurrenceServer answers the first request with a 503, like a server that is
+// starting, then counts the requests in the occurrence of a. b appears with the
+// fourth request.
+func newOccurrenceServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		switch {
+		case n == 1:
+			http.Error(w, "starting", http.StatusServiceUnavailable)
+		case n < 4:
+			fmt.Fprintf(w, `{"page":"occurrence","words":{"a":%d,"c":1}}`, n-1)
+		default:
+			fmt.Fprint(w, `{"page":"occurrence","words":{"a":3,"b":1}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestWatchUntil(t *testing.T) {
+	server, requests := newOccurrenceServer(t)
+	options := globalOptions{
+		baseURL: server.URL,
+		output:  outputText,
+		timeout: 5 * time.Second,
+		watch:   watchOptions{enabled: true, interval: 10 * time.Millisecond, until: `"b"`},
+	}
+
+	var out bytes.Buffer
+	if err := watch(context.Background(), &out, options, wordsclient.Request{Method: http.MethodGet, URL: server.URL + "/occurrence"}); err != nil {
+		t.Fatalf("watch error: %s", err)
+	}
+	if requests.Load() != 4 {
+		t.Errorf("Expected 4 requests, got %d", requests.Load())
+	}
+	// the failed request is retried, then the changes are printed until b appears
+	for _, s := range []string{
+		"] response:\n",
+		"] occurrence changed:\n  ~ a: 1 -> 2 (+1)\n",
+		"] occurrence changed:\n  ~ a: 2 -> 3 (+1)\n  + b: 1\n  - c: 1\n",
+		"] response contains \"\\\"b\\\"\"\n",
+	} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("Expected %q in\n%s", s, out.String())
+		}
+	}
+}
+
+func TestWatchUnchanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"page":"words","input":"","words":["a"]}`)
+	}))
+	defer server.Close()
+	options := globalOptions{
+		baseURL: server.URL,
+		output:  outputJSON,
+		timeout: 5 * time.Second,
+		watch:   watchOptions{enabled: true, interval: 10 * time.Millisecond},
+	}
+
+	// Ctrl+C ends the watch without an error
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	if err := watch(ctx, &out, options, wordsclient.Request{Method: http.MethodGet, URL: server.URL + "/words"}); err != nil {
+		t.Fatalf("watch error: %s", err)
+	}
+	// the same response is printed once
+	if strings.Count(out.String(), "response") != 1 || strings.Contains(out.String(), "changed") {
+		t.Errorf("Expected the response once, got\n%s", out.String())
+	}
+}
+
+func TestWatchSave(t *testing.T) {
+	server, _ := newOccurrenceServer(t)
+	dir := t.TempDir()
+	options := globalOptions{
+		baseURL: server.URL,
+		output:  outputJSON,
+		timeout: 5 * time.Second,
+		watch:   watchOptions{enabled: true, interval: 10 * time.Millisecond, until: `"b"`},
+		save:    saveOptions{dir: dir},
+	}
+
+	var out bytes.Buffer
+	if err := watch(context.Background(), &out, options, wordsclient.Request{Method: http.MethodGet, URL: server.URL + "/occurrence"}); err != nil {
+		t.Fatalf("watch error: %s", err)
+	}
+	name := filepath.Join(dir, strings.ReplaceAll(strings.TrimPrefix(server.URL, "http://"), ":", "_"), "occurrence.json")
+	if strings.Count(out.String(), "response saved to "+name) != 3 {
+		t.Errorf("Expected the 3 changes to be saved to %s, got\n%s", name, out.String())
+	}
+	// the file has the last response
+	data, err := os.ReadFile(name)
+	if err != nil || !strings.Contains(string(data), `"b": 1`) {
+		t.Errorf("Expected the last response in the file, got %s (%v)", data, err)
+	}
+}
+
+func TestPrintOccurrenceDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new map[string]int
+		want     string
+	}{
+		{name: "unchanged", old: map[string]int{"a": 1}, new: map[string]int{"a": 1}, want: ""},
+		{name: "added", old: map[string]int{}, new: map[string]int{"a": 1}, want: "  + a: 1\n"},
+		{name: "removed", old: map[string]int{"a": 1}, new: map[string]int{}, want: "  - a: 1\n"},
+		{name: "decreased", old: map[string]int{"a": 3}, new: map[string]int{"a": 1}, want: "  ~ a: 3 -> 1 (-2)\n"},
+		{name: "sorted by word", old: map[string]int{"b": 1, "c": 1}, new: map[string]int{"a": 1, "b": 2}, want: "  + a: 1\n  ~ b: 1 -> 2 (+1)\n  - c: 1\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printOccurrenceDiff(&out, wordsclient.Occurrence{Words: test.old}, wordsclient.Occurrence{Words: test.new})
+			if out.String() != test.want {
+				t.Errorf("Expected %q, got %q", test.want, out.String())
+			}
+		})
+	}
+}
@@ -1,42 +1,220 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
+// headerFlags collects the repeated -H flags
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("header %q is not in the format 'Name: value'", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+// httpGet is a minimal curl: it sends a request to the url and prints the response
+//
+//	./api-client -X POST -H 'Content-Type: application/json' -d '{"password":"secret"}' -i http://localhost:8080/login
 func httpGet() {
-	args := os.Args
+	var headers headerFlags
+	flags := flag.NewFlagSet("api-client", flag.ExitOnError)
+	method := flags.String("X", http.MethodGet, "request method")
+	flags.Var(&headers, "H", "request header 'Name: value', can be repeated")
+	data := flags.String("d", "", "request body, or @file to read it from a file")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of the whole request, 0 for none; with -download only of the connection and the response headers")
+	output := flags.String("o", "", "write the body to this file instead of stdout")
+	include := flags.Bool("i", false, "include the status line and response headers in the output")
+	timing := flags.Bool("timing", false, "print the timings of the request to stderr")
+	tlsInfo := flags.Bool("tls-info", false, "print the TLS version, cipher suite and certificates of an https url to stderr")
+	redirects := &redirectPolicy{}
+	flags.BoolVar(&redirects.follow, "follow", true, "follow redirects, printing the redirect chain to stderr")
+	flags.IntVar(&redirects.max, "max-redirects", 10, "maximum number of redirects to follow")
+	downloadMode := flags.Bool("download", false, "download the body to the -o file, or the file name of the url, with a progress bar")
+	resume := flags.Bool("resume", false, "with -download, continue a partial download")
+	checksum := flags.String("sha256", "", "with -download, the expected SHA-256 checksum of the file")
+	checkFile := flags.String("check", "", "check the urls in this file, one per line, instead of requesting a url")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ./api-client [flags] <url>\n       ./api-client -check <file> [-timeout <duration>] [-H <header>]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
 
-	if len(args) < 2 {
-		fmt.Printf("Usage: ./api-client <url>\n")
+	if *checkFile != "" {
+		urls, err := readURLs(*checkFile)
+		if err != nil {
+			log.Fatalf("Failed to read the urls: %s\n", err)
+		}
+		// -timeout applies to every url on its own
+		// the checks run concurrently, so they don't record the redirect chain
+		results := checkURLs(&http.Client{CheckRedirect: redirects.limit}, urls, headers, *timeout)
+		if down := printHealthChecks(os.Stdout, results); down > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
 		os.Exit(1)
 	}
-	if _, err := url.ParseRequestURI(args[1]); err != nil {
+	requestURL := flags.Arg(0)
+	if _, err := url.ParseRequestURI(requestURL); err != nil {
 		fmt.Printf("URL is in invalid format: %s\n", err)
 		os.Exit(1)
 	}
 
-	response, err := http.Get(args[1])
+	// a download may take longer than any timeout, only the connection and the
+	// response headers are limited then
+	ctx, cancel := withTimeout(context.Background(), *timeout)
+	if *downloadMode {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+	t := &timings{start: time.Now()}
+	ctx = httptrace.WithClientTrace(ctx, t.trace())
+
+	request, err := newRequest(ctx, *method, requestURL, *data, headers)
 	if err != nil {
-		log.Fatalf("Failed to make HTTP GET request: %s\n", err)
+		log.Fatalf("Failed to create the request: %s\n", err)
 	}
-	defer response.Body.Close()
 
-	body, err := io.ReadAll(response.Body)
+	client := &http.Client{CheckRedirect: redirects.checkRedirect}
+	if *downloadMode {
+		client.Transport = downloadTransport(*timeout)
+		options := downloadOptions{output: *output, resume: *resume, sha256: *checksum}
+		if options.output == "" {
+			options.output = downloadFileName(requestURL)
+		}
+		err := download(client, request, options)
+		printRedirects(os.Stderr, redirects.hops)
+		if err != nil {
+			log.Fatalf("%s\n", err)
+		}
+		t.done = time.Now()
+		if *timing {
+			fmt.Fprintf(os.Stderr, "%s\n", t)
+		}
+		return
+	}
 
+	response, err := client.Do(request)
+	printRedirects(os.Stderr, redirects.hops)
 	if err != nil {
+		log.Fatalf("Failed to make HTTP %s request: %s\n", request.Method, err)
+	}
+	defer response.Body.Close()
+
+	if *tlsInfo {
+		printTLSInfo(os.Stderr, response.TLS)
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create the output file: %s\n", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if err := writeResponse(out, response, *include); err != nil {
 		log.Fatalf("Failed to read response body: %s\n", err)
 	}
+	t.done = time.Now()
 
-	if response.StatusCode != http.StatusOK {
-		log.Fatalf("Received non-200 response: %d\n", response.StatusCode)
+	if *timing {
+		fmt.Fprintf(os.Stderr, "%s\n", t)
 	}
+	if response.StatusCode >= 400 {
+		// the body is printed anyway, like curl --fail-with-body
+		fmt.Fprintf(os.Stderr, "Received HTTP %d response\n", response.StatusCode)
+		os.Exit(1)
+	}
+}
+
+// newRequest creates the request of the flags. The data is the body, or @file to
+// read it from a file; like curl, a body makes a GET a POST.
+func newRequest(ctx context.Context, method, requestURL, data string, headers headerFlags) (*http.Request, error) {
+	var body io.Reader
+	if data != "" {
+		content := []byte(data)
+		if name, ok := strings.CutPrefix(data, "@"); ok {
+			var err error
+			if content, err = os.ReadFile(name); err != nil {
+				return nil, fmt.Errorf("reading the request body: %w", err)
+			}
+		}
+		body = bytes.NewReader(content)
+		if method == http.MethodGet {
+			method = http.MethodPost
+		}
+	}
+	request, err := http.NewRequestWithContext(ctx, method, requestURL, body)
+	if err != nil {
+		return nil, err
+	}
+	addHeaders(request, headers)
+	return request, nil
+}
 
-	// Process the response...
-	fmt.Printf("HTTP Status Code: %d\nBody: %v\n", response.StatusCode, string(body))
+// addHeaders adds the -H headers to the request
+func addHeaders(request *http.Request, headers headerFlags) {
+	for _, header := range headers {
+		name, value, _ := strings.Cut(header, ":")
+		request.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+}
+
+// writeResponse writes the body of the response, after its status line and headers
+// with include
+func writeResponse(w io.Writer, response *http.Response, include bool) error {
+	if include {
+		printHeaders(w, response)
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+// withTimeout is context.WithTimeout, where 0 is no timeout
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// printHeaders prints the status line and the headers, sorted by name
+func printHeaders(w io.Writer, response *http.Response) {
+	fmt.Fprintf(w, "%s %s\n", response.Proto, response.Status)
+	names := make([]string, 0, len(response.Header))
+	for name := range response.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range response.Header[name] {
+			fmt.Fprintf(w, "%s: %s\n", name, value)
+		}
+	}
+	fmt.Fprintln(w)
 }
@@ -1,23 +1,16 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 	"os"
 	"strings"
+
+	"wordsclient"
 )
 
 // {"page":"words","input":"word1","words":["word1"]}
-type Words struct {
-	Page  string   `json:"page"`
-	Input string   `json:"input"`
-	Words []string `json:"words"`
-}
-
 func fetchWordsFromAPI() {
 	args := os.Args
 
@@ -25,34 +18,24 @@ func fetchWordsFromAPI() {
 		fmt.Printf("Usage: ./api-client <url>\n")
 		os.Exit(1)
 	}
-	if _, err := url.ParseRequestURI(args[1]); err != nil {
-		fmt.Printf("URL is in invalid format: %s\n", err)
-		os.Exit(1)
-	}
 
-	response, err := http.Get(args[1])
+	words, err := fetchWords(context.Background(), wordsclient.New(wordsclient.Options{}), args[1])
 	if err != nil {
-		log.Fatalf("Failed to make HTTP GET request: %s\n", err)
+		log.Fatalf("Failed to get the words: %s\n", err)
 	}
-	defer response.Body.Close()
 
-	body, err := io.ReadAll(response.Body)
+	fmt.Printf("JSON Parsed:\nPage: words\nWords: %s\n", strings.Join(words, ", "))
+}
 
+// fetchWords returns the words of the words page at the url
+func fetchWords(ctx context.Context, client *wordsclient.Client, url string) ([]string, error) {
+	res, err := client.Get(ctx, url)
 	if err != nil {
-		log.Fatalf("Failed to read response body: %s\n", err)
+		return nil, err
 	}
-
-	if response.StatusCode != http.StatusOK {
-		log.Fatalf("Received (HTTP Code %d) response: %s\n", response.StatusCode, body)
-	}
-
-	// Process the response...
-	var words Words
-
-	err = json.Unmarshal(body, &words)
-	if err != nil {
-		log.Fatalf("Failed to unmarshal JSON response: %s\n", err)
+	words, ok := res.(wordsclient.Words)
+	if !ok {
+		return nil, fmt.Errorf("%s is not the words page", url)
 	}
-
-	fmt.Printf("JSON Parsed:\nPage: %s\nWords: %s\n", words.Page, strings.Join(words.Words, ", "))
+	return words.Words, nil
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wordsclient"
+)
+
+func TestRequestRoundTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Method", r.Method)
+		fmt.Fprintf(w, `{"contentType":%q,"body":%q}`, r.Header.Get("Content-Type"), body)
+	}))
+	defer server.Close()
+
+	// -d @file makes it a POST, with the -H headers
+	file := filepath.Join(t.TempDir(), "login.json")
+	os.WriteFile(file, []byte(`{"password":"secret"}`), 0644)
+	var headers headerFlags
+	if err := headers.Set("Content-Type:  application/json "); err != nil {
+		t.Fatal(err)
+	}
+	request, err := newRequest(context.Background(), http.MethodGet, server.URL+"/login", "@"+file, headers)
+	if err != nil {
+		t.Fatalf("newRequest error: %s", err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Do error: %s", err)
+	}
+	defer response.Body.Close()
+
+	var out bytes.Buffer
+	if err := writeResponse(&out, response, true); err != nil {
+		t.Fatalf("writeResponse error: %s", err)
+	}
+	expected := `{"contentType":"application/json","body":"{\"password\":\"secret\"}"}`
+	if !strings.HasPrefix(out.String(), "HTTP/1.1 200 OK\n") || !strings.Contains(out.String(), "X-Method: POST\n") || !strings.HasSuffix(out.String(), "\n\n"+expected) {
+		t.Errorf("Expected the status line, the headers and the body, got\n%s", out.String())
+	}
+}
+
+func TestNewRequestErrors(t *testing.T) {
+	if _, err := newRequest(context.Background(), http.MethodPost, "http://localhost", "@missing.json", nil); err == nil || !strings.Contains(err.Error(), "reading the request body") {
+		t.Errorf("Expected an error for a missing body file, got %v", err)
+	}
+	var headers headerFlags
+	if err := headers.Set("no colon"); err == nil {
+		t.Error("Expected an error for a header without a colon")
+	}
+}
+
+func TestFetchWords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/occurrence" {
+			fmt.Fprint(w, `{"page":"occurrence","words":{"word1":1}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"page":"words","input":%q,"words":["word0",%q]}`, r.URL.Query().Get("input"), r.URL.Query().Get("input"))
+	}))
+	defer server.Close()
+	client := wordsclient.New(wordsclient.Options{})
+
+	words, err := fetchWords(context.Background(), client, server.URL+"/words?input=word1")
+	if err != nil || strings.Join(words, ",") != "word0,word1" {
+		t.Errorf("Expected word0 and word1, got %v, %v", words, err)
+	}
+	if _, err := fetchWords(context.Background(), client, server.URL+"/occurrence"); err == nil || !strings.Contains(err.Error(), "is not the words page") {
+		t.Errorf("Expected an error for the occurrence page, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// downloadOptions are the flags of the download mode
+type downloadOptions struct {
+	output string // the file to write, defaults to the last element of the url path
+	resume bool   // continue a partial download with a Range request
+	sha256 string // the expected checksum in hex, if set
+}
+
+// downloadFileName returns the last element of the url path, like curl -O,
+// or "download" for urls without a path
+func downloadFileName(requestURL string) string {
+	parsed, err := url.Parse(requestURL)
+	if err != nil {
+		return "download"
+	}
+	name := path.Base(parsed.Path)
+	if name == "." || name == "/" {
+		return "download"
+	}
+	return name
+}
+
+// downloadTransport limits the time to connect and to get the response headers,
+// but not the time to read the body. 0 is no limit.
+func downloadTransport(timeout time.Duration) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: timeout}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+	return transport
+}
+
+// validatorFile stores the ETag or Last-Modified of a partial download, so a resume
+// only continues the file when it didn't change on the server
+func validatorFile(output string) string {
+	return output + ".validator"
+}
+
+// download streams the body of the request to a file with a progress bar on stderr.
+// With resume, the bytes already in the file aren't requested again, as long as the
+// file on the server is the same as when the download started.
+func download(client *http.Client, request *http.Request, options downloadOptions) error {
+	var offset int64
+	if options.resume {
+		if info, err := os.Stat(options.output); err == nil {
+			offset = info.Size()
+		}
+	}
+	if offset > 0 {
+		validator, err := os.ReadFile(validatorFile(options.output))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s has no ETag or Last-Modified to check that the file didn't change, starting over\n", options.output)
+			offset = 0
+		} else {
+			request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			// the server sends the whole file instead of the range when it changed
+			request.Header.Set("If-Range", strings.TrimSpace(string(validator)))
+		}
+	}
+	// ranges are offsets in the encoded body, so ask for the file as it is
+	request.Header.Set("Accept-Encoding", "identity")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer response.Body.Close()
+
+	checksum := sha256.New()
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the file is already complete
+		fmt.Fprintf(os.Stderr, "%s is already downloaded\n", options.output)
+		os.Remove(validatorFile(options.output))
+		if options.sha256 == "" {
+			return nil
+		}
+		if err := hashFile(checksum, options.output); err != nil {
+			return err
+		}
+		return verifyChecksum(options, checksum)
+	case response.StatusCode == http.StatusPartialContent && offset > 0:
+		if start, err := contentRangeStart(response.Header.Get("Content-Range")); err != nil || start != offset {
+			return fmt.Errorf("download failed: the server sent Content-Range %q for a resume at byte %d", response.Header.Get("Content-Range"), offset)
+		}
+		fmt.Fprintf(os.Stderr, "Resuming %s at %s\n", options.output, formatBytes(offset))
+		flags = os.O_WRONLY | os.O_APPEND
+		if options.sha256 != "" {
+			// the checksum covers the bytes downloaded before as well
+			if err := hashFile(checksum, options.output); err != nil {
+				return err
+			}
+		}
+	case response.StatusCode == http.StatusOK:
+		if offset > 0 {
+			fmt.Fprintf(os.Stderr, "The file changed on the server or it doesn't support ranges, starting over\n")
+		}
+		offset = 0
+		if err := writeValidator(options.output, response.Header); err != nil {
+			return err
+		}
+	default:
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("download failed: HTTP %d: %s", response.StatusCode, body)
+	}
+
+	file, err := os.OpenFile(options.output, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("can't open the output file: %w", err)
+	}
+	defer file.Close()
+
+	total := int64(-1)
+	if response.ContentLength >= 0 {
+		total = offset + response.ContentLength
+	}
+	progress := &progressWriter{written: offset, total: total}
+	_, err = io.Copy(io.MultiWriter(file, checksum, progress), response.Body)
+	progress.finish()
+	if err != nil {
+		return fmt.Errorf("download interrupted after %s, continue with -resume: %w", formatBytes(progress.written), err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("can't write the output file: %w", err)
+	}
+	os.Remove(validatorFile(options.output))
+	return verifyChecksum(options, checksum)
+}
+
+// writeValidator stores the ETag, or else the Last-Modified date, of a new download.
+// Without either, a partial download can't be resumed safely.
+func writeValidator(output string, header http.Header) error {
+	validator := header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		// If-Range only works with a strong ETag
+		validator = header.Get("Last-Modified")
+	}
+	if validator == "" {
+		os.Remove(validatorFile(output))
+		return nil
+	}
+	if err := os.WriteFile(validatorFile(output), []byte(validator+"\n"), 0644); err != nil {
+		return fmt.Errorf("can't write the validator file: %w", err)
+	}
+	return nil
+}
+
+// contentRangeStart returns the first byte of a Content-Range like "bytes 100-199/200"
+func contentRangeStart(contentRange string) (int64, error) {
+	byteRange, ok := strings.CutPrefix(contentRange, "bytes ")
+	start, _, found := strings.Cut(byteRange, "-")
+	if !ok || !found {
+		return 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	return strconv.ParseInt(start, 10, 64)
+}
+
+// hashFile adds the content of the file to the checksum
+func hashFile(checksum hash.Hash, name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("can't read the partial download: %w", err)
+	}
+	defer file.Close()
+	_, err = io.Copy(checksum, file)
+	return err
+}
+
+// verifyChecksum compares the checksum with the expected one, if any
+func verifyChecksum(options downloadOptions, checksum hash.Hash) error {
+	if options.sha256 == "" {
+		return nil
+	}
+	actual := hex.EncodeToString(checksum.Sum(nil))
+	if !strings.EqualFold(actual, options.sha256) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", options.output, options.sha256, actual)
+	}
+	fmt.Fprintf(os.Stderr, "Checksum OK: %s\n", actual)
+	return nil
+}
+
+// progressWriter prints a progress bar to stderr, at most every 100ms
+type progressWriter struct {
+	written, total int64
+	lastPrint      time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if time.Since(p.lastPrint) >= 100*time.Millisecond {
+		p.print()
+	}
+	return len(b), nil
+}
+
+func (p *progressWriter) print() {
+	p.lastPrint = time.Now()
+	if p.total <= 0 {
+		fmt.Fprintf(os.Stderr, "\r%s", formatBytes(p.written))
+		return
+	}
+	const width = 30
+	done := int(width * p.written / p.total)
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %3d%% %s/%s", strings.Repeat("=", done), strings.Repeat(" ", width-done),
+		100*p.written/p.total, formatBytes(p.written), formatBytes(p.total))
+}
+
+// finish prints the final state of the progress bar
+func (p *progressWriter) finish() {
+	p.print()
+	fmt.Fprintln(os.Stderr)
+}
+
+// formatBytes formats a size in B, KiB, MiB or GiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exponent := float64(n)/unit, 0
+	for value >= unit && exponent < 2 {
+		value /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMG"[exponent])
+}
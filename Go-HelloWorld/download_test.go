@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var downloadContent = []byte(strings.Repeat("0123456789", 100))
+
+// newFileServer serves downloadContent with the ETag "v1" and ranges, recording the Range
+// and If-Range headers of the requests
+func newFileServer(t *testing.T) (*httptest.Server, *[]string) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, fmt.Sprintf("Range=%s If-Range=%s", r.Header.Get("Range"), r.Header.Get("If-Range")))
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(downloadContent))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// startDownload downloads url to output
+func startDownload(t *testing.T, url string, options downloadOptions) error {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return download(http.DefaultClient, request, options)
+}
+
+func checkFile(t *testing.T, name string, expected []byte) {
+	t.Helper()
+	actual, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("Expected %d bytes of the content, got %d bytes: %.40q", len(expected), len(actual), actual)
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	// the first download is interrupted after 100 bytes
+	interrupted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", fmt.Sprint(len(downloadContent)))
+		w.Write(downloadContent[:100])
+		w.(http.Flusher).Flush()
+		conn, buf, _ := w.(http.Hijacker).Hijack()
+		buf.Flush()
+		conn.Close()
+	}))
+	defer interrupted.Close()
+	output := filepath.Join(t.TempDir(), "file.bin")
+	err := startDownload(t, interrupted.URL, downloadOptions{output: output})
+	if err == nil || !strings.Contains(err.Error(), "continue with -resume") {
+		t.Fatalf("Expected the download to be interrupted, got %v", err)
+	}
+	checkFile(t, output, downloadContent[:100])
+	if validator, _ := os.ReadFile(validatorFile(output)); string(validator) != "\"v1\"\n" {
+		t.Fatalf("Expected the ETag in the validator file, got %q", validator)
+	}
+
+	// the resume only requests the rest, if the file is still the same
+	server, requests := newFileServer(t)
+	sum := sha256.Sum256(downloadContent)
+	if err := startDownload(t, server.URL, downloadOptions{output: output, resume: true, sha256: hex.EncodeToString(sum[:])}); err != nil {
+		t.Fatalf("download error: %s", err)
+	}
+	if expected := `Range=bytes=100- If-Range="v1"`; (*requests)[0] != expected {
+		t.Errorf("Expected %s, got %s", expected, (*requests)[0])
+	}
+	checkFile(t, output, downloadContent)
+	if _, err := os.Stat(validatorFile(output)); !os.IsNotExist(err) {
+		t.Errorf("Expected the validator file to be removed, got %v", err)
+	}
+
+	// the file is complete, the server answers 416 and the checksum is verified
+	// again
+	os.WriteFile(validatorFile(output), []byte("\"v1\"\n"), 0644)
+	if err := startDownload(t, server.URL, downloadOptions{output: output, resume: true, sha256: hex.EncodeToString(sum[:])}); err != nil {
+		t.Errorf("Expected the complete file to be verified, got %v", err)
+	}
+	if expected := `Range=bytes=1000- If-Range="v1"`; (*requests)[1] != expected {
+		t.Errorf("Expected %s, got %s", expected, (*requests)[1])
+	}
+	checkFile(t, output, downloadContent)
+}
+
+func TestDownloadFileChanged(t *testing.T) {
+	server, requests := newFileServer(t)
+	output := filepath.Join(t.TempDir(), "file.bin")
+	os.WriteFile(output, []byte("old content"), 0644)
+	os.WriteFile(validatorFile(output), []byte("\"v0\"\n"), 0644)
+
+	// If-Range doesn't match, the server sends the whole file, which replaces the
+	// old part
+	if err := startDownload(t, server.URL, downloadOptions{output: output, resume: true}); err != nil {
+		t.Fatalf("download error: %s", err)
+	}
+	if expected := `Range=bytes=11- If-Range="v0"`; (*requests)[0] != expected {
+		t.Errorf("Expected %s, got %s", expected, (*requests)[0])
+	}
+	checkFile(t, output, downloadContent)
+}
+
+func TestDownloadRangeIgnored(t *testing.T) {
+	// the server ignores Range and sends 200 with the whole file
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(downloadContent)
+	}))
+	defer server.Close()
+	output := filepath.Join(t.TempDir(), "file.bin")
+	os.WriteFile(output, downloadContent[:100], 0644)
+	os.WriteFile(validatorFile(output), []byte("\"v1\"\n"), 0644)
+
+	if err := startDownload(t, server.URL, downloadOptions{output: output, resume: true}); err != nil {
+		t.Fatalf("download error: %s", err)
+	}
+	// truncated and written again, not appended
+	checkFile(t, output, downloadContent)
+}
+
+func TestDownloadWrongContentRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(downloadContent)-1, len(downloadContent)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(downloadContent)
+	}))
+	defer server.Close()
+	output := filepath.Join(t.TempDir(), "file.bin")
+	os.WriteFile(output, downloadContent[:100], 0644)
+	os.WriteFile(validatorFile(output), []byte("\"v1\"\n"), 0644)
+
+	err := startDownload(t, server.URL, downloadOptions{output: output, resume: true})
+	if err == nil || !strings.Contains(err.Error(), "for a resume at byte 100") {
+		t.Errorf("Expected a Content-Range error, got %v", err)
+	}
+	checkFile(t, output, downloadContent[:100])
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	server, _ := newFileServer(t)
+	output := filepath.Join(t.TempDir(), "file.bin")
+	sum := sha256.Sum256([]byte("other content"))
+	err := startDownload(t, server.URL, downloadOptions{output: output, sha256: hex.EncodeToString(sum[:])})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestDownloadFileName(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/releases/file.tar.gz?v=1": "file.tar.gz",
+		"https://example.com/":                         "download",
+		"https://example.com":                          "download",
+	}
+	for url, expected := range tests {
+		if name := downloadFileName(url); name != expected {
+			t.Errorf("%s: expected %s, got %s", url, expected, name)
+		}
+	}
+}
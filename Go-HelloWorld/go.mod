@@ -1,3 +1,7 @@
 module hello-world
 
 go 1.24.2
+
+require wordsclient v0.0.0-00010101000000-000000000000
+
+replace wordsclient => ../pkg/wordsclient
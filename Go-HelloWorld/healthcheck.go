@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthCheck is the result of checking a url
+type healthCheck struct {
+	url        string
+	statusCode int // 0 if no response was received
+	latency    time.Duration
+	err        error
+}
+
+// up reports whether the url answered with a status code below 400
+func (h healthCheck) up() bool {
+	return h.err == nil && h.statusCode < 400
+}
+
+// readURLs reads one url per line, skipping empty lines and # comments
+func readURLs(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, scanner.Err()
+}
+
+// checkURLs sends a GET request to every url concurrently, each with its own timeout.
+// The results are in the order of the urls.
+func checkURLs(client *http.Client, urls []string, headers headerFlags, timeout time.Duration) []healthCheck {
+	results := make([]healthCheck, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			results[i] = checkURL(client, u, headers, timeout)
+		}(i, u)
+	}
+	wg.Wait()
+	return results
+}
+
+// checkURL sends a GET request and reads the body, so the latency includes the whole response
+func checkURL(client *http.Client, u string, headers headerFlags, timeout time.Duration) healthCheck {
+	result := healthCheck{url: u}
+	ctx, cancel := withTimeout(context.Background(), timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	addHeaders(request, headers)
+
+	start := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer response.Body.Close()
+	_, result.err = io.Copy(io.Discard, response.Body)
+	result.latency = time.Since(start)
+	result.statusCode = response.StatusCode
+	return result
+}
+
+// printHealthChecks prints a table of the results and returns how many urls are down
+func printHealthChecks(w io.Writer, results []healthCheck) int {
+	width := len("URL")
+	for _, result := range results {
+		if len(result.url) > width {
+			width = len(result.url)
+		}
+	}
+
+	down := 0
+	fmt.Fprintf(w, "%-*s  %-6s  %-4s  %s\n", width, "URL", "STATUS", "CODE", "LATENCY")
+	for _, result := range results {
+		status, code, latency := "up", fmt.Sprint(result.statusCode), result.latency.Round(time.Millisecond).String()
+		if !result.up() {
+			status = "down"
+			down++
+		}
+		if result.statusCode == 0 {
+			code = "-"
+		}
+		if result.err != nil {
+			latency = result.err.Error()
+		}
+		fmt.Fprintf(w, "%-*s  %-6s  %-4s  %s\n", width, result.url, status, code, latency)
+	}
+	fmt.Fprintf(w, "%d of %d up\n", len(results)-down, len(results))
+	return down
+}
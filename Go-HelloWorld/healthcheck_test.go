@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckURLsTransitions(t *testing.T) {
+	// the server is healthy until it's switched to 503, and healthy again after
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+	var headers headerFlags
+	if err := headers.Set("Authorization: Bearer token"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, step := range []struct {
+		status int
+		up     bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusServiceUnavailable, false},
+		{http.StatusOK, true},
+	} {
+		status.Store(int32(step.status))
+		results := checkURLs(http.DefaultClient, []string{server.URL}, headers, time.Second)
+		if results[0].statusCode != step.status || results[0].up() != step.up {
+			t.Errorf("Expected status %d and up %t, got %d and %t (%v)", step.status, step.up, results[0].statusCode, results[0].up(), results[0].err)
+		}
+	}
+
+	// without the headers, the server answers 401
+	if result := checkURL(http.DefaultClient, server.URL, nil, time.Second); result.up() || result.statusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without the headers, got %d", result.statusCode)
+	}
+}
+
+func TestCheckURLsTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+
+	// the urls are checked concurrently, the slow one doesn't delay the other
+	start := time.Now()
+	results := checkURLs(http.DefaultClient, []string{slow.URL, fast.URL}, nil, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the checks to stop after the timeout, took %s", elapsed)
+	}
+	if results[0].url != slow.URL || results[0].statusCode != 0 || !errors.Is(results[0].err, context.DeadlineExceeded) {
+		t.Errorf("Expected the slow url to time out, got %d, %v", results[0].statusCode, results[0].err)
+	}
+	if results[1].url != fast.URL || !results[1].up() {
+		t.Errorf("Expected the fast url to be up, got %d, %v", results[1].statusCode, results[1].err)
+	}
+
+	var out bytes.Buffer
+	if down := printHealthChecks(&out, results); down != 1 {
+		t.Errorf("Expected 1 url down, got %d", down)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if fields := strings.Fields(lines[1]); fields[1] != "down" || fields[2] != "-" {
+		t.Errorf("Expected the slow url down without a code, got %s", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); fields[1] != "up" || fields[2] != "200" {
+		t.Errorf("Expected the fast url up with 200, got %s", lines[2])
+	}
+	if lines[3] != "1 of 2 up" {
+		t.Errorf("Expected 1 of 2 up, got %s", lines[3])
+	}
+}
+
+func TestReadURLs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "urls.txt")
+	os.WriteFile(file, []byte("# services\nhttp://localhost:8080/health\n\n  http://localhost:8081/health  \n#http://localhost:8082\n"), 0644)
+	urls, err := readURLs(file)
+	expected := []string{"http://localhost:8080/health", "http://localhost:8081/health"}
+	if err != nil || !reflect.DeepEqual(urls, expected) {
+		t.Errorf("Expected %v, got %v, %v", expected, urls, err)
+	}
+	if _, err := readURLs(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
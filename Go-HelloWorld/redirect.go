@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// redirectHop is a redirect response on the way to the final url
+type redirectHop struct {
+	url      string
+	status   string
+	location string
+}
+
+// redirectPolicy decides whether the client follows redirects, and records the hops
+type redirectPolicy struct {
+	follow bool
+	max    int
+	hops   []redirectHop
+}
+
+// limit stops following redirects when -follow=false, or after max redirects
+func (p *redirectPolicy) limit(req *http.Request, via []*http.Request) error {
+	if !p.follow {
+		// return the redirect response itself
+		return http.ErrUseLastResponse
+	}
+	if len(via) > p.max {
+		return fmt.Errorf("stopped after %d redirects", p.max)
+	}
+	return nil
+}
+
+// checkRedirect is the CheckRedirect of http.Client: it records the redirect that
+// led to req, then applies the limit. Without -follow the redirect response is the
+// response, so there is no chain to record.
+func (p *redirectPolicy) checkRedirect(req *http.Request, via []*http.Request) error {
+	if p.follow && req.Response != nil {
+		p.hops = append(p.hops, redirectHop{
+			url:      via[len(via)-1].URL.String(),
+			status:   req.Response.Status,
+			location: req.Response.Header.Get("Location"),
+		})
+	}
+	return p.limit(req, via)
+}
+
+// printRedirects prints every hop of the redirect chain
+func printRedirects(w io.Writer, hops []redirectHop) {
+	for i, hop := range hops {
+		fmt.Fprintf(w, "Redirect %d: %s %s\n  Location: %s\n", i+1, hop.status, hop.url, hop.location)
+	}
+}
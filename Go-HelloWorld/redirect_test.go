@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newRedirectServer redirects /n to /n-1 until /0, which answers 200
+func newRedirectServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if n > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/%d", n-1), http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "done")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRedirectLimit(t *testing.T) {
+	server := newRedirectServer(t)
+
+	// max redirects are followed
+	redirects := &redirectPolicy{follow: true, max: 3}
+	client := &http.Client{CheckRedirect: redirects.checkRedirect}
+	response, err := client.Get(server.URL + "/3")
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || response.Request.URL.Path != "/0" {
+		t.Errorf("Expected 200 from /0, got %d from %s", response.StatusCode, response.Request.URL.Path)
+	}
+	if len(redirects.hops) != 3 {
+		t.Fatalf("Expected 3 hops, got %d", len(redirects.hops))
+	}
+	if hop := redirects.hops[0]; hop.url != server.URL+"/3" || hop.status != "302 Found" || hop.location != "/2" {
+		t.Errorf("Expected the first hop from /3 to /2, got %+v", hop)
+	}
+	var out bytes.Buffer
+	printRedirects(&out, redirects.hops)
+	if expected := "Redirect 3: 302 Found " + server.URL + "/1\n  Location: /0\n"; !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("Expected the last hop %q, got\n%s", expected, out.String())
+	}
+
+	// one more is an error
+	redirects = &redirectPolicy{follow: true, max: 3}
+	client = &http.Client{CheckRedirect: redirects.checkRedirect}
+	if _, err := client.Get(server.URL + "/4"); err == nil || !strings.Contains(err.Error(), "stopped after 3 redirects") {
+		t.Errorf("Expected the limit error, got %v", err)
+	}
+
+	// without follow, the redirect is the response and no hop is recorded
+	redirects = &redirectPolicy{follow: false, max: 3}
+	client = &http.Client{CheckRedirect: redirects.checkRedirect}
+	response, err = client.Get(server.URL + "/3")
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusFound || response.Header.Get("Location") != "/2" || len(redirects.hops) != 0 {
+		t.Errorf("Expected the 302 to /2 without hops, got %d to %s with %d hops", response.StatusCode, response.Header.Get("Location"), len(redirects.hops))
+	}
+}
+
+func TestRedirectAuthorization(t *testing.T) {
+	// target records the Authorization header it receives
+	var authorization []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+	}))
+	defer target.Close()
+	// localhost is another host than the 127.0.0.1 of target.URL
+	otherHost := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		location := target.URL + "/same"
+		if r.URL.Path == "/cross" {
+			location = otherHost + "/cross"
+		}
+		http.Redirect(w, r, location, http.StatusFound)
+	}))
+	defer redirector.Close()
+
+	var headers headerFlags
+	if err := headers.Set("Authorization: Bearer token"); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/same", "/cross"} {
+		request, err := newRequest(context.Background(), http.MethodGet, redirector.URL+path, "", headers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		redirects := &redirectPolicy{follow: true, max: 10}
+		response, err := (&http.Client{CheckRedirect: redirects.checkRedirect}).Do(request)
+		if err != nil {
+			t.Fatalf("%s: Do error: %s", path, err)
+		}
+		response.Body.Close()
+	}
+
+	// the redirect to the same host keeps the header, the one to another host drops it
+	if len(authorization) != 2 || authorization[0] != "Bearer token" || authorization[1] != "" {
+		t.Errorf("Expected the Authorization header only on the same host, got %q", authorization)
+	}
+}
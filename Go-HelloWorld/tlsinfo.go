@@ -0,0 +1,38 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// printTLSInfo prints the negotiated TLS parameters and the certificate chain the server sent
+func printTLSInfo(w io.Writer, state *tls.ConnectionState) {
+	if state == nil {
+		fmt.Fprintf(w, "TLS: none, the url is not https\n")
+		return
+	}
+	fmt.Fprintf(w, "TLS version: %s\n", tls.VersionName(state.Version))
+	fmt.Fprintf(w, "Cipher suite: %s\n", tls.CipherSuiteName(state.CipherSuite))
+	if state.NegotiatedProtocol != "" {
+		fmt.Fprintf(w, "ALPN protocol: %s\n", state.NegotiatedProtocol)
+	}
+
+	// the first certificate is the server's, followed by the intermediates
+	for i, cert := range state.PeerCertificates {
+		fmt.Fprintf(w, "Certificate %d:\n", i)
+		fmt.Fprintf(w, "  Subject: %s\n", cert.Subject)
+		fmt.Fprintf(w, "  Issuer: %s\n", cert.Issuer)
+		if len(cert.DNSNames) > 0 || len(cert.IPAddresses) > 0 {
+			sans := append([]string{}, cert.DNSNames...)
+			for _, ip := range cert.IPAddresses {
+				sans = append(sans, ip.String())
+			}
+			fmt.Fprintf(w, "  SANs: %s\n", strings.Join(sans, ", "))
+		}
+		days := int(time.Until(cert.NotAfter).Hours() / 24)
+		fmt.Fprintf(w, "  Expires: %s (%d days)\n", cert.NotAfter.Format(time.DateOnly), days)
+	}
+}
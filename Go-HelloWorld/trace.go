@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"time"
+)
+
+// timings are collected with httptrace while a request is sent
+type timings struct {
+	start, dnsStart, dnsDone, connectStart, connectDone, tlsStart, tlsDone, firstByte, done time.Time
+}
+
+func (t *timings) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.dnsDone = time.Now() },
+		ConnectStart:         func(string, string) { t.connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { t.connectDone = time.Now() },
+		TLSHandshakeStart:    func() { t.tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.tlsDone = time.Now() },
+		GotFirstResponseByte: func() { t.firstByte = time.Now() },
+	}
+}
+
+// String prints the duration of every phase; phases that didn't happen, like tls
+// for http urls, are printed as -
+func (t *timings) String() string {
+	phase := func(start, end time.Time) string {
+		if start.IsZero() || end.IsZero() {
+			return "-"
+		}
+		return end.Sub(start).Round(time.Microsecond).String()
+	}
+	return fmt.Sprintf("dns: %s, connect: %s, tls: %s, time to first byte: %s, total: %s",
+		phase(t.dnsStart, t.dnsDone), phase(t.connectStart, t.connectDone), phase(t.tlsStart, t.tlsDone),
+		phase(t.start, t.firstByte), phase(t.start, t.done))
+}
@@ -1,19 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
 
 	"assignment1/pkg/api"
+	"telemetry"
 )
 
-// Example function to demonstrate the JSON parsing with sample data
-func demonstrateWithSampleData() {
-	fmt.Println("=== Assignment 1: JSON Parsing Demo ===")
-	fmt.Println()
-
-	// Create sample data matching the assignment requirements
-	sampleData := api.AssignmentData{
+// sampleData matches the assignment requirements
+func sampleData() api.AssignmentData {
+	return api.AssignmentData{
 		Page:  "assignment1",
 		Words: []string{"one", "two", "three"},
 		Percentages: map[string]float64{
@@ -26,51 +30,151 @@ func demonstrateWithSampleData() {
 			stringPointer("two"),
 			nil, // null value
 		},
-		ExtraSpecial: []interface{}{1, 2, "3"},
+		ExtraSpecial: []api.Value{api.NewInt(1), api.NewInt(2), api.NewString("3")},
 	}
+}
+
+// Example function to demonstrate the JSON parsing with sample data
+func demonstrateWithSampleData(w io.Writer) {
+	fmt.Fprintln(w, "=== Assignment 1: JSON Parsing Demo ===")
+	fmt.Fprintln(w)
 
-	fmt.Println("Sample parsed data structure:")
-	fmt.Println(sampleData.GetResponse())
-	fmt.Println()
-	fmt.Println("This demonstrates successful parsing of the JSON structure from")
-	fmt.Println("http://localhost:8080/assignment1")
-	fmt.Println()
-	fmt.Println("To run against the actual server:")
-	fmt.Println("1. Start the test server: cd test-server && go run main.go")
-	fmt.Println("2. Run: go run cmd/assignment1/main.go")
+	fmt.Fprintln(w, "Sample parsed data structure:")
+	fmt.Fprintln(w, sampleData().GetResponse())
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "This demonstrates successful parsing of the JSON structure from")
+	fmt.Fprintln(w, "http://localhost:8080/assignment1")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "To run against the actual server:")
+	fmt.Fprintln(w, "1. Start the test server: cd test-server && ./start-test-server.sh")
+	fmt.Fprintln(w, "2. Run: go run ./cmd/assignment1")
 }
 
 func main() {
-	// Check if we want to run the demo
-	fmt.Println("Choose mode:")
-	fmt.Println("1. Demo with sample data")
-	fmt.Println("2. Connect to test server")
-
-	var choice int
-	fmt.Print("Enter choice (1 or 2): ")
-	_, err := fmt.Scanf("%d", &choice)
-	if err != nil || choice < 1 || choice > 2 {
-		choice = 1 // Default to demo
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the assignment with the command line args and returns the exit code,
+// so the deferred calls run before main exits
+func run(args []string, stdout, stderr io.Writer) int {
+	var (
+		demo     bool
+		baseURL  string
+		endpoint string
+		output   string
+		pretty   bool
+		debug    bool
+		strict   bool
+		compare  string
+		fixtures string
+		mode     string
+		timeout  time.Duration
+	)
+	flags := flag.NewFlagSet("assignment1", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&demo, "demo", false, "show sample data instead of connecting to the test server")
+	flags.StringVar(&baseURL, "url", "http://localhost:8080", "base url of the test server")
+	flags.StringVar(&endpoint, "endpoint", "/assignment1", "endpoint to fetch")
+	flags.StringVar(&output, "output", outputText, "output format: text, json, yaml or table")
+	flags.BoolVar(&pretty, "pretty", false, "indent json output")
+	flags.BoolVar(&debug, "debug", false, "dump requests and responses to stderr")
+	flags.BoolVar(&strict, "strict", false, "fail on fields unknown to, or missing from, the client structs")
+	flags.StringVar(&compare, "compare", "", "base url of a second test server to diff the endpoint against")
+	flags.StringVar(&fixtures, "fixtures", "", "directory to record responses to, or replay them from")
+	flags.StringVar(&mode, "fixture-mode", "replay", "fixture mode: replay, record or once (record missing fixtures)")
+	flags.DurationVar(&timeout, "timeout", 30*time.Second, "timeout of the whole run")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
 	}
 
-	if choice == 1 {
-		demonstrateWithSampleData()
-		return
+	if !isValidOutput(output) {
+		fmt.Fprintf(stderr, "Help: ./assignment1 -h\nunknown output format: %s\n", output)
+		return 1
 	}
 
-	// Original functionality - connect to server
-	options := api.Options{
-		BaseURL: "http://localhost:8080",
+	fixtureMode, err := api.ParseFixtureMode(mode)
+	if err != nil {
+		fmt.Fprintf(stderr, "Help: ./assignment1 -h\n%s\n", err)
+		return 1
 	}
 
-	apiClient := api.New(options)
+	var data api.AssignmentData
+	if demo {
+		if output == outputText {
+			demonstrateWithSampleData(stdout)
+			return 0
+		}
+		data = sampleData()
+	} else {
+		// exports to the collector of OTEL_EXPORTER_OTLP_ENDPOINT, if set
+		shutdownTelemetry, err := telemetry.Setup(context.Background(), "assignment1")
+		if err != nil {
+			fmt.Fprintf(stderr, "Telemetry error: %v\n", err)
+			return 1
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			shutdownTelemetry(ctx)
+		}()
 
-	response, err := apiClient.GetAssignmentData("/assignment1")
-	if err != nil {
-		log.Fatalf("Error fetching assignment data: %v", err)
+		apiClient := api.New(api.Options{
+			BaseURL: baseURL,
+			Retry: api.RetryPolicy{
+				MaxAttempts: 3,
+				Backoff:     500 * time.Millisecond,
+			},
+			ValidateSchema: true,
+			StrictJSON:     strict,
+			Debug:          debug,
+			Fixtures:       api.FixtureOptions{Dir: fixtures, Mode: fixtureMode},
+			MeterProvider:  otel.GetMeterProvider(),
+			TracerProvider: otel.GetTracerProvider(),
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if compare != "" {
+			return compareEnvironments(ctx, stdout, stderr, apiClient, endpoint, baseURL, compare)
+		}
+
+		response, err := apiClient.GetAssignmentData(ctx, endpoint)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error fetching assignment data: %v\n", err)
+			return 1
+		}
+		data = response.(api.AssignmentData)
+	}
+
+	if err := render(stdout, data, output, pretty); err != nil {
+		fmt.Fprintf(stderr, "Output error: %v\n", err)
+		return 1
 	}
+	return 0
+}
 
-	fmt.Printf("Assignment Response:\n%s\n", response.GetResponse())
+// compareEnvironments prints the differences of endpoint between two test servers
+// and returns the exit code: 0 when they match, 1 when they differ or fail
+func compareEnvironments(ctx context.Context, stdout, stderr io.Writer, apiClient api.APIIface, endpoint, baseURLA, baseURLB string) int {
+	differences, err := apiClient.Compare(ctx, endpoint, baseURLA, baseURLB)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error comparing environments: %v\n", err)
+		return 1
+	}
+	if len(differences) == 0 {
+		fmt.Fprintf(stdout, "%s is identical on %s and %s\n", endpoint, baseURLA, baseURLB)
+		return 0
+	}
+	fmt.Fprintf(stdout, "--- %s%s\n+++ %s%s\n", baseURLA, endpoint, baseURLB, endpoint)
+	for _, difference := range differences {
+		fmt.Fprintln(stdout, difference)
+	}
+	return 1
 }
 
 // Helper function to create string pointers
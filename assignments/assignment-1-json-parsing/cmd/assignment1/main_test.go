@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"assignment1/pkg/api"
+	"assignment1/pkg/apitest"
+)
+
+func TestRenderFormats(t *testing.T) {
+	tests := map[string]struct {
+		output    string
+		pretty    bool
+		unmarshal func([]byte, any) error
+	}{
+		"json":        {output: outputJSON, unmarshal: json.Unmarshal},
+		"pretty json": {output: outputJSON, pretty: true, unmarshal: json.Unmarshal},
+		"yaml":        {output: outputYAML, unmarshal: yaml.Unmarshal},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := render(&out, sampleData(), test.output, test.pretty); err != nil {
+				t.Fatalf("render error: %s", err)
+			}
+			indented := test.pretty || test.output == outputYAML
+			if strings.Contains(out.String(), "\n  ") != indented {
+				t.Errorf("Expected indented %t, got\n%s", indented, out.String())
+			}
+
+			// the machine-readable formats decode back to the same data
+			var decoded api.AssignmentData
+			if err := test.unmarshal(out.Bytes(), &decoded); err != nil {
+				t.Fatalf("Can't decode the output: %s\n%s", err, out.String())
+			}
+			if !reflect.DeepEqual(decoded, sampleData()) {
+				t.Errorf("Expected %+v, got %+v", sampleData(), decoded)
+			}
+		})
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	data := sampleData()
+	data.Pagination = &api.Pagination{Page: 1, TotalPages: 3}
+	var out bytes.Buffer
+	if err := render(&out, data, outputTable, false); err != nil {
+		t.Fatalf("render error: %s", err)
+	}
+
+	expected := `FIELD         VALUE
+page          assignment1
+words         one, two, three
+percentages   one=0.33, three=0, two=0.66
+special       one, two, null
+extraSpecial  1, 2, "3"
+pagination    page 1 of 3
+`
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	var out bytes.Buffer
+	if err := render(&out, sampleData(), outputText, false); err != nil {
+		t.Fatalf("render error: %s", err)
+	}
+	if expected := "Assignment Response:\n" + sampleData().GetResponse() + "\n"; out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+
+	if err := render(&out, sampleData(), "xml", false); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	for _, output := range []string{outputText, outputJSON, outputYAML, outputTable} {
+		if !isValidOutput(output) {
+			t.Errorf("Expected %s to be valid", output)
+		}
+	}
+}
+
+func TestRunFlags(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		exitCode int
+		stdout   string
+		stderr   string
+	}{
+		"demo":                 {args: []string{"-demo"}, exitCode: 0, stdout: "=== Assignment 1: JSON Parsing Demo ==="},
+		"demo table":           {args: []string{"-demo", "-output", "table"}, exitCode: 0, stdout: "extraSpecial  1, 2, \"3\""},
+		"unknown output":       {args: []string{"-demo", "-output", "xml"}, exitCode: 1, stderr: "unknown output format: xml"},
+		"unknown fixture mode": {args: []string{"-demo", "-fixture-mode", "rewind"}, exitCode: 1, stderr: "Help: ./assignment1 -h"},
+		"unknown flag":         {args: []string{"-interactive"}, exitCode: 2, stderr: "flag provided but not defined: -interactive"},
+		"help":                 {args: []string{"-h"}, exitCode: 0, stderr: "-output string"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if exitCode := run(test.args, &stdout, &stderr); exitCode != test.exitCode {
+				t.Errorf("Expected exit code %d, got %d\n%s", test.exitCode, exitCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), test.stdout) || !strings.Contains(stderr.String(), test.stderr) {
+				t.Errorf("Expected %q on stdout and %q on stderr, got\n%s\n%s", test.stdout, test.stderr, stdout.String(), stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunServer(t *testing.T) {
+	server := apitest.NewServer(t)
+	other := apitest.NewServer(t)
+	server.HandleResponse("/changed", http.StatusOK, "application/json", apitest.ValidBody)
+	other.HandleResponse("/changed", http.StatusOK, "application/json", strings.Replace(apitest.ValidBody, `"two":0.66`, `"two":0.5`, 1))
+
+	var stdout, stderr bytes.Buffer
+	if exitCode := run([]string{"-url", server.URL, "-output", "json"}, &stdout, &stderr); exitCode != 0 {
+		t.Fatalf("Expected exit code 0, got %d\n%s", exitCode, stderr.String())
+	}
+	var decoded api.AssignmentData
+	if err := json.Unmarshal(stdout.Bytes(), &decoded); err != nil || !reflect.DeepEqual(decoded, sampleData()) {
+		t.Errorf("Expected the data of the server, got %s (%v)", stdout.String(), err)
+	}
+
+	stdout.Reset()
+	if exitCode := run([]string{"-url", server.URL, "-endpoint", apitest.NotFoundPath}, &stdout, &stderr); exitCode != 1 || !strings.Contains(stderr.String(), "Error fetching assignment data") {
+		t.Errorf("Expected exit code 1 and the error, got %d\n%s", exitCode, stderr.String())
+	}
+
+	// the same server is identical, the other one differs
+	stdout.Reset()
+	if exitCode := run([]string{"-url", server.URL, "-compare", server.URL}, &stdout, &stderr); exitCode != 0 || !strings.Contains(stdout.String(), "is identical on") {
+		t.Errorf("Expected exit code 0 and no differences, got %d\n%s", exitCode, stdout.String())
+	}
+	stdout.Reset()
+	if exitCode := run([]string{"-url", server.URL, "-endpoint", "/changed", "-compare", other.URL}, &stdout, &stderr); exitCode != 1 || !strings.HasPrefix(stdout.String(), "--- "+server.URL+"/changed\n+++ "+other.URL+"/changed\n") {
+		t.Errorf("Expected exit code 1 and the differences, got %d\n%s", exitCode, stdout.String())
+	}
+}
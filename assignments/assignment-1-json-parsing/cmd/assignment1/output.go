@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"assignment1/pkg/api"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported -output formats
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+)
+
+func isValidOutput(output string) bool {
+	switch output {
+	case outputText, outputJSON, outputYAML, outputTable:
+		return true
+	}
+	return false
+}
+
+// render writes the data to w in the output format.
+// json and yaml are machine-readable; pretty only affects json.
+func render(w io.Writer, data api.AssignmentData, output string, pretty bool) error {
+	switch output {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		if pretty {
+			encoder.SetIndent("", "  ")
+		}
+		return encoder.Encode(data)
+	case outputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(data); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case outputTable:
+		return renderTable(w, data)
+	case outputText:
+		_, err := fmt.Fprintf(w, "Assignment Response:\n%s\n", data.GetResponse())
+		return err
+	}
+	return fmt.Errorf("unknown output format: %s", output)
+}
+
+// renderTable prints one row per field, in a format meant for humans
+func renderTable(w io.Writer, data api.AssignmentData) error {
+	percentages := make([]string, 0, len(data.Percentages))
+	for word, percentage := range data.Percentages {
+		percentages = append(percentages, fmt.Sprintf("%s=%g", word, percentage))
+	}
+	sort.Strings(percentages)
+
+	special := make([]string, len(data.Special))
+	for i, item := range data.Special {
+		if item == nil {
+			special[i] = "null"
+		} else {
+			special[i] = *item
+		}
+	}
+
+	extraSpecial := make([]string, len(data.ExtraSpecial))
+	for i, value := range data.ExtraSpecial {
+		if s, ok := value.AsString(); ok {
+			extraSpecial[i] = fmt.Sprintf("%q", s)
+		} else {
+			extraSpecial[i] = value.String()
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tVALUE")
+	fmt.Fprintf(tw, "page\t%s\n", data.Page)
+	fmt.Fprintf(tw, "words\t%s\n", strings.Join(data.Words, ", "))
+	fmt.Fprintf(tw, "percentages\t%s\n", strings.Join(percentages, ", "))
+	fmt.Fprintf(tw, "special\t%s\n", strings.Join(special, ", "))
+	fmt.Fprintf(tw, "extraSpecial\t%s\n", strings.Join(extraSpecial, ", "))
+	if data.Pagination != nil {
+		fmt.Fprintf(tw, "pagination\tpage %d of %d\n", data.Pagination.Page, data.Pagination.TotalPages)
+	}
+	return tw.Flush()
+}
@@ -1,18 +1,23 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
-	"io"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
 )
 
 // AssignmentData represents the structure of the assignment1 JSON response
 type AssignmentData struct {
-	Page         string             `json:"page"`
-	Words        []string           `json:"words"`
-	Percentages  map[string]float64 `json:"percentages"`
-	Special      []*string          `json:"special"`      // Pointer to handle null values
-	ExtraSpecial []interface{}      `json:"extraSpecial"` // interface{} to handle mixed types
+	Page         string             `json:"page" yaml:"page"`
+	Words        []string           `json:"words" yaml:"words"`
+	Percentages  map[string]float64 `json:"percentages" yaml:"percentages"`
+	Special      []*string          `json:"special" yaml:"special"`           // Pointer to handle null values
+	ExtraSpecial []Value            `json:"extraSpecial" yaml:"extraSpecial"` // tagged union to handle mixed types
+	Pagination   *Pagination        `json:"pagination,omitempty" yaml:"pagination,omitempty"`
 }
 
 // GetResponse implements the Response interface for AssignmentData
@@ -41,39 +46,115 @@ func (a AssignmentData) GetResponse() string {
 }
 
 // GetAssignmentData implements the APIIface interface
-func (a api) GetAssignmentData(endpoint string) (Response, error) {
+func (a api) GetAssignmentData(ctx context.Context, endpoint string) (Response, error) {
+	if cached, ok := a.cache.get(endpoint); ok {
+		return cached, nil
+	}
+
 	requestURL := a.Options.BaseURL + endpoint
 
-	response, err := a.Client.Get(requestURL)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP Get error: %s", err)
+		return nil, fmt.Errorf("NewRequest error: %w", err)
 	}
-	defer response.Body.Close()
+	accept := a.Options.Accept
+	if accept == "" {
+		accept = DefaultAccept
+	}
+	request.Header.Set("Accept", accept)
+	request.Header.Set("Accept-Encoding", acceptEncoding)
 
-	body, err := io.ReadAll(response.Body)
+	response, err := a.Client.Do(request)
 	if err != nil {
-		return nil, fmt.Errorf("ReadAll error: %s", err)
+		return nil, fmt.Errorf("HTTP Get error: %w", err)
 	}
+	defer response.Body.Close()
 
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("invalid output (HTTP Code %d): %s", response.StatusCode, string(body))
+	maxBodySize := a.Options.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	body, err := readBody(response, maxBodySize)
+	if err != nil {
+		return nil, fmt.Errorf("ReadAll error: %w", err)
 	}
 
-	if !json.Valid(body) {
+	if response.StatusCode != 200 {
 		return nil, RequestError{
 			HTTPCode: response.StatusCode,
 			Body:     string(body),
-			Err:      "Response is not valid JSON",
+			Err:      statusError(response.StatusCode),
+		}
+	}
+
+	var assignmentData AssignmentData
+	switch mediaType(response.Header.Get("Content-Type")) {
+	case MediaTypeYAML:
+		err = yaml.Unmarshal(body, &assignmentData)
+		if err != nil {
+			return nil, RequestError{
+				HTTPCode: response.StatusCode,
+				Body:     string(body),
+				Err:      fmt.Errorf("%w: YAML unmarshal error: %w", ErrBadBody, err),
+			}
+		}
+	case MediaTypeXML:
+		err = xml.Unmarshal(body, &assignmentData)
+		if err != nil {
+			return nil, RequestError{
+				HTTPCode: response.StatusCode,
+				Body:     string(body),
+				Err:      fmt.Errorf("%w: XML unmarshal error: %w", ErrBadBody, err),
+			}
+		}
+	default:
+		assignmentData, err = a.decodeJSON(response.StatusCode, body)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	a.cache.set(endpoint, assignmentData)
+	return assignmentData, nil
+}
+
+// decodeJSON validates and unmarshals a JSON body
+func (a api) decodeJSON(httpCode int, body []byte) (AssignmentData, error) {
 	var assignmentData AssignmentData
-	err = json.Unmarshal(body, &assignmentData)
+
+	if !json.Valid(body) {
+		return assignmentData, RequestError{
+			HTTPCode: httpCode,
+			Body:     string(body),
+			Err:      fmt.Errorf("%w: response is not valid JSON", ErrBadJSON),
+		}
+	}
+
+	if a.Options.ValidateSchema {
+		violations, err := validateSchema(body)
+		if err != nil {
+			return assignmentData, fmt.Errorf("schema validation error: %w", err)
+		}
+		if len(violations) > 0 {
+			return assignmentData, SchemaError{
+				HTTPCode:   httpCode,
+				Body:       string(body),
+				Violations: violations,
+			}
+		}
+	}
+
+	if a.Options.StrictJSON {
+		err := decodeStrict(httpCode, body, &assignmentData)
+		return assignmentData, err
+	}
+
+	err := json.Unmarshal(body, &assignmentData)
 	if err != nil {
-		return nil, RequestError{
-			HTTPCode: response.StatusCode,
+		return assignmentData, RequestError{
+			HTTPCode: httpCode,
 			Body:     string(body),
-			Err:      fmt.Sprintf("JSON unmarshal error: %s", err),
+			Err:      fmt.Errorf("%w: JSON unmarshal error: %w", ErrBadJSON, err),
 		}
 	}
 
@@ -1,74 +1,30 @@
 package api
 
 import (
-	"bytes"
-	"encoding/json"
-	"io"
+	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"testing"
-)
-
-// MockClient implements ClientIface for testing
-type MockClient struct {
-	GetResponse *http.Response
-}
+	"time"
 
-// Get implements the ClientIface interface for testing
-func (m MockClient) Get(url string) (resp *http.Response, err error) {
-	return m.GetResponse, nil
-}
+	"assignment1/pkg/apitest"
+)
 
 func TestGetAssignmentData(t *testing.T) {
-	// Create test data that matches the expected API response
-	testData := AssignmentData{
-		Page:  "assignment1",
-		Words: []string{"one", "two", "three"},
-		Percentages: map[string]float64{
-			"one":   0.33,
-			"three": 0,
-			"two":   0.66,
-		},
-		Special: []*string{
-			stringPointer("one"),
-			stringPointer("two"),
-			nil, // This represents the null value
-		},
-		ExtraSpecial: []interface{}{1, 2, "3"},
-	}
-
-	// Marshal test data to JSON
-	testDataBytes, err := json.Marshal(testData)
-	if err != nil {
-		t.Errorf("marshal error: %s", err)
-	}
-
-	// Create API instance with mock client
-	apiInstance := api{
-		Options: Options{BaseURL: "http://localhost:8080"},
-		Client: MockClient{
-			GetResponse: &http.Response{
-				StatusCode: 200,
-				Body:       io.NopCloser(bytes.NewReader(testDataBytes)),
-			},
-		},
-	}
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{BaseURL: server.URL})
 
 	// Test the GetAssignmentData method
-	response, err := apiInstance.GetAssignmentData("/assignment1")
+	response, err := apiInstance.GetAssignmentData(context.Background(), apitest.ValidPath)
 	if err != nil {
-		t.Errorf("GetAssignmentData error: %s", err)
-	}
-
-	if response == nil {
-		t.Errorf("Response is nil")
-		return
+		t.Fatalf("GetAssignmentData error: %s", err)
 	}
 
 	// Verify the response type
 	assignmentData, ok := response.(AssignmentData)
 	if !ok {
-		t.Errorf("Response is not of type AssignmentData")
-		return
+		t.Fatalf("Response is not of type AssignmentData")
 	}
 
 	// Test individual fields
@@ -77,7 +33,7 @@ func TestGetAssignmentData(t *testing.T) {
 	}
 
 	if len(assignmentData.Words) != 3 {
-		t.Errorf("Expected 3 words, got %d", len(assignmentData.Words))
+		t.Fatalf("Expected 3 words, got %d", len(assignmentData.Words))
 	}
 
 	if assignmentData.Words[0] != "one" {
@@ -89,6 +45,10 @@ func TestGetAssignmentData(t *testing.T) {
 	}
 
 	// Test null handling in Special array
+	if len(assignmentData.Special) != 3 {
+		t.Fatalf("Expected 3 items in Special, got %d", len(assignmentData.Special))
+	}
+
 	if assignmentData.Special[2] != nil {
 		t.Errorf("Expected third item in Special to be nil")
 	}
@@ -99,19 +59,18 @@ func TestGetAssignmentData(t *testing.T) {
 
 	// Test mixed types in ExtraSpecial
 	if len(assignmentData.ExtraSpecial) != 3 {
-		t.Errorf("Expected 3 items in ExtraSpecial, got %d", len(assignmentData.ExtraSpecial))
+		t.Fatalf("Expected 3 items in ExtraSpecial, got %d", len(assignmentData.ExtraSpecial))
 	}
 
-	// Verify the response string formatting
-	responseStr := response.GetResponse()
-	if responseStr == "" {
-		t.Errorf("GetResponse returned empty string")
+	if s, ok := assignmentData.ExtraSpecial[2].AsString(); !ok || s != "3" {
+		t.Errorf("Expected third item in ExtraSpecial to be string '3'")
 	}
 
-	// Check if response contains expected elements
-	expectedContents := []string{"Page: assignment1", "Words:", "Percentages:", "Special:", "ExtraSpecial:"}
+	// Verify the response string formatting
+	responseStr := response.GetResponse()
+	expectedContents := []string{"Page: assignment1", "Words:", "Percentages:", `Special: ["one", "two", null]`, "ExtraSpecial: [1 2 3]"}
 	for _, expected := range expectedContents {
-		if !containsString(responseStr, expected) {
+		if !strings.Contains(responseStr, expected) {
 			t.Errorf("Response does not contain '%s'", expected)
 		}
 	}
@@ -119,61 +78,88 @@ func TestGetAssignmentData(t *testing.T) {
 
 func TestGetAssignmentDataErrorHandling(t *testing.T) {
 	// Test HTTP error response
-	apiInstance := api{
-		Options: Options{BaseURL: "http://localhost:8080"},
-		Client: MockClient{
-			GetResponse: &http.Response{
-				StatusCode: 404,
-				Body:       io.NopCloser(bytes.NewReader([]byte("Not Found"))),
-			},
-		},
-	}
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{BaseURL: server.URL})
 
-	_, err := apiInstance.GetAssignmentData("/assignment1")
+	_, err := apiInstance.GetAssignmentData(context.Background(), apitest.NotFoundPath)
 	if err == nil {
 		t.Errorf("Expected error for 404 response, got nil")
 	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected error to match ErrNotFound, got %s", err)
+	}
+	var requestErr RequestError
+	if !errors.As(err, &requestErr) || requestErr.HTTPCode != 404 || requestErr.Body != "Not Found" {
+		t.Errorf("Expected RequestError with HTTP code and body, got %#v", err)
+	}
 }
 
 func TestGetAssignmentDataInvalidJSON(t *testing.T) {
 	// Test invalid JSON response
-	apiInstance := api{
-		Options: Options{BaseURL: "http://localhost:8080"},
-		Client: MockClient{
-			GetResponse: &http.Response{
-				StatusCode: 200,
-				Body:       io.NopCloser(bytes.NewReader([]byte("invalid json"))),
-			},
-		},
-	}
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{BaseURL: server.URL})
 
-	_, err := apiInstance.GetAssignmentData("/assignment1")
+	_, err := apiInstance.GetAssignmentData(context.Background(), apitest.InvalidJSONPath)
 	if err == nil {
 		t.Errorf("Expected error for invalid JSON, got nil")
 	}
 
-	// Verify it's a RequestError
-	if _, ok := err.(RequestError); !ok {
+	// Verify it's a RequestError wrapping ErrBadJSON
+	var requestErr RequestError
+	if !errors.As(err, &requestErr) {
 		t.Errorf("Expected RequestError for invalid JSON, got %T", err)
 	}
+	if !errors.Is(err, ErrBadJSON) || !errors.Is(err, ErrBadBody) {
+		t.Errorf("Expected error to match ErrBadJSON and ErrBadBody, got %s", err)
+	}
+}
+
+func TestGetAssignmentDataCanceledContext(t *testing.T) {
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{BaseURL: server.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := apiInstance.GetAssignmentData(ctx, apitest.ValidPath)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error to match context.Canceled, got %v", err)
+	}
 }
 
-// Helper function to create string pointers
-func stringPointer(s string) *string {
-	return &s
+func TestGetAssignmentDataTimeout(t *testing.T) {
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{BaseURL: server.URL})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := apiInstance.GetAssignmentData(ctx, apitest.SlowPath)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error to match context.DeadlineExceeded, got %v", err)
+	}
 }
 
-// Helper function to check if a string contains a substring
-func containsString(str, substr string) bool {
-	return len(str) >= len(substr) && findSubstring(str, substr) != -1
+func TestGetAssignmentDataTruncated(t *testing.T) {
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{BaseURL: server.URL})
+
+	_, err := apiInstance.GetAssignmentData(context.Background(), apitest.TruncatedPath)
+	if err == nil {
+		t.Errorf("Expected error for truncated body, got nil")
+	}
 }
 
-// Helper function to find substring
-func findSubstring(str, substr string) int {
-	for i := 0; i <= len(str)-len(substr); i++ {
-		if str[i:i+len(substr)] == substr {
-			return i
-		}
+func TestGetAssignmentDataServerError(t *testing.T) {
+	server := apitest.NewServer(t)
+	server.HandleResponse("/unavailable", http.StatusServiceUnavailable, "text/plain", "Service Unavailable")
+	apiInstance := New(Options{BaseURL: server.URL})
+
+	_, err := apiInstance.GetAssignmentData(context.Background(), "/unavailable")
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("Expected error to match ErrServer, got %v", err)
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected error not to match ErrNotFound")
 	}
-	return -1
 }
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// TokenSource supplies the bearer token attached to every request
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+	// Invalidate is called when the server rejects the token,
+	// so that the next call to Token returns a fresh one
+	Invalidate()
+}
+
+// StaticTokenSource always returns the same token, e.g. one obtained from oidc-demo
+type StaticTokenSource string
+
+// Token returns the static token
+func (s StaticTokenSource) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+// Invalidate is a no-op: a static token can't be refreshed
+func (s StaticTokenSource) Invalidate() {}
+
+// BearerAuth returns a middleware adding an Authorization header with a token from the source.
+// When the server rejects the token, it is invalidated and the request is retried once
+// with a new token. The test-server replies 403 instead of 401 for an invalid or expired
+// token, so both codes trigger a refresh.
+func BearerAuth(tokenSource TokenSource) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			res, err := roundTripWithToken(next, tokenSource, req)
+			if err != nil || !isAuthRejected(res.StatusCode) {
+				return res, err
+			}
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				// the body can't be replayed
+				return res, nil
+			}
+
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+			tokenSource.Invalidate()
+
+			// a RoundTripper must not modify the request, the retry is a clone with
+			// a new body
+			retry := req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				retry.Body = body
+			}
+			return roundTripWithToken(next, tokenSource, retry)
+		})
+	}
+}
+
+func roundTripWithToken(next http.RoundTripper, tokenSource TokenSource, req *http.Request) (*http.Response, error) {
+	token, err := tokenSource.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	authReq := req.Clone(req.Context())
+	authReq.Header.Set("Authorization", "Bearer "+token)
+	return next.RoundTrip(authReq)
+}
+
+func isAuthRejected(statusCode int) bool {
+	return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// MockTokenSource hands out "token1", "token2", ... after every invalidation
+type MockTokenSource struct {
+	tokens      []string
+	invalidated int
+}
+
+func (m *MockTokenSource) Token(ctx context.Context) (string, error) {
+	return m.tokens[m.invalidated], nil
+}
+
+func (m *MockTokenSource) Invalidate() {
+	m.invalidated++
+}
+
+func TestBearerAuthRefresh(t *testing.T) {
+	tokenSource := &MockTokenSource{tokens: []string{"expired", "fresh"}}
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		if string(body) != "payload" {
+			t.Errorf("Expected the body to be replayed, got %q", string(body))
+		}
+		if req.Header.Get("Authorization") != "Bearer fresh" {
+			return &http.Response{StatusCode: 401, Body: io.NopCloser(strings.NewReader("expired"))}, nil
+		}
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	})
+
+	req, _ := http.NewRequest(http.MethodPut, "http://localhost:8080/words", strings.NewReader("payload"))
+	res, err := Chain(transport, BearerAuth(tokenSource)).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	if res.StatusCode != 200 {
+		t.Errorf("Expected status code 200 after refresh, got %d", res.StatusCode)
+	}
+	if tokenSource.invalidated != 1 {
+		t.Errorf("Expected token to be invalidated once, got %d", tokenSource.invalidated)
+	}
+}
+
+func TestBearerAuthRequestUntouched(t *testing.T) {
+	tokenSource := &MockTokenSource{tokens: []string{"expired", "fresh"}}
+	var bodies []io.ReadCloser
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		bodies = append(bodies, req.Body)
+		io.ReadAll(req.Body)
+		if req.Header.Get("Authorization") != "Bearer fresh" {
+			return &http.Response{StatusCode: 403, Body: http.NoBody}, nil
+		}
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/words", strings.NewReader("payload"))
+	body := req.Body
+	if _, err := Chain(transport, BearerAuth(tokenSource)).RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	if req.Body != body || req.Header.Get("Authorization") != "" {
+		t.Error("Expected the original request to be untouched")
+	}
+	if len(bodies) != 2 || bodies[0] == bodies[1] {
+		t.Errorf("Expected the retry to have a fresh body from GetBody, got %d bodies", len(bodies))
+	}
+}
+
+func TestLoginTokenSource(t *testing.T) {
+	logins := 0
+	tokenSource := &LoginTokenSource{
+		LoginURL: "http://localhost:8080/login",
+		Password: "secret",
+		Client: &http.Client{
+			Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				logins++
+				var loginRequest LoginRequest
+				if err := json.NewDecoder(req.Body).Decode(&loginRequest); err != nil || loginRequest.Password != "secret" {
+					t.Errorf("Expected password to be posted, got %v (%v)", loginRequest, err)
+				}
+				body, _ := json.Marshal(LoginResponse{Token: "123"})
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(bytes.NewReader(body))}, nil
+			}),
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := tokenSource.Token(context.Background())
+		if err != nil {
+			t.Fatalf("Token error: %s", err)
+		}
+		if token != "123" {
+			t.Errorf("Expected token 123, got %s", token)
+		}
+	}
+	if logins != 1 {
+		t.Errorf("Expected the token to be reused, got %d logins", logins)
+	}
+
+	tokenSource.Invalidate()
+	if _, err := tokenSource.Token(context.Background()); err != nil {
+		t.Fatalf("Token error: %s", err)
+	}
+	if logins != 2 {
+		t.Errorf("Expected a new login after invalidation, got %d logins", logins)
+	}
+}
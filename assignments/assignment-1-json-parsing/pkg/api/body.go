@@ -0,0 +1,63 @@
+package api
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodySize is the limit on decompressed response bodies when Options.MaxBodySize isn't set
+const DefaultMaxBodySize = 10 << 20 // 10 MiB
+
+// acceptEncoding is sent with every request. Setting it ourselves disables the
+// transparent gzip support of http.Transport, so readBody handles both encodings.
+const acceptEncoding = "gzip, deflate"
+
+// BodyTooLargeError is returned when a response body exceeds the configured limit
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+// Error implements the error interface for BodyTooLargeError
+func (b BodyTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds %d bytes", b.Limit)
+}
+
+// readBody reads the response body, decompressing it according to Content-Encoding,
+// and stops with a BodyTooLargeError once more than limit bytes have been decoded
+func readBody(response *http.Response, limit int64) ([]byte, error) {
+	var reader io.Reader = response.Body
+	switch strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: gzip error: %w", ErrBadBody, err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case "deflate":
+		// HTTP deflate is the zlib format (RFC 9110)
+		zlibReader, err := zlib.NewReader(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: deflate error: %w", ErrBadBody, err)
+		}
+		defer zlibReader.Close()
+		reader = zlibReader
+	case "", "identity":
+	default:
+		return nil, fmt.Errorf("%w: unsupported Content-Encoding %q", ErrBadBody, response.Header.Get("Content-Encoding"))
+	}
+
+	// read one byte more than allowed, to tell an exact fit from an oversized body
+	body, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, BodyTooLargeError{Limit: limit}
+	}
+	return body, nil
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"assignment1/pkg/apitest"
+)
+
+func TestGetAssignmentDataCompressed(t *testing.T) {
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{BaseURL: server.URL})
+
+	for _, path := range []string{apitest.GzipPath, apitest.DeflatePath} {
+		response, err := apiInstance.GetAssignmentData(context.Background(), path)
+		if err != nil {
+			t.Errorf("%s: GetAssignmentData error: %s", path, err)
+			continue
+		}
+		if words := response.(AssignmentData).Words; len(words) != 3 {
+			t.Errorf("%s: Expected 3 words, got %v", path, words)
+		}
+	}
+}
+
+func TestGetAssignmentDataBodyTooLarge(t *testing.T) {
+	server := apitest.NewServer(t)
+	server.HandleResponse("/large", http.StatusOK, "application/json", `{"page":"`+strings.Repeat("x", 1000)+`"}`)
+
+	// the compressed body is small, the limit applies to the decompressed body
+	apiInstance := New(Options{BaseURL: server.URL, MaxBodySize: 100})
+	for _, path := range []string{"/large", apitest.GzipPath} {
+		_, err := apiInstance.GetAssignmentData(context.Background(), path)
+		var tooLarge BodyTooLargeError
+		if !errors.As(err, &tooLarge) || tooLarge.Limit != 100 {
+			t.Errorf("%s: Expected BodyTooLargeError, got %v", path, err)
+		}
+	}
+
+	apiInstance = New(Options{BaseURL: server.URL, MaxBodySize: int64(len(apitest.ValidBody))})
+	if _, err := apiInstance.GetAssignmentData(context.Background(), apitest.ValidPath); err != nil {
+		t.Errorf("Expected a body of exactly MaxBodySize to be accepted, got %s", err)
+	}
+}
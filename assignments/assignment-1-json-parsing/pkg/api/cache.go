@@ -0,0 +1,125 @@
+package api
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures the in-memory response cache
+type CacheOptions struct {
+	// TTL is how long a response stays fresh. 0 disables the cache.
+	TTL time.Duration
+	// MaxEntries limits the number of cached endpoints; the least recently used
+	// entry is evicted first. 0 means no limit.
+	MaxEntries int
+}
+
+type cacheEntry struct {
+	endpoint string
+	response Response
+	expires  time.Time
+}
+
+// responseCache is an LRU cache of responses keyed by endpoint.
+// A nil *responseCache is a valid, always empty cache.
+type responseCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List // front is most recently used
+	now        func() time.Time
+}
+
+func newResponseCache(options CacheOptions) *responseCache {
+	if options.TTL <= 0 {
+		return nil
+	}
+	return &responseCache{
+		ttl:        options.TTL,
+		maxEntries: options.MaxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// get returns the cached response if it is still fresh.
+// The response shares its slices and maps with the cache, so callers shouldn't modify it.
+func (c *responseCache) get(endpoint string) (Response, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[endpoint]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if c.now().After(entry.expires) {
+		c.removeElement(element)
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	return entry.response, true
+}
+
+func (c *responseCache) set(endpoint string, response Response) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+	if element, ok := c.entries[endpoint]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.response = response
+		entry.expires = expires
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[endpoint] = c.lru.PushFront(&cacheEntry{
+		endpoint: endpoint,
+		response: response,
+		expires:  expires,
+	})
+	if c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// invalidate removes the endpoints from the cache, or everything when none are given
+func (c *responseCache) invalidate(endpoints ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(endpoints) == 0 {
+		c.entries = make(map[string]*list.Element)
+		c.lru.Init()
+		return
+	}
+	for _, endpoint := range endpoints {
+		if element, ok := c.entries[endpoint]; ok {
+			c.removeElement(element)
+		}
+	}
+}
+
+func (c *responseCache) removeElement(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).endpoint)
+}
+
+// InvalidateCache drops the cached responses of the endpoints, or the whole cache when
+// no endpoint is given
+func (a api) InvalidateCache(endpoints ...string) {
+	a.cache.invalidate(endpoints...)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	now := time.Now()
+	cache := newResponseCache(CacheOptions{TTL: time.Minute, MaxEntries: 2})
+	cache.now = func() time.Time { return now }
+
+	cache.set("/a", AssignmentData{Page: "a"})
+	cache.set("/b", AssignmentData{Page: "b"})
+	if _, ok := cache.get("/a"); !ok {
+		t.Errorf("Expected /a to be cached")
+	}
+	// /b is now the least recently used entry
+	cache.set("/c", AssignmentData{Page: "c"})
+	if _, ok := cache.get("/b"); ok {
+		t.Errorf("Expected /b to be evicted")
+	}
+	if response, ok := cache.get("/c"); !ok || response.(AssignmentData).Page != "c" {
+		t.Errorf("Expected /c to be cached")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.get("/a"); ok {
+		t.Errorf("Expected /a to be expired")
+	}
+}
+
+func TestGetAssignmentDataCache(t *testing.T) {
+	requests := 0
+	apiInstance := api{
+		Options: Options{BaseURL: "http://localhost:8080"},
+		Client: &http.Client{
+			Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				requests++
+				return &http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(strings.NewReader(`{"page":"assignment1"}`)),
+				}, nil
+			}),
+		},
+		cache: newResponseCache(CacheOptions{TTL: time.Minute}),
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := apiInstance.GetAssignmentData(context.Background(), "/assignment1"); err != nil {
+			t.Fatalf("GetAssignmentData error: %s", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request, got %d", requests)
+	}
+
+	apiInstance.InvalidateCache("/assignment1")
+	if _, err := apiInstance.GetAssignmentData(context.Background(), "/assignment1"); err != nil {
+		t.Fatalf("GetAssignmentData error: %s", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected a new request after invalidation, got %d requests", requests)
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through while counting failures
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every request with ErrCircuitOpen until the cooldown is over
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request through to test whether the server recovered
+	CircuitHalfOpen
+)
+
+// String returns the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerOptions configures the circuit breaker
+type CircuitBreakerOptions struct {
+	// FailureRate between 0 and 1 opens the circuit, e.g. 0.5 when half the requests fail.
+	// 0 disables the circuit breaker.
+	FailureRate float64
+	// MinRequests is the number of requests in a window before the failure rate is considered (10 when 0)
+	MinRequests int
+	// Window is the period over which requests and failures are counted (10s when 0)
+	Window time.Duration
+	// Cooldown is how long the circuit stays open before a probe request is let through (5s when 0)
+	Cooldown time.Duration
+	// OnStateChange, when set, is called on every transition, e.g. to log it
+	OnStateChange func(from, to CircuitState)
+}
+
+// enabled reports whether a circuit breaker is configured
+func (c CircuitBreakerOptions) enabled() bool {
+	return c.FailureRate > 0
+}
+
+// circuitBreaker is the state shared by all requests going through the middleware
+type circuitBreaker struct {
+	options CircuitBreakerOptions
+	now     func() time.Time
+
+	mu          sync.Mutex
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     bool
+}
+
+func newCircuitBreaker(options CircuitBreakerOptions) *circuitBreaker {
+	if options.MinRequests <= 0 {
+		options.MinRequests = 10
+	}
+	if options.Window <= 0 {
+		options.Window = 10 * time.Second
+	}
+	if options.Cooldown <= 0 {
+		options.Cooldown = 5 * time.Second
+	}
+	return &circuitBreaker{options: options, now: time.Now}
+}
+
+// allow reports whether a request may be sent, moving an open circuit
+// to half-open once the cooldown is over
+func (c *circuitBreaker) allow() bool {
+	c.mu.Lock()
+	from := c.state
+	allowed := true
+	switch c.state {
+	case CircuitOpen:
+		if c.now().Sub(c.openedAt) < c.options.Cooldown {
+			allowed = false
+			break
+		}
+		c.state = CircuitHalfOpen
+		c.probing = true
+	case CircuitHalfOpen:
+		// only one probe at a time
+		allowed = !c.probing
+		c.probing = true
+	}
+	to := c.state
+	c.mu.Unlock()
+
+	c.notify(from, to)
+	return allowed
+}
+
+// record counts the outcome of a request that was allowed through
+func (c *circuitBreaker) record(failed bool) {
+	c.mu.Lock()
+	from := c.state
+	now := c.now()
+	switch c.state {
+	case CircuitHalfOpen:
+		c.probing = false
+		if failed {
+			c.state = CircuitOpen
+			c.openedAt = now
+		} else {
+			c.state = CircuitClosed
+			c.windowStart, c.requests, c.failures = now, 0, 0
+		}
+	case CircuitClosed:
+		if now.Sub(c.windowStart) >= c.options.Window {
+			c.windowStart, c.requests, c.failures = now, 0, 0
+		}
+		c.requests++
+		if failed {
+			c.failures++
+		}
+		if c.requests >= c.options.MinRequests && float64(c.failures)/float64(c.requests) >= c.options.FailureRate {
+			c.state = CircuitOpen
+			c.openedAt = now
+		}
+	}
+	to := c.state
+	c.mu.Unlock()
+
+	c.notify(from, to)
+}
+
+func (c *circuitBreaker) notify(from, to CircuitState) {
+	if from != to && c.options.OnStateChange != nil {
+		c.options.OnStateChange(from, to)
+	}
+}
+
+// isFailure tells whether a request outcome counts against the server:
+// network errors and 5xx responses do, requests canceled by the caller don't
+func isFailure(response *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+	return response.StatusCode >= 500
+}
+
+// CircuitBreaker returns a middleware that stops sending requests with ErrCircuitOpen
+// while the server is failing, instead of piling up requests and retries.
+// All requests going through the middleware share one circuit.
+func CircuitBreaker(options CircuitBreakerOptions) Middleware {
+	breaker := newCircuitBreaker(options)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !breaker.allow() {
+				return nil, ErrCircuitOpen
+			}
+			response, err := next.RoundTrip(req)
+			breaker.record(isFailure(response, err))
+			return response, err
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"assignment1/pkg/apitest"
+)
+
+func TestCircuitBreakerStates(t *testing.T) {
+	now := time.Unix(0, 0)
+	var transitions []string
+	breaker := newCircuitBreaker(CircuitBreakerOptions{
+		FailureRate: 0.5,
+		MinRequests: 4,
+		Cooldown:    time.Second,
+		OnStateChange: func(from, to CircuitState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+	breaker.now = func() time.Time { return now }
+
+	for _, failed := range []bool{false, true, false} {
+		if !breaker.allow() {
+			t.Fatalf("Expected closed circuit to allow requests")
+		}
+		breaker.record(failed)
+	}
+	breaker.allow()
+	breaker.record(true) // 2 failures out of 4
+	if breaker.allow() {
+		t.Errorf("Expected open circuit to reject requests")
+	}
+
+	now = now.Add(time.Second)
+	if !breaker.allow() {
+		t.Fatalf("Expected a probe after the cooldown")
+	}
+	if breaker.allow() {
+		t.Errorf("Expected a single probe while half-open")
+	}
+	breaker.record(true)
+	if breaker.allow() {
+		t.Errorf("Expected failed probe to reopen the circuit")
+	}
+
+	now = now.Add(time.Second)
+	breaker.allow()
+	breaker.record(false)
+	if !breaker.allow() {
+		t.Errorf("Expected successful probe to close the circuit")
+	}
+
+	expected := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(transitions) != len(expected) {
+		t.Fatalf("Expected transitions %v, got %v", expected, transitions)
+	}
+	for i := range expected {
+		if transitions[i] != expected[i] {
+			t.Errorf("Expected transition %d to be %s, got %s", i, expected[i], transitions[i])
+		}
+	}
+}
+
+func TestCircuitBreakerFailsFast(t *testing.T) {
+	server := apitest.NewServer(t)
+	calls := 0
+	server.Handle("/down", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	}))
+	apiInstance := New(Options{
+		BaseURL:        server.URL,
+		Retry:          RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+		CircuitBreaker: CircuitBreakerOptions{FailureRate: 1, MinRequests: 2, Cooldown: time.Minute},
+	})
+
+	_, err := apiInstance.GetAssignmentData(context.Background(), "/down")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the retries to stop at the open circuit, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 requests before the circuit opened, got %d", calls)
+	}
+
+	_, err = apiInstance.GetAssignmentData(context.Background(), "/down")
+	if !errors.Is(err, ErrCircuitOpen) || calls != 2 {
+		t.Errorf("Expected open circuit to fail without a request (calls %d): %v", calls, err)
+	}
+}
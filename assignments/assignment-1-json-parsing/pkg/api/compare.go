@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DifferenceKind tells how a value differs between the two environments
+type DifferenceKind string
+
+const (
+	Added   DifferenceKind = "added"   // only present in B
+	Removed DifferenceKind = "removed" // only present in A
+	Changed DifferenceKind = "changed" // present in both with different values
+)
+
+// Difference is a single structural difference between two JSON documents
+type Difference struct {
+	Path string // JSON pointer, e.g. /percentages/one ("" is the whole document)
+	Kind DifferenceKind
+	A    any // value in A, nil when Added
+	B    any // value in B, nil when Removed
+}
+
+// String formats the difference like a diff line
+func (d Difference) String() string {
+	path := d.Path
+	if path == "" {
+		path = "/"
+	}
+	switch d.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", path, formatJSON(d.B))
+	case Removed:
+		return fmt.Sprintf("- %s: %s", path, formatJSON(d.A))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", path, formatJSON(d.A), formatJSON(d.B))
+}
+
+// formatJSON encodes a decoded JSON value back to compact JSON
+func formatJSON(v any) string {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(out)
+}
+
+// Compare fetches endpoint from baseURLA and baseURLB concurrently and returns
+// the structural differences between the two JSON bodies, ordered by path.
+// An empty result means both environments return the same document.
+// The cache is bypassed, everything else (middlewares, auth, retries) applies.
+func (a api) Compare(ctx context.Context, endpoint, baseURLA, baseURLB string) ([]Difference, error) {
+	var documentA, documentB any
+	g, groupCtx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		documentA, err = a.getJSON(groupCtx, baseURLA+endpoint)
+		if err != nil {
+			return fmt.Errorf("%s: %w", baseURLA, err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		documentB, err = a.getJSON(groupCtx, baseURLB+endpoint)
+		if err != nil {
+			return fmt.Errorf("%s: %w", baseURLB, err)
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	differences := diffJSON("", documentA, documentB, nil)
+	sort.SliceStable(differences, func(i, j int) bool {
+		return differences[i].Path < differences[j].Path
+	})
+	return differences, nil
+}
+
+// getJSON fetches requestURL and decodes the JSON body without a target struct,
+// so fields the client doesn't know about are compared as well
+func (a api) getJSON(ctx context.Context, requestURL string) (any, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("NewRequest error: %w", err)
+	}
+	request.Header.Set("Accept", MediaTypeJSON)
+	request.Header.Set("Accept-Encoding", acceptEncoding)
+
+	response, err := a.Client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP Get error: %w", err)
+	}
+	defer response.Body.Close()
+
+	maxBodySize := a.Options.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	body, err := readBody(response, maxBodySize)
+	if err != nil {
+		return nil, fmt.Errorf("ReadAll error: %w", err)
+	}
+
+	if response.StatusCode != 200 {
+		return nil, RequestError{
+			HTTPCode: response.StatusCode,
+			Body:     string(body),
+			Err:      statusError(response.StatusCode),
+		}
+	}
+
+	var document any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// keep numbers as written, so 1 and 1.0 or large integers are compared exactly
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, RequestError{
+			HTTPCode: response.StatusCode,
+			Body:     string(body),
+			Err:      fmt.Errorf("%w: JSON decode error: %w", ErrBadJSON, err),
+		}
+	}
+	return document, nil
+}
+
+// diffJSON appends the differences between two decoded JSON values to differences.
+// Objects are compared key by key and arrays index by index.
+func diffJSON(path string, a, b any, differences []Difference) []Difference {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		for key, valueA := range a {
+			valueB, ok := b[key]
+			if !ok {
+				differences = append(differences, Difference{Path: path + "/" + escapePointer(key), Kind: Removed, A: valueA})
+				continue
+			}
+			differences = diffJSON(path+"/"+escapePointer(key), valueA, valueB, differences)
+		}
+		for key, valueB := range b {
+			if _, ok := a[key]; !ok {
+				differences = append(differences, Difference{Path: path + "/" + escapePointer(key), Kind: Added, B: valueB})
+			}
+		}
+		return differences
+	case []any:
+		b, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(a) || i < len(b); i++ {
+			itemPath := path + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(b):
+				differences = append(differences, Difference{Path: itemPath, Kind: Removed, A: a[i]})
+			case i >= len(a):
+				differences = append(differences, Difference{Path: itemPath, Kind: Added, B: b[i]})
+			default:
+				differences = diffJSON(itemPath, a[i], b[i], differences)
+			}
+		}
+		return differences
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		differences = append(differences, Difference{Path: path, Kind: Changed, A: a, B: b})
+	}
+	return differences
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"assignment1/pkg/apitest"
+)
+
+func TestCompare(t *testing.T) {
+	serverA := apitest.NewServer(t)
+	serverB := apitest.NewServer(t)
+	serverB.HandleResponse("/changed", http.StatusOK, "application/json",
+		`{"page":"assignment1","words":["one","two"],"percentages":{"one":0.5,"three":0,"two":0.66},"special":["one","two",null],"extraSpecial":[1,2,"3"],"version":2}`)
+	serverA.HandleResponse("/changed", http.StatusOK, "application/json", apitest.ValidBody)
+	apiInstance := New(Options{})
+
+	differences, err := apiInstance.Compare(context.Background(), apitest.ValidPath, serverA.URL, serverB.URL)
+	if err != nil {
+		t.Fatalf("Compare error: %s", err)
+	}
+	if len(differences) != 0 {
+		t.Errorf("Expected no differences, got %v", differences)
+	}
+
+	differences, err = apiInstance.Compare(context.Background(), "/changed", serverA.URL, serverB.URL)
+	if err != nil {
+		t.Fatalf("Compare error: %s", err)
+	}
+	var lines []string
+	for _, difference := range differences {
+		lines = append(lines, difference.String())
+	}
+	expected := []string{
+		`~ /percentages/one: 0.33 -> 0.5`,
+		`+ /version: 2`,
+		`- /words/2: "three"`,
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected differences %q, got %q", expected, lines)
+	}
+}
+
+func TestCompareError(t *testing.T) {
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{})
+
+	_, err := apiInstance.Compare(context.Background(), apitest.NotFoundPath, server.URL, server.URL)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected error to match ErrNotFound, got %v", err)
+	}
+}
+
+func TestDiffJSONTypeChange(t *testing.T) {
+	differences := diffJSON("", map[string]any{"a": []any{"x"}}, map[string]any{"a": "x"}, nil)
+	if len(differences) != 1 || differences[0].Path != "/a" || differences[0].Kind != Changed {
+		t.Errorf("Expected a single change at /a, got %v", differences)
+	}
+}
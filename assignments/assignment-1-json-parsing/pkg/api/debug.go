@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+)
+
+// maxDumpBody is the number of body bytes shown in a debug dump
+const maxDumpBody = 512
+
+// sensitiveHeaders are redacted before a request or response is dumped
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// DebugLogging returns a middleware dumping every request and response to the logger.
+// Credentials are redacted and bodies are truncated to maxDumpBody bytes: only that
+// much of a response body is read for the dump, the rest is streamed to the caller.
+func DebugLogging(logger *log.Logger) Middleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// dump a copy with redacted headers, then hand the replayable body back to the original
+			dumpReq := *req
+			dumpReq.Header = redactHeaders(req.Header)
+			dump, err := httputil.DumpRequestOut(&dumpReq, true)
+			req.Body = dumpReq.Body
+			if err != nil {
+				logger.Printf("request dump error: %s", err)
+			} else {
+				logger.Printf("request:\n%s", truncateDump(dump))
+			}
+
+			res, err := next.RoundTrip(req)
+			if err != nil {
+				logger.Printf("response error: %s", err)
+				return res, err
+			}
+
+			header := res.Header
+			res.Header = redactHeaders(header)
+			dump, dumpErr := httputil.DumpResponse(res, false)
+			res.Header = header
+			if dumpErr != nil {
+				logger.Printf("response dump error: %s", dumpErr)
+				return res, nil
+			}
+			// only the part of the body that is shown is read, and put back in front of
+			// the rest, so a large or endless body isn't held in memory
+			prefix, readErr := io.ReadAll(io.LimitReader(res.Body, maxDumpBody+1))
+			rest := io.Reader(res.Body)
+			if readErr != nil {
+				rest = errorReader{err: readErr}
+			}
+			res.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(prefix), rest), Closer: res.Body}
+			logger.Printf("response:\n%s", dumpBody(dump, prefix, res.ContentLength))
+			return res, nil
+		})
+	}
+}
+
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, key := range sensitiveHeaders {
+		if redacted.Get(key) != "" {
+			redacted.Set(key, "REDACTED")
+		}
+	}
+	return redacted
+}
+
+// dumpBody appends the start of the body to the dump of the headers. The prefix is
+// read up to one byte past maxDumpBody, to know whether the body is longer.
+func dumpBody(dump, prefix []byte, contentLength int64) []byte {
+	if len(prefix) <= maxDumpBody {
+		return append(dump, prefix...)
+	}
+	dump = append(dump, prefix[:maxDumpBody]...)
+	if contentLength > 0 {
+		return append(dump, fmt.Sprintf("... (%d more bytes)", contentLength-maxDumpBody)...)
+	}
+	return append(dump, "... (truncated)"...)
+}
+
+// readCloser reads the response body from Reader and closes the original one
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// errorReader returns the error of a body that failed while it was dumped
+type errorReader struct {
+	err error
+}
+
+func (e errorReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
+
+// truncateDump shortens the body part of a dump, keeping the headers intact
+func truncateDump(dump []byte) []byte {
+	separator := []byte("\r\n\r\n")
+	i := bytes.Index(dump, separator)
+	if i == -1 {
+		return dump
+	}
+	body := dump[i+len(separator):]
+	if len(body) <= maxDumpBody {
+		return dump
+	}
+	out := append([]byte{}, dump[:i+len(separator)+maxDumpBody]...)
+	return append(out, fmt.Sprintf("... (%d more bytes)", len(body)-maxDumpBody)...)
+}
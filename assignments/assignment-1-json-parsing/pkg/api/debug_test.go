@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDebugLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New(&buf, "", 0)
+	longBody := strings.Repeat("x", maxDumpBody+100)
+
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		if string(body) != "request body" {
+			t.Errorf("Expected the request body to be passed on, got %q", string(body))
+		}
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected the Authorization header to be passed on unchanged")
+		}
+		return &http.Response{
+			StatusCode:    200,
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Set-Cookie": []string{"session=secret"}},
+			Body:          io.NopCloser(strings.NewReader(longBody)),
+			ContentLength: int64(len(longBody)),
+		}, nil
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/assignment1", strings.NewReader("request body"))
+	req.Header.Set("Authorization", "Bearer secret")
+	res, err := Chain(transport, DebugLogging(logger)).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	if string(body) != longBody {
+		t.Errorf("Expected the full response body to be returned")
+	}
+	if res.Header.Get("Set-Cookie") != "session=secret" {
+		t.Errorf("Expected the response headers to be returned unchanged")
+	}
+
+	output := buf.String()
+	if strings.Contains(output, "secret") {
+		t.Errorf("Expected credentials to be redacted:\n%s", output)
+	}
+	for _, expected := range []string{"POST /assignment1", "request body", "200 OK", "(100 more bytes)"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q:\n%s", expected, output)
+		}
+	}
+}
+
+// countingReader counts the bytes read from it
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestDebugLoggingLargeBody(t *testing.T) {
+	var buf bytes.Buffer
+	body := &countingReader{r: strings.NewReader(strings.Repeat("x", 8<<20))}
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    200,
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{},
+			Body:          io.NopCloser(body),
+			ContentLength: -1,
+		}, nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/assignment1", nil)
+	res, err := Chain(transport, DebugLogging(log.New(&buf, "", 0))).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	if body.read > maxDumpBody+1 {
+		t.Errorf("Expected at most %d bytes to be read for the dump, got %d", maxDumpBody+1, body.read)
+	}
+	if !strings.Contains(buf.String(), "... (truncated)") {
+		t.Errorf("Expected the dump to be truncated:\n%s", buf.String())
+	}
+
+	n, err := io.Copy(io.Discard, res.Body)
+	if err != nil || n != 8<<20 {
+		t.Errorf("Expected the full body of %d bytes, got %d (%v)", 8<<20, n, err)
+	}
+}
@@ -1,15 +1,57 @@
 package api
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors wrapped by the errors of this package. Use errors.Is to check for them.
+var (
+	// ErrNotFound is returned when the server replies 404
+	ErrNotFound = errors.New("not found")
+	// ErrServer is returned when the server replies with a 5xx status code
+	ErrServer = errors.New("server error")
+	// ErrUnexpectedStatus is returned for any other non-200 status code
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+	// ErrBadBody is returned when a response body can't be decoded
+	ErrBadBody = errors.New("bad response body")
+	// ErrBadJSON is returned when a JSON body is invalid; it also matches ErrBadBody
+	ErrBadJSON = fmt.Errorf("%w: bad JSON", ErrBadBody)
+	// ErrSchema is returned when a body doesn't match the JSON Schema
+	ErrSchema = errors.New("schema mismatch")
+	// ErrStrictJSON is returned in StrictJSON mode when a body has unknown or missing fields
+	ErrStrictJSON = errors.New("strict JSON mismatch")
+	// ErrCircuitOpen is returned without sending the request while the circuit breaker is open
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrFixtureNotFound is returned when replaying a request that has no recorded fixture
+	ErrFixtureNotFound = errors.New("fixture not found")
+)
 
 // RequestError represents an HTTP request error with context
 type RequestError struct {
 	HTTPCode int
 	Body     string
-	Err      string
+	Err      error
 }
 
 // Error implements the error interface for RequestError
 func (r RequestError) Error() string {
 	return fmt.Sprintf("HTTP %d: %s - %s", r.HTTPCode, r.Err, r.Body)
 }
+
+// Unwrap gives errors.Is and errors.As access to the wrapped error
+func (r RequestError) Unwrap() error {
+	return r.Err
+}
+
+// statusError returns the sentinel error matching a non-200 status code
+func statusError(statusCode int) error {
+	switch {
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound
+	case statusCode >= 500:
+		return ErrServer
+	}
+	return ErrUnexpectedStatus
+}
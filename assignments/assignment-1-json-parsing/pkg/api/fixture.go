@@ -0,0 +1,215 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FixtureMode tells the fixture recorder whether to use the network
+type FixtureMode int
+
+const (
+	// ReplayFixtures serves every request from the fixture files and never uses the network.
+	// A request without a fixture fails with ErrFixtureNotFound.
+	ReplayFixtures FixtureMode = iota
+	// RecordFixtures sends every request and overwrites its fixture with the response
+	RecordFixtures
+	// RecordMissingFixtures replays existing fixtures and records the ones that are missing
+	RecordMissingFixtures
+)
+
+// String returns the name of the mode
+func (m FixtureMode) String() string {
+	switch m {
+	case ReplayFixtures:
+		return "replay"
+	case RecordFixtures:
+		return "record"
+	case RecordMissingFixtures:
+		return "once"
+	}
+	return fmt.Sprintf("FixtureMode(%d)", int(m))
+}
+
+// ParseFixtureMode parses the name of a mode ("replay", "record" or "once")
+func ParseFixtureMode(name string) (FixtureMode, error) {
+	for _, mode := range []FixtureMode{ReplayFixtures, RecordFixtures, RecordMissingFixtures} {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown fixture mode %q", name)
+}
+
+// FixtureOptions configures recording and replaying of HTTP responses
+type FixtureOptions struct {
+	// Dir holds the fixture files. Fixtures are disabled when empty.
+	Dir  string
+	Mode FixtureMode
+}
+
+// fixture is the file format of a recorded exchange
+type fixture struct {
+	Request  fixtureRequest  `json:"request"`
+	Response fixtureResponse `json:"response"`
+}
+
+// fixtureRequest identifies the request of a fixture
+type fixtureRequest struct {
+	Method string `json:"method"`
+	URI    string `json:"uri"`
+	// Accept picks the format of the response
+	Accept string `json:"accept,omitempty"`
+	// BodySHA256 is the hash of the body of a request that isn't a GET
+	BodySHA256 string `json:"bodySha256,omitempty"`
+}
+
+type fixtureResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// newFixtureRequest returns the key of the fixture of a request. The body of a
+// request that isn't a GET is read from GetBody, or read and put back.
+func newFixtureRequest(req *http.Request) (fixtureRequest, error) {
+	key := fixtureRequest{Method: req.Method, URI: req.URL.RequestURI(), Accept: req.Header.Get("Accept")}
+	if req.Method == http.MethodGet || req.Body == nil || req.Body == http.NoBody {
+		return key, nil
+	}
+
+	var body []byte
+	var err error
+	if req.GetBody != nil {
+		var reader io.ReadCloser
+		if reader, err = req.GetBody(); err == nil {
+			body, err = io.ReadAll(reader)
+			reader.Close()
+		}
+	} else {
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if err != nil {
+		return key, fmt.Errorf("fixture request body error: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	key.BodySHA256 = hex.EncodeToString(sum[:])
+	return key, nil
+}
+
+// fixturePath returns the file of a request. Fixtures are keyed by method, path and query,
+// Accept header and body, but not by host, so responses recorded from one server can be
+// replayed for any BaseURL.
+func fixturePath(dir string, key fixtureRequest) string {
+	sum := sha256.Sum256([]byte(key.Method + " " + key.URI + "\n" + key.Accept + "\n" + key.BodySHA256))
+	path, _, _ := strings.Cut(key.URI, "?")
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(path, "_"), "_")
+	return filepath.Join(dir, fmt.Sprintf("%s_%s_%s.json", key.Method, name, hex.EncodeToString(sum[:4])))
+}
+
+// Fixtures returns a middleware that records responses to, or replays them from, JSON files,
+// so tests can run without the test server. Bodies are stored decompressed.
+func Fixtures(options FixtureOptions) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			key, err := newFixtureRequest(req)
+			if err != nil {
+				return nil, err
+			}
+			path := fixturePath(options.Dir, key)
+			if options.Mode != RecordFixtures {
+				response, err := replayFixture(path, req)
+				if err == nil || options.Mode == ReplayFixtures || !errors.Is(err, ErrFixtureNotFound) {
+					return response, err
+				}
+			}
+
+			response, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+			return recordFixture(path, key, response)
+		})
+	}
+}
+
+// replayFixture builds the response of req from its fixture file
+func replayFixture(path string, req *http.Request) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s (%s)", ErrFixtureNotFound, req.Method, req.URL.RequestURI(), path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fixture read error: %w", err)
+	}
+	var recorded fixture
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("fixture %s unmarshal error: %w", path, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Response.StatusCode, http.StatusText(recorded.Response.StatusCode)),
+		StatusCode:    recorded.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Response.Header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Response.Body)),
+		ContentLength: int64(len(recorded.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// recordFixture saves the response to its fixture file and returns an equivalent response
+// with the decompressed body
+func recordFixture(path string, key fixtureRequest, response *http.Response) (*http.Response, error) {
+	defer response.Body.Close()
+	body, err := readBody(response, DefaultMaxBodySize)
+	if err != nil {
+		return nil, fmt.Errorf("fixture body error: %w", err)
+	}
+
+	header := response.Header.Clone()
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	header.Del("Date")
+	recorded := fixture{
+		Request: key,
+		Response: fixtureResponse{
+			StatusCode: response.StatusCode,
+			Header:     header,
+			Body:       string(body),
+		},
+	}
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("fixture marshal error: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("fixture directory error: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return nil, fmt.Errorf("fixture write error: %w", err)
+	}
+
+	response.Header = header
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	response.Uncompressed = true
+	return response, nil
+}
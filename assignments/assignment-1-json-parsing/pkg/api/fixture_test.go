@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"assignment1/pkg/apitest"
+)
+
+// offlineURL is never listened on, so any request that reaches the network fails
+const offlineURL = "http://127.0.0.1:1"
+
+func TestFixturesReplay(t *testing.T) {
+	// testdata/fixtures was recorded from the test-server with -fixture-mode record
+	apiInstance := New(Options{
+		BaseURL:  offlineURL,
+		Fixtures: FixtureOptions{Dir: "testdata/fixtures", Mode: ReplayFixtures},
+	})
+
+	response, err := apiInstance.GetAssignmentData(context.Background(), "/assignment1")
+	if err != nil {
+		t.Fatalf("GetAssignmentData error: %s", err)
+	}
+	if assignmentData := response.(AssignmentData); assignmentData.Page != "assignment1" || len(assignmentData.Special) != 3 {
+		t.Errorf("Unexpected replayed response: %+v", assignmentData)
+	}
+
+	_, err = apiInstance.GetAssignmentData(context.Background(), "/unrecorded")
+	if !errors.Is(err, ErrFixtureNotFound) {
+		t.Errorf("Expected error to match ErrFixtureNotFound, got %v", err)
+	}
+}
+
+func TestFixturesRecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	server := apitest.NewServer(t)
+
+	recorder := New(Options{
+		BaseURL:  server.URL,
+		Fixtures: FixtureOptions{Dir: dir, Mode: RecordMissingFixtures},
+	})
+	for _, endpoint := range []string{apitest.GzipPath, apitest.NotFoundPath} {
+		recorder.GetAssignmentData(context.Background(), endpoint)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("Expected 2 fixtures, got %v (%v)", files, err)
+	}
+
+	server.Close()
+	replayer := New(Options{
+		BaseURL:  offlineURL,
+		Fixtures: FixtureOptions{Dir: dir, Mode: ReplayFixtures},
+	})
+	response, err := replayer.GetAssignmentData(context.Background(), apitest.GzipPath)
+	if err != nil {
+		t.Fatalf("Replay error: %s", err)
+	}
+	if response.(AssignmentData).Page != "assignment1" {
+		t.Errorf("Unexpected replayed response: %+v", response)
+	}
+	_, err = replayer.GetAssignmentData(context.Background(), apitest.NotFoundPath)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the recorded 404 to be replayed, got %v", err)
+	}
+}
+
+func TestParseFixtureMode(t *testing.T) {
+	for _, mode := range []FixtureMode{ReplayFixtures, RecordFixtures, RecordMissingFixtures} {
+		parsed, err := ParseFixtureMode(mode.String())
+		if err != nil || parsed != mode {
+			t.Errorf("Expected %s, got %s (%v)", mode, parsed, err)
+		}
+	}
+	if _, err := ParseFixtureMode("rewind"); err == nil {
+		t.Errorf("Expected error for unknown mode")
+	}
+}
+
+func TestFixturePath(t *testing.T) {
+	newRequest := func(method, target, accept, body string) *http.Request {
+		req, _ := http.NewRequest(method, "http://localhost:8080"+target, strings.NewReader(body))
+		if body == "" {
+			req, _ = http.NewRequest(method, "http://localhost:8080"+target, nil)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		return req
+	}
+	path := func(req *http.Request) string {
+		key, err := newFixtureRequest(req)
+		if err != nil {
+			t.Fatalf("newFixtureRequest error: %s", err)
+		}
+		return fixturePath("fixtures", key)
+	}
+
+	jsonPath := path(newRequest(http.MethodGet, "/assignment1", MediaTypeJSON, ""))
+	tests := []struct {
+		name string
+		req  *http.Request
+		same bool
+	}{
+		{name: "same request", req: newRequest(http.MethodGet, "/assignment1", MediaTypeJSON, ""), same: true},
+		{name: "other host", req: func() *http.Request {
+			req := newRequest(http.MethodGet, "/assignment1", MediaTypeJSON, "")
+			req.URL.Host = "example.com"
+			return req
+		}(), same: true},
+		{name: "other accept", req: newRequest(http.MethodGet, "/assignment1", MediaTypeYAML, "")},
+		{name: "no accept", req: newRequest(http.MethodGet, "/assignment1", "", "")},
+		{name: "other query", req: newRequest(http.MethodGet, "/assignment1?page=2", MediaTypeJSON, "")},
+		{name: "other method", req: newRequest(http.MethodPost, "/assignment1", MediaTypeJSON, "")},
+	}
+	for _, test := range tests {
+		if got := path(test.req); (got == jsonPath) != test.same {
+			t.Errorf("%s: expected the same path %t, got %s and %s", test.name, test.same, jsonPath, got)
+		}
+	}
+
+	login := path(newRequest(http.MethodPost, "/login", MediaTypeJSON, `{"password":"a"}`))
+	if other := path(newRequest(http.MethodPost, "/login", MediaTypeJSON, `{"password":"b"}`)); other == login {
+		t.Errorf("Expected requests with other bodies to have other fixtures, got %s", login)
+	}
+	if same := path(newRequest(http.MethodPost, "/login", MediaTypeJSON, `{"password":"a"}`)); same != login {
+		t.Errorf("Expected requests with the same body to have the same fixture, got %s and %s", login, same)
+	}
+}
+
+func TestFixtureRequestKeepsBody(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/login", nil)
+	req.Body = io.NopCloser(strings.NewReader("body without GetBody"))
+	if _, err := newFixtureRequest(req); err != nil {
+		t.Fatalf("newFixtureRequest error: %s", err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != "body without GetBody" {
+		t.Errorf("Expected the body to be put back, got %q", body)
+	}
+}
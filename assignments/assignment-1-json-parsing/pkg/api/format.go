@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Media types the client can decode
+const (
+	MediaTypeJSON = "application/json"
+	MediaTypeYAML = "application/yaml"
+	MediaTypeXML  = "application/xml"
+)
+
+// DefaultAccept asks for JSON, but accepts YAML and XML as well
+const DefaultAccept = "application/json, application/yaml;q=0.9, application/xml;q=0.8"
+
+// mediaType maps a Content-Type header to one of the supported media types.
+// Anything unknown is treated as JSON.
+func mediaType(contentType string) string {
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return MediaTypeJSON
+	}
+	switch parsed {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return MediaTypeYAML
+	case "application/xml", "text/xml":
+		return MediaTypeXML
+	}
+	return MediaTypeJSON
+}
+
+// UnmarshalYAML decodes an integer, a string or null YAML scalar into the union
+func (v *Value) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: value is not an int, string or null", node.Line)
+	}
+	switch node.ShortTag() {
+	case "!!null":
+		*v = NewNull()
+	case "!!str":
+		*v = NewString(node.Value)
+	case "!!int":
+		var i int64
+		if err := node.Decode(&i); err != nil {
+			return err
+		}
+		*v = NewInt(i)
+	default:
+		return fmt.Errorf("line %d: value %q is not an int, string or null", node.Line, node.Value)
+	}
+	return nil
+}
+
+// MarshalYAML encodes the value back into its original YAML type
+func (v Value) MarshalYAML() (interface{}, error) {
+	switch v.kind {
+	case IntValue:
+		return v.intValue, nil
+	case StringValue:
+		return v.stringValue, nil
+	}
+	return nil, nil
+}
+
+// xmlAssignment is the XML representation of AssignmentData:
+//
+//	<assignment1>
+//	  <page>assignment1</page>
+//	  <words><word>one</word></words>
+//	  <percentages><percentage word="one">0.33</percentage></percentages>
+//	  <special><item>one</item><item null="true"/></special>
+//	  <extraSpecial><value type="int">1</value><value type="string">3</value></extraSpecial>
+//	</assignment1>
+type xmlAssignment struct {
+	XMLName      xml.Name        `xml:"assignment1"`
+	Page         string          `xml:"page"`
+	Words        []string        `xml:"words>word"`
+	Percentages  []xmlPercentage `xml:"percentages>percentage"`
+	Special      []xmlItem       `xml:"special>item"`
+	ExtraSpecial []xmlValue      `xml:"extraSpecial>value"`
+	Pagination   *Pagination     `xml:"pagination"`
+}
+
+type xmlPercentage struct {
+	Word  string  `xml:"word,attr"`
+	Value float64 `xml:",chardata"`
+}
+
+type xmlItem struct {
+	Null  bool   `xml:"null,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type xmlValue struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// UnmarshalXML decodes the xmlAssignment representation into AssignmentData
+func (a *AssignmentData) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var in xmlAssignment
+	if err := d.DecodeElement(&in, &start); err != nil {
+		return err
+	}
+
+	out := AssignmentData{
+		Page:        in.Page,
+		Words:       in.Words,
+		Percentages: make(map[string]float64, len(in.Percentages)),
+		Pagination:  in.Pagination,
+	}
+	for _, p := range in.Percentages {
+		out.Percentages[p.Word] = p.Value
+	}
+	for _, item := range in.Special {
+		if item.Null {
+			out.Special = append(out.Special, nil)
+			continue
+		}
+		value := item.Value
+		out.Special = append(out.Special, &value)
+	}
+	for _, v := range in.ExtraSpecial {
+		switch v.Type {
+		case "null":
+			out.ExtraSpecial = append(out.ExtraSpecial, NewNull())
+		case "string":
+			out.ExtraSpecial = append(out.ExtraSpecial, NewString(v.Value))
+		case "int":
+			i, err := strconv.ParseInt(v.Value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("extraSpecial value %q is not an int: %w", v.Value, err)
+			}
+			out.ExtraSpecial = append(out.ExtraSpecial, NewInt(i))
+		default:
+			return fmt.Errorf("extraSpecial value has unknown type %q", v.Type)
+		}
+	}
+
+	*a = out
+	return nil
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"assignment1/pkg/apitest"
+)
+
+const yamlBody = `page: assignment1
+words: [one, two]
+percentages:
+  one: 0.33
+  two: 0.66
+special: [one, two, null]
+extraSpecial: [1, 2, "3"]
+`
+
+const xmlBody = `<assignment1>
+  <page>assignment1</page>
+  <words><word>one</word><word>two</word></words>
+  <percentages><percentage word="one">0.33</percentage><percentage word="two">0.66</percentage></percentages>
+  <special><item>one</item><item>two</item><item null="true"/></special>
+  <extraSpecial><value type="int">1</value><value type="int">2</value><value type="string">3</value></extraSpecial>
+</assignment1>`
+
+func TestGetAssignmentDataFormats(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		body        string
+	}{
+		"yaml": {contentType: "application/yaml", body: yamlBody},
+		"xml":  {contentType: "application/xml; charset=utf-8", body: xmlBody},
+	}
+
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{BaseURL: server.URL})
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server.HandleResponse("/"+name, http.StatusOK, test.contentType, test.body)
+
+			response, err := apiInstance.GetAssignmentData(context.Background(), "/"+name)
+			if err != nil {
+				t.Fatalf("GetAssignmentData error: %s", err)
+			}
+			assignmentData := response.(AssignmentData)
+
+			if assignmentData.Page != "assignment1" || len(assignmentData.Words) != 2 {
+				t.Errorf("Unexpected page or words: %s %v", assignmentData.Page, assignmentData.Words)
+			}
+			if assignmentData.Percentages["two"] != 0.66 {
+				t.Errorf("Expected percentage for 'two' to be 0.66, got %f", assignmentData.Percentages["two"])
+			}
+			if len(assignmentData.Special) != 3 || assignmentData.Special[2] != nil || *assignmentData.Special[1] != "two" {
+				t.Errorf("Unexpected special: %v", assignmentData.Special)
+			}
+			if len(assignmentData.ExtraSpecial) != 3 {
+				t.Fatalf("Expected 3 items in ExtraSpecial, got %d", len(assignmentData.ExtraSpecial))
+			}
+			if i, ok := assignmentData.ExtraSpecial[0].AsInt(); !ok || i != 1 {
+				t.Errorf("Expected first item in ExtraSpecial to be int 1")
+			}
+			if s, ok := assignmentData.ExtraSpecial[2].AsString(); !ok || s != "3" {
+				t.Errorf("Expected third item in ExtraSpecial to be string '3'")
+			}
+		})
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	tests := map[string]string{
+		"":                                MediaTypeJSON,
+		"application/json":                MediaTypeJSON,
+		"text/plain; charset=utf-8":       MediaTypeJSON,
+		"application/x-yaml":              MediaTypeYAML,
+		"text/xml; charset=utf-8":         MediaTypeXML,
+		"application/yaml; charset=utf-8": MediaTypeYAML,
+	}
+	for contentType, expected := range tests {
+		if got := mediaType(contentType); got != expected {
+			t.Errorf("mediaType(%q) = %s, expected %s", contentType, got, expected)
+		}
+	}
+}
@@ -1,22 +1,76 @@
 package api
 
 import (
+	"context"
+	"iter"
+	"log"
 	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Options contains configuration for the API client
 type Options struct {
 	BaseURL string
+	Retry   RetryPolicy
+	// RetryBudget limits the retries of all requests together (unlimited when zero)
+	RetryBudget RetryBudget
+	// CircuitBreaker fails requests fast with ErrCircuitOpen while the server keeps failing
+	CircuitBreaker CircuitBreakerOptions
+	// ValidateSchema checks every JSON response against the embedded JSON Schema before unmarshaling
+	ValidateSchema bool
+	// StrictJSON rejects JSON responses with fields unknown to, or missing from, AssignmentData
+	StrictJSON bool
+	// Accept is sent as the Accept header (DefaultAccept when empty)
+	Accept string
+	// Middlewares wrap the HTTP transport, the first one being the outermost layer.
+	// The telemetry, TokenSource, Retry, CircuitBreaker, RateLimit and Debug layers are applied after (inside) them, in that order.
+	Middlewares []Middleware
+	// Debug dumps every request and response that goes over the wire to the Logger
+	Debug bool
+	// Logger receives debug output (log.Default() when nil)
+	Logger *log.Logger
+	// Cache keeps successful responses in memory, keyed by endpoint
+	Cache CacheOptions
+	// TokenSource, when set, supplies a bearer token for every request (see LoginTokenSource)
+	TokenSource TokenSource
+	// RateLimit spaces out requests sent by this client, including retries
+	RateLimit RateLimit
+	// Timeout limits every request, including retries and reading the body (0 means no timeout)
+	Timeout time.Duration
+	// Transport is the base of the middleware chain (http.DefaultTransport when nil)
+	Transport http.RoundTripper
+	// InsecureSkipVerify disables TLS certificate verification. Only use it for test servers.
+	InsecureSkipVerify bool
+	// CAFile is a PEM bundle of CA certificates trusted in addition to the system pool
+	CAFile string
+	// Concurrency is the maximum number of requests GetMany runs in parallel (4 when 0)
+	Concurrency int
+	// MeterProvider, when set, records request, error and latency metrics
+	MeterProvider metric.MeterProvider
+	// TracerProvider, when set, creates a client span per request
+	TracerProvider trace.TracerProvider
+	// Fixtures records responses to files, or replays them without the test server
+	Fixtures FixtureOptions
+	// MaxBodySize limits the decompressed size of a response body (DefaultMaxBodySize when 0)
+	MaxBodySize int64
 }
 
 // ClientIface defines the interface for HTTP client operations
 type ClientIface interface {
-	Get(url string) (resp *http.Response, err error)
+	Do(req *http.Request) (resp *http.Response, err error)
 }
 
 // APIIface defines the interface for our API operations
 type APIIface interface {
-	GetAssignmentData(endpoint string) (Response, error)
+	GetAssignmentData(ctx context.Context, endpoint string) (Response, error)
+	GetAllPages(ctx context.Context, endpoint string) ([]AssignmentData, error)
+	Pages(ctx context.Context, endpoint string) iter.Seq2[AssignmentData, error]
+	InvalidateCache(endpoints ...string)
+	GetMany(ctx context.Context, endpoints []string) ([]Response, error)
+	Compare(ctx context.Context, endpoint, baseURLA, baseURLB string) ([]Difference, error)
 }
 
 // Response interface for different response types
@@ -28,12 +82,46 @@ type Response interface {
 type api struct {
 	Options Options
 	Client  ClientIface
+	cache   *responseCache
 }
 
 // New creates a new API client instance
 func New(options Options) APIIface {
+	middlewares := append([]Middleware{}, options.Middlewares...)
+	if options.MeterProvider != nil || options.TracerProvider != nil {
+		// outside of the retries, so a call is measured once, including all its attempts
+		middlewares = append(middlewares, Instrument(options.MeterProvider, options.TracerProvider))
+	}
+	if options.TokenSource != nil {
+		middlewares = append(middlewares, BearerAuth(options.TokenSource))
+	}
+	if options.Retry.enabled() {
+		middlewares = append(middlewares, RetryWithBudget(options.Retry, options.RetryBudget))
+	}
+	if options.CircuitBreaker.enabled() {
+		// inside the retries, so every attempt counts and an open circuit stops them
+		middlewares = append(middlewares, CircuitBreaker(options.CircuitBreaker))
+	}
+	if options.RateLimit.enabled() {
+		middlewares = append(middlewares, RateLimited(options.RateLimit))
+	}
+	if options.Debug {
+		// innermost, so every attempt is dumped exactly as it is sent
+		middlewares = append(middlewares, DebugLogging(options.Logger))
+	}
+
+	transport := baseTransport(options)
+	if options.Fixtures.Dir != "" {
+		// at the bottom of the chain, standing in for the network
+		transport = Fixtures(options.Fixtures)(transport)
+	}
+
 	return api{
 		Options: options,
-		Client:  &http.Client{},
+		Client: &http.Client{
+			Transport: Chain(transport, middlewares...),
+			Timeout:   options.Timeout,
+		},
+		cache: newResponseCache(options.Cache),
 	}
 }
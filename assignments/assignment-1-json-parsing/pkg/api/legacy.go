@@ -0,0 +1,27 @@
+package api
+
+import "context"
+
+// LegacyAPIIface is the API as it was before GetAssignmentData accepted a context.
+//
+// Deprecated: use APIIface and pass a context to GetAssignmentData.
+type LegacyAPIIface interface {
+	GetAssignmentData(endpoint string) (Response, error)
+}
+
+// legacyAPI adapts an APIIface to the context-free method set
+type legacyAPI struct {
+	api APIIface
+}
+
+// NewLegacy creates an API client with the context-free method set
+//
+// Deprecated: use New.
+func NewLegacy(options Options) LegacyAPIIface {
+	return legacyAPI{api: New(options)}
+}
+
+// GetAssignmentData calls the context-aware API with context.Background()
+func (l legacyAPI) GetAssignmentData(endpoint string) (Response, error) {
+	return l.api.GetAssignmentData(context.Background(), endpoint)
+}
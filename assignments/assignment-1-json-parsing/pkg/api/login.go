@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// LoginRequest is the body posted to the test-server /login endpoint
+type LoginRequest struct {
+	Password string `json:"password"`
+}
+
+// LoginResponse is the reply of the test-server /login endpoint
+type LoginResponse struct {
+	Token string `json:"token"`
+}
+
+// LoginTokenSource obtains tokens with the http-login flow: the password is posted
+// to LoginURL and the returned JWT is reused until it is invalidated
+type LoginTokenSource struct {
+	LoginURL string
+	Password string
+	Client   ClientIface
+
+	mu    sync.Mutex
+	token string
+}
+
+// NewLoginTokenSource creates a LoginTokenSource using its own HTTP client
+func NewLoginTokenSource(loginURL, password string) *LoginTokenSource {
+	return &LoginTokenSource{
+		LoginURL: loginURL,
+		Password: password,
+		Client:   &http.Client{},
+	}
+}
+
+// Token returns the cached token, logging in first if there is none
+func (l *LoginTokenSource) Token(ctx context.Context) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.token != "" {
+		return l.token, nil
+	}
+	token, err := doLoginRequest(ctx, l.Client, l.LoginURL, l.Password)
+	if err != nil {
+		return "", err
+	}
+	l.token = token
+	return l.token, nil
+}
+
+// Invalidate forgets the cached token, so the next call to Token logs in again
+func (l *LoginTokenSource) Invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.token = ""
+}
+
+func doLoginRequest(ctx context.Context, client ClientIface, requestURL, password string) (string, error) {
+	body, err := json.Marshal(LoginRequest{Password: password})
+	if err != nil {
+		return "", fmt.Errorf("Marshal error: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("NewRequest error: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("HTTP Post error: %w", err)
+	}
+	defer response.Body.Close()
+
+	resBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("ReadAll error: %w", err)
+	}
+
+	if response.StatusCode != 200 {
+		return "", RequestError{
+			HTTPCode: response.StatusCode,
+			Body:     string(resBody),
+			Err:      statusError(response.StatusCode),
+		}
+	}
+
+	var loginResponse LoginResponse
+	err = json.Unmarshal(resBody, &loginResponse)
+	if err != nil {
+		return "", RequestError{
+			HTTPCode: response.StatusCode,
+			Body:     string(resBody),
+			Err:      fmt.Errorf("%w: login unmarshal error: %w", ErrBadJSON, err),
+		}
+	}
+
+	if loginResponse.Token == "" {
+		return "", RequestError{
+			HTTPCode: response.StatusCode,
+			Body:     string(resBody),
+			Err:      errors.New("empty token replied"),
+		}
+	}
+
+	return loginResponse.Token, nil
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+// defaultConcurrency is used by GetMany when Options.Concurrency isn't set
+const defaultConcurrency = 4
+
+// GetMany fetches the endpoints concurrently, with at most Options.Concurrency
+// requests in flight, and returns the responses in the order of the endpoints.
+// The first error cancels the requests that are still running.
+func (a api) GetMany(ctx context.Context, endpoints []string) ([]Response, error) {
+	concurrency := a.Options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	sem := semaphore.NewWeighted(int64(concurrency))
+	g, groupCtx := errgroup.WithContext(ctx)
+
+	// every goroutine writes to its own index, so no locking is needed
+	responses := make([]Response, len(endpoints))
+	for i, endpoint := range endpoints {
+		if err := sem.Acquire(groupCtx, 1); err != nil {
+			// a request failed or the caller gave up
+			break
+		}
+		g.Go(func() error {
+			defer sem.Release(1)
+			response, err := a.GetAssignmentData(groupCtx, endpoint)
+			if err != nil {
+				return fmt.Errorf("%s: %w", endpoint, err)
+			}
+			responses[i] = response
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		// the caller gave up before all requests were started
+		return nil, err
+	}
+	return responses, nil
+}
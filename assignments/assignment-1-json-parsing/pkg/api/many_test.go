@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"assignment1/pkg/apitest"
+)
+
+func TestGetMany(t *testing.T) {
+	server := apitest.NewServer(t)
+	var inFlight, maxInFlight atomic.Int32
+	for i := 0; i < 6; i++ {
+		page := fmt.Sprintf("page%d", i)
+		server.Handle("/"+page, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				observed := maxInFlight.Load()
+				if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			fmt.Fprintf(w, `{"page":%q}`, page)
+		}))
+	}
+	apiInstance := New(Options{BaseURL: server.URL, Concurrency: 2})
+
+	endpoints := []string{"/page0", "/page1", "/page2", "/page3", "/page4", "/page5"}
+	responses, err := apiInstance.GetMany(context.Background(), endpoints)
+	if err != nil {
+		t.Fatalf("GetMany error: %s", err)
+	}
+	if len(responses) != len(endpoints) {
+		t.Fatalf("Expected %d responses, got %d", len(endpoints), len(responses))
+	}
+	for i, response := range responses {
+		if page := response.(AssignmentData).Page; page != fmt.Sprintf("page%d", i) {
+			t.Errorf("Expected response %d to be page%d, got %s", i, i, page)
+		}
+	}
+	if maxInFlight.Load() > 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", maxInFlight.Load())
+	}
+}
+
+func TestGetManyError(t *testing.T) {
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{BaseURL: server.URL})
+
+	_, err := apiInstance.GetMany(context.Background(), []string{apitest.ValidPath, apitest.NotFoundPath, apitest.SlowPath})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected error to match ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import "net/http"
+
+// RoundTripperFunc lets an ordinary function act as an http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements the http.RoundTripper interface
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a RoundTripper with cross-cutting behavior (logging, auth, retries, metrics, ...)
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Chain wraps transport with the middlewares. The first middleware is the outermost
+// layer: it sees the request first and the response last.
+func Chain(transport http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+	return transport
+}
+
+// Retry returns a middleware retrying transient failures according to the policy
+func Retry(policy RetryPolicy) Middleware {
+	return RetryWithBudget(policy, RetryBudget{})
+}
+
+// RetryWithBudget returns a middleware retrying transient failures according to the policy,
+// as long as the budget shared by all requests going through it allows
+func RetryWithBudget(policy RetryPolicy, budget RetryBudget) Middleware {
+	state := newRetryBudget(budget)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RetryTransport{
+			transport: next,
+			policy:    policy,
+			budget:    state,
+		}
+	}
+}
+
+// SetHeader returns a middleware setting a header on every outgoing request
+func SetHeader(key, value string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set(key, value)
+			return next.RoundTrip(req)
+		})
+	}
+}
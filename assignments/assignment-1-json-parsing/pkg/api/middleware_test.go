@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	order := []string{}
+	record := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" request")
+				res, err := next.RoundTrip(req)
+				order = append(order, name+" response")
+				return res, err
+			})
+		}
+	}
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "transport")
+		if req.Header.Get("X-Test") != "yes" {
+			t.Errorf("Expected X-Test header to be set by the middleware")
+		}
+		return &http.Response{StatusCode: 200}, nil
+	})
+
+	chain := Chain(transport, record("outer"), record("inner"), SetHeader("X-Test", "yes"))
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/assignment1", nil)
+	if _, err := chain.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+
+	expected := []string{"outer request", "inner request", "transport", "inner response", "outer response"}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, order)
+			break
+		}
+	}
+	if req.Header.Get("X-Test") != "" {
+		t.Errorf("SetHeader must not modify the caller's request")
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+// maxPages stops pagination when a server keeps returning a next page
+const maxPages = 1000
+
+// Pagination describes the position of a page in a paginated response
+type Pagination struct {
+	Page       int    `json:"page" yaml:"page" xml:"page,attr"`
+	TotalPages int    `json:"totalPages" yaml:"totalPages" xml:"totalPages,attr"`
+	NextCursor string `json:"nextCursor,omitempty" yaml:"nextCursor,omitempty" xml:"nextCursor,attr,omitempty"`
+}
+
+// Pages returns a lazy iterator over all pages of a paginated endpoint.
+// Pages are only fetched while the caller keeps iterating; iteration stops after the first error.
+func (a api) Pages(ctx context.Context, endpoint string) iter.Seq2[AssignmentData, error] {
+	return func(yield func(AssignmentData, error) bool) {
+		next := endpoint
+		for i := 0; next != ""; i++ {
+			if i == maxPages {
+				yield(AssignmentData{}, fmt.Errorf("pagination stopped after %d pages", maxPages))
+				return
+			}
+
+			response, err := a.GetAssignmentData(ctx, next)
+			if err != nil {
+				yield(AssignmentData{}, err)
+				return
+			}
+			page, ok := response.(AssignmentData)
+			if !ok {
+				yield(AssignmentData{}, fmt.Errorf("unexpected response type %T", response))
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+
+			next, err = nextPageEndpoint(next, page.Pagination)
+			if err != nil {
+				yield(AssignmentData{}, err)
+				return
+			}
+		}
+	}
+}
+
+// GetAllPages fetches every page of a paginated endpoint
+func (a api) GetAllPages(ctx context.Context, endpoint string) ([]AssignmentData, error) {
+	pages := []AssignmentData{}
+	for page, err := range a.Pages(ctx, endpoint) {
+		if err != nil {
+			return pages, err
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// nextPageEndpoint returns the endpoint of the page after the current one, or "" on the last page.
+// A cursor takes precedence over page numbers.
+func nextPageEndpoint(endpoint string, pagination *Pagination) (string, error) {
+	if pagination == nil {
+		return "", nil
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("endpoint parse error: %w", err)
+	}
+	query := parsed.Query()
+
+	switch {
+	case pagination.NextCursor != "":
+		query.Del("page")
+		query.Set("cursor", pagination.NextCursor)
+	case pagination.Page > 0 && pagination.Page < pagination.TotalPages:
+		query.Del("cursor")
+		query.Set("page", strconv.Itoa(pagination.Page+1))
+	default:
+		return "", nil
+	}
+
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// pagedClient serves 3 pages, either linked by cursor or by page number
+func pagedClient(withCursor bool, requests *[]string) *http.Client {
+	return &http.Client{
+		Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			*requests = append(*requests, req.URL.RequestURI())
+			page := 1
+			switch {
+			case req.URL.Query().Get("cursor") != "":
+				fmt.Sscanf(req.URL.Query().Get("cursor"), "c%d", &page)
+			case req.URL.Query().Get("page") != "":
+				fmt.Sscanf(req.URL.Query().Get("page"), "%d", &page)
+			}
+			nextCursor := ""
+			if withCursor && page < 3 {
+				nextCursor = fmt.Sprintf("c%d", page+1)
+			}
+			body := fmt.Sprintf(`{"page":"assignment1","words":["word%d"],"pagination":{"page":%d,"totalPages":3,"nextCursor":%q}}`, page, page, nextCursor)
+			return &http.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}, nil
+		}),
+	}
+}
+
+func TestGetAllPages(t *testing.T) {
+	tests := map[string]struct {
+		withCursor       bool
+		expectedRequests []string
+	}{
+		"cursor": {
+			withCursor:       true,
+			expectedRequests: []string{"/assignment1/pages", "/assignment1/pages?cursor=c2", "/assignment1/pages?cursor=c3"},
+		},
+		"page number": {
+			withCursor:       false,
+			expectedRequests: []string{"/assignment1/pages", "/assignment1/pages?page=2", "/assignment1/pages?page=3"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			requests := []string{}
+			apiInstance := api{
+				Options: Options{BaseURL: "http://localhost:8080"},
+				Client:  pagedClient(test.withCursor, &requests),
+			}
+
+			pages, err := apiInstance.GetAllPages(context.Background(), "/assignment1/pages")
+			if err != nil {
+				t.Fatalf("GetAllPages error: %s", err)
+			}
+			if len(pages) != 3 {
+				t.Fatalf("Expected 3 pages, got %d", len(pages))
+			}
+			for i, page := range pages {
+				if page.Words[0] != fmt.Sprintf("word%d", i+1) {
+					t.Errorf("Expected word%d on page %d, got %v", i+1, i+1, page.Words)
+				}
+			}
+			if strings.Join(requests, " ") != strings.Join(test.expectedRequests, " ") {
+				t.Errorf("Expected requests %v, got %v", test.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestPagesStopsEarly(t *testing.T) {
+	requests := []string{}
+	apiInstance := api{
+		Options: Options{BaseURL: "http://localhost:8080"},
+		Client:  pagedClient(true, &requests),
+	}
+
+	for page, err := range apiInstance.Pages(context.Background(), "/assignment1/pages") {
+		if err != nil {
+			t.Fatalf("Pages error: %s", err)
+		}
+		if page.Pagination.Page == 1 {
+			break
+		}
+	}
+	if len(requests) != 1 {
+		t.Errorf("Expected only the first page to be fetched, got %v", requests)
+	}
+}
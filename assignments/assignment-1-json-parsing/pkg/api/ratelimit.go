@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"assignment-2-rate-limiting/pkg/ratelimiter"
+)
+
+// RateLimit configures client-side rate limiting
+type RateLimit struct {
+	// RequestsPerSecond is the sustained request rate. 0 disables rate limiting.
+	RequestsPerSecond float64
+	// Burst is the number of requests that may be sent at once (at least 1)
+	Burst int
+}
+
+// enabled reports whether requests need to be limited
+func (r RateLimit) enabled() bool {
+	return r.RequestsPerSecond > 0
+}
+
+// RateLimited returns a middleware that waits for the token bucket of the
+// assignment-2 rate limiter before sending every request
+func RateLimited(rateLimit RateLimit) Middleware {
+	bucket := ratelimiter.NewTokenBucket(rateLimit.RequestsPerSecond, rateLimit.Burst)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := bucket.Wait(req.Context()); err != nil {
+				return nil, err
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
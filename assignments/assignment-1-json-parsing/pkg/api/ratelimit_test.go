@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimited(t *testing.T) {
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	})
+	limited := Chain(transport, RateLimited(RateLimit{RequestsPerSecond: 20, Burst: 2}))
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/assignment1", nil)
+		if _, err := limited.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip error: %s", err)
+		}
+	}
+	// 2 requests of burst, then 2 requests at 50ms intervals
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected requests to be spread out, took %s", elapsed)
+	}
+}
+
+func TestRateLimitedCanceled(t *testing.T) {
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	})
+	limited := Chain(transport, RateLimited(RateLimit{RequestsPerSecond: 0.1, Burst: 1}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/assignment1", nil)
+	if _, err := limited.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8080/assignment1", nil)
+	if _, err := limited.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded while waiting, got %v", err)
+	}
+}
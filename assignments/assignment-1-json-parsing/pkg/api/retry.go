@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configures how transient failures are retried by the client
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// A value of 0 or 1 disables retries.
+	MaxAttempts int
+	// Backoff is the wait before the first retry. It doubles after every attempt.
+	Backoff time.Duration
+	// MaxBackoff caps the wait between two attempts (0 means no cap)
+	MaxBackoff time.Duration
+	// RetryableStatusCodes lists the HTTP codes worth retrying.
+	// Defaults to 502, 503 and 504 when empty.
+	RetryableStatusCodes []int
+	// RetryNonIdempotent allows retrying methods like POST and PATCH.
+	// Only idempotent methods are retried by default.
+	RetryNonIdempotent bool
+}
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// enabled reports whether the policy results in more than one attempt
+func (p RetryPolicy) enabled() bool {
+	return p.MaxAttempts > 1
+}
+
+func (p RetryPolicy) isRetryableStatus(code int) bool {
+	codes := p.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryableStatusCodes
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// canRetry is the idempotency guard: a request is only sent twice when that is safe
+func (p RetryPolicy) canRetry(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// the body can't be replayed
+		return false
+	}
+	if p.RetryNonIdempotent {
+		return true
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.Backoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if p.MaxBackoff > 0 && wait >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return wait
+}
+
+// RetryBudget limits retries across all requests of a client, so a server outage
+// doesn't multiply the load by MaxAttempts
+type RetryBudget struct {
+	// Ratio is the number of retries allowed per request sent, e.g. 0.2 for one retry
+	// every five requests. 0 disables the budget (retries are only limited by MaxAttempts).
+	Ratio float64
+	// MinRetries are always allowed within a window, so a client sending few requests can still retry
+	MinRetries int
+	// Window is the period over which requests and retries are counted (10s when 0)
+	Window time.Duration
+}
+
+// retryBudget is the state shared by all requests going through the Retry middleware
+type retryBudget struct {
+	budget RetryBudget
+	now    func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	requests    int
+	retries     int
+}
+
+// newRetryBudget returns nil when the budget is disabled. A nil *retryBudget allows every retry.
+func newRetryBudget(budget RetryBudget) *retryBudget {
+	if budget.Ratio <= 0 {
+		return nil
+	}
+	if budget.Window <= 0 {
+		budget.Window = 10 * time.Second
+	}
+	return &retryBudget{budget: budget, now: time.Now}
+}
+
+// resetWindow starts a new window when the current one is over. The caller holds the lock.
+func (b *retryBudget) resetWindow() {
+	if now := b.now(); now.Sub(b.windowStart) >= b.budget.Window {
+		b.windowStart, b.requests, b.retries = now, 0, 0
+	}
+}
+
+// recordRequest counts a request, which adds Ratio retries to the budget
+func (b *retryBudget) recordRequest() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resetWindow()
+	b.requests++
+}
+
+// allowRetry takes a retry from the budget, if there is one left
+func (b *retryBudget) allowRetry() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resetWindow()
+	if float64(b.retries+1) > float64(b.budget.MinRetries)+b.budget.Ratio*float64(b.requests) {
+		return false
+	}
+	b.retries++
+	return true
+}
+
+// RetryTransport retries requests that fail with a network error or a retryable status code
+type RetryTransport struct {
+	transport http.RoundTripper
+	policy    RetryPolicy
+	budget    *retryBudget
+	// sleep replaces the wait between the attempts in the tests
+	sleep func(time.Duration)
+}
+
+func (r RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !r.policy.enabled() || !r.policy.canRetry(req) {
+		return r.transport.RoundTrip(req)
+	}
+	ctx := req.Context()
+
+	r.budget.recordRequest()
+	attemptReq := req
+	for attempt := 1; ; attempt++ {
+		response, err := r.transport.RoundTrip(attemptReq)
+		if err == nil && !r.policy.isRetryableStatus(response.StatusCode) {
+			return response, nil
+		}
+		if attempt >= r.policy.MaxAttempts || errors.Is(err, ErrCircuitOpen) || ctx.Err() != nil || !r.budget.allowRetry() {
+			// out of attempts, failing fast, cancelled or out of budget: give the caller the last outcome
+			return response, err
+		}
+		if response != nil {
+			// discard the body so the connection can be reused
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+
+		if err := r.wait(ctx, r.policy.backoff(attempt)); err != nil {
+			return nil, err
+		}
+		// a RoundTripper must not modify the request, the next attempt is a copy with a fresh body
+		attemptReq = req.Clone(ctx)
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			attemptReq.Body = body
+		}
+	}
+}
+
+// wait sleeps for d, or until the request is cancelled
+func (r RetryTransport) wait(ctx context.Context, d time.Duration) error {
+	if r.sleep != nil {
+		r.sleep(d)
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
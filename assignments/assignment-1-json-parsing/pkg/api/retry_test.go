@@ -0,0 +1,199 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// MockRoundTripper returns the configured status codes in order
+type MockRoundTripper struct {
+	StatusCodes []int
+	calls       *int
+}
+
+func (m MockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := *m.calls
+	*m.calls++
+	if call >= len(m.StatusCodes) {
+		return nil, fmt.Errorf("unexpected call %d", call+1)
+	}
+	return &http.Response{
+		StatusCode: m.StatusCodes[call],
+		Body:       io.NopCloser(bytes.NewReader([]byte("body"))),
+	}, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	calls := 0
+	var waits []time.Duration
+	retryTransport := RetryTransport{
+		transport: MockRoundTripper{StatusCodes: []int{503, 502, 200}, calls: &calls},
+		policy:    RetryPolicy{MaxAttempts: 3, Backoff: time.Second},
+		sleep:     func(d time.Duration) { waits = append(waits, d) },
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/assignment1", nil)
+
+	res, err := retryTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	if res.StatusCode != 200 {
+		t.Errorf("expected status code 200, got %d", res.StatusCode)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+	if len(waits) != 2 || waits[0] != time.Second || waits[1] != 2*time.Second {
+		t.Errorf("unexpected backoff: %v", waits)
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	calls := 0
+	retryTransport := RetryTransport{
+		transport: MockRoundTripper{StatusCodes: []int{503, 503}, calls: &calls},
+		policy:    RetryPolicy{MaxAttempts: 2},
+		sleep:     func(time.Duration) {},
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/assignment1", nil)
+
+	res, err := retryTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	if res.StatusCode != 503 {
+		t.Errorf("expected last status code 503, got %d", res.StatusCode)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestRetryTransportIdempotencyGuard(t *testing.T) {
+	calls := 0
+	retryTransport := RetryTransport{
+		transport: MockRoundTripper{StatusCodes: []int{503, 200}, calls: &calls},
+		policy:    RetryPolicy{MaxAttempts: 3},
+		sleep:     func(time.Duration) {},
+	}
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/login", strings.NewReader("{}"))
+
+	res, err := retryTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	if res.StatusCode != 503 || calls != 1 {
+		t.Errorf("POST must not be retried (status %d, attempts %d)", res.StatusCode, calls)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	budget := newRetryBudget(RetryBudget{Ratio: 0.5, MinRetries: 1})
+	budget.now = func() time.Time { return time.Unix(0, 0) }
+
+	budget.recordRequest()
+	budget.recordRequest()
+	// 1 + 0.5 * 2 requests
+	for i := 0; i < 2; i++ {
+		if !budget.allowRetry() {
+			t.Errorf("Expected retry %d to be within the budget", i+1)
+		}
+	}
+	if budget.allowRetry() {
+		t.Errorf("Expected budget to be exhausted")
+	}
+
+	budget.now = func() time.Time { return time.Unix(10, 0) }
+	if !budget.allowRetry() {
+		t.Errorf("Expected a new window to allow MinRetries again")
+	}
+}
+
+func TestRetryTransportBudgetExhausted(t *testing.T) {
+	calls := 0
+	retryTransport := RetryTransport{
+		transport: MockRoundTripper{StatusCodes: []int{503, 503, 503}, calls: &calls},
+		policy:    RetryPolicy{MaxAttempts: 3},
+		budget:    newRetryBudget(RetryBudget{Ratio: 0.1, MinRetries: 1}),
+		sleep:     func(time.Duration) {},
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/assignment1", nil)
+
+	res, err := retryTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip error: %s", err)
+	}
+	if res.StatusCode != 503 || calls != 2 {
+		t.Errorf("Expected the budget to allow a single retry (status %d, attempts %d)", res.StatusCode, calls)
+	}
+}
+
+func TestRetryTransportCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	retryTransport := RetryTransport{
+		transport: RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+			calls++
+			cancel()
+			return nil, context.Canceled
+		}),
+		policy: RetryPolicy{MaxAttempts: 3},
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8080/assignment1", nil)
+
+	if _, err := retryTransport.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("a cancelled request must not be retried, got %d attempts", calls)
+	}
+}
+
+func TestRetryTransportCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	calls := 0
+	retryTransport := RetryTransport{
+		transport: MockRoundTripper{StatusCodes: []int{503, 200}, calls: &calls},
+		policy:    RetryPolicy{MaxAttempts: 2, Backoff: time.Hour},
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:8080/assignment1", nil)
+
+	start := time.Now()
+	if _, err := retryTransport.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second || calls != 1 {
+		t.Errorf("expected the backoff to end with the context (took %s, %d attempts)", elapsed, calls)
+	}
+}
+
+func TestRetryTransportDoesNotModifyRequest(t *testing.T) {
+	var bodies []string
+	retryTransport := RetryTransport{
+		transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+			return &http.Response{StatusCode: 503, Body: http.NoBody}, nil
+		}),
+		policy: RetryPolicy{MaxAttempts: 2, RetryNonIdempotent: true},
+		sleep:  func(time.Duration) {},
+	}
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/login", strings.NewReader(`{"password":"secret"}`))
+	originalBody := req.Body
+
+	retryTransport.RoundTrip(req)
+	if len(bodies) != 2 || bodies[1] != `{"password":"secret"}` {
+		t.Errorf("expected the body to be sent twice, got %q", bodies)
+	}
+	if req.Body != originalBody {
+		t.Errorf("the request of the caller was modified")
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+//go:embed assignment1.schema.json
+var assignmentSchemaJSON []byte
+
+// assignmentSchema compiles the embedded schema once, on first use
+var assignmentSchema = sync.OnceValues(func() (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(assignmentSchemaJSON))
+	if err != nil {
+		return nil, fmt.Errorf("schema unmarshal error: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("assignment1.schema.json", doc); err != nil {
+		return nil, fmt.Errorf("schema resource error: %w", err)
+	}
+	return compiler.Compile("assignment1.schema.json")
+})
+
+// SchemaViolation is a single mismatch between the response and the schema
+type SchemaViolation struct {
+	Path    string // JSON pointer to the offending value, e.g. /words/1
+	Message string
+}
+
+// SchemaError is returned when a response body doesn't match the embedded JSON Schema
+type SchemaError struct {
+	HTTPCode   int
+	Body       string
+	Violations []SchemaViolation
+}
+
+// Error implements the error interface for SchemaError
+func (s SchemaError) Error() string {
+	violations := make([]string, len(s.Violations))
+	for i, v := range s.Violations {
+		path := v.Path
+		if path == "" {
+			path = "/"
+		}
+		violations[i] = fmt.Sprintf("%s: %s", path, v.Message)
+	}
+	return fmt.Sprintf("response does not match schema: %s", strings.Join(violations, "; "))
+}
+
+// Unwrap makes errors.Is(err, ErrSchema) match schema errors
+func (s SchemaError) Unwrap() error {
+	return ErrSchema
+}
+
+// validateSchema checks a raw JSON body against the assignment schema
+// and returns the violations found, ordered by path
+func validateSchema(body []byte) ([]SchemaViolation, error) {
+	schema, err := assignmentSchema()
+	if err != nil {
+		return nil, err
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("body unmarshal error: %w", err)
+	}
+
+	err = schema.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+
+	violations := []SchemaViolation{}
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		violations = append(violations, SchemaViolation{
+			Path:    unit.InstanceLocation,
+			Message: unit.Error.String(),
+		})
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+	return violations, nil
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"assignment1/pkg/apitest"
+)
+
+func TestValidateSchema(t *testing.T) {
+	valid := `{"page":"assignment1","words":["one"],"percentages":{"one":0.33},"special":["one",null],"extraSpecial":[1,"3",null]}`
+	violations, err := validateSchema([]byte(valid))
+	if err != nil {
+		t.Fatalf("validateSchema error: %s", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+}
+
+func TestValidateSchemaViolations(t *testing.T) {
+	invalid := `{"page":"assignment1","words":["one",2],"percentages":{"one":1.5},"special":[],"extraSpecial":[1.5]}`
+	violations, err := validateSchema([]byte(invalid))
+	if err != nil {
+		t.Fatalf("validateSchema error: %s", err)
+	}
+
+	expectedPaths := []string{"/extraSpecial/0", "/percentages/one", "/words/1"}
+	if len(violations) != len(expectedPaths) {
+		t.Fatalf("Expected %d violations, got %v", len(expectedPaths), violations)
+	}
+	for i, path := range expectedPaths {
+		if violations[i].Path != path {
+			t.Errorf("Expected violation %d at %s, got %s (%s)", i, path, violations[i].Path, violations[i].Message)
+		}
+	}
+}
+
+func TestGetAssignmentDataSchemaError(t *testing.T) {
+	server := apitest.NewServer(t)
+	server.HandleResponse("/drifted", http.StatusOK, "application/json", `{"page":"assignment1"}`)
+	apiInstance := New(Options{BaseURL: server.URL, ValidateSchema: true})
+
+	if _, err := apiInstance.GetAssignmentData(context.Background(), apitest.ValidPath); err != nil {
+		t.Errorf("Expected valid response to pass the schema, got %s", err)
+	}
+
+	_, err := apiInstance.GetAssignmentData(context.Background(), "/drifted")
+	var schemaErr SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected SchemaError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, ErrSchema) {
+		t.Errorf("Expected error to match ErrSchema")
+	}
+	if len(schemaErr.Violations) == 0 {
+		t.Errorf("Expected violations for missing properties")
+	}
+}
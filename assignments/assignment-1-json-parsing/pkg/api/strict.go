@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StrictError is returned in StrictJSON mode when the fields of a response body
+// don't match the AssignmentData struct
+type StrictError struct {
+	HTTPCode int
+	Body     string
+	Unknown  []string // JSON pointers of fields the struct doesn't know, e.g. /pagination/limit
+	Missing  []string // JSON pointers of required fields absent from the body
+}
+
+// Error implements the error interface for StrictError
+func (s StrictError) Error() string {
+	var problems []string
+	if len(s.Unknown) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown fields %s", strings.Join(s.Unknown, ", ")))
+	}
+	if len(s.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("missing fields %s", strings.Join(s.Missing, ", ")))
+	}
+	return fmt.Sprintf("response does not match AssignmentData: %s", strings.Join(problems, "; "))
+}
+
+// Unwrap makes errors.Is(err, ErrStrictJSON) match strict decoding errors
+func (s StrictError) Unwrap() error {
+	return ErrStrictJSON
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// checkFields compares the object keys of a raw JSON value against the json tags of t,
+// recording the JSON pointer of every unknown and missing field
+func checkFields(path string, raw json.RawMessage, t reflect.Type, unknown, missing *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(raw, &object) != nil {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, omitempty, ok := jsonField(field)
+			if !ok {
+				continue
+			}
+			value, present := object[name]
+			if !present {
+				if !omitempty {
+					*missing = append(*missing, path+"/"+escapePointer(name))
+				}
+				continue
+			}
+			delete(object, name)
+			checkFields(path+"/"+escapePointer(name), value, field.Type, unknown, missing)
+		}
+		for name := range object {
+			*unknown = append(*unknown, path+"/"+escapePointer(name))
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if json.Unmarshal(raw, &items) != nil {
+			return
+		}
+		for i, item := range items {
+			checkFields(path+"/"+strconv.Itoa(i), item, t.Elem(), unknown, missing)
+		}
+	case reflect.Map:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(raw, &object) != nil {
+			return
+		}
+		for key, value := range object {
+			checkFields(path+"/"+escapePointer(key), value, t.Elem(), unknown, missing)
+		}
+	}
+}
+
+// jsonField returns the JSON name of a struct field and whether it is omitempty.
+// ok is false for unexported and ignored fields.
+func jsonField(field reflect.StructField) (name string, omitempty bool, ok bool) {
+	if !field.IsExported() {
+		return "", false, false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" || option == "omitzero" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, true
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// decodeStrict unmarshals body into v, rejecting unknown and missing fields
+func decodeStrict(httpCode int, body []byte, v any) error {
+	var unknown, missing []string
+	checkFields("", body, reflect.TypeOf(v), &unknown, &missing)
+	if len(unknown) > 0 || len(missing) > 0 {
+		sort.Strings(unknown)
+		sort.Strings(missing)
+		return StrictError{
+			HTTPCode: httpCode,
+			Body:     string(body),
+			Unknown:  unknown,
+			Missing:  missing,
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return RequestError{
+			HTTPCode: httpCode,
+			Body:     string(body),
+			Err:      fmt.Errorf("%w: JSON decode error: %w", ErrBadJSON, err),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"assignment1/pkg/apitest"
+)
+
+func TestDecodeStrict(t *testing.T) {
+	var assignmentData AssignmentData
+	if err := decodeStrict(http.StatusOK, []byte(apitest.ValidBody), &assignmentData); err != nil {
+		t.Fatalf("decodeStrict error: %s", err)
+	}
+	if assignmentData.Page != "assignment1" || len(assignmentData.ExtraSpecial) != 3 {
+		t.Errorf("Unexpected result: %+v", assignmentData)
+	}
+}
+
+func TestDecodeStrictFieldPaths(t *testing.T) {
+	drifted := `{"page":"assignment1","wordz":["one"],"percentages":{"one":0.33},"special":[],"extraSpecial":[],"pagination":{"page":1,"totalPages":2,"limit":4}}`
+	var assignmentData AssignmentData
+	err := decodeStrict(http.StatusOK, []byte(drifted), &assignmentData)
+
+	var strictErr StrictError
+	if !errors.As(err, &strictErr) {
+		t.Fatalf("Expected StrictError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, ErrStrictJSON) {
+		t.Errorf("Expected error to match ErrStrictJSON")
+	}
+	if expected := []string{"/pagination/limit", "/wordz"}; !reflect.DeepEqual(strictErr.Unknown, expected) {
+		t.Errorf("Expected unknown fields %v, got %v", expected, strictErr.Unknown)
+	}
+	if expected := []string{"/words"}; !reflect.DeepEqual(strictErr.Missing, expected) {
+		t.Errorf("Expected missing fields %v, got %v", expected, strictErr.Missing)
+	}
+}
+
+func TestGetAssignmentDataStrictJSON(t *testing.T) {
+	server := apitest.NewServer(t)
+	server.HandleResponse("/extra", http.StatusOK, "application/json", `{"page":"assignment1","words":[],"percentages":{},"special":[],"extraSpecial":[],"version":2}`)
+
+	lenient := New(Options{BaseURL: server.URL})
+	if _, err := lenient.GetAssignmentData(context.Background(), "/extra"); err != nil {
+		t.Errorf("Expected unknown fields to be ignored without StrictJSON, got %s", err)
+	}
+
+	strict := New(Options{BaseURL: server.URL, StrictJSON: true})
+	if _, err := strict.GetAssignmentData(context.Background(), apitest.ValidPath); err != nil {
+		t.Errorf("Expected valid response to pass strict decoding, got %s", err)
+	}
+	_, err := strict.GetAssignmentData(context.Background(), "/extra")
+	if !errors.Is(err, ErrStrictJSON) {
+		t.Errorf("Expected error to match ErrStrictJSON, got %v", err)
+	}
+}
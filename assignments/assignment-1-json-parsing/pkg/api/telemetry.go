@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"telemetry"
+)
+
+// instrumentationName identifies the meter and tracer of this package
+const instrumentationName = "assignment1/pkg/api"
+
+// Instrument returns a middleware recording a request counter, an error counter and a
+// latency histogram with the meter provider, and a client span per request with the
+// tracer provider, whose trace context is sent to the server with the global
+// propagator. Either provider may be nil to skip metrics or tracing.
+func Instrument(meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) Middleware {
+	var (
+		requests metric.Int64Counter
+		failures metric.Int64Counter
+		duration metric.Float64Histogram
+		tracer   trace.Tracer
+	)
+	if meterProvider != nil {
+		meter := meterProvider.Meter(instrumentationName)
+		// instrument creation only fails on invalid names, which are constants here
+		requests, _ = meter.Int64Counter("assignment1.client.requests",
+			metric.WithDescription("Number of HTTP requests sent"))
+		failures, _ = meter.Int64Counter("assignment1.client.errors",
+			metric.WithDescription("Number of HTTP requests that failed or returned a non-2xx status code"))
+		duration, _ = meter.Float64Histogram("assignment1.client.duration",
+			metric.WithDescription("Duration of HTTP requests"),
+			metric.WithUnit("s"))
+	}
+	if tracerProvider != nil {
+		tracer = tracerProvider.Tracer(instrumentationName)
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			attributes := []attribute.KeyValue{
+				attribute.String("http.request.method", req.Method),
+				attribute.String("server.address", req.URL.Host),
+				attribute.String("url.path", req.URL.Path),
+			}
+
+			var span trace.Span
+			if tracer != nil {
+				ctx, span = tracer.Start(ctx, "HTTP "+req.Method,
+					trace.WithSpanKind(trace.SpanKindClient),
+					trace.WithAttributes(attributes...))
+				defer span.End()
+				// a clone, the headers of the caller's request must not change
+				req = req.Clone(ctx)
+				telemetry.Inject(ctx, req.Header)
+			}
+
+			start := time.Now()
+			res, err := next.RoundTrip(req)
+			elapsed := time.Since(start).Seconds()
+
+			failed := err != nil || res.StatusCode >= 300
+			if err == nil {
+				attributes = append(attributes, attribute.Int("http.response.status_code", res.StatusCode))
+			}
+			if span != nil {
+				span.SetAttributes(attributes...)
+				switch {
+				case err != nil:
+					span.RecordError(err)
+					span.SetStatus(codes.Error, err.Error())
+				case failed:
+					span.SetStatus(codes.Error, "HTTP "+strconv.Itoa(res.StatusCode))
+				}
+			}
+			if meterProvider != nil {
+				options := metric.WithAttributes(attributes...)
+				requests.Add(ctx, 1, options)
+				duration.Record(ctx, elapsed, options)
+				if failed {
+					failures.Add(ctx, 1, options)
+				}
+			}
+			return res, err
+		})
+	}
+}
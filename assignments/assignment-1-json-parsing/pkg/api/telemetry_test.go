@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"assignment1/pkg/apitest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrument(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	spanRecorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{
+		BaseURL:        server.URL,
+		MeterProvider:  meterProvider,
+		TracerProvider: tracerProvider,
+	})
+	apiInstance.GetAssignmentData(context.Background(), apitest.ValidPath)
+	apiInstance.GetAssignmentData(context.Background(), apitest.NotFoundPath)
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &metrics); err != nil {
+		t.Fatalf("Collect error: %s", err)
+	}
+	counts := map[string]int64{}
+	for _, scopeMetrics := range metrics.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					counts[m.Name] += point.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					counts[m.Name] += int64(point.Count)
+				}
+			}
+		}
+	}
+	expected := map[string]int64{
+		"assignment1.client.requests": 2,
+		"assignment1.client.errors":   1,
+		"assignment1.client.duration": 2,
+	}
+	for name, count := range expected {
+		if counts[name] != count {
+			t.Errorf("Expected %s to be %d, got %d", name, count, counts[name])
+		}
+	}
+
+	spans := spanRecorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].Name() != "HTTP GET" {
+		t.Errorf("Expected span name 'HTTP GET', got %s", spans[0].Name())
+	}
+}
+
+func TestInstrumentPropagation(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	traceparents := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	spanRecorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+	client := &http.Client{Transport: Instrument(nil, tracerProvider)(http.DefaultTransport)}
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request error: %s", err)
+	}
+	res.Body.Close()
+
+	spans := spanRecorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	expected := "00-" + spans[0].SpanContext().TraceID().String() + "-" + spans[0].SpanContext().SpanID().String() + "-01"
+	if traceparent := <-traceparents; traceparent != expected {
+		t.Errorf("Expected traceparent %s, got %q", expected, traceparent)
+	}
+	if len(req.Header) != 0 {
+		t.Errorf("Expected the request of the caller to be unchanged, got headers %v", req.Header)
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// baseTransport returns the transport at the bottom of the middleware chain:
+// Options.Transport (or http.DefaultTransport) with the TLS options applied.
+// Configuration errors, like an unreadable CA bundle, are returned by every request.
+func baseTransport(options Options) http.RoundTripper {
+	transport := options.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if !options.InsecureSkipVerify && options.CAFile == "" {
+		return transport
+	}
+
+	httpTransport, ok := transport.(*http.Transport)
+	if !ok {
+		return errorTransport{err: fmt.Errorf("TLS options require an *http.Transport, got %T", transport)}
+	}
+	tlsConfig, err := tlsClientConfig(options)
+	if err != nil {
+		return errorTransport{err: err}
+	}
+
+	// clone, so http.DefaultTransport or the caller's transport aren't modified
+	httpTransport = httpTransport.Clone()
+	httpTransport.TLSClientConfig = tlsConfig
+	return httpTransport
+}
+
+func tlsClientConfig(options Options) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if options.Transport != nil {
+		if httpTransport, ok := options.Transport.(*http.Transport); ok && httpTransport.TLSClientConfig != nil {
+			tlsConfig = httpTransport.TLSClientConfig.Clone()
+		}
+	}
+
+	if options.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	if options.CAFile != "" {
+		pem, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("CA bundle read error: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", options.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// errorTransport fails every request with a configuration error
+type errorTransport struct {
+	err error
+}
+
+func (e errorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, e.err
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"assignment1/pkg/apitest"
+)
+
+func TestTLSOptions(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(apitest.ValidBody))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatalf("WriteFile error: %s", err)
+	}
+
+	tests := map[string]struct {
+		options   Options
+		expectErr bool
+	}{
+		"untrusted": {options: Options{}, expectErr: true},
+		"insecure":  {options: Options{InsecureSkipVerify: true}},
+		"ca bundle": {options: Options{CAFile: caFile}},
+		"missing ca bundle": {
+			options:   Options{CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+			expectErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.options.BaseURL = server.URL
+			_, err := New(test.options).GetAssignmentData(context.Background(), "/assignment1")
+			if test.expectErr && err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+			if !test.expectErr && err != nil {
+				t.Errorf("GetAssignmentData error: %s", err)
+			}
+		})
+	}
+}
+
+func TestTimeoutOption(t *testing.T) {
+	server := apitest.NewServer(t)
+	apiInstance := New(Options{BaseURL: server.URL, Timeout: 50 * time.Millisecond})
+
+	_, err := apiInstance.GetAssignmentData(context.Background(), apitest.SlowPath)
+	var timeoutErr interface{ Timeout() bool }
+	if !errors.As(err, &timeoutErr) || !timeoutErr.Timeout() {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+}
+
+func TestTransportOption(t *testing.T) {
+	server := apitest.NewServer(t)
+	called := false
+	apiInstance := New(Options{
+		BaseURL: server.URL,
+		Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			called = true
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+	})
+
+	if _, err := apiInstance.GetAssignmentData(context.Background(), apitest.ValidPath); err != nil {
+		t.Fatalf("GetAssignmentData error: %s", err)
+	}
+	if !called {
+		t.Errorf("Expected the custom transport to be used")
+	}
+}
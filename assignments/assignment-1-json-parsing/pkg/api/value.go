@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ValueKind identifies which member of the Value union is set
+type ValueKind int
+
+const (
+	NullValue ValueKind = iota
+	IntValue
+	StringValue
+)
+
+// String returns the name of the kind
+func (k ValueKind) String() string {
+	switch k {
+	case NullValue:
+		return "null"
+	case IntValue:
+		return "int"
+	case StringValue:
+		return "string"
+	}
+	return fmt.Sprintf("ValueKind(%d)", int(k))
+}
+
+// Value is a tagged union holding one element of the extraSpecial array,
+// which mixes integers, strings and nulls
+type Value struct {
+	kind        ValueKind
+	intValue    int64
+	stringValue string
+}
+
+// NewInt creates an IntValue
+func NewInt(i int64) Value {
+	return Value{kind: IntValue, intValue: i}
+}
+
+// NewString creates a StringValue
+func NewString(s string) Value {
+	return Value{kind: StringValue, stringValue: s}
+}
+
+// NewNull creates a NullValue
+func NewNull() Value {
+	return Value{kind: NullValue}
+}
+
+// Kind returns which member of the union is set
+func (v Value) Kind() ValueKind {
+	return v.kind
+}
+
+// IsNull reports whether the value is a JSON null
+func (v Value) IsNull() bool {
+	return v.kind == NullValue
+}
+
+// AsInt returns the integer and true if the value is an IntValue
+func (v Value) AsInt() (int64, bool) {
+	return v.intValue, v.kind == IntValue
+}
+
+// AsString returns the string and true if the value is a StringValue
+func (v Value) AsString() (string, bool) {
+	return v.stringValue, v.kind == StringValue
+}
+
+// String formats the value the same way fmt prints the untyped JSON value
+func (v Value) String() string {
+	switch v.kind {
+	case IntValue:
+		return strconv.FormatInt(v.intValue, 10)
+	case StringValue:
+		return v.stringValue
+	}
+	return "null"
+}
+
+// MarshalJSON encodes the value back into its original JSON type
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch v.kind {
+	case IntValue:
+		return json.Marshal(v.intValue)
+	case StringValue:
+		return json.Marshal(v.stringValue)
+	}
+	return []byte("null"), nil
+}
+
+// UnmarshalJSON decodes an integer, a string or null into the union
+func (v *Value) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*v = NewNull()
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*v = NewString(s)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("value %s is not an int, string or null", string(data))
+	}
+	i, err := number.Int64()
+	if err != nil {
+		return fmt.Errorf("value %s is not an int: %w", string(data), err)
+	}
+	*v = NewInt(i)
+	return nil
+}
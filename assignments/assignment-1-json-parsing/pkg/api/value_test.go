@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValueUnmarshalJSON(t *testing.T) {
+	var values []Value
+	err := json.Unmarshal([]byte(`[1, 2, "3", null]`), &values)
+	if err != nil {
+		t.Fatalf("unmarshal error: %s", err)
+	}
+	if len(values) != 4 {
+		t.Fatalf("Expected 4 values, got %d", len(values))
+	}
+
+	if i, ok := values[0].AsInt(); !ok || i != 1 {
+		t.Errorf("Expected first value to be int 1, got %s %s", values[0].Kind(), values[0])
+	}
+	if _, ok := values[1].AsString(); ok {
+		t.Errorf("Expected second value not to be a string")
+	}
+	if s, ok := values[2].AsString(); !ok || s != "3" {
+		t.Errorf("Expected third value to be string \"3\", got %s %s", values[2].Kind(), values[2])
+	}
+	if !values[3].IsNull() {
+		t.Errorf("Expected fourth value to be null, got %s", values[3].Kind())
+	}
+}
+
+func TestValueUnmarshalJSONInvalid(t *testing.T) {
+	for _, input := range []string{`[1.5]`, `[true]`, `[{"a": 1}]`, `[[1]]`} {
+		var values []Value
+		if err := json.Unmarshal([]byte(input), &values); err == nil {
+			t.Errorf("Expected error for %s, got %v", input, values)
+		}
+	}
+}
+
+func TestValueRoundTrip(t *testing.T) {
+	input := `[1,2,"3",null]`
+	var values []Value
+	if err := json.Unmarshal([]byte(input), &values); err != nil {
+		t.Fatalf("unmarshal error: %s", err)
+	}
+	out, err := json.Marshal(values)
+	if err != nil {
+		t.Fatalf("marshal error: %s", err)
+	}
+	if string(out) != input {
+		t.Errorf("Expected %s, got %s", input, string(out))
+	}
+}
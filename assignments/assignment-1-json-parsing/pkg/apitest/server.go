@@ -0,0 +1,108 @@
+// Package apitest provides an httptest server preloaded with canned assignment1
+// responses, so the api package can be tested against a real HTTP server
+package apitest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Paths of the canned responses served by NewServer
+const (
+	ValidPath       = "/assignment1"
+	InvalidJSONPath = "/invalid-json"
+	NotFoundPath    = "/not-found"
+	SlowPath        = "/slow"
+	TruncatedPath   = "/truncated"
+	GzipPath        = "/gzip"
+	DeflatePath     = "/deflate"
+)
+
+// ValidBody is the body served on ValidPath, matching the test-server /assignment1 output
+const ValidBody = `{"page":"assignment1","words":["one","two","three"],"percentages":{"one":0.33,"three":0,"two":0.66},"special":["one","two",null],"extraSpecial":[1,2,"3"]}`
+
+// InvalidJSONBody is the body served on InvalidJSONPath
+const InvalidJSONBody = "invalid json"
+
+// Server is an httptest.Server serving canned responses
+type Server struct {
+	*httptest.Server
+	mux *http.ServeMux
+	// SlowDelay is how long SlowPath waits before replying (or until the client gives up)
+	SlowDelay time.Duration
+}
+
+// NewServer starts a Server that is closed when the test finishes
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		mux:       http.NewServeMux(),
+		SlowDelay: 5 * time.Second,
+	}
+	s.HandleResponse(ValidPath, http.StatusOK, "application/json", ValidBody)
+	s.HandleResponse(InvalidJSONPath, http.StatusOK, "application/json", InvalidJSONBody)
+	s.HandleResponse(NotFoundPath, http.StatusNotFound, "text/plain", "Not Found")
+	s.mux.HandleFunc(SlowPath, s.slow)
+	s.mux.HandleFunc(TruncatedPath, truncated)
+	s.mux.HandleFunc(GzipPath, compressed("gzip"))
+	s.mux.HandleFunc(DeflatePath, compressed("deflate"))
+
+	s.Server = httptest.NewServer(s.mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// HandleResponse serves a fixed status code, content type and body on the path
+func (s *Server) HandleResponse(path string, statusCode int, contentType, body string) {
+	s.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(statusCode)
+		fmt.Fprint(w, body)
+	})
+}
+
+// Handle registers a custom handler on the server
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) slow(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-time.After(s.SlowDelay):
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, ValidBody)
+	case <-r.Context().Done():
+	}
+}
+
+// truncated announces the full body length but only sends half of the body
+func truncated(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", fmt.Sprint(len(ValidBody)))
+	fmt.Fprint(w, ValidBody[:len(ValidBody)/2])
+}
+
+// compressed serves ValidBody with the gzip or deflate (zlib) Content-Encoding
+func compressed(encoding string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		var writer io.WriteCloser
+		if encoding == "gzip" {
+			writer = gzip.NewWriter(&buf)
+		} else {
+			writer = zlib.NewWriter(&buf)
+		}
+		writer.Write([]byte(ValidBody))
+		writer.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", encoding)
+		w.Write(buf.Bytes())
+	}
+}
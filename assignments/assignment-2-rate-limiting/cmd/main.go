@@ -2,22 +2,94 @@ package main
 
 import (
 	"assignment-2-rate-limiting/pkg/ratelimiter"
+	"context"
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
+	"time"
+
+	"lifecycle"
+	"logging"
+	"notify"
+	"secrets"
+	"telemetry"
+)
+
+// failedTemplate is the alert of a run that didn't find the limit
+var failedTemplate = notify.MustTemplate(
+	"Rate limiter stopped without finding the limit",
+	"{{.Requests}} requests in {{.Duration}}: {{.OK}} OK, {{.TooManyRequests}} rate limited, {{.Errors}} errors",
 )
 
 func main() {
+	// the errors go to stderr, the responses to stdout
+	logger, err := logging.Setup()
+	if err != nil {
+		logger.Error("Invalid logging configuration", "error", err)
+		os.Exit(1)
+	}
+	// exports the traces to the collector of OTEL_EXPORTER_OTLP_ENDPOINT, if set
+	shutdownTelemetry, err := telemetry.Setup(context.Background(), "rate-limiter")
+	if err != nil {
+		logger.Error("Telemetry error", "error", err)
+		os.Exit(1)
+	}
+
+	// alerts the channels of NOTIFY_SLACK_WEBHOOK_URL and NOTIFY_TEAMS_WEBHOOK_URL,
+	// and the webhook of NOTIFY_WEBHOOK_URL, if set, when the limit isn't found
+	store, err := secrets.FromEnv("NOTIFY_")
+	if err != nil {
+		logger.Error("Invalid secrets configuration", "error", err)
+		os.Exit(1)
+	}
+	notifiers, err := notify.FromSecrets(context.Background(), store, notify.Options{})
+	if err != nil {
+		logger.Error("Invalid notifications configuration", "error", err)
+		os.Exit(1)
+	}
+
 	rl := ratelimiter.NewRateLimiter(5)
 
-	go rl.Start()
+	// stops on Ctrl-C or SIGTERM, or when the rate limiter found the limit, then
+	// exports the spans left
+	var group lifecycle.Group
+	group.Add(
+		lifecycle.Component{Name: "telemetry", Stop: shutdownTelemetry, StopTimeout: 5 * time.Second},
+		lifecycle.Func("rate-limiter", func(ctx context.Context) error {
+			rl.Run(ctx)
+			return nil
+		}),
+	)
+	ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+	runErr := group.Run(ctx)
 
-	// Wait for a signal to gracefully shut down
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	report := rl.Report()
+	logger.Info("Report", "requests", report.Requests, "ok", report.OK, "too_many_requests", report.TooManyRequests,
+		"errors", report.Errors, "done", report.Done, "duration", report.Duration)
+	if !report.Done && len(notifiers) > 0 {
+		if err := sendReport(notifiers, report); err != nil {
+			logger.Error("Failed to send the report", "error", err)
+		}
+	}
+	if runErr != nil {
+		logger.Error("Stopped with errors", "error", runErr)
+		stop()
+		os.Exit(1)
+	}
+}
 
-	fmt.Println("Shutting down...")
-	rl.Stop()
+// sendReport alerts the channels of the notifiers of a failed run
+func sendReport(notifiers notify.Notifier, report ratelimiter.Report) error {
+	message, err := failedTemplate.Message(notify.SeverityError, report,
+		notify.Field{Name: "Started", Value: report.Started.Format(time.RFC3339)},
+		notify.Field{Name: "Requests", Value: fmt.Sprint(report.Requests)},
+		notify.Field{Name: "Rate limited", Value: fmt.Sprint(report.TooManyRequests)},
+		notify.Field{Name: "Errors", Value: fmt.Sprint(report.Errors)},
+	)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return notifiers.Notify(ctx, message)
 }
@@ -0,0 +1,122 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records the waits for a token in the trace of the request, with the
+// global tracer provider, e.g. of telemetry.Setup
+var tracer = otel.Tracer("assignment-2-rate-limiting/pkg/ratelimiter")
+
+// TokenBucket allows rate events per second, with bursts of up to burst events.
+// A rate of 0 or less means no limit. It is safe for concurrent use.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewTokenBucket creates a full TokenBucket. A burst below 1 is raised to 1.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Allow takes a token if one is available and reports whether it did.
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate <= 0 {
+		return true
+	}
+
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait blocks until a token is available or the context is done. A wait shows up
+// as a span in the trace of ctx.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	wait := b.reserve()
+	if wait == 0 {
+		return nil
+	}
+	_, span := tracer.Start(ctx, "TokenBucket.Wait", trace.WithAttributes(attribute.Float64("ratelimiter.wait_seconds", wait.Seconds())))
+	defer span.End()
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		span.SetStatus(codes.Error, ctx.Err().Error())
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token, possibly going into debt, and returns how long
+// the caller has to wait before using it.
+func (b *TokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate <= 0 {
+		return 0
+	}
+
+	b.refill()
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// SetRate changes the rate, like for a load test ramping up. The tokens earned at
+// the previous rate are kept, and a debt is paid back at the new rate.
+func (b *TokenBucket) SetRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.rate = rate
+}
+
+// refill adds the tokens earned since the last call. b.mu must be held.
+func (b *TokenBucket) refill() {
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// cancel hands back a token reserved by a Wait that was given up.
+func (b *TokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
@@ -0,0 +1,121 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestBucket creates a bucket with a clock that only moves with the returned function
+func newTestBucket(rate float64, burst int) (*TokenBucket, func(d time.Duration)) {
+	now := time.Now()
+	bucket := NewTokenBucket(rate, burst)
+	bucket.now = func() time.Time { return now }
+	return bucket, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestTokenBucketAllow(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		// steps are the time before each Allow call, and expected its result
+		steps    []time.Duration
+		expected []bool
+	}{
+		{name: "burst", rate: 1, burst: 3, steps: []time.Duration{0, 0, 0, 0}, expected: []bool{true, true, true, false}},
+		{name: "refill", rate: 2, burst: 1, steps: []time.Duration{0, 0, 250 * time.Millisecond, 250 * time.Millisecond}, expected: []bool{true, false, false, true}},
+		{name: "refill capped at burst", rate: 10, burst: 2, steps: []time.Duration{0, 0, time.Hour, 0, 0}, expected: []bool{true, true, true, true, false}},
+		{name: "burst raised to 1", rate: 1, burst: 0, steps: []time.Duration{0, 0}, expected: []bool{true, false}},
+		{name: "no limit", rate: 0, burst: 1, steps: []time.Duration{0, 0, 0}, expected: []bool{true, true, true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bucket, advance := newTestBucket(test.rate, test.burst)
+			for i, step := range test.steps {
+				advance(step)
+				if allowed := bucket.Allow(); allowed != test.expected[i] {
+					t.Errorf("call %d: expected %t, got %t", i+1, test.expected[i], allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	bucket := NewTokenBucket(20, 1)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := bucket.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait error: %s", err)
+		}
+	}
+	// the first token is there, the next two take 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected 3 tokens at 20/s to take 100ms, took %s", elapsed)
+	}
+}
+
+func TestTokenBucketWaitCancelled(t *testing.T) {
+	bucket := NewTokenBucket(0.1, 1)
+	bucket.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := bucket.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Wait to return right away, took %s", elapsed)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bucket.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestTokenBucketCancelReservation(t *testing.T) {
+	bucket, advance := newTestBucket(1, 1)
+	if !bucket.Allow() {
+		t.Fatal("Expected the first token to be allowed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := bucket.Wait(ctx); err == nil {
+		t.Fatal("Expected the cancelled Wait to fail")
+	}
+
+	// the given up reservation is handed back: a second later, the bucket has a
+	// token again instead of paying off the debt of the reservation
+	advance(time.Second)
+	if !bucket.Allow() {
+		t.Errorf("Expected the token of the cancelled reservation to be handed back")
+	}
+	if bucket.Allow() {
+		t.Errorf("Expected only one token after a second")
+	}
+}
+
+func TestTokenBucketSetRate(t *testing.T) {
+	bucket, advance := newTestBucket(1, 1)
+	bucket.Allow()
+	advance(500 * time.Millisecond)
+	// half a token earned at 1/s, the other half takes 50ms at 10/s
+	bucket.SetRate(10)
+	if bucket.Allow() {
+		t.Error("Expected no token right after the rate change")
+	}
+	advance(50 * time.Millisecond)
+	if !bucket.Allow() {
+		t.Error("Expected a token at the new rate")
+	}
+	bucket.SetRate(0)
+	if !bucket.Allow() || !bucket.Allow() {
+		t.Error("Expected no limit at a rate of 0")
+	}
+}
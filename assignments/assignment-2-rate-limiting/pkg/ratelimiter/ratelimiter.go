@@ -1,12 +1,16 @@
 package ratelimiter
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
+
+	"telemetry"
 )
 
 // RateLimiter controls the rate of HTTP requests.
@@ -15,63 +19,122 @@ type RateLimiter struct {
 	Rate        int
 	StopChannel chan bool
 	stopOnce    sync.Once
+	// bucket paces the requests at Rate per second, one at a time
+	bucket *TokenBucket
+
+	mu     sync.Mutex
+	report Report
+}
+
+// Report counts the responses of a run of the rate limiter
+type Report struct {
+	Requests        int
+	OK              int
+	TooManyRequests int
+	// Errors are the requests that failed, and the unexpected status codes
+	Errors int
+	// Done is set when the test-server replied DONE!, with the Result
+	Done     bool
+	Result   string
+	Started  time.Time
+	Duration time.Duration
 }
 
 // NewRateLimiter creates a new RateLimiter.
 func NewRateLimiter(rate int) *RateLimiter {
 	return &RateLimiter{
-		Client:      &http.Client{},
+		// the requests continue the trace of the ticks on the test-server
+		Client:      &http.Client{Transport: telemetry.Transport(nil)},
 		Rate:        rate,
 		StopChannel: make(chan bool),
+		bucket:      NewTokenBucket(float64(rate), 1),
 	}
 }
 
-// Start sends requests at a specified rate.
+// Start sends requests at a specified rate, until Stop is called.
 func (rl *RateLimiter) Start() {
-	ticker := time.NewTicker(time.Second / time.Duration(rl.Rate))
-	defer ticker.Stop()
+	rl.Run(context.Background())
+}
 
-	for {
+// Run sends requests at a specified rate, until ctx is done or Stop is called,
+// like when the limit is found.
+func (rl *RateLimiter) Run(ctx context.Context) {
+	rl.record(func(r *Report) { r.Started = time.Now() })
+	defer rl.record(func(r *Report) { r.Duration = time.Since(r.Started).Round(time.Millisecond) })
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
 		select {
-		case <-ticker.C:
-			req, err := http.NewRequest("GET", "http://localhost:8080/ratelimit", nil)
-			if err != nil {
-				fmt.Println("Error creating request:", err)
-				continue
-			}
-			rl.MakeRequest(req)
 		case <-rl.StopChannel:
-			return
+			cancel()
+		case <-ctx.Done():
 		}
+	}()
+
+	for ctx.Err() == nil {
+		rl.tick(ctx)
+	}
+}
+
+// tick waits for a token and sends a request, in a trace of its own
+func (rl *RateLimiter) tick(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "RateLimiter.tick")
+	defer span.End()
+	if err := rl.bucket.Wait(ctx); err != nil {
+		return
 	}
+	req, err := http.NewRequestWithContext(ctx, "GET", "http://localhost:8080/ratelimit", nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating request", "error", err)
+		return
+	}
+	rl.MakeRequest(req)
 }
 
 // MakeRequest sends an HTTP request and handles the response.
 func (rl *RateLimiter) MakeRequest(req *http.Request) {
 	resp, err := rl.Client.Do(req)
 	if err != nil {
-		fmt.Println("Error making request:", err)
+		if req.Context().Err() == nil {
+			rl.record(func(r *Report) { r.Requests++; r.Errors++ })
+		}
+		slog.ErrorContext(req.Context(), "Error making request", "error", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error reading response body:", err)
+		rl.record(func(r *Report) { r.Requests++; r.Errors++ })
+		slog.ErrorContext(req.Context(), "Error reading response body", "error", err)
 		return
 	}
 
 	switch resp.StatusCode {
 	case http.StatusOK:
 		fmt.Println(string(body))
-		if strings.HasPrefix(string(body), "DONE!") {
+		done := strings.HasPrefix(string(body), "DONE!")
+		rl.record(func(r *Report) {
+			r.Requests++
+			r.OK++
+			if done {
+				r.Done, r.Result = true, strings.TrimSpace(string(body))
+			}
+		})
+		if done {
 			rl.Stop()
 		}
 	case http.StatusTooManyRequests:
-		fmt.Println("Rate limit exceeded. Backing off...")
-		time.Sleep(10 * time.Second)
+		rl.record(func(r *Report) { r.Requests++; r.TooManyRequests++ })
+		slog.WarnContext(req.Context(), "Rate limit exceeded, backing off", "backoff", 10*time.Second)
+		// stopping doesn't wait for the backoff
+		select {
+		case <-time.After(10 * time.Second):
+		case <-req.Context().Done():
+		}
 	default:
-		fmt.Printf("Received status code %d: %s\n", resp.StatusCode, string(body))
+		rl.record(func(r *Report) { r.Requests++; r.Errors++ })
+		slog.WarnContext(req.Context(), "Unexpected status code", "status", resp.StatusCode, "body", string(body))
 	}
 }
 
@@ -81,3 +144,16 @@ func (rl *RateLimiter) Stop() {
 		close(rl.StopChannel)
 	})
 }
+
+// Report returns the counts of the responses so far
+func (rl *RateLimiter) Report() Report {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.report
+}
+
+func (rl *RateLimiter) record(update func(r *Report)) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	update(&rl.report)
+}
@@ -0,0 +1,50 @@
+package ratelimiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	// 2 words, a 500, then the limit
+	replies := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusOK, "one"},
+		{http.StatusOK, "two"},
+		{http.StatusInternalServerError, "oops"},
+		{http.StatusOK, "DONE! The rate limit is 5 per second"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := replies[0]
+		replies = replies[1:]
+		w.WriteHeader(reply.status)
+		w.Write([]byte(reply.body))
+	}))
+	defer server.Close()
+
+	rl := NewRateLimiter(0)
+	for range 4 {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rl.MakeRequest(req)
+	}
+
+	report := rl.Report()
+	if report.Requests != 4 || report.OK != 3 || report.Errors != 1 || report.TooManyRequests != 0 {
+		t.Errorf("Unexpected counts %+v", report)
+	}
+	if !report.Done || report.Result != "DONE! The rate limit is 5 per second" {
+		t.Errorf("Expected the run to be done, got %+v", report)
+	}
+	select {
+	case <-rl.StopChannel:
+	default:
+		t.Error("Expected the rate limiter to stop when done")
+	}
+}
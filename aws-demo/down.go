@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// down deletes what up created. What doesn't exist anymore is skipped, so it
+// cleans up after an up that failed halfway as well.
+func (d demo) down(ctx context.Context) error {
+	instance, err := d.findInstance(ctx, "pending", "running", "stopping", "stopped", "shutting-down")
+	if err != nil {
+		return err
+	}
+	if instance != nil {
+		instanceID := aws.ToString(instance.InstanceId)
+		if _, err := d.client.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+			InstanceIds: []string{instanceID},
+		}); err != nil {
+			return fmt.Errorf("TerminateInstances error: %w", err)
+		}
+		fmt.Printf("Terminating instance %s\n", instanceID)
+		// the security group can only be deleted when no instance uses it anymore
+		if err := ec2.NewInstanceTerminatedWaiter(d.client).Wait(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []string{instanceID},
+		}, 10*time.Minute); err != nil {
+			return fmt.Errorf("instance %s isn't terminated: %w", instanceID, err)
+		}
+		fmt.Printf("Instance %s is terminated\n", instanceID)
+	}
+
+	groupID, err := d.findSecurityGroup(ctx)
+	if err != nil {
+		return err
+	}
+	if groupID != "" {
+		if err := d.deleteSecurityGroup(ctx, groupID); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted security group %s (%s)\n", d.name, groupID)
+	}
+
+	// DeleteKeyPair succeeds when the key pair doesn't exist
+	if _, err := d.client.DeleteKeyPair(ctx, &ec2.DeleteKeyPairInput{KeyName: aws.String(d.name)}); err != nil {
+		return fmt.Errorf("DeleteKeyPair error: %w", err)
+	}
+	fmt.Printf("Deleted key pair %s\n", d.name)
+	for _, file := range []string{d.keyFile(), d.hostsFile()} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSecurityGroup retries for a minute while the group is in use: the network
+// interface of a terminated instance takes a while to go away
+func (d demo) deleteSecurityGroup(ctx context.Context, groupID string) error {
+	deadline := time.Now().Add(time.Minute)
+	for {
+		_, err := d.client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(groupID)})
+		if err == nil || errorCode(err) != "DependencyViolation" || time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("DeleteSecurityGroup error: %w", err)
+			}
+			return nil
+		}
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+const usage = `Usage: %s [flags] up|down
+
+Commands:
+  up    launch the instance, or print the one that runs already
+  down  terminate the instance and delete its key pair and security group
+
+`
+
+func main() {
+	o, err := parseFlags(os.Args[1:], os.Stderr)
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(o.region))
+	if err != nil {
+		fmt.Printf("LoadDefaultConfig error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+	d := demo{client: ec2.NewFromConfig(cfg), name: o.name}
+
+	switch o.command {
+	case "up":
+		err = d.up(ctx, o.up)
+	case "down":
+		err = d.down(ctx)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// options are the flags and the command of the command line
+type options struct {
+	region  string
+	name    string
+	command string
+	up      upOptions
+}
+
+// parseFlags parses the command line without the program name. The usage and the
+// errors are written to output.
+func parseFlags(args []string, output io.Writer) (options, error) {
+	var o options
+	flags := flag.NewFlagSet("aws-demo", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&o.region, "region", "us-east-1", "AWS region")
+	flags.StringVar(&o.name, "name", "aws-demo", "name of the instance, the key pair and the security group")
+	flags.StringVar(&o.up.instanceType, "instance-type", "t3.micro", "instance type")
+	flags.StringVar(&o.up.amiName, "ami-name", "ubuntu/images/hvm-ssd-gp3/ubuntu-noble-24.04-amd64-server-*", "name filter of the AMI, the newest match is used")
+	flags.StringVar(&o.up.amiOwner, "ami-owner", "099720109477", "owner of the AMI, Canonical by default")
+	flags.StringVar(&o.up.sshUser, "ssh-user", "ubuntu", "user to log in with, depends on the AMI")
+	flags.StringVar(&o.up.sshCIDR, "ssh-cidr", "", "network allowed to connect with SSH, the public IP of this machine by default")
+	flags.Usage = func() {
+		fmt.Fprintf(output, usage, flags.Name())
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return o, err
+	}
+
+	var err error
+	switch {
+	case flags.NArg() != 1:
+		err = fmt.Errorf("one command expected, got %d", flags.NArg())
+	case flags.Arg(0) != "up" && flags.Arg(0) != "down":
+		err = fmt.Errorf("unknown command %q", flags.Arg(0))
+	case o.name == "":
+		err = errors.New("-name is empty")
+	case o.up.sshCIDR != "":
+		if _, _, cidrErr := net.ParseCIDR(o.up.sshCIDR); cidrErr != nil {
+			err = fmt.Errorf("invalid -ssh-cidr: %w", cidrErr)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(output, "%s\n\n", err)
+		flags.Usage()
+		return o, err
+	}
+	o.command = flags.Arg(0)
+	return o, nil
+}
+
+// EC2Client is the part of the EC2 API the demo calls, so the tests replace it.
+// It's a DescribeInstancesAPIClient for the waiters.
+type EC2Client interface {
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error)
+	TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error)
+	DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error)
+	DescribeKeyPairs(ctx context.Context, params *ec2.DescribeKeyPairsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeKeyPairsOutput, error)
+	CreateKeyPair(ctx context.Context, params *ec2.CreateKeyPairInput, optFns ...func(*ec2.Options)) (*ec2.CreateKeyPairOutput, error)
+	DeleteKeyPair(ctx context.Context, params *ec2.DeleteKeyPairInput, optFns ...func(*ec2.Options)) (*ec2.DeleteKeyPairOutput, error)
+	DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
+	CreateSecurityGroup(ctx context.Context, params *ec2.CreateSecurityGroupInput, optFns ...func(*ec2.Options)) (*ec2.CreateSecurityGroupOutput, error)
+	AuthorizeSecurityGroupIngress(ctx context.Context, params *ec2.AuthorizeSecurityGroupIngressInput, optFns ...func(*ec2.Options)) (*ec2.AuthorizeSecurityGroupIngressOutput, error)
+	DeleteSecurityGroup(ctx context.Context, params *ec2.DeleteSecurityGroupInput, optFns ...func(*ec2.Options)) (*ec2.DeleteSecurityGroupOutput, error)
+}
+
+// demo creates and deletes the resources of the demo. They're all found by name,
+// so up and down can run again after a failure.
+type demo struct {
+	client EC2Client
+	name   string
+}
+
+// keyFile is the private key of the key pair, for ssh-demo
+func (d demo) keyFile() string {
+	return d.name + ".pem"
+}
+
+// hostsFile has the instance, for the -hosts-file of ssh-demo
+func (d demo) hostsFile() string {
+	return d.name + ".hosts"
+}
+
+// nameTag tags a new resource with the name of the demo, as shown in the console
+func (d demo) nameTag(resourceType types.ResourceType) types.TagSpecification {
+	return types.TagSpecification{
+		ResourceType: resourceType,
+		Tags:         []types.Tag{{Key: aws.String("Name"), Value: aws.String(d.name)}},
+	}
+}
+
+// findInstance returns the instance of the demo in one of the states, or nil
+func (d demo) findInstance(ctx context.Context, states ...string) (*types.Instance, error) {
+	describeInstances, err := d.client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		Filters: []types.Filter{
+			{Name: aws.String("tag:Name"), Values: []string{d.name}},
+			{Name: aws.String("instance-state-name"), Values: states},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DescribeInstances error: %w", err)
+	}
+	for _, reservation := range describeInstances.Reservations {
+		for _, instance := range reservation.Instances {
+			return &instance, nil
+		}
+	}
+	return nil, nil
+}
+
+// findSecurityGroup returns the id of the security group of the demo, or ""
+func (d demo) findSecurityGroup(ctx context.Context) (string, error) {
+	describeGroups, err := d.client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{{Name: aws.String("group-name"), Values: []string{d.name}}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("DescribeSecurityGroups error: %w", err)
+	}
+	if len(describeGroups.SecurityGroups) == 0 {
+		return "", nil
+	}
+	return aws.ToString(describeGroups.SecurityGroups[0].GroupId), nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+)
+
+// upOptions are the settings of a new instance
+type upOptions struct {
+	instanceType string
+	amiName      string
+	amiOwner     string
+	sshUser      string
+	sshCIDR      string
+}
+
+func (d demo) up(ctx context.Context, o upOptions) error {
+	instance, err := d.findInstance(ctx, "pending", "running")
+	if err != nil {
+		return err
+	}
+	if instance != nil {
+		fmt.Printf("Instance %s exists already\n", aws.ToString(instance.InstanceId))
+	} else {
+		if instance, err = d.launch(ctx, o); err != nil {
+			return err
+		}
+	}
+
+	instance, err = d.waitRunning(ctx, aws.ToString(instance.InstanceId))
+	if err != nil {
+		return err
+	}
+	ip := aws.ToString(instance.PublicIpAddress)
+	if ip == "" {
+		return fmt.Errorf("instance %s has no public IP, the subnet doesn't assign one", aws.ToString(instance.InstanceId))
+	}
+	fmt.Printf("Instance %s is running at %s, waiting for SSH\n", aws.ToString(instance.InstanceId), ip)
+	if err := waitForSSH(ctx, ip, 3*time.Minute); err != nil {
+		return err
+	}
+	if err := os.WriteFile(d.hostsFile(), []byte(o.sshUser+"@"+ip+"\n"), 0644); err != nil {
+		return fmt.Errorf("WriteFile (hosts) error: %w", err)
+	}
+
+	fmt.Printf("\nConnect with the SSH demo:\n")
+	fmt.Printf("  ssh-keyscan %s >> ~/.ssh/known_hosts\n", ip)
+	fmt.Printf("  ssh-demo -hosts-file %s -key %s uptime\n", d.hostsFile(), d.keyFile())
+	fmt.Printf("\nTear it down with: aws-demo -name %s down\n", d.name)
+	return nil
+}
+
+// launch creates the key pair and the security group, when they don't exist yet,
+// and runs the instance with them
+func (d demo) launch(ctx context.Context, o upOptions) (*types.Instance, error) {
+	if err := d.createKeyPair(ctx); err != nil {
+		return nil, err
+	}
+	if o.sshCIDR == "" {
+		ip, err := publicIP(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("can't find the public IP of this machine, set -ssh-cidr: %w", err)
+		}
+		o.sshCIDR = ip + "/32"
+	}
+	groupID, err := d.createSecurityGroup(ctx, o.sshCIDR)
+	if err != nil {
+		return nil, err
+	}
+	image, err := d.findImage(ctx, o.amiName, o.amiOwner)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Launching a %s with %s (%s)\n", o.instanceType, aws.ToString(image.Name), aws.ToString(image.ImageId))
+
+	runInstances, err := d.client.RunInstances(ctx, &ec2.RunInstancesInput{
+		ImageId:          image.ImageId,
+		InstanceType:     types.InstanceType(o.instanceType),
+		KeyName:          aws.String(d.name),
+		SecurityGroupIds: []string{groupID},
+		MinCount:         aws.Int32(1),
+		MaxCount:         aws.Int32(1),
+		TagSpecifications: []types.TagSpecification{
+			d.nameTag(types.ResourceTypeInstance),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("RunInstances error: %w", err)
+	}
+	if len(runInstances.Instances) == 0 {
+		return nil, fmt.Errorf("RunInstances has empty length (%d)", len(runInstances.Instances))
+	}
+	return &runInstances.Instances[0], nil
+}
+
+// createKeyPair creates an ed25519 key pair and writes the private key in the
+// OpenSSH format, which ssh-demo reads. AWS only returns the private key when the
+// pair is created, so an existing pair without the key file can't be used.
+func (d demo) createKeyPair(ctx context.Context) error {
+	_, err := d.client.DescribeKeyPairs(ctx, &ec2.DescribeKeyPairsInput{
+		KeyNames: []string{d.name},
+	})
+	switch {
+	case err == nil:
+		if _, err := os.Stat(d.keyFile()); err != nil {
+			return fmt.Errorf("key pair %s exists, but not %s: run down first", d.name, d.keyFile())
+		}
+		return nil
+	case errorCode(err) != "InvalidKeyPair.NotFound":
+		return fmt.Errorf("DescribeKeyPairs error: %w", err)
+	}
+
+	keyPair, err := d.client.CreateKeyPair(ctx, &ec2.CreateKeyPairInput{
+		KeyName:           aws.String(d.name),
+		KeyType:           types.KeyTypeEd25519,
+		TagSpecifications: []types.TagSpecification{d.nameTag(types.ResourceTypeKeyPair)},
+	})
+	if err != nil {
+		return fmt.Errorf("CreateKeyPair error: %w", err)
+	}
+	if err := os.WriteFile(d.keyFile(), []byte(aws.ToString(keyPair.KeyMaterial)), 0600); err != nil {
+		return fmt.Errorf("WriteFile (keypair) error: %w", err)
+	}
+	fmt.Printf("Created key pair %s, the private key is in %s\n", d.name, d.keyFile())
+	return nil
+}
+
+// createSecurityGroup creates the security group in the default VPC, and allows
+// SSH from cidr. It returns the id of the group.
+func (d demo) createSecurityGroup(ctx context.Context, cidr string) (string, error) {
+	groupID, err := d.findSecurityGroup(ctx)
+	if err != nil {
+		return "", err
+	}
+	if groupID == "" {
+		group, err := d.client.CreateSecurityGroup(ctx, &ec2.CreateSecurityGroupInput{
+			GroupName:         aws.String(d.name),
+			Description:       aws.String("SSH access to the " + d.name + " instance"),
+			TagSpecifications: []types.TagSpecification{d.nameTag(types.ResourceTypeSecurityGroup)},
+		})
+		if err != nil {
+			return "", fmt.Errorf("CreateSecurityGroup error: %w", err)
+		}
+		groupID = aws.ToString(group.GroupId)
+		fmt.Printf("Created security group %s (%s)\n", d.name, groupID)
+	}
+
+	_, err = d.client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId: aws.String(groupID),
+		IpPermissions: []types.IpPermission{{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int32(22),
+			ToPort:     aws.Int32(22),
+			IpRanges:   []types.IpRange{{CidrIp: aws.String(cidr), Description: aws.String("SSH")}},
+		}},
+	})
+	if err != nil && errorCode(err) != "InvalidPermission.Duplicate" {
+		return "", fmt.Errorf("AuthorizeSecurityGroupIngress error: %w", err)
+	}
+	fmt.Printf("SSH is allowed from %s\n", cidr)
+	return groupID, nil
+}
+
+// findImage returns the newest available AMI with a name that matches the filter
+func (d demo) findImage(ctx context.Context, name, owner string) (types.Image, error) {
+	describeImages, err := d.client.DescribeImages(ctx, &ec2.DescribeImagesInput{
+		Filters: []types.Filter{
+			{Name: aws.String("name"), Values: []string{name}},
+			{Name: aws.String("state"), Values: []string{"available"}},
+			{Name: aws.String("virtualization-type"), Values: []string{"hvm"}},
+		},
+		Owners: []string{owner},
+	})
+	if err != nil {
+		return types.Image{}, fmt.Errorf("DescribeImages error: %w", err)
+	}
+	if len(describeImages.Images) == 0 {
+		return types.Image{}, fmt.Errorf("no AMI named %s of %s", name, owner)
+	}
+	// the creation dates are in ISO 8601, so they sort as strings
+	return slices.MaxFunc(describeImages.Images, func(a, b types.Image) int {
+		return strings.Compare(aws.ToString(a.CreationDate), aws.ToString(b.CreationDate))
+	}), nil
+}
+
+// waitRunning waits for the instance to run, and returns it with its public IP
+func (d demo) waitRunning(ctx context.Context, instanceID string) (*types.Instance, error) {
+	output, err := ec2.NewInstanceRunningWaiter(d.client).WaitForOutput(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	}, 5*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("instance %s isn't running: %w", instanceID, err)
+	}
+	for _, reservation := range output.Reservations {
+		for _, instance := range reservation.Instances {
+			return &instance, nil
+		}
+	}
+	return nil, fmt.Errorf("instance %s not found", instanceID)
+}
+
+// waitForSSH waits for the SSH port to accept connections. The instance runs
+// before it has booted, so the SSH demo would fail right after the waiter.
+func waitForSSH(ctx context.Context, ip string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, "22"))
+		if err == nil {
+			return conn.Close()
+		}
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return fmt.Errorf("SSH on %s doesn't answer: %w", ip, err)
+		}
+	}
+}
+
+// checkIPURL answers with the IP of the client
+var checkIPURL = "https://checkip.amazonaws.com"
+
+// publicIP asks AWS for the IP this machine connects from
+func publicIP(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checkIPURL, nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64))
+	if err != nil {
+		return "", err
+	}
+	ip := strings.TrimSpace(string(body))
+	if res.StatusCode != http.StatusOK || net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("unexpected answer: HTTP %d %q", res.StatusCode, ip)
+	}
+	return ip, nil
+}
+
+// errorCode returns the code of an AWS API error, like InvalidKeyPair.NotFound
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
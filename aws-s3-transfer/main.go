@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const usage = `Usage: %s [flags] command [arguments]
+
+Commands:
+  upload file [key]          upload a file, in parts of -part-size when it's larger
+  download key [file]        download an object, or the bytes in -range of it
+  list [prefix]              list the objects, a page of -page-size at a time
+  presign get|put key        print a URL to download or upload the object without credentials
+
+`
+
+func main() {
+	region := flag.String("region", "us-east-1", "AWS region")
+	bucket := flag.String("bucket", "", "name of the bucket (required)")
+	partSize := flag.Int64("part-size", 16, "size of the parts of uploads and downloads in MiB, at least 5")
+	concurrency := flag.Int("concurrency", 5, "number of parts to upload or download at the same time")
+	byteRange := flag.String("range", "", "bytes to download: first-last, first- or -suffix length, e.g. 0-1023")
+	pageSize := flag.Int("page-size", 1000, "number of objects to list per request")
+	expires := flag.Duration("expires", 15*time.Minute, "validity of presigned URLs, at most 7 days")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || *bucket == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(*region))
+	if err != nil {
+		fmt.Printf("LoadDefaultConfig error: %s\n", err)
+		os.Exit(1)
+	}
+	t := transfer{
+		client:      s3.NewFromConfig(cfg),
+		bucket:      *bucket,
+		partSize:    *partSize << 20,
+		concurrency: *concurrency,
+	}
+
+	args := flag.Args()[1:]
+	switch {
+	case flag.Arg(0) == "upload" && (len(args) == 1 || len(args) == 2):
+		err = t.upload(ctx, args[0], argument(args, 1, ""))
+	case flag.Arg(0) == "download" && (len(args) == 1 || len(args) == 2):
+		err = t.download(ctx, args[0], argument(args, 1, ""), *byteRange)
+	case flag.Arg(0) == "list" && len(args) <= 1:
+		err = t.list(ctx, argument(args, 0, ""), int32(*pageSize))
+	case flag.Arg(0) == "presign" && len(args) == 2:
+		err = t.presign(ctx, args[0], args[1], *expires)
+	default:
+		flag.Usage()
+		stop()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// argument returns the argument at index i, or the fallback when it isn't given
+func argument(args []string, i int, fallback string) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return fallback
+}
+
+// transfer moves files from and to a bucket
+type transfer struct {
+	client      *s3.Client
+	bucket      string
+	partSize    int64
+	concurrency int
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// list prints the objects that start with prefix. ListObjectsV2 returns at most
+// 1000 objects per call, the paginator follows the continuation tokens.
+func (t transfer) list(ctx context.Context, prefix string, pageSize int32) error {
+	paginator := s3.NewListObjectsV2Paginator(t.client, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(t.bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(pageSize),
+	})
+	var count, size int64
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("ListObjectsV2 error: %w", err)
+		}
+		for _, object := range page.Contents {
+			fmt.Printf("%s  %10s  %s\n", aws.ToTime(object.LastModified).Local().Format(time.DateTime), formatBytes(aws.ToInt64(object.Size)), aws.ToString(object.Key))
+			count++
+			size += aws.ToInt64(object.Size)
+		}
+	}
+	fmt.Printf("%d objects, %s\n", count, formatBytes(size))
+	return nil
+}
+
+// presign prints a URL to get or put the object at key. The URL is signed with
+// the credentials of this machine, so it works without credentials until it
+// expires, or until the credentials expire when they're temporary.
+func (t transfer) presign(ctx context.Context, method, key string, expires time.Duration) error {
+	presignClient := s3.NewPresignClient(t.client, s3.WithPresignExpires(expires))
+	var (
+		req *v4.PresignedHTTPRequest
+		err error
+	)
+	switch method {
+	case "get":
+		req, err = presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(t.bucket),
+			Key:    aws.String(key),
+		})
+	case "put":
+		req, err = presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(t.bucket),
+			Key:    aws.String(key),
+		})
+	default:
+		return fmt.Errorf("unknown method %q: expected get or put", method)
+	}
+	if err != nil {
+		return fmt.Errorf("Presign error: %w", err)
+	}
+	fmt.Println(req.URL)
+	if method == "put" {
+		fmt.Printf("Upload with: curl -X PUT -T <file> '%s'\n", req.URL)
+	}
+	return nil
+}
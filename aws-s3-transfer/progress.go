@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// progress prints how much of a transfer is done, at most every printInterval.
+// Writes of a download come from several goroutines, so it's safe for
+// concurrent use.
+type progress struct {
+	mu      sync.Mutex
+	action  string
+	total   int64
+	current int64
+	printed time.Time
+}
+
+const printInterval = 200 * time.Millisecond
+
+func newProgress(action string, total int64) *progress {
+	return &progress{action: action, total: total}
+}
+
+func (p *progress) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current += int64(n)
+	if time.Since(p.printed) >= printInterval {
+		p.print()
+	}
+}
+
+// done prints the final state and ends the line
+func (p *progress) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.print()
+	fmt.Fprintln(os.Stderr)
+}
+
+func (p *progress) print() {
+	p.printed = time.Now()
+	fmt.Fprintf(os.Stderr, "\r%s %s", p.action, p.String())
+}
+
+func (p *progress) String() string {
+	if p.total <= 0 {
+		return formatBytes(p.current)
+	}
+	return fmt.Sprintf("%s of %s (%d%%)", formatBytes(p.current), formatBytes(p.total), p.current*100/p.total)
+}
+
+// reader counts the bytes read from r. It only passes on Read: when the body
+// of an upload is an io.ReaderAt, the uploader reads the parts from it directly,
+// and reads them again for the checksums, so the count would be off. As a plain
+// reader, the count is the bytes read into the parts, which runs ahead of the
+// upload by at most the parts being uploaded.
+func (p *progress) reader(r io.Reader) io.Reader {
+	return progressReader{r: r, progress: p}
+}
+
+type progressReader struct {
+	r        io.Reader
+	progress *progress
+}
+
+func (r progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.progress.add(n)
+	return n, err
+}
+
+// writerAt counts the bytes written to w, as the parts of a download arrive
+func (p *progress) writerAt(w io.WriterAt) io.WriterAt {
+	return progressWriterAt{w: w, progress: p}
+}
+
+type progressWriterAt struct {
+	w        io.WriterAt
+	progress *progress
+}
+
+func (w progressWriterAt) WriteAt(b []byte, off int64) (int, error) {
+	n, err := w.w.WriteAt(b, off)
+	w.progress.add(n)
+	return n, err
+}
+
+// formatBytes formats n in B, KiB, MiB or GiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n)/unit, 0
+	for value >= unit && exp < 2 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMG"[exp])
+}
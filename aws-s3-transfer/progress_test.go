@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n        int64
+		expected string
+	}{
+		{n: 0, expected: "0 B"},
+		{n: 1023, expected: "1023 B"},
+		{n: 1536, expected: "1.5 KiB"},
+		{n: 5 << 20, expected: "5.0 MiB"},
+		{n: 3 << 40, expected: "3072.0 GiB"},
+	}
+	for _, test := range tests {
+		if s := formatBytes(test.n); s != test.expected {
+			t.Errorf("formatBytes(%d): expected %s, got %s", test.n, test.expected, s)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+	p := newProgress("Uploaded", 1000)
+	if _, err := io.Copy(io.Discard, p.reader(strings.NewReader(strings.Repeat("x", 250)))); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.String(); s != "250 B of 1000 B (25%)" {
+		t.Errorf("Expected 250 B of 1000 B (25%%), got %s", s)
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p = newProgress("Downloaded", 0)
+	w := p.writerAt(f)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.WriteAt([]byte(strings.Repeat("x", 100)), int64(i*100))
+		}()
+	}
+	wg.Wait()
+	if s := p.String(); s != "1000 B" {
+		t.Errorf("Expected 1000 B without a total, got %s", s)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// upload uploads the file to key, the name of the file by default. The transfer
+// manager uploads files larger than a part as a multipart upload, with the parts
+// in parallel, and aborts the upload when a part fails.
+func (t transfer) upload(ctx context.Context, file, key string) error {
+	if key == "" {
+		key = filepath.Base(file)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	partSize := uploadPartSize(info.Size(), t.partSize)
+	progress := newProgress("Uploaded", info.Size())
+	uploader := manager.NewUploader(t.client, func(u *manager.Uploader) {
+		u.PartSize = partSize
+		u.Concurrency = t.concurrency
+	})
+	out, err := uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(key),
+		Body:   progress.reader(f),
+	})
+	progress.done()
+	if err != nil {
+		return fmt.Errorf("Upload error: %w", err)
+	}
+	if out.UploadID != "" {
+		fmt.Printf("Uploaded %s to s3://%s/%s in %d parts of %s\n", file, t.bucket, key, len(out.CompletedParts), formatBytes(partSize))
+	} else {
+		fmt.Printf("Uploaded %s to s3://%s/%s\n", file, t.bucket, key)
+	}
+	return nil
+}
+
+// uploadPartSize raises the part size when the file needs more parts than
+// S3 allows for a multipart upload. The uploader can't do that itself, as the
+// progress reader hides the size of the file.
+func uploadPartSize(size, partSize int64) int64 {
+	partSize = max(partSize, manager.MinUploadPartSize)
+	if size/partSize >= int64(manager.MaxUploadParts) {
+		partSize = size/int64(manager.MaxUploadParts) + 1
+	}
+	return partSize
+}
+
+// download downloads the object at key to file, the last part of the key by
+// default. Without a byte range, the transfer manager downloads the parts with
+// range requests in parallel. With one, it downloads the range in one request.
+func (t transfer) download(ctx context.Context, key, file, byteRange string) error {
+	if file == "" {
+		file = filepath.Base(key)
+	}
+	head, err := t.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("HeadObject error: %w", err)
+	}
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(key),
+	}
+	size := aws.ToInt64(head.ContentLength)
+	if byteRange != "" {
+		if size, err = rangeLength(byteRange, size); err != nil {
+			return err
+		}
+		input.Range = aws.String("bytes=" + byteRange)
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	progress := newProgress("Downloaded", size)
+	downloader := manager.NewDownloader(t.client, func(d *manager.Downloader) {
+		d.PartSize = max(t.partSize, manager.MinUploadPartSize)
+		d.Concurrency = t.concurrency
+	})
+	n, err := downloader.Download(ctx, progress.writerAt(f), input)
+	progress.done()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file)
+		return fmt.Errorf("Download error: %w", err)
+	}
+	fmt.Printf("Downloaded %s of s3://%s/%s to %s\n", formatBytes(n), t.bucket, key, file)
+	return nil
+}
+
+// rangeLength returns the number of bytes in the byte range of an object of the
+// given size. The range is first-last, first- or -suffix length, like the Range
+// header without the "bytes=".
+func rangeLength(byteRange string, size int64) (int64, error) {
+	first, last, ok := strings.Cut(byteRange, "-")
+	if !ok || (first == "" && last == "") {
+		return 0, fmt.Errorf("invalid range %q: expected first-last, first- or -suffix length", byteRange)
+	}
+	var start, end int64
+	var err error
+	if first != "" {
+		if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+			return 0, fmt.Errorf("invalid range %q: %w", byteRange, err)
+		}
+	}
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil {
+			return 0, fmt.Errorf("invalid range %q: %w", byteRange, err)
+		}
+	}
+	switch {
+	case first == "":
+		// the last bytes of the object
+		return min(end, size), nil
+	case start >= size:
+		return 0, errors.New("range starts after the end of the object")
+	case last == "":
+		return size - start, nil
+	case end < start:
+		return 0, fmt.Errorf("invalid range %q: the last byte is before the first", byteRange)
+	}
+	return min(end, size-1) - start + 1, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// newTestTransfer returns a transfer to a fake S3 that serves handler on the
+// paths of the bucket "test"
+func newTestTransfer(t *testing.T, handler http.HandlerFunc) transfer {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return transfer{
+		client: s3.New(s3.Options{
+			BaseEndpoint: aws.String(server.URL),
+			UsePathStyle: true,
+			Region:       "us-east-1",
+			Credentials:  aws.AnonymousCredentials{},
+		}),
+		bucket:      "test",
+		partSize:    manager.MinUploadPartSize,
+		concurrency: 3,
+	}
+}
+
+func TestRangeLength(t *testing.T) {
+	tests := []struct {
+		byteRange string
+		length    int64
+		wantErr   bool
+	}{
+		{byteRange: "0-99", length: 100},
+		{byteRange: "10-10", length: 1},
+		{byteRange: "900-2000", length: 100},
+		{byteRange: "100-", length: 900},
+		{byteRange: "-100", length: 100},
+		{byteRange: "-2000", length: 1000},
+		{byteRange: "1000-", wantErr: true},
+		{byteRange: "99-10", wantErr: true},
+		{byteRange: "-", wantErr: true},
+		{byteRange: "100", wantErr: true},
+		{byteRange: "a-b", wantErr: true},
+	}
+	for _, test := range tests {
+		length, err := rangeLength(test.byteRange, 1000)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %d", test.byteRange, length)
+			}
+			continue
+		}
+		if err != nil || length != test.length {
+			t.Errorf("%s: expected %d, got %d (%v)", test.byteRange, test.length, length, err)
+		}
+	}
+}
+
+func TestUploadPartSize(t *testing.T) {
+	tests := []struct {
+		size, partSize, expected int64
+	}{
+		{size: 100 << 20, partSize: 16 << 20, expected: 16 << 20},
+		{size: 100 << 20, partSize: 1 << 20, expected: manager.MinUploadPartSize},
+		{size: 1 << 40, partSize: 16 << 20, expected: 1<<40/int64(manager.MaxUploadParts) + 1},
+	}
+	for _, test := range tests {
+		if partSize := uploadPartSize(test.size, test.partSize); partSize != test.expected {
+			t.Errorf("size %d: expected a part size of %d, got %d", test.size, test.expected, partSize)
+		}
+	}
+}
+
+func TestUpload(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		parts = map[string]int{}
+		calls []string
+	)
+	tr := newTestTransfer(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			calls = append(calls, "create")
+			fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodPut && query.Has("partNumber"):
+			parts[query.Get("partNumber")] = len(body)
+			w.Header().Set("ETag", `"etag-`+query.Get("partNumber")+`"`)
+		case r.Method == http.MethodPost && query.Get("uploadId") == "upload-1":
+			calls = append(calls, "complete")
+			fmt.Fprint(w, `<CompleteMultipartUploadResult><Key>big</Key></CompleteMultipartUploadResult>`)
+		case r.Method == http.MethodPut:
+			calls = append(calls, fmt.Sprintf("put %s %d", r.URL.Path, len(body)))
+		default:
+			calls = append(calls, r.Method+" "+r.URL.String())
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.txt")
+	big := filepath.Join(dir, "big")
+	if err := os.WriteFile(small, []byte("this is a test"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(big, make([]byte, 2*manager.MinUploadPartSize+100), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tr.upload(context.Background(), small, ""); err != nil {
+		t.Fatalf("upload error: %s", err)
+	}
+	if err := tr.upload(context.Background(), big, "dir/big"); err != nil {
+		t.Fatalf("upload error: %s", err)
+	}
+	if expected := "[put /test/small.txt 14 create complete]"; fmt.Sprint(calls) != expected {
+		t.Errorf("Expected %s, got %v", expected, calls)
+	}
+	expected := map[string]int{"1": int(manager.MinUploadPartSize), "2": int(manager.MinUploadPartSize), "3": 100}
+	if fmt.Sprint(parts) != fmt.Sprint(expected) {
+		t.Errorf("Expected the parts %v, got %v", expected, parts)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	object := bytes.Repeat([]byte("0123456789"), 1<<20+10)
+	var ranges []string
+	tr := newTestTransfer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/test/dir/object" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodGet {
+			ranges = append(ranges, r.Header.Get("Range"))
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(object))
+	})
+
+	tests := []struct {
+		name      string
+		byteRange string
+		expected  []byte
+		requests  int
+	}{
+		{name: "parts", expected: object, requests: 3},
+		{name: "range", byteRange: "5-14", expected: object[5:15], requests: 1},
+		{name: "suffix", byteRange: "-3", expected: object[len(object)-3:], requests: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ranges = nil
+			file := filepath.Join(t.TempDir(), "object")
+			if err := tr.download(context.Background(), "dir/object", file, test.byteRange); err != nil {
+				t.Fatalf("download error: %s", err)
+			}
+			out, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, test.expected) {
+				t.Errorf("Expected %d bytes, got %d", len(test.expected), len(out))
+			}
+			// one range request per part, the concurrency doesn't change the number
+			if len(ranges) != test.requests {
+				t.Errorf("Expected %d requests, got %d: %v", test.requests, len(ranges), ranges)
+			}
+		})
+	}
+
+	file := filepath.Join(t.TempDir(), "missing")
+	if err := tr.download(context.Background(), "missing", file, ""); err == nil {
+		t.Errorf("Expected an error for a missing object")
+	}
+	if _, err := os.Stat(file); err == nil {
+		t.Errorf("Expected no file for a missing object")
+	}
+}
+
+func TestList(t *testing.T) {
+	var tokens []string
+	tr := newTestTransfer(t, func(w http.ResponseWriter, r *http.Request) {
+		// three pages of two objects, the token is the number of the next page
+		token := r.URL.Query().Get("continuation-token")
+		tokens = append(tokens, token)
+		page := map[string]int{"": 0, "1": 1, "2": 2}[token]
+		next := ""
+		if page < 2 {
+			next = fmt.Sprintf("<NextContinuationToken>%d</NextContinuationToken>", page+1)
+		}
+		fmt.Fprintf(w, `<ListBucketResult><IsTruncated>%t</IsTruncated>%s`, page < 2, next)
+		for i := 0; i < 2; i++ {
+			fmt.Fprintf(w, `<Contents><Key>logs/%d.txt</Key><Size>512</Size><LastModified>2026-10-01T10:00:00Z</LastModified></Contents>`, page*2+i)
+		}
+		fmt.Fprint(w, `</ListBucketResult>`)
+	})
+
+	if err := tr.list(context.Background(), "logs/", 2); err != nil {
+		t.Fatalf("list error: %s", err)
+	}
+	if strings.Join(tokens, ",") != ",1,2" {
+		t.Errorf("Expected the paginator to follow the continuation tokens, got %q", tokens)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// fakeCredential returns a token without asking Azure AD
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// fakeARM is the Azure Resource Manager API of the resources of the demo. The
+// resources are created at once, so the pollers are done with the first response.
+type fakeARM struct {
+	mu       sync.Mutex
+	requests []string
+	// bodies are the JSON bodies of the PUT requests by resource type, like
+	// virtualMachines
+	bodies map[string]map[string]any
+	// groupExists is whether the resource group exists for DELETE
+	groupExists bool
+}
+
+func (f *fakeARM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	// /subscriptions/sub/resourceGroups/azure-demo/providers/Microsoft.Network/virtualNetworks/azure-demo
+	parts := strings.Split(r.URL.Path, "/")
+	resource := parts[len(parts)-2]
+	f.requests = append(f.requests, r.Method+" "+resource)
+
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"error":{"code":"ResourceNotFound","message":"not found"}}`)
+	}
+	switch {
+	case r.Method == http.MethodGet && resource == "virtualMachines":
+		notFound()
+	case r.Method == http.MethodGet && resource == "publicIPAddresses":
+		io.WriteString(w, `{"id":"/pip","properties":{"ipAddress":"20.245.1.17"}}`)
+	case r.Method == http.MethodDelete:
+		if !f.groupExists {
+			notFound()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut:
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		f.bodies[resource] = body
+		response := map[string]any{"id": "/" + resource, "location": body["location"],
+			"properties": map[string]any{"provisioningState": "Succeeded"}}
+		if resource == "virtualNetworks" {
+			response["properties"].(map[string]any)["subnets"] = []any{map[string]any{"id": "/subnets/default"}}
+		}
+		json.NewEncoder(w).Encode(response)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// newTestDemo returns a demo calling fake, in a temporary directory for the key
+// and hosts files
+func newTestDemo(t *testing.T, fake *fakeARM) *demo {
+	server := httptest.NewTLSServer(fake)
+	t.Cleanup(server.Close)
+	t.Chdir(t.TempDir())
+	options := &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Cloud: cloud.Configuration{
+				ActiveDirectoryAuthorityHost: server.URL,
+				Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+					cloud.ResourceManager: {Audience: "https://management.core.windows.net", Endpoint: server.URL},
+				},
+			},
+			Transport: server.Client(),
+			Retry:     policy.RetryOptions{MaxRetries: -1},
+		},
+		DisableRPRegistration: true,
+	}
+	d, err := newDemo("sub", fakeCredential{}, "azure-demo", "westus", options)
+	if err != nil {
+		t.Fatalf("newDemo error: %s", err)
+	}
+	return d
+}
+
+func TestCreate(t *testing.T) {
+	fake := &fakeARM{bodies: map[string]map[string]any{}}
+	d := newTestDemo(t, fake)
+	err := d.create(context.Background(), upOptions{
+		vmSize:  "Standard_B1s",
+		image:   "Canonical:ubuntu-24_04-lts:server:latest",
+		sshUser: "azureuser",
+		sshCIDR: "203.0.113.7/32",
+	})
+	if err != nil {
+		t.Fatalf("create error: %s", err)
+	}
+
+	expected := "PUT resourcegroups, PUT virtualNetworks, PUT publicIPAddresses, PUT networkSecurityGroups, PUT networkInterfaces, PUT virtualMachines"
+	if requests := strings.Join(fake.requests, ", "); requests != expected {
+		t.Errorf("Expected the requests %s, got %s", expected, requests)
+	}
+	// the bodies as JSON, to check the references between the resources
+	get := func(resource string) string {
+		b, _ := json.Marshal(fake.bodies[resource])
+		return string(b)
+	}
+	if nsg := get("networkSecurityGroups"); !strings.Contains(nsg, `"sourceAddressPrefix":"203.0.113.7/32"`) || !strings.Contains(nsg, `"destinationPortRange":"22"`) {
+		t.Errorf("Expected SSH from -ssh-cidr in the security group, got %s", nsg)
+	}
+	if nic := get("networkInterfaces"); !strings.Contains(nic, `"id":"/subnets/default"`) || !strings.Contains(nic, `"id":"/publicIPAddresses"`) {
+		t.Errorf("Expected the subnet and the public IP in the NIC, got %s", nic)
+	}
+	public, err := os.ReadFile("azure-demo.pem.pub")
+	if err != nil {
+		t.Fatalf("Expected the key pair to be written: %s", err)
+	}
+	vm := get("virtualMachines")
+	key, _ := json.Marshal(strings.TrimSpace(string(public)))
+	for _, expected := range []string{`"keyData":` + string(key), `"vmSize":"Standard_B1s"`, `"offer":"ubuntu-24_04-lts"`, `"disablePasswordAuthentication":true`} {
+		if !strings.Contains(vm, expected) {
+			t.Errorf("Expected %s in the VM, got %s", expected, vm)
+		}
+	}
+
+	// a second create after a failure uses the same key
+	if _, err := d.sshKey(); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile("azure-demo.pem.pub"); string(again) != string(public) {
+		t.Error("Expected the existing key to be used again")
+	}
+}
+
+func TestCreateInvalidImage(t *testing.T) {
+	fake := &fakeARM{bodies: map[string]map[string]any{}}
+	d := newTestDemo(t, fake)
+	err := d.create(context.Background(), upOptions{image: "ubuntu", sshCIDR: "203.0.113.7/32"})
+	if err == nil || !strings.Contains(err.Error(), "publisher:offer:sku:version") {
+		t.Errorf("Expected an invalid image error, got %v", err)
+	}
+	if len(fake.requests) != 0 {
+		t.Errorf("Expected no requests, got %v", fake.requests)
+	}
+}
+
+func TestDown(t *testing.T) {
+	for _, exists := range []bool{true, false} {
+		fake := &fakeARM{bodies: map[string]map[string]any{}, groupExists: exists}
+		d := newTestDemo(t, fake)
+		for _, file := range []string{"azure-demo.pem", "azure-demo.pem.pub", "azure-demo.hosts"} {
+			os.WriteFile(file, nil, 0600)
+		}
+		if err := d.down(context.Background()); err != nil {
+			t.Fatalf("down error with the group existing %t: %s", exists, err)
+		}
+		if len(fake.requests) != 1 || fake.requests[0] != "DELETE resourcegroups" {
+			t.Errorf("Expected the resource group to be deleted, got %v", fake.requests)
+		}
+		for _, file := range []string{"azure-demo.pem", "azure-demo.pem.pub", "azure-demo.hosts"} {
+			if _, err := os.Stat(file); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Expected %s to be removed, got %v", file, err)
+			}
+		}
+	}
+}
+
+func TestParseImage(t *testing.T) {
+	image, err := parseImage("Canonical:ubuntu-24_04-lts:server:latest")
+	if err != nil || *image.Publisher != "Canonical" || *image.Offer != "ubuntu-24_04-lts" || *image.SKU != "server" || *image.Version != "latest" {
+		t.Errorf("Unexpected image %+v, %v", image, err)
+	}
+	for _, invalid := range []string{"", "Canonical:ubuntu", "a:b:c:d:e"} {
+		if _, err := parseImage(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	if !isNotFound(&azcore.ResponseError{StatusCode: http.StatusNotFound}) {
+		t.Error("Expected a 404 to be not found")
+	}
+	if isNotFound(&azcore.ResponseError{StatusCode: http.StatusForbidden}) || isNotFound(errors.New("timeout")) {
+		t.Error("Expected other errors not to be not found")
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// down deletes the resource group, which deletes the VM, its disk and the
+// network with it, and removes the local files
+func (d *demo) down(ctx context.Context) error {
+	poller, err := d.groups.BeginDelete(ctx, d.name, nil)
+	switch {
+	case isNotFound(err):
+		fmt.Printf("Resource group %s doesn't exist\n", d.name)
+	case err != nil:
+		return fmt.Errorf("ResourceGroups.BeginDelete error: %w", err)
+	default:
+		fmt.Printf("Deleting resource group %s, this takes a few minutes\n", d.name)
+		if _, err := poller.PollUntilDone(ctx, &runtime.PollUntilDoneOptions{Frequency: 10 * time.Second}); err != nil {
+			return fmt.Errorf("resource group %s isn't deleted: %w", d.name, err)
+		}
+		fmt.Printf("Deleted resource group %s\n", d.name)
+	}
+
+	for _, file := range []string{d.keyFile(), d.keyFile() + ".pub", d.hostsFile()} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+const usage = `Usage: %s [flags] up|down
+
+Commands:
+  up    create the VM with its network, or print the one that runs already
+  down  delete the resource group with everything in it
+
+`
+
+func main() {
+	subscriptionID := flag.String("subscription", os.Getenv("AZURE_SUBSCRIPTION_ID"), "id of the Azure subscription, $AZURE_SUBSCRIPTION_ID by default")
+	location := flag.String("location", "westus", "Azure region")
+	name := flag.String("name", "azure-demo", "name of the resource group, the VM and its resources")
+	vmSize := flag.String("vm-size", "Standard_B1s", "size of the VM")
+	image := flag.String("image", "Canonical:ubuntu-24_04-lts:server:latest", "image of the VM as publisher:offer:sku:version")
+	sshUser := flag.String("ssh-user", "azureuser", "user created on the VM with the SSH key")
+	sshCIDR := flag.String("ssh-cidr", "", "network allowed to connect with SSH, the public IP of this machine by default")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *subscriptionID == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// the default credential tries the environment, a managed identity and
+	// the Azure CLI, in that order
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		fmt.Printf("NewDefaultAzureCredential error: %s\n", err)
+		os.Exit(1)
+	}
+	d, err := newDemo(*subscriptionID, cred, *name, *location, nil)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	switch flag.Arg(0) {
+	case "up":
+		err = d.up(ctx, upOptions{
+			vmSize:  *vmSize,
+			image:   *image,
+			sshUser: *sshUser,
+			sshCIDR: *sshCIDR,
+		})
+	case "down":
+		err = d.down(ctx)
+	default:
+		flag.Usage()
+		stop()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// demo creates and deletes the resources of the demo. They're all in a resource
+// group of the same name, so up can run again after a failure, and down deletes
+// everything with the group.
+type demo struct {
+	name     string
+	location string
+
+	groups     *armresources.ResourceGroupsClient
+	vnets      *armnetwork.VirtualNetworksClient
+	publicIPs  *armnetwork.PublicIPAddressesClient
+	nsgs       *armnetwork.SecurityGroupsClient
+	interfaces *armnetwork.InterfacesClient
+	vms        *armcompute.VirtualMachinesClient
+}
+
+// newDemo returns the clients of the demo, with the default options of the SDK
+// when options is nil
+func newDemo(subscriptionID string, cred azcore.TokenCredential, name, location string, options *arm.ClientOptions) (*demo, error) {
+	d := &demo{name: name, location: location}
+	var err error
+	if d.groups, err = armresources.NewResourceGroupsClient(subscriptionID, cred, options); err != nil {
+		return nil, err
+	}
+	if d.vnets, err = armnetwork.NewVirtualNetworksClient(subscriptionID, cred, options); err != nil {
+		return nil, err
+	}
+	if d.publicIPs, err = armnetwork.NewPublicIPAddressesClient(subscriptionID, cred, options); err != nil {
+		return nil, err
+	}
+	if d.nsgs, err = armnetwork.NewSecurityGroupsClient(subscriptionID, cred, options); err != nil {
+		return nil, err
+	}
+	if d.interfaces, err = armnetwork.NewInterfacesClient(subscriptionID, cred, options); err != nil {
+		return nil, err
+	}
+	if d.vms, err = armcompute.NewVirtualMachinesClient(subscriptionID, cred, options); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// keyFile is the private key of the VM, for ssh-demo. The public key is next to
+// it in keyFile().pub.
+func (d *demo) keyFile() string {
+	return d.name + ".pem"
+}
+
+// hostsFile has the VM, for the -hosts-file of ssh-demo
+func (d *demo) hostsFile() string {
+	return d.name + ".hosts"
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+
+	"ssh-demo/pkg/sshkeys"
+)
+
+// upOptions are the settings of a new VM
+type upOptions struct {
+	vmSize  string
+	image   string
+	sshUser string
+	sshCIDR string
+}
+
+func (d *demo) up(ctx context.Context, o upOptions) error {
+	_, err := d.vms.Get(ctx, d.name, d.name, nil)
+	switch {
+	case err == nil:
+		if _, err := os.Stat(d.keyFile()); err != nil {
+			return fmt.Errorf("VM %s exists, but not %s: run down first", d.name, d.keyFile())
+		}
+		fmt.Printf("VM %s exists already\n", d.name)
+	case isNotFound(err):
+		if err := d.create(ctx, o); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("VirtualMachines.Get error: %w", err)
+	}
+
+	publicIP, err := d.publicIPs.Get(ctx, d.name, d.name, nil)
+	if err != nil {
+		return fmt.Errorf("PublicIPAddresses.Get error: %w", err)
+	}
+	if publicIP.Properties == nil || publicIP.Properties.IPAddress == nil {
+		return fmt.Errorf("public IP %s has no address yet", d.name)
+	}
+	ip := *publicIP.Properties.IPAddress
+	fmt.Printf("VM %s is running at %s, waiting for SSH\n", d.name, ip)
+	if err := waitForSSH(ctx, ip, 3*time.Minute); err != nil {
+		return err
+	}
+	if err := os.WriteFile(d.hostsFile(), []byte(o.sshUser+"@"+ip+"\n"), 0644); err != nil {
+		return fmt.Errorf("WriteFile (hosts) error: %w", err)
+	}
+
+	fmt.Printf("\nConnect with the SSH demo:\n")
+	fmt.Printf("  ssh-keyscan %s >> ~/.ssh/known_hosts\n", ip)
+	fmt.Printf("  ssh-demo -hosts-file %s -key %s uptime\n", d.hostsFile(), d.keyFile())
+	fmt.Printf("\nTear it down with: azure-demo -name %s down\n", d.name)
+	return nil
+}
+
+// create creates the resource group, the network and the VM. Every step is a
+// create or update, so it continues where a failed up stopped.
+func (d *demo) create(ctx context.Context, o upOptions) error {
+	imageReference, err := parseImage(o.image)
+	if err != nil {
+		return err
+	}
+	publicKey, err := d.sshKey()
+	if err != nil {
+		return err
+	}
+	if o.sshCIDR == "" {
+		ip, err := myPublicIP(ctx)
+		if err != nil {
+			return fmt.Errorf("can't find the public IP of this machine, set -ssh-cidr: %w", err)
+		}
+		o.sshCIDR = ip + "/32"
+	}
+
+	if _, err := d.groups.CreateOrUpdate(ctx, d.name, armresources.ResourceGroup{
+		Location: to.Ptr(d.location),
+	}, nil); err != nil {
+		return fmt.Errorf("ResourceGroups.CreateOrUpdate error: %w", err)
+	}
+	fmt.Printf("Created resource group %s in %s\n", d.name, d.location)
+
+	// the subnet is created with the VNet, so it comes back with its id
+	poller, err := d.vnets.BeginCreateOrUpdate(ctx, d.name, d.name, armnetwork.VirtualNetwork{
+		Location: to.Ptr(d.location),
+		Properties: &armnetwork.VirtualNetworkPropertiesFormat{
+			AddressSpace: &armnetwork.AddressSpace{
+				AddressPrefixes: []*string{to.Ptr("10.1.0.0/16")},
+			},
+			Subnets: []*armnetwork.Subnet{{
+				Name: to.Ptr("default"),
+				Properties: &armnetwork.SubnetPropertiesFormat{
+					AddressPrefix: to.Ptr("10.1.0.0/24"),
+				},
+			}},
+		},
+	}, nil)
+	vnet, err := pollUntilDone(ctx, "virtual network", poller, err)
+	if err != nil {
+		return err
+	}
+	if len(vnet.Properties.Subnets) == 0 {
+		return fmt.Errorf("virtual network %s has no subnet", d.name)
+	}
+
+	// basic public IPs are retired, a standard one is static and only lets in
+	// what the security group allows
+	publicIPPoller, err := d.publicIPs.BeginCreateOrUpdate(ctx, d.name, d.name, armnetwork.PublicIPAddress{
+		Location: to.Ptr(d.location),
+		SKU: &armnetwork.PublicIPAddressSKU{
+			Name: to.Ptr(armnetwork.PublicIPAddressSKUNameStandard),
+			Tier: to.Ptr(armnetwork.PublicIPAddressSKUTierRegional),
+		},
+		Properties: &armnetwork.PublicIPAddressPropertiesFormat{
+			PublicIPAllocationMethod: to.Ptr(armnetwork.IPAllocationMethodStatic),
+		},
+	}, nil)
+	publicIP, err := pollUntilDone(ctx, "public IP", publicIPPoller, err)
+	if err != nil {
+		return err
+	}
+
+	nsgPoller, err := d.nsgs.BeginCreateOrUpdate(ctx, d.name, d.name, armnetwork.SecurityGroup{
+		Location: to.Ptr(d.location),
+		Properties: &armnetwork.SecurityGroupPropertiesFormat{
+			SecurityRules: []*armnetwork.SecurityRule{{
+				Name: to.Ptr("allow-ssh"),
+				Properties: &armnetwork.SecurityRulePropertiesFormat{
+					SourceAddressPrefix:      to.Ptr(o.sshCIDR),
+					SourcePortRange:          to.Ptr("*"),
+					DestinationAddressPrefix: to.Ptr("*"),
+					DestinationPortRange:     to.Ptr("22"),
+					Protocol:                 to.Ptr(armnetwork.SecurityRuleProtocolTCP),
+					Access:                   to.Ptr(armnetwork.SecurityRuleAccessAllow),
+					Direction:                to.Ptr(armnetwork.SecurityRuleDirectionInbound),
+					Priority:                 to.Ptr[int32](1001),
+					Description:              to.Ptr("SSH"),
+				},
+			}},
+		},
+	}, nil)
+	nsg, err := pollUntilDone(ctx, "network security group", nsgPoller, err)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("SSH is allowed from %s\n", o.sshCIDR)
+
+	nicPoller, err := d.interfaces.BeginCreateOrUpdate(ctx, d.name, d.name, armnetwork.Interface{
+		Location: to.Ptr(d.location),
+		Properties: &armnetwork.InterfacePropertiesFormat{
+			NetworkSecurityGroup: &armnetwork.SecurityGroup{ID: nsg.ID},
+			IPConfigurations: []*armnetwork.InterfaceIPConfiguration{{
+				Name: to.Ptr("default"),
+				Properties: &armnetwork.InterfaceIPConfigurationPropertiesFormat{
+					PrivateIPAllocationMethod: to.Ptr(armnetwork.IPAllocationMethodDynamic),
+					Subnet:                    &armnetwork.Subnet{ID: vnet.Properties.Subnets[0].ID},
+					PublicIPAddress:           &armnetwork.PublicIPAddress{ID: publicIP.ID},
+				},
+			}},
+		},
+	}, nil)
+	nic, err := pollUntilDone(ctx, "network interface", nicPoller, err)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Creating a %s VM with %s\n", o.vmSize, o.image)
+	vmPoller, err := d.vms.BeginCreateOrUpdate(ctx, d.name, d.name, armcompute.VirtualMachine{
+		Location: to.Ptr(d.location),
+		Properties: &armcompute.VirtualMachineProperties{
+			HardwareProfile: &armcompute.HardwareProfile{
+				VMSize: to.Ptr(armcompute.VirtualMachineSizeTypes(o.vmSize)),
+			},
+			StorageProfile: &armcompute.StorageProfile{
+				ImageReference: imageReference,
+				OSDisk: &armcompute.OSDisk{
+					Name:         to.Ptr(d.name),
+					CreateOption: to.Ptr(armcompute.DiskCreateOptionTypesFromImage),
+					ManagedDisk: &armcompute.ManagedDiskParameters{
+						StorageAccountType: to.Ptr(armcompute.StorageAccountTypesStandardLRS),
+					},
+				},
+			},
+			OSProfile: &armcompute.OSProfile{
+				ComputerName:  to.Ptr(d.name),
+				AdminUsername: to.Ptr(o.sshUser),
+				LinuxConfiguration: &armcompute.LinuxConfiguration{
+					DisablePasswordAuthentication: to.Ptr(true),
+					SSH: &armcompute.SSHConfiguration{
+						PublicKeys: []*armcompute.SSHPublicKey{{
+							Path:    to.Ptr("/home/" + o.sshUser + "/.ssh/authorized_keys"),
+							KeyData: to.Ptr(publicKey),
+						}},
+					},
+				},
+			},
+			NetworkProfile: &armcompute.NetworkProfile{
+				NetworkInterfaces: []*armcompute.NetworkInterfaceReference{{ID: nic.ID}},
+			},
+		},
+	}, nil)
+	if _, err := pollUntilDone(ctx, "VM", vmPoller, err); err != nil {
+		return err
+	}
+	return nil
+}
+
+// pollUntilDone waits for the long-running operation of the poller to finish.
+// err is the error of the Begin call that returned the poller.
+func pollUntilDone[T any](ctx context.Context, resource string, poller *runtime.Poller[T], err error) (T, error) {
+	var zero T
+	if err != nil {
+		return zero, fmt.Errorf("can't create the %s: %w", resource, err)
+	}
+	fmt.Printf("Waiting for the %s\n", resource)
+	result, err := poller.PollUntilDone(ctx, &runtime.PollUntilDoneOptions{Frequency: 5 * time.Second})
+	if err != nil {
+		return zero, fmt.Errorf("%s isn't created: %w", resource, err)
+	}
+	return result, nil
+}
+
+// sshKey returns the public key to log in to the VM with. It generates an ed25519
+// key pair unless the key file exists, from an up that failed after it.
+func (d *demo) sshKey() (string, error) {
+	if public, err := os.ReadFile(d.keyFile() + ".pub"); err == nil {
+		fmt.Printf("Using the key in %s\n", d.keyFile())
+		return strings.TrimSpace(string(public)), nil
+	}
+	pair, err := sshkeys.Generate(sshkeys.ED25519, 0, d.name, "")
+	if err != nil {
+		return "", err
+	}
+	if err := sshkeys.WriteKeyPair(d.keyFile(), pair, false); err != nil {
+		return "", err
+	}
+	fmt.Printf("Created key %s, the private key is in %s\n", pair.Fingerprint, d.keyFile())
+	return strings.TrimSpace(string(pair.Public)), nil
+}
+
+// parseImage parses an image as publisher:offer:sku:version, like the Azure CLI
+func parseImage(image string) (*armcompute.ImageReference, error) {
+	parts := strings.Split(image, ":")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid image %q: expected publisher:offer:sku:version", image)
+	}
+	return &armcompute.ImageReference{
+		Publisher: to.Ptr(parts[0]),
+		Offer:     to.Ptr(parts[1]),
+		SKU:       to.Ptr(parts[2]),
+		Version:   to.Ptr(parts[3]),
+	}, nil
+}
+
+// waitForSSH waits for the SSH port to accept connections. The VM is created
+// before it has booted, so the SSH demo would fail right after the poller.
+func waitForSSH(ctx context.Context, ip string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip, "22"))
+		if err == nil {
+			return conn.Close()
+		}
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return fmt.Errorf("SSH on %s doesn't answer: %w", ip, err)
+		}
+	}
+}
+
+// myPublicIP asks for the IP this machine connects from
+func myPublicIP(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://checkip.amazonaws.com", nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64))
+	if err != nil {
+		return "", err
+	}
+	ip := strings.TrimSpace(string(body))
+	if res.StatusCode != http.StatusOK || net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("unexpected answer: HTTP %d %q", res.StatusCode, ip)
+	}
+	return ip, nil
+}
+
+// isNotFound returns whether err is an Azure API error for a resource, or its
+// resource group, that doesn't exist
+func isNotFound(err error) bool {
+	var responseErr *azcore.ResponseError
+	return errors.As(err, &responseErr) && responseErr.StatusCode == http.StatusNotFound
+}
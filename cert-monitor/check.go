@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// result is the check of an endpoint or a PEM file
+type result struct {
+	Source string `json:"source"`
+	// Subject and NotAfter are the ones of the certificate of the chain that
+	// expires first, the leaf unless an intermediate expires before it
+	Subject  string    `json:"subject,omitempty"`
+	NotAfter time.Time `json:"notAfter,omitzero"`
+	DaysLeft int       `json:"daysLeft"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// the statuses of a result, ok is the only one with a zero exit code
+const (
+	statusOK       = "ok"
+	statusExpiring = "expiring"
+	statusExpired  = "expired"
+	statusInvalid  = "invalid"
+	statusError    = "error"
+)
+
+type checker struct {
+	// roots are the CAs the chains are verified with, nil for the system ones
+	roots      *x509.CertPool
+	skipVerify bool
+	threshold  time.Duration
+	timeout    time.Duration
+}
+
+// check checks the file when it exists, or connects to source as host:port
+func (c checker) check(ctx context.Context, source string) result {
+	if _, err := os.Stat(source); err == nil {
+		return c.checkFile(source)
+	}
+	return c.checkEndpoint(ctx, source)
+}
+
+// checkEndpoint connects to the endpoint, port 443 by default, and checks the
+// chain the server presents for the host
+func (c checker) checkEndpoint(ctx context.Context, addr string) result {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, "443"
+	}
+	dialer := tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: c.timeout},
+		// the chain is verified after the handshake, to report the expiry of an
+		// invalid chain too
+		Config: &tls.Config{InsecureSkipVerify: true, ServerName: host},
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return result{Source: addr, Status: statusError, Error: err.Error()}
+	}
+	defer conn.Close()
+	chain := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	return c.evaluate(addr, chain, x509.VerifyOptions{DNSName: host})
+}
+
+// checkFile checks the certificates in the PEM file, the first one is the leaf
+func (c checker) checkFile(file string) result {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return result{Source: file, Status: statusError, Error: err.Error()}
+	}
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return result{Source: file, Status: statusError, Error: err.Error()}
+		}
+		chain = append(chain, cert)
+	}
+	// a file can have a client certificate, or a CA
+	return c.evaluate(file, chain, x509.VerifyOptions{KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+}
+
+// evaluate verifies the chain, and finds the certificate that expires first
+func (c checker) evaluate(source string, chain []*x509.Certificate, options x509.VerifyOptions) result {
+	if len(chain) == 0 {
+		return result{Source: source, Status: statusError, Error: "no certificates"}
+	}
+	first := chain[0]
+	for _, cert := range chain[1:] {
+		if cert.NotAfter.Before(first.NotAfter) {
+			first = cert
+		}
+	}
+	r := result{
+		Source:   source,
+		Subject:  first.Subject.String(),
+		NotAfter: first.NotAfter,
+		DaysLeft: int(time.Until(first.NotAfter).Hours() / 24),
+		Status:   statusOK,
+	}
+
+	switch {
+	case time.Now().After(first.NotAfter):
+		r.Status = statusExpired
+	case time.Until(first.NotAfter) < c.threshold:
+		r.Status = statusExpiring
+	}
+	if c.skipVerify {
+		return r
+	}
+
+	options.Roots = c.roots
+	options.Intermediates = x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		options.Intermediates.AddCert(cert)
+	}
+	if _, err := chain[0].Verify(options); err != nil {
+		// an expired chain is reported as expired, not invalid
+		var invalid x509.CertificateInvalidError
+		if errors.As(err, &invalid) && invalid.Reason == x509.Expired {
+			return r
+		}
+		if r.Status == statusOK {
+			r.Status = statusInvalid
+		}
+		r.Error = fmt.Sprintf("chain not valid: %s", err)
+	}
+	return r
+}
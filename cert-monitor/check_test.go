@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"certs"
+)
+
+// newTLSServer serves the certificate, with the chain of intermediates after it
+func newTLSServer(t *testing.T, cert *certs.Cert, intermediates ...*certs.Cert) string {
+	tlsCert := tls.Certificate{Certificate: [][]byte{cert.Cert.Raw}, PrivateKey: cert.Key}
+	for _, intermediate := range intermediates {
+		tlsCert.Certificate = append(tlsCert.Certificate, intermediate.Cert.Raw)
+	}
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "https://")
+}
+
+func newIntermediate(t *testing.T, ca *certs.Cert, validity time.Duration) *certs.Cert {
+	intermediate, err := certs.Generate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, ca, validity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return intermediate
+}
+
+func TestCheckEndpoint(t *testing.T) {
+	ca, err := certs.NewCA("test CA", 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	intermediate := newIntermediate(t, ca, 10*24*time.Hour)
+	server, err := certs.NewServer(intermediate, "localhost", []string{"127.0.0.1"}, 90*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := certs.NewServer(ca, "other", []string{"other.example.com"}, 90*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := newTLSServer(t, server, intermediate)
+	otherAddr := newTLSServer(t, other)
+
+	tests := []struct {
+		name      string
+		addr      string
+		roots     *x509.CertPool
+		threshold time.Duration
+		status    string
+		subject   string
+		err       string
+	}{
+		{name: "valid", addr: addr, roots: roots, threshold: 5 * 24 * time.Hour, status: statusOK, subject: "CN=intermediate"},
+		{name: "intermediate expires first", addr: addr, roots: roots, threshold: 30 * 24 * time.Hour, status: statusExpiring, subject: "CN=intermediate"},
+		{name: "unknown CA", addr: addr, threshold: time.Hour, status: statusInvalid, err: "unknown authority"},
+		{name: "other host", addr: otherAddr, roots: roots, threshold: time.Hour, status: statusInvalid, err: "127.0.0.1"},
+		{name: "connection refused", addr: "127.0.0.1:1", status: statusError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := checker{roots: test.roots, threshold: test.threshold, timeout: 5 * time.Second}
+			r := c.check(context.Background(), test.addr)
+			if r.Status != test.status {
+				t.Errorf("Expected status %s, got %s (%s)", test.status, r.Status, r.Error)
+			}
+			if test.subject != "" && r.Subject != test.subject {
+				t.Errorf("Expected the subject %s, got %s", test.subject, r.Subject)
+			}
+			if !strings.Contains(r.Error, test.err) {
+				t.Errorf("Expected the error to contain %q, got %q", test.err, r.Error)
+			}
+		})
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := certs.NewCA("test CA", 365*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := certs.NewClient(ca, "client", 90*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// certs.Generate makes the certificate valid from an hour ago, so a validity
+	// of -30 minutes is expired
+	expired, err := certs.NewClient(ca, "expired", -30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, cert *certs.Cert) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, cert.CertPEM(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	clientFile, expiredFile, caFile := write("client.pem", client), write("expired.pem", expired), write("ca.pem", ca)
+	empty := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	c := checker{roots: roots, threshold: 30 * 24 * time.Hour, timeout: time.Second}
+	results := checkAll(context.Background(), c, []string{clientFile, expiredFile, caFile, empty}, 2)
+	expected := []string{statusOK, statusExpired, statusOK, statusError}
+	for i, r := range results {
+		if r.Status != expected[i] {
+			t.Errorf("%s: expected %s, got %s (%s)", r.Source, expected[i], r.Status, r.Error)
+		}
+	}
+	if results[0].DaysLeft != 89 {
+		t.Errorf("Expected 89 full days left, got %d", results[0].DaysLeft)
+	}
+	if results[1].Error != "" {
+		t.Errorf("Expected an expired certificate without a chain error, got %s", results[1].Error)
+	}
+}
@@ -0,0 +1,15 @@
+module cert-monitor
+
+go 1.24.2
+
+require (
+	certs v0.0.0-00010101000000-000000000000
+	notify v0.0.0-00010101000000-000000000000
+	secrets v0.0.0-00010101000000-000000000000
+)
+
+replace certs => ../pkg/certs
+
+replace notify => ../pkg/notify
+
+replace secrets => ../pkg/secrets
@@ -0,0 +1,120 @@
+// Command cert-monitor checks the certificates of TLS endpoints and PEM files, and
+// exits with 1 when one expires within the threshold or isn't valid, e.g. in a
+// cron job
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"notify"
+	"secrets"
+)
+
+const usage = `Usage: %s [flags] host[:port]|file.pem...
+
+Checks the certificate chain of every endpoint (port 443 by default) and PEM file,
+and reports when the first certificate of the chain expires. Exits with 1 when a
+certificate expires within -threshold, has an invalid chain, or can't be checked.
+The certificates that aren't ok are sent to the Slack or Teams channels of
+NOTIFY_SLACK_WEBHOOK_URL and NOTIFY_TEAMS_WEBHOOK_URL, and to the webhook of
+NOTIFY_WEBHOOK_URL, when they're set.
+
+`
+
+func main() {
+	threshold := flag.Duration("threshold", 30*24*time.Hour, "report the certificates that expire within this duration")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of a connection and TLS handshake")
+	concurrency := flag.Int("concurrency", 10, "number of sources checked at the same time")
+	caFile := flag.String("cacert", "", "PEM file with the CAs to verify the chains with, instead of the system CAs")
+	skipVerify := flag.Bool("skip-verify", false, "only check the expiry, not the chains")
+	jsonOutput := flag.Bool("json", false, "write the report as JSON")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || *concurrency < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	store, err := secrets.FromEnv("NOTIFY_")
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+	notifiers, err := notify.FromSecrets(ctx, store, notify.Options{})
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	c := checker{skipVerify: *skipVerify, threshold: *threshold, timeout: *timeout}
+	if *caFile != "" {
+		caPEM, err := os.ReadFile(*caFile)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		c.roots = x509.NewCertPool()
+		if !c.roots.AppendCertsFromPEM(caPEM) {
+			fmt.Printf("Error: no PEM certificates found in %s\n", *caFile)
+			os.Exit(1)
+		}
+	}
+
+	results := checkAll(ctx, c, flag.Args(), *concurrency)
+	if *jsonOutput {
+		err = writeJSON(os.Stdout, results)
+	} else {
+		err = writeTable(os.Stdout, results)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+	message, failed, err := alert(results)
+	if err == nil && failed && len(notifiers) > 0 {
+		err = notifiers.Notify(ctx, message)
+	}
+	if err != nil {
+		fmt.Printf("Error: failed to send the alert: %s\n", err)
+	}
+	if failed {
+		stop()
+		os.Exit(1)
+	}
+}
+
+// checkAll checks the sources with concurrency workers, and returns the results
+// in the order of the sources
+func checkAll(ctx context.Context, c checker, sources []string, concurrency int) []result {
+	results := make([]result, len(sources))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = c.check(ctx, sources[i])
+			}
+		}()
+	}
+	for i := range sources {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"notify"
+)
+
+// alertTemplate is the alert of the certificates that aren't ok
+var alertTemplate = notify.MustTemplate(
+	"{{len .Failed}} of {{.Total}} certificates need attention",
+	`{{range .Failed}}{{.Source}}: {{.Status}}{{if not .NotAfter.IsZero}}, expires on {{.NotAfter.Format "2006-01-02"}} ({{.DaysLeft}} days){{end}}{{if .Error}}, {{.Error}}{{end}}
+{{end}}`,
+)
+
+// writeTable writes a line per source in aligned columns, and the errors after them
+func writeTable(w io.Writer, results []result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tSTATUS\tEXPIRES\tDAYS\tSUBJECT")
+	for _, r := range results {
+		expires, days := "-", "-"
+		if !r.NotAfter.IsZero() {
+			expires, days = r.NotAfter.Format(time.DateOnly), fmt.Sprint(r.DaysLeft)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Source, r.Status, expires, days, r.Subject)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(w, "%s: %s\n", r.Source, r.Error)
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, results []result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+// alert returns the alert of the results that aren't ok, a warning when they're only
+// expiring, and false when they're all ok
+func alert(results []result) (notify.Message, bool, error) {
+	data := struct {
+		Total  int
+		Failed []result
+	}{Total: len(results)}
+	severity := notify.SeverityWarning
+	for _, r := range results {
+		if r.Status == statusOK {
+			continue
+		}
+		data.Failed = append(data.Failed, r)
+		if r.Status != statusExpiring {
+			severity = notify.SeverityError
+		}
+	}
+	if len(data.Failed) == 0 {
+		return notify.Message{}, false, nil
+	}
+	message, err := alertTemplate.Message(severity, data)
+	return message, true, err
+}
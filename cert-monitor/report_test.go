@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"notify"
+)
+
+func TestWriteTable(t *testing.T) {
+	results := []result{
+		{Source: "example.com:443", Subject: "CN=example.com", NotAfter: time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), DaysLeft: 100, Status: statusOK},
+		{Source: "down:443", Status: statusError, Error: "connection refused"},
+	}
+	var out bytes.Buffer
+	if err := writeTable(&out, results); err != nil {
+		t.Fatal(err)
+	}
+	expected := `SOURCE           STATUS  EXPIRES     DAYS  SUBJECT
+example.com:443  ok      2030-01-02  100   CN=example.com
+down:443         error   -           -     
+down:443: connection refused
+`
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := writeJSON(&out, results); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []result
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %s", err)
+	}
+	if len(decoded) != 2 || decoded[0].NotAfter != results[0].NotAfter || !strings.Contains(out.String(), `"status": "error"`) {
+		t.Errorf("Unexpected JSON: %s", out.String())
+	}
+}
+
+func TestAlert(t *testing.T) {
+	expiring := result{Source: "soon.example.com:443", NotAfter: time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), DaysLeft: 5, Status: statusExpiring}
+	down := result{Source: "down:443", Status: statusError, Error: "connection refused"}
+	ok := result{Source: "example.com:443", Status: statusOK}
+
+	if _, failed, err := alert([]result{ok}); failed || err != nil {
+		t.Errorf("Expected no alert, got %v, %v", failed, err)
+	}
+
+	message, failed, err := alert([]result{ok, expiring})
+	if !failed || err != nil || message.Severity != notify.SeverityWarning {
+		t.Errorf("Expected a warning, got %+v, %v, %v", message, failed, err)
+	}
+
+	message, _, _ = alert([]result{ok, expiring, down})
+	expected := "soon.example.com:443: expiring, expires on 2030-01-02 (5 days)\ndown:443: error, connection refused\n"
+	if message.Severity != notify.SeverityError || message.Title != "2 of 3 certificates need attention" || message.Text != expected {
+		t.Errorf("Unexpected alert %+v", message)
+	}
+}
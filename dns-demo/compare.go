@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// check asks every nameserver for every record type concurrently, and returns the
+// answers by type, in the order of the nameservers
+func check(ctx context.Context, name string, nameservers, types []string, timeout time.Duration, tcp bool) map[string][]answer {
+	answers := make(map[string][]answer)
+	for _, recordType := range types {
+		answers[recordType] = make([]answer, len(nameservers))
+	}
+	var wg sync.WaitGroup
+	for _, recordType := range types {
+		for i, nameserver := range nameservers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// every goroutine writes to its own element, the map isn't written
+				answers[recordType][i] = lookup(ctx, nameserver, fqdn(name), recordType, timeout, tcp)
+			}()
+		}
+	}
+	wg.Wait()
+	return answers
+}
+
+// inconsistency is a record type the nameservers answer differently, e.g. when a
+// change hasn't propagated to all of them yet
+type inconsistency struct {
+	Type string
+	// Answers are the different answers, with the nameservers giving them
+	Answers map[string][]string
+	// MaxTTL is the highest TTL of the answers: the caches that have an old
+	// answer can keep it that long
+	MaxTTL uint32
+}
+
+// compare returns the inconsistency of the answers of the nameservers that
+// answered, nil when they all answered the same
+func compare(recordType string, answers []answer) *inconsistency {
+	found := &inconsistency{Type: recordType, Answers: make(map[string][]string)}
+	for _, a := range answers {
+		if a.Err != nil {
+			continue
+		}
+		values := strings.Join(a.Values, ", ")
+		found.Answers[values] = append(found.Answers[values], a.Nameserver)
+		if a.TTL > found.MaxTTL {
+			found.MaxTTL = a.TTL
+		}
+	}
+	if len(found.Answers) < 2 {
+		return nil
+	}
+	return found
+}
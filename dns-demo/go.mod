@@ -0,0 +1,5 @@
+module dns-demo
+
+go 1.24.2
+
+require golang.org/x/net v0.49.0
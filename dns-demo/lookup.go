@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// the record types of -types, with the query type of their TTLs
+var supportedTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"TXT":   dnsmessage.TypeTXT,
+	"MX":    dnsmessage.TypeMX,
+}
+
+// answer is the answer of a nameserver for a record type
+type answer struct {
+	Nameserver string
+	Type       string
+	// Values are sorted, so answers in another order are the same
+	Values []string
+	// TTL is the lowest TTL of the records, what a cache keeps them for
+	TTL uint32
+	// Err is set when the nameserver didn't answer, a name without records of
+	// the type is an answer without values
+	Err error
+}
+
+// lookup asks the nameserver, host:port, for the records of the type
+func lookup(ctx context.Context, nameserver, name, recordType string, timeout time.Duration, tcp bool) answer {
+	rec := &recorder{}
+	resolver := &net.Resolver{
+		// the Go resolver sends the queries through Dial, the cgo one doesn't
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			// the address is the nameserver of resolv.conf, use the one asked instead
+			if tcp {
+				network = "tcp"
+			}
+			dialer := net.Dialer{Timeout: timeout}
+			conn, err := dialer.DialContext(ctx, network, nameserver)
+			if err != nil {
+				return nil, err
+			}
+			return rec.wrap(conn), nil
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	a := answer{Nameserver: nameserver, Type: recordType}
+	var err error
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		var ips []net.IP
+		ips, err = resolver.LookupIP(ctx, network, name)
+		for _, ip := range ips {
+			a.Values = append(a.Values, ip.String())
+		}
+	case "CNAME":
+		var cname string
+		cname, err = resolver.LookupCNAME(ctx, name)
+		// without a CNAME record, the canonical name is the name itself
+		if cname != "" && cname != name {
+			a.Values = []string{cname}
+		}
+	case "TXT":
+		a.Values, err = resolver.LookupTXT(ctx, name)
+	case "MX":
+		var mxs []*net.MX
+		mxs, err = resolver.LookupMX(ctx, name)
+		for _, mx := range mxs {
+			a.Values = append(a.Values, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+	default:
+		err = fmt.Errorf("unsupported record type %s", recordType)
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		err = nil
+	}
+	a.Err = err
+	sort.Strings(a.Values)
+	a.TTL = rec.ttl(supportedTypes[recordType])
+	return a
+}
+
+// recorder keeps the DNS messages the resolver reads, for their TTLs, which
+// net.Resolver doesn't return
+type recorder struct {
+	mu       sync.Mutex
+	messages [][]byte
+}
+
+func (r *recorder) add(message []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, append([]byte{}, message...))
+}
+
+// wrap records what's read from the connection. The resolver only reads a UDP
+// connection packet by packet when it's a net.PacketConn, so it stays one.
+func (r *recorder) wrap(conn net.Conn) net.Conn {
+	if udp, ok := conn.(*net.UDPConn); ok {
+		return &recordingPacketConn{UDPConn: udp, rec: r}
+	}
+	return &recordingStreamConn{Conn: conn, rec: r}
+}
+
+// ttl returns the lowest TTL of the answer records of the type
+func (r *recorder) ttl(recordType dnsmessage.Type) uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ttl uint32
+	found := false
+	for _, message := range r.messages {
+		var parser dnsmessage.Parser
+		if _, err := parser.Start(message); err != nil {
+			continue
+		}
+		if err := parser.SkipAllQuestions(); err != nil {
+			continue
+		}
+		answers, err := parser.AllAnswers()
+		if err != nil {
+			continue
+		}
+		for _, answer := range answers {
+			if answer.Header.Type == recordType && (!found || answer.Header.TTL < ttl) {
+				ttl, found = answer.Header.TTL, true
+			}
+		}
+	}
+	return ttl
+}
+
+// recordingPacketConn records every UDP packet read, a DNS message each
+type recordingPacketConn struct {
+	*net.UDPConn
+	rec *recorder
+}
+
+func (c *recordingPacketConn) Read(b []byte) (int, error) {
+	n, err := c.UDPConn.Read(b)
+	if n > 0 {
+		c.rec.add(b[:n])
+	}
+	return n, err
+}
+
+// recordingStreamConn records the TCP stream, messages prefixed by their length,
+// and splits it into messages when it's closed
+type recordingStreamConn struct {
+	net.Conn
+	rec    *recorder
+	stream []byte
+}
+
+func (c *recordingStreamConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.stream = append(c.stream, b[:n]...)
+	return n, err
+}
+
+func (c *recordingStreamConn) Close() error {
+	for len(c.stream) >= 2 {
+		length := int(c.stream[0])<<8 | int(c.stream[1])
+		if len(c.stream) < 2+length {
+			break
+		}
+		c.rec.add(c.stream[2 : 2+length])
+		c.stream = c.stream[2+length:]
+	}
+	return c.Conn.Close()
+}
+
+// fqdn adds the final dot, so the resolver doesn't try the search domains of
+// resolv.conf first
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// withPort adds the DNS port to a nameserver without one
+func withPort(nameserver string) string {
+	if _, _, err := net.SplitHostPort(nameserver); err == nil {
+		return nameserver
+	}
+	return net.JoinHostPort(nameserver, "53")
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeNameserver answers the questions with the records of their type, over UDP
+// and TCP on the same port
+type fakeNameserver struct {
+	records []dnsmessage.Resource
+}
+
+func newFakeNameserver(t *testing.T, records ...dnsmessage.Resource) string {
+	ns := &fakeNameserver{records: records}
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", packetConn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		packetConn.Close()
+		listener.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := packetConn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			packetConn.WriteTo(ns.answer(buf[:n]), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var length uint16
+				for binary.Read(conn, binary.BigEndian, &length) == nil {
+					query := make([]byte, length)
+					if _, err := io.ReadFull(conn, query); err != nil {
+						return
+					}
+					response := ns.answer(query)
+					conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(response))), response...))
+				}
+			}()
+		}
+	}()
+	return packetConn.LocalAddr().String()
+}
+
+func (ns *fakeNameserver) answer(query []byte) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil
+	}
+	response := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true},
+		Questions: []dnsmessage.Question{question},
+	}
+	for _, record := range ns.records {
+		if record.Header.Type == question.Type && strings.EqualFold(record.Header.Name.String(), question.Name.String()) {
+			response.Answers = append(response.Answers, record)
+		}
+	}
+	packed, _ := response.Pack()
+	return packed
+}
+
+func header(recordType dnsmessage.Type, ttl uint32) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("demo.example."), Type: recordType, Class: dnsmessage.ClassINET, TTL: ttl}
+}
+
+func aRecord(ip string, ttl uint32) dnsmessage.Resource {
+	return dnsmessage.Resource{Header: header(dnsmessage.TypeA, ttl), Body: &dnsmessage.AResource{A: [4]byte(net.ParseIP(ip).To4())}}
+}
+
+func TestLookup(t *testing.T) {
+	nameserver := newFakeNameserver(t,
+		aRecord("192.0.2.2", 300),
+		aRecord("192.0.2.1", 60),
+		dnsmessage.Resource{Header: header(dnsmessage.TypeAAAA, 120), Body: &dnsmessage.AAAAResource{AAAA: [16]byte(net.ParseIP("2001:db8::1"))}},
+		dnsmessage.Resource{Header: header(dnsmessage.TypeTXT, 30), Body: &dnsmessage.TXTResource{TXT: []string{"v=spf1 -all"}}},
+		dnsmessage.Resource{Header: header(dnsmessage.TypeMX, 3600), Body: &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.example.")}},
+	)
+
+	tests := []struct {
+		recordType string
+		values     string
+		ttl        uint32
+	}{
+		// the TTL is the lowest of the records
+		{recordType: "A", values: "192.0.2.1, 192.0.2.2", ttl: 60},
+		{recordType: "AAAA", values: "2001:db8::1", ttl: 120},
+		{recordType: "TXT", values: "v=spf1 -all", ttl: 30},
+		{recordType: "MX", values: "10 mail.example.", ttl: 3600},
+		{recordType: "CNAME", values: "", ttl: 0},
+	}
+	for _, tcp := range []bool{false, true} {
+		for _, test := range tests {
+			a := lookup(context.Background(), nameserver, "demo.example.", test.recordType, 5*time.Second, tcp)
+			if a.Err != nil {
+				t.Errorf("%s (tcp %t): lookup error: %s", test.recordType, tcp, a.Err)
+				continue
+			}
+			if values := strings.Join(a.Values, ", "); values != test.values || a.TTL != test.ttl {
+				t.Errorf("%s (tcp %t): expected %q with TTL %d, got %q with TTL %d", test.recordType, tcp, test.values, test.ttl, values, a.TTL)
+			}
+		}
+	}
+}
+
+func TestCheckInconsistent(t *testing.T) {
+	updated := newFakeNameserver(t, aRecord("192.0.2.10", 300))
+	stale := newFakeNameserver(t, aRecord("192.0.2.1", 3600))
+	empty := newFakeNameserver(t)
+	same := newFakeNameserver(t, aRecord("192.0.2.10", 250))
+
+	answers := check(context.Background(), "demo.example", []string{updated, same}, []string{"A"}, 5*time.Second, false)
+	if found := compare("A", answers["A"]); found != nil {
+		t.Errorf("Expected the same answers with other TTLs to be consistent, got %v", found.Answers)
+	}
+
+	answers = check(context.Background(), "demo.example", []string{updated, stale, empty, "127.0.0.1:1"}, []string{"A"}, time.Second, false)
+	found := compare("A", answers["A"])
+	if found == nil {
+		t.Fatalf("Expected an inconsistency, got %v", answers["A"])
+	}
+	if len(found.Answers) != 3 || found.MaxTTL != 3600 {
+		t.Errorf("Expected 3 answers with a max TTL of 3600, got %v with %d", found.Answers, found.MaxTTL)
+	}
+	if answers["A"][3].Err == nil {
+		t.Errorf("Expected an error from a nameserver that doesn't answer")
+	}
+}
+
+func TestLookupCNAME(t *testing.T) {
+	nameserver := newFakeNameserver(t, dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("www.demo.example."), Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET, TTL: 900},
+		Body:   &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("demo.example.")},
+	})
+	a := lookup(context.Background(), nameserver, "www.demo.example.", "CNAME", 5*time.Second, false)
+	if a.Err != nil || strings.Join(a.Values, ", ") != "demo.example." || a.TTL != 900 {
+		t.Errorf("Expected demo.example. with TTL 900, got %v with TTL %d (%v)", a.Values, a.TTL, a.Err)
+	}
+}
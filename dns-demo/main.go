@@ -0,0 +1,65 @@
+// Command dns-demo resolves the records of a name against several nameservers at
+// the same time, and reports the ones they answer differently, with their TTLs,
+// e.g. to follow the propagation of a change
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+const usage = `Usage: %s [flags] name
+
+Asks every nameserver for the records of the name, and exits with 1 when they
+answer differently, or when a nameserver doesn't answer.
+
+`
+
+func main() {
+	nameservers := flag.String("nameservers", "8.8.8.8,1.1.1.1,9.9.9.9", "comma separated nameservers to ask, host[:port]")
+	types := flag.String("types", "A,AAAA,CNAME,TXT,MX", "comma separated record types: A, AAAA, CNAME, TXT or MX")
+	timeout := flag.Duration("timeout", 5*time.Second, "timeout of a lookup, with its retries")
+	tcp := flag.Bool("tcp", false, "ask over TCP instead of UDP")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var servers []string
+	for _, nameserver := range strings.Split(*nameservers, ",") {
+		servers = append(servers, withPort(strings.TrimSpace(nameserver)))
+	}
+	var recordTypes []string
+	for _, recordType := range strings.Split(*types, ",") {
+		recordType = strings.ToUpper(strings.TrimSpace(recordType))
+		if _, ok := supportedTypes[recordType]; !ok {
+			fmt.Printf("Error: unsupported record type %s\n", recordType)
+			os.Exit(2)
+		}
+		recordTypes = append(recordTypes, recordType)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	answers := check(ctx, flag.Arg(0), servers, recordTypes, *timeout, *tcp)
+	problems, err := writeReport(os.Stdout, recordTypes, answers)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+	if problems {
+		stop()
+		os.Exit(1)
+	}
+}
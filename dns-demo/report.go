@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// writeReport writes the answers of every nameserver in aligned columns, then the
+// record types they answer differently, and returns whether there were any
+// inconsistencies or errors
+func writeReport(w io.Writer, types []string, answers map[string][]answer) (bool, error) {
+	problems := false
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tNAMESERVER\tTTL\tANSWER")
+	for _, recordType := range types {
+		for _, a := range answers[recordType] {
+			ttl, values := "-", "(no records)"
+			switch {
+			case a.Err != nil:
+				values = fmt.Sprintf("error: %s", a.Err)
+				problems = true
+			case len(a.Values) > 0:
+				ttl = fmt.Sprint(a.TTL)
+				values = strings.Join(a.Values, ", ")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", recordType, a.Nameserver, ttl, values)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return problems, err
+	}
+
+	for _, recordType := range types {
+		found := compare(recordType, answers[recordType])
+		if found == nil {
+			continue
+		}
+		problems = true
+		fmt.Fprintf(w, "\n%s: %d different answers, caches can keep an old one for up to %ds\n", recordType, len(found.Answers), found.MaxTTL)
+		values := make([]string, 0, len(found.Answers))
+		for value := range found.Answers {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		for _, value := range values {
+			label := value
+			if label == "" {
+				label = "(no records)"
+			}
+			fmt.Fprintf(w, "  %s: %s\n", label, strings.Join(found.Answers[value], ", "))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	answers := map[string][]answer{
+		"A": {
+			{Nameserver: "192.0.2.53:53", Type: "A", Values: []string{"192.0.2.10"}, TTL: 300},
+			{Nameserver: "198.51.100.53:53", Type: "A", Values: []string{"192.0.2.1"}, TTL: 3600},
+			{Nameserver: "203.0.113.53:53", Type: "A"},
+		},
+		"MX": {
+			{Nameserver: "192.0.2.53:53", Type: "MX", Values: []string{"10 mail.example."}, TTL: 60},
+			{Nameserver: "198.51.100.53:53", Type: "MX", Values: []string{"10 mail.example."}, TTL: 45},
+			{Nameserver: "203.0.113.53:53", Type: "MX", Err: errors.New("i/o timeout")},
+		},
+	}
+	var out bytes.Buffer
+	problems, err := writeReport(&out, []string{"A", "MX"}, answers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `TYPE  NAMESERVER        TTL   ANSWER
+A     192.0.2.53:53     300   192.0.2.10
+A     198.51.100.53:53  3600  192.0.2.1
+A     203.0.113.53:53   -     (no records)
+MX    192.0.2.53:53     60    10 mail.example.
+MX    198.51.100.53:53  45    10 mail.example.
+MX    203.0.113.53:53   -     error: i/o timeout
+
+A: 3 different answers, caches can keep an old one for up to 3600s
+  (no records): 203.0.113.53:53
+  192.0.2.1: 198.51.100.53:53
+  192.0.2.10: 192.0.2.53:53
+`
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+	if !problems {
+		t.Errorf("Expected problems for the inconsistent A records and the MX error")
+	}
+
+	out.Reset()
+	problems, err = writeReport(&out, []string{"A"}, map[string][]answer{"A": answers["A"][:1]})
+	if err != nil || problems {
+		t.Errorf("Expected no problems for a single answer, got %t (%v)", problems, err)
+	}
+}
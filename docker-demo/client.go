@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiVersion is the version of the Engine API the client is written for, Docker
+// 24 and later support it
+const apiVersion = "v1.43"
+
+// defaultHost is the socket of the daemon when DOCKER_HOST isn't set
+const defaultHost = "unix:///var/run/docker.sock"
+
+// client sends the requests of the Docker Engine API to the daemon, over its Unix
+// socket or TCP
+type client struct {
+	http *http.Client
+	// baseURL is the url of the versioned API, like http://docker/v1.43
+	baseURL string
+}
+
+// errNotFound is returned for a 404, like for an image that isn't pulled yet
+var errNotFound = errors.New("not found")
+
+// newClient returns a client of the daemon at host, like unix:///var/run/docker.sock
+// or tcp://127.0.0.1:2375
+func newClient(host string) (client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return client{}, fmt.Errorf("invalid host %q: %s", host, err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	switch u.Scheme {
+	case "unix":
+		// every connection goes to the socket, the host of the urls is only sent
+		// in the Host header
+		dialer := &net.Dialer{Timeout: 30 * time.Second}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", u.Path)
+		}
+		return client{http: &http.Client{Transport: transport}, baseURL: "http://docker/" + apiVersion}, nil
+	case "tcp", "http":
+		return client{http: &http.Client{Transport: transport}, baseURL: "http://" + u.Host + "/" + apiVersion}, nil
+	default:
+		return client{}, fmt.Errorf("invalid host %q: unix:// or tcp:// expected", host)
+	}
+}
+
+// do sends a request with body as JSON when it isn't nil, and returns the response
+// when its status is 2xx or 304, or the message of the error of the daemon otherwise.
+// The caller closes the body of the response.
+func (c client) do(ctx context.Context, method, path string, query url.Values, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	rawURL := c.baseURL + path
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 300 || res.StatusCode == http.StatusNotModified {
+		return res, nil
+	}
+	defer res.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	message := strings.TrimSpace(string(b))
+	if json.Unmarshal(b, &apiErr) == nil && apiErr.Message != "" {
+		message = apiErr.Message
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", message, errNotFound)
+	}
+	return nil, fmt.Errorf("%s %s: %s: %s", method, path, res.Status, message)
+}
+
+// call sends a request, and decodes the JSON of the response in v when it isn't nil
+func (c client) call(ctx context.Context, method, path string, query url.Values, body, v any) error {
+	res, err := c.do(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if v == nil {
+		_, err = io.Copy(io.Discard, res.Body)
+		return err
+	}
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response of %s %s: %s", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Container is an item of the container list, GET /containers/json
+type Container struct {
+	ID      string   `json:"Id"`
+	Names   []string `json:"Names"`
+	Image   string   `json:"Image"`
+	Created int64    `json:"Created"`
+	State   string   `json:"State"`
+	Status  string   `json:"Status"`
+	Ports   []Port   `json:"Ports"`
+}
+
+// Port is a port of a container, published on the host when PublicPort isn't 0
+type Port struct {
+	IP          string `json:"IP,omitempty"`
+	PrivatePort int    `json:"PrivatePort"`
+	PublicPort  int    `json:"PublicPort,omitempty"`
+	Type        string `json:"Type"`
+}
+
+// Name returns the first name of the container, without the leading /
+func (c Container) Name() string {
+	if len(c.Names) == 0 {
+		return shortID(c.ID)
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// listContainers lists the running containers, or all of them
+func listContainers(ctx context.Context, c client, all bool) ([]Container, error) {
+	var containers []Container
+	err := c.call(ctx, http.MethodGet, "/containers/json", url.Values{"all": {strconv.FormatBool(all)}}, nil, &containers)
+	return containers, err
+}
+
+// pullMessage is a message of the progress of a pull, like
+// {"status":"Downloading","progressDetail":{"current":1,"total":2},"id":"a1b2"}
+type pullMessage struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress string `json:"progress"`
+	Error    string `json:"error"`
+}
+
+// splitImage returns the repository and the tag of an image reference, latest
+// when it has neither a tag nor a digest, or else the API pulls every tag
+func splitImage(image string) (string, string) {
+	if strings.Contains(image, "@") {
+		return image, ""
+	}
+	// the port of a registry, like localhost:5000/test-server, isn't a tag
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// pullImage pulls image, writing its progress to w, a line per layer and step. The
+// daemon streams the progress as JSON messages until the pull is done, and a pull
+// that fails after it started still has a 200 response, with a message having the
+// error.
+func pullImage(ctx context.Context, c client, image string, w io.Writer) error {
+	repository, tag := splitImage(image)
+	query := url.Values{"fromImage": {repository}}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	res, err := c.do(ctx, http.MethodPost, "/images/create", query, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	decoder := json.NewDecoder(res.Body)
+	for {
+		var message pullMessage
+		if err := decoder.Decode(&message); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("pull %s: %s", image, err)
+		}
+		switch {
+		case message.Error != "":
+			return fmt.Errorf("pull %s: %s", image, message.Error)
+		case message.Progress != "":
+			// the progress bars of the downloads and the extractions
+		case message.ID != "":
+			fmt.Fprintf(w, "%s: %s\n", message.ID, message.Status)
+		default:
+			fmt.Fprintln(w, message.Status)
+		}
+	}
+}
+
+// runOptions configure the container of runContainer
+type runOptions struct {
+	Name  string
+	Image string
+	Env   []string
+	// Port is published on the same port of the loopback interface of the host, as
+	// with docker run -p 127.0.0.1:8080:8080
+	Port int
+}
+
+// containerConfig is the body of POST /containers/create
+type containerConfig struct {
+	Image        string              `json:"Image"`
+	Env          []string            `json:"Env,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	HostConfig   hostConfig          `json:"HostConfig"`
+}
+
+type hostConfig struct {
+	PortBindings map[string][]portBinding `json:"PortBindings,omitempty"`
+}
+
+type portBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// label marks the containers of docker-demo
+const label = "docker-demo"
+
+// runContainer creates and starts a container, pulling its image first when it isn't
+// there, like docker run, and returns its id
+func runContainer(ctx context.Context, c client, options runOptions, progress io.Writer) (string, error) {
+	config := containerConfig{
+		Image:  options.Image,
+		Env:    options.Env,
+		Labels: map[string]string{label: "true"},
+	}
+	if options.Port != 0 {
+		port := fmt.Sprintf("%d/tcp", options.Port)
+		config.ExposedPorts = map[string]struct{}{port: {}}
+		config.HostConfig.PortBindings = map[string][]portBinding{port: {{HostIP: "127.0.0.1", HostPort: strconv.Itoa(options.Port)}}}
+	}
+	query := url.Values{}
+	if options.Name != "" {
+		query.Set("name", options.Name)
+	}
+
+	var created struct {
+		ID       string   `json:"Id"`
+		Warnings []string `json:"Warnings"`
+	}
+	err := c.call(ctx, http.MethodPost, "/containers/create", query, config, &created)
+	if errors.Is(err, errNotFound) {
+		fmt.Fprintf(progress, "Unable to find image %s locally, pulling it\n", options.Image)
+		if err := pullImage(ctx, c, options.Image, progress); err != nil {
+			return "", err
+		}
+		err = c.call(ctx, http.MethodPost, "/containers/create", query, config, &created)
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, warning := range created.Warnings {
+		fmt.Fprintf(progress, "Warning: %s\n", warning)
+	}
+	// a 304 when it's already started
+	err = c.call(ctx, http.MethodPost, "/containers/"+created.ID+"/start", nil, nil, nil)
+	return created.ID, err
+}
+
+// containerState is the state of an inspected container
+type containerState struct {
+	Status   string `json:"Status"`
+	Running  bool   `json:"Running"`
+	ExitCode int    `json:"ExitCode"`
+}
+
+// inspectedContainer is the part of an inspected container, GET
+// /containers/{id}/json, the commands use
+type inspectedContainer struct {
+	ID     string         `json:"Id"`
+	Name   string         `json:"Name"`
+	State  containerState `json:"State"`
+	Config struct {
+		Tty bool `json:"Tty"`
+	} `json:"Config"`
+}
+
+// inspectContainer returns the container of a name or an id
+func inspectContainer(ctx context.Context, c client, container string) (inspectedContainer, error) {
+	var inspected inspectedContainer
+	err := c.call(ctx, http.MethodGet, "/containers/"+url.PathEscape(container)+"/json", nil, nil, &inspected)
+	return inspected, err
+}
+
+// stopContainer stops a container, with SIGTERM, then SIGKILL after timeout, like
+// docker stop. A container that's already stopped is left as it is.
+func stopContainer(ctx context.Context, c client, container string, timeout time.Duration) error {
+	query := url.Values{"t": {strconv.Itoa(int(timeout.Seconds()))}}
+	return c.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(container)+"/stop", query, nil, nil)
+}
+
+// removeContainer removes a stopped container, with its anonymous volumes
+func removeContainer(ctx context.Context, c client, container string) error {
+	return c.call(ctx, http.MethodDelete, "/containers/"+url.PathEscape(container), url.Values{"v": {"true"}}, nil, nil)
+}
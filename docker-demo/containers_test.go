@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// daemon is a fake Docker daemon, with an image that's pulled on the first pull and
+// a container created from it
+type daemon struct {
+	mu        sync.Mutex
+	pulled    bool
+	created   *containerConfig
+	name      string
+	started   bool
+	removed   bool
+	pullQuery string
+}
+
+const testID = "4f66ad9a0b2e7c3d1e5f6a7b8c9d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f7a8b9"
+
+// newDaemon serves the fake daemon on a Unix socket, and returns a client of it
+func newDaemon(t *testing.T) (*daemon, client) {
+	d := &daemon{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.43/containers/json", d.list)
+	mux.HandleFunc("POST /v1.43/images/create", d.pull)
+	mux.HandleFunc("POST /v1.43/containers/create", d.create)
+	mux.HandleFunc("POST /v1.43/containers/{id}/start", d.update(func() { d.started = true }))
+	mux.HandleFunc("POST /v1.43/containers/{id}/stop", d.update(func() { d.started = false }))
+	mux.HandleFunc("DELETE /v1.43/containers/{id}", d.update(func() { d.removed = true }))
+	mux.HandleFunc("GET /v1.43/containers/{id}/json", d.inspect)
+	mux.HandleFunc("GET /v1.43/containers/{id}/logs", d.logs)
+
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewUnstartedServer(mux)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	c, err := newClient("unix://" + socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, c
+}
+
+func (d *daemon) list(w http.ResponseWriter, r *http.Request) {
+	containers := []Container{{
+		ID:     testID,
+		Names:  []string{"/test-server"},
+		Image:  "test-server",
+		State:  "running",
+		Status: "Up 2 minutes",
+		Ports:  []Port{{IP: "127.0.0.1", PrivatePort: 8080, PublicPort: 8080, Type: "tcp"}},
+	}}
+	if r.URL.Query().Get("all") == "true" {
+		containers = append(containers, Container{ID: "0123456789abcdef", Names: []string{"/old"}, Image: "alpine", State: "exited", Status: "Exited (0) 2 days ago"})
+	}
+	json.NewEncoder(w).Encode(containers)
+}
+
+func (d *daemon) pull(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pullQuery = r.URL.RawQuery
+	if r.URL.Query().Get("fromImage") == "missing" {
+		// the errors after the start of the pull are messages of a 200
+		fmt.Fprintln(w, `{"status":"Pulling from library/missing","id":"latest"}`)
+		fmt.Fprintln(w, `{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown"}`)
+		return
+	}
+	for _, message := range []string{
+		`{"status":"Pulling from library/test-server","id":"latest"}`,
+		`{"status":"Downloading","progressDetail":{"current":1,"total":2},"progress":"[=====>     ]","id":"a1b2c3"}`,
+		`{"status":"Pull complete","progressDetail":{},"id":"a1b2c3"}`,
+		`{"status":"Status: Downloaded newer image for test-server:latest"}`,
+	} {
+		fmt.Fprintln(w, message)
+	}
+	d.pulled = true
+}
+
+func (d *daemon) create(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.pulled {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"No such image: test-server:latest"}`)
+		return
+	}
+	var config containerConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	d.created = &config
+	d.name = r.URL.Query().Get("name")
+	fmt.Fprintf(w, `{"Id":%q,"Warnings":[]}`, testID)
+}
+
+// update handles the requests of the container, changing its state
+func (d *daemon) update(change func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if id := r.PathValue("id"); d.created == nil || (id != testID && id != d.name) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"message":"No such container: %s"}`, id)
+			return
+		}
+		change()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (d *daemon) inspect(w http.ResponseWriter, r *http.Request) {
+	if id := r.PathValue("id"); id != testID && id != "test-server" {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"message":"No such container: %s"}`, id)
+		return
+	}
+	fmt.Fprintf(w, `{"Id":%q,"Name":"/test-server","State":{"Status":"running","Running":true},"Config":{"Tty":false}}`, testID)
+}
+
+func (d *daemon) logs(w http.ResponseWriter, r *http.Request) {
+	w.Write(frame(streamStdout, "Listening on :8080\n"))
+	w.Write(frame(streamStderr, "No password, the API isn't protected\n"))
+}
+
+// frame returns a frame of a multiplexed stream
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8, 8+len(payload))
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestListContainers(t *testing.T) {
+	_, c := newDaemon(t)
+	containers, err := listContainers(context.Background(), c, true)
+	if err != nil {
+		t.Fatalf("listContainers error: %s", err)
+	}
+	var out bytes.Buffer
+	if err := writeContainers(&out, containers); err != nil {
+		t.Fatal(err)
+	}
+	expected := `CONTAINER ID  IMAGE        STATUS                 PORTS                     NAME
+4f66ad9a0b2e  test-server  Up 2 minutes           127.0.0.1:8080->8080/tcp  test-server
+0123456789ab  alpine       Exited (0) 2 days ago                            old
+`
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestRunContainer(t *testing.T) {
+	d, c := newDaemon(t)
+	var progress bytes.Buffer
+	id, err := runContainer(context.Background(), c, runOptions{Name: "test-server", Image: "test-server", Env: []string{"TEST_SERVER_PASSWORD=secret"}, Port: 8080}, &progress)
+	if err != nil {
+		t.Fatalf("runContainer error: %s", err)
+	}
+
+	// the image is pulled when it isn't there, without the progress bars
+	expected := `Unable to find image test-server locally, pulling it
+latest: Pulling from library/test-server
+a1b2c3: Pull complete
+Status: Downloaded newer image for test-server:latest
+`
+	if progress.String() != expected {
+		t.Errorf("Expected the progress:\n%s\ngot:\n%s", expected, progress.String())
+	}
+	if d.pullQuery != "fromImage=test-server&tag=latest" {
+		t.Errorf("Expected the latest tag to be pulled, got %s", d.pullQuery)
+	}
+	binding := d.created.HostConfig.PortBindings["8080/tcp"]
+	if id != testID || !d.started || d.name != "test-server" || len(binding) != 1 || binding[0] != (portBinding{HostIP: "127.0.0.1", HostPort: "8080"}) || d.created.Env[0] != "TEST_SERVER_PASSWORD=secret" {
+		t.Errorf("Unexpected container %s %+v", id, d.created)
+	}
+
+	if err := stopContainer(context.Background(), c, "test-server", 0); err != nil || d.started {
+		t.Errorf("Expected the container to stop, got %v", err)
+	}
+	if err := removeContainer(context.Background(), c, "test-server"); err != nil || !d.removed {
+		t.Errorf("Expected the container to be removed, got %v", err)
+	}
+	err = removeContainer(context.Background(), c, "other")
+	if !errors.Is(err, errNotFound) || !strings.Contains(err.Error(), "No such container: other") {
+		t.Errorf("Expected the error of the daemon, got %v", err)
+	}
+}
+
+func TestPullError(t *testing.T) {
+	_, c := newDaemon(t)
+	var progress bytes.Buffer
+	err := pullImage(context.Background(), c, "missing", &progress)
+	if err == nil || err.Error() != "pull missing: manifest unknown" {
+		t.Errorf("Expected the error of the pull, got %v", err)
+	}
+}
+
+func TestSplitImage(t *testing.T) {
+	tests := map[string][2]string{
+		"test-server":                  {"test-server", "latest"},
+		"alpine:3.20":                  {"alpine", "3.20"},
+		"localhost:5000/test-server":   {"localhost:5000/test-server", "latest"},
+		"localhost:5000/test-server:1": {"localhost:5000/test-server", "1"},
+		"alpine@sha256:0123":           {"alpine@sha256:0123", ""},
+	}
+	for image, expected := range tests {
+		if repository, tag := splitImage(image); repository != expected[0] || tag != expected[1] {
+			t.Errorf("Expected %v for %s, got %s %s", expected, image, repository, tag)
+		}
+	}
+}
+
+func TestStreamLogs(t *testing.T) {
+	_, c := newDaemon(t)
+	var stdout, stderr bytes.Buffer
+	if err := streamLogs(context.Background(), c, "test-server", false, "all", &stdout, &stderr); err != nil {
+		t.Fatalf("streamLogs error: %s", err)
+	}
+	if stdout.String() != "Listening on :8080\n" || stderr.String() != "No password, the API isn't protected\n" {
+		t.Errorf("Unexpected logs %q %q", stdout.String(), stderr.String())
+	}
+
+	// a truncated frame
+	err := demux(bytes.NewReader(frame(streamStdout, "cut")[:9]), &stdout, &stderr)
+	if err == nil {
+		t.Error("Expected an error for a truncated frame")
+	}
+}
@@ -0,0 +1,3 @@
+module docker-demo
+
+go 1.24.2
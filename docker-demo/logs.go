@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Streams of the frames of the logs
+const (
+	streamStdout = 1
+	streamStderr = 2
+)
+
+// streamLogs writes the logs of a container to stdout and stderr, the last tail
+// lines ("all" for all of them), then the new ones until the container stops or the
+// context is done when follow is set
+func streamLogs(ctx context.Context, c client, container string, follow bool, tail string, stdout, stderr io.Writer) error {
+	// the logs of a container with a TTY aren't multiplexed
+	inspected, err := inspectContainer(ctx, c, container)
+	if err != nil {
+		return err
+	}
+	query := url.Values{
+		"stdout": {"true"},
+		"stderr": {"true"},
+		"follow": {fmt.Sprint(follow)},
+		"tail":   {tail},
+	}
+	res, err := c.do(ctx, http.MethodGet, "/containers/"+inspected.ID+"/logs", query, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if inspected.Config.Tty {
+		_, err = io.Copy(stdout, res.Body)
+	} else {
+		err = demux(res.Body, stdout, stderr)
+	}
+	if ctx.Err() != nil {
+		// Ctrl-C stops following the logs
+		return nil
+	}
+	return err
+}
+
+// demux copies the frames of a multiplexed stream to stdout and stderr. A frame has
+// an 8 bytes header, the stream (1 for stdout, 2 for stderr), 3 zero bytes and the
+// size of the payload as a big endian uint32, then the payload.
+func demux(r io.Reader, stdout, stderr io.Writer) error {
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read frame header: %w", err)
+		}
+		w := stdout
+		switch header[0] {
+		case streamStdout:
+		case streamStderr:
+			w = stderr
+		default:
+			return fmt.Errorf("invalid stream %d", header[0])
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return fmt.Errorf("read frame: %w", err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Command docker-demo manages containers with the Docker Engine API, over the Unix
+// socket of the daemon: it lists them, pulls images, runs the test-server, streams
+// its logs, then stops and removes it
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage: %s [flags] command [args]
+
+Commands:
+  ps                    list the containers
+  pull image            pull an image
+  run                   run the -image of the test-server, publishing -port
+  logs container        write the logs of a container, following them with -follow
+  rm container          stop and remove a container
+  demo                  run the test-server, wait until it's ready, follow its logs
+                        for -duration, then stop and remove it
+
+The daemon is DOCKER_HOST, unix:///var/run/docker.sock by default.
+
+`
+
+// envFlags are the repeated -env flags
+type envFlags []string
+
+func (e *envFlags) String() string {
+	return strings.Join(*e, ",")
+}
+
+func (e *envFlags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("%q isn't KEY=VALUE", value)
+	}
+	*e = append(*e, value)
+	return nil
+}
+
+func main() {
+	var env envFlags
+	host := flag.String("host", os.Getenv("DOCKER_HOST"), "daemon socket, unix:///path or tcp://host:port (default DOCKER_HOST, or "+defaultHost+")")
+	all := flag.Bool("all", false, "ps lists the stopped containers too")
+	image := flag.String("image", "test-server", "image of the test-server, built with docker build -f test-server/Dockerfile -t test-server .")
+	name := flag.String("name", "test-server", "name of the container of the test-server")
+	port := flag.Int("port", 8080, "port of the test-server, published on 127.0.0.1")
+	flag.Var(&env, "env", "KEY=VALUE environment variable of the test-server, e.g. TEST_SERVER_PASSWORD=secret, repeatable")
+	follow := flag.Bool("follow", false, "logs follows the logs until the container stops or Ctrl-C")
+	tail := flag.String("tail", "all", "logs writes this many last lines")
+	stopTimeout := flag.Duration("stop-timeout", 10*time.Second, "wait this long after SIGTERM before killing a container")
+	duration := flag.Duration("duration", 5*time.Second, "demo follows the logs this long")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *host == "" {
+		*host = defaultHost
+	}
+	c, err := newClient(*host)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	options := runOptions{Name: *name, Image: *image, Env: env, Port: *port}
+	switch command, args := flag.Arg(0), flag.Args()[1:]; {
+	case command == "ps" && len(args) == 0:
+		var containers []Container
+		containers, err = listContainers(ctx, c, *all)
+		if err == nil {
+			err = writeContainers(os.Stdout, containers)
+		}
+	case command == "pull" && len(args) == 1:
+		err = pullImage(ctx, c, args[0], os.Stdout)
+	case command == "run" && len(args) == 0:
+		var id string
+		id, err = runContainer(ctx, c, options, os.Stdout)
+		if err == nil {
+			fmt.Printf("Started %s on http://127.0.0.1:%d\n", shortID(id), *port)
+		}
+	case command == "logs" && len(args) == 1:
+		err = streamLogs(ctx, c, args[0], *follow, *tail, os.Stdout, os.Stderr)
+	case command == "rm" && len(args) == 1:
+		err = stopContainer(ctx, c, args[0], *stopTimeout)
+		if err == nil {
+			err = removeContainer(ctx, c, args[0])
+		}
+	case command == "demo" && len(args) == 0:
+		err = demo(ctx, c, options, *duration, *stopTimeout)
+	default:
+		flag.Usage()
+		stop()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// demo runs the test-server, follows its logs while it starts and for duration once
+// it's ready, then stops and removes it, even after Ctrl-C
+func demo(ctx context.Context, c client, options runOptions, duration, stopTimeout time.Duration) error {
+	id, err := runContainer(ctx, c, options, os.Stdout)
+	if id == "" {
+		return err
+	}
+	// the container is removed when it was created, whatever happens next
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), stopTimeout+10*time.Second)
+		defer cancel()
+		if err := removeContainer(cleanupCtx, c, id); err != nil {
+			fmt.Printf("Error: %s\n", err)
+			return
+		}
+		fmt.Printf("Removed %s\n", shortID(id))
+	}()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Started %s\n", shortID(id))
+
+	// the logs are followed until the container stops
+	logs := make(chan error, 1)
+	go func() {
+		logs <- streamLogs(context.WithoutCancel(ctx), c, id, true, "all", os.Stdout, os.Stderr)
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d", options.Port)
+	err = waitReady(ctx, url+"/readyz", 30*time.Second)
+	if ctx.Err() != nil {
+		// Ctrl-C stops the demo early
+		err = nil
+	} else if err == nil {
+		fmt.Printf("Ready on %s\n", url)
+		timer := time.NewTimer(duration)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), stopTimeout+10*time.Second)
+	defer cancel()
+	fmt.Printf("Stopping %s\n", shortID(id))
+	if stopErr := stopContainer(stopCtx, c, id, stopTimeout); stopErr != nil {
+		return errors.Join(err, stopErr)
+	}
+	return errors.Join(err, <-logs)
+}
+
+// waitReady gets url until it replies with a 200, within timeout
+func waitReady(ctx context.Context, url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err == nil {
+			res.Body.Close()
+			if res.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s not ready within %s", url, timeout)
+		case <-ticker.C:
+		}
+	}
+}
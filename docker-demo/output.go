@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// shortID returns the first 12 characters of an id, like the docker CLI
+func shortID(id string) string {
+	return id[:min(len(id), 12)]
+}
+
+// writeContainers writes a line per container in aligned columns, like docker ps
+func writeContainers(w io.Writer, containers []Container) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTAINER ID\tIMAGE\tSTATUS\tPORTS\tNAME")
+	for _, c := range containers {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", shortID(c.ID), c.Image, c.Status, formatPorts(c.Ports), c.Name())
+	}
+	return tw.Flush()
+}
+
+// formatPorts returns the ports like docker ps, 127.0.0.1:8080->8080/tcp for a
+// published one, 8080/tcp otherwise
+func formatPorts(ports []Port) string {
+	formatted := make([]string, 0, len(ports))
+	for _, p := range ports {
+		port := fmt.Sprintf("%d/%s", p.PrivatePort, p.Type)
+		if p.PublicPort != 0 {
+			port = fmt.Sprintf("%s:%d->%s", p.IP, p.PublicPort, port)
+		}
+		formatted = append(formatted, port)
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// allowList is the -allow flag: the hosts the clients can reach through the proxy
+type allowList struct {
+	// hosts are exact names, or *.example.com for the subdomains of example.com
+	hosts    []string
+	networks []*net.IPNet
+}
+
+// parseAllowList parses comma separated hosts, *.domain wildcards, IPs and CIDRs
+func parseAllowList(value string) (allowList, error) {
+	var list allowList
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return allowList{}, fmt.Errorf("invalid network %q: %s", entry, err)
+			}
+			list.networks = append(list.networks, network)
+		case strings.Contains(entry, "*") && (!strings.HasPrefix(entry, "*.") || strings.Count(entry, "*") > 1):
+			return allowList{}, fmt.Errorf("invalid wildcard %q, only *.domain is supported", entry)
+		default:
+			list.hosts = append(list.hosts, entry)
+		}
+	}
+	return list, nil
+}
+
+// allows returns whether the host, with or without a port, is in the list. An
+// empty list allows every host.
+func (a allowList) allows(hostPort string) bool {
+	if len(a.hosts) == 0 && len(a.networks) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		host = hostPort
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range a.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	for _, allowed := range a.hosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,3 @@
+module forward-proxy
+
+go 1.24.2
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// proxy forwards the requests of HTTP clients with an absolute url, and tunnels
+// the CONNECT requests, e.g. for HTTPS
+type proxy struct {
+	// credentials is user:password for basic auth, empty to allow every client
+	credentials string
+	allow       allowList
+	dialer      *net.Dialer
+	forward     *httputil.ReverseProxy
+	logger      *log.Logger
+}
+
+func newProxy(credentials string, allow allowList, logger *log.Logger) *proxy {
+	p := &proxy{
+		credentials: credentials,
+		allow:       allow,
+		dialer:      &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		logger:      logger,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// the proxy connects to the servers itself, not through another proxy
+	transport.Proxy = nil
+	transport.DialContext = p.dialer.DialContext
+	p.forward = &httputil.ReverseProxy{
+		// the url is the absolute one of the client, the hop-by-hop headers like
+		// Proxy-Authorization are removed by the ReverseProxy
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL = r.In.URL
+			r.Out.Host = r.In.Host
+		},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			p.logger.Printf("%s %s: %s", r.Method, r.URL, err)
+			http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		},
+	}
+	return p
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		p.logger.Printf("%s %s %d %s", r.Method, r.Host, recorder.status, time.Since(start).Round(time.Millisecond))
+	}()
+
+	if !p.authorized(r) {
+		recorder.Header().Set("Proxy-Authenticate", `Basic realm="forward-proxy"`)
+		http.Error(recorder, "Proxy Authentication Required", http.StatusProxyAuthRequired)
+		return
+	}
+	if r.Method != http.MethodConnect && !r.URL.IsAbs() {
+		http.Error(recorder, "not a proxy request, the url must be absolute", http.StatusBadRequest)
+		return
+	}
+	if !p.allow.allows(r.Host) {
+		http.Error(recorder, "host not allowed", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodConnect {
+		p.tunnel(recorder, r)
+		return
+	}
+	p.forward.ServeHTTP(recorder, r)
+}
+
+// authorized checks the basic auth credentials of the Proxy-Authorization header
+func (p *proxy) authorized(r *http.Request) bool {
+	if p.credentials == "" {
+		return true
+	}
+	encoded, ok := strings.CutPrefix(r.Header.Get("Proxy-Authorization"), "Basic ")
+	if !ok {
+		return false
+	}
+	credentials, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(credentials, []byte(p.credentials)) == 1
+}
+
+// tunnel connects to the host of the CONNECT request, answers 200 to the client,
+// and copies the bytes both ways until one side closes. The proxy doesn't see
+// what goes through, e.g. the TLS connection of the client to the server.
+func (p *proxy) tunnel(w *statusRecorder, r *http.Request) {
+	server, err := p.dialer.DialContext(r.Context(), "tcp", r.Host)
+	if err != nil {
+		p.logger.Printf("CONNECT %s: %s", r.Host, err)
+		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		return
+	}
+	defer server.Close()
+
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		p.logger.Printf("CONNECT %s: hijack error: %s", r.Host, err)
+		return
+	}
+	defer client.Close()
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		// the client may have sent the start of the TLS handshake with the CONNECT
+		io.Copy(server, buffered)
+		closeWrite(server)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(client, server)
+		closeWrite(client)
+	}()
+	wg.Wait()
+}
+
+// closeWrite tells the other side nothing more is sent, while still reading
+func closeWrite(conn net.Conn) {
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.CloseWrite()
+		return
+	}
+	conn.Close()
+}
+
+// statusRecorder keeps the status of the response for the log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newClient returns a client going through the proxy, trusting the certificate of
+// the TLS test server
+func newClient(t *testing.T, proxyURL string, tlsServer *httptest.Server) *http.Client {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := tlsServer.Client().Transport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(parsed)
+	return &http.Client{Transport: transport}
+}
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	res, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Get %s error: %s", url, err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("ReadAll error: %s", err)
+	}
+	return res.StatusCode, string(body)
+}
+
+// syncBuffer is a buffer the proxy logs to while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestProxy(t *testing.T) {
+	backend := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "" {
+			t.Errorf("Expected the proxy credentials not to be forwarded")
+		}
+		io.WriteString(w, "hello from "+r.URL.Path)
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(backend))
+	defer httpServer.Close()
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(backend))
+	defer tlsServer.Close()
+
+	var logs syncBuffer
+	allow, err := parseAllowList("127.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyServer := httptest.NewServer(newProxy("user:secret", allow, log.New(&logs, "", 0)))
+	defer proxyServer.Close()
+	proxyHost := strings.TrimPrefix(proxyServer.URL, "http://")
+	client := newClient(t, "http://user:secret@"+proxyHost, tlsServer)
+
+	if status, body := get(t, client, httpServer.URL+"/http"); status != http.StatusOK || body != "hello from /http" {
+		t.Errorf("HTTP: expected 200 hello from /http, got %d %s", status, body)
+	}
+	// https goes through a CONNECT tunnel, the TLS connection is the one of the client
+	if status, body := get(t, client, tlsServer.URL+"/https"); status != http.StatusOK || body != "hello from /https" {
+		t.Errorf("HTTPS: expected 200 hello from /https, got %d %s", status, body)
+	}
+	// the tunnel is logged when it's closed
+	client.CloseIdleConnections()
+	connect := "CONNECT " + strings.TrimPrefix(tlsServer.URL, "https://") + " 200"
+	for i := 0; i < 100 && !strings.Contains(logs.String(), connect); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(logs.String(), connect) {
+		t.Errorf("Expected the CONNECT in the log, got:\n%s", logs.String())
+	}
+
+	wrongPassword := newClient(t, "http://user:wrong@"+proxyHost, tlsServer)
+	res, err := wrongPassword.Get(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusProxyAuthRequired || res.Header.Get("Proxy-Authenticate") == "" {
+		t.Errorf("Expected 407 with Proxy-Authenticate, got %d", res.StatusCode)
+	}
+	// the client reports a failed CONNECT as an error
+	if _, err := wrongPassword.Get(tlsServer.URL); err == nil || !strings.Contains(err.Error(), "Proxy Authentication Required") {
+		t.Errorf("Expected the CONNECT to fail with 407, got %v", err)
+	}
+
+	if status, _ := get(t, client, "http://localhost:1/"); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a host not in the allow-list, got %d", status)
+	}
+	if status, _ := get(t, client, "http://127.0.0.1:1/"); status != http.StatusBadGateway {
+		t.Errorf("Expected 502 for a server that's down, got %d", status)
+	}
+
+	// a request for the proxy itself, not a proxy request
+	req, err := http.NewRequest(http.MethodGet, proxyServer.URL+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("user", "secret")
+	req.Header.Set("Proxy-Authorization", req.Header.Get("Authorization"))
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a request that isn't a proxy request, got %d", res.StatusCode)
+	}
+}
+
+func TestAllowList(t *testing.T) {
+	allow, err := parseAllowList("localhost, *.example.com,10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		host    string
+		allowed bool
+	}{
+		{host: "localhost:8080", allowed: true},
+		{host: "LOCALHOST", allowed: true},
+		{host: "api.example.com:443", allowed: true},
+		{host: "a.b.example.com", allowed: true},
+		{host: "example.com", allowed: false},
+		{host: "evil-example.com", allowed: false},
+		{host: "10.1.2.3:22", allowed: true},
+		{host: "192.0.2.1", allowed: true},
+		{host: "192.0.2.2", allowed: false},
+		{host: "[::1]:443", allowed: false},
+	}
+	for _, test := range tests {
+		if allowed := allow.allows(test.host); allowed != test.allowed {
+			t.Errorf("%s: expected allowed %t, got %t", test.host, test.allowed, allowed)
+		}
+	}
+
+	if empty, _ := parseAllowList(""); !empty.allows("anything:443") {
+		t.Errorf("Expected an empty list to allow every host")
+	}
+	for _, invalid := range []string{"10.0.0.0/99", "api.*.com", "*example.com"} {
+		if _, err := parseAllowList(invalid); err == nil {
+			t.Errorf("%s: expected an error", invalid)
+		}
+	}
+}
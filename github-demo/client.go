@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"assignment-2-rate-limiting/pkg/ratelimiter"
+)
+
+// apiVersion is the version of the REST API the client is written for
+const apiVersion = "2022-11-28"
+
+// client lists resources of the GitHub REST API, page by page
+type client struct {
+	http    *http.Client
+	baseURL string
+	token   string
+}
+
+// errNotFound is returned for a 404, like for an organization that's a user
+var errNotFound = errors.New("not found")
+
+// get sends a GET to url, relative to the base url unless it's absolute, like the
+// links of the next pages, and returns the body and the url of the next page, empty
+// on the last page
+func (c client) get(ctx context.Context, rawURL string) ([]byte, string, error) {
+	if !strings.HasPrefix(rawURL, "https://") && !strings.HasPrefix(rawURL, "http://") {
+		rawURL = strings.TrimSuffix(c.baseURL, "/") + rawURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	req.Header.Set("User-Agent", "github-demo")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("read response: %s", err)
+	}
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, "", fmt.Errorf("%s: %w", req.URL.Path, errNotFound)
+	case res.StatusCode != http.StatusOK:
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return nil, "", fmt.Errorf("%s: %s: %s", req.URL.Path, res.Status, apiErr.Message)
+		}
+		return nil, "", fmt.Errorf("%s: %s", req.URL.Path, res.Status)
+	}
+	return body, nextLink(res.Header.Get("Link")), nil
+}
+
+// nextLink returns the url of rel="next" in a Link header, like
+// <https://api.github.com/orgs/golang/repos?page=2>; rel="next", <...>; rel="last"
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(target, "<>")
+			}
+		}
+	}
+	return ""
+}
+
+// list gets the items of path and of its next pages, decoded by items, until there
+// are limit items, or all of them when limit is 0
+func list[T any](ctx context.Context, c client, path string, limit int, items func(body []byte) ([]T, error)) ([]T, error) {
+	var all []T
+	for next := path; next != ""; {
+		body, link, err := c.get(ctx, next)
+		if err != nil {
+			return all, err
+		}
+		page, err := items(body)
+		if err != nil {
+			return all, fmt.Errorf("invalid response of %s: %s", next, err)
+		}
+		all = append(all, page...)
+		if limit > 0 && len(all) >= limit {
+			return all[:limit], nil
+		}
+		next = link
+	}
+	return all, nil
+}
+
+// array decodes a page that's a JSON array
+func array[T any](body []byte) ([]T, error) {
+	var items []T
+	err := json.Unmarshal(body, &items)
+	return items, err
+}
+
+// rateLimitTransport waits for a token of the bucket before every request, and
+// waits for the reset of the rate limit of GitHub when it's exhausted: before the
+// next request when X-RateLimit-Remaining is 0, and before sending a request again
+// when it's rejected with a 403 or a 429 because of the primary or a secondary rate
+// limit
+type rateLimitTransport struct {
+	transport http.RoundTripper
+	bucket    *ratelimiter.TokenBucket
+	// maxWait is the longest wait for a reset, the request fails when the reset is
+	// later
+	maxWait time.Duration
+	logger  *log.Logger
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+
+	mu sync.Mutex
+	// resetAt is when the exhausted rate limit resets, zero when it isn't
+	resetAt time.Time
+}
+
+func newRateLimitTransport(transport http.RoundTripper, bucket *ratelimiter.TokenBucket, maxWait time.Duration, logger *log.Logger) *rateLimitTransport {
+	return &rateLimitTransport{
+		transport: transport,
+		bucket:    bucket,
+		maxWait:   maxWait,
+		logger:    logger,
+		now:       time.Now,
+		sleep:     sleep,
+	}
+}
+
+func (r *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the retries of a rejected request, a secondary rate limit can reject it again
+	for attempt := 0; ; attempt++ {
+		if err := r.waitForReset(req.Context()); err != nil {
+			return nil, err
+		}
+		if err := r.bucket.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		res, err := r.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		limited := r.update(res)
+		if !limited || attempt >= 3 {
+			return res, nil
+		}
+		// the body of a GET is empty, the request can be sent again as it is
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+}
+
+// waitForReset waits until the exhausted rate limit resets
+func (r *rateLimitTransport) waitForReset(ctx context.Context) error {
+	r.mu.Lock()
+	resetAt := r.resetAt
+	r.mu.Unlock()
+	if resetAt.IsZero() {
+		return nil
+	}
+	wait := resetAt.Sub(r.now())
+	if wait > r.maxWait {
+		return fmt.Errorf("rate limit exceeded until %s, over -max-wait", resetAt.Format(time.TimeOnly))
+	}
+	if wait > 0 {
+		r.logger.Printf("Rate limit exceeded, waiting %s for the reset", wait.Round(time.Second))
+		if err := r.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	if r.resetAt.Equal(resetAt) {
+		r.resetAt = time.Time{}
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// update records the reset of the rate limit of the headers of res when it's
+// exhausted, and returns whether res was rejected because of it
+func (r *rateLimitTransport) update(res *http.Response) bool {
+	var resetAt time.Time
+	rejected := res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusTooManyRequests
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && rejected {
+		// a secondary rate limit
+		resetAt = r.now().Add(time.Duration(seconds) * time.Second)
+	} else if res.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return false
+		}
+		// a second later, for the clock skew
+		resetAt = time.Unix(reset+1, 0)
+	} else {
+		return false
+	}
+	r.mu.Lock()
+	if resetAt.After(r.resetAt) {
+		r.resetAt = resetAt
+	}
+	r.mu.Unlock()
+	return rejected
+}
+
+// sleep waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// queryPath returns path with the query of values
+func queryPath(path string, values url.Values) string {
+	if len(values) == 0 {
+		return path
+	}
+	return path + "?" + values.Encode()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"assignment-2-rate-limiting/pkg/ratelimiter"
+)
+
+func TestNextLink(t *testing.T) {
+	tests := map[string]string{
+		`<https://api.github.com/orgs/golang/repos?page=2>; rel="next", <https://api.github.com/orgs/golang/repos?page=5>; rel="last"`:  "https://api.github.com/orgs/golang/repos?page=2",
+		`<https://api.github.com/orgs/golang/repos?page=4>; rel="prev", <https://api.github.com/orgs/golang/repos?page=1>; rel="first"`: "",
+		``:        "",
+		`garbage`: "",
+	}
+	for header, expected := range tests {
+		if next := nextLink(header); next != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, header, next)
+		}
+	}
+}
+
+// newTestClient returns a client of server, sleeping in the rate limit waits by
+// advancing now instead, recorded in slept
+func newTestClient(server *httptest.Server, slept *time.Duration) client {
+	transport := newRateLimitTransport(server.Client().Transport, ratelimiter.NewTokenBucket(0, 1), time.Hour, log.New(io.Discard, "", 0))
+	now := time.Now()
+	transport.now = func() time.Time { return now.Add(*slept) }
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		*slept += d
+		return nil
+	}
+	return client{http: &http.Client{Transport: transport}, baseURL: server.URL, token: "token"}
+}
+
+func TestListRepositories(t *testing.T) {
+	// 3 pages of 2 repositories of the user aruruka, which isn't an organization
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-GitHub-Api-Version") != apiVersion {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/users/aruruka/repos" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/users/aruruka/repos?per_page=100&page=%d>; rel="next", <%s/users/aruruka/repos?page=3>; rel="last"`, server.URL, page+1, server.URL))
+		}
+		fmt.Fprintf(w, `[{"full_name":"aruruka/repo%d"},{"full_name":"aruruka/repo%d"}]`, 2*page-1, 2*page)
+	}))
+	defer server.Close()
+	var slept time.Duration
+	c := newTestClient(server, &slept)
+
+	repositories, err := listRepositories(context.Background(), c, "aruruka", 0)
+	if err != nil {
+		t.Fatalf("listRepositories error: %s", err)
+	}
+	if len(repositories) != 6 || repositories[5].FullName != "aruruka/repo6" {
+		t.Errorf("Expected the 6 repositories of the 3 pages, got %+v", repositories)
+	}
+
+	repositories, err = listRepositories(context.Background(), c, "aruruka", 3)
+	if err != nil {
+		t.Fatalf("listRepositories error: %s", err)
+	}
+	if len(repositories) != 3 || repositories[2].FullName != "aruruka/repo3" {
+		t.Errorf("Expected the first 3 repositories, got %+v", repositories)
+	}
+}
+
+func TestListIssues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/aruruka/notes/issues":
+			fmt.Fprint(w, `[{"number":1,"title":"an issue"},{"number":2,"title":"a pull request","pull_request":{}},{"number":3,"title":"another issue"}]`)
+		case "/search/issues":
+			if q := r.URL.Query().Get("q"); q != "org:aruruka is:issue state:open" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				fmt.Fprintf(w, `{"message":"unexpected query %s"}`, q)
+				return
+			}
+			fmt.Fprint(w, `{"total_count":1,"items":[{"number":7,"repository_url":"https://api.github.com/repos/aruruka/notes"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	var slept time.Duration
+	c := newTestClient(server, &slept)
+
+	issues, err := listIssues(context.Background(), c, "aruruka/notes", "open", 0)
+	if err != nil {
+		t.Fatalf("listIssues error: %s", err)
+	}
+	if len(issues) != 2 || issues[1].Number != 3 {
+		t.Errorf("Expected the issues without the pull request, got %+v", issues)
+	}
+
+	issues, err = listIssues(context.Background(), c, "aruruka", "open", 0)
+	if err != nil {
+		t.Fatalf("listIssues error: %s", err)
+	}
+	if len(issues) != 1 || issues[0].Repository() != "aruruka/notes" {
+		t.Errorf("Expected the issue of the search, got %+v", issues)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			// the last request of the primary rate limit
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+			fmt.Fprint(w, `[]`)
+		case 2:
+			// a secondary rate limit
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit"}`)
+		default:
+			w.Header().Set("X-RateLimit-Remaining", "4999")
+			fmt.Fprint(w, `[{"full_name":"aruruka/notes"}]`)
+		}
+	}))
+	defer server.Close()
+	var slept time.Duration
+	c := newTestClient(server, &slept)
+
+	if _, err := listRepositories(context.Background(), c, "aruruka", 0); err != nil {
+		t.Fatalf("First list error: %s", err)
+	}
+	if slept != 0 {
+		t.Errorf("Expected no wait before the limit is exhausted, slept %s", slept)
+	}
+	repositories, err := listRepositories(context.Background(), c, "aruruka", 0)
+	if err != nil {
+		t.Fatalf("Second list error: %s", err)
+	}
+	if len(repositories) != 1 || requests.Load() != 3 {
+		t.Errorf("Expected the repository after a retry, got %+v after %d requests", repositories, requests.Load())
+	}
+	// about a minute for the reset, and 30s for the secondary rate limit
+	if slept < 80*time.Second || slept > 95*time.Second {
+		t.Errorf("Expected to wait for the reset and the Retry-After, slept %s", slept)
+	}
+}
+
+func TestRateLimitMaxWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(2*time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"API rate limit exceeded"}`)
+	}))
+	defer server.Close()
+	var slept time.Duration
+	c := newTestClient(server, &slept)
+
+	if _, err := listRepositories(context.Background(), c, "aruruka", 0); err == nil {
+		t.Error("Expected an error for a reset after -max-wait")
+	}
+	if slept != 0 {
+		t.Errorf("Expected no wait, slept %s", slept)
+	}
+}
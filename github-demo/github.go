@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// perPage is the largest page of the REST API
+const perPage = 100
+
+// Repository is a repository of the REST API
+type Repository struct {
+	FullName        string    `json:"full_name"`
+	Description     string    `json:"description"`
+	Language        string    `json:"language"`
+	StargazersCount int       `json:"stargazers_count"`
+	ForksCount      int       `json:"forks_count"`
+	OpenIssuesCount int       `json:"open_issues_count"`
+	Archived        bool      `json:"archived"`
+	Private         bool      `json:"private"`
+	HTMLURL         string    `json:"html_url"`
+	PushedAt        time.Time `json:"pushed_at"`
+}
+
+// Issue is an issue of the REST API
+type Issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	User   struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Comments  int       `json:"comments"`
+	HTMLURL   string    `json:"html_url"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// PullRequest is set for the pull requests, which the API lists as issues
+	PullRequest *json.RawMessage `json:"pull_request,omitempty"`
+	// RepositoryURL is the url of the repository in the API
+	RepositoryURL string `json:"repository_url"`
+}
+
+// Repository returns the owner/name of the repository of the issue
+func (i Issue) Repository() string {
+	_, repository, _ := strings.Cut(i.RepositoryURL, "/repos/")
+	return repository
+}
+
+// listRepositories lists the repositories of the organization org, or of the user
+// org when there's no such organization
+func listRepositories(ctx context.Context, c client, org string, limit int) ([]Repository, error) {
+	query := url.Values{"per_page": {strconv.Itoa(perPage)}, "sort": {"pushed"}}
+	repositories, err := list(ctx, c, queryPath("/orgs/"+url.PathEscape(org)+"/repos", query), limit, array[Repository])
+	if errors.Is(err, errNotFound) {
+		return list(ctx, c, queryPath("/users/"+url.PathEscape(org)+"/repos", query), limit, array[Repository])
+	}
+	return repositories, err
+}
+
+// listIssues lists the issues, without the pull requests, of a repository
+// owner/name, or of all the repositories of an organization with the search API
+func listIssues(ctx context.Context, c client, target, state string, limit int) ([]Issue, error) {
+	if owner, name, ok := strings.Cut(target, "/"); ok {
+		query := url.Values{"per_page": {strconv.Itoa(perPage)}, "state": {state}}
+		path := queryPath("/repos/"+url.PathEscape(owner)+"/"+url.PathEscape(name)+"/issues", query)
+		return list(ctx, c, path, limit, func(body []byte) ([]Issue, error) {
+			page, err := array[Issue](body)
+			return withoutPullRequests(page), err
+		})
+	}
+
+	q := "org:" + target + " is:issue"
+	if state != "all" {
+		q += " state:" + state
+	}
+	query := url.Values{"per_page": {strconv.Itoa(perPage)}, "q": {q}, "sort": {"updated"}}
+	return list(ctx, c, queryPath("/search/issues", query), limit, func(body []byte) ([]Issue, error) {
+		var result struct {
+			Items []Issue `json:"items"`
+		}
+		err := json.Unmarshal(body, &result)
+		return result.Items, err
+	})
+}
+
+func withoutPullRequests(issues []Issue) []Issue {
+	filtered := issues[:0]
+	for _, issue := range issues {
+		if issue.PullRequest == nil {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,118 @@
+// Command github-demo lists the repositories and the issues of a GitHub
+// organization, page by page, within the rate limits of the API
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"assignment-2-rate-limiting/pkg/ratelimiter"
+	"secrets"
+)
+
+const usage = `Usage: %s [flags] command [args]
+
+Commands:
+  repos org                    list the repositories of an organization, or of a user
+  issues owner/repo|org        list the issues of a repository, or of an organization
+
+The token is GITHUB_TOKEN, or the token key in Vault or the token file with
+SECRETS_BACKEND=vault or file. Without it, the API allows 60 requests per hour.
+
+`
+
+func main() {
+	apiURL := flag.String("api-url", "https://api.github.com", "url of the API, e.g. https://github.example.com/api/v3 for GitHub Enterprise")
+	format := flag.String("format", formatTable, "output format: table or json")
+	limit := flag.Int("limit", 0, "list at most this many items, 0 for all of them")
+	state := flag.String("state", "open", "state of the issues: open, closed or all")
+	rate := flag.Float64("rate", 10, "requests per second at most, 0 for no limit")
+	maxWait := flag.Duration("max-wait", 15*time.Minute, "wait this long at most for the reset of an exceeded rate limit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 || (*format != formatTable && *format != formatJSON) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch *state {
+	case "open", "closed", "all":
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Ctrl-C stops the waits for a reset
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	c, err := newClient(ctx, *apiURL, *rate, *maxWait)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+
+	switch command, arg := flag.Arg(0), flag.Arg(1); command {
+	case "repos":
+		var repositories []Repository
+		repositories, err = listRepositories(ctx, c, arg, *limit)
+		if err == nil {
+			err = write(*format, repositories, func() error {
+				return writeRepositories(os.Stdout, repositories)
+			})
+		}
+	case "issues":
+		var issues []Issue
+		issues, err = listIssues(ctx, c, arg, *state, *limit)
+		if err == nil {
+			err = write(*format, issues, func() error {
+				return writeIssues(os.Stdout, issues, !strings.Contains(arg, "/"))
+			})
+		}
+	default:
+		flag.Usage()
+		stop()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// newClient returns a client of the API at apiURL, with the token of the secrets
+func newClient(ctx context.Context, apiURL string, rate float64, maxWait time.Duration) (client, error) {
+	store, err := secrets.FromEnv("GITHUB_")
+	if err != nil {
+		return client{}, err
+	}
+	token, err := store.Get(ctx, "token")
+	if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return client{}, err
+	}
+	if token == "" {
+		log.Printf("No GITHUB_TOKEN, the API allows 60 requests per hour")
+	}
+	transport := newRateLimitTransport(http.DefaultTransport, ratelimiter.NewTokenBucket(rate, 1), maxWait, log.Default())
+	return client{http: &http.Client{Transport: transport}, baseURL: apiURL, token: token}, nil
+}
+
+// write writes v as JSON, or calls table
+func write(format string, v any, table func() error) error {
+	if format == formatJSON {
+		return writeJSON(os.Stdout, v)
+	}
+	return table()
+}
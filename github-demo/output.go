@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Formats of -format
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// maxTitle is the width of the titles in the tables, longer ones are cut
+const maxTitle = 60
+
+// writeJSON writes v as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// writeRepositories writes a line per repository in aligned columns
+func writeRepositories(w io.Writer, repositories []Repository) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tLANGUAGE\tSTARS\tFORKS\tISSUES\tPUSHED")
+	for _, r := range repositories {
+		name := r.FullName
+		if r.Archived {
+			name += " (archived)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\n", name, r.Language, r.StargazersCount, r.ForksCount, r.OpenIssuesCount, r.PushedAt.Format(time.DateOnly))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d repositories\n", len(repositories))
+	return err
+}
+
+// writeIssues writes a line per issue in aligned columns, with the repository when
+// they're of an organization
+func writeIssues(w io.Writer, issues []Issue, withRepository bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if withRepository {
+		fmt.Fprint(tw, "REPOSITORY\t")
+	}
+	fmt.Fprintln(tw, "NUMBER\tSTATE\tTITLE\tAUTHOR\tCOMMENTS\tUPDATED")
+	for _, i := range issues {
+		if withRepository {
+			fmt.Fprintf(tw, "%s\t", i.Repository())
+		}
+		fmt.Fprintf(tw, "#%d\t%s\t%s\t%s\t%d\t%s\n", i.Number, i.State, cut(i.Title, maxTitle), i.User.Login, i.Comments, i.UpdatedAt.Format(time.DateOnly))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d issues\n", len(issues))
+	return err
+}
+
+// cut cuts s to n runes, ending with ... when it's longer
+func cut(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
@@ -0,0 +1,132 @@
+// Command words-client calls the WordsService of the words-server
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	wordsv1 "grpc-demo/gen/words/v1"
+	"grpc-demo/pkg/auth"
+)
+
+const usage = `Usage: %s [flags] words [input]|occurrence|stream
+
+Commands:
+  words       add the input, when given, and print all the words
+  occurrence  print how many times every word was added
+  stream      print the words added so far, and every new word, until interrupted
+
+`
+
+func main() {
+	addr := flag.String("addr", "localhost:9090", "address of the words-server")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of the words and occurrence calls")
+	token := flag.String("token", "", "bearer token to send, e.g. the IDToken the oidc-demo appserver prints")
+	tokenFile := flag.String("token-file", "", "file with the bearer token to send, read for every call")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	options := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	switch {
+	case *tokenFile != "":
+		// the server runs without TLS, so the token is sent in plain text
+		options = append(options, grpc.WithPerRPCCredentials(auth.TokenCredentials{Token: auth.FileToken(*tokenFile), Insecure: true}))
+	case *token != "":
+		options = append(options, grpc.WithPerRPCCredentials(auth.TokenCredentials{Token: auth.StaticToken(*token), Insecure: true}))
+	}
+	conn, err := grpc.NewClient(*addr, options...)
+	if err != nil {
+		fmt.Printf("NewClient error: %s\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+	client := wordsv1.NewWordsServiceClient(conn)
+
+	switch flag.Arg(0) {
+	case "words":
+		err = getWords(ctx, client, *timeout, flag.Arg(1))
+	case "occurrence":
+		err = getOccurrence(ctx, client, *timeout)
+	case "stream":
+		err = wordStream(ctx, client)
+	default:
+		flag.Usage()
+		stop()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		conn.Close()
+		stop()
+		os.Exit(1)
+	}
+}
+
+func getWords(ctx context.Context, client wordsv1.WordsServiceClient, timeout time.Duration, input string) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	res, err := client.GetWords(ctx, &wordsv1.GetWordsRequest{Input: input})
+	if err != nil {
+		return fmt.Errorf("GetWords error: %s", err)
+	}
+	for _, word := range res.GetWords() {
+		fmt.Println(word)
+	}
+	return nil
+}
+
+func getOccurrence(ctx context.Context, client wordsv1.WordsServiceClient, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	res, err := client.GetOccurrence(ctx, &wordsv1.GetOccurrenceRequest{})
+	if err != nil {
+		return fmt.Errorf("GetOccurrence error: %s", err)
+	}
+	words := make([]string, 0, len(res.GetWords()))
+	for word := range res.GetWords() {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	for _, word := range words {
+		fmt.Printf("%s: %d\n", word, res.GetWords()[word])
+	}
+	return nil
+}
+
+// wordStream prints the words until the server ends the stream or ctx is cancelled
+func wordStream(ctx context.Context, client wordsv1.WordsServiceClient) error {
+	stream, err := client.WordStream(ctx, &wordsv1.WordStreamRequest{})
+	if err != nil {
+		return fmt.Errorf("WordStream error: %s", err)
+	}
+	for {
+		res, err := stream.Recv()
+		if errors.Is(err, io.EOF) || ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Recv error: %s", err)
+		}
+		fmt.Println(res.GetWord())
+	}
+}
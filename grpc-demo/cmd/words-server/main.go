@@ -0,0 +1,113 @@
+// Command words-server serves the WordsService over gRPC, and as JSON over HTTP
+// on the same port
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+
+	wordsv1 "grpc-demo/gen/words/v1"
+	"grpc-demo/pkg/auth"
+	"grpc-demo/pkg/gateway"
+	"grpc-demo/pkg/server"
+
+	"lifecycle"
+	"logging"
+	"words"
+)
+
+func main() {
+	listen := flag.String("listen", ":9090", "address of the gRPC and the HTTP server")
+	jwksURL := flag.String("jwks-url", "", "jwks of the oidc provider, e.g. http://localhost:8080/jwks.json of the oidc-demo, to require its tokens for every call")
+	issuer := flag.String("issuer", "", "issuer the tokens must have, any when empty")
+	audience := flag.String("audience", "", "audience the tokens must have, e.g. the client ID of the app, any when empty")
+	flag.Parse()
+
+	logger, err := logging.Setup()
+	if err != nil {
+		logger.Error("Invalid logging configuration", "error", err)
+		os.Exit(1)
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		logger.Error("Listen error", "error", err)
+		os.Exit(1)
+	}
+
+	store := words.NewStore()
+	var options []grpc.ServerOption
+	if *jwksURL != "" {
+		verifier := &auth.Verifier{JWKS: auth.NewJWKS(*jwksURL), Issuer: *issuer, Audience: *audience}
+		options = append(options,
+			grpc.UnaryInterceptor(verifier.UnaryServerInterceptor()),
+			grpc.StreamInterceptor(verifier.StreamServerInterceptor()),
+		)
+	}
+	grpcServer := grpc.NewServer(options...)
+	wordsv1.RegisterWordsServiceServer(grpcServer, server.New(store))
+	// reflection lets grpcurl list the services without the proto files
+	reflection.Register(grpcServer)
+
+	// the gateway calls the gRPC server through the port, so the interceptors
+	// check the HTTP requests too
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", listener.Addr().(*net.TCPAddr).Port),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		logger.Error("NewClient error", "error", err)
+		os.Exit(1)
+	}
+	httpServer := &http.Server{
+		Handler:           logging.Middleware(gateway.Handler(wordsv1.NewWordsServiceClient(conn))),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// gRPC clients wait for the settings of the server before they send the
+	// headers, the content-type tells the gRPC calls apart from HTTP/1 requests
+	mux := cmux.New(listener)
+	grpcListener := mux.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"))
+	httpListener := mux.Match(cmux.Any())
+
+	var group lifecycle.Group
+	group.Add(lifecycle.Component{
+		Name: "server",
+		Run: func(ctx context.Context) error {
+			go grpcServer.Serve(grpcListener)
+			go httpServer.Serve(httpListener)
+			logger.Info("Serving the words", "listen", *listen)
+			if err := mux.Serve(); !errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			// streams never end by themselves, end them so the shutdown doesn't wait for them
+			store.Close()
+			// closing the HTTP listener closes the port, for the gRPC calls too
+			err := httpServer.Shutdown(ctx)
+			// the gateway connects again after GracefulStop, close it first
+			conn.Close()
+			grpcServer.GracefulStop()
+			return err
+		},
+		StopTimeout: 5 * time.Second,
+	})
+	ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+	if err := group.Run(ctx); err != nil {
+		logger.Error("Server stopped with errors", "error", err)
+		stop()
+		os.Exit(1)
+	}
+}
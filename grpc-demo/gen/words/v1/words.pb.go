@@ -0,0 +1,365 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: words/v1/words.proto
+
+package wordsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetWordsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWordsRequest) Reset() {
+	*x = GetWordsRequest{}
+	mi := &file_words_v1_words_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWordsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWordsRequest) ProtoMessage() {}
+
+func (x *GetWordsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_words_v1_words_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWordsRequest.ProtoReflect.Descriptor instead.
+func (*GetWordsRequest) Descriptor() ([]byte, []int) {
+	return file_words_v1_words_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetWordsRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+type GetWordsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	Words         []string               `protobuf:"bytes,2,rep,name=words,proto3" json:"words,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWordsResponse) Reset() {
+	*x = GetWordsResponse{}
+	mi := &file_words_v1_words_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWordsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWordsResponse) ProtoMessage() {}
+
+func (x *GetWordsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_words_v1_words_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWordsResponse.ProtoReflect.Descriptor instead.
+func (*GetWordsResponse) Descriptor() ([]byte, []int) {
+	return file_words_v1_words_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetWordsResponse) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *GetWordsResponse) GetWords() []string {
+	if x != nil {
+		return x.Words
+	}
+	return nil
+}
+
+type GetOccurrenceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOccurrenceRequest) Reset() {
+	*x = GetOccurrenceRequest{}
+	mi := &file_words_v1_words_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOccurrenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOccurrenceRequest) ProtoMessage() {}
+
+func (x *GetOccurrenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_words_v1_words_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOccurrenceRequest.ProtoReflect.Descriptor instead.
+func (*GetOccurrenceRequest) Descriptor() ([]byte, []int) {
+	return file_words_v1_words_proto_rawDescGZIP(), []int{2}
+}
+
+type GetOccurrenceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Words         map[string]int64       `protobuf:"bytes,1,rep,name=words,proto3" json:"words,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOccurrenceResponse) Reset() {
+	*x = GetOccurrenceResponse{}
+	mi := &file_words_v1_words_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOccurrenceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOccurrenceResponse) ProtoMessage() {}
+
+func (x *GetOccurrenceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_words_v1_words_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOccurrenceResponse.ProtoReflect.Descriptor instead.
+func (*GetOccurrenceResponse) Descriptor() ([]byte, []int) {
+	return file_words_v1_words_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetOccurrenceResponse) GetWords() map[string]int64 {
+	if x != nil {
+		return x.Words
+	}
+	return nil
+}
+
+type WordStreamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WordStreamRequest) Reset() {
+	*x = WordStreamRequest{}
+	mi := &file_words_v1_words_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WordStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WordStreamRequest) ProtoMessage() {}
+
+func (x *WordStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_words_v1_words_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WordStreamRequest.ProtoReflect.Descriptor instead.
+func (*WordStreamRequest) Descriptor() ([]byte, []int) {
+	return file_words_v1_words_proto_rawDescGZIP(), []int{4}
+}
+
+type WordStreamResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Word          string                 `protobuf:"bytes,1,opt,name=word,proto3" json:"word,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WordStreamResponse) Reset() {
+	*x = WordStreamResponse{}
+	mi := &file_words_v1_words_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WordStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WordStreamResponse) ProtoMessage() {}
+
+func (x *WordStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_words_v1_words_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WordStreamResponse.ProtoReflect.Descriptor instead.
+func (*WordStreamResponse) Descriptor() ([]byte, []int) {
+	return file_words_v1_words_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WordStreamResponse) GetWord() string {
+	if x != nil {
+		return x.Word
+	}
+	return ""
+}
+
+var File_words_v1_words_proto protoreflect.FileDescriptor
+
+const file_words_v1_words_proto_rawDesc = "" +
+	"\n" +
+	"\x14words/v1/words.proto\x12\bwords.v1\"'\n" +
+	"\x0fGetWordsRequest\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\">\n" +
+	"\x10GetWordsResponse\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\x12\x14\n" +
+	"\x05words\x18\x02 \x03(\tR\x05words\"\x16\n" +
+	"\x14GetOccurrenceRequest\"\x93\x01\n" +
+	"\x15GetOccurrenceResponse\x12@\n" +
+	"\x05words\x18\x01 \x03(\v2*.words.v1.GetOccurrenceResponse.WordsEntryR\x05words\x1a8\n" +
+	"\n" +
+	"WordsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\x13\n" +
+	"\x11WordStreamRequest\"(\n" +
+	"\x12WordStreamResponse\x12\x12\n" +
+	"\x04word\x18\x01 \x01(\tR\x04word2\xee\x01\n" +
+	"\fWordsService\x12A\n" +
+	"\bGetWords\x12\x19.words.v1.GetWordsRequest\x1a\x1a.words.v1.GetWordsResponse\x12P\n" +
+	"\rGetOccurrence\x12\x1e.words.v1.GetOccurrenceRequest\x1a\x1f.words.v1.GetOccurrenceResponse\x12I\n" +
+	"\n" +
+	"WordStream\x12\x1b.words.v1.WordStreamRequest\x1a\x1c.words.v1.WordStreamResponse0\x01B Z\x1egrpc-demo/gen/words/v1;wordsv1b\x06proto3"
+
+var (
+	file_words_v1_words_proto_rawDescOnce sync.Once
+	file_words_v1_words_proto_rawDescData []byte
+)
+
+func file_words_v1_words_proto_rawDescGZIP() []byte {
+	file_words_v1_words_proto_rawDescOnce.Do(func() {
+		file_words_v1_words_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_words_v1_words_proto_rawDesc), len(file_words_v1_words_proto_rawDesc)))
+	})
+	return file_words_v1_words_proto_rawDescData
+}
+
+var file_words_v1_words_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_words_v1_words_proto_goTypes = []any{
+	(*GetWordsRequest)(nil),       // 0: words.v1.GetWordsRequest
+	(*GetWordsResponse)(nil),      // 1: words.v1.GetWordsResponse
+	(*GetOccurrenceRequest)(nil),  // 2: words.v1.GetOccurrenceRequest
+	(*GetOccurrenceResponse)(nil), // 3: words.v1.GetOccurrenceResponse
+	(*WordStreamRequest)(nil),     // 4: words.v1.WordStreamRequest
+	(*WordStreamResponse)(nil),    // 5: words.v1.WordStreamResponse
+	nil,                           // 6: words.v1.GetOccurrenceResponse.WordsEntry
+}
+var file_words_v1_words_proto_depIdxs = []int32{
+	6, // 0: words.v1.GetOccurrenceResponse.words:type_name -> words.v1.GetOccurrenceResponse.WordsEntry
+	0, // 1: words.v1.WordsService.GetWords:input_type -> words.v1.GetWordsRequest
+	2, // 2: words.v1.WordsService.GetOccurrence:input_type -> words.v1.GetOccurrenceRequest
+	4, // 3: words.v1.WordsService.WordStream:input_type -> words.v1.WordStreamRequest
+	1, // 4: words.v1.WordsService.GetWords:output_type -> words.v1.GetWordsResponse
+	3, // 5: words.v1.WordsService.GetOccurrence:output_type -> words.v1.GetOccurrenceResponse
+	5, // 6: words.v1.WordsService.WordStream:output_type -> words.v1.WordStreamResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_words_v1_words_proto_init() }
+func file_words_v1_words_proto_init() {
+	if File_words_v1_words_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_words_v1_words_proto_rawDesc), len(file_words_v1_words_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_words_v1_words_proto_goTypes,
+		DependencyIndexes: file_words_v1_words_proto_depIdxs,
+		MessageInfos:      file_words_v1_words_proto_msgTypes,
+	}.Build()
+	File_words_v1_words_proto = out.File
+	file_words_v1_words_proto_goTypes = nil
+	file_words_v1_words_proto_depIdxs = nil
+}
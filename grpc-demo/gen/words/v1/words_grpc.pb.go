@@ -0,0 +1,215 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: words/v1/words.proto
+
+package wordsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	WordsService_GetWords_FullMethodName      = "/words.v1.WordsService/GetWords"
+	WordsService_GetOccurrence_FullMethodName = "/words.v1.WordsService/GetOccurrence"
+	WordsService_WordStream_FullMethodName    = "/words.v1.WordsService/WordStream"
+)
+
+// WordsServiceClient is the client API for WordsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// WordsService is the gRPC version of the /words, /occurrence and /words/stream
+// endpoints of the test-server.
+type WordsServiceClient interface {
+	// GetWords adds the input, when set, and returns all the words
+	GetWords(ctx context.Context, in *GetWordsRequest, opts ...grpc.CallOption) (*GetWordsResponse, error)
+	// GetOccurrence returns how many times every word was added
+	GetOccurrence(ctx context.Context, in *GetOccurrenceRequest, opts ...grpc.CallOption) (*GetOccurrenceResponse, error)
+	// WordStream sends the words added so far, and then every word added with
+	// GetWords as it arrives, until the client cancels
+	WordStream(ctx context.Context, in *WordStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WordStreamResponse], error)
+}
+
+type wordsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWordsServiceClient(cc grpc.ClientConnInterface) WordsServiceClient {
+	return &wordsServiceClient{cc}
+}
+
+func (c *wordsServiceClient) GetWords(ctx context.Context, in *GetWordsRequest, opts ...grpc.CallOption) (*GetWordsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetWordsResponse)
+	err := c.cc.Invoke(ctx, WordsService_GetWords_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wordsServiceClient) GetOccurrence(ctx context.Context, in *GetOccurrenceRequest, opts ...grpc.CallOption) (*GetOccurrenceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOccurrenceResponse)
+	err := c.cc.Invoke(ctx, WordsService_GetOccurrence_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wordsServiceClient) WordStream(ctx context.Context, in *WordStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WordStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WordsService_ServiceDesc.Streams[0], WordsService_WordStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WordStreamRequest, WordStreamResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WordsService_WordStreamClient = grpc.ServerStreamingClient[WordStreamResponse]
+
+// WordsServiceServer is the server API for WordsService service.
+// All implementations must embed UnimplementedWordsServiceServer
+// for forward compatibility.
+//
+// WordsService is the gRPC version of the /words, /occurrence and /words/stream
+// endpoints of the test-server.
+type WordsServiceServer interface {
+	// GetWords adds the input, when set, and returns all the words
+	GetWords(context.Context, *GetWordsRequest) (*GetWordsResponse, error)
+	// GetOccurrence returns how many times every word was added
+	GetOccurrence(context.Context, *GetOccurrenceRequest) (*GetOccurrenceResponse, error)
+	// WordStream sends the words added so far, and then every word added with
+	// GetWords as it arrives, until the client cancels
+	WordStream(*WordStreamRequest, grpc.ServerStreamingServer[WordStreamResponse]) error
+	mustEmbedUnimplementedWordsServiceServer()
+}
+
+// UnimplementedWordsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWordsServiceServer struct{}
+
+func (UnimplementedWordsServiceServer) GetWords(context.Context, *GetWordsRequest) (*GetWordsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetWords not implemented")
+}
+func (UnimplementedWordsServiceServer) GetOccurrence(context.Context, *GetOccurrenceRequest) (*GetOccurrenceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetOccurrence not implemented")
+}
+func (UnimplementedWordsServiceServer) WordStream(*WordStreamRequest, grpc.ServerStreamingServer[WordStreamResponse]) error {
+	return status.Error(codes.Unimplemented, "method WordStream not implemented")
+}
+func (UnimplementedWordsServiceServer) mustEmbedUnimplementedWordsServiceServer() {}
+func (UnimplementedWordsServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeWordsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WordsServiceServer will
+// result in compilation errors.
+type UnsafeWordsServiceServer interface {
+	mustEmbedUnimplementedWordsServiceServer()
+}
+
+func RegisterWordsServiceServer(s grpc.ServiceRegistrar, srv WordsServiceServer) {
+	// If the following call panics, it indicates UnimplementedWordsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&WordsService_ServiceDesc, srv)
+}
+
+func _WordsService_GetWords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WordsServiceServer).GetWords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WordsService_GetWords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WordsServiceServer).GetWords(ctx, req.(*GetWordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WordsService_GetOccurrence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOccurrenceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WordsServiceServer).GetOccurrence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WordsService_GetOccurrence_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WordsServiceServer).GetOccurrence(ctx, req.(*GetOccurrenceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WordsService_WordStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WordStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WordsServiceServer).WordStream(m, &grpc.GenericServerStream[WordStreamRequest, WordStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WordsService_WordStreamServer = grpc.ServerStreamingServer[WordStreamResponse]
+
+// WordsService_ServiceDesc is the grpc.ServiceDesc for WordsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WordsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "words.v1.WordsService",
+	HandlerType: (*WordsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetWords",
+			Handler:    _WordsService_GetWords_Handler,
+		},
+		{
+			MethodName: "GetOccurrence",
+			Handler:    _WordsService_GetOccurrence_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WordStream",
+			Handler:       _WordsService_WordStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "words/v1/words.proto",
+}
@@ -0,0 +1,4 @@
+// Package grpcdemo generates the Go code of the protobuf definitions in proto/
+package grpcdemo
+
+//go:generate buf generate
@@ -0,0 +1,29 @@
+module grpc-demo
+
+go 1.24.2
+
+require (
+	github.com/golang-jwt/jwt/v4 v4.5.2
+	github.com/soheilhy/cmux v0.1.5
+	google.golang.org/grpc v1.80.0
+	google.golang.org/protobuf v1.36.11
+	lifecycle v0.0.0-00010101000000-000000000000
+	logging v0.0.0-00010101000000-000000000000
+	words v0.0.0-00010101000000-000000000000
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	go.opentelemetry.io/otel v1.39.0 // indirect
+	go.opentelemetry.io/otel/trace v1.39.0 // indirect
+	golang.org/x/net v0.49.0 // indirect
+	golang.org/x/sys v0.40.0 // indirect
+	golang.org/x/text v0.33.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260120221211-b8f7ae30c516 // indirect
+)
+
+replace words => ../pkg/words
+
+replace lifecycle => ../pkg/lifecycle
+
+replace logging => ../pkg/logging
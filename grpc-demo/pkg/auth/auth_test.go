@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	wordsv1 "grpc-demo/gen/words/v1"
+	"grpc-demo/pkg/server"
+
+	"words"
+)
+
+// newJWKS serves the public key as the jwks of the oidc-demo, with the standard
+// base64 encoding it uses
+func newJWKS(t *testing.T, key *rsa.PrivateKey) *JWKS {
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "0-0-0-1",
+			"kty": "RSA",
+			"n":   base64.StdEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.StdEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(jwksServer.Close)
+	return NewJWKS(jwksServer.URL)
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, audience string, expires time.Time) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": "http://localhost:8080",
+		"sub": "9-9-9-9",
+		"aud": audience,
+		"exp": expires.Unix(),
+	})
+	token.Header["kid"] = "0-0-0-1"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString error: %s", err)
+	}
+	return signed
+}
+
+// newClient starts the words server with the interceptors of the verifier, and
+// returns a client sending the token
+func newClient(t *testing.T, verifier *Verifier, token func(context.Context) (string, error)) wordsv1.WordsServiceClient {
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(verifier.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(verifier.StreamServerInterceptor()),
+	)
+	wordsv1.RegisterWordsServiceServer(grpcServer, server.New(words.NewStore()))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	options := []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if token != nil {
+		options = append(options, grpc.WithPerRPCCredentials(TokenCredentials{Token: token, Insecure: true}))
+	}
+	conn, err := grpc.NewClient("passthrough:///bufnet", options...)
+	if err != nil {
+		t.Fatalf("NewClient error: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return wordsv1.NewWordsServiceClient(conn)
+}
+
+func TestInterceptors(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	verifier := &Verifier{JWKS: newJWKS(t, key), Issuer: "http://localhost:8080", Audience: "grpc-demo"}
+	hour := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		token func(context.Context) (string, error)
+		code  codes.Code
+	}{
+		{name: "valid", token: StaticToken(signToken(t, key, "grpc-demo", hour)), code: codes.OK},
+		{name: "missing", code: codes.Unauthenticated},
+		{name: "garbage", token: StaticToken("not-a-jwt"), code: codes.Unauthenticated},
+		{name: "expired", token: StaticToken(signToken(t, key, "grpc-demo", time.Now().Add(-time.Hour))), code: codes.Unauthenticated},
+		{name: "other key", token: StaticToken(signToken(t, otherKey, "grpc-demo", hour)), code: codes.Unauthenticated},
+		{name: "other audience", token: StaticToken(signToken(t, key, "other-app", hour)), code: codes.PermissionDenied},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newClient(t, verifier, test.token)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err := client.GetWords(ctx, &wordsv1.GetWordsRequest{Input: "word"})
+			if code := status.Code(err); code != test.code {
+				t.Errorf("GetWords: expected %s, got %s (%v)", test.code, code, err)
+			}
+
+			stream, err := client.WordStream(ctx, &wordsv1.WordStreamRequest{})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if code := status.Code(err); code != test.code {
+				t.Errorf("WordStream: expected %s, got %s (%v)", test.code, code, err)
+			}
+		})
+	}
+}
+
+func TestClaimsFromContext(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %s", err)
+	}
+	verifier := &Verifier{JWKS: newJWKS(t, key)}
+	token := signToken(t, key, "grpc-demo", time.Now().Add(time.Hour))
+	md, err := TokenCredentials{Token: StaticToken(token)}.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("GetRequestMetadata error: %s", err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.New(md))
+
+	var sub string
+	_, err = verifier.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		claims, ok := ClaimsFromContext(ctx)
+		if ok {
+			sub = claims.Subject
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("interceptor error: %s", err)
+	}
+	if sub != "9-9-9-9" {
+		t.Errorf("Expected the claims of the token in the context, got sub %q", sub)
+	}
+}
+
+func TestFileToken(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(file, []byte("first\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	token := FileToken(file)
+	if got, _ := token(context.Background()); got != "first" {
+		t.Errorf("Expected first, got %q", got)
+	}
+	if err := os.WriteFile(file, []byte("second\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := token(context.Background()); got != "second" {
+		t.Errorf("Expected the token written again, got %q", got)
+	}
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TokenCredentials sends a bearer token with every call, as grpc.WithPerRPCCredentials
+type TokenCredentials struct {
+	// Token returns the token for a call
+	Token func(ctx context.Context) (string, error)
+	// Insecure allows sending the token without TLS, for a local server only
+	Insecure bool
+}
+
+// StaticToken returns the same token for every call, e.g. the token of the
+// test-server /login that http-login prints
+func StaticToken(token string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// FileToken reads the token from the file for every call, so a token the oidc
+// flow writes again after it expired is picked up
+func FileToken(file string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		token, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("can't read the token: %w", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+}
+
+func (c TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (c TokenCredentials) RequireTransportSecurity() bool {
+	return !c.Insecure
+}
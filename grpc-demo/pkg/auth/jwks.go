@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWKS fetches the public keys of an oidc provider, like the oidc-demo, to
+// verify the tokens it signed
+type JWKS struct {
+	url  string
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey
+}
+
+func NewJWKS(url string) *JWKS {
+	return &JWKS{url: url}
+}
+
+type jwks struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// Key returns the public key with the kid, fetching the keys again if it's
+// unknown, so a rotated key is found
+func (j *JWKS) Key(kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	response, err := client.Get(j.url)
+	if err != nil {
+		return nil, fmt.Errorf("can't fetch the jwks: %w", err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read the jwks: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("can't fetch the jwks: HTTP %d", response.StatusCode)
+	}
+	var set jwks
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, fmt.Errorf("can't parse the jwks: %w", err)
+	}
+
+	j.keys = make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		n, err := decodeBase64(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid modulus: %w", key.Kid, err)
+		}
+		e, err := decodeBase64(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid exponent: %w", key.Kid, err)
+		}
+		j.keys[key.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("kid %q not found in the jwks", kid)
+}
+
+// decodeBase64 decodes the base64url encoding of the JWK spec, and the standard
+// encoding the oidc-demo uses
+func decodeBase64(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "=")); err == nil {
+		return b, nil
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
@@ -0,0 +1,110 @@
+// Package auth protects the WordsService with the JWTs of the oidc-demo: server
+// interceptors verify the bearer token of every call, and TokenCredentials sends
+// it from the client
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// errAudience is returned for a valid token issued for another client
+var errAudience = errors.New("token audience not allowed")
+
+// Verifier verifies the RS256 tokens signed with the keys of the jwks
+type Verifier struct {
+	JWKS *JWKS
+	// Issuer and Audience are checked when they're set
+	Issuer   string
+	Audience string
+}
+
+// Verify returns the claims of a valid token
+func (v *Verifier) Verify(tokenString string) (*jwt.RegisteredClaims, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.JWKS.Key(kid)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	if v.Issuer != "" && !claims.VerifyIssuer(v.Issuer, true) {
+		return nil, fmt.Errorf("token issuer %q not allowed", claims.Issuer)
+	}
+	if v.Audience != "" && !claims.VerifyAudience(v.Audience, true) {
+		return nil, errAudience
+	}
+	return claims, nil
+}
+
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims of the token the call was made with
+func ClaimsFromContext(ctx context.Context) (*jwt.RegisteredClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*jwt.RegisteredClaims)
+	return claims, ok
+}
+
+// authenticate verifies the token in the authorization metadata, and returns
+// the context with its claims. A missing or invalid token is Unauthenticated,
+// a valid token of another client PermissionDenied.
+func (v *Verifier) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	authorization := md.Get("authorization")
+	if len(authorization) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata not set")
+	}
+	tokenString, ok := strings.CutPrefix(authorization[0], "Bearer ")
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata is not a bearer token")
+	}
+	claims, err := v.Verify(tokenString)
+	if errors.Is(err, errAudience) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s", err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "authorization token invalid: %s", err)
+	}
+	return context.WithValue(ctx, claimsKey{}, claims), nil
+}
+
+// UnaryServerInterceptor verifies the token of the unary calls
+func (v *Verifier) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := v.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor verifies the token of the streams, once when they start
+func (v *Verifier) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := v.authenticate(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticatedStream passes the context with the claims to the stream handler
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,156 @@
+// Package gateway serves the WordsService as JSON over HTTP, like grpc-gateway
+// would: every request is a call of the gRPC client, and the messages are
+// encoded with protojson, so the payloads are the same in both protocols
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	wordsv1 "grpc-demo/gen/words/v1"
+)
+
+// marshaler writes the empty lists and maps too, so a response always has the same fields
+var marshaler = protojson.MarshalOptions{EmitUnpopulated: true}
+
+type gateway struct {
+	client wordsv1.WordsServiceClient
+}
+
+// Handler returns the routes of the REST API:
+//
+//	GET /v1/words?input=  -> GetWords
+//	GET /v1/occurrence    -> GetOccurrence
+//	GET /v1/words/stream  -> WordStream, as newline delimited JSON
+func Handler(client wordsv1.WordsServiceClient) http.Handler {
+	g := gateway{client: client}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/words", g.getWords)
+	mux.HandleFunc("GET /v1/occurrence", g.getOccurrence)
+	mux.HandleFunc("GET /v1/words/stream", g.wordStream)
+	return mux
+}
+
+// outgoingContext passes the Authorization header on as the metadata the auth
+// interceptors read
+func outgoingContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+	}
+	return ctx
+}
+
+func (g gateway) getWords(w http.ResponseWriter, r *http.Request) {
+	res, err := g.client.GetWords(outgoingContext(r), &wordsv1.GetWordsRequest{Input: r.URL.Query().Get("input")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeMessage(w, res)
+}
+
+func (g gateway) getOccurrence(w http.ResponseWriter, r *http.Request) {
+	res, err := g.client.GetOccurrence(outgoingContext(r), &wordsv1.GetOccurrenceRequest{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeMessage(w, res)
+}
+
+// wordStream writes one message per line, until the client disconnects or the
+// server ends the stream
+func (g gateway) wordStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, status.Error(codes.Internal, "streaming not supported"))
+		return
+	}
+	stream, err := g.client.WordStream(outgoingContext(r), &wordsv1.WordStreamRequest{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// the first message tells whether the call is allowed, before the status is sent
+	res, err := stream.Recv()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			writeError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	for {
+		out, err := marshaler.Marshal(res)
+		if err != nil {
+			return
+		}
+		if _, err := w.Write(append(out, '\n')); err != nil {
+			return
+		}
+		flusher.Flush()
+		if res, err = stream.Recv(); err != nil {
+			return
+		}
+	}
+}
+
+func writeMessage(w http.ResponseWriter, message proto.Message) {
+	out, err := marshaler.Marshal(message)
+	if err != nil {
+		writeError(w, status.Errorf(codes.Internal, "marshal error: %s", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+// writeError writes the gRPC status as JSON, with the HTTP status of its code
+func writeError(w http.ResponseWriter, err error) {
+	s := status.Convert(err)
+	out, _ := marshaler.Marshal(s.Proto())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(s.Code()))
+	w.Write(out)
+}
+
+// httpStatus maps the gRPC codes to HTTP statuses, as in
+// https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
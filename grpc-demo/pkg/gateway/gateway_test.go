@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	wordsv1 "grpc-demo/gen/words/v1"
+	"grpc-demo/pkg/server"
+
+	"words"
+)
+
+// requireSecret stands in for the auth interceptors: it only allows the calls
+// with the authorization metadata "Bearer secret"
+func requireSecret(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if authorization := md.Get("authorization"); len(authorization) == 0 || authorization[0] != "Bearer secret" {
+		return status.Error(codes.Unauthenticated, "authorization token invalid")
+	}
+	return nil
+}
+
+// newGateway starts the words server on an in-memory listener, and the gateway
+// in front of it
+func newGateway(t *testing.T, store *words.Store) *httptest.Server {
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := requireSecret(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := requireSecret(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	)
+	wordsv1.RegisterWordsServiceServer(grpcServer, server.New(store))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient error: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	gatewayServer := httptest.NewServer(Handler(wordsv1.NewWordsServiceClient(conn)))
+	t.Cleanup(gatewayServer.Close)
+	return gatewayServer
+}
+
+func get(t *testing.T, url, token string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("NewRequest error: %s", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	return res
+}
+
+// compact removes the whitespace protojson adds at random, to compare the JSON
+func compact(body []byte) string {
+	var out bytes.Buffer
+	if err := json.Compact(&out, body); err != nil {
+		return string(body)
+	}
+	return out.String()
+}
+
+func readBody(t *testing.T, res *http.Response) string {
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("ReadAll error: %s", err)
+	}
+	return compact(body)
+}
+
+func TestGateway(t *testing.T) {
+	gatewayServer := newGateway(t, words.NewStore())
+
+	tests := []struct {
+		path   string
+		token  string
+		status int
+		body   string
+	}{
+		{path: "/v1/words", token: "secret", status: http.StatusOK, body: `{"input":"","words":[]}`},
+		{path: "/v1/words?input=hello", token: "secret", status: http.StatusOK, body: `{"input":"hello","words":["hello"]}`},
+		{path: "/v1/words?input=hello", token: "secret", status: http.StatusOK, body: `{"input":"hello","words":["hello","hello"]}`},
+		{path: "/v1/occurrence", token: "secret", status: http.StatusOK, body: `{"words":{"hello":"2"}}`},
+		{path: "/v1/words", status: http.StatusUnauthorized, body: `{"code":16,"message":"authorization token invalid","details":[]}`},
+		{path: "/v1/occurrence", token: "wrong", status: http.StatusUnauthorized, body: `{"code":16,"message":"authorization token invalid","details":[]}`},
+		{path: "/v1/unknown", token: "secret", status: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		res := get(t, gatewayServer.URL+test.path, test.token)
+		body := readBody(t, res)
+		if res.StatusCode != test.status {
+			t.Errorf("%s: expected HTTP %d, got %d: %s", test.path, test.status, res.StatusCode, body)
+		}
+		if test.body != "" && body != test.body {
+			t.Errorf("%s: expected %s, got %s", test.path, test.body, body)
+		}
+	}
+}
+
+func TestGatewayStream(t *testing.T) {
+	store := words.NewStore()
+	store.Add("a")
+	gatewayServer := newGateway(t, store)
+
+	res := get(t, gatewayServer.URL+"/v1/words/stream", "")
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected HTTP 401 without a token, got %d", res.StatusCode)
+	}
+	res.Body.Close()
+
+	res = get(t, gatewayServer.URL+"/v1/words/stream", "secret")
+	defer res.Body.Close()
+	if contentType := res.Header.Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("Expected application/x-ndjson, got %s", contentType)
+	}
+	lines := bufio.NewScanner(res.Body)
+	store.Add("b")
+	var got []string
+	for len(got) < 2 && lines.Scan() {
+		got = append(got, compact(lines.Bytes()))
+	}
+	if strings.Join(got, "\n") != "{\"word\":\"a\"}\n{\"word\":\"b\"}" {
+		t.Errorf("Expected the words a and b, got %v", got)
+	}
+
+	// closing the store on shutdown ends the response
+	store.Close()
+	if lines.Scan() {
+		t.Errorf("Expected the stream to end, got %s", lines.Text())
+	}
+}
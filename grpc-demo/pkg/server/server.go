@@ -0,0 +1,63 @@
+// Package server implements the WordsService on the words store the test-server
+// uses for its REST endpoints
+package server
+
+import (
+	"context"
+
+	wordsv1 "grpc-demo/gen/words/v1"
+
+	"words"
+)
+
+type Server struct {
+	wordsv1.UnimplementedWordsServiceServer
+	words *words.Store
+}
+
+func New(store *words.Store) *Server {
+	return &Server{words: store}
+}
+
+func (s *Server) GetWords(ctx context.Context, req *wordsv1.GetWordsRequest) (*wordsv1.GetWordsResponse, error) {
+	if req.GetInput() != "" {
+		s.words.Add(req.GetInput())
+	}
+	return &wordsv1.GetWordsResponse{
+		Input: req.GetInput(),
+		Words: s.words.Words(),
+	}, nil
+}
+
+func (s *Server) GetOccurrence(ctx context.Context, req *wordsv1.GetOccurrenceRequest) (*wordsv1.GetOccurrenceResponse, error) {
+	occurrence := make(map[string]int64)
+	for word, count := range s.words.Occurrence() {
+		occurrence[word] = int64(count)
+	}
+	return &wordsv1.GetOccurrenceResponse{Words: occurrence}, nil
+}
+
+// WordStream returns when the client cancels, or when the store is closed on shutdown
+func (s *Server) WordStream(req *wordsv1.WordStreamRequest, stream wordsv1.WordsService_WordStreamServer) error {
+	existing, words, unsubscribe := s.words.Subscribe()
+	defer unsubscribe()
+
+	for _, word := range existing {
+		if err := stream.Send(&wordsv1.WordStreamResponse{Word: word}); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case word, ok := <-words:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(&wordsv1.WordStreamResponse{Word: word}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	wordsv1 "grpc-demo/gen/words/v1"
+
+	"words"
+)
+
+// newClient starts the server on an in-memory listener and returns a client for it
+func newClient(t *testing.T, store *words.Store) wordsv1.WordsServiceClient {
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	wordsv1.RegisterWordsServiceServer(grpcServer, New(store))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient error: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return wordsv1.NewWordsServiceClient(conn)
+}
+
+func TestGetWords(t *testing.T) {
+	client := newClient(t, words.NewStore())
+	ctx := context.Background()
+	for _, input := range []string{"a", "b", "", "a"} {
+		if _, err := client.GetWords(ctx, &wordsv1.GetWordsRequest{Input: input}); err != nil {
+			t.Fatalf("GetWords error: %s", err)
+		}
+	}
+	res, err := client.GetWords(ctx, &wordsv1.GetWordsRequest{})
+	if err != nil {
+		t.Fatalf("GetWords error: %s", err)
+	}
+	if fmt.Sprint(res.GetWords()) != "[a b a]" {
+		t.Errorf("Expected [a b a], got %v", res.GetWords())
+	}
+
+	occurrence, err := client.GetOccurrence(ctx, &wordsv1.GetOccurrenceRequest{})
+	if err != nil {
+		t.Fatalf("GetOccurrence error: %s", err)
+	}
+	if fmt.Sprint(occurrence.GetWords()) != "map[a:2 b:1]" {
+		t.Errorf("Expected map[a:2 b:1], got %v", occurrence.GetWords())
+	}
+}
+
+func TestWordStream(t *testing.T) {
+	store := words.NewStore()
+	store.Add("a")
+	client := newClient(t, store)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.WordStream(ctx, &wordsv1.WordStreamRequest{})
+	if err != nil {
+		t.Fatalf("WordStream error: %s", err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv error: %s", err)
+	}
+	if first.GetWord() != "a" {
+		t.Errorf("Expected the existing word a, got %s", first.GetWord())
+	}
+
+	if _, err := client.GetWords(ctx, &wordsv1.GetWordsRequest{Input: "b"}); err != nil {
+		t.Fatalf("GetWords error: %s", err)
+	}
+	next, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv error: %s", err)
+	}
+	if next.GetWord() != "b" {
+		t.Errorf("Expected the added word b, got %s", next.GetWord())
+	}
+
+	// closing the store on shutdown ends the stream
+	store.Close()
+	if _, err := stream.Recv(); err == nil {
+		t.Errorf("Expected the stream to end after Close")
+	}
+}
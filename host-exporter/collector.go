@@ -0,0 +1,163 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	load1Desc  = prometheus.NewDesc("host_load1", "1 minute load average.", nil, nil)
+	load5Desc  = prometheus.NewDesc("host_load5", "5 minute load average.", nil, nil)
+	load15Desc = prometheus.NewDesc("host_load15", "15 minute load average.", nil, nil)
+
+	memoryTotalDesc     = prometheus.NewDesc("host_memory_total_bytes", "Total usable memory.", nil, nil)
+	memoryFreeDesc      = prometheus.NewDesc("host_memory_free_bytes", "Memory not used at all.", nil, nil)
+	memoryAvailableDesc = prometheus.NewDesc("host_memory_available_bytes", "Memory available to start new applications, without swapping.", nil, nil)
+	swapTotalDesc       = prometheus.NewDesc("host_swap_total_bytes", "Total swap space.", nil, nil)
+	swapFreeDesc        = prometheus.NewDesc("host_swap_free_bytes", "Unused swap space.", nil, nil)
+
+	filesystemSizeDesc  = prometheus.NewDesc("host_filesystem_size_bytes", "Size of the filesystem.", []string{"path"}, nil)
+	filesystemFreeDesc  = prometheus.NewDesc("host_filesystem_free_bytes", "Free space of the filesystem.", []string{"path"}, nil)
+	filesystemAvailDesc = prometheus.NewDesc("host_filesystem_avail_bytes", "Free space of the filesystem available to non-root users.", []string{"path"}, nil)
+
+	processesDesc        = prometheus.NewDesc("host_processes", "Number of processes.", nil, nil)
+	processesRunningDesc = prometheus.NewDesc("host_processes_running", "Number of runnable threads.", nil, nil)
+
+	scrapeSuccessDesc  = prometheus.NewDesc("host_scrape_collector_success", "Whether the collector succeeded.", []string{"collector"}, nil)
+	scrapeDurationDesc = prometheus.NewDesc("host_scrape_collector_duration_seconds", "Duration of the collector.", []string{"collector"}, nil)
+)
+
+// hostCollector reads the host metrics from the proc filesystem and the
+// filesystems of paths on every scrape, so the values are never stale
+type hostCollector struct {
+	procRoot string
+	paths    []string
+	logger   *log.Logger
+	// scrapeErrors counts the failed collections since the start, by collector
+	scrapeErrors *prometheus.CounterVec
+}
+
+func newHostCollector(procRoot string, paths []string, logger *log.Logger) *hostCollector {
+	return &hostCollector{
+		procRoot: procRoot,
+		paths:    paths,
+		logger:   logger,
+		scrapeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "host_scrape_errors_total",
+			Help: "Number of failed collections.",
+		}, []string{"collector"}),
+	}
+}
+
+// Describe sends the descriptions of every metric, so the registry can check
+// them for conflicts when the collector is registered
+func (c *hostCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		load1Desc, load5Desc, load15Desc,
+		memoryTotalDesc, memoryFreeDesc, memoryAvailableDesc, swapTotalDesc, swapFreeDesc,
+		filesystemSizeDesc, filesystemFreeDesc, filesystemAvailDesc,
+		processesDesc, processesRunningDesc,
+		scrapeSuccessDesc, scrapeDurationDesc,
+	} {
+		ch <- desc
+	}
+	c.scrapeErrors.Describe(ch)
+}
+
+// Collect runs every collector, and reports its success and duration. A failed
+// collector doesn't fail the scrape: its metrics are left out, and its error is
+// logged.
+func (c *hostCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range []struct {
+		name    string
+		collect func(ch chan<- prometheus.Metric) error
+	}{
+		{"load", c.collectLoad},
+		{"memory", c.collectMemory},
+		{"filesystem", c.collectFilesystems},
+		{"processes", c.collectProcesses},
+	} {
+		start := time.Now()
+		err := collector.collect(ch)
+		success := 1.0
+		if err != nil {
+			success = 0
+			c.scrapeErrors.WithLabelValues(collector.name).Inc()
+			c.logger.Printf("Collector %s error: %s", collector.name, err)
+		}
+		ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, collector.name)
+		ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(start).Seconds(), collector.name)
+	}
+	c.scrapeErrors.Collect(ch)
+}
+
+func (c *hostCollector) collectLoad(ch chan<- prometheus.Metric) error {
+	f, err := os.Open(filepath.Join(c.procRoot, "loadavg"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	l, err := parseLoadavg(f)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(load1Desc, prometheus.GaugeValue, l.Load1)
+	ch <- prometheus.MustNewConstMetric(load5Desc, prometheus.GaugeValue, l.Load5)
+	ch <- prometheus.MustNewConstMetric(load15Desc, prometheus.GaugeValue, l.Load15)
+	ch <- prometheus.MustNewConstMetric(processesRunningDesc, prometheus.GaugeValue, float64(l.Running))
+	return nil
+}
+
+func (c *hostCollector) collectMemory(ch chan<- prometheus.Metric) error {
+	f, err := os.Open(filepath.Join(c.procRoot, "meminfo"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fields, err := parseMeminfo(f)
+	if err != nil {
+		return err
+	}
+	for desc, field := range map[*prometheus.Desc]string{
+		memoryTotalDesc:     "MemTotal",
+		memoryFreeDesc:      "MemFree",
+		memoryAvailableDesc: "MemAvailable",
+		swapTotalDesc:       "SwapTotal",
+		swapFreeDesc:        "SwapFree",
+	} {
+		if value, ok := fields[field]; ok {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+		}
+	}
+	return nil
+}
+
+// collectFilesystems reports the filesystems that can be read, and the error of
+// the last one that can't
+func (c *hostCollector) collectFilesystems(ch chan<- prometheus.Metric) error {
+	var lastErr error
+	for _, path := range c.paths {
+		fs, err := statFilesystem(path)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(filesystemSizeDesc, prometheus.GaugeValue, fs.Size, path)
+		ch <- prometheus.MustNewConstMetric(filesystemFreeDesc, prometheus.GaugeValue, fs.Free, path)
+		ch <- prometheus.MustNewConstMetric(filesystemAvailDesc, prometheus.GaugeValue, fs.Avail, path)
+	}
+	return lastErr
+}
+
+func (c *hostCollector) collectProcesses(ch chan<- prometheus.Metric) error {
+	count, err := countProcesses(c.procRoot)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(processesDesc, prometheus.GaugeValue, float64(count))
+	return nil
+}
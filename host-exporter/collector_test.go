@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newProcRoot returns a proc filesystem with two processes
+func newProcRoot(t *testing.T) string {
+	procRoot := t.TempDir()
+	files := map[string]string{
+		"loadavg": "1.50 0.75 0.25 3/120 4242\n",
+		"meminfo": "MemTotal: 4 kB\nMemFree: 1 kB\nMemAvailable: 2 kB\nSwapTotal: 0 kB\nSwapFree: 0 kB\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(procRoot, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pid := range []string{"1", "2"} {
+		if err := os.Mkdir(filepath.Join(procRoot, pid), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return procRoot
+}
+
+func TestHostCollector(t *testing.T) {
+	c := newHostCollector(newProcRoot(t), nil, log.New(io.Discard, "", 0))
+	expected := `
+# HELP host_load1 1 minute load average.
+# TYPE host_load1 gauge
+host_load1 1.5
+# HELP host_load15 15 minute load average.
+# TYPE host_load15 gauge
+host_load15 0.25
+# HELP host_memory_available_bytes Memory available to start new applications, without swapping.
+# TYPE host_memory_available_bytes gauge
+host_memory_available_bytes 2048
+# HELP host_memory_total_bytes Total usable memory.
+# TYPE host_memory_total_bytes gauge
+host_memory_total_bytes 4096
+# HELP host_processes Number of processes.
+# TYPE host_processes gauge
+host_processes 2
+# HELP host_processes_running Number of runnable threads.
+# TYPE host_processes_running gauge
+host_processes_running 3
+# HELP host_scrape_collector_success Whether the collector succeeded.
+# TYPE host_scrape_collector_success gauge
+host_scrape_collector_success{collector="filesystem"} 1
+host_scrape_collector_success{collector="load"} 1
+host_scrape_collector_success{collector="memory"} 1
+host_scrape_collector_success{collector="processes"} 1
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"host_load1", "host_load15", "host_memory_total_bytes", "host_memory_available_bytes",
+		"host_processes", "host_processes_running", "host_scrape_collector_success")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHostCollectorErrors(t *testing.T) {
+	procRoot := newProcRoot(t)
+	if err := os.Remove(filepath.Join(procRoot, "meminfo")); err != nil {
+		t.Fatal(err)
+	}
+	logs := &bytes.Buffer{}
+	c := newHostCollector(procRoot, []string{filepath.Join(procRoot, "missing")}, log.New(logs, "", 0))
+
+	// a failed collector is reported without failing the others, and counted on every scrape
+	for range 2 {
+		if _, err := testutil.CollectAndLint(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := `
+# HELP host_scrape_collector_success Whether the collector succeeded.
+# TYPE host_scrape_collector_success gauge
+host_scrape_collector_success{collector="filesystem"} 0
+host_scrape_collector_success{collector="load"} 1
+host_scrape_collector_success{collector="memory"} 0
+host_scrape_collector_success{collector="processes"} 1
+# HELP host_scrape_errors_total Number of failed collections.
+# TYPE host_scrape_errors_total counter
+host_scrape_errors_total{collector="filesystem"} 3
+host_scrape_errors_total{collector="memory"} 3
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "host_scrape_collector_success", "host_scrape_errors_total"); err != nil {
+		t.Error(err)
+	}
+	if testutil.CollectAndCount(c, "host_memory_total_bytes") != 0 {
+		t.Error("memory metrics reported without meminfo")
+	}
+	if !strings.Contains(logs.String(), "Collector memory error") {
+		t.Errorf("error not logged: %s", logs)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(newHandler(newHostCollector(newProcRoot(t), []string{t.TempDir()}, log.New(io.Discard, "", 0))))
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d: %s", res.StatusCode, body)
+	}
+	for _, want := range []string{"host_filesystem_size_bytes{path=", "host_load1 1.5", "go_goroutines", "promhttp_metric_handler_requests_total"} {
+		if !bytes.Contains(body, []byte(want)) {
+			t.Errorf("no %s in the metrics", want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Command host-exporter exposes the load, memory, filesystem usage and process
+// count of a Linux host in the Prometheus exposition format
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"lifecycle"
+)
+
+const usage = `Usage: %s [flags]
+
+Serves the host metrics on /metrics, read from the proc filesystem on every scrape.
+
+`
+
+func main() {
+	listen := flag.String("listen", ":9100", "address of the HTTP server")
+	procRoot := flag.String("proc", "/proc", "mount point of the proc filesystem, e.g. /host/proc in a container")
+	paths := flag.String("filesystems", "/", "paths of the filesystems to report the usage of, comma separated")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           newHandler(newHostCollector(*procRoot, splitList(*paths), log.Default())),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	component := lifecycle.HTTPServer("http", server, nil)
+	component.StopTimeout = 5 * time.Second
+	var group lifecycle.Group
+	group.Add(component)
+
+	ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+	log.Printf("Serving the metrics on %s/metrics", *listen)
+	if err := group.Run(ctx); err != nil {
+		log.Printf("Server error: %s", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// newHandler registers the host collector, and the metrics of the exporter
+// itself, in a registry of its own rather than the global one
+func newHandler(host *hostCollector) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		host,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	mux := http.NewServeMux()
+	// InstrumentMetricHandler counts the scrapes by status code
+	mux.Handle("GET /metrics", promhttp.InstrumentMetricHandler(registry,
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorLog: log.Default(), Registry: registry}),
+	))
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `<html><body><a href="/metrics">Metrics</a></body></html>`)
+	})
+	return mux
+}
+
+// splitList splits a comma separated list, without the empty items
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
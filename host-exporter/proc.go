@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// loadavg is the content of /proc/loadavg
+type loadavg struct {
+	Load1, Load5, Load15 float64
+	// Running is the number of runnable threads
+	Running int
+}
+
+// parseLoadavg parses a line like "0.20 0.18 0.12 1/80 11206"
+func parseLoadavg(r io.Reader) (loadavg, error) {
+	var (
+		l     loadavg
+		total int
+		last  int
+	)
+	_, err := fmt.Fscanf(r, "%f %f %f %d/%d %d", &l.Load1, &l.Load5, &l.Load15, &l.Running, &total, &last)
+	if err != nil {
+		return loadavg{}, fmt.Errorf("invalid loadavg: %s", err)
+	}
+	return l, nil
+}
+
+// parseMeminfo returns the fields of /proc/meminfo, in bytes for the ones in kB
+func parseMeminfo(r io.Reader) (map[string]float64, error) {
+	fields := make(map[string]float64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// MemTotal:        2035576 kB
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		parts := strings.Fields(value)
+		if len(parts) == 0 {
+			continue
+		}
+		n, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid meminfo field %s: %s", name, err)
+		}
+		if len(parts) > 1 && parts[1] == "kB" {
+			n *= 1024
+		}
+		fields[name] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["MemTotal"]; !ok {
+		return nil, fmt.Errorf("no MemTotal in meminfo")
+	}
+	return fields, nil
+}
+
+// countProcesses counts the processes in the proc filesystem: the directories
+// named by a pid
+func countProcesses(procRoot string) (int, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// filesystem is the usage of the filesystem of a path
+type filesystem struct {
+	Size, Free, Avail float64
+}
+
+// statFilesystem returns the usage of the filesystem of path. Avail is the space
+// available to unprivileged users, without the blocks reserved for root.
+func statFilesystem(path string) (filesystem, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return filesystem{}, fmt.Errorf("statfs %s: %s", path, err)
+	}
+	blockSize := float64(stat.Bsize)
+	return filesystem{
+		Size:  float64(stat.Blocks) * blockSize,
+		Free:  float64(stat.Bfree) * blockSize,
+		Avail: float64(stat.Bavail) * blockSize,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLoadavg(t *testing.T) {
+	l, err := parseLoadavg(strings.NewReader("0.20 0.18 0.12 1/80 11206\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (loadavg{Load1: 0.20, Load5: 0.18, Load15: 0.12, Running: 1}); l != want {
+		t.Errorf("got %+v, want %+v", l, want)
+	}
+	if _, err := parseLoadavg(strings.NewReader("0.20 0.18\n")); err == nil {
+		t.Error("no error for a short loadavg")
+	}
+}
+
+func TestParseMeminfo(t *testing.T) {
+	fields, err := parseMeminfo(strings.NewReader(`MemTotal:        2035576 kB
+MemFree:          110528 kB
+MemAvailable:    1323392 kB
+HugePages_Total:       0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for field, want := range map[string]float64{
+		"MemTotal":        2035576 * 1024,
+		"MemAvailable":    1323392 * 1024,
+		"HugePages_Total": 0,
+	} {
+		if fields[field] != want {
+			t.Errorf("%s is %v, want %v", field, fields[field], want)
+		}
+	}
+	if _, err := parseMeminfo(strings.NewReader("MemFree: 1 kB\n")); err == nil {
+		t.Error("no error without MemTotal")
+	}
+}
+
+func TestCountProcesses(t *testing.T) {
+	procRoot := t.TempDir()
+	for _, dir := range []string{"1", "42", "self", "sys"} {
+		if err := os.Mkdir(filepath.Join(procRoot, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(procRoot, "7"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	count, err := countProcesses(procRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("got %d processes, want 2", count)
+	}
+}
+
+func TestStatFilesystem(t *testing.T) {
+	fs, err := statFilesystem(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.Size <= 0 || fs.Free > fs.Size || fs.Avail > fs.Free {
+		t.Errorf("inconsistent usage %+v", fs)
+	}
+	if _, err := statFilesystem(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("no error for a missing path")
+	}
+}
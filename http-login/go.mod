@@ -1,3 +1,7 @@
 module http-login
 
 go 1.24.2
+
+require secrets v0.0.0-00010101000000-000000000000
+
+replace secrets => ../pkg/secrets
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"jobs"
+)
+
+// fetchJob is the payload of a job fetching a url
+type fetchJob struct {
+	URL string `json:"url"`
+}
+
+// fetcher fetches the urls of the jobs, and writes a line per fetched url
+type fetcher struct {
+	client *http.Client
+	out    io.Writer
+
+	mu sync.Mutex
+}
+
+// fetch gets the url of a job. Network errors, 429s and 5xx are retried, the other
+// statuses that aren't 2xx and invalid urls are permanent errors.
+func (f *fetcher) fetch(ctx context.Context, job jobs.Job) error {
+	var payload fetchJob
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %s", err))
+	}
+	if u, err := url.Parse(payload.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return jobs.Permanent(fmt.Errorf("invalid url %q", payload.URL))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, payload.URL, nil)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	start := time.Now()
+	res, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	size, err := io.Copy(io.Discard, res.Body)
+	if err != nil {
+		return fmt.Errorf("read %s: %s", payload.URL, err)
+	}
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return fmt.Errorf("%s: %s", payload.URL, res.Status)
+	case res.StatusCode < 200 || res.StatusCode >= 300:
+		return jobs.Permanent(fmt.Errorf("%s: %s", payload.URL, res.Status))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = fmt.Fprintf(f.out, "%d %s %d bytes in %s, attempt %d\n", res.StatusCode, payload.URL, size, time.Since(start).Round(time.Millisecond), job.Attempts)
+	return err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jobs"
+)
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("hello"))
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	f := &fetcher{client: server.Client(), out: &out}
+	for _, test := range []struct {
+		url       string
+		err       bool
+		permanent bool
+	}{
+		{url: server.URL + "/ok"},
+		{url: server.URL + "/unavailable", err: true},
+		{url: server.URL + "/limited", err: true},
+		{url: server.URL + "/missing", err: true, permanent: true},
+		{url: "ftp://example.com", err: true, permanent: true},
+		{url: "http://127.0.0.1:1", err: true},
+	} {
+		payload, _ := json.Marshal(fetchJob{URL: test.url})
+		err := f.fetch(context.Background(), jobs.Job{Payload: payload, Attempts: 1})
+		if (err != nil) != test.err || jobs.IsPermanent(err) != test.permanent {
+			t.Errorf("%s: unexpected error %v", test.url, err)
+		}
+	}
+	if expected := "200 " + server.URL + "/ok 5 bytes in "; !strings.HasPrefix(out.String(), expected) || strings.Count(out.String(), "\n") != 1 {
+		t.Errorf("Expected a line starting with %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,174 @@
+// Command jobs-demo fetches urls in bulk with the work queue of pkg/jobs: the urls
+// are enqueued as jobs, and a pool of workers fetches them, retrying the failures
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // -backend postgres
+	_ "modernc.org/sqlite"             // -backend sqlite
+
+	"jobs"
+	"logging"
+)
+
+const usage = `Usage: %s [flags] command [args]
+
+Commands:
+  fetch url...|-        enqueue the urls, or the lines of stdin with -, and fetch
+                        them, until they're all fetched or dead
+  enqueue url...|-      enqueue the urls, for the workers of work
+  work                  fetch the urls of the queue until Ctrl-C
+  dead                  write the dead letters as JSON lines
+
+The -backend memory only lives for a fetch, the redis, sqlite and postgres ones
+are shared by the processes using the same -dsn and -queue.
+
+`
+
+func main() {
+	backend := flag.String("backend", "memory", "store of the jobs: memory, redis, sqlite or postgres")
+	dsn := flag.String("dsn", os.Getenv("JOBS_DSN"), "redis url, like redis://localhost:6379/0, or database, like jobs.db or postgres://localhost/jobs (default JOBS_DSN)")
+	queue := flag.String("queue", "fetch", "name of the queue")
+	concurrency := flag.Int("concurrency", 4, "number of workers")
+	maxAttempts := flag.Int("max-attempts", 5, "attempts of a url before it's moved to the dead letters")
+	backoff := flag.Duration("backoff", time.Second, "wait before the first retry, doubled for every other one")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of a fetch, the lease of its job")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	command, args := flag.Arg(0), flag.Args()[min(flag.NArg(), 1):]
+	switch {
+	case (command == "fetch" || command == "enqueue") && len(args) > 0:
+	case (command == "work" || command == "dead") && len(args) == 0:
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *backend == "memory" && command != "fetch" {
+		fmt.Printf("Error: the memory backend only supports fetch\n")
+		os.Exit(2)
+	}
+
+	logger, err := logging.Setup()
+	if err != nil {
+		logger.Error("Invalid logging configuration", "error", err)
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	store, err := openStore(ctx, *backend, *dsn, *queue)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+
+	pool := jobs.Pool{
+		Store:       store,
+		Handler:     (&fetcher{client: &http.Client{}, out: os.Stdout}).fetch,
+		Concurrency: *concurrency,
+		MaxAttempts: *maxAttempts,
+		Backoff:     *backoff,
+		Lease:       *timeout,
+		Logger:      logger,
+	}
+	switch command {
+	case "fetch":
+		if err = enqueue(ctx, store, args); err == nil {
+			pool.StopWhenEmpty = true
+			err = pool.Run(ctx)
+		}
+		if err == nil {
+			err = writeDead(ctx, store, os.Stderr)
+		}
+	case "enqueue":
+		err = enqueue(ctx, store, args)
+	case "work":
+		err = pool.Run(ctx)
+	case "dead":
+		err = writeDead(ctx, store, os.Stdout)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// openStore returns the store of the queue in the backend
+func openStore(ctx context.Context, backend, dsn, queue string) (jobs.Store, error) {
+	switch backend {
+	case "memory":
+		return jobs.NewMemory(), nil
+	case "redis":
+		if dsn == "" {
+			dsn = "redis://localhost:6379/0"
+		}
+		return jobs.NewRedis(ctx, dsn, queue)
+	case "sqlite", "postgres":
+		// the names modernc.org/sqlite and github.com/jackc/pgx/v5/stdlib register
+		// their drivers with
+		driver := map[string]string{"sqlite": "sqlite", "postgres": "pgx"}[backend]
+		if dsn == "" {
+			return nil, fmt.Errorf("-dsn is required for %s", backend)
+		}
+		db, err := sql.Open(driver, dsn)
+		if err != nil {
+			return nil, err
+		}
+		return jobs.NewSQL(ctx, db, jobs.Dialect(backend), queue)
+	default:
+		return nil, fmt.Errorf("invalid backend %q: memory, redis, sqlite or postgres expected", backend)
+	}
+}
+
+// enqueue enqueues a job per url, the urls are the lines of stdin for -
+func enqueue(ctx context.Context, store jobs.Store, urls []string) error {
+	if len(urls) == 1 && urls[0] == "-" {
+		urls = nil
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				urls = append(urls, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	for _, u := range urls {
+		if _, err := jobs.Enqueue(ctx, store, fetchJob{URL: u}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDead writes the dead letters as JSON lines
+func writeDead(ctx context.Context, store jobs.Store, w io.Writer) error {
+	dead, err := store.Dead(ctx)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	for _, job := range dead {
+		if err := encoder.Encode(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenStoreSQLite(t *testing.T) {
+	// the jobs enqueued by a run are in the database for the next one
+	dsn := filepath.Join(t.TempDir(), "jobs.db")
+	store, err := openStore(t.Context(), "sqlite", dsn, "fetch")
+	if err != nil {
+		t.Fatalf("openStore error: %s", err)
+	}
+	if err := enqueue(t.Context(), store, []string{"https://example.com/a", "https://example.com/b"}); err != nil {
+		t.Fatalf("enqueue error: %s", err)
+	}
+	store, err = openStore(t.Context(), "sqlite", dsn, "fetch")
+	if err != nil {
+		t.Fatalf("openStore error: %s", err)
+	}
+	if pending, err := store.Pending(t.Context()); err != nil || pending != 2 {
+		t.Errorf("Expected 2 pending jobs, got %d, %v", pending, err)
+	}
+
+	for _, backend := range []string{"sqlite", "mysql"} {
+		if _, err := openStore(t.Context(), backend, "", "fetch"); err == nil {
+			t.Errorf("Expected an error for %s without -dsn", backend)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// demo manages the deployment and the service of an app in a namespace
+type demo struct {
+	clientset kubernetes.Interface
+	namespace string
+}
+
+// apply creates the deployment and the service of the app, or updates them to
+// match it, and waits for the pods of the deployment to roll out
+func (d demo) apply(ctx context.Context, a app, timeout time.Duration) error {
+	deployment, err := d.applyDeployment(ctx, a)
+	if err != nil {
+		return err
+	}
+	if err := d.applyService(ctx, a); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return d.waitForRollout(ctx, deployment)
+}
+
+// applyDeployment creates or updates the deployment. An update sends the whole
+// object back with the resource version it read, so it's retried when the
+// deployment changed in between.
+func (d demo) applyDeployment(ctx context.Context, a app) (*appsv1.Deployment, error) {
+	deployments := d.clientset.AppsV1().Deployments(d.namespace)
+	desired := a.deployment()
+	var result *appsv1.Deployment
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := deployments.Get(ctx, desired.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			result, err = deployments.Create(ctx, desired, metav1.CreateOptions{})
+			if err == nil {
+				fmt.Printf("Created deployment %s\n", desired.Name)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		existing.Labels = desired.Labels
+		existing.Spec = desired.Spec
+		result, err = deployments.Update(ctx, existing, metav1.UpdateOptions{})
+		if err == nil {
+			fmt.Printf("Updated deployment %s\n", desired.Name)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("deployment %s: %w", desired.Name, err)
+	}
+	return result, nil
+}
+
+// applyService creates or updates the service. The cluster IP and the node ports
+// are assigned by the cluster, so an update keeps them.
+func (d demo) applyService(ctx context.Context, a app) error {
+	services := d.clientset.CoreV1().Services(d.namespace)
+	desired := a.service()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := services.Get(ctx, desired.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if _, err := services.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+				return err
+			}
+			fmt.Printf("Created service %s\n", desired.Name)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for i, port := range desired.Spec.Ports {
+			for _, existingPort := range existing.Spec.Ports {
+				if existingPort.Name == port.Name && desired.Spec.Type != corev1.ServiceTypeClusterIP {
+					desired.Spec.Ports[i].NodePort = existingPort.NodePort
+				}
+			}
+		}
+		existing.Labels = desired.Labels
+		existing.Spec.Type = desired.Spec.Type
+		existing.Spec.Selector = desired.Spec.Selector
+		existing.Spec.Ports = desired.Spec.Ports
+		if _, err := services.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		fmt.Printf("Updated service %s\n", desired.Name)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("service %s: %w", desired.Name, err)
+	}
+	return nil
+}
+
+// list prints the deployments and the services of the demo in the namespace
+func (d demo) list(ctx context.Context) error {
+	selector := metav1.ListOptions{LabelSelector: "app.kubernetes.io/managed-by=" + managedBy}
+	deployments, err := d.clientset.AppsV1().Deployments(d.namespace).List(ctx, selector)
+	if err != nil {
+		return fmt.Errorf("list deployments: %w", err)
+	}
+	for _, deployment := range deployments.Items {
+		var images []string
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			images = append(images, container.Image)
+		}
+		fmt.Printf("deployment/%s  %d/%d ready  %v\n", deployment.Name, deployment.Status.ReadyReplicas, replicas(deployment.Spec.Replicas), images)
+	}
+	services, err := d.clientset.CoreV1().Services(d.namespace).List(ctx, selector)
+	if err != nil {
+		return fmt.Errorf("list services: %w", err)
+	}
+	for _, service := range services.Items {
+		var ports []string
+		for _, port := range service.Spec.Ports {
+			if port.NodePort != 0 {
+				ports = append(ports, fmt.Sprintf("%d:%d/%s", port.Port, port.NodePort, port.Protocol))
+			} else {
+				ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+			}
+		}
+		fmt.Printf("service/%s  %s  %s  %v\n", service.Name, service.Spec.Type, service.Spec.ClusterIP, ports)
+	}
+	return nil
+}
+
+// delete deletes the service and the deployment. The garbage collector deletes
+// the ReplicaSets and the pods of the deployment after it.
+func (d demo) delete(ctx context.Context, name string) error {
+	err := d.clientset.CoreV1().Services(d.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		fmt.Printf("Service %s doesn't exist\n", name)
+	case err != nil:
+		return fmt.Errorf("delete service %s: %w", name, err)
+	default:
+		fmt.Printf("Deleted service %s\n", name)
+	}
+
+	err = d.clientset.AppsV1().Deployments(d.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		fmt.Printf("Deployment %s doesn't exist\n", name)
+	case err != nil:
+		return fmt.Errorf("delete deployment %s: %w", name, err)
+	default:
+		fmt.Printf("Deleted deployment %s\n", name)
+	}
+	return nil
+}
+
+// replicas returns the number of replicas, which defaults to 1
+func replicas(n *int32) int32 {
+	if n == nil {
+		return 1
+	}
+	return *n
+}
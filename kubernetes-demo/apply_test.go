@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testApp() app {
+	return app{name: "web", image: "nginx:1.27", replicas: 2, port: 80, serviceType: corev1.ServiceTypeNodePort}
+}
+
+func TestApplyDeployment(t *testing.T) {
+	ctx := context.Background()
+	d := demo{clientset: fake.NewClientset(), namespace: "demo"}
+
+	a := testApp()
+	if _, err := d.applyDeployment(ctx, a); err != nil {
+		t.Fatalf("applyDeployment error: %s", err)
+	}
+	a.image = "nginx:1.28"
+	a.replicas = 3
+	if _, err := d.applyDeployment(ctx, a); err != nil {
+		t.Fatalf("applyDeployment error: %s", err)
+	}
+
+	deployment, err := d.clientset.AppsV1().Deployments("demo").Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "nginx:1.28" || *deployment.Spec.Replicas != 3 {
+		t.Errorf("Expected 3 replicas of nginx:1.28, got %d of %s", *deployment.Spec.Replicas, image)
+	}
+	if deployment.Labels["app.kubernetes.io/managed-by"] != managedBy {
+		t.Errorf("Expected the managed-by label, got %v", deployment.Labels)
+	}
+}
+
+func TestApplyServiceKeepsAssignedValues(t *testing.T) {
+	ctx := context.Background()
+	d := demo{clientset: fake.NewClientset(), namespace: "demo"}
+	if err := d.applyService(ctx, testApp()); err != nil {
+		t.Fatalf("applyService error: %s", err)
+	}
+	// the fake doesn't assign them like the API server does
+	services := d.clientset.CoreV1().Services("demo")
+	service, err := services.Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.Spec.ClusterIP = "10.96.0.10"
+	service.Spec.Ports[0].NodePort = 30080
+	if _, err := services.Update(ctx, service, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.applyService(ctx, testApp()); err != nil {
+		t.Fatalf("applyService error: %s", err)
+	}
+	service, err = services.Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.Spec.ClusterIP != "10.96.0.10" || service.Spec.Ports[0].NodePort != 30080 {
+		t.Errorf("Expected the cluster IP and the node port to be kept, got %s and %d", service.Spec.ClusterIP, service.Spec.Ports[0].NodePort)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	d := demo{clientset: fake.NewClientset(), namespace: "demo"}
+	if err := d.delete(ctx, "web"); err != nil {
+		t.Errorf("Expected no error when nothing exists, got %s", err)
+	}
+	if _, err := d.applyDeployment(ctx, testApp()); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.applyService(ctx, testApp()); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.delete(ctx, "web"); err != nil {
+		t.Fatalf("delete error: %s", err)
+	}
+	deployments, _ := d.clientset.AppsV1().Deployments("demo").List(ctx, metav1.ListOptions{})
+	services, _ := d.clientset.CoreV1().Services("demo").List(ctx, metav1.ListOptions{})
+	if len(deployments.Items) != 0 || len(services.Items) != 0 {
+		t.Errorf("Expected everything to be deleted, got %d deployments and %d services", len(deployments.Items), len(services.Items))
+	}
+}
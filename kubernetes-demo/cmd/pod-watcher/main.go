@@ -0,0 +1,84 @@
+// Command pod-watcher watches the Pods of a namespace with a shared informer,
+// logs their changes, and serves the Pods in its cache over HTTP
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+
+	"kubernetes-demo/pkg/kube"
+)
+
+func main() {
+	kubeconfig := flag.String("kubeconfig", kube.DefaultKubeconfig(), "kubeconfig to connect with, not used in a cluster unless set")
+	namespace := flag.String("namespace", "default", "namespace of the Pods, all namespaces when empty")
+	selector := flag.String("selector", "", "label selector of the Pods, e.g. app.kubernetes.io/name=kubernetes-demo")
+	listen := flag.String("listen", ":8080", "address of the HTTP server")
+	resync := flag.Duration("resync", 0, "interval to pass every Pod in the cache to the update handler again, 0 to never")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	clientset, err := kube.NewClientset(*kubeconfig, isFlagSet("kubeconfig"))
+	if err != nil {
+		log.Fatalf("Kubernetes config error: %s", err)
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, *resync,
+		informers.WithNamespace(*namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = *selector
+		}),
+	)
+	w, err := newWatcher(factory, log.Default())
+	if err != nil {
+		log.Fatalf("Informer error: %s", err)
+	}
+
+	server := &http.Server{Addr: *listen, Handler: w.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	// the informers run until ctx is cancelled
+	factory.Start(ctx.Done())
+	go func() {
+		if err := w.waitForSync(ctx); err != nil {
+			log.Printf("Cache isn't synced: %s", err)
+			return
+		}
+		log.Printf("Cache synced with %d pods", len(w.pods()))
+	}()
+
+	log.Printf("Serving the pods on %s", *listen)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Server error: %s", err)
+	}
+	// Shutdown waits for the informers to stop
+	stop()
+	factory.Shutdown()
+}
+
+// isFlagSet returns whether the flag is set on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
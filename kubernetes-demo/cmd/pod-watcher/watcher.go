@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// watcher logs the changes of the Pods, and reads them from the cache of the
+// informer instead of the API server
+type watcher struct {
+	informer cache.SharedIndexInformer
+	lister   listersv1.PodLister
+	logger   *log.Logger
+	synced   atomic.Bool
+}
+
+// newWatcher registers the event handlers on the Pod informer of the factory.
+// The factory still has to be started.
+func newWatcher(factory informers.SharedInformerFactory, logger *log.Logger) (*watcher, error) {
+	pods := factory.Core().V1().Pods()
+	w := &watcher{informer: pods.Informer(), lister: pods.Lister(), logger: logger}
+	_, err := w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.onAdd,
+		UpdateFunc: w.onUpdate,
+		DeleteFunc: w.onDelete,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// waitForSync waits for the first list of the informer to be in the cache.
+// Before, the cache is incomplete, so the HTTP server isn't ready.
+func (w *watcher) waitForSync(ctx context.Context) error {
+	if !cache.WaitForCacheSync(ctx.Done(), w.informer.HasSynced) {
+		return errors.New("the informer stopped before it synced")
+	}
+	w.synced.Store(true)
+	return nil
+}
+
+func (w *watcher) onAdd(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	w.logger.Printf("ADD %s/%s %s", pod.Namespace, pod.Name, podStatus(pod))
+}
+
+// onUpdate logs the updates that change the status of the Pod. The informer
+// passes every update, and at every resync the Pod again unchanged.
+func (w *watcher) onUpdate(oldObj, newObj interface{}) {
+	oldPod, ok := oldObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	newPod, ok := newObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	if oldStatus, newStatus := podStatus(oldPod), podStatus(newPod); oldStatus != newStatus {
+		w.logger.Printf("UPDATE %s/%s %s -> %s", newPod.Namespace, newPod.Name, oldStatus, newStatus)
+	}
+}
+
+// onDelete logs deleted Pods. When the watch missed the delete, the informer
+// finds out at the next list and passes the last known state as a tombstone.
+func (w *watcher) onDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	w.logger.Printf("DELETE %s/%s", pod.Namespace, pod.Name)
+}
+
+// podState is a Pod in the JSON of the HTTP endpoint
+type podState struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Phase     string    `json:"phase"`
+	Ready     string    `json:"ready"`
+	Restarts  int32     `json:"restarts"`
+	Node      string    `json:"node,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Created   time.Time `json:"created"`
+}
+
+// pods returns the Pods in the cache, sorted by namespace and name
+func (w *watcher) pods() []podState {
+	pods, err := w.lister.List(labels.Everything())
+	if err != nil {
+		// the lister of a cache doesn't fail
+		w.logger.Printf("List error: %s", err)
+	}
+	states := make([]podState, 0, len(pods))
+	for _, pod := range pods {
+		ready, total, restarts := containerCounts(pod)
+		states = append(states, podState{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Phase:     string(pod.Status.Phase),
+			Ready:     formatReady(ready, total),
+			Restarts:  restarts,
+			Node:      pod.Spec.NodeName,
+			IP:        pod.Status.PodIP,
+			Created:   pod.CreationTimestamp.Time,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Namespace != states[j].Namespace {
+			return states[i].Namespace < states[j].Namespace
+		}
+		return states[i].Name < states[j].Name
+	})
+	return states
+}
+
+// handler serves GET /pods with the Pods in the cache, and the probes. The
+// readiness probe fails until the cache is synced.
+func (w *watcher) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pods", func(rw http.ResponseWriter, r *http.Request) {
+		if !w.synced.Load() {
+			http.Error(rw, "cache not synced yet", http.StatusServiceUnavailable)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(w.pods())
+	})
+	mux.HandleFunc("GET /healthz", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("GET /readyz", func(rw http.ResponseWriter, r *http.Request) {
+		if !w.synced.Load() {
+			http.Error(rw, "cache not synced yet", http.StatusServiceUnavailable)
+			return
+		}
+		rw.Write([]byte("ok\n"))
+	})
+	return mux
+}
+
+// podStatus summarizes the Pod like kubectl get pods: the phase, the ready
+// containers and the restarts
+func podStatus(pod *corev1.Pod) string {
+	ready, total, restarts := containerCounts(pod)
+	status := string(pod.Status.Phase) + " " + formatReady(ready, total)
+	if pod.DeletionTimestamp != nil {
+		status += " terminating"
+	}
+	if restarts > 0 {
+		status += " restarts=" + itoa(restarts)
+	}
+	return status
+}
+
+// containerCounts returns the number of ready containers, of containers, and of
+// their restarts
+func containerCounts(pod *corev1.Pod) (ready, total, restarts int32) {
+	total = int32(len(pod.Spec.Containers))
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+		restarts += status.RestartCount
+	}
+	return ready, total, restarts
+}
+
+func formatReady(ready, total int32) string {
+	return itoa(ready) + "/" + itoa(total)
+}
+
+func itoa(n int32) string {
+	return strconv.FormatInt(int64(n), 10)
+}
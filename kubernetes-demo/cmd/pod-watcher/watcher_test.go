@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// syncBuffer is a buffer the informer goroutine writes the log to while the
+// test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitForLog waits for the log to contain s
+func waitForLog(t *testing.T, buf *syncBuffer, s string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(buf.String(), s) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the log to contain %q:\n%s", s, buf.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func testPod(name string, ready bool) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "demo"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}}, NodeName: "node-1"},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "web", Ready: ready}},
+		},
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clientset := fake.NewClientset(testPod("web-b", true), testPod("web-a", false))
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithNamespace("demo"))
+	var buf syncBuffer
+	w, err := newWatcher(factory, log.New(&buf, "", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(w.handler())
+	t.Cleanup(server.Close)
+
+	// the endpoints aren't ready until the cache is synced
+	for _, path := range []string{"/pods", "/readyz"} {
+		res, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503 before the sync, got %d", path, res.StatusCode)
+		}
+	}
+
+	factory.Start(ctx.Done())
+	// Shutdown waits for the informers, which stop when ctx is cancelled
+	defer func() {
+		cancel()
+		factory.Shutdown()
+	}()
+	if err := w.waitForSync(ctx); err != nil {
+		t.Fatalf("waitForSync error: %s", err)
+	}
+	waitForLog(t, &buf, "ADD demo/web-a Running 0/1")
+	waitForLog(t, &buf, "ADD demo/web-b Running 1/1")
+
+	res, err := http.Get(server.URL + "/pods")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pods []podState
+	err = json.NewDecoder(res.Body).Decode(&pods)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 2 || pods[0].Name != "web-a" || pods[0].Ready != "0/1" || pods[1].Ready != "1/1" || pods[1].Node != "node-1" {
+		t.Errorf("Expected web-a and web-b sorted by name, got %+v", pods)
+	}
+
+	pod := testPod("web-a", true)
+	pod.Status.ContainerStatuses[0].RestartCount = 2
+	if _, err := clientset.CoreV1().Pods("demo").UpdateStatus(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForLog(t, &buf, "UPDATE demo/web-a Running 0/1 -> Running 1/1 restarts=2")
+
+	if err := clientset.CoreV1().Pods("demo").Delete(ctx, "web-b", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitForLog(t, &buf, "DELETE demo/web-b")
+	if pods := w.pods(); len(pods) != 1 || pods[0].Name != "web-a" || pods[0].Restarts != 2 {
+		t.Errorf("Expected the cache to have web-a with 2 restarts, got %+v", pods)
+	}
+}
+
+func TestOnUpdateSkipsResyncs(t *testing.T) {
+	var buf syncBuffer
+	w := &watcher{logger: log.New(&buf, "", 0)}
+	pod := testPod("web", true)
+	w.onUpdate(pod, pod.DeepCopy())
+	if buf.String() != "" {
+		t.Errorf("Expected an unchanged pod not to be logged, got %q", buf.String())
+	}
+}
@@ -0,0 +1,108 @@
+// Command tls-secret creates a TLS Secret with a certificate signed by a dev CA,
+// rotates it before it expires, and restarts the Deployments that mount it
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"certs"
+
+	"kubernetes-demo/pkg/kube"
+)
+
+func main() {
+	kubeconfig := flag.String("kubeconfig", kube.DefaultKubeconfig(), "kubeconfig to connect with, not used in a cluster unless set")
+	namespace := flag.String("namespace", "default", "namespace of the secret")
+	name := flag.String("name", "kubernetes-demo-tls", "name of the secret")
+	service := flag.String("service", "kubernetes-demo", "service the certificate is for, when -hosts isn't set")
+	hosts := flag.String("hosts", "", "comma-separated DNS names and IPs of the certificate, the names of -service in the cluster by default")
+	caDir := flag.String("ca-dir", "ca", "directory with ca.pem and ca-key.pem, created with a new CA on the first run")
+	validity := flag.Duration("validity", 90*24*time.Hour, "validity of the certificate")
+	renewBefore := flag.Duration("renew-before", 30*24*time.Hour, "rotate the certificate when it expires within this time")
+	force := flag.Bool("force", false, "rotate the certificate even when it's valid")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	clientset, err := kube.NewClientset(*kubeconfig, isFlagSet("kubeconfig"))
+	if err != nil {
+		fmt.Printf("Kubernetes config error: %s\n", err)
+		os.Exit(1)
+	}
+	ca, err := loadCA(*caDir)
+	if err != nil {
+		fmt.Printf("CA error: %s\n", err)
+		os.Exit(1)
+	}
+	o := options{
+		name:        *name,
+		hosts:       serviceHosts(*service, *namespace),
+		validity:    *validity,
+		renewBefore: *renewBefore,
+		force:       *force,
+	}
+	if *hosts != "" {
+		o.hosts = strings.Split(*hosts, ",")
+	}
+
+	t := tool{clientset: clientset, namespace: *namespace, ca: ca}
+	rotated, err := t.apply(ctx, o)
+	if err == nil && rotated {
+		err = t.restartDeployments(ctx, o.name)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// loadCA loads the CA in dir, or creates one. The CA is valid for 10 years, so
+// the clients that trust it keep working through the rotations.
+func loadCA(dir string) (*certs.Cert, error) {
+	certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+	if _, err := os.Stat(certFile); err == nil {
+		return certs.Load(certFile, keyFile)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	ca, err := certs.NewCA("kubernetes-demo dev CA", 10*365*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if err := ca.WriteFiles(certFile, keyFile); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Created a CA in %s, trust %s in the clients\n", dir, certFile)
+	return ca, nil
+}
+
+// serviceHosts returns the names of a service in the cluster
+func serviceHosts(service, namespace string) []string {
+	return []string{
+		service,
+		service + "." + namespace,
+		service + "." + namespace + ".svc",
+		service + "." + namespace + ".svc.cluster.local",
+	}
+}
+
+// isFlagSet returns whether the flag is set on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
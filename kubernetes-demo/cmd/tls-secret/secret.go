@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"certs"
+)
+
+// restartedAtAnnotation is set on the pod template of the Deployments that mount
+// a rotated secret. Changing the template rolls out new pods, which read the new
+// certificate, like kubectl rollout restart does with its own annotation.
+const restartedAtAnnotation = "kubernetes-demo/tls-rotated-at"
+
+// options are the settings of the certificate in the secret
+type options struct {
+	name        string
+	hosts       []string
+	validity    time.Duration
+	renewBefore time.Duration
+	force       bool
+}
+
+// tool manages TLS secrets in a namespace, with certificates signed by ca
+type tool struct {
+	clientset kubernetes.Interface
+	namespace string
+	ca        *certs.Cert
+}
+
+// apply creates the secret, or rotates its certificate when it needs to. It
+// returns whether the certificate is new.
+func (t tool) apply(ctx context.Context, o options) (bool, error) {
+	secrets := t.clientset.CoreV1().Secrets(t.namespace)
+	existing, err := secrets.Get(ctx, o.name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		existing = nil
+	case err != nil:
+		return false, fmt.Errorf("get secret %s: %w", o.name, err)
+	case existing.Type != corev1.SecretTypeTLS:
+		return false, fmt.Errorf("secret %s is of type %s, not %s", o.name, existing.Type, corev1.SecretTypeTLS)
+	default:
+		reason := t.rotationReason(existing, o, time.Now())
+		if reason == "" && !o.force {
+			cert, _ := certs.ParseCert(existing.Data[corev1.TLSCertKey])
+			fmt.Printf("Secret %s is up to date, the certificate expires at %s\n", o.name, cert.NotAfter.Format(time.RFC3339))
+			return false, nil
+		}
+		if reason == "" {
+			reason = "-force is set"
+		}
+		fmt.Printf("Rotating the certificate of secret %s: %s\n", o.name, reason)
+	}
+
+	cert, err := certs.NewServer(t.ca, o.hosts[0], o.hosts, o.validity)
+	if err != nil {
+		return false, err
+	}
+	keyPEM, err := cert.KeyPEM()
+	if err != nil {
+		return false, err
+	}
+	data := map[string][]byte{
+		corev1.TLSCertKey:       cert.CertPEM(),
+		corev1.TLSPrivateKeyKey: keyPEM,
+		"ca.crt":                t.ca.CertPEM(),
+	}
+
+	if existing == nil {
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   o.name,
+				Labels: map[string]string{"app.kubernetes.io/managed-by": "kubernetes-demo"},
+			},
+			Type: corev1.SecretTypeTLS,
+			Data: data,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return false, fmt.Errorf("create secret %s: %w", o.name, err)
+		}
+		fmt.Printf("Created secret %s, the certificate expires at %s\n", o.name, cert.Cert.NotAfter.Format(time.RFC3339))
+		return true, nil
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secrets.Get(ctx, o.name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		secret.Data = data
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("update secret %s: %w", o.name, err)
+	}
+	fmt.Printf("Rotated secret %s, the certificate expires at %s\n", o.name, cert.Cert.NotAfter.Format(time.RFC3339))
+	return true, nil
+}
+
+// rotationReason returns why the certificate in the secret has to be rotated,
+// or "" when it's still good at now
+func (t tool) rotationReason(secret *corev1.Secret, o options, now time.Time) string {
+	cert, err := certs.ParseCert(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return fmt.Sprintf("invalid certificate: %s", err)
+	}
+	if expires := cert.NotAfter; now.Add(o.renewBefore).After(expires) {
+		return fmt.Sprintf("it expires at %s", expires.Format(time.RFC3339))
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(t.ca.Cert)
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, CurrentTime: now}); err != nil {
+		return "it isn't signed by the CA"
+	}
+	hosts := append(slices.Clone(cert.DNSNames), ipStrings(cert)...)
+	if !sameHosts(hosts, o.hosts) {
+		return fmt.Sprintf("it's for %v, not %v", hosts, o.hosts)
+	}
+	return ""
+}
+
+// restartDeployments restarts the Deployments in the namespace that mount the
+// secret, with an annotation on their pod template
+func (t tool) restartDeployments(ctx context.Context, secretName string) error {
+	deployments := t.clientset.AppsV1().Deployments(t.namespace)
+	list, err := deployments.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("list deployments: %w", err)
+	}
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`, restartedAtAnnotation, time.Now().Format(time.RFC3339))
+	for _, deployment := range list.Items {
+		if !mountsSecret(deployment.Spec.Template.Spec, secretName) {
+			continue
+		}
+		if _, err := deployments.Patch(ctx, deployment.Name, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("restart deployment %s: %w", deployment.Name, err)
+		}
+		fmt.Printf("Restarted deployment %s\n", deployment.Name)
+	}
+	return nil
+}
+
+// mountsSecret returns whether the pod has a volume of the secret, directly or
+// in a projected volume
+func mountsSecret(pod corev1.PodSpec, secretName string) bool {
+	for _, volume := range pod.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == secretName {
+			return true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil && source.Secret.Name == secretName {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func ipStrings(cert *x509.Certificate) []string {
+	ips := make([]string, len(cert.IPAddresses))
+	for i, ip := range cert.IPAddresses {
+		ips[i] = ip.String()
+	}
+	return ips
+}
+
+// sameHosts returns whether a and b have the same hosts, in any order
+func sameHosts(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
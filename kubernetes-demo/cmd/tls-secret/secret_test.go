@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"certs"
+)
+
+func newTestTool(t *testing.T, objects ...metav1.Object) tool {
+	ca, err := certs.NewCA("test CA", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientset := fake.NewClientset()
+	for _, object := range objects {
+		var err error
+		switch object := object.(type) {
+		case *appsv1.Deployment:
+			_, err = clientset.AppsV1().Deployments("demo").Create(context.Background(), object, metav1.CreateOptions{})
+		case *corev1.Secret:
+			_, err = clientset.CoreV1().Secrets("demo").Create(context.Background(), object, metav1.CreateOptions{})
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tool{clientset: clientset, namespace: "demo", ca: ca}
+}
+
+func testOptions() options {
+	return options{name: "web-tls", hosts: serviceHosts("web", "demo"), validity: 90 * 24 * time.Hour, renewBefore: 30 * 24 * time.Hour}
+}
+
+func getCert(t *testing.T, tl tool) []byte {
+	secret, err := tl.clientset.CoreV1().Secrets("demo").Get(context.Background(), "web-tls", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secret.Data[corev1.TLSCertKey]
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	tl := newTestTool(t)
+	o := testOptions()
+
+	rotated, err := tl.apply(ctx, o)
+	if err != nil || !rotated {
+		t.Fatalf("Expected the secret to be created, got %t (%v)", rotated, err)
+	}
+	created := getCert(t, tl)
+	secret, _ := tl.clientset.CoreV1().Secrets("demo").Get(ctx, "web-tls", metav1.GetOptions{})
+	if secret.Type != corev1.SecretTypeTLS || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 || len(secret.Data["ca.crt"]) == 0 {
+		t.Errorf("Expected a TLS secret with the key and the CA, got %s with %d keys", secret.Type, len(secret.Data))
+	}
+
+	if rotated, err := tl.apply(ctx, o); err != nil || rotated {
+		t.Errorf("Expected the secret to be up to date, got %t (%v)", rotated, err)
+	}
+	if string(getCert(t, tl)) != string(created) {
+		t.Errorf("Expected the certificate to be kept")
+	}
+
+	o.force = true
+	if rotated, err := tl.apply(ctx, o); err != nil || !rotated {
+		t.Errorf("Expected -force to rotate the certificate, got %t (%v)", rotated, err)
+	}
+	if string(getCert(t, tl)) == string(created) {
+		t.Errorf("Expected a new certificate")
+	}
+}
+
+func TestApplyOtherSecretType(t *testing.T) {
+	tl := newTestTool(t, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "web-tls"}, Type: corev1.SecretTypeOpaque})
+	if _, err := tl.apply(context.Background(), testOptions()); err == nil {
+		t.Errorf("Expected an error for an Opaque secret")
+	}
+}
+
+func TestRotationReason(t *testing.T) {
+	tl := newTestTool(t)
+	other := newTestTool(t)
+	o := testOptions()
+	secret := func(tl tool, hosts []string, validity time.Duration) *corev1.Secret {
+		cert, err := certs.NewServer(tl.ca, hosts[0], hosts, validity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &corev1.Secret{Data: map[string][]byte{corev1.TLSCertKey: cert.CertPEM()}}
+	}
+
+	tests := []struct {
+		name   string
+		secret *corev1.Secret
+		rotate bool
+	}{
+		{name: "valid", secret: secret(tl, o.hosts, o.validity)},
+		{name: "hosts in another order", secret: secret(tl, []string{o.hosts[3], o.hosts[2], o.hosts[1], o.hosts[0]}, o.validity)},
+		{name: "expires soon", secret: secret(tl, o.hosts, 10*24*time.Hour), rotate: true},
+		{name: "other hosts", secret: secret(tl, []string{"web", "127.0.0.1"}, o.validity), rotate: true},
+		{name: "other CA", secret: secret(other, o.hosts, o.validity), rotate: true},
+		{name: "no certificate", secret: &corev1.Secret{}, rotate: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reason := tl.rotationReason(test.secret, o, time.Now())
+			if (reason != "") != test.rotate {
+				t.Errorf("Expected rotate %t, got reason %q", test.rotate, reason)
+			}
+		})
+	}
+}
+
+func TestRestartDeployments(t *testing.T) {
+	deployment := func(name string, volumes ...corev1.Volume) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Volumes: volumes}},
+			},
+		}
+	}
+	tl := newTestTool(t,
+		deployment("mounts", corev1.Volume{Name: "tls", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "web-tls"}}}),
+		deployment("projected", corev1.Volume{Name: "tls", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+			Sources: []corev1.VolumeProjection{{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "web-tls"}}}},
+		}}}),
+		deployment("other-secret", corev1.Volume{Name: "tls", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "other"}}}),
+		deployment("no-volumes"),
+	)
+	if err := tl.restartDeployments(context.Background(), "web-tls"); err != nil {
+		t.Fatalf("restartDeployments error: %s", err)
+	}
+
+	expected := map[string]bool{"mounts": true, "projected": true, "other-secret": false, "no-volumes": false}
+	for name, restarted := range expected {
+		deployment, err := tl.clientset.AppsV1().Deployments("demo").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := deployment.Spec.Template.Annotations[restartedAtAnnotation]; ok != restarted {
+			t.Errorf("%s: expected restarted %t, got the annotations %v", name, restarted, deployment.Spec.Template.Annotations)
+		}
+	}
+}
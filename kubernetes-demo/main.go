@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"kubernetes-demo/pkg/kube"
+)
+
+const usage = `Usage: %s [flags] apply|list|delete
+
+Commands:
+  apply   create or update the deployment and the service, and wait for the rollout
+  list    list the deployments and services of the demo
+  delete  delete the deployment and the service
+
+`
+
+func main() {
+	kubeconfig := flag.String("kubeconfig", kube.DefaultKubeconfig(), "kubeconfig to connect with, not used in a cluster unless set")
+	namespace := flag.String("namespace", "default", "namespace of the deployment")
+	name := flag.String("name", "kubernetes-demo", "name of the deployment and the service")
+	image := flag.String("image", "nginx:1.27", "container image")
+	replicas := flag.Int("replicas", 2, "number of pods")
+	port := flag.Int("port", 80, "port the container listens on, and the service exposes")
+	serviceType := flag.String("service-type", string(corev1.ServiceTypeClusterIP), "type of the service: ClusterIP, NodePort or LoadBalancer")
+	timeout := flag.Duration("timeout", 5*time.Minute, "how long apply waits for the rollout")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	clientset, err := kube.NewClientset(*kubeconfig, isFlagSet("kubeconfig"))
+	if err != nil {
+		fmt.Printf("Kubernetes config error: %s\n", err)
+		os.Exit(1)
+	}
+	d := demo{clientset: clientset, namespace: *namespace}
+	app := app{
+		name:        *name,
+		image:       *image,
+		replicas:    int32(*replicas),
+		port:        int32(*port),
+		serviceType: corev1.ServiceType(*serviceType),
+	}
+
+	switch flag.Arg(0) {
+	case "apply":
+		err = d.apply(ctx, app, *timeout)
+	case "list":
+		err = d.list(ctx)
+	case "delete":
+		err = d.delete(ctx, app.name)
+	default:
+		flag.Usage()
+		stop()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// isFlagSet returns whether the flag is set on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
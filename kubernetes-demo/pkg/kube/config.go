@@ -0,0 +1,52 @@
+// Package kube creates the Kubernetes clients of the demos, in a cluster or
+// from a kubeconfig
+package kube
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// DefaultKubeconfig returns $KUBECONFIG, or ~/.kube/config
+func DefaultKubeconfig() string {
+	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
+		return kubeconfig
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+// Config returns the config of the service account of the pod when running in
+// a cluster, and the current context of the kubeconfig otherwise. A kubeconfig
+// set explicitly is used in a cluster as well.
+func Config(kubeconfig string, explicit bool) (*rest.Config, error) {
+	if !explicit {
+		config, err := rest.InClusterConfig()
+		if err == nil {
+			return config, nil
+		}
+		if !errors.Is(err, rest.ErrNotInCluster) {
+			return nil, err
+		}
+	}
+	// an empty kubeconfig falls back to the in-cluster config, and then to
+	// http://localhost:8080 with a warning
+	return clientcmd.BuildConfigFromFlags("", kubeconfig)
+}
+
+// NewClientset returns a clientset for the config of Config
+func NewClientset(kubeconfig string, explicit bool) (*kubernetes.Clientset, error) {
+	config, err := Config(kubeconfig, explicit)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
@@ -0,0 +1,60 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+users:
+- name: test
+  user:
+    token: secret
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+`
+
+func TestConfig(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// outside a cluster, the kubeconfig is used whether it's set explicitly or not
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	for _, explicit := range []bool{false, true} {
+		config, err := Config(kubeconfig, explicit)
+		if err != nil {
+			t.Fatalf("Config error: %s", err)
+		}
+		if config.Host != "https://127.0.0.1:6443" || config.BearerToken != "secret" {
+			t.Errorf("Expected the cluster of the kubeconfig, got %s", config.Host)
+		}
+	}
+
+	if _, err := Config(filepath.Join(t.TempDir(), "missing"), true); err == nil {
+		t.Errorf("Expected an error for a missing kubeconfig")
+	}
+}
+
+func TestDefaultKubeconfig(t *testing.T) {
+	t.Setenv("KUBECONFIG", "/etc/kubeconfig")
+	if kubeconfig := DefaultKubeconfig(); kubeconfig != "/etc/kubeconfig" {
+		t.Errorf("Expected $KUBECONFIG, got %s", kubeconfig)
+	}
+	t.Setenv("KUBECONFIG", "")
+	t.Setenv("HOME", "/home/demo")
+	if kubeconfig := DefaultKubeconfig(); kubeconfig != "/home/demo/.kube/config" {
+		t.Errorf("Expected ~/.kube/config, got %s", kubeconfig)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// managedBy labels the resources of the demo, so list finds them
+const managedBy = "kubernetes-demo"
+
+// app is a container image served by a deployment and a service
+type app struct {
+	name        string
+	image       string
+	replicas    int32
+	port        int32
+	serviceType corev1.ServiceType
+}
+
+// labels are the labels of the resources and the pods. The selector uses the
+// name only: the selector of a deployment can't change after it's created.
+func (a app) labels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       a.name,
+		"app.kubernetes.io/managed-by": managedBy,
+	}
+}
+
+func (a app) selector() map[string]string {
+	return map[string]string{"app.kubernetes.io/name": a.name}
+}
+
+func (a app) deployment() *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   a.name,
+			Labels: a.labels(),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &a.replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: a.selector()},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: a.labels()},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  a.name,
+						Image: a.image,
+						Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: a.port}},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("http")},
+							},
+							PeriodSeconds: 5,
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func (a app) service() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   a.name,
+			Labels: a.labels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     a.serviceType,
+			Selector: a.selector(),
+			Ports: []corev1.ServicePort{{
+				Name:       "http",
+				Port:       a.port,
+				TargetPort: intstr.FromString("http"),
+			}},
+		},
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// revisionAnnotation is set by the deployment controller on the deployment and
+// its ReplicaSets. The ReplicaSet with the revision of the deployment is the new one.
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// waitForRollout waits until the pods of the new ReplicaSet of the deployment
+// are available, and the pods of the old ones are gone
+func (d demo) waitForRollout(ctx context.Context, deployment *appsv1.Deployment) error {
+	deployment, err := d.waitForObservedGeneration(ctx, deployment)
+	if err != nil {
+		return err
+	}
+	revision := deployment.Annotations[revisionAnnotation]
+	desired := replicas(deployment.Spec.Replicas)
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return err
+	}
+
+	// UntilWithSync lists the ReplicaSets first, then watches them, and starts
+	// the watch again when it ends before the condition is met
+	replicaSetsClient := d.clientset.AppsV1().ReplicaSets(d.namespace)
+	lw := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = selector.String()
+			return replicaSetsClient.List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = selector.String()
+			return replicaSetsClient.Watch(ctx, options)
+		},
+	}
+	replicaSets := map[string]*appsv1.ReplicaSet{}
+	lastStatus := ""
+	_, err = watchtools.UntilWithSync(ctx, lw, &appsv1.ReplicaSet{}, nil, func(event watch.Event) (bool, error) {
+		replicaSet, ok := event.Object.(*appsv1.ReplicaSet)
+		if !ok || !ownedBy(replicaSet, deployment.UID) {
+			return false, nil
+		}
+		if event.Type == watch.Deleted {
+			delete(replicaSets, replicaSet.Name)
+		} else {
+			replicaSets[replicaSet.Name] = replicaSet
+		}
+		done, status, err := rolloutStatus(revision, desired, replicaSets)
+		if status != lastStatus {
+			fmt.Println(status)
+			lastStatus = status
+		}
+		return done, err
+	})
+	if err != nil {
+		return fmt.Errorf("rollout of deployment %s: %w", deployment.Name, err)
+	}
+	fmt.Printf("Deployment %s is rolled out\n", deployment.Name)
+	return nil
+}
+
+// waitForObservedGeneration waits for the deployment controller to handle the
+// latest change of the deployment, and returns the deployment then. Before, the
+// revision can still be the one of the previous rollout.
+func (d demo) waitForObservedGeneration(ctx context.Context, deployment *appsv1.Deployment) (*appsv1.Deployment, error) {
+	err := wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+		current, err := d.clientset.AppsV1().Deployments(d.namespace).Get(ctx, deployment.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		deployment = current
+		return current.Status.ObservedGeneration >= current.Generation, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("deployment %s isn't handled by the controller: %w", deployment.Name, err)
+	}
+	return deployment, nil
+}
+
+// rolloutStatus returns whether the rollout to the revision is done, and a line
+// about its progress. It fails when the new ReplicaSet can't create pods, like
+// when the quota of the namespace is used up.
+func rolloutStatus(revision string, desired int32, replicaSets map[string]*appsv1.ReplicaSet) (bool, string, error) {
+	var newReplicaSet *appsv1.ReplicaSet
+	var oldReplicas int32
+	for _, replicaSet := range replicaSets {
+		if replicaSet.Annotations[revisionAnnotation] == revision {
+			newReplicaSet = replicaSet
+		} else {
+			oldReplicas += replicaSet.Status.Replicas
+		}
+	}
+	if newReplicaSet == nil {
+		return false, fmt.Sprintf("Waiting for the ReplicaSet of revision %s", revision), nil
+	}
+	for _, condition := range newReplicaSet.Status.Conditions {
+		if condition.Type == appsv1.ReplicaSetReplicaFailure && condition.Status == corev1.ConditionTrue {
+			return false, "", fmt.Errorf("ReplicaSet %s can't create pods: %s", newReplicaSet.Name, condition.Message)
+		}
+	}
+
+	status := fmt.Sprintf("ReplicaSet %s: %d of %d pods available", newReplicaSet.Name, newReplicaSet.Status.AvailableReplicas, desired)
+	if oldReplicas > 0 {
+		status += fmt.Sprintf(", %d old pods left", oldReplicas)
+	}
+	done := newReplicaSet.Status.ObservedGeneration >= newReplicaSet.Generation &&
+		newReplicaSet.Status.AvailableReplicas >= desired && oldReplicas == 0
+	return done, status, nil
+}
+
+// ownedBy returns whether the object is controlled by the owner with the uid
+func ownedBy(object metav1.Object, uid types.UID) bool {
+	owner := metav1.GetControllerOf(object)
+	return owner != nil && owner.UID == uid
+}
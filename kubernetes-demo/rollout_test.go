@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testReplicaSet returns a ReplicaSet of the deployment "web" with the revision
+func testReplicaSet(name, revision string, replicas, available int32) *appsv1.ReplicaSet {
+	controller := true
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "demo",
+			Labels:          testApp().labels(),
+			Annotations:     map[string]string{revisionAnnotation: revision},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: "web-uid", Controller: &controller}},
+		},
+		Status: appsv1.ReplicaSetStatus{Replicas: replicas, AvailableReplicas: available},
+	}
+}
+
+func TestRolloutStatus(t *testing.T) {
+	failed := testReplicaSet("web-2", "2", 0, 0)
+	failed.Status.Conditions = []appsv1.ReplicaSetCondition{{Type: appsv1.ReplicaSetReplicaFailure, Status: corev1.ConditionTrue, Message: "exceeded quota"}}
+	tests := []struct {
+		name        string
+		replicaSets []*appsv1.ReplicaSet
+		done        bool
+		status      string
+		wantErr     bool
+	}{
+		{name: "no new ReplicaSet", replicaSets: []*appsv1.ReplicaSet{testReplicaSet("web-1", "1", 2, 2)}, status: "Waiting for the ReplicaSet of revision 2"},
+		{name: "scaling up", replicaSets: []*appsv1.ReplicaSet{testReplicaSet("web-1", "1", 2, 2), testReplicaSet("web-2", "2", 1, 0)},
+			status: "ReplicaSet web-2: 0 of 2 pods available, 2 old pods left"},
+		{name: "old pods left", replicaSets: []*appsv1.ReplicaSet{testReplicaSet("web-1", "1", 1, 1), testReplicaSet("web-2", "2", 2, 2)},
+			status: "ReplicaSet web-2: 2 of 2 pods available, 1 old pods left"},
+		{name: "done", replicaSets: []*appsv1.ReplicaSet{testReplicaSet("web-1", "1", 0, 0), testReplicaSet("web-2", "2", 2, 2)},
+			done: true, status: "ReplicaSet web-2: 2 of 2 pods available"},
+		{name: "replica failure", replicaSets: []*appsv1.ReplicaSet{failed}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			replicaSets := map[string]*appsv1.ReplicaSet{}
+			for _, replicaSet := range test.replicaSets {
+				replicaSets[replicaSet.Name] = replicaSet
+			}
+			done, status, err := rolloutStatus("2", 2, replicaSets)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %q", status)
+				}
+				return
+			}
+			if err != nil || done != test.done || status != test.status {
+				t.Errorf("Expected %t %q, got %t %q (%v)", test.done, test.status, done, status, err)
+			}
+		})
+	}
+}
+
+func TestWaitForRollout(t *testing.T) {
+	a := testApp()
+	deployment := a.deployment()
+	deployment.Namespace = "demo"
+	deployment.UID = types.UID("web-uid")
+	deployment.Annotations = map[string]string{revisionAnnotation: "2"}
+	other := testReplicaSet("other-2", "2", 0, 0)
+	other.OwnerReferences[0].UID = "other-uid"
+	clientset := fake.NewClientset(deployment, other, testReplicaSet("web-1", "1", 2, 2), testReplicaSet("web-2", "2", 1, 0))
+	d := demo{clientset: clientset, namespace: "demo"}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- d.waitForRollout(context.Background(), deployment)
+	}()
+	select {
+	case err := <-errs:
+		t.Fatalf("Expected the rollout to wait, got %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// the deployment controller scales the new ReplicaSet up and the old one down
+	replicaSets := clientset.AppsV1().ReplicaSets("demo")
+	for _, replicaSet := range []*appsv1.ReplicaSet{testReplicaSet("web-2", "2", 2, 2), testReplicaSet("web-1", "1", 0, 0)} {
+		if _, err := replicaSets.UpdateStatus(context.Background(), replicaSet, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("waitForRollout error: %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the rollout to be done")
+	}
+}
+
+func TestWaitForRolloutTimeout(t *testing.T) {
+	deployment := testApp().deployment()
+	deployment.Namespace = "demo"
+	deployment.UID = types.UID("web-uid")
+	deployment.Annotations = map[string]string{revisionAnnotation: "1"}
+	d := demo{clientset: fake.NewClientset(deployment, testReplicaSet("web-1", "1", 2, 1)), namespace: "demo"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := d.waitForRollout(ctx, deployment)
+	if err == nil || !strings.Contains(err.Error(), "rollout of deployment web") {
+		t.Errorf("Expected the rollout to time out, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+//go:embed templates/report.html
+var reportHTML string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ms":      formatMs,
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+}).Parse(reportHTML))
+
+// chart sizes of the timeline, in pixels
+const (
+	chartWidth  = 800
+	chartHeight = 200
+)
+
+// htmlReport is the report with its charts drawn
+type htmlReport struct {
+	*Report
+	Width, Height int
+	// Histogram are the bars of the histogram, their width a percentage of the
+	// largest bucket
+	Histogram []htmlBar
+	Statuses  []statusCount
+	// Requests are the bars of the requests sent every second, Failures the bars
+	// of the failed ones, Target the line of the target rate, over MaxRate
+	Requests, Failures []rect
+	Target             string
+	MaxRate            float64
+	// P50 and P99 are the lines of the latencies of every second, over MaxLatency
+	P50, P99   string
+	MaxLatency float64
+}
+
+type htmlBar struct {
+	UpTo  float64
+	Count int
+	Width float64
+}
+
+type statusCount struct {
+	Status string
+	Count  int
+}
+
+type rect struct {
+	X, Y, Width, Height float64
+}
+
+// writeHTML writes the report as an HTML page, with charts of the histogram and of
+// the timeline
+func (r *Report) writeHTML(w io.Writer) error {
+	h := htmlReport{Report: r, Width: chartWidth, Height: chartHeight}
+	largest := 0
+	for _, b := range r.Histogram {
+		largest = max(largest, b.Count)
+	}
+	for _, b := range r.Histogram {
+		h.Histogram = append(h.Histogram, htmlBar{UpTo: b.UpTo, Count: b.Count, Width: float64(b.Count) / float64(largest) * 100})
+	}
+	for status, count := range r.Statuses {
+		h.Statuses = append(h.Statuses, statusCount{Status: status, Count: count})
+	}
+	sort.Slice(h.Statuses, func(i, j int) bool { return h.Statuses[i].Status < h.Statuses[j].Status })
+
+	for _, s := range r.Timeline {
+		h.MaxRate = max(h.MaxRate, s.TargetRate, float64(s.Requests))
+		h.MaxLatency = max(h.MaxLatency, s.P99)
+	}
+	step := float64(chartWidth) / float64(max(len(r.Timeline), 1))
+	y := func(v, top float64) float64 {
+		if top == 0 {
+			return chartHeight
+		}
+		return chartHeight - v/top*chartHeight
+	}
+	var target, p50, p99 []string
+	for i, s := range r.Timeline {
+		x := float64(i) * step
+		h.Requests = append(h.Requests, rect{X: x + 1, Y: y(float64(s.Requests), h.MaxRate), Width: step - 2, Height: chartHeight - y(float64(s.Requests), h.MaxRate)})
+		h.Failures = append(h.Failures, rect{X: x + 1, Y: y(float64(s.Failures), h.MaxRate), Width: step - 2, Height: chartHeight - y(float64(s.Failures), h.MaxRate)})
+		// the target rate is a step line, the latencies are in the middle of the seconds
+		target = append(target, fmt.Sprintf("%.1f,%.1f %.1f,%.1f", x, y(s.TargetRate, h.MaxRate), x+step, y(s.TargetRate, h.MaxRate)))
+		p50 = append(p50, fmt.Sprintf("%.1f,%.1f", x+step/2, y(s.P50, h.MaxLatency)))
+		p99 = append(p99, fmt.Sprintf("%.1f,%.1f", x+step/2, y(s.P99, h.MaxLatency)))
+	}
+	h.Target, h.P50, h.P99 = strings.Join(target, " "), strings.Join(p50, " "), strings.Join(p99, " ")
+	return reportTemplate.Execute(w, h)
+}
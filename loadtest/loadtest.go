@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"assignment-2-rate-limiting/pkg/ratelimiter"
+)
+
+// loadTest sends requests to the targets, in turn, at the rate of the profile
+type loadTest struct {
+	targets     []string
+	method      string
+	header      http.Header
+	body        []byte
+	profile     profile
+	concurrency int
+	client      *http.Client
+}
+
+// result is the outcome of one request
+type result struct {
+	target int
+	// start is the time of the request since the start of the test
+	start    time.Duration
+	duration time.Duration
+	// status is the HTTP status code, or timeout or error without a response
+	status string
+	err    string
+	bytes  int64
+}
+
+// run sends the requests for the duration of the profile, or until ctx is done, and
+// returns the report of the requests done
+func (l *loadTest) run(ctx context.Context) *Report {
+	started := time.Now()
+	jobs := make(chan int)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < l.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range jobs {
+				r, ok := l.send(ctx, target, started)
+				if ok {
+					results <- r
+				}
+			}
+		}()
+	}
+
+	// a request is dropped rather than delayed when all the workers are busy, so
+	// the rate of the requests sent stays the one of the profile
+	dropped := 0
+	go func() {
+		defer close(jobs)
+		paceCtx, cancel := context.WithTimeout(ctx, l.profile.duration)
+		defer cancel()
+		bucket := ratelimiter.NewTokenBucket(l.profile.rateAt(0), 1)
+		for i := 0; ; i++ {
+			bucket.SetRate(l.profile.rateAt(time.Since(started)))
+			if bucket.Wait(paceCtx) != nil || paceCtx.Err() != nil {
+				return
+			}
+			select {
+			case jobs <- i % len(l.targets):
+			default:
+				dropped++
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var all []result
+	for r := range results {
+		all = append(all, r)
+	}
+	// dropped is final once jobs is closed, before the workers are done
+	return newReport(l, started, time.Since(started), all, dropped)
+}
+
+// send sends a request to a target. It returns false when ctx was done, like on
+// Ctrl-C, the request doesn't count then.
+func (l *loadTest) send(ctx context.Context, target int, started time.Time) (result, bool) {
+	r := result{target: target, start: time.Since(started)}
+	req, err := http.NewRequestWithContext(ctx, l.method, l.targets[target], bytes.NewReader(l.body))
+	if err != nil {
+		r.status, r.err = "error", err.Error()
+		return r, true
+	}
+	req.Header = l.header.Clone()
+
+	res, err := l.client.Do(req)
+	if err == nil {
+		r.bytes, err = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		r.status = strconv.Itoa(res.StatusCode)
+	}
+	r.duration = time.Since(started) - r.start
+	if ctx.Err() != nil {
+		return r, false
+	}
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		r.status, r.err = "timeout", err.Error()
+	case err != nil:
+		r.status, r.err = "error", err.Error()
+	}
+	return r, true
+}
@@ -0,0 +1,139 @@
+// Command loadtest sends requests to HTTP endpoints at a rate following a profile,
+// constant, in steps or with a spike, and reports the latencies and the status codes
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage: %s [flags] url...
+
+Sends requests to the urls, in turn, at the rate of the profile, then prints the
+latencies, their histogram and the status codes, and writes them to the -json and
+-html reports. Ctrl-C stops the test, the requests so far are reported.
+
+Profiles:
+  constant   -rate requests/s
+  step       from -rate/-steps to -rate requests/s, in -steps steps
+  spike      -rate requests/s, and -spike-rate for -spike-duration in the middle
+
+`
+
+func main() {
+	p := profile{}
+	flag.StringVar(&p.kind, "profile", "constant", "rate profile: constant, step or spike")
+	flag.DurationVar(&p.duration, "duration", 30*time.Second, "duration of the test")
+	flag.Float64Var(&p.rate, "rate", 50, "requests per second, of the last step with -profile step")
+	flag.IntVar(&p.steps, "steps", 5, "number of steps of -profile step")
+	flag.Float64Var(&p.spikeRate, "spike-rate", 0, "requests per second of the spike of -profile spike (default 5 times -rate)")
+	flag.DurationVar(&p.spikeDuration, "spike-duration", 0, "duration of the spike of -profile spike (default a fifth of -duration)")
+	concurrency := flag.Int("concurrency", 50, "maximum number of requests in flight, the requests beyond are dropped")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of a request")
+	method := flag.String("method", http.MethodGet, "method of the requests")
+	body := flag.String("body", "", "body of the requests")
+	header := http.Header{}
+	flag.Func("H", "header of the requests, like 'Authorization: Bearer token', repeatable", func(s string) error {
+		name, value, ok := strings.Cut(s, ":")
+		if !ok {
+			return fmt.Errorf("invalid header %q: 'Name: value' expected", s)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		return nil
+	})
+	jsonFile := flag.String("json", "", "file to write the JSON report to")
+	htmlFile := flag.String("html", "", "file to write the HTML report to")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if p.spikeRate == 0 {
+		p.spikeRate = 5 * p.rate
+	}
+	if p.spikeDuration == 0 {
+		p.spikeDuration = p.duration / 5
+	}
+	if err := validate(p, *concurrency, flag.Args()); err != nil {
+		fmt.Printf("Error: %s\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// the connections are kept for the next requests, like the clients of a service
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = *concurrency
+	l := &loadTest{
+		targets:     flag.Args(),
+		method:      *method,
+		header:      header,
+		body:        []byte(*body),
+		profile:     p,
+		concurrency: *concurrency,
+		client:      &http.Client{Transport: transport, Timeout: *timeout},
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report := l.run(ctx)
+
+	report.writeText(os.Stdout)
+	if err := writeReports(report, *jsonFile, *htmlFile); err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+// validate checks the profile, the concurrency and the urls
+func validate(p profile, concurrency int, urls []string) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if concurrency < 1 {
+		return fmt.Errorf("invalid concurrency %d", concurrency)
+	}
+	if len(urls) == 0 {
+		return fmt.Errorf("no url")
+	}
+	for _, u := range urls {
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return fmt.Errorf("invalid url %q", u)
+		}
+	}
+	return nil
+}
+
+// writeReports writes the JSON and HTML reports to their files, if set
+func writeReports(report *Report, jsonFile, htmlFile string) error {
+	for _, output := range []struct {
+		file  string
+		write func(f *os.File) error
+	}{
+		{jsonFile, func(f *os.File) error { return report.writeJSON(f) }},
+		{htmlFile, func(f *os.File) error { return report.writeHTML(f) }},
+	} {
+		if output.file == "" {
+			continue
+		}
+		f, err := os.Create(output.file)
+		if err != nil {
+			return err
+		}
+		err = output.write(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("write %s: %w", output.file, err)
+		}
+	}
+	return nil
+}
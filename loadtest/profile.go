@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// profile is the target rate of requests over the duration of a test
+type profile struct {
+	kind     string
+	duration time.Duration
+	// rate is the constant rate, the rate of the last step, or the rate around
+	// the spike
+	rate  float64
+	steps int
+	// spikeRate is the rate during the spikeDuration in the middle of the test
+	spikeRate     float64
+	spikeDuration time.Duration
+}
+
+func (p profile) validate() error {
+	switch {
+	case p.duration <= 0:
+		return fmt.Errorf("invalid duration %s", p.duration)
+	case p.rate <= 0:
+		return fmt.Errorf("invalid rate %g", p.rate)
+	}
+	switch p.kind {
+	case "constant":
+	case "step":
+		if p.steps < 1 {
+			return fmt.Errorf("invalid number of steps %d", p.steps)
+		}
+	case "spike":
+		if p.spikeRate <= 0 || p.spikeDuration <= 0 || p.spikeDuration >= p.duration {
+			return fmt.Errorf("invalid spike of %g requests/s for %s: a positive rate for less than the duration expected", p.spikeRate, p.spikeDuration)
+		}
+	default:
+		return fmt.Errorf("invalid profile %q: constant, step or spike expected", p.kind)
+	}
+	return nil
+}
+
+// rateAt returns the target rate after elapsed
+func (p profile) rateAt(elapsed time.Duration) float64 {
+	switch p.kind {
+	case "step":
+		// the rate rises from rate/steps to rate, in steps of the same duration
+		step := min(int(int64(elapsed)*int64(p.steps)/int64(p.duration)), p.steps-1)
+		return p.rate * float64(step+1) / float64(p.steps)
+	case "spike":
+		start := (p.duration - p.spikeDuration) / 2
+		if elapsed >= start && elapsed < start+p.spikeDuration {
+			return p.spikeRate
+		}
+	}
+	return p.rate
+}
+
+func (p profile) String() string {
+	switch p.kind {
+	case "step":
+		return fmt.Sprintf("%g to %g requests/s in %d steps over %s", p.rate/float64(p.steps), p.rate, p.steps, p.duration)
+	case "spike":
+		return fmt.Sprintf("%g requests/s over %s, %g requests/s for %s in the middle", p.rate, p.duration, p.spikeRate, p.spikeDuration)
+	default:
+		return fmt.Sprintf("%g requests/s over %s", p.rate, p.duration)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProfileRateAt(t *testing.T) {
+	step := profile{kind: "step", duration: 10 * time.Second, rate: 100, steps: 4}
+	spike := profile{kind: "spike", duration: 10 * time.Second, rate: 10, spikeRate: 50, spikeDuration: 2 * time.Second}
+	tests := []struct {
+		profile  profile
+		elapsed  time.Duration
+		expected float64
+	}{
+		{profile{kind: "constant", duration: time.Minute, rate: 20}, 30 * time.Second, 20},
+		{step, 0, 25},
+		{step, 2499 * time.Millisecond, 25},
+		{step, 2500 * time.Millisecond, 50},
+		{step, 9 * time.Second, 100},
+		// the pacing can go a bit past the duration
+		{step, 11 * time.Second, 100},
+		{spike, 3999 * time.Millisecond, 10},
+		{spike, 4 * time.Second, 50},
+		{spike, 5999 * time.Millisecond, 50},
+		{spike, 6 * time.Second, 10},
+	}
+	for _, test := range tests {
+		if rate := test.profile.rateAt(test.elapsed); rate != test.expected {
+			t.Errorf("%s after %s: expected %g requests/s, got %g", test.profile, test.elapsed, test.expected, rate)
+		}
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	valid := []profile{
+		{kind: "constant", duration: time.Second, rate: 1},
+		{kind: "step", duration: time.Second, rate: 1, steps: 1},
+		{kind: "spike", duration: time.Second, rate: 1, spikeRate: 5, spikeDuration: time.Second / 2},
+	}
+	for _, p := range valid {
+		if err := p.validate(); err != nil {
+			t.Errorf("%+v: unexpected error %s", p, err)
+		}
+	}
+	invalid := []profile{
+		{kind: "ramp", duration: time.Second, rate: 1},
+		{kind: "constant", rate: 1},
+		{kind: "constant", duration: time.Second},
+		{kind: "step", duration: time.Second, rate: 1},
+		{kind: "spike", duration: time.Second, rate: 1, spikeRate: 5, spikeDuration: time.Second},
+	}
+	for _, p := range invalid {
+		if err := p.validate(); err == nil {
+			t.Errorf("%+v: expected an error", p)
+		}
+	}
+}
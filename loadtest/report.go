@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report is the summary of a load test, the durations are in milliseconds
+type Report struct {
+	Targets  []string  `json:"targets"`
+	Profile  string    `json:"profile"`
+	Started  time.Time `json:"started"`
+	Duration float64   `json:"durationSeconds"`
+	Requests int       `json:"requests"`
+	// Dropped are the requests not sent because all the workers were busy
+	Dropped int `json:"dropped"`
+	// Rate is the number of requests sent per second
+	Rate float64 `json:"rate"`
+	// Success is the share of the requests with a status below 400
+	Success   float64  `json:"success"`
+	Bytes     int64    `json:"bytes"`
+	Latency   Latency  `json:"latency"`
+	Histogram []Bucket `json:"histogram"`
+	// Statuses counts the requests by status code, or timeout or error without a
+	// response
+	Statuses map[string]int `json:"statuses"`
+	// Errors are the most frequent errors without a response
+	Errors    []ErrorCount   `json:"errors,omitempty"`
+	PerTarget []TargetReport `json:"perTarget,omitempty"`
+	Timeline  []Second       `json:"timeline"`
+}
+
+// Latency are the statistics of the durations of the requests
+type Latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// Bucket counts the requests that took longer than the previous bucket, up to UpTo
+type Bucket struct {
+	UpTo  float64 `json:"upTo"`
+	Count int     `json:"count"`
+}
+
+// ErrorCount is an error and the number of requests that failed with it
+type ErrorCount struct {
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// TargetReport is the summary of the requests of a target, with several targets
+type TargetReport struct {
+	URL      string  `json:"url"`
+	Requests int     `json:"requests"`
+	Success  float64 `json:"success"`
+	P50      float64 `json:"p50"`
+	P99      float64 `json:"p99"`
+}
+
+// Second is the summary of the requests sent during a second of the test
+type Second struct {
+	Second     int     `json:"second"`
+	TargetRate float64 `json:"targetRate"`
+	Requests   int     `json:"requests"`
+	Failures   int     `json:"failures"`
+	P50        float64 `json:"p50"`
+	P99        float64 `json:"p99"`
+}
+
+// bounds are the upper bounds of the buckets of the histogram, in milliseconds
+var bounds = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000, 20000, 50000}
+
+// maxErrors is the number of errors of a report
+const maxErrors = 10
+
+// newReport summarizes the results of a load test
+func newReport(l *loadTest, started time.Time, elapsed time.Duration, results []result, dropped int) *Report {
+	r := &Report{
+		Targets:  l.targets,
+		Profile:  l.profile.kind + ", " + l.profile.String(),
+		Started:  started,
+		Duration: elapsed.Seconds(),
+		Requests: len(results),
+		Dropped:  dropped,
+		Rate:     float64(len(results)) / elapsed.Seconds(),
+		Statuses: map[string]int{},
+	}
+
+	messages := map[string]int{}
+	targets := make([][]result, len(l.targets))
+	seconds := make([][]result, int(math.Ceil(l.profile.duration.Seconds())))
+	for _, res := range results {
+		r.Statuses[res.status]++
+		r.Bytes += res.bytes
+		if res.err != "" {
+			messages[res.err]++
+		}
+		targets[res.target] = append(targets[res.target], res)
+		second := min(int(res.start/time.Second), len(seconds)-1)
+		seconds[second] = append(seconds[second], res)
+	}
+	r.Success = success(results)
+	durations := sortedDurations(results)
+	if len(durations) > 0 {
+		var sum time.Duration
+		for _, d := range durations {
+			sum += d
+		}
+		r.Latency = Latency{
+			Min:  ms(durations[0]),
+			Mean: ms(sum / time.Duration(len(durations))),
+			P50:  ms(percentile(durations, 50)),
+			P90:  ms(percentile(durations, 90)),
+			P95:  ms(percentile(durations, 95)),
+			P99:  ms(percentile(durations, 99)),
+			Max:  ms(durations[len(durations)-1]),
+		}
+		r.Histogram = histogram(durations)
+	}
+
+	for message, count := range messages {
+		r.Errors = append(r.Errors, ErrorCount{Error: message, Count: count})
+	}
+	sort.Slice(r.Errors, func(i, j int) bool {
+		if r.Errors[i].Count != r.Errors[j].Count {
+			return r.Errors[i].Count > r.Errors[j].Count
+		}
+		return r.Errors[i].Error < r.Errors[j].Error
+	})
+	r.Errors = r.Errors[:min(len(r.Errors), maxErrors)]
+
+	if len(l.targets) > 1 {
+		for i, target := range targets {
+			durations := sortedDurations(target)
+			r.PerTarget = append(r.PerTarget, TargetReport{
+				URL:      l.targets[i],
+				Requests: len(target),
+				Success:  success(target),
+				P50:      ms(percentile(durations, 50)),
+				P99:      ms(percentile(durations, 99)),
+			})
+		}
+	}
+	for i, second := range seconds {
+		durations := sortedDurations(second)
+		r.Timeline = append(r.Timeline, Second{
+			Second:     i,
+			TargetRate: l.profile.rateAt(time.Duration(i)*time.Second + time.Second/2),
+			Requests:   len(second),
+			Failures:   len(second) - int(math.Round(success(second)*float64(len(second)))),
+			P50:        ms(percentile(durations, 50)),
+			P99:        ms(percentile(durations, 99)),
+		})
+	}
+	return r
+}
+
+// ok reports whether the status of a request is below 400
+func (res result) ok() bool {
+	code, err := strconv.Atoi(res.status)
+	return err == nil && code < 400
+}
+
+// success returns the share of the results that are ok, 0 without results
+func success(results []result) float64 {
+	if len(results) == 0 {
+		return 0
+	}
+	n := 0
+	for _, res := range results {
+		if res.ok() {
+			n++
+		}
+	}
+	return float64(n) / float64(len(results))
+}
+
+func sortedDurations(results []result) []time.Duration {
+	durations := make([]time.Duration, len(results))
+	for i, res := range results {
+		durations[i] = res.duration
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// histogram counts the sorted durations in the buckets of bounds, from the one
+// of the fastest request, the last bucket ending at the slowest request
+func histogram(sorted []time.Duration) []Bucket {
+	slowest := ms(sorted[len(sorted)-1])
+	var buckets []Bucket
+	i := 0
+	for _, bound := range bounds {
+		if bound >= slowest {
+			break
+		}
+		n := 0
+		for ; i < len(sorted) && ms(sorted[i]) <= bound; i++ {
+			n++
+		}
+		if n > 0 || len(buckets) > 0 {
+			buckets = append(buckets, Bucket{UpTo: bound, Count: n})
+		}
+	}
+	return append(buckets, Bucket{UpTo: slowest, Count: len(sorted) - i})
+}
+
+func ms(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
+
+// formatMs formats milliseconds like a duration, e.g. 1.234ms or 1.5s
+func formatMs(v float64) string {
+	return time.Duration(v * float64(time.Millisecond)).Round(10 * time.Microsecond).String()
+}
+
+// writeText writes the report, hey-style
+func (r *Report) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Load test of %s: %s\n", strings.Join(r.Targets, ", "), r.Profile)
+	fmt.Fprintf(w, "%d requests in %s: %.1f requests/s, %.1f%% successful, %d dropped\n",
+		r.Requests, time.Duration(r.Duration*float64(time.Second)).Round(time.Millisecond), r.Rate, r.Success*100, r.Dropped)
+	if r.Requests == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nLatency:")
+	for _, stat := range []struct {
+		name  string
+		value float64
+	}{{"min", r.Latency.Min}, {"mean", r.Latency.Mean}, {"p50", r.Latency.P50}, {"p90", r.Latency.P90}, {"p95", r.Latency.P95}, {"p99", r.Latency.P99}, {"max", r.Latency.Max}} {
+		fmt.Fprintf(w, "  %-5s %s\n", stat.name, formatMs(stat.value))
+	}
+
+	fmt.Fprintln(w, "\nHistogram:")
+	largest := 0
+	for _, b := range r.Histogram {
+		largest = max(largest, b.Count)
+	}
+	for _, b := range r.Histogram {
+		bar := strings.Repeat("■", int(math.Ceil(float64(b.Count)/float64(largest)*40)))
+		fmt.Fprintf(w, "  ≤ %-9s %-7d %s\n", formatMs(b.UpTo), b.Count, bar)
+	}
+
+	codes := make([]string, 0, len(r.Statuses))
+	for code := range r.Statuses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	fmt.Fprintln(w, "\nStatus codes:")
+	for _, code := range codes {
+		fmt.Fprintf(w, "  %-8s %d\n", code, r.Statuses[code])
+	}
+
+	if len(r.PerTarget) > 0 {
+		fmt.Fprintln(w, "\nTargets:")
+		for _, t := range r.PerTarget {
+			fmt.Fprintf(w, "  %s: %d requests, %.1f%% successful, p50 %s, p99 %s\n", t.URL, t.Requests, t.Success*100, formatMs(t.P50), formatMs(t.P99))
+		}
+	}
+	if len(r.Errors) > 0 {
+		fmt.Fprintln(w, "\nErrors:")
+		for _, e := range r.Errors {
+			fmt.Fprintf(w, "  %-7d %s\n", e.Count, e.Error)
+		}
+	}
+}
+
+// writeJSON writes the report as indented JSON
+func (r *Report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewReport(t *testing.T) {
+	l := &loadTest{
+		targets: []string{"http://a", "http://b"},
+		profile: profile{kind: "step", duration: 2 * time.Second, rate: 20, steps: 2},
+	}
+	var results []result
+	for i := range 100 {
+		// 1ms to 100ms, the first second to a, the next one to b
+		res := result{target: i / 50, start: time.Duration(i) * 20 * time.Millisecond, duration: time.Duration(i+1) * time.Millisecond, status: "200"}
+		switch {
+		case i%10 == 9:
+			res.status = "503"
+		case i == 0:
+			res.status, res.err = "error", "connection refused"
+		}
+		results = append(results, res)
+	}
+	r := newReport(l, time.Now(), 2*time.Second, results, 3)
+
+	if r.Requests != 100 || r.Dropped != 3 || r.Rate != 50 || r.Success != 0.89 {
+		t.Errorf("Unexpected totals %+v", r)
+	}
+	if expected := (Latency{Min: 1, Mean: 50.5, P50: 50, P90: 90, P95: 95, P99: 99, Max: 100}); r.Latency != expected {
+		t.Errorf("Expected latencies %+v, got %+v", expected, r.Latency)
+	}
+	expected := []Bucket{{1, 1}, {2, 1}, {5, 3}, {10, 5}, {20, 10}, {50, 30}, {100, 50}}
+	if len(r.Histogram) != len(expected) {
+		t.Fatalf("Expected the histogram %v, got %v", expected, r.Histogram)
+	}
+	for i := range expected {
+		if r.Histogram[i] != expected[i] {
+			t.Errorf("Expected the histogram %v, got %v", expected, r.Histogram)
+			break
+		}
+	}
+	if r.Statuses["200"] != 89 || r.Statuses["503"] != 10 || r.Statuses["error"] != 1 {
+		t.Errorf("Unexpected statuses %v", r.Statuses)
+	}
+	if len(r.Errors) != 1 || r.Errors[0] != (ErrorCount{Error: "connection refused", Count: 1}) {
+		t.Errorf("Unexpected errors %v", r.Errors)
+	}
+	if len(r.PerTarget) != 2 || r.PerTarget[1].Requests != 50 || r.PerTarget[1].P50 != 75 {
+		t.Errorf("Unexpected targets %+v", r.PerTarget)
+	}
+	if len(r.Timeline) != 2 || r.Timeline[0].TargetRate != 10 || r.Timeline[1].TargetRate != 20 ||
+		r.Timeline[0].Requests != 50 || r.Timeline[0].Failures != 6 || r.Timeline[1].P99 != 100 {
+		t.Errorf("Unexpected timeline %+v", r.Timeline)
+	}
+
+	var text, html bytes.Buffer
+	r.writeText(&text)
+	for _, s := range []string{"100 requests in 2s: 50.0 requests/s, 89.0% successful, 3 dropped", "p99   99ms", "≤ 100ms", "503      10", "1       connection refused"} {
+		if !strings.Contains(text.String(), s) {
+			t.Errorf("Expected %q in the report:\n%s", s, text.String())
+		}
+	}
+	if err := r.writeHTML(&html); err != nil || strings.Count(html.String(), `<rect class="requests"`) != 2 {
+		t.Errorf("Expected an HTML report with a bar per second, got %v:\n%s", err, html.String())
+	}
+}
+
+func TestRun(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Method != http.MethodPost || r.Header.Get("X-Test") != "yes" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	l := &loadTest{
+		targets:     []string{server.URL},
+		method:      http.MethodPost,
+		header:      http.Header{"X-Test": {"yes"}},
+		profile:     profile{kind: "constant", duration: time.Second, rate: 20},
+		concurrency: 4,
+		client:      server.Client(),
+	}
+	r := l.run(context.Background())
+	// the first token is there at the start, then one every 50ms
+	if r.Requests < 18 || r.Requests > 21 || r.Requests != int(requests.Load()) || r.Success != 1 || r.Dropped != 0 {
+		b, _ := json.Marshal(r)
+		t.Errorf("Expected about 20 successful requests, got %d: %s", requests.Load(), b)
+	}
+}
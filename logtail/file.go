@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// fileFollower follows files like tail -F: the lines appended to a file are read
+// every poll interval, and a file that is rotated, replaced by a new one, or
+// truncated, is read again from its start
+type fileFollower struct {
+	// fromStart reads the lines already in the files, or else only the new ones
+	fromStart bool
+	poll      time.Duration
+	logger    *slog.Logger
+}
+
+// follow sends the lines of the file of s until ctx is done. A file that doesn't
+// exist yet is waited for.
+func (f *fileFollower) follow(ctx context.Context, s source, lines chan<- line) error {
+	fromStart := f.fromStart
+	for {
+		err := f.read(ctx, s, fromStart, lines)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			f.logger.Debug("Waiting for the file", "source", s.label, "file", s.target)
+		} else if err != nil {
+			return err
+		}
+		// a new file, after a rotation or when it didn't exist, is read from its start
+		fromStart = true
+		if !sleep(ctx, f.poll) {
+			return nil
+		}
+	}
+}
+
+// read reads the lines of the file, until it's rotated or ctx is done
+func (f *fileFollower) read(ctx context.Context, s source, fromStart bool, lines chan<- line) error {
+	file, err := os.Open(s.target)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var offset int64
+	if !fromStart {
+		if offset, err = file.Seek(0, io.SeekEnd); err != nil {
+			return err
+		}
+	}
+
+	reader := bufio.NewReader(file)
+	// partial is the end of the file without a newline yet, the beginning of the next
+	// line
+	var partial strings.Builder
+	rotated := false
+	for {
+		text, err := reader.ReadString('\n')
+		offset += int64(len(text))
+		if err == nil {
+			partial.WriteString(text)
+			if !send(ctx, lines, line{time: time.Now(), source: s.label, text: strings.TrimRight(partial.String(), "\r\n")}) {
+				return nil
+			}
+			partial.Reset()
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		partial.WriteString(text)
+		if rotated {
+			// the end of the old file is read, the new one is next
+			f.logger.Info("File rotated", "source", s.label, "file", s.target)
+			if partial.Len() > 0 {
+				send(ctx, lines, line{time: time.Now(), source: s.label, text: strings.TrimRight(partial.String(), "\r\n")})
+			}
+			return nil
+		}
+		if !sleep(ctx, f.poll) {
+			return nil
+		}
+
+		current, err := os.Stat(s.target)
+		if errors.Is(err, fs.ErrNotExist) {
+			// rotated, the new file isn't created yet
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		opened, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		if !os.SameFile(opened, current) {
+			// the lines written to the old file before the rotation are read first
+			rotated = true
+			continue
+		}
+		if current.Size() < offset {
+			f.logger.Info("File truncated", "source", s.label, "file", s.target)
+			if offset, err = file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			reader.Reset(file)
+			partial.Reset()
+		}
+	}
+}
+
+// send sends l, and returns false when ctx is done first
+func send(ctx context.Context, lines chan<- line, l line) bool {
+	select {
+	case lines <- l:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sleep waits for d, and returns false when ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// receive returns the texts of the next n lines, failing after a second
+func receive(t *testing.T, lines <-chan line, n int) []string {
+	t.Helper()
+	var texts []string
+	timeout := time.After(time.Second)
+	for len(texts) < n {
+		select {
+		case l := <-lines:
+			texts = append(texts, l.text)
+		case <-timeout:
+			t.Fatalf("Expected %d lines, got %q", n, texts)
+		}
+	}
+	return texts
+}
+
+func appendFile(t *testing.T, path, text string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollowFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "before\n")
+	follower := &fileFollower{poll: 5 * time.Millisecond, logger: testLogger}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines := make(chan line)
+	go follower.follow(ctx, source{label: "app", target: path}, lines)
+	time.Sleep(20 * time.Millisecond)
+
+	// the lines already there are skipped, a line is sent once it's complete
+	appendFile(t, path, "first\nsec")
+	time.Sleep(20 * time.Millisecond)
+	appendFile(t, path, "ond\r\n")
+	if texts := receive(t, lines, 2); texts[0] != "first" || texts[1] != "second" {
+		t.Errorf("Expected the new lines, got %q", texts)
+	}
+
+	// truncated, like with logrotate copytruncate
+	os.WriteFile(path, []byte("a\n"), 0o644)
+	if texts := receive(t, lines, 1); texts[0] != "a" {
+		t.Errorf("Expected the line of the truncated file, got %q", texts)
+	}
+
+	// rotated: the end of the old file, then the new one from its start
+	appendFile(t, path, "last of the old file\n")
+	os.Rename(path, path+".1")
+	appendFile(t, path, "new file\n")
+	if texts := receive(t, lines, 2); texts[0] != "last of the old file" || texts[1] != "new file" {
+		t.Errorf("Expected the lines of both files, got %q", texts)
+	}
+}
+
+func TestFollowFileFromStart(t *testing.T) {
+	// a file that doesn't exist yet is waited for
+	path := filepath.Join(t.TempDir(), "app.log")
+	follower := &fileFollower{fromStart: true, poll: 5 * time.Millisecond, logger: testLogger}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines := make(chan line)
+	done := make(chan error)
+	go func() { done <- follower.follow(ctx, source{label: "app", target: path}, lines) }()
+	time.Sleep(20 * time.Millisecond)
+	appendFile(t, path, "one\ntwo\n")
+	if texts := receive(t, lines, 2); texts[0] != "one" || texts[1] != "two" {
+		t.Errorf("Expected the lines of the file, got %q", texts)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error after cancel, got %s", err)
+	}
+}
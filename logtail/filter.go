@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// filter keeps the lines matching match and not matching exclude, and extracts the
+// fields of the JSON lines
+type filter struct {
+	match   *regexp.Regexp
+	exclude *regexp.Regexp
+	// fields are the names of the fields to extract, a.b for the field b of the
+	// object a
+	fields []string
+}
+
+// apply reports whether l passes the filter, and sets its fields
+func (f *filter) apply(l *line) bool {
+	if f.match != nil && !f.match.MatchString(l.text) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(l.text) {
+		return false
+	}
+	if len(f.fields) > 0 {
+		l.fields = extract(l.text, f.fields)
+	}
+	return true
+}
+
+// extract returns the fields of names in text, when it's a JSON object, none
+// otherwise. The missing fields are left out.
+func extract(text string, names []string) []field {
+	if !strings.HasPrefix(strings.TrimSpace(text), "{") {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+	// the numbers are printed as they are, like large ids
+	decoder.UseNumber()
+	var object map[string]any
+	if decoder.Decode(&object) != nil {
+		return nil
+	}
+	var fields []field
+	for _, name := range names {
+		if value, ok := lookup(object, name); ok {
+			fields = append(fields, field{name: name, value: value})
+		}
+	}
+	return fields
+}
+
+// lookup returns the value of a field of object, a.b being the field b of the
+// object a, unless object has a field a.b
+func lookup(object map[string]any, name string) (any, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
+	}
+	for i := range name {
+		if name[i] != '.' {
+			continue
+		}
+		if nested, ok := object[name[:i]].(map[string]any); ok {
+			if value, ok := lookup(nested, name[i+1:]); ok {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
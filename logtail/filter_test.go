@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"notify"
+)
+
+func TestFilter(t *testing.T) {
+	f := &filter{
+		match:   regexp.MustCompile(`(?i)error|warn`),
+		exclude: regexp.MustCompile(`healthz`),
+		fields:  []string{"level", "msg", "http.status", "error.detail", "missing"},
+	}
+	tests := []struct {
+		text     string
+		pass     bool
+		expected string
+	}{
+		{text: `{"level":"ERROR","msg":"Request failed","http":{"status":503},"error.detail":{"code":7}}`, pass: true, expected: `level=ERROR msg="Request failed" http.status=503 error.detail={"code":7}`},
+		{text: `{"level":"WARN","msg":"","id":12345678901234567890}`, pass: true, expected: `level=WARN msg=""`},
+		{text: `time=2026-10-15T15:21:09Z level=ERROR msg=oops`, pass: true, expected: `time=2026-10-15T15:21:09Z level=ERROR msg=oops`},
+		{text: `{"level":"INFO","msg":"ok"}`},
+		{text: `{"level":"ERROR","msg":"GET /healthz"}`},
+	}
+	for _, test := range tests {
+		l := line{text: test.text}
+		if pass := f.apply(&l); pass != test.pass {
+			t.Errorf("%s: expected %t, got %t", test.text, test.pass, pass)
+		} else if pass && l.display() != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.text, test.expected, l.display())
+		}
+	}
+}
+
+func TestPrintJSON(t *testing.T) {
+	var out strings.Builder
+	p := &printer{out: &out, format: "json"}
+	at := time.Date(2026, 10, 15, 15, 21, 9, 0, time.UTC)
+	p.print(line{time: at, source: "app", text: "plain"})
+	p.print(line{time: at, source: "app", text: `{"level":"ERROR"}`, fields: []field{{name: "level", value: "ERROR"}}})
+	expected := `{"time":"2026-10-15T15:21:09Z","source":"app","line":"plain"}
+{"time":"2026-10-15T15:21:09Z","source":"app","fields":{"level":"ERROR"}}
+`
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestForwarder(t *testing.T) {
+	var mu sync.Mutex
+	var messages []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message map[string]any
+		json.NewDecoder(r.Body).Decode(&message)
+		mu.Lock()
+		messages = append(messages, message)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	webhook := notify.NewWebhook(server.URL, notify.Options{Interval: time.Millisecond})
+	f := newForwarder(webhook, regexp.MustCompile("ERROR"), time.Hour, testLogger)
+	done := make(chan struct{})
+	go func() {
+		f.run(context.Background())
+		close(done)
+	}()
+	for i := range 25 {
+		f.add(line{time: time.Now(), source: "app", text: "ERROR " + strings.Repeat("x", i)})
+		f.add(line{time: time.Now(), source: "app", text: "INFO"})
+	}
+	// the batch is sent when the forwarder is closed, before the interval
+	f.close()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(messages) != 1 {
+		t.Fatalf("Expected a message, got %v", messages)
+	}
+	text, _ := messages[0]["text"].(string)
+	if messages[0]["title"] != "25 log lines matched ERROR" || strings.Count(text, "[app] ERROR") != forwardShown || !strings.HasSuffix(text, "... and 5 more\n") {
+		t.Errorf("Unexpected message %v", messages[0])
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	for spec, expected := range map[string]string{
+		"/var/log/app.log":                   "app.log",
+		"web=/var/log/nginx/access.log":      "web",
+		"http://localhost:8080/events":       "localhost:8080/events",
+		"server=http://localhost:8080/words": "server",
+		"./a=b.log":                          "a=b.log",
+	} {
+		s, err := parseSource(spec, &fileFollower{}, &streamFollower{})
+		if err != nil || s.label != expected {
+			t.Errorf("%s: expected the label %s, got %+v, %v", spec, expected, s, err)
+		}
+	}
+}
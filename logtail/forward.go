@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"notify"
+)
+
+// forwardTemplate is the message of a batch of lines
+var forwardTemplate = notify.MustTemplate(
+	"{{.Total}} log lines matched{{with .Pattern}} {{.}}{{end}}",
+	"{{range .Lines}}[{{.Source}}] {{.Text}}\n{{end}}{{with .More}}... and {{.}} more\n{{end}}",
+)
+
+const (
+	// forwardBuffer is the number of lines waiting to be forwarded, the next ones are
+	// dropped
+	forwardBuffer = 1000
+	// forwardShown is the number of lines in a message, the others are counted
+	forwardShown = 20
+)
+
+// forwarder sends the lines matching match to a notifier, in batches, at most one
+// message every interval, so a burst of errors doesn't flood the channel
+type forwarder struct {
+	notifier notify.Notifier
+	match    *regexp.Regexp
+	interval time.Duration
+	logger   *slog.Logger
+
+	lines   chan line
+	dropped atomic.Int64
+}
+
+func newForwarder(notifier notify.Notifier, match *regexp.Regexp, interval time.Duration, logger *slog.Logger) *forwarder {
+	return &forwarder{notifier: notifier, match: match, interval: interval, logger: logger, lines: make(chan line, forwardBuffer)}
+}
+
+// add queues a line when it matches, without waiting for the webhook
+func (f *forwarder) add(l line) {
+	if f.match != nil && !f.match.MatchString(l.text) {
+		return
+	}
+	select {
+	case f.lines <- l:
+	default:
+		f.dropped.Add(1)
+	}
+}
+
+// close sends the last batch, run returns once it's sent
+func (f *forwarder) close() {
+	close(f.lines)
+}
+
+// run sends the queued lines every interval, until close
+func (f *forwarder) run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	var batch []line
+	for {
+		select {
+		case l, ok := <-f.lines:
+			if !ok {
+				// the last lines are sent after Ctrl-C too
+				ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+				defer cancel()
+				f.send(ctx, batch)
+				return
+			}
+			batch = append(batch, l)
+		case <-ticker.C:
+			f.send(ctx, batch)
+			batch = nil
+		}
+	}
+}
+
+// batchData is the data of forwardTemplate
+type batchData struct {
+	Total   int
+	Pattern string
+	Lines   []struct{ Source, Text string }
+	More    int
+}
+
+// send sends a message of the lines of a batch, the errors are logged
+func (f *forwarder) send(ctx context.Context, batch []line) {
+	if len(batch) == 0 {
+		return
+	}
+	data := batchData{Total: len(batch), More: max(len(batch)-forwardShown, 0)}
+	if f.match != nil {
+		data.Pattern = f.match.String()
+	}
+	sources := map[string]bool{}
+	var labels []string
+	for i, l := range batch {
+		if i < forwardShown {
+			data.Lines = append(data.Lines, struct{ Source, Text string }{l.source, l.text})
+		}
+		if !sources[l.source] {
+			sources[l.source] = true
+			labels = append(labels, l.source)
+		}
+	}
+	fields := []notify.Field{
+		{Name: "Sources", Value: strings.Join(labels, ", ")},
+		{Name: "From", Value: batch[0].time.Format(time.RFC3339)},
+	}
+	if dropped := f.dropped.Swap(0); dropped > 0 {
+		fields = append(fields, notify.Field{Name: "Dropped", Value: strconv.FormatInt(dropped, 10)})
+	}
+	message, err := forwardTemplate.Message(notify.SeverityWarning, data, fields...)
+	if err == nil {
+		err = f.notifier.Notify(ctx, message)
+	}
+	if err != nil {
+		f.logger.Error("Failed to forward the lines", "lines", len(batch), "error", err)
+	}
+}
@@ -0,0 +1,20 @@
+module logtail
+
+go 1.24.2
+
+require (
+	logging v0.0.0-00010101000000-000000000000
+	notify v0.0.0-00010101000000-000000000000
+	secrets v0.0.0-00010101000000-000000000000
+)
+
+require (
+	go.opentelemetry.io/otel v1.35.0 // indirect
+	go.opentelemetry.io/otel/trace v1.35.0 // indirect
+)
+
+replace logging => ../pkg/logging
+
+replace notify => ../pkg/notify
+
+replace secrets => ../pkg/secrets
@@ -0,0 +1,184 @@
+// Command logtail follows log files and HTTP log streams at the same time, and
+// merges their lines, labeled by source, filtered with regular expressions, with
+// the fields of the JSON lines extracted, and forwarded to a webhook
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"logging"
+	"notify"
+	"secrets"
+)
+
+const usage = `Usage: %s [flags] [label=]source...
+
+Follows the sources, files like tail -F or http(s) urls of server-sent events or
+line streams, and prints their lines as they come:
+
+  %s app=/var/log/app.log http://localhost:8080/events
+
+The label of a line is the one of its source, the name of the file or the host and
+the path of the url by default. With -webhook, or NOTIFY_SLACK_WEBHOOK_URL,
+NOTIFY_TEAMS_WEBHOOK_URL or NOTIFY_WEBHOOK_URL, the lines printed, or the ones
+matching -forward-match, are forwarded in batches.
+
+`
+
+func main() {
+	files := &fileFollower{}
+	streams := &streamFollower{header: http.Header{}}
+	flag.BoolVar(&files.fromStart, "from-start", false, "print the lines already in the files, not only the new ones")
+	flag.DurationVar(&files.poll, "poll", 250*time.Millisecond, "interval between the reads of the files")
+	flag.DurationVar(&streams.retry, "retry", time.Second, "wait before reconnecting to a stream, unless the server sends another one")
+	flag.Func("H", "header of the requests to the streams, like 'Authorization: Bearer token', repeatable", func(s string) error {
+		name, value, ok := strings.Cut(s, ":")
+		if !ok {
+			return fmt.Errorf("invalid header %q: 'Name: value' expected", s)
+		}
+		streams.header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		return nil
+	})
+	f := &filter{}
+	flag.Func("match", "regular expression of the lines to print", regexpFlag(&f.match))
+	flag.Func("exclude", "regular expression of the lines not to print", regexpFlag(&f.exclude))
+	flag.Func("fields", "comma-separated fields of the JSON lines to print instead of the lines, like level,msg,http.status", func(s string) error {
+		f.fields = strings.Split(s, ",")
+		return nil
+	})
+	output := flag.String("output", "text", "output format: text or json")
+	webhook := flag.String("webhook", "", "url to POST the lines to as JSON, see pkg/notify")
+	var forwardMatch *regexp.Regexp
+	flag.Func("forward-match", "regular expression of the printed lines to forward, all by default", regexpFlag(&forwardMatch))
+	forwardInterval := flag.Duration("forward-interval", 10*time.Second, "interval between the batches of forwarded lines")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || (*output != "text" && *output != "json") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// the errors go to stderr, the lines to stdout
+	logger, err := logging.Setup()
+	if err != nil {
+		logger.Error("Invalid logging configuration", "error", err)
+		os.Exit(2)
+	}
+	files.logger = logger
+	streams.logger = logger
+	streams.client = &http.Client{}
+	var sources []source
+	p := &printer{out: os.Stdout, format: *output}
+	for _, arg := range flag.Args() {
+		s, err := parseSource(arg, files, streams)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(2)
+		}
+		sources = append(sources, s)
+		p.width = max(p.width, len(s.label))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	store, err := secrets.FromEnv("NOTIFY_")
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(2)
+	}
+	notifiers, err := notify.FromSecrets(ctx, store, notify.Options{})
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(2)
+	}
+	if *webhook != "" {
+		notifiers = append(notifiers, notify.NewWebhook(*webhook, notify.Options{}))
+	}
+	var fwd *forwarder
+	var forwarding sync.WaitGroup
+	if len(notifiers) > 0 {
+		fwd = newForwarder(notifiers, forwardMatch, *forwardInterval, logger)
+		forwarding.Add(1)
+		go func() {
+			defer forwarding.Done()
+			fwd.run(ctx)
+		}()
+	}
+
+	failed := tail(ctx, sources, f, p, fwd, logger)
+	if fwd != nil {
+		fwd.close()
+		forwarding.Wait()
+	}
+	if failed > 0 && ctx.Err() == nil {
+		fmt.Printf("Error: %d of %d sources failed\n", failed, len(sources))
+		stop()
+		os.Exit(1)
+	}
+}
+
+// tail follows the sources until ctx is done or they all stopped, and prints and
+// forwards the lines passing the filter. It returns the number of sources that
+// failed.
+func tail(ctx context.Context, sources []source, f *filter, p *printer, fwd *forwarder, logger *slog.Logger) int {
+	lines := make(chan line, 100)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	for _, s := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.follow(ctx, s, lines); err != nil {
+				logger.Error("Source stopped", "source", s.label, "error", err)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	// the lines of all the sources are printed by this goroutine, one at a time
+	for l := range lines {
+		if !f.apply(&l) {
+			continue
+		}
+		if err := p.print(l); err != nil {
+			// like a closed pipe
+			return 0
+		}
+		if fwd != nil {
+			fwd.add(l)
+		}
+	}
+	return failed
+}
+
+// regexpFlag parses the value of a flag into re
+func regexpFlag(re **regexp.Regexp) func(s string) error {
+	return func(s string) error {
+		var err error
+		*re, err = regexp.Compile(s)
+		return err
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// printer writes the merged lines of the sources, as text or JSON
+type printer struct {
+	out    io.Writer
+	format string
+	// width is the width of the longest label, to align the lines
+	width int
+}
+
+// jsonLine is a line of the JSON output
+type jsonLine struct {
+	Time   time.Time      `json:"time"`
+	Source string         `json:"source"`
+	Line   string         `json:"line,omitempty"`
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// print writes a line: the time it was read, the label of its source, and the
+// line, or its fields when some were extracted
+func (p *printer) print(l line) error {
+	if p.format == "json" {
+		out := jsonLine{Time: l.time, Source: l.source, Line: l.text}
+		if len(l.fields) > 0 {
+			out.Line, out.Fields = "", map[string]any{}
+			for _, f := range l.fields {
+				out.Fields[f.name] = f.value
+			}
+		}
+		b, err := json.Marshal(out)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.out, "%s\n", b)
+		return err
+	}
+	_, err := fmt.Fprintf(p.out, "%s %-*s | %s\n", l.time.Format("15:04:05.000"), p.width, l.source, l.display())
+	return err
+}
+
+// display returns the line, or its fields as name=value, logfmt-style
+func (l line) display() string {
+	if len(l.fields) == 0 {
+		return l.text
+	}
+	parts := make([]string, len(l.fields))
+	for i, f := range l.fields {
+		parts[i] = f.name + "=" + formatValue(f.value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatValue formats a JSON value, quoting the strings with spaces or quotes
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		if v == "" || strings.ContainsAny(v, " \"=\t") {
+			return strconv.Quote(v)
+		}
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return "null"
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
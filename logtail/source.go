@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// line is a line of a source, with the time it was read
+type line struct {
+	time   time.Time
+	source string
+	text   string
+	// fields are the fields extracted from a JSON line, in the order of -fields
+	fields []field
+}
+
+type field struct {
+	name  string
+	value any
+}
+
+// source is a file or an HTTP stream, and its label
+type source struct {
+	label  string
+	target string
+	// follow sends the lines of the source until ctx is done
+	follow func(ctx context.Context, s source, lines chan<- line) error
+}
+
+// parseSource parses label=target, where target is a file or an http(s) url. The
+// label is the name of the file, or the host and the path of the url, by default.
+func parseSource(spec string, f *fileFollower, s *streamFollower) (source, error) {
+	label, target, ok := strings.Cut(spec, "=")
+	if !ok || strings.Contains(label, "/") || strings.Contains(label, ":") {
+		label, target = "", spec
+	}
+	if target == "" {
+		return source{}, fmt.Errorf("invalid source %q", spec)
+	}
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return source{}, fmt.Errorf("invalid source %q: %s", spec, err)
+		}
+		if label == "" {
+			label = u.Host + u.Path
+		}
+		return source{label: label, target: target, follow: s.follow}, nil
+	}
+	if label == "" {
+		label = filepath.Base(target)
+	}
+	return source{label: label, target: target, follow: f.follow}, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxReconnectDelay is the longest wait before a reconnect to a stream failing
+// again and again
+const maxReconnectDelay = time.Minute
+
+// errStopped is returned by a stream that won't be reconnected, like after a 404
+var errStopped = errors.New("stream stopped")
+
+// streamFollower follows HTTP streams: server-sent events, every line of data
+// being a line, or any other body, like newline delimited JSON, line by line. A
+// dropped connection is reconnected, the server-sent events from the last event id.
+type streamFollower struct {
+	client *http.Client
+	header http.Header
+	// retry is the wait before a reconnect, unless the server sends another one, and
+	// doubled while the reconnects fail
+	retry  time.Duration
+	logger *slog.Logger
+}
+
+// stream is the state of a stream kept across the reconnects
+type stream struct {
+	lastEventID string
+	retry       time.Duration
+}
+
+// follow sends the lines of the stream of s until ctx is done, or the server
+// answers with a status that won't change, like a 404
+func (f *streamFollower) follow(ctx context.Context, s source, lines chan<- line) error {
+	st := &stream{retry: f.retry}
+	wait := st.retry
+	for {
+		received, err := f.read(ctx, s, st, lines)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, errStopped) {
+			return err
+		}
+		if received {
+			wait = st.retry
+		}
+		if err == nil {
+			err = errors.New("end of the stream")
+		}
+		f.logger.Warn("Stream disconnected, reconnecting", "source", s.label, "error", err, "in", wait)
+		if !sleep(ctx, wait) {
+			return nil
+		}
+		if !received {
+			wait = min(2*wait, maxReconnectDelay)
+		}
+	}
+}
+
+// read connects once and sends the lines until the stream ends. It reports whether
+// lines were received.
+func (f *streamFollower) read(ctx context.Context, s source, st *stream, lines chan<- line) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.target, nil)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", errStopped, err)
+	}
+	for name, values := range f.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "text/event-stream, */*")
+	if st.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", st.lastEventID)
+	}
+	res, err := f.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusOK:
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return false, fmt.Errorf("HTTP %s", res.Status)
+	default:
+		// like a 404 for a wrong url, or a 204 for a server telling to stop
+		return false, fmt.Errorf("%w: HTTP %s", errStopped, res.Status)
+	}
+	f.logger.Info("Stream connected", "source", s.label)
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	events := mediaType == "text/event-stream"
+	received := false
+	// eventID is the id of the event being read, the last event id once it's read
+	eventID := ""
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if events {
+			// the fields are "name: value", the lines starting with a colon are
+			// comments, and an empty line ends an event
+			field, value, _ := strings.Cut(text, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "data":
+				text = value
+			case "id":
+				eventID = value
+				continue
+			case "":
+				if text == "" && eventID != "" {
+					st.lastEventID = eventID
+				}
+				continue
+			case "retry":
+				if ms, err := strconv.Atoi(value); err == nil {
+					st.retry = time.Duration(ms) * time.Millisecond
+				}
+				continue
+			default:
+				continue
+			}
+		}
+		if !send(ctx, lines, line{time: time.Now(), source: s.label, text: text}) {
+			return received, nil
+		}
+		received = true
+	}
+	return received, scanner.Err()
+}
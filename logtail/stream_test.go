@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFollowEvents(t *testing.T) {
+	// the first connection sends two events and drops, the second one gets the
+	// events after the Last-Event-ID
+	var mu sync.Mutex
+	var lastEventIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		reconnect := len(lastEventIDs) > 1
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		if !reconnect {
+			fmt.Fprint(w, "retry: 10\n\n: keep-alive\n\nid: 1\nevent: log\ndata: first\n\nid: 2\ndata: second\ndata: third\n\n")
+			return
+		}
+		fmt.Fprint(w, "id: 3\ndata: fourth\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	follower := &streamFollower{client: server.Client(), retry: time.Hour, logger: testLogger}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines := make(chan line)
+	go follower.follow(ctx, source{label: "events", target: server.URL}, lines)
+	texts := receive(t, lines, 4)
+	if fmt.Sprint(texts) != "[first second third fourth]" {
+		t.Errorf("Expected the data of the events, got %q", texts)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lastEventIDs) != 2 || lastEventIDs[1] != "2" {
+		t.Errorf("Expected a reconnect from event 2 after the retry of the server, got %q", lastEventIDs)
+	}
+}
+
+func TestFollowLines(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprint(w, "{\"word\":\"a\"}\n{\"word\":\"b\"}\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	follower := &streamFollower{client: server.Client(), header: http.Header{"Authorization": {"Bearer token"}}, retry: time.Hour, logger: testLogger}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lines := make(chan line)
+	go follower.follow(ctx, source{label: "words", target: server.URL}, lines)
+	if texts := receive(t, lines, 2); texts[0] != `{"word":"a"}` || texts[1] != `{"word":"b"}` {
+		t.Errorf("Expected the lines of the body, got %q", texts)
+	}
+
+	// without the header, the 401 won't change, the stream stops
+	follower = &streamFollower{client: server.Client(), retry: time.Hour, logger: testLogger}
+	err := follower.follow(ctx, source{label: "words", target: server.URL}, lines)
+	if !errors.Is(err, errStopped) {
+		t.Errorf("Expected the stream to stop, got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// serveTCPEcho sends back what every client sends, until the client closes the
+// connection or is idle for idleTimeout. It returns when ctx is cancelled, after
+// closing the connections.
+func serveTCPEcho(ctx context.Context, listener net.Listener, idleTimeout time.Duration, logger *log.Logger) error {
+	var (
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+		wg    sync.WaitGroup
+	)
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for conn := range conns {
+			conn.Close()
+		}
+	}()
+	defer wg.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close()
+			}()
+			n, err := echo(conn, idleTimeout)
+			logger.Printf("tcp %s: echoed %d bytes (%s)", conn.RemoteAddr(), n, closeReason(err))
+		}()
+	}
+}
+
+// echo copies what's read from the connection back to it, with a deadline before
+// every read
+func echo(conn net.Conn, idleTimeout time.Duration) (int64, error) {
+	buf := make([]byte, 32*1024)
+	var total int64
+	for {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		n, err := conn.Read(buf)
+		if n > 0 {
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return total, err
+			}
+			total += int64(n)
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// closeReason describes why an echo connection ended
+func closeReason(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "idle timeout"
+	case errors.Is(err, net.ErrClosed):
+		return "server shutdown"
+	case errors.Is(err, io.EOF):
+		return "closed by the client"
+	}
+	return err.Error()
+}
+
+// serveUDPEcho sends every datagram back to its sender, until ctx is cancelled
+func serveUDPEcho(ctx context.Context, conn net.PacketConn, logger *log.Logger) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	// the largest UDP payload
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if _, err := conn.WriteTo(buf[:n], addr); err != nil {
+			logger.Printf("udp %s: %s", addr, err)
+			continue
+		}
+		logger.Printf("udp %s: echoed %d bytes", addr, n)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPEcho(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- serveTCPEcho(ctx, listener, time.Minute, log.New(io.Discard, "", 0))
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for _, line := range []string{"hello\n", "world\n"} {
+		if _, err := conn.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != line {
+			t.Errorf("got %q, want %q", got, line)
+		}
+	}
+
+	// the shutdown closes the connection of the client
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("serveTCPEcho error: %s", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Errorf("got %v after the shutdown, want EOF", err)
+	}
+}
+
+func TestTCPEchoIdleTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go serveTCPEcho(ctx, listener, 50*time.Millisecond, log.New(io.Discard, "", 0))
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("got %v, want EOF after the idle timeout", err)
+	}
+}
+
+func TestUDPEcho(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- serveUDPEcho(ctx, server, log.New(io.Discard, "", 0))
+	}()
+
+	conn, err := net.Dial("udp", server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 64)
+	for _, datagram := range []string{"ping", "pong"} {
+		if _, err := conn.Write([]byte(datagram)); err != nil {
+			t.Fatal(err)
+		}
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != datagram {
+			t.Errorf("got %q, want %q", got, datagram)
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("serveUDPEcho error: %s", err)
+	}
+}
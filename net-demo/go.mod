@@ -0,0 +1,3 @@
+module net-demo
+
+go 1.24.2
@@ -0,0 +1,127 @@
+// Command net-demo uses the net package directly: TCP and UDP echo servers, and a
+// concurrent port scanner
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage: %s [flags] command [args]
+
+Commands:
+  tcp-echo                     echo what TCP clients send on -listen
+  udp-echo                     echo the UDP datagrams sent to -listen
+  scan host|ip|cidr...         connect to the -ports of every host, and list the open ones
+  check host:port...           connect to every address, exits with 1 when one isn't reachable
+
+`
+
+func main() {
+	listen := flag.String("listen", ":7007", "address of the echo servers")
+	idleTimeout := flag.Duration("idle-timeout", time.Minute, "close the TCP echo connections idle this long")
+	ports := flag.String("ports", "22,80,443", "ports to scan: a list of ports and ranges, like 22,80,8000-8100")
+	timeout := flag.Duration("timeout", 2*time.Second, "timeout of a connection, after which the port is filtered")
+	concurrency := flag.Int("concurrency", 100, "number of connections at the same time")
+	all := flag.Bool("all", false, "list the closed and filtered ports of a scan as well")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || *concurrency < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s := scanner{timeout: *timeout, concurrency: *concurrency}
+	var err error
+	switch command, args := flag.Arg(0), flag.Args()[1:]; command {
+	case "tcp-echo":
+		err = runTCPEcho(ctx, *listen, *idleTimeout)
+	case "udp-echo":
+		err = runUDPEcho(ctx, *listen)
+	case "scan":
+		err = runScan(ctx, s, args, *ports, *all)
+	case "check":
+		var ok bool
+		ok, err = runCheck(ctx, s, args)
+		if err == nil && !ok {
+			stop()
+			os.Exit(1)
+		}
+	default:
+		flag.Usage()
+		stop()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+func runTCPEcho(ctx context.Context, listen string, idleTimeout time.Duration) error {
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	log.Printf("TCP echo server listening on %s", listener.Addr())
+	return serveTCPEcho(ctx, listener, idleTimeout, log.Default())
+}
+
+func runUDPEcho(ctx context.Context, listen string) error {
+	conn, err := net.ListenPacket("udp", listen)
+	if err != nil {
+		return err
+	}
+	log.Printf("UDP echo server listening on %s", conn.LocalAddr())
+	return serveUDPEcho(ctx, conn, log.Default())
+}
+
+func runScan(ctx context.Context, s scanner, args []string, portList string, all bool) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no hosts to scan")
+	}
+	hosts, err := expandHosts(args)
+	if err != nil {
+		return err
+	}
+	ports, err := parsePorts(portList)
+	if err != nil {
+		return err
+	}
+	return writeResults(os.Stdout, s.scan(ctx, targetsOf(hosts, ports)), all)
+}
+
+// runCheck returns whether every address is reachable
+func runCheck(ctx context.Context, s scanner, args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, fmt.Errorf("no addresses to check")
+	}
+	targets, err := parseTargets(args)
+	if err != nil {
+		return false, err
+	}
+	results := s.scan(ctx, targets)
+	if err := writeResults(os.Stdout, results, true); err != nil {
+		return false, err
+	}
+	for _, r := range results {
+		if r.State != stateOpen {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// writeResults writes a line per result in aligned columns, only the open ports
+// unless all is set, and the errors after them
+func writeResults(w io.Writer, results []result, all bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tPORT\tSTATE\tLATENCY")
+	for _, r := range results {
+		if !all && r.State != stateOpen {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", r.Host, r.Port, r.State, r.Latency.Round(time.Microsecond))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(w, "%s: %s\n", r.target, r.Error)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d ports: %s\n", len(results), summary(results))
+	return err
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxAddresses limits the addresses a CIDR expands to, a /16 of IPv4
+const maxAddresses = 1 << 16
+
+// Port states of a scan
+const (
+	stateOpen     = "open"
+	stateClosed   = "closed"
+	stateFiltered = "filtered"
+	stateError    = "error"
+)
+
+// target is an address to connect to
+type target struct {
+	Host string
+	Port int
+}
+
+func (t target) String() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+// result is the state of a port after connecting to it
+type result struct {
+	target
+	State   string
+	Latency time.Duration
+	Error   string
+}
+
+// parsePorts parses a list of ports and port ranges, like 22,80,8000-8100
+func parsePorts(list string) ([]int, error) {
+	seen := make(map[int]bool)
+	ports := []int{}
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := parsePort(first)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			if to, err = parsePort(last); err != nil {
+				return nil, err
+			}
+			if to < from {
+				return nil, fmt.Errorf("invalid port range %q", part)
+			}
+		}
+		for port := from; port <= to; port++ {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no ports")
+	}
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// expandHosts returns the hosts of the arguments: host names and IP addresses as
+// they are, and the addresses of CIDRs, without the network and broadcast
+// addresses of IPv4 networks larger than a /31
+func expandHosts(args []string) ([]string, error) {
+	hosts := []string{}
+	for _, arg := range args {
+		if !strings.Contains(arg, "/") {
+			hosts = append(hosts, arg)
+			continue
+		}
+		prefix, err := netip.ParsePrefix(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %s", arg, err)
+		}
+		prefix = prefix.Masked()
+		hostBits := prefix.Addr().BitLen() - prefix.Bits()
+		if hostBits > 16 {
+			return nil, fmt.Errorf("CIDR %s has more than %d addresses", prefix, maxAddresses)
+		}
+		addrs := []string{}
+		for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+			addrs = append(addrs, addr.String())
+			if len(hosts)+len(addrs) > maxAddresses {
+				return nil, fmt.Errorf("more than %d addresses", maxAddresses)
+			}
+			if !addr.Next().IsValid() {
+				break
+			}
+		}
+		if prefix.Addr().Is4() && hostBits > 1 {
+			addrs = addrs[1 : len(addrs)-1]
+		}
+		hosts = append(hosts, addrs...)
+	}
+	return hosts, nil
+}
+
+// parseTargets parses host:port arguments, with IPv6 addresses in brackets
+func parseTargets(args []string) ([]target, error) {
+	targets := make([]target, 0, len(args))
+	for _, arg := range args {
+		host, portString, err := net.SplitHostPort(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %s", arg, err)
+		}
+		port, err := parsePort(portString)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target{Host: host, Port: port})
+	}
+	return targets, nil
+}
+
+// scanner connects to targets with a pool of workers
+type scanner struct {
+	timeout     time.Duration
+	concurrency int
+	dial        func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// scan connects to every target, and returns the results in the order of the targets
+func (s scanner) scan(ctx context.Context, targets []target) []result {
+	results := make([]result, len(targets))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(s.concurrency, len(targets)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = s.probe(ctx, targets[i])
+			}
+		}()
+	}
+	for i := range targets {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// probe connects to the target over TCP, and tells the state of the port by the
+// error: refused connections are closed, and timeouts filtered by a firewall
+func (s scanner) probe(ctx context.Context, t target) result {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	dial := s.dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	start := time.Now()
+	conn, err := dial(ctx, "tcp", t.String())
+	r := result{target: t, Latency: time.Since(start)}
+	if err == nil {
+		conn.Close()
+		r.State = stateOpen
+		return r
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		r.State = stateClosed
+	case errors.As(err, &netErr) && netErr.Timeout(), errors.Is(err, context.DeadlineExceeded):
+		r.State = stateFiltered
+	default:
+		r.State = stateError
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// targetsOf returns every port of every host, by host
+func targetsOf(hosts []string, ports []int) []target {
+	targets := make([]target, 0, len(hosts)*len(ports))
+	for _, host := range hosts {
+		for _, port := range ports {
+			targets = append(targets, target{Host: host, Port: port})
+		}
+	}
+	return targets
+}
+
+// summary counts the results by state, like "2 closed, 1 open"
+func summary(results []result) string {
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.State]++
+	}
+	states := make([]string, 0, len(counts))
+	for state := range counts {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	for i, state := range states {
+		states[i] = fmt.Sprintf("%d %s", counts[state], state)
+	}
+	return strings.Join(states, ", ")
+}
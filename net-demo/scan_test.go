@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []int
+		wantErr bool
+	}{
+		{list: "22", want: []int{22}},
+		{list: "22, 80,8000-8002", want: []int{22, 80, 8000, 8001, 8002}},
+		{list: "80,79-81", want: []int{80, 79, 81}},
+		{list: "", wantErr: true},
+		{list: "0", wantErr: true},
+		{list: "65536", wantErr: true},
+		{list: "90-80", wantErr: true},
+		{list: "http", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := parsePorts(test.list)
+		if (err != nil) != test.wantErr {
+			t.Errorf("parsePorts(%q) error: %v", test.list, err)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parsePorts(%q) = %v, want %v", test.list, got, test.want)
+		}
+	}
+}
+
+func TestExpandHosts(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{args: []string{"example.com", "10.0.0.1"}, want: []string{"example.com", "10.0.0.1"}},
+		{args: []string{"10.0.0.0/30"}, want: []string{"10.0.0.1", "10.0.0.2"}},
+		// the network of 10.0.0.5/30 is 10.0.0.4
+		{args: []string{"10.0.0.5/30"}, want: []string{"10.0.0.5", "10.0.0.6"}},
+		{args: []string{"10.0.0.0/31"}, want: []string{"10.0.0.0", "10.0.0.1"}},
+		{args: []string{"10.0.0.1/32"}, want: []string{"10.0.0.1"}},
+		{args: []string{"fd00::/126"}, want: []string{"fd00::", "fd00::1", "fd00::2", "fd00::3"}},
+		{args: []string{"255.255.255.254/31"}, want: []string{"255.255.255.254", "255.255.255.255"}},
+		{args: []string{"10.0.0.0/15"}, wantErr: true},
+		{args: []string{"10.0.0.0/16", "10.1.0.0/24"}, wantErr: true},
+		{args: []string{"10.0.0.0/33"}, wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := expandHosts(test.args)
+		if (err != nil) != test.wantErr {
+			t.Errorf("expandHosts(%v) error: %v", test.args, err)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("expandHosts(%v) = %v, want %v", test.args, got, test.want)
+		}
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	got, err := parseTargets([]string{"localhost:80", "[::1]:443"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []target{{Host: "localhost", Port: 80}, {Host: "::1", Port: 443}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, arg := range []string{"localhost", "localhost:0", "localhost:http"} {
+		if _, err := parseTargets([]string{arg}); err == nil {
+			t.Errorf("no error for %q", arg)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	openPort := listener.Addr().(*net.TCPAddr).Port
+
+	// a port that was just free is most likely closed
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	s := scanner{timeout: time.Second, concurrency: 2}
+	results := s.scan(context.Background(), targetsOf([]string{"127.0.0.1"}, []int{openPort, closedPort}))
+	states := []string{}
+	for _, r := range results {
+		states = append(states, r.State)
+	}
+	if want := []string{stateOpen, stateClosed}; !reflect.DeepEqual(states, want) {
+		t.Errorf("got states %v, want %v", states, want)
+	}
+}
+
+func TestProbeFiltered(t *testing.T) {
+	// a dial that never connects, like to a port dropped by a firewall
+	s := scanner{timeout: 10 * time.Millisecond, dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	r := s.probe(context.Background(), target{Host: "192.0.2.1", Port: 22})
+	if r.State != stateFiltered {
+		t.Errorf("got state %s, want %s", r.State, stateFiltered)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	results := []result{{State: stateOpen}, {State: stateClosed}, {State: stateClosed}}
+	if got, want := summary(results), "2 closed, 1 open"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
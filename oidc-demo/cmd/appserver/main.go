@@ -3,10 +3,13 @@ package main
 import (
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 
 	"oidc-demo/pkg/oidc"
+
+	"logging"
 )
 
 const redirectUri = "http://localhost:8081/callback"
@@ -16,6 +19,11 @@ type app struct {
 }
 
 func main() {
+	logger, err := logging.Setup()
+	if err != nil {
+		logger.Error("Invalid logging configuration", "error", err)
+		os.Exit(1)
+	}
 
 	a := app{
 		states: make(map[string]bool),
@@ -24,9 +32,9 @@ func main() {
 	http.HandleFunc("/", a.index)
 	http.HandleFunc("/callback", a.callback)
 
-	err := http.ListenAndServe(":8081", nil)
+	err = http.ListenAndServe(":8081", logging.Middleware(http.DefaultServeMux))
 	if err != nil {
-		fmt.Printf("ListenAndServe error: %s\n", err)
+		logger.Error("ListenAndServe error", "error", err)
 	}
 }
 
@@ -69,7 +77,7 @@ func (a *app) callback(w http.ResponseWriter, r *http.Request) {
 
 	delete(a.states, r.URL.Query().Get("state"))
 
-	tokens, _, err := getTokenFromCode(discovery.TokenEndpoint, discovery.JwksURI, redirectUri, os.Getenv("CLIENT_ID"), os.Getenv("CLIENT_SECRET"), r.URL.Query().Get("code"))
+	tokens, claims, err := getTokenFromCode(discovery.TokenEndpoint, discovery.JwksURI, redirectUri, os.Getenv("CLIENT_ID"), os.Getenv("CLIENT_SECRET"), r.URL.Query().Get("code"))
 	if err != nil {
 		returnError(w, fmt.Errorf("getTokenFromCode error: %s", err))
 		return
@@ -96,7 +104,8 @@ func (a *app) callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Printf("IDToken: %s\n", tokens[0].Raw)
+	// the token itself is redacted by the logger
+	slog.InfoContext(r.Context(), "Token received", "subject", claims.Subject, "id_token", tokens[0].Raw)
 
 	w.Write([]byte(fmt.Sprintf("Token received. Userinfo: %s", body)))
 }
@@ -104,5 +113,5 @@ func (a *app) callback(w http.ResponseWriter, r *http.Request) {
 func returnError(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusInternalServerError)
 	w.Write([]byte(err.Error()))
-	fmt.Printf("Error: %s\n", err)
+	slog.Error("Returned HTTP 500 error to client", "error", err)
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"oidc-demo/pkg/server"
+
+	"config"
+	"secrets"
+	"watcher"
+)
+
+// Config configures the oidc-demo server. It's read from config.yaml, or the file
+// of -config, the OIDC_ environment variables override the file, and the flags
+// override both:
+//
+//	url: http://localhost:8080
+//	listen: :8080
+//	encryptionKey: enckey.pem
+//	apps:
+//	  app1:
+//	    clientID: 1-2-3-4
+//	    ...
+//
+// The client secrets of the apps are the secrets apps/<app>/client-secret, like
+// OIDC_APPS_APP1_CLIENT_SECRET, or else the clientSecret of the file.
+type Config struct {
+	server.Config `yaml:",inline"`
+	Listen        string `yaml:"listen" env:"LISTEN" flag:"listen" usage:"address to listen on"`
+	// EncryptionKey is the PEM file of the key signing the tokens, generated on the
+	// first run
+	EncryptionKey string `yaml:"encryptionKey" env:"ENCRYPTION_KEY" flag:"encryption-key" usage:"PEM file of the key signing the tokens, generated when it doesn't exist"`
+	DevCerts      string `yaml:"devCerts" env:"DEV_CERTS" flag:"dev-certs" usage:"directory with a dev CA and a server certificate for localhost, generated on the first run, to serve HTTPS"`
+}
+
+func defaultConfig() Config {
+	return Config{Listen: ":8080", EncryptionKey: "enckey.pem"}
+}
+
+var configOptions = config.Options{Name: "oidc-demo", EnvPrefix: "OIDC_", File: "config.yaml"}
+
+// loadConfig loads the config of the config file, the environment and the flags in
+// args, with the client secrets of store
+func loadConfig(args []string, store secrets.Store) (Config, error) {
+	c, err := config.Load(defaultConfig(), args, configOptions)
+	if err != nil {
+		return c, err
+	}
+	for name, app := range c.Apps {
+		secret, err := store.Get(context.Background(), "apps/"+name+"/client-secret")
+		switch {
+		case err == nil:
+			app.ClientSecret = secret
+		case !errors.Is(err, secrets.ErrNotFound):
+			return c, fmt.Errorf("client secret of app %s: %s", name, err)
+		case app.ClientSecret == "":
+			return c, fmt.Errorf("app %s has no client secret: %s", name, err)
+		}
+		c.Apps[name] = app
+	}
+	return c, nil
+}
+
+// reloadConfig sends the config of the config file, the environment and the flags
+// in args to reload on SIGHUP, and when the config file changes
+func reloadConfig(ctx context.Context, args []string, store secrets.Store, reload chan<- server.Config) error {
+	load := func() (Config, error) {
+		return loadConfig(args, store)
+	}
+	apply := func(c Config) {
+		select {
+		case reload <- c.Config:
+		case <-ctx.Done():
+		}
+	}
+	path, err := config.File(defaultConfig(), args, configOptions)
+	if err != nil {
+		return err
+	}
+	go config.ReloadOnSIGHUP(ctx, load, apply)
+	w := &watcher.Watcher{}
+	if err := w.Add(path); err != nil {
+		return err
+	}
+	reloadNow := config.Reloader(ctx, load, apply)
+	w.OnChange(func([]string) { reloadNow() })
+	return w.Run(ctx)
+}
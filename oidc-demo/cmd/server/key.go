@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/wardviaene/golang-for-devops-course/ssh-demo"
+
+	"secrets"
+)
+
+// encryptionKey returns the PEM key signing the tokens: the signing-key secret of
+// store, like OIDC_SIGNING_KEY or the key in Vault, or else the key of file,
+// generated and written on the first run
+func encryptionKey(store secrets.Store, file string) ([]byte, error) {
+	key, err := store.Get(context.Background(), "signing-key")
+	if err == nil {
+		return []byte(key), nil
+	}
+	if !errors.Is(err, secrets.ErrNotFound) {
+		return nil, err
+	}
+
+	privateKey, err := os.ReadFile(file)
+	if !errors.Is(err, os.ErrNotExist) {
+		return privateKey, err
+	}
+	if privateKey, _, err = ssh.GenerateKeys(); err != nil {
+		return nil, fmt.Errorf("generate: %s", err)
+	}
+	if err = os.WriteFile(file, privateKey, 0600); err != nil {
+		return nil, fmt.Errorf("write: %s", err)
+	}
+	return privateKey, nil
+}
@@ -1,50 +1,80 @@
 package main
 
 import (
+	"context"
 	"errors"
-	"fmt"
-	"io/ioutil"
-	"log"
+	"flag"
 	"net/http"
 	"os"
 
 	"oidc-demo/pkg/server"
 
-	"github.com/wardviaene/golang-for-devops-course/ssh-demo"
+	"lifecycle"
+	"logging"
+	"secrets"
 )
 
-const configFile = "config.yaml"
-
 func main() {
-	var (
-		privateKey []byte
-		err        error
-	)
-	// read config
-	if _, err = os.Stat(configFile); errors.Is(err, os.ErrNotExist) {
-		fmt.Printf("Error: %s doesn't exist\n", configFile)
+	logger, err := logging.Setup()
+	if err != nil {
+		logger.Error("Invalid logging configuration", "error", err)
 		os.Exit(1)
 	}
-	config, err := ioutil.ReadFile(configFile)
+	store, err := secrets.FromEnv("OIDC_")
 	if err != nil {
-		log.Fatalf("Failed to load %s, err: %v", configFile, err)
+		logger.Error("Invalid secrets configuration", "error", err)
+		os.Exit(1)
 	}
-	// read encryption key
-	if _, err = os.Stat("enckey.pem"); errors.Is(err, os.ErrNotExist) {
-		if privateKey, _, err = ssh.GenerateKeys(); err != nil {
-			fmt.Printf("Error: %s\n", err)
-			os.Exit(1)
-		}
-		if err = os.WriteFile("enckey.pem", privateKey, 0600); err != nil {
-			fmt.Printf("Error: %s\n", err)
+	c, err := loadConfig(os.Args[1:], store)
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	privateKey, err := encryptionKey(store, c.EncryptionKey)
+	if err != nil {
+		logger.Error("Failed to load the encryption key", "error", err)
+		os.Exit(1)
+	}
+	// the handlers are registered on the default mux by server.Start
+	httpServer := &http.Server{Addr: c.Listen, Handler: logging.Middleware(http.DefaultServeMux)}
+	if c.DevCerts != "" {
+		if httpServer.TLSConfig, err = devTLSConfig(c.DevCerts); err != nil {
+			logger.Error("Invalid TLS configuration", "error", err)
 			os.Exit(1)
 		}
-	} else {
-		privateKey, err = ioutil.ReadFile("enckey.pem")
-		if err != nil {
-			log.Fatalf("Failed to load authorized_keys, err: %v", err)
-		}
+	}
 
+	// kill -HUP and the changes of the config file apply the changes to the url and
+	// the apps
+	reload := make(chan server.Config)
+	var group lifecycle.Group
+	group.Add(
+		lifecycle.Func("config", func(ctx context.Context) error {
+			return reloadConfig(ctx, os.Args[1:], store, reload)
+		}),
+		lifecycle.Component{
+			Name: "http",
+			Run: func(ctx context.Context) error {
+				logger.Info("Starting the server", "addr", httpServer.Addr)
+				err := server.Start(httpServer, privateKey, c.Config, store, reload)
+				if errors.Is(err, http.ErrServerClosed) {
+					return nil
+				}
+				return err
+			},
+			Stop: httpServer.Shutdown,
+		},
+	)
+	ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+	if err := group.Run(ctx); err != nil {
+		logger.Error("Server stopped with errors", "error", err)
+		stop()
+		os.Exit(1)
 	}
-	fmt.Printf("Server stopped: %s", server.Start(&http.Server{Addr: ":8080"}, privateKey, server.ReadConfig(config)))
+	logger.Info("Server stopped")
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"certs"
+)
+
+const (
+	devCertsValidity    = 365 * 24 * time.Hour
+	devCertsRenewBefore = 30 * 24 * time.Hour
+)
+
+// devTLSConfig serves the server certificate for localhost in dir, signed by the dev
+// CA in dir. They're generated on the first run, and renewed when they expire within
+// a month. They're for trying out HTTPS, never for production.
+func devTLSConfig(dir string) (*tls.Config, error) {
+	caFile, caKeyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem")
+	serverFile, serverKeyFile := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server-key.pem")
+
+	if _, err := os.Stat(caFile); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+		ca, err := certs.NewCA("oidc-demo dev CA", devCertsValidity)
+		if err != nil {
+			return nil, err
+		}
+		server, err := certs.NewServer(ca, "localhost", []string{"localhost", "127.0.0.1", "::1"}, devCertsValidity)
+		if err != nil {
+			return nil, err
+		}
+		if err := ca.WriteFiles(caFile, caKeyFile); err != nil {
+			return nil, err
+		}
+		if err := server.WriteFiles(serverFile, serverKeyFile); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := certs.RenewFiles(caFile, caKeyFile, nil, devCertsRenewBefore, devCertsValidity); err != nil {
+			return nil, fmt.Errorf("can't renew the dev CA: %s", err)
+		}
+		ca, err := certs.Load(caFile, caKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := certs.RenewFiles(serverFile, serverKeyFile, ca, devCertsRenewBefore, devCertsValidity); err != nil {
+			return nil, fmt.Errorf("can't renew the server certificate: %s", err)
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(serverFile, serverKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
@@ -3,14 +3,34 @@ module oidc-demo
 go 1.24.2
 
 require (
+	certs v0.0.0-00010101000000-000000000000
+	config v0.0.0-00010101000000-000000000000
 	github.com/golang-jwt/jwt/v4 v4.5.2
-	oidc-demo v0.0.0-20230918131850-8398cd208d4b
 	github.com/wardviaene/golang-for-devops-course/ssh-demo v0.0.0-20230918131850-8398cd208d4b
-	gopkg.in/yaml.v3 v3.0.1
+	lifecycle v0.0.0-00010101000000-000000000000
+	logging v0.0.0-00010101000000-000000000000
+	secrets v0.0.0-00010101000000-000000000000
+	watcher v0.0.0-00010101000000-000000000000
 )
 
 require (
+	github.com/fsnotify/fsnotify v1.9.0 // indirect
+	go.opentelemetry.io/otel v1.35.0 // indirect
+	go.opentelemetry.io/otel/trace v1.35.0 // indirect
 	golang.org/x/crypto v0.39.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/term v0.32.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace certs => ../pkg/certs
+
+replace config => ../pkg/config
+
+replace lifecycle => ../pkg/lifecycle
+
+replace logging => ../pkg/logging
+
+replace secrets => ../pkg/secrets
+
+replace watcher => ../pkg/watcher
@@ -36,7 +36,7 @@ func (s *server) authorization(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	appConfig := AppConfig{}
-	for _, app := range s.Config.Apps {
+	for _, app := range s.Config().Apps {
 		if app.ClientID == clientID {
 			appConfig = app
 		}
@@ -9,11 +9,11 @@ import (
 )
 
 func TestAuthorization(t *testing.T) {
-	s := newServer(privkeyPem, testConfig) // testConfig is defined in http_test.go and defines a static config
+	s := newServer(privkeyPem, testConfig, testSecrets) // testConfig is defined in http_test.go and defines a static config
 
 	endpoint := fmt.Sprintf("/authorization?client_id=%s&redirect_uri=%s&scope=openid&response_type=code&state=randomstring",
-		s.Config.Apps["app1"].ClientID, // app1 is defined in testConfig
-		s.Config.Apps["app1"].RedirectURIs[0],
+		s.Config().Apps["app1"].ClientID, // app1 is defined in testConfig
+		s.Config().Apps["app1"].RedirectURIs[0],
 	)
 	req := httptest.NewRequest(http.MethodGet, endpoint, nil)
 	w := httptest.NewRecorder()
@@ -1,14 +1,27 @@
 package server
 
-import "gopkg.in/yaml.v3"
+import (
+	"errors"
+	"fmt"
+)
 
-func ReadConfig(bytes []byte) Config {
-	var config Config
-
-	// config parsing
-	err := yaml.Unmarshal(bytes, &config)
-	if err != nil {
-		config.LoadError = err
+// Validate checks the url and the apps of the config, so a server never starts,
+// or reloads, with apps no client can log in to
+func (c Config) Validate() error {
+	var errs []error
+	if c.Url == "" {
+		errs = append(errs, errors.New("url is empty"))
+	}
+	if len(c.Apps) == 0 {
+		errs = append(errs, errors.New("no apps"))
+	}
+	for name, app := range c.Apps {
+		if app.ClientID == "" {
+			errs = append(errs, fmt.Errorf("app %s has no clientID", name))
+		}
+		if len(app.RedirectURIs) == 0 {
+			errs = append(errs, fmt.Errorf("app %s has no redirectURIs", name))
+		}
 	}
-	return config
+	return errors.Join(errs...)
 }
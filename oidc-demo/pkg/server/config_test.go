@@ -0,0 +1,20 @@
+package server
+
+import "testing"
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Url: "http://localhost:8080", Apps: testConfig.Apps}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected a valid config, got %s", err)
+	}
+	invalid := []Config{
+		{Apps: testConfig.Apps},
+		{Url: "http://localhost:8080"},
+		{Url: "http://localhost:8080", Apps: map[string]AppConfig{"app1": {ClientID: "1-2-3-4"}}},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
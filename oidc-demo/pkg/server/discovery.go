@@ -8,12 +8,13 @@ import (
 )
 
 func (s *server) discovery(w http.ResponseWriter, r *http.Request) {
+	url := s.Config().Url
 	discovery := oidc.Discovery{
-		Issuer:                            s.Config.Url,
-		AuthorizationEndpoint:             s.Config.Url + "/authorization",
-		TokenEndpoint:                     s.Config.Url + "/token",
-		UserinfoEndpoint:                  s.Config.Url + "/userinfo",
-		JwksURI:                           s.Config.Url + "/jwks.json",
+		Issuer:                            url,
+		AuthorizationEndpoint:             url + "/authorization",
+		TokenEndpoint:                     url + "/token",
+		UserinfoEndpoint:                  url + "/userinfo",
+		JwksURI:                           url + "/jwks.json",
 		ScopesSupported:                   []string{"openid"}, // was oidc in lecture, but should be openid
 		ResponseTypesSupported:            []string{"code"},
 		TokenEndpointAuthMethodsSupported: []string{"none"},
@@ -1,28 +1,49 @@
 package server
 
 import (
-	"fmt"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
+
+	"secrets"
 )
 
 type server struct {
 	PrivateKey   []byte
-	Config       Config
+	config       atomic.Pointer[Config]
+	secrets      secrets.Store
 	LoginRequest map[string]LoginRequest
 	Codes        map[string]LoginRequest
 }
 
-func newServer(privateKey []byte, config Config) *server {
-	return &server{
+func newServer(privateKey []byte, config Config, store secrets.Store) *server {
+	s := &server{
 		PrivateKey:   privateKey,
-		Config:       config,
+		secrets:      store,
 		LoginRequest: make(map[string]LoginRequest),
 		Codes:        make(map[string]LoginRequest),
 	}
+	s.config.Store(&config)
+	return s
 }
 
-func Start(httpServer *http.Server, privateKey []byte, config Config) error {
-	s := newServer(privateKey, config)
+// Config returns the current config. Handlers get it once per request.
+func (s *server) Config() Config {
+	return *s.config.Load()
+}
+
+// Start serves the oidc provider with config, replaced by the configs received on
+// reload, if not nil, without a restart. The passwords of the users are the secrets
+// users/<login>/password of store.
+func Start(httpServer *http.Server, privateKey []byte, config Config, store secrets.Store, reload <-chan Config) error {
+	s := newServer(privateKey, config, store)
+	if reload != nil {
+		go func() {
+			for config := range reload {
+				s.config.Store(&config)
+			}
+		}()
+	}
 
 	http.HandleFunc("/authorization", s.authorization)
 	http.HandleFunc("/token", s.token)
@@ -31,11 +52,15 @@ func Start(httpServer *http.Server, privateKey []byte, config Config) error {
 	http.HandleFunc("/.well-known/openid-configuration", s.discovery)
 	http.HandleFunc("/userinfo", s.userinfo)
 
+	if httpServer.TLSConfig != nil {
+		// the certificates are in the TLS config
+		return httpServer.ListenAndServeTLS("", "")
+	}
 	return httpServer.ListenAndServe()
 }
 
 func returnError(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusBadRequest)
 	w.Write([]byte(err.Error()))
-	fmt.Printf("Error: %s\n", err)
+	slog.Error("Returned HTTP 400 error to client", "error", err)
 }
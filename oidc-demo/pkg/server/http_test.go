@@ -12,6 +12,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"secrets"
 )
 
 /* Global code for all tests */
@@ -19,6 +21,9 @@ var privkeyPem []byte
 
 var testConfig Config
 
+// testSecrets has the password of the user of the tests
+var testSecrets = secrets.Map{"users/raymond/password": "password"}
+
 func TestMain(m *testing.M) {
 	err := testSetup()
 	if err != nil {
@@ -58,7 +63,7 @@ func TestStart(t *testing.T) {
 	httpServer := &http.Server{Addr: ":8080"}
 
 	go func() {
-		err := Start(httpServer, privkeyPem, testConfig)
+		err := Start(httpServer, privkeyPem, testConfig, testSecrets, nil)
 		if err != nil && err.Error() != "http: Server closed" {
 			t.Errorf("Start error: %s\n", err)
 		}
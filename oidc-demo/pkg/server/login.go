@@ -30,7 +30,7 @@ func (s *server) login(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		auth, user, err := users.Auth(r.PostForm.Get("login"), r.PostForm.Get("password"), "")
+		auth, user, err := users.Auth(r.Context(), s.secrets, r.PostForm.Get("login"), r.PostForm.Get("password"), "")
 		if err != nil {
 			returnError(w, fmt.Errorf("Authentication error: %s", err))
 			return
@@ -12,12 +12,12 @@ import (
 )
 
 func TestLoginGet(t *testing.T) {
-	s := newServer(privkeyPem, testConfig)
+	s := newServer(privkeyPem, testConfig, testSecrets)
 
 	// 1. authorization flow
 	endpoint := fmt.Sprintf("/authorization?client_id=%s&redirect_uri=%s&scope=openid&response_type=code&state=randomstring",
-		s.Config.Apps["app1"].ClientID,
-		s.Config.Apps["app1"].RedirectURIs[0],
+		s.Config().Apps["app1"].ClientID,
+		s.Config().Apps["app1"].RedirectURIs[0],
 	)
 	req := httptest.NewRequest(http.MethodGet, endpoint, nil)
 	w := httptest.NewRecorder()
@@ -59,12 +59,12 @@ func TestLoginPost(t *testing.T) {
 	loginValue := "raymond"
 	passwordValue := "password"
 
-	s := newServer(privkeyPem, testConfig)
+	s := newServer(privkeyPem, testConfig, testSecrets)
 
 	// 1. authorization flow
 	endpoint := fmt.Sprintf("/authorization?client_id=%s&redirect_uri=%s&scope=openid&response_type=code&state=randomstring",
-		s.Config.Apps["app1"].ClientID,
-		s.Config.Apps["app1"].RedirectURIs[0],
+		s.Config().Apps["app1"].ClientID,
+		s.Config().Apps["app1"].RedirectURIs[0],
 	)
 	req := httptest.NewRequest(http.MethodGet, endpoint, nil)
 	w := httptest.NewRecorder()
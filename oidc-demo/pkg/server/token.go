@@ -51,8 +51,9 @@ func (s *server) token(w http.ResponseWriter, r *http.Request) {
 		returnError(w, fmt.Errorf("private key parsing error: %s", err))
 		return
 	}
+	url := s.Config().Url
 	claims := jwt.MapClaims{
-		"iss": s.Config.Url,
+		"iss": url,
 		"sub": loginRequest.User.Sub,
 		"aud": loginRequest.ClientID,
 		"exp": time.Now().Add(1 * time.Hour).Unix(),
@@ -70,10 +71,10 @@ func (s *server) token(w http.ResponseWriter, r *http.Request) {
 
 	// access token
 	claims = jwt.MapClaims{
-		"iss": s.Config.Url,
+		"iss": url,
 		"sub": loginRequest.User.Sub,
 		"aud": []string{
-			s.Config.Url + "/userinfo",
+			url + "/userinfo",
 		},
 		"exp": time.Now().Add(1 * time.Hour).Unix(),
 		"nbf": time.Now().Unix(),
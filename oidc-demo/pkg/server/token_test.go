@@ -22,12 +22,12 @@ func TestToken(t *testing.T) {
 	loginValue := "raymond"
 	passwordValue := "password"
 
-	s := newServer(privkeyPem, testConfig)
+	s := newServer(privkeyPem, testConfig, testSecrets)
 
 	// 1. authorization flow
 	endpoint := fmt.Sprintf("/authorization?client_id=%s&redirect_uri=%s&scope=openid&response_type=code&state=randomstring",
-		s.Config.Apps["app1"].ClientID,
-		s.Config.Apps["app1"].RedirectURIs[0],
+		s.Config().Apps["app1"].ClientID,
+		s.Config().Apps["app1"].RedirectURIs[0],
 	)
 	req := httptest.NewRequest(http.MethodGet, endpoint, nil)
 	w := httptest.NewRecorder()
@@ -101,9 +101,9 @@ func TestToken(t *testing.T) {
 	// 4. exchange code into token
 	form = url.Values{}
 	form.Add("grant_type", "authorization_code")
-	form.Add("client_id", s.Config.Apps["app1"].ClientID)
-	form.Add("client_secret", s.Config.Apps["app1"].ClientSecret)
-	form.Add("redirect_uri", s.Config.Apps["app1"].RedirectURIs[0])
+	form.Add("client_id", s.Config().Apps["app1"].ClientID)
+	form.Add("client_secret", s.Config().Apps["app1"].ClientSecret)
+	form.Add("redirect_uri", s.Config().Apps["app1"].RedirectURIs[0])
 	form.Add("code", postLoginUrl.Query().Get("code"))
 
 	req = httptest.NewRequest(http.MethodPost, "/token", bytes.NewBufferString(form.Encode()))
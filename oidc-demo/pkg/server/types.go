@@ -6,10 +6,10 @@ import (
 	"oidc-demo/pkg/users"
 )
 
+// Config configures the oidc provider. The apps can only be set in the YAML file.
 type Config struct {
-	Apps      map[string]AppConfig `yaml:"apps"`
-	Url       string               `yaml:"url"`
-	LoadError error
+	Apps map[string]AppConfig `yaml:"apps"`
+	Url  string               `yaml:"url" env:"URL" flag:"url" usage:"url of the oidc provider, the issuer of its tokens"`
 }
 type AppConfig struct {
 	ClientID     string   `yaml:"clientID"`
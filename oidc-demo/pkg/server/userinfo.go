@@ -36,7 +36,7 @@ func (s *server) userinfo(w http.ResponseWriter, r *http.Request) {
 
 	found := false
 	for _, aud := range claims.Audience {
-		if aud == s.Config.Url+"/userinfo" {
+		if aud == s.Config().Url+"/userinfo" {
 			found = true
 		}
 	}
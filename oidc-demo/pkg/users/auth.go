@@ -1,6 +1,13 @@
 package users
 
-import "fmt"
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"secrets"
+)
 
 type User struct {
 	Sub               string `json:"sub"`
@@ -12,9 +19,23 @@ type User struct {
 	Picture           string `json:"picture"`
 }
 
-func Auth(login, password, mfa string) (bool, User, error) {
-	if login == "raymond" && password == "password" {
-		return true, GetAllUsers()[0], nil
+// Auth checks password against the secret users/<login>/password of store
+func Auth(ctx context.Context, store secrets.Store, login, password, mfa string) (bool, User, error) {
+	for _, user := range GetAllUsers() {
+		if user.PreferredUsername != login {
+			continue
+		}
+		expected, err := store.Get(ctx, "users/"+login+"/password")
+		if errors.Is(err, secrets.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return false, User{}, fmt.Errorf("password of %s: %s", login, err)
+		}
+		if subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1 {
+			return true, user, nil
+		}
+		break
 	}
 	return false, User{}, fmt.Errorf("invalid login or password")
 }
@@ -0,0 +1,35 @@
+package users
+
+import (
+	"context"
+	"testing"
+
+	"secrets"
+)
+
+func TestAuth(t *testing.T) {
+	store := secrets.Map{"users/raymond/password": "hunter2"}
+	tests := []struct {
+		login, password string
+		auth            bool
+	}{
+		{login: "raymond", password: "hunter2", auth: true},
+		{login: "raymond", password: "password"},
+		{login: "raymond"},
+		{login: "unknown", password: "hunter2"},
+	}
+	for _, test := range tests {
+		auth, user, err := Auth(context.Background(), store, test.login, test.password, "")
+		if auth != test.auth {
+			t.Errorf("Expected %v for %s/%s, got %v (%v)", test.auth, test.login, test.password, auth, err)
+		}
+		if auth && user.Sub != "9-9-9-9" {
+			t.Errorf("Expected the user 9-9-9-9, got %+v", user)
+		}
+	}
+
+	// a user without a password secret can't log in
+	if auth, _, _ := Auth(context.Background(), secrets.Map{}, "raymond", "", ""); auth {
+		t.Error("Expected no login without a password secret")
+	}
+}
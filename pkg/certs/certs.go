@@ -0,0 +1,185 @@
+// Package certs generates certificates for trying out TLS: a CA, and server and
+// client certificates signed by it. They're never meant for production.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+// Cert is a certificate with its private key
+type Cert struct {
+	Cert *x509.Certificate
+	Key  *ecdsa.PrivateKey
+}
+
+// NewCA creates a self-signed CA
+func NewCA(commonName string, validity time.Duration) (*Cert, error) {
+	return Generate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: commonName},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}, nil, validity)
+}
+
+// NewServer creates a server certificate for the hosts, DNS names or IPs, signed by ca
+func NewServer(ca *Cert, commonName string, hosts []string, validity time.Duration) (*Cert, error) {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	return Generate(template, ca, validity)
+}
+
+// NewClient creates a client certificate for mTLS, signed by ca
+func NewClient(ca *Cert, commonName string, validity time.Duration) (*Cert, error) {
+	return Generate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, validity)
+}
+
+// Generate creates a P-256 key, and signs the template with the key of the parent,
+// or self-signs it without a parent. The certificate is valid from an hour ago, for
+// clocks that are behind, until validity from now.
+func Generate(template *x509.Certificate, parent *Cert, validity time.Duration) (*Cert, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return sign(template, key, parent, validity)
+}
+
+// sign signs the template for the key with the key of the parent, or self-signs it
+// without a parent
+func sign(template *x509.Certificate, key *ecdsa.PrivateKey, parent *Cert, validity time.Duration) (*Cert, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(validity)
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.Cert, parent.Key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, fmt.Errorf("can't create the certificate of %s: %w", template.Subject.CommonName, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &Cert{Cert: cert, Key: key}, nil
+}
+
+// CertPEM returns the certificate as a PEM block
+func (c *Cert) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw})
+}
+
+// KeyPEM returns the private key as an EC PRIVATE KEY PEM block
+func (c *Cert) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(c.Key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// WriteFiles writes the certificate and its key as PEM files. Only the owner can
+// read the key.
+func (c *Cert) WriteFiles(certFile, keyFile string) error {
+	keyPEM, err := c.KeyPEM()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(certFile, c.CertPEM(), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(keyFile, keyPEM, 0o600)
+}
+
+// Load reads a certificate and its ECDSA key from PEM files, as written by WriteFiles
+func Load(certFile, keyFile string) (*Cert, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := ParseCert(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", certFile, err)
+	}
+	key, err := parseKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyFile, err)
+	}
+	if !key.PublicKey.Equal(cert.PublicKey) {
+		return nil, fmt.Errorf("%s isn't the key of %s", keyFile, certFile)
+	}
+	return &Cert{Cert: cert, Key: key}, nil
+}
+
+// ParseCert parses the first certificate in PEM data
+func ParseCert(data []byte) (*x509.Certificate, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no PEM certificate")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// parseKey parses an ECDSA key in an EC PRIVATE KEY or a PKCS #8 PRIVATE KEY block
+func parseKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM private key")
+	}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("expected an ECDSA key, got %T", key)
+		}
+		return ecKey, nil
+	}
+	return nil, fmt.Errorf("unexpected PEM block %s", block.Type)
+}
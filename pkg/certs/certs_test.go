@@ -0,0 +1,84 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServerCertVerifies(t *testing.T) {
+	ca, err := NewCA("test CA", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewServer(ca, "web", []string{"web.demo.svc", "127.0.0.1"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+
+	tests := []struct {
+		host string
+		ok   bool
+	}{
+		{host: "web.demo.svc", ok: true},
+		{host: "127.0.0.1", ok: true},
+		{host: "other.demo.svc", ok: false},
+	}
+	for _, test := range tests {
+		_, err := server.Cert.Verify(x509.VerifyOptions{DNSName: test.host, Roots: roots})
+		if (err == nil) != test.ok {
+			t.Errorf("%s: expected ok %t, got %v", test.host, test.ok, err)
+		}
+	}
+	if server.Cert.NotAfter.After(time.Now().Add(time.Hour)) {
+		t.Errorf("Expected the certificate to expire within the validity, got %s", server.Cert.NotAfter)
+	}
+}
+
+func TestWriteFilesAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := NewCA("test CA", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(ca, "client", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	if err := client.WriteFiles(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(keyFile); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the key to be readable by the owner only, got %v (%v)", info.Mode(), err)
+	}
+	// the files are a valid key pair for crypto/tls as well
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		t.Errorf("LoadX509KeyPair error: %s", err)
+	}
+
+	loaded, err := Load(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Load error: %s", err)
+	}
+	if !loaded.Cert.Equal(client.Cert) || !loaded.Key.Equal(client.Key) {
+		t.Errorf("Expected the loaded certificate to be the written one")
+	}
+
+	other, err := NewCA("other CA", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKeyFile := filepath.Join(dir, "other-key.pem")
+	if err := other.WriteFiles(filepath.Join(dir, "other.pem"), otherKeyFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(certFile, otherKeyFile); err == nil {
+		t.Errorf("Expected an error for a key of another certificate")
+	}
+}
@@ -0,0 +1,144 @@
+// Command certs creates a CA with server and client certificates for the TLS and
+// mTLS demos, and renews them before they expire
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"certs"
+)
+
+const usage = `Usage: %s [flags] ca|server|client|renew [name]
+
+Commands:
+  ca           create the CA, ca.pem and ca-key.pem
+  server name  create name.pem and name-key.pem for -hosts, signed by the CA
+  client name  create name.pem and name-key.pem for mTLS, signed by the CA
+  renew        renew the CA and the certificates that expire within -renew-before
+
+`
+
+const (
+	caFile    = "ca.pem"
+	caKeyFile = "ca-key.pem"
+)
+
+func main() {
+	dir := flag.String("dir", "certs", "directory of the CA and the certificates")
+	hosts := flag.String("hosts", "localhost,127.0.0.1,::1", "comma separated DNS names and IPs of a server certificate")
+	validity := flag.Duration("validity", 365*24*time.Hour, "validity of the server and client certificates")
+	caValidity := flag.Duration("ca-validity", 10*365*24*time.Hour, "validity of the CA")
+	renewBefore := flag.Duration("renew-before", 30*24*time.Hour, "renew the certificates that expire within this duration")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var err error
+	switch {
+	case flag.Arg(0) == "ca" && flag.NArg() == 1:
+		err = createCA(*dir, *caValidity)
+	case flag.Arg(0) == "server" && flag.NArg() == 2:
+		err = create(*dir, flag.Arg(1), func(ca *certs.Cert) (*certs.Cert, error) {
+			return certs.NewServer(ca, flag.Arg(1), strings.Split(*hosts, ","), *validity)
+		})
+	case flag.Arg(0) == "client" && flag.NArg() == 2:
+		err = create(*dir, flag.Arg(1), func(ca *certs.Cert) (*certs.Cert, error) {
+			return certs.NewClient(ca, flag.Arg(1), *validity)
+		})
+	case flag.Arg(0) == "renew" && flag.NArg() == 1:
+		err = renew(*dir, *renewBefore, *caValidity, *validity)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func createCA(dir string, validity time.Duration) error {
+	if _, err := os.Stat(filepath.Join(dir, caFile)); err == nil {
+		return fmt.Errorf("%s exists already, renew it with the renew command", filepath.Join(dir, caFile))
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	ca, err := certs.NewCA("certs dev CA", validity)
+	if err != nil {
+		return err
+	}
+	if err := ca.WriteFiles(filepath.Join(dir, caFile), filepath.Join(dir, caKeyFile)); err != nil {
+		return err
+	}
+	fmt.Printf("Created %s, valid until %s\n", filepath.Join(dir, caFile), ca.Cert.NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+// create writes the certificate that newCert signs with the CA of dir
+func create(dir, name string, newCert func(ca *certs.Cert) (*certs.Cert, error)) error {
+	ca, err := certs.Load(filepath.Join(dir, caFile), filepath.Join(dir, caKeyFile))
+	if err != nil {
+		return fmt.Errorf("can't load the CA, create it with the ca command: %w", err)
+	}
+	cert, err := newCert(ca)
+	if err != nil {
+		return err
+	}
+	certFile := filepath.Join(dir, name+".pem")
+	if err := cert.WriteFiles(certFile, filepath.Join(dir, name+"-key.pem")); err != nil {
+		return err
+	}
+	fmt.Printf("Created %s, valid until %s\n", certFile, cert.Cert.NotAfter.Format(time.RFC3339))
+	return nil
+}
+
+// renew renews the CA first, so the certificates are signed by the renewed one.
+// The CA keeps its key, so the certificates that aren't renewed stay valid.
+func renew(dir string, renewBefore, caValidity, validity time.Duration) error {
+	caCertFile, caKeyPath := filepath.Join(dir, caFile), filepath.Join(dir, caKeyFile)
+	if err := renewFile(caCertFile, caKeyPath, nil, renewBefore, caValidity); err != nil {
+		return err
+	}
+	ca, err := certs.Load(caCertFile, caKeyPath)
+	if err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	for _, certFile := range files {
+		keyFile := strings.TrimSuffix(certFile, ".pem") + "-key.pem"
+		if certFile == caCertFile || strings.HasSuffix(certFile, "-key.pem") {
+			continue
+		}
+		if _, err := os.Stat(keyFile); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := renewFile(certFile, keyFile, ca, renewBefore, validity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renewFile(certFile, keyFile string, ca *certs.Cert, renewBefore, validity time.Duration) error {
+	renewed, err := certs.RenewFiles(certFile, keyFile, ca, renewBefore, validity)
+	if err != nil {
+		return fmt.Errorf("%s: %w", certFile, err)
+	}
+	if renewed {
+		fmt.Printf("Renewed %s for %s\n", certFile, validity)
+	}
+	return nil
+}
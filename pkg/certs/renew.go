@@ -0,0 +1,47 @@
+package certs
+
+import (
+	"crypto/x509"
+	"errors"
+	"time"
+)
+
+// ExpiresWithin returns whether the certificate expires in less than d
+func (c *Cert) ExpiresWithin(d time.Duration) bool {
+	return time.Until(c.Cert.NotAfter) < d
+}
+
+// Renew issues the certificate again for validity from now, with the same key,
+// subject, hosts and usages, signed by ca. A CA renews itself with a nil ca. Keeping
+// the key means a renewed CA still verifies the certificates it signed before.
+func (c *Cert) Renew(ca *Cert, validity time.Duration) (*Cert, error) {
+	if ca == nil && c.Cert.CheckSignatureFrom(c.Cert) != nil {
+		return nil, errors.New("only a self-signed certificate renews without a CA")
+	}
+	return sign(&x509.Certificate{
+		Subject:               c.Cert.Subject,
+		DNSNames:              c.Cert.DNSNames,
+		IPAddresses:           c.Cert.IPAddresses,
+		IsCA:                  c.Cert.IsCA,
+		BasicConstraintsValid: c.Cert.BasicConstraintsValid,
+		KeyUsage:              c.Cert.KeyUsage,
+		ExtKeyUsage:           c.Cert.ExtKeyUsage,
+	}, c.Key, ca, validity)
+}
+
+// RenewFiles renews the certificate in the PEM files when it expires within
+// renewBefore, and returns whether it did
+func RenewFiles(certFile, keyFile string, ca *Cert, renewBefore, validity time.Duration) (bool, error) {
+	cert, err := Load(certFile, keyFile)
+	if err != nil {
+		return false, err
+	}
+	if !cert.ExpiresWithin(renewBefore) {
+		return false, nil
+	}
+	renewed, err := cert.Renew(ca, validity)
+	if err != nil {
+		return false, err
+	}
+	return true, renewed.WriteFiles(certFile, keyFile)
+}
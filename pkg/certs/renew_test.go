@@ -0,0 +1,82 @@
+package certs
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenew(t *testing.T) {
+	ca, err := NewCA("test CA", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewServer(ca, "web", []string{"web.demo.svc", "127.0.0.1"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !server.ExpiresWithin(2*time.Hour) || server.ExpiresWithin(30*time.Minute) {
+		t.Errorf("Expected the certificate to expire within 2h and not within 30m, it expires at %s", server.Cert.NotAfter)
+	}
+
+	// the renewed CA has the key of the old one, so it verifies the server
+	// certificate signed before, and the renewed server certificate
+	renewedCA, err := ca.Renew(nil, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Renew CA error: %s", err)
+	}
+	renewed, err := server.Renew(renewedCA, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Renew error: %s", err)
+	}
+	if renewed.ExpiresWithin(23*time.Hour) || !renewed.Key.Equal(server.Key) {
+		t.Errorf("Expected the renewed certificate to keep the key and expire in 24h, it expires at %s", renewed.Cert.NotAfter)
+	}
+	if renewed.Cert.SerialNumber.Cmp(server.Cert.SerialNumber) == 0 {
+		t.Errorf("Expected a new serial number")
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(renewedCA.Cert)
+	for _, cert := range []*Cert{server, renewed} {
+		if _, err := cert.Cert.Verify(x509.VerifyOptions{DNSName: "127.0.0.1", Roots: roots}); err != nil {
+			t.Errorf("Expected the renewed CA to verify %s: %s", cert.Cert.SerialNumber, err)
+		}
+	}
+
+	if _, err := server.Renew(nil, time.Hour); err == nil {
+		t.Errorf("Expected an error renewing a certificate signed by a CA without it")
+	}
+}
+
+func TestRenewFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := NewCA("test CA", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(ca, "client", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	if err := client.WriteFiles(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+
+	renewed, err := RenewFiles(certFile, keyFile, ca, 30*time.Minute, 24*time.Hour)
+	if err != nil || renewed {
+		t.Errorf("Expected no renewal 1h before the expiry, got %t (%v)", renewed, err)
+	}
+	renewed, err = RenewFiles(certFile, keyFile, ca, 2*time.Hour, 24*time.Hour)
+	if err != nil || !renewed {
+		t.Fatalf("Expected a renewal 1h before the expiry, got %t (%v)", renewed, err)
+	}
+	loaded, err := Load(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Load error: %s", err)
+	}
+	if loaded.ExpiresWithin(23*time.Hour) || loaded.Cert.Subject.CommonName != "client" {
+		t.Errorf("Expected the renewed client certificate in the files, got %s until %s", loaded.Cert.Subject, loaded.Cert.NotAfter)
+	}
+}
@@ -0,0 +1,294 @@
+// Package config loads the configuration of the servers of this repository into a
+// struct, from the defaults, a YAML file, the environment and the flags, configured
+// with the tags of its fields
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileFlag is the flag of the YAML file, also read from the <EnvPrefix>CONFIG
+// environment variable
+const FileFlag = "config"
+
+// Validator is implemented by the configs that check their values once they're
+// loaded
+type Validator interface {
+	Validate() error
+}
+
+// Options configure Load
+type Options struct {
+	// Name is the name of the command in the errors of the flags
+	Name string
+	// EnvPrefix is put before the env tags, like TEST_SERVER_ for TEST_SERVER_PORT
+	EnvPrefix string
+	// File is the YAML file read when neither -config nor <EnvPrefix>CONFIG is set,
+	// none when empty
+	File string
+	// Output gets the usage and the errors of the flags, os.Stderr when nil
+	Output io.Writer
+}
+
+// Load returns defaults with the values of, from the lowest to the highest
+// precedence: the YAML file, the environment variables and the flags set in args.
+// The fields are configured with tags, the yaml tag for the file:
+//
+//	Port int `yaml:"port" env:"PORT" flag:"port" usage:"port to listen on"`
+//
+// The env and flag values of strings, bools, numbers, durations and comma separated
+// lists of strings are supported, of the fields of T and of the structs it embeds.
+// The config is validated when T implements Validator. It returns flag.ErrHelp for
+// -h.
+func Load[T any](defaults T, args []string, options Options) (T, error) {
+	config := defaults
+	fields, err := fieldsOf(reflect.ValueOf(&config).Elem())
+	if err != nil {
+		return defaults, err
+	}
+
+	flags, copies, path, err := parse(fields, args, options)
+	if err != nil {
+		return defaults, err
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return defaults, fmt.Errorf("can't read the config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return defaults, fmt.Errorf("can't parse the config file %s: %w", path, err)
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		name := options.EnvPrefix + f.env
+		if s, ok := os.LookupEnv(name); ok {
+			if err := set(f.value, s); err != nil {
+				return defaults, fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+	}
+
+	// only the flags that were set override the file and the environment
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		if copy := copies[f.Name]; copy != nil && flagErr == nil {
+			if err := copy(); err != nil {
+				flagErr = fmt.Errorf("invalid value %q for flag -%s: %w", f.Value, f.Name, err)
+			}
+		}
+	})
+	if flagErr != nil {
+		return defaults, flagErr
+	}
+
+	if validator, ok := any(&config).(Validator); ok {
+		return config, validator.Validate()
+	}
+	return config, nil
+}
+
+// File returns the YAML file Load reads with args and options, none when empty,
+// like to watch it for changes. It returns flag.ErrHelp for -h.
+func File[T any](defaults T, args []string, options Options) (string, error) {
+	fields, err := fieldsOf(reflect.ValueOf(&defaults).Elem())
+	if err != nil {
+		return "", err
+	}
+	options.Output = io.Discard
+	_, _, path, err := parse(fields, args, options)
+	return path, err
+}
+
+// parse parses the flags in args, and returns them with the functions copying
+// them to the fields, and the YAML file of -config, <EnvPrefix>CONFIG or
+// options.File
+func parse(fields []field, args []string, options Options) (*flag.FlagSet, map[string]func() error, string, error) {
+	flags := flag.NewFlagSet(options.Name, flag.ContinueOnError)
+	if options.Output != nil {
+		flags.SetOutput(options.Output)
+	}
+	file := flags.String(FileFlag, "", "YAML file with the configuration, overridden by the environment and the flags")
+	// the flags are parsed into copies of the fields, copied over once the file and
+	// the environment are read
+	copies := make(map[string]func() error)
+	for _, f := range fields {
+		if f.flag != "" {
+			copies[f.flag] = define(flags, f)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, "", err
+	}
+
+	path := *file
+	if path == "" {
+		path = os.Getenv(options.EnvPrefix + "CONFIG")
+	}
+	if path == "" {
+		path = options.File
+	}
+	return flags, copies, path, nil
+}
+
+// field is a field of the config with an env or a flag tag
+type field struct {
+	value            reflect.Value
+	env, flag, usage string
+}
+
+// fieldsOf returns the tagged fields of the struct v, and of the structs it embeds
+func fieldsOf(v reflect.Value) ([]field, error) {
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config of type %s is not a struct", v.Type())
+	}
+	var fields []field
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			embedded, err := fieldsOf(v.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+		f := field{
+			value: v.Field(i),
+			env:   structField.Tag.Get("env"),
+			flag:  structField.Tag.Get("flag"),
+			usage: structField.Tag.Get("usage"),
+		}
+		if f.env == "" && f.flag == "" {
+			continue
+		}
+		if !structField.IsExported() {
+			return nil, fmt.Errorf("field %s is not exported", structField.Name)
+		}
+		if err := set(reflect.New(f.value.Type()).Elem(), ""); errors.Is(err, errUnsupported) {
+			return nil, fmt.Errorf("field %s: %w", structField.Name, err)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var errUnsupported = errors.New("unsupported type")
+
+// set parses s into v
+func set(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%w %s", errUnsupported, v.Type())
+		}
+		list := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = reflect.Append(list, reflect.ValueOf(item).Convert(v.Type().Elem()))
+			}
+		}
+		v.Set(list)
+	default:
+		return fmt.Errorf("%w %s", errUnsupported, v.Type())
+	}
+	return nil
+}
+
+// format returns v as set parses it, for the defaults of the flags
+func format(v reflect.Value) string {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	if v.Kind() == reflect.Slice {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).String()
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// define defines the flag of f, with the types of the flag package for their help,
+// and returns the function copying its value to f
+func define(flags *flag.FlagSet, f field) func() error {
+	value := reflect.New(f.value.Type())
+	value.Elem().Set(f.value)
+	switch p := value.Interface().(type) {
+	case *string:
+		flags.StringVar(p, f.flag, *p, f.usage)
+	case *bool:
+		flags.BoolVar(p, f.flag, *p, f.usage)
+	case *int:
+		flags.IntVar(p, f.flag, *p, f.usage)
+	case *int64:
+		flags.Int64Var(p, f.flag, *p, f.usage)
+	case *uint:
+		flags.UintVar(p, f.flag, *p, f.usage)
+	case *uint64:
+		flags.Uint64Var(p, f.flag, *p, f.usage)
+	case *float64:
+		flags.Float64Var(p, f.flag, *p, f.usage)
+	case *time.Duration:
+		flags.DurationVar(p, f.flag, *p, f.usage)
+	default:
+		// the other types, like the lists, are parsed like the environment
+		text := flags.String(f.flag, format(f.value), f.usage)
+		return func() error { return set(f.value, *text) }
+	}
+	return func() error {
+		f.value.Set(value.Elem())
+		return nil
+	}
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type Embedded struct {
+	URL string `yaml:"url" env:"URL" flag:"url"`
+}
+
+type testConfig struct {
+	Embedded `yaml:",inline"`
+	Port     int           `yaml:"port" env:"PORT" flag:"port" usage:"port to listen on"`
+	Password string        `yaml:"password" env:"PASSWORD" flag:"password"`
+	Timeout  time.Duration `yaml:"timeout" env:"TIMEOUT" flag:"timeout"`
+	Rate     float64       `yaml:"rate" flag:"rate"`
+	Debug    bool          `yaml:"debug" env:"DEBUG" flag:"debug"`
+	Skip     []string      `yaml:"skip" env:"SKIP" flag:"skip"`
+	// FileOnly can only be set in the file
+	FileOnly string `yaml:"fileOnly"`
+}
+
+func (c testConfig) Validate() error {
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("port %d is not between 1 and 65535", c.Port)
+	}
+	return nil
+}
+
+func defaults() testConfig {
+	return testConfig{Port: 8080, Timeout: time.Second, Skip: []string{"/healthz"}}
+}
+
+func TestLoad(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte("url: http://file\nport: 9090\npassword: file\nrate: 0.5\nfileOnly: yes\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		file    string
+		check   func(c testConfig) error
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			check: func(c testConfig) error {
+				if c.Port != 8080 || c.Timeout != time.Second || fmt.Sprint(c.Skip) != "[/healthz]" {
+					return fmt.Errorf("expected the defaults, got %+v", c)
+				}
+				return nil
+			},
+		},
+		{
+			name: "file",
+			args: []string{"-config", configFile},
+			check: func(c testConfig) error {
+				if c.URL != "http://file" || c.Port != 9090 || c.Password != "file" || c.Rate != 0.5 || c.FileOnly != "yes" {
+					return fmt.Errorf("expected the values of the file, got %+v", c)
+				}
+				if c.Timeout != time.Second {
+					return fmt.Errorf("expected the defaults for the rest, got %+v", c)
+				}
+				return nil
+			},
+		},
+		{
+			name: "default file",
+			file: configFile,
+			check: func(c testConfig) error {
+				if c.Port != 9090 {
+					return fmt.Errorf("expected the port of the file, got %d", c.Port)
+				}
+				return nil
+			},
+		},
+		{
+			name: "file of the environment",
+			env:  map[string]string{"TEST_CONFIG": configFile},
+			check: func(c testConfig) error {
+				if c.Port != 9090 {
+					return fmt.Errorf("expected the port of the file, got %d", c.Port)
+				}
+				return nil
+			},
+		},
+		{
+			name: "environment overrides the file",
+			args: []string{"-config", configFile},
+			env:  map[string]string{"TEST_URL": "http://env", "TEST_PASSWORD": "env", "TEST_TIMEOUT": "5s", "TEST_DEBUG": "true", "TEST_SKIP": "/a, /b"},
+			check: func(c testConfig) error {
+				if c.URL != "http://env" || c.Password != "env" || c.Timeout != 5*time.Second || !c.Debug || fmt.Sprint(c.Skip) != "[/a /b]" {
+					return fmt.Errorf("expected the values of the environment, got %+v", c)
+				}
+				if c.Port != 9090 {
+					return fmt.Errorf("expected the file for the variables that aren't set, got %+v", c)
+				}
+				return nil
+			},
+		},
+		{
+			name: "flags override the environment",
+			args: []string{"-config", configFile, "-password", "flag", "-debug", "-port", "8080"},
+			env:  map[string]string{"TEST_PASSWORD": "env", "TEST_PORT": "7070"},
+			check: func(c testConfig) error {
+				if c.Password != "flag" || !c.Debug || c.Port != 8080 {
+					return fmt.Errorf("expected the values of the flags, got %+v", c)
+				}
+				if c.URL != "http://file" {
+					return fmt.Errorf("expected the file for the flags that aren't set, got %+v", c)
+				}
+				return nil
+			},
+		},
+		{name: "missing file", args: []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, wantErr: true},
+		{name: "invalid flag", args: []string{"-timeout", "soon"}, wantErr: true},
+		{name: "invalid environment", env: map[string]string{"TEST_PORT": "eighty"}, wantErr: true},
+		{name: "invalid config", args: []string{"-port", "70000"}, wantErr: true},
+		{name: "unknown flag", args: []string{"-unknown"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			config, err := Load(defaults(), test.args, Options{Name: "test", EnvPrefix: "TEST_", File: test.file, Output: io.Discard})
+			if test.wantErr {
+				if err == nil || err == flag.ErrHelp {
+					t.Fatalf("Expected an error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load error: %s", err)
+			}
+			if err := test.check(config); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	if _, err := Load(defaults(), []string{"-h"}, Options{Output: io.Discard}); err != flag.ErrHelp {
+		t.Errorf("Expected flag.ErrHelp, got %v", err)
+	}
+}
+
+func TestFile(t *testing.T) {
+	t.Setenv("TEST_CONFIG", "env.yaml")
+	options := Options{EnvPrefix: "TEST_", File: "default.yaml"}
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-port", "9090", "-config", "flag.yaml"}, "flag.yaml"},
+		{[]string{"-debug"}, "env.yaml"},
+	} {
+		if path, err := File(defaults(), test.args, options); err != nil || path != test.expected {
+			t.Errorf("Expected %s for %v, got %q, %v", test.expected, test.args, path, err)
+		}
+	}
+	os.Unsetenv("TEST_CONFIG")
+	if path, _ := File(defaults(), nil, options); path != "default.yaml" {
+		t.Errorf("Expected default.yaml, got %q", path)
+	}
+}
+
+func TestLoadUnsupported(t *testing.T) {
+	type unsupported struct {
+		Ports []int `flag:"ports"`
+	}
+	if _, err := Load(unsupported{}, nil, Options{}); !errors.Is(err, errUnsupported) {
+		t.Errorf("Expected an unsupported type error, got %v", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal)
+	applied := make(chan int)
+	done := make(chan struct{})
+
+	port := 0
+	go func() {
+		defer close(done)
+		reload(ctx, signals, func() (int, error) {
+			port++
+			if port == 2 {
+				return 0, errors.New("invalid config")
+			}
+			return port, nil
+		}, func(port int) {
+			applied <- port
+		})
+	}()
+
+	signals <- syscall.SIGHUP
+	if got := <-applied; got != 1 {
+		t.Errorf("Expected config 1, got %d", got)
+	}
+	// the invalid config 2 isn't applied
+	signals <- syscall.SIGHUP
+	signals <- syscall.SIGHUP
+	if got := <-applied; got != 3 {
+		t.Errorf("Expected config 3, got %d", got)
+	}
+	cancel()
+	<-done
+}
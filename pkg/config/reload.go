@@ -0,0 +1,52 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReloadOnSIGHUP loads the config again with load on every SIGHUP, and passes it
+// to apply, until ctx is done. A config that can't be loaded or isn't valid is
+// logged and not applied, so the service keeps the config it has:
+//
+//	go config.ReloadOnSIGHUP(ctx, func() (Config, error) {
+//		return config.Load(defaultConfig(), os.Args[1:], options)
+//	}, apply)
+func ReloadOnSIGHUP[T any](ctx context.Context, load func() (T, error), apply func(T)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	reload(ctx, signals, load, apply)
+}
+
+// Reloader returns a function loading the config again with load and passing it
+// to apply, like ReloadOnSIGHUP on every signal, to reload on other events like
+// the changes of the config file:
+//
+//	w.OnChange(func([]string) { reload() })
+func Reloader[T any](ctx context.Context, load func() (T, error), apply func(T)) func() {
+	return func() {
+		config, err := load()
+		if err != nil {
+			slog.ErrorContext(ctx, "Config not reloaded, keeping the current config", "error", err)
+			return
+		}
+		apply(config)
+		slog.InfoContext(ctx, "Config reloaded")
+	}
+}
+
+func reload[T any](ctx context.Context, signals <-chan os.Signal, load func() (T, error), apply func(T)) {
+	reloadNow := Reloader(ctx, load, apply)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+		}
+		reloadNow()
+	}
+}
@@ -0,0 +1,100 @@
+// Package jobs is a persistent work queue: jobs are enqueued in a store, in memory,
+// in a SQL database or in Redis, and a pool of workers processes them at least once,
+// retrying the failed ones with an exponential backoff, and moving the ones failing
+// too often to the dead letters
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Job is a unit of work of a queue
+type Job struct {
+	ID string `json:"id"`
+	// Payload is the JSON of the work, like the url to fetch
+	Payload json.RawMessage `json:"payload"`
+	// Attempts is the number of times the job was claimed, 1 on the first run
+	Attempts int `json:"attempts"`
+	// AvailableAt is when the job can be claimed: when it was enqueued, when it's
+	// retried, or when the lease of the worker running it expires
+	AvailableAt time.Time `json:"availableAt"`
+	// LastError is the error of the last attempt
+	LastError string    `json:"lastError,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Store keeps the jobs of a queue. A claimed job is leased to a worker: it stays
+// in the store, unavailable until the lease expires, so the job of a worker that
+// died is claimed again. Complete, Retry and Bury return ErrLeaseLost when the lease
+// of the job expired and it was claimed again.
+type Store interface {
+	// Enqueue adds a job
+	Enqueue(ctx context.Context, job Job) error
+	// Claim returns the job available the longest at now, with its Attempts
+	// incremented and available at the end of the lease, or ErrEmpty when none is
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (Job, error)
+	// Complete removes a claimed job
+	Complete(ctx context.Context, job Job) error
+	// Retry makes a claimed job available again at availableAt, with its LastError
+	Retry(ctx context.Context, job Job, availableAt time.Time) error
+	// Bury moves a claimed job to the dead letters
+	Bury(ctx context.Context, job Job) error
+	// Pending returns the number of jobs in the queue, the claimed ones included,
+	// without the dead letters
+	Pending(ctx context.Context) (int, error)
+	// Dead returns the dead letters, the oldest first
+	Dead(ctx context.Context) ([]Job, error)
+}
+
+var (
+	// ErrEmpty is returned by Claim when no job is available
+	ErrEmpty = errors.New("no job available")
+	// ErrLeaseLost is returned when the lease of a job expired and it was claimed
+	// again
+	ErrLeaseLost = errors.New("lease lost")
+)
+
+// Enqueue adds a job of the JSON of payload to the store, available now, and
+// returns its id
+func Enqueue(ctx context.Context, store Store, payload any) (string, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	now := time.Now()
+	job := Job{ID: hex.EncodeToString(id), Payload: b, AvailableAt: now, CreatedAt: now}
+	return job.ID, store.Enqueue(ctx, job)
+}
+
+// permanentError is an error of a job that retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (p permanentError) Error() string { return p.err.Error() }
+func (p permanentError) Unwrap() error { return p.err }
+
+// Permanent marks the error of a job as permanent, like a 404 for a url: the job is
+// moved to the dead letters without the retries
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked by Permanent
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+// leaseEnd returns the end of the lease of a claim at now, in milliseconds like the
+// stores keep the times, so the lease of a job can be compared
+func leaseEnd(now time.Time, lease time.Duration) time.Time {
+	return time.UnixMilli(now.Add(lease).UnixMilli())
+}
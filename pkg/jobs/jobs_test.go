@@ -0,0 +1,187 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testStore checks the claims, the leases and the dead letters of a store
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	now := time.Now()
+	for i, payload := range []string{"first", "second"} {
+		b, _ := json.Marshal(payload)
+		job := Job{ID: payload, Payload: b, AvailableAt: now.Add(time.Duration(i-2) * time.Second), CreatedAt: now}
+		if err := store.Enqueue(ctx, job); err != nil {
+			t.Fatalf("Enqueue error: %s", err)
+		}
+	}
+	if pending, err := store.Pending(ctx); err != nil || pending != 2 {
+		t.Errorf("Expected 2 pending jobs, got %d, %v", pending, err)
+	}
+
+	// the job available the longest first
+	first, err := store.Claim(ctx, now, time.Minute)
+	if err != nil || first.ID != "first" || first.Attempts != 1 || string(first.Payload) != `"first"` {
+		t.Fatalf("Expected the first job, got %+v, %v", first, err)
+	}
+	// a second later, so its lease ends after the one of the first job
+	second, err := store.Claim(ctx, now.Add(time.Second), time.Minute)
+	if err != nil || second.ID != "second" {
+		t.Fatalf("Expected the second job, got %+v, %v", second, err)
+	}
+	if _, err := store.Claim(ctx, now, time.Minute); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("Expected no job while they're leased, got %v", err)
+	}
+
+	// the lease of the first one expires, another worker claims it again
+	again, err := store.Claim(ctx, now.Add(2*time.Minute), time.Minute)
+	if err != nil || again.ID != "first" || again.Attempts != 2 {
+		t.Fatalf("Expected the first job again after its lease, got %+v, %v", again, err)
+	}
+	if err := store.Complete(ctx, first); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Expected the lease to be lost, got %v", err)
+	}
+	if err := store.Complete(ctx, again); err != nil {
+		t.Errorf("Complete error: %s", err)
+	}
+
+	// the second one is retried later, then buried
+	second.LastError = "503 Service Unavailable"
+	if err := store.Retry(ctx, second, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Retry error: %s", err)
+	}
+	if _, err := store.Claim(ctx, now.Add(time.Minute), time.Minute); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("Expected no job before the retry, got %v", err)
+	}
+	retried, err := store.Claim(ctx, now.Add(time.Hour), time.Minute)
+	if err != nil || retried.Attempts != 2 || retried.LastError != "503 Service Unavailable" {
+		t.Fatalf("Expected the retried job, got %+v, %v", retried, err)
+	}
+	retried.LastError = "404 Not Found"
+	if err := store.Bury(ctx, retried); err != nil {
+		t.Fatalf("Bury error: %s", err)
+	}
+	if pending, err := store.Pending(ctx); err != nil || pending != 0 {
+		t.Errorf("Expected no pending jobs, got %d, %v", pending, err)
+	}
+	dead, err := store.Dead(ctx)
+	if err != nil || len(dead) != 1 || dead[0].ID != "second" || dead[0].LastError != "404 Not Found" {
+		t.Errorf("Expected the second job in the dead letters, got %+v, %v", dead, err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory())
+}
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestPool(t *testing.T) {
+	store := NewMemory()
+	for i := range 20 {
+		if _, err := Enqueue(context.Background(), store, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 0 is processed, 1 fails twice then succeeds, 2 always fails, 3 is permanent
+	var mu sync.Mutex
+	attempts := map[int]int{}
+	var running, concurrent atomic.Int32
+	pool := Pool{
+		Store: store,
+		Handler: func(ctx context.Context, job Job) error {
+			if n := running.Add(1); n > concurrent.Load() {
+				concurrent.Store(n)
+			}
+			defer running.Add(-1)
+			time.Sleep(5 * time.Millisecond)
+
+			var i int
+			json.Unmarshal(job.Payload, &i)
+			mu.Lock()
+			attempts[i]++
+			mu.Unlock()
+			switch {
+			case i == 1 && job.Attempts < 3, i == 2:
+				return errors.New("503 Service Unavailable")
+			case i == 3:
+				return Permanent(errors.New("404 Not Found"))
+			case i == 4 && job.Attempts == 1:
+				panic("oops")
+			}
+			return nil
+		},
+		Concurrency:   4,
+		MaxAttempts:   3,
+		Backoff:       time.Millisecond,
+		PollInterval:  time.Millisecond,
+		StopWhenEmpty: true,
+		Logger:        testLogger,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.Run(ctx); err != nil {
+		t.Fatalf("Run error: %s", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Expected the pool to stop when the store is empty")
+	}
+
+	if attempts[0] != 1 || attempts[1] != 3 || attempts[2] != 3 || attempts[3] != 1 || attempts[4] != 2 {
+		t.Errorf("Unexpected attempts %v", attempts)
+	}
+	if concurrent.Load() < 2 || concurrent.Load() > 4 {
+		t.Errorf("Expected up to 4 concurrent jobs, got %d", concurrent.Load())
+	}
+	dead, _ := store.Dead(context.Background())
+	if len(dead) != 2 || dead[0].LastError == dead[1].LastError {
+		t.Errorf("Expected 2 dead letters, got %+v", dead)
+	}
+}
+
+func TestLease(t *testing.T) {
+	// the handler of a job whose lease expires is canceled, and the job is claimed
+	// again
+	store := NewMemory()
+	Enqueue(context.Background(), store, "slow")
+	var calls atomic.Int32
+	pool := Pool{
+		Store: store,
+		Handler: func(ctx context.Context, job Job) error {
+			if calls.Add(1) == 1 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		},
+		Lease:         20 * time.Millisecond,
+		Backoff:       time.Millisecond,
+		PollInterval:  time.Millisecond,
+		StopWhenEmpty: true,
+		Logger:        testLogger,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	pool.Run(ctx)
+	if calls.Load() != 2 {
+		t.Errorf("Expected the job to run again after its lease, got %d calls", calls.Load())
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Pool{Backoff: time.Second, MaxBackoff: 10 * time.Second}
+	for attempts, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: 10 * time.Second} {
+		if d := p.backoff(attempts); d < expected/2 || d > expected {
+			t.Errorf("Expected a backoff between %s and %s after %d attempts, got %s", expected/2, expected, attempts, d)
+		}
+	}
+}
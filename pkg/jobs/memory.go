@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is a store in memory, for the tests and for the queues of a process that
+// can be lost
+type Memory struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+	dead []Job
+}
+
+// NewMemory returns an empty store in memory
+func NewMemory() *Memory {
+	return &Memory{jobs: map[string]Job{}}
+}
+
+// Enqueue adds a job
+func (m *Memory) Enqueue(ctx context.Context, job Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job.AvailableAt = time.UnixMilli(job.AvailableAt.UnixMilli())
+	m.jobs[job.ID] = job
+	return nil
+}
+
+// Claim returns the job available the longest
+func (m *Memory) Claim(ctx context.Context, now time.Time, lease time.Duration) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var claimed *Job
+	for _, job := range m.jobs {
+		if job.AvailableAt.After(now) || (claimed != nil && !job.AvailableAt.Before(claimed.AvailableAt)) {
+			continue
+		}
+		claimed = &job
+	}
+	if claimed == nil {
+		return Job{}, ErrEmpty
+	}
+	claimed.Attempts++
+	claimed.AvailableAt = leaseEnd(now, lease)
+	m.jobs[claimed.ID] = *claimed
+	return *claimed, nil
+}
+
+// leased returns an error unless job is in the store with the same lease
+func (m *Memory) leased(job Job) error {
+	if stored, ok := m.jobs[job.ID]; !ok || !stored.AvailableAt.Equal(job.AvailableAt) {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Complete removes a claimed job
+func (m *Memory) Complete(ctx context.Context, job Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.leased(job); err != nil {
+		return err
+	}
+	delete(m.jobs, job.ID)
+	return nil
+}
+
+// Retry makes a claimed job available again
+func (m *Memory) Retry(ctx context.Context, job Job, availableAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.leased(job); err != nil {
+		return err
+	}
+	job.AvailableAt = time.UnixMilli(availableAt.UnixMilli())
+	m.jobs[job.ID] = job
+	return nil
+}
+
+// Bury moves a claimed job to the dead letters
+func (m *Memory) Bury(ctx context.Context, job Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.leased(job); err != nil {
+		return err
+	}
+	delete(m.jobs, job.ID)
+	m.dead = append(m.dead, job)
+	return nil
+}
+
+// Pending returns the number of jobs
+func (m *Memory) Pending(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.jobs), nil
+}
+
+// Dead returns the dead letters
+func (m *Memory) Dead(ctx context.Context) ([]Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Job(nil), m.dead...), nil
+}
@@ -0,0 +1,157 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Handler processes a job. The context is canceled when the lease of the job
+// expires. The job is retried when it returns an error, unless the error is
+// Permanent.
+type Handler func(ctx context.Context, job Job) error
+
+// Pool runs workers claiming the jobs of a store and processing them with a handler
+type Pool struct {
+	Store   Store
+	Handler Handler
+	// Concurrency is the number of workers, 1 when 0
+	Concurrency int
+	// MaxAttempts is the number of attempts before a job is moved to the dead
+	// letters, 5 when 0
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled for every other one up to
+	// MaxBackoff, with a random part up to half of it. 1s and 1h when 0.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Lease is the time a worker has to process a job before another one claims it,
+	// 5 minutes when 0
+	Lease time.Duration
+	// PollInterval is the wait before claiming a job again when none is available,
+	// 1s when 0
+	PollInterval time.Duration
+	// StopWhenEmpty returns from Run when the store has no jobs left, the retried
+	// ones included
+	StopWhenEmpty bool
+	// Logger logs the failed jobs, slog.Default() when nil
+	Logger *slog.Logger
+}
+
+// Run runs the workers until the context is done, or until the store is empty with
+// StopWhenEmpty. The jobs being processed when the context is done aren't
+// canceled, Run returns when they are done.
+func (p *Pool) Run(ctx context.Context) error {
+	if p.Store == nil || p.Handler == nil {
+		return errors.New("a store and a handler are required")
+	}
+	concurrency := max(p.Concurrency, 1)
+	var workers sync.WaitGroup
+	for range concurrency {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			p.work(ctx)
+		}()
+	}
+	workers.Wait()
+	return nil
+}
+
+// work claims and processes jobs until the context is done
+func (p *Pool) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := p.Store.Claim(ctx, time.Now(), p.lease())
+		if err == nil {
+			p.process(ctx, job)
+			continue
+		}
+		if errors.Is(err, ErrEmpty) && p.StopWhenEmpty {
+			if pending, err := p.Store.Pending(ctx); err == nil && pending == 0 {
+				return
+			}
+		} else if !errors.Is(err, ErrEmpty) && ctx.Err() == nil {
+			p.logger().Error("Can't claim a job", "error", err)
+		}
+		timer := time.NewTimer(withDefault(p.PollInterval, time.Second))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// process runs the handler, and completes, retries or buries the job
+func (p *Pool) process(ctx context.Context, job Job) {
+	// stopping the pool lets the running jobs finish, within their lease
+	handlerCtx, cancel := context.WithDeadline(context.WithoutCancel(ctx), job.AvailableAt)
+	err := handle(handlerCtx, p.Handler, job)
+	cancel()
+
+	// the store is updated after Ctrl-C too
+	ctx = context.WithoutCancel(ctx)
+	logger := p.logger().With("job", job.ID, "attempts", job.Attempts)
+	switch {
+	case err == nil:
+		err = p.Store.Complete(ctx, job)
+	case IsPermanent(err) || job.Attempts >= withDefault(p.MaxAttempts, 5):
+		logger.Error("Job failed, moved to the dead letters", "error", err)
+		job.LastError = err.Error()
+		err = p.Store.Bury(ctx, job)
+	default:
+		retryAt := time.Now().Add(p.backoff(job.Attempts))
+		logger.Warn("Job failed, retrying", "error", err, "at", retryAt)
+		job.LastError = err.Error()
+		err = p.Store.Retry(ctx, job, retryAt)
+	}
+	if errors.Is(err, ErrLeaseLost) {
+		logger.Warn("Job processed after its lease, it was claimed again")
+	} else if err != nil {
+		logger.Error("Can't update the job", "error", err)
+	}
+}
+
+// handle calls handler, returning its panic as an error
+func handle(ctx context.Context, handler Handler, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// backoff returns the wait before the retry of a job that failed attempts times
+func (p *Pool) backoff(attempts int) time.Duration {
+	d := withDefault(p.Backoff, time.Second)
+	maxBackoff := withDefault(p.MaxBackoff, time.Hour)
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, maxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
+
+func (p *Pool) lease() time.Duration {
+	return withDefault(p.Lease, 5*time.Minute)
+}
+
+func (p *Pool) logger() *slog.Logger {
+	if p.Logger != nil {
+		return p.Logger
+	}
+	return slog.Default()
+}
+
+// withDefault returns v, or def when v is 0
+func withDefault[T comparable](v, def T) T {
+	var zero T
+	if v == zero {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,284 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Redis is a store in Redis. The ids of the jobs of a queue are in the sorted set
+// jobs:<queue>, scored by the Unix milliseconds when they're available, the jobs
+// are JSON strings in jobs:<queue>:<id>, and the dead letters are in the list
+// jobs:<queue>:dead. The updates of a claimed job are transactions checking its
+// lease with WATCH, so a worker can't update a job another worker claimed again.
+type Redis struct {
+	url   string
+	queue string
+
+	// mu serializes the commands on the connection, a transaction runs on the
+	// connection of its WATCH
+	mu   sync.Mutex
+	conn *redisConn
+}
+
+// NewRedis returns the store of a queue in the Redis of a url, like
+// redis://:password@localhost:6379/0
+func NewRedis(ctx context.Context, url, queue string) (*Redis, error) {
+	r := &Redis{url: url, queue: queue}
+	if err := r.run(ctx, func(c *redisConn) error {
+		_, err := c.do(ctx, "PING")
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Redis) key() string             { return "jobs:" + r.queue }
+func (r *Redis) jobKey(id string) string { return "jobs:" + r.queue + ":" + id }
+func (r *Redis) deadKey() string         { return "jobs:" + r.queue + ":dead" }
+
+// run runs f on the connection, connecting when there's none. The connection is
+// closed after a network error, and a new one is made for the next command.
+func (r *Redis) run(ctx context.Context, f func(c *redisConn) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		conn, err := dialRedis(ctx, r.url)
+		if err != nil {
+			return err
+		}
+		r.conn = conn
+	}
+	err := f(r.conn)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) && !errors.Is(err, ErrEmpty) && !errors.Is(err, ErrLeaseLost) && !errors.Is(err, errConflict) {
+		r.conn.Close()
+		r.conn = nil
+	}
+	return err
+}
+
+// errConflict is returned when a watched key changed before the transaction
+var errConflict = errors.New("conflict")
+
+// transaction runs the commands in MULTI and EXEC, after WATCH of the key of the
+// queue. It returns errConflict when the key changed since WATCH.
+func transaction(ctx context.Context, c *redisConn, commands ...[]string) error {
+	if _, err := c.do(ctx, "MULTI"); err != nil {
+		return err
+	}
+	for _, command := range commands {
+		if _, err := c.do(ctx, command...); err != nil {
+			c.do(ctx, "DISCARD")
+			return err
+		}
+	}
+	reply, err := c.do(ctx, "EXEC")
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		return errConflict
+	}
+	return nil
+}
+
+// Enqueue adds a job
+func (r *Redis) Enqueue(ctx context.Context, job Job) error {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return r.run(ctx, func(c *redisConn) error {
+		return transaction(ctx, c,
+			[]string{"SET", r.jobKey(job.ID), string(b)},
+			[]string{"ZADD", r.key(), strconv.FormatInt(job.AvailableAt.UnixMilli(), 10), job.ID},
+		)
+	})
+}
+
+// Claim returns the job available the longest
+func (r *Redis) Claim(ctx context.Context, now time.Time, lease time.Duration) (Job, error) {
+	var job Job
+	err := r.run(ctx, func(c *redisConn) error {
+		for {
+			if _, err := c.do(ctx, "WATCH", r.key()); err != nil {
+				return err
+			}
+			reply, err := c.do(ctx, "ZRANGEBYSCORE", r.key(), "-inf", strconv.FormatInt(now.UnixMilli(), 10), "LIMIT", "0", "1")
+			if err != nil {
+				return err
+			}
+			ids, _ := reply.([]any)
+			if len(ids) == 0 {
+				c.do(ctx, "UNWATCH")
+				return ErrEmpty
+			}
+			id, _ := ids[0].(string)
+			if job, err = r.get(ctx, c, id); err != nil {
+				c.do(ctx, "UNWATCH")
+				return err
+			}
+			job.Attempts++
+			job.AvailableAt = leaseEnd(now, lease)
+			err = r.update(ctx, c, job)
+			if errors.Is(err, errConflict) {
+				// claimed by another worker in between
+				continue
+			}
+			return err
+		}
+	})
+	return job, err
+}
+
+// get returns the job of an id
+func (r *Redis) get(ctx context.Context, c *redisConn, id string) (Job, error) {
+	reply, err := c.do(ctx, "GET", r.jobKey(id))
+	if err != nil {
+		return Job{}, err
+	}
+	s, ok := reply.(string)
+	if !ok {
+		return Job{}, fmt.Errorf("job %s: not found", id)
+	}
+	var job Job
+	if err := json.Unmarshal([]byte(s), &job); err != nil {
+		return Job{}, fmt.Errorf("job %s: %s", id, err)
+	}
+	return job, nil
+}
+
+// update saves a job available at its AvailableAt, in a transaction
+func (r *Redis) update(ctx context.Context, c *redisConn, job Job) error {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return transaction(ctx, c,
+		[]string{"SET", r.jobKey(job.ID), string(b)},
+		[]string{"ZADD", r.key(), strconv.FormatInt(job.AvailableAt.UnixMilli(), 10), job.ID},
+	)
+}
+
+// leased watches the key of the queue, and returns ErrLeaseLost unless the job is
+// in it with the same lease
+func (r *Redis) leased(ctx context.Context, c *redisConn, job Job) error {
+	if _, err := c.do(ctx, "WATCH", r.key()); err != nil {
+		return err
+	}
+	reply, err := c.do(ctx, "ZSCORE", r.key(), job.ID)
+	if err != nil {
+		return err
+	}
+	// the scores are doubles, like 1.7605404e+12
+	score, _ := reply.(string)
+	if f, err := strconv.ParseFloat(score, 64); err != nil || int64(f) != job.AvailableAt.UnixMilli() {
+		c.do(ctx, "UNWATCH")
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// leasedTransaction runs the commands when the job still has its lease. The key
+// of the queue also changes when another job is claimed, so the lease is checked
+// again after a conflict.
+func (r *Redis) leasedTransaction(ctx context.Context, job Job, commands ...[]string) error {
+	return r.run(ctx, func(c *redisConn) error {
+		for {
+			if err := r.leased(ctx, c, job); err != nil {
+				return err
+			}
+			err := transaction(ctx, c, commands...)
+			if !errors.Is(err, errConflict) {
+				return err
+			}
+		}
+	})
+}
+
+// Complete removes a claimed job
+func (r *Redis) Complete(ctx context.Context, job Job) error {
+	return r.leasedTransaction(ctx, job,
+		[]string{"ZREM", r.key(), job.ID},
+		[]string{"DEL", r.jobKey(job.ID)},
+	)
+}
+
+// Retry makes a claimed job available again
+func (r *Redis) Retry(ctx context.Context, job Job, availableAt time.Time) error {
+	lease := job.AvailableAt
+	job.AvailableAt = time.UnixMilli(availableAt.UnixMilli())
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	job.AvailableAt = lease
+	return r.leasedTransaction(ctx, job,
+		[]string{"SET", r.jobKey(job.ID), string(b)},
+		[]string{"ZADD", r.key(), strconv.FormatInt(availableAt.UnixMilli(), 10), job.ID},
+	)
+}
+
+// Bury moves a claimed job to the dead letters
+func (r *Redis) Bury(ctx context.Context, job Job) error {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return r.leasedTransaction(ctx, job,
+		[]string{"ZREM", r.key(), job.ID},
+		[]string{"DEL", r.jobKey(job.ID)},
+		[]string{"RPUSH", r.deadKey(), string(b)},
+	)
+}
+
+// Pending returns the number of jobs of the queue
+func (r *Redis) Pending(ctx context.Context) (int, error) {
+	var n int64
+	err := r.run(ctx, func(c *redisConn) error {
+		reply, err := c.do(ctx, "ZCARD", r.key())
+		n, _ = reply.(int64)
+		return err
+	})
+	return int(n), err
+}
+
+// Dead returns the dead letters of the queue
+func (r *Redis) Dead(ctx context.Context) ([]Job, error) {
+	var dead []Job
+	err := r.run(ctx, func(c *redisConn) error {
+		reply, err := c.do(ctx, "LRANGE", r.deadKey(), "0", "-1")
+		if err != nil {
+			return err
+		}
+		items, _ := reply.([]any)
+		for _, item := range items {
+			s, _ := item.(string)
+			var job Job
+			if err := json.Unmarshal([]byte(s), &job); err != nil {
+				return fmt.Errorf("dead letter: %s", err)
+			}
+			dead = append(dead, job)
+		}
+		return nil
+	})
+	return dead, err
+}
+
+// Close closes the connection
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
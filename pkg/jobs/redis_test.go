@@ -0,0 +1,319 @@
+package jobs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a Redis server with the commands of the Redis store, and the
+// optimistic locking of WATCH
+type fakeRedis struct {
+	mu       sync.Mutex
+	strings  map[string]string
+	zsets    map[string]map[string]float64
+	lists    map[string][]string
+	versions map[string]int
+	password string
+}
+
+// newFakeRedis serves a fake Redis, and returns its url
+func newFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	f := &fakeRedis{
+		strings:  map[string]string{},
+		zsets:    map[string]map[string]float64{},
+		lists:    map[string][]string{},
+		versions: map[string]int{},
+		password: password,
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	if password != "" {
+		return f, "redis://:" + password + "@" + listener.Addr().String() + "/0"
+	}
+	return f, "redis://" + listener.Addr().String()
+}
+
+// session is the state of a connection
+type session struct {
+	authenticated bool
+	watched       map[string]int
+	queued        [][]string
+	multi         bool
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	s := &session{authenticated: f.password == ""}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		io.WriteString(conn, f.handle(s, args))
+	}
+}
+
+// readCommand reads an array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(line, "\r\n")
+	}
+	return args, nil
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func array(items []string) string {
+	reply := fmt.Sprintf("*%d\r\n", len(items))
+	for _, item := range items {
+		reply += bulk(item)
+	}
+	return reply
+}
+
+func (f *fakeRedis) handle(s *session, args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	command := strings.ToUpper(args[0])
+	switch {
+	case command == "AUTH":
+		if args[len(args)-1] != f.password {
+			return "-WRONGPASS invalid username-password pair\r\n"
+		}
+		s.authenticated = true
+		return "+OK\r\n"
+	case !s.authenticated:
+		return "-NOAUTH Authentication required.\r\n"
+	case command == "MULTI":
+		s.multi = true
+		return "+OK\r\n"
+	case command == "DISCARD":
+		s.multi, s.queued, s.watched = false, nil, nil
+		return "+OK\r\n"
+	case command == "EXEC":
+		conflict := false
+		for key, version := range s.watched {
+			conflict = conflict || f.versions[key] != version
+		}
+		queued := s.queued
+		s.multi, s.queued, s.watched = false, nil, nil
+		if conflict {
+			return "*-1\r\n"
+		}
+		reply := fmt.Sprintf("*%d\r\n", len(queued))
+		for _, args := range queued {
+			reply += f.run(s, args)
+		}
+		return reply
+	case s.multi:
+		s.queued = append(s.queued, args)
+		return "+QUEUED\r\n"
+	}
+	return f.run(s, args)
+}
+
+// run runs a command outside of a transaction
+func (f *fakeRedis) run(s *session, args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "WATCH":
+		if s.watched == nil {
+			s.watched = map[string]int{}
+		}
+		for _, key := range args[1:] {
+			s.watched[key] = f.versions[key]
+		}
+		return "+OK\r\n"
+	case "UNWATCH":
+		s.watched = nil
+		return "+OK\r\n"
+	case "SET":
+		f.strings[args[1]] = args[2]
+		f.versions[args[1]]++
+		return "+OK\r\n"
+	case "GET":
+		value, ok := f.strings[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(value)
+	case "DEL":
+		_, ok := f.strings[args[1]]
+		delete(f.strings, args[1])
+		f.versions[args[1]]++
+		if ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "ZADD":
+		zset := f.zsets[args[1]]
+		if zset == nil {
+			zset = map[string]float64{}
+			f.zsets[args[1]] = zset
+		}
+		score, _ := strconv.ParseFloat(args[2], 64)
+		zset[args[3]] = score
+		f.versions[args[1]]++
+		return ":1\r\n"
+	case "ZREM":
+		delete(f.zsets[args[1]], args[2])
+		f.versions[args[1]]++
+		return ":1\r\n"
+	case "ZSCORE":
+		score, ok := f.zsets[args[1]][args[2]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		// like Redis, large integers are formatted as doubles
+		return bulk(strconv.FormatFloat(score, 'g', -1, 64))
+	case "ZCARD":
+		return fmt.Sprintf(":%d\r\n", len(f.zsets[args[1]]))
+	case "ZRANGEBYSCORE":
+		maxScore, _ := strconv.ParseFloat(args[3], 64)
+		if args[2] != "-inf" {
+			return "-ERR only -inf is supported\r\n"
+		}
+		var ids []string
+		for id, score := range f.zsets[args[1]] {
+			if score <= maxScore {
+				ids = append(ids, id)
+			}
+		}
+		zset := f.zsets[args[1]]
+		sort.Slice(ids, func(i, j int) bool {
+			if zset[ids[i]] != zset[ids[j]] {
+				return zset[ids[i]] < zset[ids[j]]
+			}
+			return ids[i] < ids[j]
+		})
+		if len(args) == 7 && strings.ToUpper(args[4]) == "LIMIT" {
+			count, _ := strconv.Atoi(args[6])
+			ids = ids[:min(count, len(ids))]
+		}
+		return array(ids)
+	case "RPUSH":
+		f.lists[args[1]] = append(f.lists[args[1]], args[2:]...)
+		f.versions[args[1]]++
+		return fmt.Sprintf(":%d\r\n", len(f.lists[args[1]]))
+	case "LRANGE":
+		return array(f.lists[args[1]])
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func TestRedis(t *testing.T) {
+	_, url := newFakeRedis(t, "secret")
+	store, err := NewRedis(context.Background(), url, "test")
+	if err != nil {
+		t.Fatalf("NewRedis error: %s", err)
+	}
+	defer store.Close()
+	testStore(t, store)
+}
+
+func TestRedisAuth(t *testing.T) {
+	_, url := newFakeRedis(t, "secret")
+	_, err := NewRedis(context.Background(), strings.Replace(url, "secret", "wrong", 1), "test")
+	if err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Expected an authentication error, got %v", err)
+	}
+	if _, err := NewRedis(context.Background(), "localhost:6379", "test"); err == nil {
+		t.Error("Expected an error for a url without redis://")
+	}
+}
+
+func TestRedisWorkers(t *testing.T) {
+	// two processes, each with its connection, claim the jobs of the same queue
+	_, url := newFakeRedis(t, "")
+	var stores [2]*Redis
+	for i := range stores {
+		store, err := NewRedis(context.Background(), url, "fetch")
+		if err != nil {
+			t.Fatalf("NewRedis error: %s", err)
+		}
+		defer store.Close()
+		stores[i] = store
+	}
+	for i := range 30 {
+		if _, err := Enqueue(context.Background(), stores[0], i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	processed := map[string]int{}
+	var wg sync.WaitGroup
+	for _, store := range stores {
+		pool := Pool{
+			Store: store,
+			Handler: func(ctx context.Context, job Job) error {
+				mu.Lock()
+				defer mu.Unlock()
+				processed[string(job.Payload)]++
+				return nil
+			},
+			Concurrency:   3,
+			PollInterval:  time.Millisecond,
+			StopWhenEmpty: true,
+			Logger:        testLogger,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.Run(context.Background())
+		}()
+	}
+	wg.Wait()
+	if len(processed) != 30 {
+		t.Errorf("Expected 30 jobs processed, got %d", len(processed))
+	}
+	for payload, n := range processed {
+		if n != 1 {
+			t.Errorf("Expected job %s to be processed once, got %d times", payload, n)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect is the flavor of SQL of a database
+type Dialect string
+
+const (
+	// SQLite uses ? placeholders, like with the modernc.org/sqlite and
+	// github.com/mattn/go-sqlite3 drivers
+	SQLite Dialect = "sqlite"
+	// Postgres uses $1 placeholders, like with the github.com/jackc/pgx/v5/stdlib
+	// driver
+	Postgres Dialect = "postgres"
+)
+
+// schema is the table of the jobs of all the queues. The times are Unix
+// milliseconds, the dead letters have a dead_at.
+const schema = `CREATE TABLE IF NOT EXISTS jobs (
+	id TEXT PRIMARY KEY,
+	queue TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	available_at BIGINT NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at BIGINT NOT NULL,
+	dead_at BIGINT
+);
+CREATE INDEX IF NOT EXISTS jobs_available ON jobs (queue, dead_at, available_at)`
+
+// SQL is a store in a table of a SQL database, SQLite or PostgreSQL, through
+// database/sql. The program imports the driver.
+type SQL struct {
+	db      *sql.DB
+	dialect Dialect
+	queue   string
+}
+
+// NewSQL returns the store of a queue in db, creating the jobs table when it doesn't
+// exist
+func NewSQL(ctx context.Context, db *sql.DB, dialect Dialect, queue string) (*SQL, error) {
+	if dialect != SQLite && dialect != Postgres {
+		return nil, fmt.Errorf("invalid dialect %q: sqlite or postgres expected", dialect)
+	}
+	for _, statement := range strings.Split(schema, ";\n") {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("create the jobs table: %w", err)
+		}
+	}
+	return &SQL{db: db, dialect: dialect, queue: queue}, nil
+}
+
+// query returns query with the placeholders of the dialect, $1, $2... instead of ?
+// for Postgres
+func (s *SQL) query(query string) string {
+	if s.dialect != Postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// exec runs a statement updating a claimed job, and returns ErrLeaseLost when it
+// updated no row
+func (s *SQL) exec(ctx context.Context, query string, args ...any) error {
+	result, err := s.db.ExecContext(ctx, s.query(query), args...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Enqueue adds a job
+func (s *SQL) Enqueue(ctx context.Context, job Job) error {
+	_, err := s.db.ExecContext(ctx, s.query(`INSERT INTO jobs (id, queue, payload, attempts, available_at, last_error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		job.ID, s.queue, string(job.Payload), job.Attempts, job.AvailableAt.UnixMilli(), job.LastError, job.CreatedAt.UnixMilli())
+	return err
+}
+
+// Claim returns the job available the longest. The job is selected, then claimed
+// if it's still available, so two workers can't claim the same job, without locking
+// the table.
+func (s *SQL) Claim(ctx context.Context, now time.Time, lease time.Duration) (Job, error) {
+	for {
+		var job Job
+		var payload string
+		var availableAt, createdAt int64
+		err := s.db.QueryRowContext(ctx, s.query(`SELECT id, payload, attempts, available_at, last_error, created_at FROM jobs
+			WHERE queue = ? AND dead_at IS NULL AND available_at <= ? ORDER BY available_at LIMIT 1`), s.queue, now.UnixMilli()).
+			Scan(&job.ID, &payload, &job.Attempts, &availableAt, &job.LastError, &createdAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return Job{}, ErrEmpty
+		}
+		if err != nil {
+			return Job{}, err
+		}
+
+		end := leaseEnd(now, lease)
+		err = s.exec(ctx, `UPDATE jobs SET attempts = attempts + 1, available_at = ? WHERE id = ? AND available_at = ? AND dead_at IS NULL`,
+			end.UnixMilli(), job.ID, availableAt)
+		if errors.Is(err, ErrLeaseLost) {
+			// claimed by another worker in between
+			continue
+		}
+		if err != nil {
+			return Job{}, err
+		}
+		job.Payload = []byte(payload)
+		job.Attempts++
+		job.AvailableAt = end
+		job.CreatedAt = time.UnixMilli(createdAt)
+		return job, nil
+	}
+}
+
+// Complete removes a claimed job
+func (s *SQL) Complete(ctx context.Context, job Job) error {
+	return s.exec(ctx, `DELETE FROM jobs WHERE id = ? AND available_at = ? AND dead_at IS NULL`, job.ID, job.AvailableAt.UnixMilli())
+}
+
+// Retry makes a claimed job available again
+func (s *SQL) Retry(ctx context.Context, job Job, availableAt time.Time) error {
+	return s.exec(ctx, `UPDATE jobs SET available_at = ?, last_error = ? WHERE id = ? AND available_at = ? AND dead_at IS NULL`,
+		availableAt.UnixMilli(), job.LastError, job.ID, job.AvailableAt.UnixMilli())
+}
+
+// Bury moves a claimed job to the dead letters
+func (s *SQL) Bury(ctx context.Context, job Job) error {
+	return s.exec(ctx, `UPDATE jobs SET dead_at = ?, last_error = ? WHERE id = ? AND available_at = ? AND dead_at IS NULL`,
+		time.Now().UnixMilli(), job.LastError, job.ID, job.AvailableAt.UnixMilli())
+}
+
+// Pending returns the number of jobs of the queue
+func (s *SQL) Pending(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, s.query(`SELECT COUNT(*) FROM jobs WHERE queue = ? AND dead_at IS NULL`), s.queue).Scan(&n)
+	return n, err
+}
+
+// Dead returns the dead letters of the queue
+func (s *SQL) Dead(ctx context.Context) ([]Job, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(`SELECT id, payload, attempts, available_at, last_error, created_at FROM jobs
+		WHERE queue = ? AND dead_at IS NOT NULL ORDER BY dead_at`), s.queue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var dead []Job
+	for rows.Next() {
+		var job Job
+		var payload string
+		var availableAt, createdAt int64
+		if err := rows.Scan(&job.ID, &payload, &job.Attempts, &availableAt, &job.LastError, &createdAt); err != nil {
+			return nil, err
+		}
+		job.Payload = []byte(payload)
+		job.AvailableAt = time.UnixMilli(availableAt)
+		job.CreatedAt = time.UnixMilli(createdAt)
+		dead = append(dead, job)
+	}
+	return dead, rows.Err()
+}
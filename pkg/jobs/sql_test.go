@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestSQL returns the store of queue in db
+func newTestSQL(t *testing.T, db *sql.DB, queue string) *SQL {
+	t.Helper()
+	store, err := NewSQL(context.Background(), db, SQLite, queue)
+	if err != nil {
+		t.Fatalf("NewSQL error: %s", err)
+	}
+	return store
+}
+
+// openTestDB opens a SQLite database in memory. Every connection to :memory: has
+// its own database, so the pool keeps a single one.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQL(t *testing.T) {
+	testStore(t, newTestSQL(t, openTestDB(t), "test"))
+}
+
+func TestSQLQueues(t *testing.T) {
+	db := openTestDB(t)
+	fetch := newTestSQL(t, db, "fetch")
+	// the table exists already
+	other := newTestSQL(t, db, "other")
+	if _, err := Enqueue(context.Background(), fetch, "https://example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err := other.Pending(context.Background()); err != nil || pending != 0 {
+		t.Errorf("Expected no job in another queue, got %d, %v", pending, err)
+	}
+	if pending, err := fetch.Pending(context.Background()); err != nil || pending != 1 {
+		t.Errorf("Expected a job in the queue, got %d, %v", pending, err)
+	}
+}
+
+func TestSQLPlaceholders(t *testing.T) {
+	query := "UPDATE jobs SET available_at = ? WHERE id = ? AND available_at = ?"
+	if got := (&SQL{dialect: SQLite}).query(query); got != query {
+		t.Errorf("Expected the query unchanged for SQLite, got %s", got)
+	}
+	expected := "UPDATE jobs SET available_at = $1 WHERE id = $2 AND available_at = $3"
+	if got := (&SQL{dialect: Postgres}).query(query); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if _, err := NewSQL(context.Background(), nil, "mysql", "fetch"); err == nil {
+		t.Error("Expected an invalid dialect")
+	}
+}
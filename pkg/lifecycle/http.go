@@ -0,0 +1,49 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// HTTPServer serves server on listener, over TLS when server has a TLSConfig with
+// the certificates, or on server.Addr when listener is nil. Stopping it drains the
+// connections, and closes the ones left when the timeout is over.
+func HTTPServer(name string, server *http.Server, listener net.Listener) Component {
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			if listener != nil {
+				return nil
+			}
+			var err error
+			listener, err = net.Listen("tcp", server.Addr)
+			return err
+		},
+		Run: func(ctx context.Context) error {
+			var err error
+			if server.TLSConfig != nil {
+				err = server.ServeTLS(listener, "", "")
+			} else {
+				err = server.Serve(listener)
+			}
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return err
+		},
+		Stop: func(ctx context.Context) error {
+			if err := server.Shutdown(ctx); err != nil {
+				server.Close()
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// Func runs run until its context is done, like a ticker or a pool of workers
+func Func(name string, run func(ctx context.Context) error) Component {
+	return Component{Name: name, Run: run}
+}
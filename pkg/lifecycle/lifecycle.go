@@ -0,0 +1,159 @@
+// Package lifecycle starts the components of a service in order, like the telemetry,
+// the HTTP servers and the workers, and stops them in the reverse order when the
+// context is done or one of them fails, each within its own timeout
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DefaultStopTimeout is the time a component has to stop when its StopTimeout isn't set
+const DefaultStopTimeout = 10 * time.Second
+
+// Component is a part of a service. All the functions are optional.
+type Component struct {
+	Name string
+	// Start prepares the component, before the next components start, like binding
+	// a port. When it fails, the components started before it are stopped.
+	Start func(ctx context.Context) error
+	// Run runs the component until its context is done, in a goroutine. The service
+	// stops when it returns early, with or without an error.
+	Run func(ctx context.Context) error
+	// Stop stops the component gracefully, like draining the connections of a
+	// server, before the context of Run is done
+	Stop func(ctx context.Context) error
+	// StopTimeout is the time Stop and Run have to return, DefaultStopTimeout when 0
+	StopTimeout time.Duration
+}
+
+// Group runs components
+type Group struct {
+	components []Component
+	// Logger logs the start and the stop of the components, slog.Default() when nil
+	Logger *slog.Logger
+}
+
+// Add adds components, started after the ones added before them and stopped before
+// them
+func (g *Group) Add(components ...Component) {
+	g.components = append(g.components, components...)
+}
+
+// started is a component that was started, with its Run running
+type started struct {
+	Component
+	cancel context.CancelFunc
+	done   chan struct{}
+	// stopping is set before Stop, after which Run may return, like Serve does
+	stopping atomic.Bool
+}
+
+// Run starts the components in order and runs them until ctx is done or one of
+// them returns from Run, then stops them in the reverse order. It returns the
+// errors of the components, joined, nil when they all stopped cleanly.
+func (g *Group) Run(ctx context.Context) error {
+	logger := g.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	// exited gets the components returning from Run before they're stopped, and the
+	// errors of the others
+	exited := make(chan error, len(g.components))
+	var running []*started
+	var errs []error
+	for _, c := range g.components {
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+				break
+			}
+		}
+		// the context of Run is only done when the component is stopped, in order
+		runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		s := &started{Component: c, cancel: cancel, done: make(chan struct{})}
+		running = append(running, s)
+		go func() {
+			defer close(s.done)
+			if s.Run == nil {
+				<-runCtx.Done()
+				return
+			}
+			err := s.Run(runCtx)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", s.Name, err)
+			}
+			if !s.stopping.Load() {
+				// returned before it was stopped, the service stops
+				if err == nil {
+					logger.Info("Component exited", "component", s.Name)
+				}
+				exited <- err
+			} else if err != nil {
+				exited <- err
+			}
+		}()
+		logger.Debug("Component started", "component", c.Name)
+	}
+
+	if len(errs) == 0 {
+		select {
+		case <-ctx.Done():
+		case err := <-exited:
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for i := len(running) - 1; i >= 0; i-- {
+		if err := stop(running[i]); err != nil {
+			errs = append(errs, err)
+		}
+		logger.Debug("Component stopped", "component", running[i].Name)
+	}
+	// the errors of Run returned while stopping
+	for len(exited) > 0 {
+		if err := <-exited; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stop calls Stop, then ends the context of Run and waits for it to return, within
+// the timeout of the component
+func stop(s *started) error {
+	timeout := s.StopTimeout
+	if timeout == 0 {
+		timeout = DefaultStopTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	s.stopping.Store(true)
+	var err error
+	if s.Stop != nil {
+		if err = s.Stop(ctx); err != nil {
+			err = fmt.Errorf("%s: stop: %w", s.Name, err)
+		}
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		err = errors.Join(err, fmt.Errorf("%s: not stopped within %s", s.Name, timeout))
+	}
+	return err
+}
+
+// SignalContext returns a context done on Ctrl-C or SIGTERM, for Run
+func SignalContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+}
@@ -0,0 +1,184 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder records the starts and stops of the components
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.events, " ")
+}
+
+// component records its start and stop, and the end of its Run
+func (r *recorder) component(name string) Component {
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			r.record("start " + name)
+			return nil
+		},
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			r.record("done " + name)
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			r.record("stop " + name)
+			return nil
+		},
+	}
+}
+
+func newGroup() *Group {
+	return &Group{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+func TestRunOrder(t *testing.T) {
+	r := &recorder{}
+	g := newGroup()
+	g.Add(r.component("a"), r.component("b"), r.component("c"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	if err := g.Run(ctx); err != nil {
+		t.Fatalf("Run error: %s", err)
+	}
+	expected := "start a start b start c stop c done c stop b done b stop a done a"
+	if r.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, r.String())
+	}
+}
+
+func TestRunStartError(t *testing.T) {
+	r := &recorder{}
+	g := newGroup()
+	failing := r.component("b")
+	failing.Start = func(ctx context.Context) error {
+		return errors.New("port in use")
+	}
+	g.Add(r.component("a"), failing, r.component("c"))
+
+	err := g.Run(context.Background())
+	if err == nil || err.Error() != "b: port in use" {
+		t.Errorf("Expected the error of b, got %v", err)
+	}
+	expected := "start a stop a done a"
+	if r.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, r.String())
+	}
+}
+
+func TestRunExit(t *testing.T) {
+	for _, runErr := range []error{nil, errors.New("connection lost")} {
+		t.Run(fmt.Sprint(runErr), func(t *testing.T) {
+			r := &recorder{}
+			g := newGroup()
+			exiting := r.component("b")
+			exiting.Run = func(ctx context.Context) error {
+				return runErr
+			}
+			g.Add(r.component("a"), exiting)
+
+			err := g.Run(context.Background())
+			if !errors.Is(err, runErr) {
+				t.Errorf("Expected %v, got %v", runErr, err)
+			}
+			expected := "start a start b stop b stop a done a"
+			if r.String() != expected {
+				t.Errorf("Expected %q, got %q", expected, r.String())
+			}
+		})
+	}
+}
+
+func TestRunStopErrors(t *testing.T) {
+	r := &recorder{}
+	g := newGroup()
+	failing := r.component("a")
+	failing.Stop = func(ctx context.Context) error {
+		return errors.New("flush failed")
+	}
+	stuck := r.component("b")
+	stuck.Run = func(ctx context.Context) error {
+		select {}
+	}
+	stuck.StopTimeout = 10 * time.Millisecond
+	g.Add(failing, stuck)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := g.Run(ctx)
+	for _, expected := range []string{"b: not stopped within 10ms", "a: stop: flush failed"} {
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected %q in the errors, got %v", expected, err)
+		}
+	}
+}
+
+func TestHTTPServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a request in flight when the server stops is answered
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("ok"))
+	})}
+	g := newGroup()
+	g.Add(HTTPServer("http", server, listener))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- g.Run(ctx)
+	}()
+	body := make(chan string, 1)
+	go func() {
+		res, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		body <- string(b)
+	}()
+	<-started
+	cancel()
+
+	if b := <-body; b != "ok" {
+		t.Errorf("Expected the request in flight to be answered, got %q", b)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("Run error: %s", err)
+	}
+}
@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header of the request id, also logged by the test-server
+// and sent by the wordsclient
+const RequestIDHeader = "X-Request-ID"
+
+// Middleware puts the X-Request-ID of the request, or a new one, in the context of
+// the request and in the response header, so the records of the handler have it
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
+	})
+}
+
+// NewRequestID returns 16 random bytes, hex encoded
+func NewRequestID() string {
+	b := make([]byte, 16)
+	// rand.Read never fails on the supported platforms
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
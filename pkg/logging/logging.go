@@ -0,0 +1,144 @@
+// Package logging configures slog for the servers and clients of this repository:
+// JSON or text output, the level from the environment, the request and trace ids
+// of the context on every record, and secrets redacted
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats of the output
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Redacted replaces the values of the secret attributes
+const Redacted = "[REDACTED]"
+
+// DefaultRedactKeys are redacted in every logger: an attribute is redacted when its
+// key, in lowercase and without - and _, ends with one of them, like client_secret
+// or X-Api-Key
+var DefaultRedactKeys = []string{"password", "secret", "token", "authorization", "cookie", "apikey", "privatekey"}
+
+// Options configure a logger
+type Options struct {
+	// Level is the minimum level of the records, slog.LevelInfo when nil
+	Level slog.Leveler
+	// Format is FormatText (default) or FormatJSON
+	Format string
+	// AddSource adds the file and line of the log call
+	AddSource bool
+	// RedactKeys are redacted in addition to DefaultRedactKeys
+	RedactKeys []string
+}
+
+// FromEnv returns the options of the LOG_LEVEL (debug, info, warn or error) and
+// LOG_FORMAT (text or json) environment variables
+func FromEnv() (Options, error) {
+	options := Options{Format: os.Getenv("LOG_FORMAT")}
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(level)); err != nil {
+			return options, fmt.Errorf("invalid LOG_LEVEL %q: %s", level, err)
+		}
+		options.Level = l
+	}
+	switch options.Format {
+	case "", FormatText, FormatJSON:
+	default:
+		return options, fmt.Errorf("invalid LOG_FORMAT %q: not text or json", options.Format)
+	}
+	return options, nil
+}
+
+// New returns a logger writing to w
+func New(w io.Writer, options Options) *slog.Logger {
+	redactKeys := append(append([]string{}, DefaultRedactKeys...), options.RedactKeys...)
+	handlerOptions := &slog.HandlerOptions{
+		Level:     options.Level,
+		AddSource: options.AddSource,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if isSecret(a.Key, redactKeys) {
+				return slog.String(a.Key, Redacted)
+			}
+			return a
+		},
+	}
+	var handler slog.Handler
+	if options.Format == FormatJSON {
+		handler = slog.NewJSONHandler(w, handlerOptions)
+	} else {
+		handler = slog.NewTextHandler(w, handlerOptions)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// Setup creates a logger writing to stderr with the options of the environment,
+// and makes it the default logger, also of the log package. It returns the error
+// of the environment after setting up a logger with the defaults, so it can be
+// logged.
+func Setup() (*slog.Logger, error) {
+	options, err := FromEnv()
+	if err != nil {
+		options = Options{}
+	}
+	logger := New(os.Stderr, options)
+	slog.SetDefault(logger)
+	return logger, err
+}
+
+// isSecret reports whether the key ends with one of the redacted keys
+func isSecret(key string, redactKeys []string) bool {
+	key = strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+	for _, redactKey := range redactKeys {
+		if strings.HasSuffix(key, redactKey) {
+			return true
+		}
+	}
+	return false
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx with the request id logged by the records of ctx
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request id of ctx, "" if it has none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request id, and the trace and span ids of the span of
+// the context, to the records logged with a context, like with InfoContext
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		r.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()), slog.String("span_id", spanContext.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
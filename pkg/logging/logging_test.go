@@ -0,0 +1,131 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record logs a message with the logger and returns the JSON record
+func record(t *testing.T, log func(logger *slog.Logger)) map[string]any {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	log(New(buf, Options{Format: FormatJSON, Level: slog.LevelDebug, RedactKeys: []string{"ssn"}}))
+	var r map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &r); err != nil {
+		t.Fatalf("invalid JSON record %q: %s", buf, err)
+	}
+	return r
+}
+
+func TestRedact(t *testing.T) {
+	r := record(t, func(logger *slog.Logger) {
+		logger.Info("login", "user", "admin", "password", "secret", "X-Api-Key", "k", "client_secret", "s",
+			"ssn", "123", slog.Group("request", "Authorization", "Bearer abc", "path", "/login"))
+	})
+	for _, key := range []string{"password", "X-Api-Key", "client_secret", "ssn"} {
+		if r[key] != Redacted {
+			t.Errorf("%s is %v, want %s", key, r[key], Redacted)
+		}
+	}
+	if r["user"] != "admin" {
+		t.Errorf("user is %v, want admin", r["user"])
+	}
+	request := r["request"].(map[string]any)
+	if request["Authorization"] != Redacted || request["path"] != "/login" {
+		t.Errorf("got request group %v", request)
+	}
+}
+
+func TestContextIDs(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	ctx = WithRequestID(ctx, "my-id")
+
+	r := record(t, func(logger *slog.Logger) {
+		logger.With("component", "test").InfoContext(ctx, "hello")
+	})
+	expected := map[string]string{
+		"request_id": "my-id",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+		"component":  "test",
+	}
+	for key, value := range expected {
+		if r[key] != value {
+			t.Errorf("%s is %v, want %s", key, r[key], value)
+		}
+	}
+
+	r = record(t, func(logger *slog.Logger) { logger.Info("no context") })
+	for _, key := range []string{"request_id", "trace_id", "span_id"} {
+		if _, ok := r[key]; ok {
+			t.Errorf("%s logged without a context", key)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		level, format string
+		expected      Options
+		wantErr       bool
+	}{
+		{expected: Options{}},
+		{level: "debug", format: "json", expected: Options{Level: slog.LevelDebug, Format: FormatJSON}},
+		{level: "WARN", format: "text", expected: Options{Level: slog.LevelWarn, Format: FormatText}},
+		{level: "loud", wantErr: true},
+		{format: "xml", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Setenv("LOG_LEVEL", test.level)
+		t.Setenv("LOG_FORMAT", test.format)
+		options, err := FromEnv()
+		if (err != nil) != test.wantErr {
+			t.Errorf("LOG_LEVEL=%q LOG_FORMAT=%q: error %v", test.level, test.format, err)
+			continue
+		}
+		if !test.wantErr && (options.Level != test.expected.Level || options.Format != test.expected.Format) {
+			t.Errorf("LOG_LEVEL=%q LOG_FORMAT=%q: got %+v, want %+v", test.level, test.format, options, test.expected)
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := New(buf, Options{Level: slog.LevelWarn})
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "level=WARN msg=shown") {
+		t.Errorf("got %q", buf)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var id string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "client-id")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if id != "client-id" || rec.Header().Get(RequestIDHeader) != "client-id" {
+		t.Errorf("got id %q and header %q, want the id of the client", id, rec.Header().Get(RequestIDHeader))
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(id) != 32 || rec.Header().Get(RequestIDHeader) != id {
+		t.Errorf("got id %q and header %q, want a new id", id, rec.Header().Get(RequestIDHeader))
+	}
+}
@@ -0,0 +1,215 @@
+// Package migrate applies versioned SQL migrations to a database, like the tables of
+// the words-db, from files usually embedded in the program. The applied versions
+// are recorded in the schema_migrations table, so every migration runs once.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect is the flavor of SQL of a database
+type Dialect string
+
+const (
+	// SQLite uses ? placeholders, like with the modernc.org/sqlite and
+	// github.com/mattn/go-sqlite3 drivers
+	SQLite Dialect = "sqlite"
+	// Postgres uses $1 placeholders, like with the github.com/jackc/pgx/v5/stdlib
+	// driver
+	Postgres Dialect = "postgres"
+)
+
+// lockID is the key of the PostgreSQL advisory lock taken while migrating
+const lockID = 7_209_454_301
+
+// schema is the table of the applied migrations, applied_at is in Unix
+// milliseconds
+const schema = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at BIGINT NOT NULL
+)`
+
+// Migration is a version of the schema
+type Migration struct {
+	Version int64
+	Name    string
+	// SQL is run at once in a transaction. The drivers of SQLite and of PostgreSQL
+	// (without arguments) run the statements separated by ; one after the other.
+	SQL string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+// Status is a migration and when it was applied, zero when it's pending
+type Status struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// Load returns the migrations of the .sql files in dir of fsys, named
+// <version>_<name>.sql like 001_create_words.sql, sorted by version
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	versions := make(map[int64]string)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file %s: <version>_<name>.sql expected", entry.Name())
+		}
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("migration files %s and %s have the same version", other, entry.Name())
+		}
+		versions[version] = entry.Name()
+		b, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(b)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies the migrations that weren't, in the order of their versions, every one
+// in a transaction with the record of its version, and returns the ones it applied.
+// It stops at the first that fails, the ones before stay applied. On PostgreSQL the
+// instances of a service starting together wait for each other with an advisory
+// lock, on SQLite the one that loses the race fails, its transaction is rolled back.
+func Up(ctx context.Context, db *sql.DB, dialect Dialect, migrations []Migration) ([]Migration, error) {
+	insert, err := insertQuery(dialect)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if dialect == Postgres {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+			return nil, fmt.Errorf("lock the migrations: %w", err)
+		}
+		defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID)
+	}
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, m := range migrations {
+		if a, ok := applied[m.Version]; ok {
+			if a.Name != m.Name {
+				return done, fmt.Errorf("migration %d is %s in the database, not %s", m.Version, a, m)
+			}
+			continue
+		}
+		if err := apply(ctx, conn, insert, m); err != nil {
+			return done, fmt.Errorf("migration %s: %w", m, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// apply runs a migration and records it in a transaction
+func apply(ctx context.Context, conn *sql.Conn, insert string, m Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, insert, m.Version, m.Name, time.Now().UnixMilli()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Statuses returns the migrations with when they were applied, and the migrations
+// applied to the database that aren't in migrations, like by a newer version of the
+// program, sorted by version
+func Statuses(ctx context.Context, db *sql.DB, dialect Dialect, migrations []Migration) ([]Status, error) {
+	if _, err := insertQuery(dialect); err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []Status
+	for _, m := range migrations {
+		status := Status{Migration: m}
+		if a, ok := applied[m.Version]; ok {
+			status.AppliedAt = a.AppliedAt
+			delete(applied, m.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, a := range applied {
+		statuses = append(statuses, a)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// appliedMigrations creates the table of the migrations when it doesn't exist, and
+// returns the applied ones by version, without their SQL
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]Status, error) {
+	if _, err := conn.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("create the schema_migrations table: %w", err)
+	}
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]Status)
+	for rows.Next() {
+		var s Status
+		var appliedAt int64
+		if err := rows.Scan(&s.Version, &s.Name, &appliedAt); err != nil {
+			return nil, err
+		}
+		s.AppliedAt = time.UnixMilli(appliedAt)
+		applied[s.Version] = s
+	}
+	return applied, rows.Err()
+}
+
+func insertQuery(dialect Dialect) (string, error) {
+	switch dialect {
+	case SQLite:
+		return "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", nil
+	case Postgres:
+		return "INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)", nil
+	default:
+		return "", fmt.Errorf("invalid dialect %q: sqlite or postgres expected", dialect)
+	}
+}
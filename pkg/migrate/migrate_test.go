@@ -0,0 +1,202 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// fakeDB is a database/sql driver recording the statements, with the rows of the
+// schema_migrations table. A statement with FAIL in it fails.
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []string
+	// migrations are the rows of schema_migrations
+	migrations [][]driver.Value
+}
+
+func (f *fakeDB) Connect(ctx context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                            { return nil }
+
+func (f *fakeDB) executed() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.statements...)
+}
+
+type fakeConn struct {
+	db *fakeDB
+	// pending are the changes of the transaction, nil outside of one
+	pending []func()
+	inTx    bool
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements aren't supported")
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	for _, change := range c.pending {
+		change()
+	}
+	c.pending, c.inTx = nil, false
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.pending, c.inTx = nil, false
+	return nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "FAIL") {
+		return nil, errors.New("syntax error")
+	}
+	change := func() {
+		c.db.mu.Lock()
+		defer c.db.mu.Unlock()
+		c.db.statements = append(c.db.statements, query)
+		if strings.HasPrefix(query, "INSERT INTO schema_migrations") {
+			c.db.migrations = append(c.db.migrations, []driver.Value{args[0].Value, args[1].Value, args[2].Value})
+		}
+	}
+	if c.inTx {
+		c.pending = append(c.pending, change)
+	} else {
+		change()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.HasPrefix(query, "SELECT version, name, applied_at FROM schema_migrations") {
+		return nil, errors.New("unexpected query " + query)
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	return &fakeRows{rows: append([][]driver.Value{}, c.db.migrations...)}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version", "name", "applied_at"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var files = fstest.MapFS{
+	"migrations/001_create_words.sql":     {Data: []byte("CREATE TABLE words (word TEXT)")},
+	"migrations/002_index_words_word.sql": {Data: []byte("CREATE INDEX words_word ON words (word)")},
+	"migrations/README.md":                {Data: []byte("not a migration")},
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(files, "migrations")
+	if err != nil {
+		t.Fatalf("Load error: %s", err)
+	}
+	if len(migrations) != 2 || migrations[0].String() != "1_create_words" || migrations[1].Version != 2 || migrations[1].SQL != "CREATE INDEX words_word ON words (word)" {
+		t.Errorf("Unexpected migrations %+v", migrations)
+	}
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"no version":   {"migrations/create_words.sql": {}},
+		"same version": {"migrations/1_a.sql": {}, "migrations/001_b.sql": {}},
+	} {
+		if _, err := Load(fsys, "migrations"); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
+
+func TestUp(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeDB{}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+	migrations, _ := Load(files, "migrations")
+
+	applied, err := Up(ctx, db, SQLite, migrations[:1])
+	if err != nil || len(applied) != 1 {
+		t.Fatalf("Expected 1 migration applied, got %v, %v", applied, err)
+	}
+	// the next version of the program has a new migration
+	applied, err = Up(ctx, db, SQLite, migrations)
+	if err != nil || len(applied) != 1 || applied[0].Version != 2 {
+		t.Fatalf("Expected migration 2 applied, got %v, %v", applied, err)
+	}
+	if applied, err := Up(ctx, db, SQLite, migrations); err != nil || len(applied) != 0 {
+		t.Errorf("Expected no migration applied again, got %v, %v", applied, err)
+	}
+	var ran []string
+	for _, statement := range fake.executed() {
+		if strings.HasPrefix(statement, "CREATE TABLE words") || strings.HasPrefix(statement, "CREATE INDEX") {
+			ran = append(ran, statement)
+		}
+	}
+	if len(ran) != 2 {
+		t.Errorf("Expected every migration to run once, got %v", ran)
+	}
+
+	statuses, err := Statuses(ctx, db, SQLite, migrations)
+	if err != nil || len(statuses) != 2 || statuses[0].AppliedAt.IsZero() || statuses[1].AppliedAt.IsZero() {
+		t.Errorf("Expected 2 applied migrations, got %+v, %v", statuses, err)
+	}
+
+	// a renamed migration is another one
+	renamed := append([]Migration{}, migrations...)
+	renamed[1].Name = "other"
+	if _, err := Up(ctx, db, SQLite, renamed); err == nil || !strings.Contains(err.Error(), "2_index_words_word in the database") {
+		t.Errorf("Expected a mismatch error, got %v", err)
+	}
+}
+
+func TestUpFailure(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeDB{}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+	migrations := []Migration{
+		{Version: 1, Name: "create_words", SQL: "CREATE TABLE words (word TEXT)"},
+		{Version: 2, Name: "broken", SQL: "FAIL"},
+		{Version: 3, Name: "never", SQL: "CREATE INDEX words_word ON words (word)"},
+	}
+	applied, err := Up(ctx, db, Postgres, migrations)
+	if err == nil || !strings.Contains(err.Error(), "migration 2_broken: syntax error") || len(applied) != 1 {
+		t.Fatalf("Expected migration 2 to fail after 1, got %v, %v", applied, err)
+	}
+
+	statuses, _ := Statuses(ctx, db, Postgres, migrations)
+	if statuses[0].AppliedAt.IsZero() || !statuses[1].AppliedAt.IsZero() || !statuses[2].AppliedAt.IsZero() {
+		t.Errorf("Expected only migration 1 applied, got %+v", statuses)
+	}
+	executed := strings.Join(fake.executed(), "\n")
+	for _, expected := range []string{"SELECT pg_advisory_lock($1)", "VALUES ($1, $2, $3)", "SELECT pg_advisory_unlock($1)"} {
+		if !strings.Contains(executed, expected) {
+			t.Errorf("Expected %q in the statements:\n%s", expected, executed)
+		}
+	}
+
+	if _, err := Up(ctx, db, "mysql", migrations); err == nil {
+		t.Error("Expected an error for an unsupported dialect")
+	}
+}
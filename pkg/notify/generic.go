@@ -0,0 +1,36 @@
+package notify
+
+import "context"
+
+// Webhook sends the messages to any webhook as JSON, like
+// {"title":"...","text":"...","severity":"error","fields":[{"name":"...","value":"..."}]},
+// for a receiver of its own or an incident tool
+type Webhook struct {
+	webhook *webhook
+}
+
+// NewWebhook returns a notifier posting to url
+func NewWebhook(url string, options Options) *Webhook {
+	return &Webhook{webhook: newWebhook("webhook", url, options)}
+}
+
+type webhookPayload struct {
+	Title    string         `json:"title"`
+	Text     string         `json:"text,omitempty"`
+	Severity Severity       `json:"severity"`
+	Fields   []webhookField `json:"fields,omitempty"`
+}
+
+type webhookField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Notify posts message to the webhook
+func (w *Webhook) Notify(ctx context.Context, message Message) error {
+	payload := webhookPayload{Title: message.Title, Text: message.Text, Severity: message.Severity}
+	for _, field := range message.Fields {
+		payload.Fields = append(payload.Fields, webhookField{Name: field.Name, Value: field.Value})
+	}
+	return w.webhook.post(ctx, payload)
+}
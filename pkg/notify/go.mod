@@ -0,0 +1,7 @@
+module notify
+
+go 1.22.0
+
+require secrets v0.0.0-00010101000000-000000000000
+
+replace secrets => ../secrets
@@ -0,0 +1,126 @@
+// Package notify sends messages to Slack and Microsoft Teams channels with their
+// incoming webhooks, or to any webhook as JSON, rendered from templates, retried when the webhook fails, and
+// rate limited, so the jobs of this repository can alert a channel when they fail
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"secrets"
+)
+
+// Severity is the color of a message
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Field is a name and a value shown in a message, like a table row
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Message is a message to a channel
+type Message struct {
+	Title    string
+	Text     string
+	Severity Severity
+	Fields   []Field
+}
+
+// Notifier sends messages to a channel
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+// Multi sends the messages to every notifier
+type Multi []Notifier
+
+// Notify sends message to every notifier, even when one fails, and returns their
+// errors, joined
+func (m Multi) Notify(ctx context.Context, message Message) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Template renders messages with text/template, from the fields of the data passed
+// to Message
+type Template struct {
+	title *template.Template
+	text  *template.Template
+}
+
+// NewTemplate parses the templates of the title and the text of the messages, like
+// "{{len .Failed}} certificates expire soon"
+func NewTemplate(title, text string) (*Template, error) {
+	t := &Template{}
+	var err error
+	if t.title, err = template.New("title").Parse(title); err != nil {
+		return nil, err
+	}
+	if t.text, err = template.New("text").Parse(text); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// MustTemplate is NewTemplate for the templates of the code, it panics when one
+// isn't valid
+func MustTemplate(title, text string) *Template {
+	t, err := NewTemplate(title, text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Message returns the message of the templates rendered with data
+func (t *Template) Message(severity Severity, data any, fields ...Field) (Message, error) {
+	var title, text bytes.Buffer
+	if err := t.title.Execute(&title, data); err != nil {
+		return Message{}, fmt.Errorf("render title: %s", err)
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("render text: %s", err)
+	}
+	return Message{Title: title.String(), Text: text.String(), Severity: severity, Fields: fields}, nil
+}
+
+// FromSecrets returns the notifiers of the webhook urls of the secrets
+// slack-webhook-url, teams-webhook-url and webhook-url, like
+// NOTIFY_SLACK_WEBHOOK_URL with secrets.FromEnv("NOTIFY_"), none when none is set. The urls are secrets, anyone
+// having one can post to the channel.
+func FromSecrets(ctx context.Context, store secrets.Store, options Options) (Multi, error) {
+	var notifiers Multi
+	for _, webhook := range []struct {
+		name string
+		new  func(url string, options Options) Notifier
+	}{
+		{name: "slack-webhook-url", new: func(url string, options Options) Notifier { return NewSlack(url, options) }},
+		{name: "teams-webhook-url", new: func(url string, options Options) Notifier { return NewTeams(url, options) }},
+		{name: "webhook-url", new: func(url string, options Options) Notifier { return NewWebhook(url, options) }},
+	} {
+		url, err := store.Get(ctx, webhook.name)
+		if errors.Is(err, secrets.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, webhook.new(url, options))
+	}
+	return notifiers, nil
+}
@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"secrets"
+)
+
+// channel records the payloads posted to a webhook, replying with the statuses of
+// replies first, then 200
+type channel struct {
+	mu       sync.Mutex
+	payloads []string
+	times    []time.Time
+	replies  []int
+}
+
+func (c *channel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.times = append(c.times, time.Now())
+	if len(c.replies) > 0 {
+		status := c.replies[0]
+		c.replies = c.replies[1:]
+		w.WriteHeader(status)
+		w.Write([]byte("no_service"))
+		return
+	}
+	c.payloads = append(c.payloads, string(body))
+	w.Write([]byte("ok"))
+}
+
+func newChannel(t *testing.T, replies ...int) (*channel, *httptest.Server) {
+	c := &channel{replies: replies}
+	server := httptest.NewServer(c)
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+var testOptions = Options{Backoff: time.Millisecond, Interval: time.Millisecond}
+
+func TestSlack(t *testing.T) {
+	c, server := newChannel(t)
+	slack := NewSlack(server.URL, testOptions)
+
+	err := slack.Notify(context.Background(), Message{
+		Title:    "2 certificates expire soon",
+		Text:     "Renew them",
+		Severity: SeverityWarning,
+		Fields:   []Field{{Name: "example.com", Value: "expires in 3 days"}},
+	})
+	if err != nil {
+		t.Fatalf("Notify error: %s", err)
+	}
+	var payload slackPayload
+	if err := json.Unmarshal([]byte(c.payloads[0]), &payload); err != nil {
+		t.Fatal(err)
+	}
+	attachment := payload.Attachments[0]
+	if payload.Text != "2 certificates expire soon" || attachment.Color != slackColors[SeverityWarning] || attachment.Fields[0].Title != "example.com" {
+		t.Errorf("Unexpected payload %s", c.payloads[0])
+	}
+}
+
+func TestTeams(t *testing.T) {
+	c, server := newChannel(t)
+	teams := NewTeams(server.URL, testOptions)
+
+	if err := teams.Notify(context.Background(), Message{Title: "Rate limiter failed", Severity: SeverityError, Fields: []Field{{Name: "Requests", Value: "42"}}}); err != nil {
+		t.Fatalf("Notify error: %s", err)
+	}
+	var payload teamsPayload
+	if err := json.Unmarshal([]byte(c.payloads[0]), &payload); err != nil {
+		t.Fatal(err)
+	}
+	card := payload.Attachments[0].Content
+	if card.Type != "AdaptiveCard" || card.Body[0].Color != "attention" || card.Body[1].Facts[0].Value != "42" {
+		t.Errorf("Unexpected payload %s", c.payloads[0])
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	c, server := newChannel(t)
+	webhook := NewWebhook(server.URL, testOptions)
+
+	if err := webhook.Notify(context.Background(), Message{Title: "3 lines matched", Text: "ERROR", Severity: SeverityError, Fields: []Field{{Name: "Source", Value: "test-server"}}}); err != nil {
+		t.Fatalf("Notify error: %s", err)
+	}
+	expected := `{"title":"3 lines matched","text":"ERROR","severity":"error","fields":[{"name":"Source","value":"test-server"}]}`
+	if c.payloads[0] != expected {
+		t.Errorf("Expected the payload %s, got %s", expected, c.payloads[0])
+	}
+}
+
+func TestRetries(t *testing.T) {
+	c, server := newChannel(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	if err := NewSlack(server.URL, testOptions).Notify(context.Background(), Message{Title: "retried"}); err != nil {
+		t.Fatalf("Notify error: %s", err)
+	}
+	if len(c.payloads) != 1 || len(c.times) != 3 {
+		t.Errorf("Expected a message after 2 retries, got %d messages in %d requests", len(c.payloads), len(c.times))
+	}
+
+	// a webhook that was removed isn't retried
+	c, server = newChannel(t, http.StatusNotFound, http.StatusNotFound)
+	err := NewSlack(server.URL+"/secret-path", testOptions).Notify(context.Background(), Message{Title: "lost"})
+	if err == nil || len(c.times) != 1 {
+		t.Errorf("Expected an error without retries, got %v after %d requests", err, len(c.times))
+	}
+	if err != nil && strings.Contains(err.Error(), "secret-path") {
+		t.Errorf("Expected the url not to be in the error, got %s", err)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	c, server := newChannel(t)
+	slack := NewSlack(server.URL, Options{Interval: 50 * time.Millisecond})
+	for range 3 {
+		if err := slack.Notify(context.Background(), Message{Title: "paced"}); err != nil {
+			t.Fatalf("Notify error: %s", err)
+		}
+	}
+	for i := 1; i < len(c.times); i++ {
+		if gap := c.times[i].Sub(c.times[i-1]); gap < 45*time.Millisecond {
+			t.Errorf("Expected 50ms between the messages, got %s", gap)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := NewTemplate("{{len .Failed}} of {{.Total}} checks failed", "{{range .Failed}}- {{.}}\n{{end}}")
+	if err != nil {
+		t.Fatalf("NewTemplate error: %s", err)
+	}
+	message, err := tmpl.Message(SeverityError, struct {
+		Total  int
+		Failed []string
+	}{Total: 3, Failed: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("Message error: %s", err)
+	}
+	if message.Title != "2 of 3 checks failed" || message.Text != "- a\n- b\n" || message.Severity != SeverityError {
+		t.Errorf("Unexpected message %+v", message)
+	}
+
+	if _, err := NewTemplate("{{.Total", ""); err == nil {
+		t.Error("Expected a parse error")
+	}
+}
+
+func TestFromSecrets(t *testing.T) {
+	slackChannel, slackServer := newChannel(t)
+	teamsChannel, teamsServer := newChannel(t)
+	notifiers, err := FromSecrets(context.Background(), secrets.Map{"slack-webhook-url": slackServer.URL, "teams-webhook-url": teamsServer.URL}, testOptions)
+	if err != nil {
+		t.Fatalf("FromSecrets error: %s", err)
+	}
+	if err := notifiers.Notify(context.Background(), Message{Title: "both"}); err != nil {
+		t.Fatalf("Notify error: %s", err)
+	}
+	if len(slackChannel.payloads) != 1 || len(teamsChannel.payloads) != 1 {
+		t.Errorf("Expected a message in both channels, got %d and %d", len(slackChannel.payloads), len(teamsChannel.payloads))
+	}
+
+	notifiers, err = FromSecrets(context.Background(), secrets.Map{}, testOptions)
+	if err != nil || len(notifiers) != 0 {
+		t.Errorf("Expected no notifiers, got %v, %v", notifiers, err)
+	}
+}
+
+func TestMultiErrors(t *testing.T) {
+	_, server := newChannel(t, http.StatusBadRequest)
+	c, ok := newChannel(t)
+	err := Multi{NewSlack(server.URL, testOptions), NewTeams(ok.URL, testOptions)}.Notify(context.Background(), Message{Title: "one fails"})
+	if err == nil || !strings.HasPrefix(err.Error(), "slack: 400") {
+		t.Errorf("Expected the error of slack, got %v", err)
+	}
+	if len(c.payloads) != 1 {
+		t.Error("Expected the message to be sent to teams despite the error of slack")
+	}
+}
@@ -0,0 +1,54 @@
+package notify
+
+import "context"
+
+// Slack sends the messages to the channel of a Slack incoming webhook, as an
+// attachment colored by the severity
+type Slack struct {
+	webhook *webhook
+}
+
+// NewSlack returns a Slack notifier of the webhook url, like
+// https://hooks.slack.com/services/T000/B000/XXXX
+func NewSlack(url string, options Options) *Slack {
+	return &Slack{webhook: newWebhook("slack", url, options)}
+}
+
+// slackColors are the colors of the attachments of the severities
+var slackColors = map[Severity]string{
+	SeverityInfo:    "#2eb886",
+	SeverityWarning: "#daa038",
+	SeverityError:   "#a30200",
+}
+
+type slackPayload struct {
+	// Text is the text of the notifications
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color,omitempty"`
+	Title  string       `json:"title"`
+	Text   string       `json:"text,omitempty"`
+	Fields []slackField `json:"fields,omitempty"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// Notify posts message to the webhook
+func (s *Slack) Notify(ctx context.Context, message Message) error {
+	attachment := slackAttachment{
+		Color: slackColors[message.Severity],
+		Title: message.Title,
+		Text:  message.Text,
+	}
+	for _, field := range message.Fields {
+		attachment.Fields = append(attachment.Fields, slackField{Title: field.Name, Value: field.Value, Short: len(field.Value) < 40})
+	}
+	return s.webhook.post(ctx, slackPayload{Text: message.Title, Attachments: []slackAttachment{attachment}})
+}
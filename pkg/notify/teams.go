@@ -0,0 +1,82 @@
+package notify
+
+import "context"
+
+// Teams sends the messages to the channel of a Microsoft Teams workflow webhook,
+// "Post to a channel when a webhook request is received", as an Adaptive Card
+type Teams struct {
+	webhook *webhook
+}
+
+// NewTeams returns a Teams notifier of the url of the workflow, like
+// https://prod-00.westus.logic.azure.com/workflows/...
+func NewTeams(url string, options Options) *Teams {
+	return &Teams{webhook: newWebhook("teams", url, options)}
+}
+
+// teamsColors are the colors of the titles of the severities
+var teamsColors = map[Severity]string{
+	SeverityInfo:    "good",
+	SeverityWarning: "warning",
+	SeverityError:   "attention",
+}
+
+type teamsPayload struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+}
+
+// teamsElement is a TextBlock or a FactSet of a card
+type teamsElement struct {
+	Type   string      `json:"type"`
+	Text   string      `json:"text,omitempty"`
+	Size   string      `json:"size,omitempty"`
+	Weight string      `json:"weight,omitempty"`
+	Color  string      `json:"color,omitempty"`
+	Wrap   bool        `json:"wrap,omitempty"`
+	Facts  []teamsFact `json:"facts,omitempty"`
+}
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Notify posts message to the webhook
+func (t *Teams) Notify(ctx context.Context, message Message) error {
+	body := []teamsElement{{Type: "TextBlock", Text: message.Title, Size: "Medium", Weight: "Bolder", Color: teamsColors[message.Severity], Wrap: true}}
+	if message.Text != "" {
+		body = append(body, teamsElement{Type: "TextBlock", Text: message.Text, Wrap: true})
+	}
+	if len(message.Fields) > 0 {
+		facts := teamsElement{Type: "FactSet"}
+		for _, field := range message.Fields {
+			facts.Facts = append(facts.Facts, teamsFact{Title: field.Name, Value: field.Value})
+		}
+		body = append(body, facts)
+	}
+	return t.webhook.post(ctx, teamsPayload{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: teamsCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body:    body,
+			},
+		}},
+	})
+}
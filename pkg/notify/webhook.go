@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Options configure the webhooks
+type Options struct {
+	// Client sends the requests, http.DefaultClient when nil
+	Client *http.Client
+	// Retries is the number of times a message is sent again after a network error,
+	// a 429 or a 5xx, 3 when 0, none when negative
+	Retries int
+	// Backoff is the wait before the first retry, doubled for every other one, unless
+	// the webhook replies with a Retry-After. 1s when 0.
+	Backoff time.Duration
+	// Interval is the minimum time between two messages to a webhook, 1s when 0, the
+	// rate Slack allows
+	Interval time.Duration
+}
+
+// webhook posts JSON payloads to a url, with the retries and the rate limit of the
+// options
+type webhook struct {
+	name    string
+	url     string
+	options Options
+
+	mu sync.Mutex
+	// next is when the next message can be sent
+	next time.Time
+}
+
+func newWebhook(name, url string, options Options) *webhook {
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	if options.Retries == 0 {
+		options.Retries = 3
+	}
+	if options.Backoff == 0 {
+		options.Backoff = time.Second
+	}
+	if options.Interval == 0 {
+		options.Interval = time.Second
+	}
+	return &webhook{name: name, url: url, options: options}
+}
+
+// post posts payload as JSON, after the interval since the previous message
+func (w *webhook) post(ctx context.Context, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if err := w.wait(ctx); err != nil {
+		return err
+	}
+	backoff := w.options.Backoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := w.send(ctx, body)
+		if err == nil || retryAfter < 0 || attempt >= w.options.Retries {
+			if err != nil {
+				// the url isn't in the errors, it's a secret
+				return fmt.Errorf("%s: %s", w.name, err)
+			}
+			return nil
+		}
+		if retryAfter == 0 {
+			retryAfter = backoff
+			backoff *= 2
+		}
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// wait waits for the interval since the previous message, and reserves the time
+// of this one
+func (w *webhook) wait(ctx context.Context) error {
+	w.mu.Lock()
+	now := time.Now()
+	at := w.next
+	if at.Before(now) {
+		at = now
+	}
+	w.next = at.Add(w.options.Interval)
+	w.mu.Unlock()
+
+	timer := time.NewTimer(at.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// send posts body once. It returns the Retry-After of the response, or -1 when the
+// error can't be retried, like a 400 for an invalid payload or a 404 for a
+// webhook that was removed.
+func (w *webhook) send(ctx context.Context, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return -1, withoutURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := w.options.Client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, ctx.Err()
+		}
+		return 0, withoutURL(err)
+	}
+	defer res.Body.Close()
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return 0, nil
+	}
+	err = fmt.Errorf("%s: %s", res.Status, bytes.TrimSpace(resBody))
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode < 500 {
+		return -1, err
+	}
+	seconds, _ := strconv.Atoi(res.Header.Get("Retry-After"))
+	return time.Duration(seconds) * time.Second, err
+}
+
+// withoutURL returns the error of a *url.Error, without the url
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
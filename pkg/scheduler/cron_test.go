@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	// Wednesday the 15th of October 2026, 10:07:30
+	now := time.Date(2026, 10, 15, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec     string
+		from     time.Time
+		expected time.Time
+	}{
+		{"* * * * *", now, time.Date(2026, 10, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", now, time.Date(2026, 10, 15, 10, 15, 0, 0, time.UTC)},
+		{"5/20 * * * *", now, time.Date(2026, 10, 15, 10, 25, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", now, time.Date(2026, 10, 15, 13, 0, 0, 0, time.UTC)},
+		{"30 2 * * mon-fri", now, time.Date(2026, 10, 16, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", now, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", now, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		// the day of month or the day of week, Friday the 16th
+		{"0 0 1 * fri", now, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 feb *", now, time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"@hourly", now, time.Date(2026, 10, 15, 11, 0, 0, 0, time.UTC)},
+		{"@yearly", now, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", now, now.Add(90 * time.Second)},
+		{"0 0 30 feb *", now, time.Time{}},
+		// 2:30 doesn't exist on the last Sunday of March in Paris, the run is skipped
+		{"30 2 * * *", time.Date(2027, 3, 28, 1, 0, 0, 0, paris), time.Date(2027, 3, 29, 2, 30, 0, 0, paris)},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.spec)
+		if err != nil {
+			t.Errorf("Parse %q error: %s", test.spec, err)
+			continue
+		}
+		if next := schedule.Next(test.from); !next.Equal(test.expected) {
+			t.Errorf("Expected %s for %q, got %s", test.expected, test.spec, next)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "* * * * foo", "@every 10ms", "@every soon"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
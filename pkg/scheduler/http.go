@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// HTTPTarget is a job calling an HTTP endpoint, like a health check or a cleanup
+// endpoint of a service
+type HTTPTarget struct {
+	// Method is GET when empty
+	Method string
+	URL    string
+	Header http.Header
+	Body   string
+	// Status are the statuses of a successful run, any 2xx when empty
+	Status []int
+	// Client sends the requests, http.DefaultClient when nil
+	Client *http.Client
+}
+
+// Run sends the request, and returns an error with the beginning of the body when
+// the status isn't expected
+func (h HTTPTarget) Run(ctx context.Context) error {
+	method := h.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if h.Body != "" {
+		body = strings.NewReader(h.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, h.URL, body)
+	if err != nil {
+		return err
+	}
+	for name, values := range h.Header {
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(io.LimitReader(res.Body, 512))
+	if err != nil {
+		return err
+	}
+	// the connection is reused when the body is read to the end
+	io.Copy(io.Discard, res.Body)
+
+	if len(h.Status) == 0 && res.StatusCode >= 200 && res.StatusCode < 300 || slices.Contains(h.Status, res.StatusCode) {
+		return nil
+	}
+	return fmt.Errorf("%s %s: %s: %s", method, req.URL.Redacted(), res.Status, strings.TrimSpace(string(resBody)))
+}
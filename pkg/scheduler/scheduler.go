@@ -0,0 +1,236 @@
+// Package scheduler runs jobs on cron schedules, like calling an HTTP endpoint every
+// 5 minutes, with a timeout per run, a random delay to spread the runs of many
+// instances, and a policy for the runs that start before the previous one is done
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Overlap is what happens when a run is due while the previous run of the job is
+// still running
+type Overlap string
+
+const (
+	// OverlapSkip skips the run, the default
+	OverlapSkip Overlap = "skip"
+	// OverlapAllow starts the run anyway, the runs are concurrent
+	OverlapAllow Overlap = "allow"
+	// OverlapQueue starts the run when the previous one is done. Only one run waits,
+	// the next ones are skipped while it does.
+	OverlapQueue Overlap = "queue"
+)
+
+// Job is a function run on a schedule
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+	// Timeout cancels the context of a run after that long, none when 0
+	Timeout time.Duration
+	// Jitter delays every run by a random duration up to Jitter, so the instances of
+	// a service don't all call an API at the same time
+	Jitter time.Duration
+	// Overlap is OverlapSkip when empty
+	Overlap Overlap
+}
+
+// Result is the outcome of a run of a job
+type Result struct {
+	Job string
+	// Scheduled is when the run was due, before the jitter
+	Scheduled time.Time
+	Started   time.Time
+	Duration  time.Duration
+	// Err is the error of the run, or of its timeout, nil when it succeeded
+	Err error
+	// Skipped is set when the run didn't start because the previous one was running
+	Skipped bool
+}
+
+// Scheduler runs jobs on their schedules
+type Scheduler struct {
+	// Location is the time zone of the cron schedules, time.Local when nil
+	Location *time.Location
+	// OnResult is called with the result of every run, and of every skipped run,
+	// concurrently when the runs of the jobs are
+	OnResult func(Result)
+	// Logger logs the runs that fail or are skipped, slog.Default() when nil
+	Logger *slog.Logger
+
+	jobs []*job
+}
+
+// job is a job and the state of its runs
+type job struct {
+	Job
+
+	mu      sync.Mutex
+	running int
+	// queued is the scheduled time of the run waiting for the running one, zero when
+	// there's none
+	queued time.Time
+}
+
+// Add adds a job, before Run
+func (s *Scheduler) Add(j Job) error {
+	if j.Name == "" || j.Schedule == nil || j.Run == nil {
+		return errors.New("invalid job: name, schedule and run are required")
+	}
+	switch j.Overlap {
+	case "":
+		j.Overlap = OverlapSkip
+	case OverlapSkip, OverlapAllow, OverlapQueue:
+	default:
+		return fmt.Errorf("invalid job %s: overlap %q isn't skip, allow or queue", j.Name, j.Overlap)
+	}
+	for _, other := range s.jobs {
+		if other.Name == j.Name {
+			return fmt.Errorf("invalid job %s: duplicate name", j.Name)
+		}
+	}
+	s.jobs = append(s.jobs, &job{Job: j})
+	return nil
+}
+
+// Run runs the jobs until the context is done, then waits for the running ones to
+// return. The runs aren't canceled when the context is done, only their timeouts
+// stop them.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if len(s.jobs) == 0 {
+		return errors.New("no jobs")
+	}
+	var runs sync.WaitGroup
+	var loops sync.WaitGroup
+	for _, j := range s.jobs {
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			s.loop(ctx, j, &runs)
+		}()
+	}
+	loops.Wait()
+	runs.Wait()
+	return nil
+}
+
+// loop starts the runs of a job at the times of its schedule
+func (s *Scheduler) loop(ctx context.Context, j *job, runs *sync.WaitGroup) {
+	next := j.Schedule.Next(s.now())
+	for !next.IsZero() {
+		delay := time.Until(next)
+		if j.Jitter > 0 {
+			delay += rand.N(j.Jitter)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.start(ctx, j, next, runs)
+
+		// the runs missed while the computer was sleeping are skipped
+		next = j.Schedule.Next(next)
+		if now := s.now(); !next.IsZero() && next.Before(now) {
+			next = j.Schedule.Next(now)
+		}
+	}
+	s.logger().Info("No more runs", "job", j.Name)
+}
+
+// start starts a run due at scheduled, unless the overlap policy skips or queues it
+func (s *Scheduler) start(ctx context.Context, j *job, scheduled time.Time, runs *sync.WaitGroup) {
+	j.mu.Lock()
+	if j.running > 0 && j.Overlap != OverlapAllow {
+		if j.Overlap == OverlapQueue && j.queued.IsZero() {
+			j.queued = scheduled
+			j.mu.Unlock()
+			return
+		}
+		j.mu.Unlock()
+		s.logger().Warn("Run skipped, the previous one is still running", "job", j.Name, "scheduled", scheduled)
+		s.report(Result{Job: j.Name, Scheduled: scheduled, Skipped: true})
+		return
+	}
+	j.running++
+	j.mu.Unlock()
+
+	runs.Add(1)
+	go func() {
+		defer runs.Done()
+		for {
+			s.run(ctx, j, scheduled)
+
+			j.mu.Lock()
+			// the queued run starts now, in the same goroutine
+			if scheduled = j.queued; !scheduled.IsZero() {
+				j.queued = time.Time{}
+				j.mu.Unlock()
+				continue
+			}
+			j.running--
+			j.mu.Unlock()
+			return
+		}
+	}()
+}
+
+// run runs the job once, and reports its result
+func (s *Scheduler) run(ctx context.Context, j *job, scheduled time.Time) {
+	// stopping the scheduler lets the runs finish
+	ctx = context.WithoutCancel(ctx)
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+	result := Result{Job: j.Name, Scheduled: scheduled, Started: time.Now()}
+	result.Err = runJob(ctx, j.Run)
+	result.Duration = time.Since(result.Started)
+	if result.Err != nil && ctx.Err() != nil {
+		result.Err = fmt.Errorf("%w, timeout %s", result.Err, j.Timeout)
+	}
+	if result.Err != nil {
+		s.logger().Error("Run failed", "job", j.Name, "duration", result.Duration, "error", result.Err)
+	}
+	s.report(result)
+}
+
+// runJob calls run, returning its panic as an error
+func runJob(ctx context.Context, run func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+func (s *Scheduler) report(result Result) {
+	if s.OnResult != nil {
+		s.OnResult(result)
+	}
+}
+
+// now returns the current time in the location of the schedules
+func (s *Scheduler) now() time.Time {
+	if s.Location != nil {
+		return time.Now().In(s.Location)
+	}
+	return time.Now()
+}
+
+func (s *Scheduler) logger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,188 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// results records the results of a scheduler
+type results struct {
+	mu      sync.Mutex
+	results []Result
+}
+
+func (r *results) add(result Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+}
+
+// run runs the jobs for d, and returns the results of their runs
+func run(t *testing.T, d time.Duration, jobs ...Job) []Result {
+	var r results
+	s := Scheduler{OnResult: r.add, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	for _, j := range jobs {
+		if err := s.Add(j); err != nil {
+			t.Fatalf("Add error: %s", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	if err := s.Run(ctx); err != nil {
+		t.Fatalf("Run error: %s", err)
+	}
+	return r.results
+}
+
+// sleeper returns a job sleeping for d, and records the highest number of its
+// concurrent runs
+func sleeper(d time.Duration, concurrent *atomic.Int32) func(ctx context.Context) error {
+	var running atomic.Int32
+	return func(ctx context.Context) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		if n > concurrent.Load() {
+			concurrent.Store(n)
+		}
+		time.Sleep(d)
+		return nil
+	}
+}
+
+// count returns the numbers of results that ran and that were skipped
+func count(results []Result) (ran, skipped int) {
+	for _, r := range results {
+		if r.Skipped {
+			skipped++
+		} else {
+			ran++
+		}
+	}
+	return ran, skipped
+}
+
+func TestOverlap(t *testing.T) {
+	// runs of 50ms every 20ms
+	var concurrent atomic.Int32
+	results := run(t, 130*time.Millisecond, Job{Name: "skip", Schedule: Every(20 * time.Millisecond), Run: sleeper(50*time.Millisecond, &concurrent)})
+	if ran, skipped := count(results); ran < 2 || skipped < 2 || concurrent.Load() != 1 {
+		t.Errorf("Expected runs one at a time and skipped runs, got %d runs, %d skipped, %d concurrent", ran, skipped, concurrent.Load())
+	}
+
+	concurrent.Store(0)
+	results = run(t, 130*time.Millisecond, Job{Name: "allow", Schedule: Every(20 * time.Millisecond), Run: sleeper(50*time.Millisecond, &concurrent), Overlap: OverlapAllow})
+	if ran, skipped := count(results); ran < 5 || skipped != 0 || concurrent.Load() < 2 {
+		t.Errorf("Expected concurrent runs, got %d runs, %d skipped, %d concurrent", ran, skipped, concurrent.Load())
+	}
+
+	concurrent.Store(0)
+	results = run(t, 130*time.Millisecond, Job{Name: "queue", Schedule: Every(20 * time.Millisecond), Run: sleeper(50*time.Millisecond, &concurrent), Overlap: OverlapQueue})
+	delayed := false
+	for _, r := range results {
+		// a queued run starts after the previous one, 30ms after it was due
+		if !r.Skipped && r.Started.Sub(r.Scheduled) > 20*time.Millisecond {
+			delayed = true
+		}
+	}
+	if ran, _ := count(results); ran < 3 || !delayed || concurrent.Load() != 1 {
+		t.Errorf("Expected queued runs one at a time, got %d runs, %d concurrent, delayed %v", ran, concurrent.Load(), delayed)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	results := run(t, 70*time.Millisecond,
+		Job{Name: "slow", Schedule: Every(20 * time.Millisecond), Timeout: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		Job{Name: "panics", Schedule: Every(20 * time.Millisecond), Run: func(ctx context.Context) error {
+			panic("oops")
+		}},
+	)
+	for _, r := range results {
+		switch {
+		case r.Job == "slow" && !errors.Is(r.Err, context.DeadlineExceeded):
+			t.Errorf("Expected the run to time out, got %v", r.Err)
+		case r.Job == "slow" && r.Duration > 30*time.Millisecond:
+			t.Errorf("Expected the run to stop after 10ms, got %s", r.Duration)
+		case r.Job == "panics" && (r.Err == nil || r.Err.Error() != "panic: oops"):
+			t.Errorf("Expected the panic as an error, got %v", r.Err)
+		}
+	}
+	if len(results) < 4 {
+		t.Errorf("Expected runs of both jobs, got %d", len(results))
+	}
+}
+
+func TestJitter(t *testing.T) {
+	results := run(t, 200*time.Millisecond, Job{Name: "jitter", Schedule: Every(20 * time.Millisecond), Jitter: 40 * time.Millisecond, Run: func(ctx context.Context) error { return nil }})
+	spread := false
+	for _, r := range results {
+		delay := r.Started.Sub(r.Scheduled)
+		if delay > 45*time.Millisecond {
+			t.Errorf("Expected a delay under the jitter, got %s", delay)
+		}
+		if delay > 5*time.Millisecond {
+			spread = true
+		}
+	}
+	if len(results) == 0 || !spread {
+		t.Errorf("Expected runs delayed by the jitter, got %d runs", len(results))
+	}
+}
+
+func TestAdd(t *testing.T) {
+	var s Scheduler
+	run := func(ctx context.Context) error { return nil }
+	if err := s.Add(Job{Name: "a", Schedule: Every(time.Second), Run: run}); err != nil {
+		t.Fatalf("Add error: %s", err)
+	}
+	for _, j := range []Job{
+		{Name: "a", Schedule: Every(time.Second), Run: run},
+		{Name: "b", Run: run},
+		{Name: "c", Schedule: Every(time.Second), Run: run, Overlap: "sometimes"},
+	} {
+		if err := s.Add(j); err == nil {
+			t.Errorf("Expected an error for %+v", j)
+		}
+	}
+}
+
+func TestHTTPTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "{}" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid body\n"))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	target := HTTPTarget{Method: http.MethodPost, URL: server.URL, Header: http.Header{"Authorization": {"Bearer token"}}, Body: "{}"}
+	if err := target.Run(context.Background()); err != nil {
+		t.Errorf("Run error: %s", err)
+	}
+	target.Status = []int{http.StatusOK}
+	if err := target.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "202 Accepted") {
+		t.Errorf("Expected an error for an unexpected status, got %v", err)
+	}
+	target.Body, target.Status = "[]", nil
+	if err := target.Run(context.Background()); err == nil || !strings.HasSuffix(err.Error(), "400 Bad Request: invalid body") {
+		t.Errorf("Expected the body in the error, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Package secrets reads the passwords and keys of the servers and clients of this
+// repository from the environment, from files, like the secrets Docker and
+// Kubernetes mount, or from HashiCorp Vault, instead of flags and literals
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned by the stores for a secret they don't have
+var ErrNotFound = errors.New("secret not found")
+
+// Store returns secrets by name. Names are paths like users/raymond/password, each
+// store maps them to its own keys.
+type Store interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// Env reads the secrets from environment variables: the name in uppercase, with
+// the characters other than letters and digits replaced by _, after the prefix,
+// like OIDC_USERS_RAYMOND_PASSWORD for users/raymond/password and the prefix OIDC_
+type Env struct {
+	Prefix string
+}
+
+// Get returns the environment variable of name, ErrNotFound when it's not set
+func (e Env) Get(ctx context.Context, name string) (string, error) {
+	key := EnvKey(e.Prefix, name)
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("%s: %w (%s is not set)", name, ErrNotFound, key)
+	}
+	return value, nil
+}
+
+// EnvKey returns the environment variable of the secret name
+func EnvKey(prefix, name string) string {
+	return prefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// Files reads the secrets from the files under Dir, named like the secrets, like
+// /run/secrets/users/raymond/password. A trailing newline is removed.
+type Files struct {
+	Dir string
+}
+
+// Get returns the content of the file of name, ErrNotFound when it doesn't exist
+func (f Files) Get(ctx context.Context, name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	content, err := os.ReadFile(filepath.Join(f.Dir, filepath.FromSlash(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%s: %w (no file in %s)", name, ErrNotFound, f.Dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read secret %s: %s", name, err)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r"), nil
+}
+
+// Map is a store of fixed secrets, for the tests
+type Map map[string]string
+
+// Get returns the secret of name, ErrNotFound when there's none
+func (m Map) Get(ctx context.Context, name string) (string, error) {
+	value, ok := m[name]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	return value, nil
+}
+
+// Chain returns the secret of the first store that has it, like the environment to
+// override the secrets of Vault
+type Chain []Store
+
+// Get returns the secret of the first store that has it, ErrNotFound when none has
+// it, and stops at the first error other than ErrNotFound
+func (c Chain) Get(ctx context.Context, name string) (string, error) {
+	for _, store := range c {
+		value, err := store.Get(ctx, name)
+		if !errors.Is(err, ErrNotFound) {
+			return value, err
+		}
+	}
+	return "", fmt.Errorf("%s: %w", name, ErrNotFound)
+}
+
+// Backends of FromEnv
+const (
+	BackendEnv   = "env"
+	BackendFile  = "file"
+	BackendVault = "vault"
+)
+
+// DefaultDir is the directory of the secrets of the file backend, where Docker
+// mounts them
+const DefaultDir = "/run/secrets"
+
+// FromEnv returns the store of the SECRETS_BACKEND environment variable:
+//   - env (default): the environment variables with prefix, see Env
+//   - file: the files in SECRETS_DIR, /run/secrets by default, see Files
+//   - vault: the KV v2 secrets of Vault, see VaultFromEnv
+//
+// With the file and vault backends, the environment variables still override the
+// secrets.
+func FromEnv(prefix string) (Store, error) {
+	env := Env{Prefix: prefix}
+	switch backend := os.Getenv("SECRETS_BACKEND"); backend {
+	case "", BackendEnv:
+		return env, nil
+	case BackendFile:
+		dir := os.Getenv("SECRETS_DIR")
+		if dir == "" {
+			dir = DefaultDir
+		}
+		return Chain{env, Files{Dir: dir}}, nil
+	case BackendVault:
+		vault, err := VaultFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return Chain{env, vault}, nil
+	default:
+		return nil, fmt.Errorf("invalid SECRETS_BACKEND %q: not env, file or vault", backend)
+	}
+}
@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	t.Setenv("OIDC_USERS_RAYMOND_PASSWORD", "hunter2")
+	store := Env{Prefix: "OIDC_"}
+
+	value, err := store.Get(context.Background(), "users/raymond/password")
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if value != "hunter2" {
+		t.Errorf("Expected hunter2, got %q", value)
+	}
+	if _, err := store.Get(context.Background(), "signing-key"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "users", "raymond"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "users", "raymond", "password"), []byte("hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store := Files{Dir: dir}
+
+	value, err := store.Get(context.Background(), "users/raymond/password")
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if value != "hunter2" {
+		t.Errorf("Expected hunter2 without the newline, got %q", value)
+	}
+	if _, err := store.Get(context.Background(), "users/other/password"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.Get(context.Background(), "../password"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected an invalid name error, got %v", err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	store := Chain{Map{"a": "first"}, Map{"a": "second", "b": "second"}}
+
+	for name, expected := range map[string]string{"a": "first", "b": "second"} {
+		value, err := store.Get(context.Background(), name)
+		if err != nil {
+			t.Fatalf("Get %s error: %s", name, err)
+		}
+		if value != expected {
+			t.Errorf("Expected %s for %s, got %q", expected, name, value)
+		}
+	}
+	if _, err := store.Get(context.Background(), "c"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		env     map[string]string
+		invalid bool
+	}{
+		{env: map[string]string{}},
+		{env: map[string]string{"SECRETS_BACKEND": "file", "SECRETS_DIR": t.TempDir()}},
+		{env: map[string]string{"SECRETS_BACKEND": "vault", "VAULT_ADDR": "http://127.0.0.1:8200", "VAULT_TOKEN": "root"}},
+		{env: map[string]string{"SECRETS_BACKEND": "vault", "VAULT_ADDR": "http://127.0.0.1:8200"}, invalid: true},
+		{env: map[string]string{"SECRETS_BACKEND": "vault"}, invalid: true},
+		{env: map[string]string{"SECRETS_BACKEND": "consul"}, invalid: true},
+	}
+	for _, test := range tests {
+		for _, key := range []string{"SECRETS_BACKEND", "SECRETS_DIR", "VAULT_ADDR", "VAULT_TOKEN", "VAULT_ROLE_ID", "VAULT_SECRET_ID"} {
+			t.Setenv(key, test.env[key])
+		}
+		_, err := FromEnv("TEST_")
+		if test.invalid && err == nil {
+			t.Errorf("Expected an error for %v", test.env)
+		}
+		if !test.invalid && err != nil {
+			t.Errorf("FromEnv error for %v: %s", test.env, err)
+		}
+	}
+}
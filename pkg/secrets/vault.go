@@ -0,0 +1,224 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Vault reads the secrets of a KV v2 secrets engine of HashiCorp Vault. The last
+// element of a name is the key of the secret at the path of the rest under Path: with
+// the Path oidc-demo, users/raymond/password is the key password of the secret
+// oidc-demo/users/raymond, and signing-key the key signing-key of the secret
+// oidc-demo.
+//
+// It authenticates with Token, or logs in with the AppRole of RoleID and SecretID,
+// and again when the token is rejected, like when it expired.
+type Vault struct {
+	// Address of the server, like http://127.0.0.1:8200
+	Address string
+	// Namespace of Vault Enterprise, none when empty
+	Namespace string
+	// Mount is the path of the KV v2 secrets engine, secret when empty
+	Mount string
+	// Path of the secrets in the engine
+	Path string
+	// Token authenticates the requests
+	Token string
+	// RoleID and SecretID log in with AppRole when Token is empty
+	RoleID   string
+	SecretID string
+	// AppRoleMount is the path of the AppRole auth method, approle when empty
+	AppRoleMount string
+	// Client sends the requests, http.DefaultClient when nil
+	Client *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+// VaultFromEnv returns the Vault store of the environment variables of the vault
+// CLI, VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE, or VAULT_ROLE_ID and
+// VAULT_SECRET_ID for AppRole, and of VAULT_KV_MOUNT and SECRETS_PATH, the path of
+// the secrets
+func VaultFromEnv() (*Vault, error) {
+	v := &Vault{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Mount:     os.Getenv("VAULT_KV_MOUNT"),
+		Path:      os.Getenv("SECRETS_PATH"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		RoleID:    os.Getenv("VAULT_ROLE_ID"),
+		SecretID:  os.Getenv("VAULT_SECRET_ID"),
+	}
+	if v.Address == "" {
+		return nil, errors.New("VAULT_ADDR is not set")
+	}
+	if v.Token == "" && (v.RoleID == "" || v.SecretID == "") {
+		return nil, errors.New("neither VAULT_TOKEN nor VAULT_ROLE_ID and VAULT_SECRET_ID are set")
+	}
+	return v, nil
+}
+
+// kvResponse is the response of a KV v2 secret
+type kvResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+// loginResponse is the response of an AppRole login
+type loginResponse struct {
+	Auth struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+}
+
+// Get returns the key of the secret of name, ErrNotFound when the secret or the key
+// don't exist
+func (v *Vault) Get(ctx context.Context, name string) (string, error) {
+	dir, key := path.Split(name)
+	if key == "" {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	secretPath := strings.Trim(path.Join(v.Path, dir), "/")
+	if secretPath == "" {
+		return "", fmt.Errorf("invalid secret name %q: no path in Vault", name)
+	}
+
+	var secret kvResponse
+	if err := v.read(ctx, secretPath, &secret); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return "", fmt.Errorf("%s: %w (no secret %s in Vault)", name, ErrNotFound, secretPath)
+		}
+		return "", fmt.Errorf("read secret %s: %s", name, err)
+	}
+	value, ok := secret.Data.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%s: %w (no key %s in the secret %s in Vault)", name, ErrNotFound, key, secretPath)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	// the other JSON values, like numbers, as they were written
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("read secret %s: %s", name, err)
+	}
+	return string(b), nil
+}
+
+// read reads the secret at secretPath, logging in again once when the token is
+// rejected
+func (v *Vault) read(ctx context.Context, secretPath string, secret *kvResponse) error {
+	mount := v.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	endpoint := v.url("v1", mount, "data", secretPath)
+	for retry := true; ; retry = false {
+		token, err := v.clientToken(ctx)
+		if err != nil {
+			return err
+		}
+		status, err := v.do(ctx, http.MethodGet, endpoint, token, nil, secret)
+		if status == http.StatusForbidden && retry && v.Token == "" {
+			v.mu.Lock()
+			if v.token == token {
+				v.token = ""
+			}
+			v.mu.Unlock()
+			continue
+		}
+		if status == http.StatusNotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+}
+
+// clientToken returns Token, or the token of an AppRole login, logging in when
+// there's none yet
+func (v *Vault) clientToken(ctx context.Context) (string, error) {
+	if v.Token != "" {
+		return v.Token, nil
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.token != "" {
+		return v.token, nil
+	}
+	mount := v.AppRoleMount
+	if mount == "" {
+		mount = "approle"
+	}
+	body, err := json.Marshal(map[string]string{"role_id": v.RoleID, "secret_id": v.SecretID})
+	if err != nil {
+		return "", err
+	}
+	var login loginResponse
+	if _, err := v.do(ctx, http.MethodPost, v.url("v1", "auth", mount, "login"), "", body, &login); err != nil {
+		return "", fmt.Errorf("approle login: %s", err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", errors.New("approle login: no token returned")
+	}
+	v.token = login.Auth.ClientToken
+	return v.token, nil
+}
+
+func (v *Vault) url(elements ...string) string {
+	return strings.TrimSuffix(v.Address, "/") + "/" + path.Join(elements...)
+}
+
+// do sends a request to Vault and decodes the JSON response into out. It returns the
+// HTTP status code, and an error with the errors of Vault when it's not 200.
+func (v *Vault) do(ctx context.Context, method, endpoint, token string, body []byte, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, fmt.Errorf("read response: %s", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(resBody, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return res.StatusCode, fmt.Errorf("%s: %s", res.Status, strings.Join(vaultErr.Errors, ", "))
+		}
+		return res.StatusCode, fmt.Errorf("%s", res.Status)
+	}
+	if err := json.Unmarshal(resBody, out); err != nil {
+		return res.StatusCode, fmt.Errorf("invalid response: %s", err)
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,175 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Inject adds the trace context of ctx to the headers of an outgoing request
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract returns ctx with the trace context of the headers of an incoming
+// request, so its spans continue the trace of the client
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Handler starts a server span for every request, continuing the trace of the
+// client, and records the http.server.request.duration histogram. route returns
+// the route of the request for the span name, like the pattern of a mux, to keep
+// the names few; without it the spans are only named by the method.
+func Handler(next http.Handler, route func(*http.Request) string) http.Handler {
+	tracer := otel.Tracer(instrumentationName)
+	// instrument creation only fails on invalid names, which are constants here
+	duration, _ := otel.Meter(instrumentationName).Float64Histogram("http.server.request.duration",
+		metric.WithDescription("Duration of HTTP server requests."),
+		metric.WithUnit("s"))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Method
+		attributes := []attribute.KeyValue{
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		}
+		if route != nil {
+			if pattern := route(r); pattern != "" {
+				name += " " + pattern
+				attributes = append(attributes, attribute.String("http.route", pattern))
+			}
+		}
+		ctx, span := tracer.Start(Extract(r.Context(), r.Header), name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attributes...))
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		start := time.Now()
+		defer func() {
+			if err := recover(); err != nil {
+				// like http.ErrAbortHandler, to drop the connection
+				span.SetStatus(codes.Error, fmt.Sprint(err))
+				panic(err)
+			}
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			statusAttribute := attribute.Int("http.response.status_code", status)
+			attributes = append(attributes, statusAttribute)
+			span.SetAttributes(statusAttribute)
+			// 4xx are errors of the client, not of the server
+			if status >= 500 {
+				span.SetStatus(codes.Error, "HTTP "+strconv.Itoa(status))
+			}
+			duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attributes...))
+		}()
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+	})
+}
+
+// Transport starts a client span for every request, sends its trace context to
+// the server, and records the http.client.request.duration histogram. Put it
+// outside of the retries to get a span per call, with the spans of the retries
+// inside.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	duration, _ := otel.Meter(instrumentationName).Float64Histogram("http.client.request.duration",
+		metric.WithDescription("Duration of HTTP client requests."),
+		metric.WithUnit("s"))
+	return transport{next: next, tracer: otel.Tracer(instrumentationName), duration: duration}
+}
+
+type transport struct {
+	next     http.RoundTripper
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// url.full isn't recorded in the metrics, it would give every url a series of its own
+	attributes := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Host),
+	}
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+		trace.WithAttributes(attribute.String("url.full", req.URL.Redacted())))
+	defer span.End()
+
+	// a RoundTripper must not change the request of the caller
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		status := attribute.Int("http.response.status_code", res.StatusCode)
+		attributes = append(attributes, status)
+		span.SetAttributes(status)
+		if res.StatusCode >= 400 {
+			span.SetStatus(codes.Error, "HTTP "+strconv.Itoa(res.StatusCode))
+		}
+	}
+	t.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attributes...))
+	return res, err
+}
+
+// statusRecorder remembers the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush passes flushes through, so streamed responses keep working
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes the connection on for websocket upgrades
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T doesn't support hijacking", s.ResponseWriter)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && s.status == 0 {
+		s.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
@@ -0,0 +1,84 @@
+// Package telemetry sets up OpenTelemetry tracing and metrics with the OTLP
+// exporters, and instruments HTTP servers and clients, so a request going through
+// the modules of this repository shows up as a single trace in Jaeger
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// instrumentationName identifies the tracer and meter of this package
+const instrumentationName = "telemetry"
+
+// Setup installs the global propagator, and the global tracer and meter providers
+// exporting over OTLP/HTTP to the collector of the standard environment variables:
+//
+//	OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4318
+//	OTEL_SERVICE_NAME overrides serviceName
+//	OTEL_TRACES_EXPORTER=none or OTEL_METRICS_EXPORTER=none turns one of them off
+//
+// Without an endpoint nothing is exported, but the trace context of incoming
+// requests is still passed on to the outgoing ones. Shutdown flushes the spans and
+// metrics that weren't exported yet.
+func Setup(ctx context.Context, serviceName string) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var shutdowns []func(context.Context) error
+	shutdown = func(ctx context.Context) error {
+		var errs []error
+		for _, fn := range shutdowns {
+			errs = append(errs, fn(ctx))
+		}
+		return errors.Join(errs...)
+	}
+
+	// the attributes of the environment come last, so OTEL_SERVICE_NAME wins
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return shutdown, err
+	}
+
+	if exporterEnabled("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_TRACES_EXPORTER") {
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return shutdown, err
+		}
+		tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+		shutdowns = append(shutdowns, tracerProvider.Shutdown)
+		otel.SetTracerProvider(tracerProvider)
+	}
+	if exporterEnabled("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "OTEL_METRICS_EXPORTER") {
+		exporter, err := otlpmetrichttp.New(ctx)
+		if err != nil {
+			return shutdown, err
+		}
+		meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)), sdkmetric.WithResource(res))
+		shutdowns = append(shutdowns, meterProvider.Shutdown)
+		otel.SetMeterProvider(meterProvider)
+	}
+	return shutdown, nil
+}
+
+// exporterEnabled reports whether there's an OTLP endpoint for the signal, and
+// its exporter isn't turned off
+func exporterEnabled(endpointVariable, exporterVariable string) bool {
+	if os.Getenv(exporterVariable) == "none" {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv(endpointVariable) != ""
+}
@@ -0,0 +1,189 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	spans   = tracetest.NewSpanRecorder()
+	metrics = sdkmetric.NewManualReader()
+)
+
+// TestMain installs the global providers once: the tracers and meters of the
+// global providers only delegate to the first ones installed
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(metrics)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	os.Exit(m.Run())
+}
+
+// endedSpans returns the spans ended since the last call, by name
+func endedSpans() map[string]sdktrace.ReadOnlySpan {
+	ended := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range spans.Ended() {
+		ended[span.Name()] = span
+	}
+	spans.Reset()
+	return ended
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTrace(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/words", func(w http.ResponseWriter, r *http.Request) {
+		// the handler gets the context of the server span
+		if !trace.SpanContextFromContext(r.Context()).IsValid() {
+			t.Error("no span in the context of the handler")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(Handler(mux, func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}))
+	defer server.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/words?input=a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: Transport(nil)}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	parent.End()
+	if len(req.Header) != 0 {
+		t.Errorf("the request of the caller was changed: %v", req.Header)
+	}
+
+	ended := endedSpans()
+	clientSpan, serverSpan := ended["HTTP GET"], ended["GET /words"]
+	if clientSpan == nil || serverSpan == nil {
+		t.Fatalf("got spans %v, want a client and a server span", ended)
+	}
+	// one trace: parent > client > server
+	if clientSpan.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("the client span isn't a child of the span of the context")
+	}
+	if serverSpan.Parent().SpanID() != clientSpan.SpanContext().SpanID() || !serverSpan.Parent().IsRemote() {
+		t.Error("the server span doesn't continue the trace of the client")
+	}
+	for _, span := range []sdktrace.ReadOnlySpan{clientSpan, serverSpan} {
+		if got := attributeValue(span, "http.response.status_code").AsInt64(); got != http.StatusServiceUnavailable {
+			t.Errorf("%s: got status code %d, want 503", span.Name(), got)
+		}
+		if span.Status().Code != codes.Error {
+			t.Errorf("%s: got status %v, want an error", span.Name(), span.Status())
+		}
+	}
+	if got := attributeValue(serverSpan, "http.route").AsString(); got != "/words" {
+		t.Errorf("got route %q, want /words", got)
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := metrics.Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+	recorded := make(map[string]bool)
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			recorded[m.Name] = true
+		}
+	}
+	for _, name := range []string{"http.server.request.duration", "http.client.request.duration"} {
+		if !recorded[name] {
+			t.Errorf("no %s metric", name)
+		}
+	}
+}
+
+func TestTransportError(t *testing.T) {
+	// nothing listens on the port of a closed server
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := &http.Client{Transport: Transport(nil)}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatal("no error from a closed server")
+	}
+	span := endedSpans()["HTTP GET"]
+	if span == nil {
+		t.Fatal("no client span")
+	}
+	if span.Status().Code != codes.Error || len(span.Events()) == 0 {
+		t.Errorf("the error isn't recorded: %v, %v", span.Status(), span.Events())
+	}
+}
+
+func TestHandlerClientError(t *testing.T) {
+	handler := Handler(http.NotFoundHandler(), nil)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	span := endedSpans()["GET"]
+	if span == nil {
+		t.Fatal("no server span named by the method")
+	}
+	// a 404 is the error of the client
+	if span.Status().Code == codes.Error {
+		t.Errorf("got status %v for a 404", span.Status())
+	}
+}
+
+func TestExporterEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected bool
+	}{
+		{name: "no endpoint", expected: false},
+		{name: "endpoint", env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318"}, expected: true},
+		{name: "signal endpoint", env: map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://localhost:4318/v1/traces"}, expected: true},
+		{name: "exporter off", env: map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318", "OTEL_TRACES_EXPORTER": "none"}, expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_TRACES_EXPORTER"} {
+				t.Setenv(name, test.env[name])
+			}
+			if got := exporterEnabled("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_TRACES_EXPORTER"); got != test.expected {
+				t.Errorf("got %t, want %t", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	shutdown, err := Setup(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown error: %s", err)
+	}
+}
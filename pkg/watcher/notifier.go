@@ -0,0 +1,70 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// notifier wakes the watcher on the events of the watched directories, from inotify
+// on Linux, kqueue on the BSDs and macOS, and ReadDirectoryChangesW on Windows. The
+// events only tell that something changed, the watcher compares the paths to know
+// what.
+type notifier struct {
+	watcher *fsnotify.Watcher
+	events  chan struct{}
+}
+
+func newNotifier() (*notifier, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	n := &notifier{watcher: w, events: make(chan struct{}, 1)}
+	go n.read()
+	return n, nil
+}
+
+// read wakes the watcher on the events, and on the errors too: after an overflow
+// of the queue of events, only a comparison of the paths tells what changed
+func (n *notifier) read() {
+	for {
+		select {
+		case _, ok := <-n.watcher.Events:
+			if !ok {
+				return
+			}
+		case _, ok := <-n.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+		select {
+		case n.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// watch watches the directories of paths that exist, and the paths that are
+// directories. Watching a path again is a no-op. It returns false when the
+// directory of a path doesn't exist yet, so it can only be polled until it's
+// created.
+func (n *notifier) watch(paths []string) bool {
+	complete := true
+	for _, path := range paths {
+		if err := n.watcher.Add(filepath.Dir(path)); err != nil {
+			complete = false
+		}
+		// the entries of a directory, a file is seen through its directory
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			n.watcher.Add(path)
+		}
+	}
+	return complete
+}
+
+func (n *notifier) close() {
+	n.watcher.Close()
+}
@@ -0,0 +1,228 @@
+// Package watcher calls functions when files or directories change, like to reload
+// the config of a service without a restart. The changes are debounced, so the many
+// writes of an editor saving a file, or of Kubernetes updating a ConfigMap volume,
+// are one change.
+package watcher
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDebounce = 100 * time.Millisecond
+	defaultInterval = time.Second
+)
+
+// Watcher watches files and directories, and calls its callbacks with the paths
+// that changed. The events of the file system wake it, through fsnotify, and it
+// polls when they aren't available.
+type Watcher struct {
+	// Debounce is how long the paths must be quiet before the callbacks are called,
+	// 100ms when 0
+	Debounce time.Duration
+	// Poll compares the paths every Interval instead of waiting for events, for the
+	// file systems without them, like NFS. The watcher also polls when fsnotify
+	// fails, and while the directory of a path doesn't exist.
+	Poll bool
+	// Interval is how often the paths are compared when polling, 1s when 0
+	Interval time.Duration
+	// Logger logs the changes, slog.Default() when nil
+	Logger *slog.Logger
+
+	mu        sync.Mutex
+	paths     []string
+	callbacks []func(changed []string)
+}
+
+// Add watches paths, before Run. A file is watched through its directory, so it can
+// be replaced or not exist yet. A directory is watched with its entries, not
+// recursively.
+func (w *Watcher) Add(paths ...string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		w.paths = append(w.paths, abs)
+	}
+	return nil
+}
+
+// OnChange registers a function called with the paths that changed, sorted: the
+// watched files, and the entries of the watched directories. The functions are
+// called one after the other, in the order they were registered.
+func (w *Watcher) OnChange(f func(changed []string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, f)
+}
+
+func (w *Watcher) logger() *slog.Logger {
+	if w.Logger != nil {
+		return w.Logger
+	}
+	return slog.Default()
+}
+
+// Run watches the paths until ctx is done
+func (w *Watcher) Run(ctx context.Context) error {
+	debounce, interval := w.Debounce, w.Interval
+	if debounce <= 0 {
+		debounce = defaultDebounce
+	}
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	w.mu.Lock()
+	paths := w.paths
+	w.mu.Unlock()
+
+	var n *notifier
+	if !w.Poll {
+		var err error
+		if n, err = newNotifier(); err != nil {
+			w.logger().WarnContext(ctx, "No events of the file system, polling the watched paths", "interval", interval, "error", err)
+			n = nil
+		}
+	}
+	// poll is the channel of the ticker while polling, nil otherwise
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var poll <-chan time.Time
+	var events <-chan struct{}
+	if n == nil {
+		poll = ticker.C
+	} else {
+		defer n.close()
+		events = n.events
+		if !n.watch(paths) {
+			poll = ticker.C
+		}
+	}
+
+	state := scan(paths)
+	// quiet fires once the paths were quiet for debounce, it's nil when nothing is
+	// pending
+	var quiet <-chan time.Time
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-events:
+		case <-poll:
+			if quiet != nil {
+				continue
+			}
+			// polled without waiting, the events reset the timer
+			if changed := diff(state, scan(paths)); len(changed) == 0 {
+				continue
+			}
+		case <-quiet:
+			quiet = nil
+			next := scan(paths)
+			changed := diff(state, next)
+			state = next
+			if n != nil {
+				// the directories created since are watched too, the polling stops
+				// once they all are
+				poll = nil
+				if !n.watch(paths) {
+					poll = ticker.C
+				}
+			}
+			if len(changed) > 0 {
+				w.logger().InfoContext(ctx, "Watched files changed", "paths", changed)
+				w.mu.Lock()
+				callbacks := w.callbacks
+				w.mu.Unlock()
+				for _, f := range callbacks {
+					f(changed)
+				}
+			}
+			continue
+		}
+		if !timer.Stop() && quiet != nil {
+			<-timer.C
+		}
+		timer.Reset(debounce)
+		quiet = timer.C
+	}
+}
+
+// entry is the state of a path, found when info isn't nil
+type entry struct {
+	info os.FileInfo
+}
+
+// scan returns the state of the paths and of the entries of the directories. The
+// symlinks are followed, so a Kubernetes ConfigMap is seen to change when it
+// switches its ..data link.
+func scan(paths []string) map[string]entry {
+	state := make(map[string]entry)
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			state[path] = entry{}
+			continue
+		}
+		state[path] = entry{info: info}
+		if !info.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			child := filepath.Join(path, e.Name())
+			if info, err := os.Stat(child); err == nil {
+				state[child] = entry{info: info}
+			}
+		}
+	}
+	return state
+}
+
+// diff returns the sorted paths whose state changed
+func diff(old, new map[string]entry) []string {
+	var changed []string
+	for path, e := range new {
+		if !old[path].same(e) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range old {
+		if _, ok := new[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// same tells whether the entries are the same file with the same size, mode and
+// modification time. A directory changes with its entries, which are compared on
+// their own.
+func (e entry) same(other entry) bool {
+	if e.info == nil || other.info == nil {
+		return e.info == nil && other.info == nil
+	}
+	if e.info.IsDir() && other.info.IsDir() {
+		return os.SameFile(e.info, other.info)
+	}
+	return os.SameFile(e.info, other.info) &&
+		e.info.Size() == other.info.Size() &&
+		e.info.Mode() == other.info.Mode() &&
+		e.info.ModTime().Equal(other.info.ModTime())
+}
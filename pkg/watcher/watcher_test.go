@@ -0,0 +1,147 @@
+package watcher
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	confd := filepath.Join(dir, "conf.d")
+	os.Mkdir(confd, 0o755)
+	write(t, file, "port: 8080")
+	write(t, filepath.Join(confd, "a.yaml"), "a")
+	paths := []string{file, confd, filepath.Join(dir, "missing.yaml")}
+
+	state := scan(paths)
+	if changed := diff(state, scan(paths)); changed != nil {
+		t.Errorf("Expected no changes, got %v", changed)
+	}
+
+	// replaced by a rename, like an editor or a ConfigMap update, with the same size
+	// and modification time
+	info, _ := os.Stat(file)
+	tmp := filepath.Join(dir, "config.yaml.tmp")
+	write(t, tmp, "port: 9090")
+	os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	os.Rename(tmp, file)
+	write(t, filepath.Join(confd, "b.yaml"), "b")
+	os.Remove(filepath.Join(confd, "a.yaml"))
+	write(t, filepath.Join(dir, "missing.yaml"), "")
+	write(t, filepath.Join(dir, "unwatched.yaml"), "")
+
+	expected := []string{
+		filepath.Join(confd, "a.yaml"),
+		filepath.Join(confd, "b.yaml"),
+		file,
+		filepath.Join(dir, "missing.yaml"),
+	}
+	if changed := diff(state, scan(paths)); !reflect.DeepEqual(changed, expected) {
+		t.Errorf("Expected %v, got %v", expected, changed)
+	}
+}
+
+// testRun checks the changes seen by w of a file rewritten, then removed
+func testRun(t *testing.T, w *Watcher) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	write(t, file, "port: 8080")
+
+	w.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	if err := w.Add(file); err != nil {
+		t.Fatal(err)
+	}
+	changes := make(chan []string, 10)
+	w.OnChange(func(changed []string) { changes <- changed })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	// the first scan
+	time.Sleep(100 * time.Millisecond)
+
+	// many writes are one change
+	for _, content := range []string{"", "port:", "port: 9090"} {
+		write(t, file, content)
+		time.Sleep(5 * time.Millisecond)
+	}
+	write(t, filepath.Join(dir, "other.yaml"), "")
+	select {
+	case changed := <-changes:
+		if !reflect.DeepEqual(changed, []string{file}) {
+			t.Errorf("Expected %s to change, got %v", file, changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a change")
+	}
+	select {
+	case changed := <-changes:
+		t.Errorf("Expected one change, got %v too", changed)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	os.Remove(file)
+	select {
+	case changed := <-changes:
+		if !reflect.DeepEqual(changed, []string{file}) {
+			t.Errorf("Expected %s to be removed, got %v", file, changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the removal")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run error: %s", err)
+	}
+}
+
+func TestRunEvents(t *testing.T) {
+	// only the events of fsnotify, the paths are never polled
+	testRun(t, &Watcher{Debounce: 50 * time.Millisecond, Interval: time.Hour})
+}
+
+func TestRunPoll(t *testing.T) {
+	testRun(t, &Watcher{Debounce: 50 * time.Millisecond, Poll: true, Interval: 20 * time.Millisecond})
+}
+
+func TestRunMissingDirectory(t *testing.T) {
+	// the directory of the file doesn't exist, so it's polled until it's created,
+	// then its events are watched
+	conf := filepath.Join(t.TempDir(), "conf")
+	file := filepath.Join(conf, "config.yaml")
+	w := &Watcher{Debounce: 20 * time.Millisecond, Interval: 20 * time.Millisecond, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	w.Add(file)
+	changes := make(chan []string, 10)
+	w.OnChange(func(changed []string) { changes <- changed })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	os.Mkdir(conf, 0o755)
+	for _, content := range []string{"port: 8080", "port: 9090"} {
+		write(t, file, content)
+		select {
+		case changed := <-changes:
+			if !reflect.DeepEqual(changed, []string{file}) {
+				t.Errorf("Expected %s to change, got %v", file, changed)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the change to %q", content)
+		}
+	}
+}
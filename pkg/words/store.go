@@ -0,0 +1,85 @@
+// Package words stores the words of the test-server and the grpc-demo, and sends
+// the new words to the subscribers of the streams
+package words
+
+import "sync"
+
+// Store holds the words in the order they're added. It's safe for concurrent use.
+type Store struct {
+	mu          sync.Mutex
+	words       []string
+	subscribers map[chan string]struct{}
+	closed      bool
+}
+
+func NewStore() *Store {
+	return &Store{subscribers: make(map[chan string]struct{})}
+}
+
+// Add adds the word, and sends it to every subscriber. Subscribers that can't
+// keep up miss words.
+func (s *Store) Add(word string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.words = append(s.words, word)
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber <- word:
+		default:
+		}
+	}
+}
+
+// Words returns a copy of the words
+func (s *Store) Words() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.words...)
+}
+
+// Occurrence returns how many times every word was added
+func (s *Store) Occurrence() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	occurrence := make(map[string]int)
+	for _, word := range s.words {
+		occurrence[word]++
+	}
+	return occurrence
+}
+
+// Subscribe returns the words added so far, a channel receiving the words added
+// after them, and a function to unsubscribe. The channel is closed when the
+// store is closed.
+func (s *Store) Subscribe() ([]string, <-chan string, func()) {
+	words := make(chan string, 100)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the copy is taken under the same lock as the subscription, so no word is
+	// missed or sent twice in between
+	existing := append([]string{}, s.words...)
+	if s.closed {
+		close(words)
+		return existing, words, func() {}
+	}
+	s.subscribers[words] = struct{}{}
+	return existing, words, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[words]; ok {
+			delete(s.subscribers, words)
+			close(words)
+		}
+	}
+}
+
+// Close ends the streams of all subscribers, and of the ones subscribing later
+func (s *Store) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for subscriber := range s.subscribers {
+		delete(s.subscribers, subscriber)
+		close(subscriber)
+	}
+}
@@ -0,0 +1,72 @@
+package words
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore()
+	for _, word := range []string{"a", "b", "a"} {
+		s.Add(word)
+	}
+	if words := fmt.Sprint(s.Words()); words != "[a b a]" {
+		t.Errorf("Expected [a b a], got %s", words)
+	}
+	if occurrence := fmt.Sprint(s.Occurrence()); occurrence != "map[a:2 b:1]" {
+		t.Errorf("Expected map[a:2 b:1], got %s", occurrence)
+	}
+
+	words := s.Words()
+	words[0] = "changed"
+	if s.Words()[0] != "a" {
+		t.Errorf("Expected Words to return a copy")
+	}
+}
+
+func TestStoreSubscribe(t *testing.T) {
+	s := NewStore()
+	s.Add("a")
+	existing, words, unsubscribe := s.Subscribe()
+	s.Add("b")
+	if fmt.Sprint(existing) != "[a]" {
+		t.Errorf("Expected the existing words [a], got %v", existing)
+	}
+	if word := <-words; word != "b" {
+		t.Errorf("Expected b, got %s", word)
+	}
+
+	unsubscribe()
+	if _, ok := <-words; ok {
+		t.Errorf("Expected the channel to be closed after unsubscribe")
+	}
+	unsubscribe()
+
+	_, other, _ := s.Subscribe()
+	s.Close()
+	if _, ok := <-other; ok {
+		t.Errorf("Expected the channel to be closed with the store")
+	}
+	if _, later, _ := s.Subscribe(); func() bool { _, ok := <-later; return ok }() {
+		t.Errorf("Expected the channel of a subscriber after Close to be closed")
+	}
+}
+
+func TestStoreConcurrent(t *testing.T) {
+	s := NewStore()
+	_, words, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.Add(fmt.Sprint(i))
+		}(i)
+	}
+	wg.Wait()
+	if len(s.Words()) != 50 || len(words) != 50 {
+		t.Errorf("Expected 50 words stored and sent, got %d and %d", len(s.Words()), len(words))
+	}
+}
@@ -0,0 +1,239 @@
+// Package wordsclient is the client of the words and occurrence endpoints of the
+// test-server, shared by the API examples of this repository
+package wordsclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the address of the test-server started with start-test-server.sh
+const DefaultBaseURL = "http://localhost:8080"
+
+// Options configure a Client
+type Options struct {
+	// BaseURL is prepended to request urls starting with /. Defaults to DefaultBaseURL.
+	BaseURL string
+	// HTTPClient sends the requests, e.g. with a timeout or the transport of a CLI.
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Retry retries network errors and 502, 503 and 504 responses of GET and HEAD
+	// requests, and connection errors of the other methods. The zero value sends
+	// every request once.
+	Retry RetryPolicy
+	// Logger logs every request with its X-Request-ID. Defaults to a logger
+	// discarding everything.
+	Logger *slog.Logger
+}
+
+// Client sends requests to the test-server
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	retry      RetryPolicy
+	logger     *slog.Logger
+	sleep      func(ctx context.Context, d time.Duration) error
+	now        func() time.Time
+}
+
+// New creates a Client, filling in the defaults of the options
+func New(options Options) *Client {
+	client := &Client{
+		baseURL:    strings.TrimSuffix(options.BaseURL, "/"),
+		httpClient: options.HTTPClient,
+		retry:      options.Retry,
+		logger:     options.Logger,
+		sleep:      sleep,
+		now:        time.Now,
+	}
+	if client.baseURL == "" {
+		client.baseURL = DefaultBaseURL
+	}
+	if client.httpClient == nil {
+		client.httpClient = http.DefaultClient
+	}
+	if client.logger == nil {
+		client.logger = slog.New(slog.DiscardHandler)
+	}
+	return client
+}
+
+// Request is a request sent by Do
+type Request struct {
+	Method string // defaults to GET
+	URL    string // an absolute url, or a path of the base url
+	Body   []byte // sent as application/json, unless the transport sets the Content-Type
+}
+
+// URL resolves a path of the base url; absolute urls are returned as they are
+func (c *Client) URL(requestURL string) string {
+	if strings.HasPrefix(requestURL, "/") {
+		return c.baseURL + requestURL
+	}
+	return requestURL
+}
+
+// Do sends the request and returns the body of the response. Anything but a 200
+// with a JSON body is a RequestError. Transient errors are retried according to
+// the RetryPolicy of the options; if all attempts fail, the error is a RetryError.
+func (c *Client) Do(ctx context.Context, req Request) ([]byte, error) {
+	body, _, err := c.DoWithMetadata(ctx, req)
+	return body, err
+}
+
+// do sends the request once
+func (c *Client) do(ctx context.Context, req Request) ([]byte, Metadata, error) {
+	requestURL := c.URL(req.URL)
+	if _, err := url.ParseRequestURI(requestURL); err != nil {
+		return nil, Metadata{}, fmt.Errorf("%w: %s", ErrInvalidURL, err)
+	}
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var requestBody io.Reader
+	if req.Body != nil {
+		requestBody = bytes.NewReader(req.Body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, requestURL, requestBody)
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("%w: %w", ErrValidation, err)
+	}
+	if req.Body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	request.Header.Set(RequestIDHeader, RequestID(ctx))
+
+	response, err := c.httpClient.Do(request)
+
+	if err != nil {
+		return nil, Metadata{}, fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+
+	defer response.Body.Close()
+
+	metadata := Metadata{StatusCode: response.StatusCode, Header: response.Header}
+
+	if response.StatusCode != 200 {
+		return nil, metadata, RequestError{
+			HTTPCode: response.StatusCode,
+			Body:     readErrorBody(response.Body),
+			Err:      ErrHTTPStatus,
+		}
+	}
+
+	body, err := io.ReadAll(response.Body)
+
+	if err != nil {
+		return nil, metadata, fmt.Errorf("%w: reading the body: %w", ErrNetwork, err)
+	}
+
+	if !json.Valid(body) {
+		return nil, metadata, RequestError{
+			HTTPCode: response.StatusCode,
+			Body:     errorBody(body),
+			Err:      fmt.Errorf("%w: no valid json returned", ErrDecode),
+		}
+	}
+
+	return body, metadata, nil
+}
+
+// Get requests the url and parses the response. Pages other than words and
+// occurrence are an UnknownPageError.
+func (c *Client) Get(ctx context.Context, requestURL string) (Response, error) {
+	body, err := c.Do(ctx, Request{URL: requestURL})
+	if err != nil {
+		return nil, err
+	}
+	return ParseResponse(body)
+}
+
+// Words adds the input, if any, and returns all words
+func (c *Client) Words(ctx context.Context, input string) (Words, error) {
+	requestURL := "/words"
+	if input != "" {
+		requestURL += "?" + url.Values{"input": {input}}.Encode()
+	}
+	var words Words
+	err := c.getJSON(ctx, requestURL, &words)
+	return words, err
+}
+
+// Occurrence returns how often every word was added
+func (c *Client) Occurrence(ctx context.Context) (Occurrence, error) {
+	var occurrence Occurrence
+	err := c.getJSON(ctx, "/occurrence", &occurrence)
+	return occurrence, err
+}
+
+func (c *Client) getJSON(ctx context.Context, requestURL string, v interface{}) error {
+	body, err := c.Do(ctx, Request{URL: requestURL})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+	return nil
+}
+
+type LoginRequest struct {
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	Token string `json:"token"`
+}
+
+// Login exchanges the password for a token, sent by the examples as a bearer token:
+//
+//	curl -X POST 'http://localhost:8080/login' -d '{"password":"secret"}'
+//	{"token":"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."}
+func (c *Client) Login(ctx context.Context, password string) (string, error) {
+	loginRequest, err := json.Marshal(LoginRequest{Password: password})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrValidation, err)
+	}
+
+	body, err := c.Do(ctx, Request{Method: http.MethodPost, URL: "/login", Body: loginRequest})
+	var reqErr RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPCode != 200 {
+		reqErr.Err = fmt.Errorf("login failed: %w", reqErr.Err)
+		return "", reqErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var loginResponse LoginResponse
+
+	err = json.Unmarshal(body, &loginResponse)
+	if err != nil {
+		return "", RequestError{
+			HTTPCode: 200,
+			Body:     errorBody(body),
+			Err:      fmt.Errorf("%w: login: %w", ErrDecode, err),
+		}
+	}
+
+	if loginResponse.Token == "" {
+		return "", RequestError{
+			HTTPCode: 200,
+			Body:     errorBody(body),
+			Err:      fmt.Errorf("%w: no token found", ErrDecode),
+		}
+	}
+
+	return loginResponse.Token, nil
+}
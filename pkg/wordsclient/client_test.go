@@ -0,0 +1,158 @@
+package wordsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newTestServer serves the pages of the test-server used by the tests
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/words", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"page":"words","input":%q,"words":["a","b"]}`, r.URL.Query().Get("input"))
+	})
+	mux.HandleFunc("/occurrence", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"page":"occurrence","words":{"a":1,"b":2}}`)
+	})
+	mux.HandleFunc("/other", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"page":"other"}`)
+	})
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "not json")
+	})
+	mux.HandleFunc("/huge-error", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, strings.Repeat("x", 1<<20), http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" || string(body) != `{"password":"secret"}` {
+			http.Error(w, "Password doesn't match", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"token":"abc"}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGet(t *testing.T) {
+	server := newTestServer(t)
+	client := New(Options{BaseURL: server.URL})
+
+	res, err := client.Get(context.Background(), "/words")
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if res.GetResponse() != "Words: a, b" {
+		t.Errorf("Got wrong output: %s", res.GetResponse())
+	}
+
+	res, err = client.Get(context.Background(), server.URL+"/occurrence")
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	occurrence, ok := res.(Occurrence)
+	if !ok || occurrence.Words["b"] != 2 {
+		t.Errorf("Expected an Occurrence with b: 2, got %#v", res)
+	}
+
+	_, err = client.Get(context.Background(), "/other")
+	var unknownPage UnknownPageError
+	if !errors.Is(err, ErrUnknownPage) || !errors.Is(err, ErrDecode) || !errors.As(err, &unknownPage) {
+		t.Fatalf("Expected an UnknownPageError, got %v", err)
+	}
+	if unknownPage.Page != "other" || unknownPage.Body != `{"page":"other"}` {
+		t.Errorf("Got wrong UnknownPageError: %#v", unknownPage)
+	}
+}
+
+func TestWordsAndOccurrence(t *testing.T) {
+	server := newTestServer(t)
+	client := New(Options{BaseURL: server.URL + "/"})
+
+	words, err := client.Words(context.Background(), "a b")
+	if err != nil {
+		t.Fatalf("Words error: %s", err)
+	}
+	if words.Input != "a b" || len(words.Words) != 2 {
+		t.Errorf("Got wrong words: %#v", words)
+	}
+
+	occurrence, err := client.Occurrence(context.Background())
+	if err != nil {
+		t.Fatalf("Occurrence error: %s", err)
+	}
+	if occurrence.Words["a"] != 1 {
+		t.Errorf("Got wrong occurrence: %#v", occurrence)
+	}
+}
+
+func TestDoErrors(t *testing.T) {
+	server := newTestServer(t)
+	client := New(Options{BaseURL: server.URL})
+
+	_, err := client.Do(context.Background(), Request{URL: "/nope"})
+	var reqErr RequestError
+	if !errors.As(err, &reqErr) || reqErr.HTTPCode != http.StatusNotFound || !errors.Is(err, ErrHTTPStatus) {
+		t.Errorf("Expected a RequestError with HTTP code 404, got %#v", err)
+	}
+
+	_, err = client.Do(context.Background(), Request{URL: "/text"})
+	if !errors.As(err, &reqErr) || !errors.Is(err, ErrDecode) || reqErr.Body != "not json" {
+		t.Errorf("Expected a RequestError for the invalid json, got %#v", err)
+	}
+
+	_, err = client.Do(context.Background(), Request{URL: "/huge-error"})
+	if !errors.As(err, &reqErr) || len(reqErr.Body) != MaxErrorBody {
+		t.Errorf("Expected the body of the error to be cut at %d bytes, got %d", MaxErrorBody, len(reqErr.Body))
+	}
+
+	_, err = client.Do(context.Background(), Request{URL: "words"})
+	if !errors.Is(err, ErrInvalidURL) || !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrInvalidURL, got %v", err)
+	}
+
+	server.Close()
+	_, err = client.Do(context.Background(), Request{URL: "/words"})
+	var urlErr *url.Error
+	if !errors.Is(err, ErrNetwork) || !errors.As(err, &urlErr) || errors.As(err, &reqErr) {
+		t.Errorf("Expected a network error wrapping the url.Error, got %#v", err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	server := newTestServer(t)
+	client := New(Options{BaseURL: server.URL})
+
+	token, err := client.Login(context.Background(), "secret")
+	if err != nil {
+		t.Fatalf("Login error: %s", err)
+	}
+	if token != "abc" {
+		t.Errorf("Expected token abc, got %s", token)
+	}
+
+	_, err = client.Login(context.Background(), "wrong")
+	var reqErr RequestError
+	if !errors.As(err, &reqErr) || !errors.Is(err, ErrHTTPStatus) || reqErr.HTTPCode != http.StatusBadRequest {
+		t.Errorf("Expected a login failed RequestError, got %#v", err)
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	client := New(Options{})
+	if client.URL("/words") != DefaultBaseURL+"/words" {
+		t.Errorf("Expected the default base url, got %s", client.URL("/words"))
+	}
+	if client.httpClient != http.DefaultClient {
+		t.Errorf("Expected http.DefaultClient")
+	}
+}
@@ -0,0 +1,74 @@
+package wordsclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Sentinel errors categorizing the errors of this package. Use errors.Is to check for them.
+var (
+	// ErrValidation is returned for a request that can't be sent
+	ErrValidation = errors.New("validation error")
+	// ErrInvalidURL is returned for a request url that can't be parsed; it also matches ErrValidation
+	ErrInvalidURL = fmt.Errorf("%w: URL is in invalid format", ErrValidation)
+	// ErrNetwork is returned when no complete response was received: connection errors,
+	// timeouts, or a body that couldn't be read
+	ErrNetwork = errors.New("network error")
+	// ErrHTTPStatus is returned when the server replies with another status code than 200
+	ErrHTTPStatus = errors.New("unexpected HTTP status")
+	// ErrDecode is returned when a body isn't the expected JSON
+	ErrDecode = errors.New("decode error")
+	// ErrUnknownPage matches an UnknownPageError; it also matches ErrDecode
+	ErrUnknownPage = fmt.Errorf("%w: unknown page", ErrDecode)
+)
+
+// MaxErrorBody is the number of bytes of a response body kept in the errors, so an
+// error page of a misbehaving server isn't read, logged or printed in full
+const MaxErrorBody = 4 << 10
+
+// RequestError is a response the client couldn't use: an error status code,
+// or a body that isn't the expected JSON. Err wraps ErrHTTPStatus or ErrDecode.
+type RequestError struct {
+	HTTPCode int
+	Body     string // the start of the body, up to MaxErrorBody bytes
+	Err      error
+}
+
+func (r RequestError) Error() string {
+	return r.Err.Error()
+}
+
+// Unwrap gives errors.Is and errors.As access to the wrapped error
+func (r RequestError) Unwrap() error {
+	return r.Err
+}
+
+// UnknownPageError is a valid JSON response of a page without a Response type
+type UnknownPageError struct {
+	Page string // the page field of the response, empty if it had none
+	Body string // the start of the body, up to MaxErrorBody bytes
+}
+
+func (u UnknownPageError) Error() string {
+	return fmt.Sprintf("unknown page %q", u.Page)
+}
+
+func (u UnknownPageError) Unwrap() error {
+	return ErrUnknownPage
+}
+
+// errorBody returns the start of a body for an error, up to MaxErrorBody bytes
+func errorBody(body []byte) string {
+	if len(body) > MaxErrorBody {
+		body = body[:MaxErrorBody]
+	}
+	return string(body)
+}
+
+// readErrorBody reads the start of the body of an error response, up to
+// MaxErrorBody bytes. The rest isn't read at all.
+func readErrorBody(r io.Reader) string {
+	body, _ := io.ReadAll(io.LimitReader(r, MaxErrorBody))
+	return string(body)
+}
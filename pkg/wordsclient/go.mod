@@ -0,0 +1,3 @@
+module wordsclient
+
+go 1.24.2
@@ -0,0 +1,62 @@
+package wordsclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Metadata is the HTTP part of a response: what came with the body
+type Metadata struct {
+	StatusCode int
+	Header     http.Header
+	// Duration is the time from sending the request until the body was read,
+	// including the retries and their backoff
+	Duration time.Duration
+}
+
+// Result is a parsed Response together with its Metadata. It implements Response
+// itself, so it can be used wherever a Response is expected.
+type Result struct {
+	Response
+	Metadata Metadata
+}
+
+// DoWithMetadata is Do, returning the Metadata of the last attempt as well.
+// The Metadata is returned on errors too, with a zero StatusCode if no response was received.
+func (c *Client) DoWithMetadata(ctx context.Context, req Request) ([]byte, Metadata, error) {
+	ctx, id := withRequestID(ctx)
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	logger := c.logger.With("request_id", id, "method", method, "url", c.URL(req.URL))
+
+	start := c.now()
+	var metadata Metadata
+	body, err := c.doWithRetry(ctx, logger, method, func() ([]byte, error) {
+		var (
+			body []byte
+			err  error
+		)
+		body, metadata, err = c.do(ctx, req)
+		return body, err
+	})
+	metadata.Duration = c.now().Sub(start)
+	if err != nil {
+		logger.Warn("request failed", "status", metadata.StatusCode, "duration", metadata.Duration, "error", err)
+	} else {
+		logger.Info("request finished", "status", metadata.StatusCode, "duration", metadata.Duration)
+	}
+	return body, metadata, err
+}
+
+// GetResult is Get, returning the Metadata of the response with it
+func (c *Client) GetResult(ctx context.Context, requestURL string) (Result, error) {
+	body, metadata, err := c.DoWithMetadata(ctx, Request{URL: requestURL})
+	if err != nil {
+		return Result{Metadata: metadata}, err
+	}
+	res, err := ParseResponse(body)
+	return Result{Response: res, Metadata: metadata}, err
+}
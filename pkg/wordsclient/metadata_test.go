@@ -0,0 +1,69 @@
+package wordsclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetResult(t *testing.T) {
+	server := newTestServer(t)
+	client := New(Options{BaseURL: server.URL})
+	calls := 0
+	client.now = func() time.Time {
+		calls++
+		return time.Unix(0, 0).Add(time.Duration(calls) * 10 * time.Millisecond)
+	}
+
+	res, err := client.GetResult(context.Background(), "/words")
+	if err != nil {
+		t.Fatalf("GetResult error: %s", err)
+	}
+	if res.GetResponse() != "Words: a, b" {
+		t.Errorf("Got wrong output: %s", res.GetResponse())
+	}
+	if _, ok := res.Response.(Words); !ok {
+		t.Errorf("Expected the Result to hold Words, got %T", res.Response)
+	}
+	if res.Metadata.StatusCode != http.StatusOK || res.Metadata.Header.Get("Content-Type") == "" {
+		t.Errorf("Got wrong metadata: %#v", res.Metadata)
+	}
+	if res.Metadata.Duration != 10*time.Millisecond {
+		t.Errorf("Expected a duration of 10ms, got %s", res.Metadata.Duration)
+	}
+}
+
+func TestGetResultError(t *testing.T) {
+	server := newTestServer(t)
+	client := New(Options{BaseURL: server.URL})
+
+	res, err := client.GetResult(context.Background(), "/nope")
+	if !errors.Is(err, ErrHTTPStatus) {
+		t.Fatalf("Expected ErrHTTPStatus, got %v", err)
+	}
+	if res.Metadata.StatusCode != http.StatusNotFound || res.Metadata.Header.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("Expected the metadata of the 404, got %#v", res.Metadata)
+	}
+
+	server.Close()
+	_, metadata, err := client.DoWithMetadata(context.Background(), Request{URL: "/words"})
+	if !errors.Is(err, ErrNetwork) || metadata.StatusCode != 0 || metadata.Header != nil {
+		t.Errorf("Expected no metadata of a network error, got %#v (%v)", metadata, err)
+	}
+}
+
+func TestDoWithMetadataRetries(t *testing.T) {
+	server, _ := newStatusServer(t, 503)
+	var waits []time.Duration
+	client := newRetryClient(server.URL, RetryPolicy{MaxAttempts: 2}, &waits)
+
+	_, metadata, err := client.DoWithMetadata(context.Background(), Request{URL: "/words"})
+	if err != nil {
+		t.Fatalf("DoWithMetadata error: %s", err)
+	}
+	if metadata.StatusCode != http.StatusOK {
+		t.Errorf("Expected the metadata of the last attempt, got %#v", metadata)
+	}
+}
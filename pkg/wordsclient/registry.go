@@ -0,0 +1,69 @@
+package wordsclient
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	pagesMu sync.RWMutex
+	pages   = map[string]func() Response{}
+)
+
+func init() {
+	RegisterPage("words", func() Response { return &Words{} })
+	RegisterPage("occurrence", func() Response { return &Occurrence{} })
+}
+
+// RegisterPage makes ParseResponse decode the responses of a page into the type
+// returned by newResponse, which must be a pointer to unmarshal into:
+//
+//	wordsclient.RegisterPage("health", func() wordsclient.Response { return &Health{} })
+//
+// ParseResponse returns the value it points to when that implements Response too,
+// e.g. Words rather than *Words. Like sql.Register, it panics when a page is
+// registered twice, so it is meant to be called from init functions.
+func RegisterPage(name string, newResponse func() Response) {
+	pagesMu.Lock()
+	defer pagesMu.Unlock()
+	if newResponse == nil {
+		panic("wordsclient: RegisterPage of " + name + " with a nil function")
+	}
+	if _, exists := pages[name]; exists {
+		panic(fmt.Sprintf("wordsclient: RegisterPage called twice for page %q", name))
+	}
+	pages[name] = newResponse
+}
+
+// Pages returns the names of the registered pages, sorted
+func Pages() []string {
+	pagesMu.RLock()
+	defer pagesMu.RUnlock()
+	names := make([]string, 0, len(pages))
+	for name := range pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupPage(name string) (func() Response, bool) {
+	pagesMu.RLock()
+	defer pagesMu.RUnlock()
+	newResponse, ok := pages[name]
+	return newResponse, ok
+}
+
+// dereference returns the value res points to, if it is a Response as well
+func dereference(res Response) Response {
+	value := reflect.ValueOf(res)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return res
+	}
+	if elem, ok := value.Elem().Interface().(Response); ok {
+		return elem
+	}
+	return res
+}
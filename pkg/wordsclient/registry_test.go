@@ -0,0 +1,49 @@
+package wordsclient
+
+import (
+	"fmt"
+	"testing"
+)
+
+type health struct {
+	Status string `json:"status"`
+}
+
+func (h *health) GetResponse() string {
+	return fmt.Sprintf("Status: %s", h.Status)
+}
+
+func TestRegisterPage(t *testing.T) {
+	RegisterPage("health-test", func() Response { return &health{} })
+
+	res, err := ParseResponse([]byte(`{"page":"health-test","status":"ok"}`))
+	if err != nil {
+		t.Fatalf("ParseResponse error: %s", err)
+	}
+	// health has a pointer receiver: the pointer is returned
+	if h, ok := res.(*health); !ok || h.Status != "ok" {
+		t.Errorf("Expected *health with status ok, got %#v", res)
+	}
+
+	// Words has value receivers: the value is returned, as before the registry
+	res, err = ParseResponse([]byte(`{"page":"words","words":["a"]}`))
+	if _, ok := res.(Words); err != nil || !ok {
+		t.Errorf("Expected Words, got %#v, %v", res, err)
+	}
+}
+
+func TestRegisterPageTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic when registering words again")
+		}
+	}()
+	RegisterPage("words", func() Response { return &Words{} })
+}
+
+func TestPages(t *testing.T) {
+	pages := Pages()
+	if len(pages) < 2 || pages[len(pages)-1] != "words" {
+		t.Errorf("Expected the sorted registered pages, got %v", pages)
+	}
+}
@@ -0,0 +1,73 @@
+package wordsclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newRequestIDServer records the X-Request-ID of every request, replying with the status codes in order
+func newRequestIDServer(t *testing.T, ids *[]string, statusCodes ...int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*ids = append(*ids, r.Header.Get(RequestIDHeader))
+		if len(*ids) <= len(statusCodes) {
+			w.WriteHeader(statusCodes[len(*ids)-1])
+			return
+		}
+		fmt.Fprint(w, `{"page":"words","words":["a"]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRequestID(t *testing.T) {
+	var ids []string
+	server := newRequestIDServer(t, &ids)
+	var logs bytes.Buffer
+	client := New(Options{BaseURL: server.URL, Logger: slog.New(slog.NewJSONHandler(&logs, nil))})
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Get(context.Background(), "/words"); err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+	}
+	if len(ids) != 2 || len(ids[0]) != 32 || ids[0] == ids[1] {
+		t.Fatalf("Expected a new request id per call, got %q", ids)
+	}
+
+	var entry struct {
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+		Status    int    `json:"status"`
+	}
+	if err := json.NewDecoder(&logs).Decode(&entry); err != nil {
+		t.Fatalf("Expected a JSON log entry: %s", err)
+	}
+	if entry.Msg != "request finished" || entry.RequestID != ids[0] || entry.Status != 200 {
+		t.Errorf("Expected the log entry of the first request, got %+v", entry)
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	var ids []string
+	server := newRequestIDServer(t, &ids, http.StatusServiceUnavailable)
+	var waits []time.Duration
+	client := newRetryClient(server.URL, RetryPolicy{MaxAttempts: 2}, &waits)
+
+	ctx := WithRequestID(context.Background(), "my-id")
+	if _, err := client.Get(ctx, "/words"); err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if len(ids) != 2 || ids[0] != "my-id" || ids[1] != "my-id" {
+		t.Errorf("Expected every attempt to send my-id, got %q", ids)
+	}
+	if RequestID(context.Background()) != "" {
+		t.Errorf("Expected no request id without WithRequestID")
+	}
+}
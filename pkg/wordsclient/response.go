@@ -0,0 +1,69 @@
+package wordsclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Page is the field every response of the test-server has, naming its type
+type Page struct {
+	Name string `json:"page"`
+}
+
+// Response is a parsed response of the test-server
+type Response interface {
+	GetResponse() string
+}
+
+// Words is the response of the words page:
+//
+//	curl 'http://localhost:8080/words?input=word1'
+//	{"page":"words","input":"word3","words":["word1","word2","word2","word3","word3","word3","word3"]}
+type Words struct {
+	Input string   `json:"input" yaml:"input"`
+	Words []string `json:"words" yaml:"words"`
+}
+
+func (w Words) GetResponse() string {
+	return fmt.Sprintf("Words: %s", strings.Join(w.Words, ", "))
+}
+
+// Occurrence is the response of the occurrence page:
+//
+//	curl 'http://localhost:8080/occurrence'
+//	{"page":"occurrence","words":{"word1":1,"word2":2,"word3":3}}
+type Occurrence struct {
+	Words map[string]int `json:"words" yaml:"words"`
+}
+
+func (o Occurrence) GetResponse() string {
+	words := []string{}
+	for word, occurrence := range o.Words {
+		words = append(words, fmt.Sprintf("%s: %d", word, occurrence))
+	}
+	return fmt.Sprintf("Words: %s", strings.Join(words, ", "))
+}
+
+// ParseResponse unmarshals a body into the Response type registered for its page
+// with RegisterPage. Other pages are an UnknownPageError.
+func ParseResponse(body []byte) (Response, error) {
+	var page Page
+
+	err := json.Unmarshal(body, &page)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	newResponse, ok := lookupPage(page.Name)
+	if !ok {
+		return nil, UnknownPageError{Page: page.Name, Body: errorBody(body)}
+	}
+	res := newResponse()
+	err = json.Unmarshal(body, res)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrDecode, page.Name, err)
+	}
+
+	return dereference(res), nil
+}
@@ -0,0 +1,37 @@
+package wordsclient
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseResponse(t *testing.T) {
+	res, err := ParseResponse([]byte(`{"page":"words","input":"c","words":["a","b","c"]}`))
+	if err != nil {
+		t.Fatalf("ParseResponse error: %s", err)
+	}
+	words, ok := res.(Words)
+	if !ok || words.Input != "c" || words.GetResponse() != "Words: a, b, c" {
+		t.Errorf("Got wrong words: %#v", res)
+	}
+
+	res, err = ParseResponse([]byte(`{"page":"occurrence","words":{"a":3}}`))
+	if err != nil {
+		t.Fatalf("ParseResponse error: %s", err)
+	}
+	if res.GetResponse() != "Words: a: 3" {
+		t.Errorf("Got wrong output: %s", res.GetResponse())
+	}
+}
+
+func TestParseResponseInvalid(t *testing.T) {
+	var syntaxErr *json.SyntaxError
+	if _, err := ParseResponse([]byte(`{"page":`)); !errors.As(err, &syntaxErr) || !errors.Is(err, ErrDecode) {
+		t.Errorf("Expected a json.SyntaxError, got %v", err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if _, err := ParseResponse([]byte(`{"page":"words","words":"a"}`)); !errors.As(err, &typeErr) {
+		t.Errorf("Expected a json.UnmarshalTypeError, got %v", err)
+	}
+}
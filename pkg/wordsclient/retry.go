@@ -0,0 +1,184 @@
+package wordsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures how Do retries transient errors: network errors and the
+// 502, 503 and 504 status codes. Other status codes, like 4xx, are never retried.
+// Only GET and HEAD requests, which are safe to send twice, are retried once the
+// request may have reached the server; the other methods are only retried when
+// the connection couldn't be made.
+type RetryPolicy struct {
+	// MaxAttempts is the number of requests sent, including the first one.
+	// 0 or 1 disables retries.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubling after every retry
+	Backoff time.Duration
+	// MaxBackoff caps the doubled backoff, if set
+	MaxBackoff time.Duration
+}
+
+// RetryError is returned when all attempts of a retried request failed.
+// It unwraps to the error of the last attempt, so the sentinel categories still match.
+type RetryError struct {
+	Attempts []error // the error of every attempt, in order
+}
+
+func (r RetryError) Error() string {
+	attempts := make([]string, len(r.Attempts))
+	for i, err := range r.Attempts {
+		attempts[i] = fmt.Sprintf("attempt %d: %s", i+1, err)
+	}
+	return fmt.Sprintf("giving up after %d attempts (%s)", len(r.Attempts), strings.Join(attempts, "; "))
+}
+
+func (r RetryError) Unwrap() error {
+	return r.Attempts[len(r.Attempts)-1]
+}
+
+// isTransient reports whether the request with the method may succeed when sent
+// again, without the server running it twice
+func isTransient(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil {
+		// the caller gave up, not the server
+		return false
+	}
+	if errors.Is(err, ErrNetwork) && isDialError(err) {
+		// the server never got the request
+		return true
+	}
+	if !isIdempotent(method) {
+		return false
+	}
+	var reqErr RequestError
+	if errors.As(err, &reqErr) {
+		return isRetryableStatus(reqErr.HTTPCode) && errors.Is(err, ErrHTTPStatus)
+	}
+	return errors.Is(err, ErrNetwork)
+}
+
+// isDialError reports whether the connection to the server couldn't be made
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isIdempotent reports whether a request with the method can be sent twice
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// isRetryableStatus reports whether the status code is a transient server error
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before the retry following the attempt, starting at 0
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.Backoff
+	for i := 0; i < attempt && (p.MaxBackoff <= 0 || backoff < p.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// sleep waits for d, or returns the error of the context if it's done first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// doWithRetry sends the request of the method with send until it succeeds, fails
+// with an error that isn't transient, or the attempts of the policy are used up
+func (c *Client) doWithRetry(ctx context.Context, logger *slog.Logger, method string, send func() ([]byte, error)) ([]byte, error) {
+	if c.retry.MaxAttempts <= 1 {
+		logger.Debug("sending request")
+		return send()
+	}
+
+	var attempts []error
+	for attempt := 0; ; attempt++ {
+		logger.Debug("sending request", "attempt", attempt+1)
+		body, err := send()
+		if err == nil {
+			return body, nil
+		}
+		attempts = append(attempts, err)
+		if !isTransient(ctx, method, err) {
+			if len(attempts) == 1 {
+				return nil, err
+			}
+			return nil, RetryError{Attempts: attempts}
+		}
+		if len(attempts) >= c.retry.MaxAttempts {
+			return nil, RetryError{Attempts: attempts}
+		}
+		backoff := c.retry.backoff(attempt)
+		logger.Warn("retrying request", "attempt", attempt+1, "backoff", backoff, "error", err)
+		if err := c.sleep(ctx, backoff); err != nil {
+			attempts = append(attempts, fmt.Errorf("%w: %w", ErrNetwork, err))
+			return nil, RetryError{Attempts: attempts}
+		}
+	}
+}
+
+// Transport returns a RoundTripper retrying with the policy, for http.Clients that
+// don't use a Client. Only GET and HEAD requests are retried, as they're safe to
+// send twice, on network errors and the same status codes as Do.
+func (p RetryPolicy) Transport(next http.RoundTripper) http.RoundTripper {
+	return retryTransport{policy: p, next: next, sleep: sleep}
+}
+
+type retryTransport struct {
+	policy RetryPolicy
+	next   http.RoundTripper
+	// sleep waits between the attempts, replaced in the tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func (t retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.policy.MaxAttempts <= 1 || !isIdempotent(req.Method) {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		response, err := t.next.RoundTrip(req)
+		if err == nil && !isRetryableStatus(response.StatusCode) {
+			return response, nil
+		}
+		if attempt+1 >= t.policy.MaxAttempts || ctx.Err() != nil {
+			return response, err
+		}
+		if response != nil {
+			// discard the body so the connection can be reused
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+		if err := t.sleep(ctx, t.policy.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
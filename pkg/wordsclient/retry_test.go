@@ -0,0 +1,244 @@
+package wordsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newStatusServer replies with the status codes in order, and a words page after them
+func newStatusServer(t *testing.T, statusCodes ...int) (*httptest.Server, *int) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= len(statusCodes) {
+			http.Error(w, http.StatusText(statusCodes[calls-1]), statusCodes[calls-1])
+			return
+		}
+		fmt.Fprint(w, `{"page":"words","words":["a"]}`)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// newRetryClient creates a client recording the backoff instead of sleeping
+func newRetryClient(baseURL string, policy RetryPolicy, waits *[]time.Duration) *Client {
+	client := New(Options{BaseURL: baseURL, Retry: policy})
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	return client
+}
+
+func TestRetryTransientStatus(t *testing.T) {
+	server, calls := newStatusServer(t, 503, 502, 504)
+	var waits []time.Duration
+	client := newRetryClient(server.URL, RetryPolicy{MaxAttempts: 4, Backoff: time.Second, MaxBackoff: 3 * time.Second}, &waits)
+
+	if _, err := client.Get(context.Background(), "/words"); err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if *calls != 4 {
+		t.Errorf("Expected 4 attempts, got %d", *calls)
+	}
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if fmt.Sprint(waits) != fmt.Sprint(expected) {
+		t.Errorf("Expected backoff %v, got %v", expected, waits)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server, calls := newStatusServer(t, 503, 503, 503)
+	var waits []time.Duration
+	client := newRetryClient(server.URL, RetryPolicy{MaxAttempts: 3}, &waits)
+
+	_, err := client.Get(context.Background(), "/words")
+	var retryErr RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 3 {
+		t.Fatalf("Expected a RetryError with 3 attempts, got %#v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", *calls)
+	}
+	var reqErr RequestError
+	if !errors.Is(err, ErrHTTPStatus) || !errors.As(err, &reqErr) || reqErr.HTTPCode != 503 {
+		t.Errorf("Expected the RetryError to unwrap to the last 503, got %#v", err)
+	}
+	if !strings.Contains(err.Error(), "attempt 3: unexpected HTTP status") {
+		t.Errorf("Expected the attempt history in the error, got %s", err)
+	}
+}
+
+func TestRetryNever4xx(t *testing.T) {
+	server, calls := newStatusServer(t, 404)
+	var waits []time.Duration
+	client := newRetryClient(server.URL, RetryPolicy{MaxAttempts: 3}, &waits)
+
+	_, err := client.Get(context.Background(), "/words")
+	var retryErr RetryError
+	if errors.As(err, &retryErr) || !errors.Is(err, ErrHTTPStatus) {
+		t.Errorf("Expected the 404 RequestError without retries, got %#v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("Expected 1 attempt, got %d", *calls)
+	}
+}
+
+func TestRetryStopsAtPermanentError(t *testing.T) {
+	server, calls := newStatusServer(t, 503, 400)
+	var waits []time.Duration
+	client := newRetryClient(server.URL, RetryPolicy{MaxAttempts: 5}, &waits)
+
+	_, err := client.Get(context.Background(), "/words")
+	var retryErr RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 2 {
+		t.Fatalf("Expected a RetryError with 2 attempts, got %#v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("Expected 2 attempts, got %d", *calls)
+	}
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	server, _ := newStatusServer(t)
+	server.Close()
+	var waits []time.Duration
+	client := newRetryClient(server.URL, RetryPolicy{MaxAttempts: 2}, &waits)
+
+	_, err := client.Get(context.Background(), "/words")
+	var retryErr RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 2 || !errors.Is(err, ErrNetwork) {
+		t.Errorf("Expected a RetryError of 2 network errors, got %#v", err)
+	}
+}
+
+func TestRetryPostNotRetried(t *testing.T) {
+	// the server got the request, then the connection is closed without a response
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer server.Close()
+	var waits []time.Duration
+	client := newRetryClient(server.URL, RetryPolicy{MaxAttempts: 3}, &waits)
+
+	_, err := client.Do(context.Background(), Request{Method: http.MethodPost, URL: "/words", Body: []byte(`{"word":"a"}`)})
+	var retryErr RetryError
+	if !errors.Is(err, ErrNetwork) || errors.As(err, &retryErr) || calls != 1 {
+		t.Errorf("Expected the POST to be sent once, got %d attempts and %#v", calls, err)
+	}
+	// a GET is retried
+	calls = 0
+	client.Get(context.Background(), "/words")
+	if calls != 3 {
+		t.Errorf("Expected the GET to be sent 3 times, got %d", calls)
+	}
+
+	statusServer, statusCalls := newStatusServer(t, 503)
+	client = newRetryClient(statusServer.URL, RetryPolicy{MaxAttempts: 3}, &waits)
+	_, err = client.Do(context.Background(), Request{Method: http.MethodPost, URL: "/words"})
+	if !errors.Is(err, ErrHTTPStatus) || *statusCalls != 1 {
+		t.Errorf("Expected the 503 of the POST without retries, got %d attempts and %#v", *statusCalls, err)
+	}
+}
+
+func TestRetryPostDialError(t *testing.T) {
+	// the connection is refused, so the server never got the request
+	server, _ := newStatusServer(t)
+	server.Close()
+	var waits []time.Duration
+	client := newRetryClient(server.URL, RetryPolicy{MaxAttempts: 2}, &waits)
+
+	_, err := client.Do(context.Background(), Request{Method: http.MethodPost, URL: "/words"})
+	var retryErr RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Attempts) != 2 {
+		t.Errorf("Expected the POST to be retried after a dial error, got %#v", err)
+	}
+}
+
+func TestRetryCanceledContext(t *testing.T) {
+	server, calls := newStatusServer(t, 503, 503)
+	client := New(Options{BaseURL: server.URL, Retry: RetryPolicy{MaxAttempts: 3, Backoff: time.Hour}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.Get(ctx, "/words")
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrNetwork) {
+		t.Errorf("Expected the backoff to stop at the deadline, got %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("Expected 1 attempt, got %d", *calls)
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		statusCodes []int
+		status      int
+		calls       int
+	}{
+		{name: "retried", method: http.MethodGet, statusCodes: []int{503, 502}, status: 200, calls: 3},
+		{name: "gives up", method: http.MethodGet, statusCodes: []int{503, 503, 503}, status: 503, calls: 3},
+		{name: "4xx", method: http.MethodGet, statusCodes: []int{404}, status: 404, calls: 1},
+		{name: "not idempotent", method: http.MethodPost, statusCodes: []int{503}, status: 503, calls: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, calls := newStatusServer(t, test.statusCodes...)
+			var waits []time.Duration
+			transport := RetryPolicy{MaxAttempts: 3, Backoff: time.Second}.Transport(http.DefaultTransport).(retryTransport)
+			transport.sleep = func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+
+			req, err := http.NewRequest(test.method, server.URL+"/words", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := (&http.Client{Transport: transport}).Do(req)
+			if err != nil {
+				t.Fatalf("Do error: %s", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != test.status {
+				t.Errorf("Expected status %d, got %d", test.status, res.StatusCode)
+			}
+			if *calls != test.calls {
+				t.Errorf("Expected %d attempts, got %d", test.calls, *calls)
+			}
+			if len(waits) != test.calls-1 {
+				t.Errorf("Expected %d backoffs, got %v", test.calls-1, waits)
+			}
+		})
+	}
+}
+
+func TestRetryTransportCanceled(t *testing.T) {
+	server, calls := newStatusServer(t, 503, 503, 503)
+	ctx, cancel := context.WithCancel(context.Background())
+	transport := RetryPolicy{MaxAttempts: 3, Backoff: time.Hour}.Transport(http.DefaultTransport)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/words", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = transport.RoundTrip(req)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("Expected 1 attempt, got %d", *calls)
+	}
+}
@@ -0,0 +1,77 @@
+package wordsclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// StreamedWord is a line of the words stream:
+//
+//	curl -N 'http://localhost:8080/words/stream'
+//	{"word":"word1"}
+type StreamedWord struct {
+	Word string `json:"word" yaml:"word"`
+}
+
+// StreamWords reads the newline delimited JSON of /words/stream and calls fn with
+// every word as it arrives. The stream doesn't end by itself: StreamWords returns
+// when ctx is done, fn returns an error, or the connection is lost. Streams are
+// never retried, as fn already processed the words received.
+func (c *Client) StreamWords(ctx context.Context, fn func(word string) error) error {
+	ctx, id := withRequestID(ctx)
+	requestURL := c.URL("/words/stream")
+	c.logger.Info("streaming words", "request_id", id, "url", requestURL)
+	if _, err := url.ParseRequestURI(requestURL); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidURL, err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrValidation, err)
+	}
+	request.Header.Set("Accept", "application/x-ndjson")
+	request.Header.Set(RequestIDHeader, id)
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return RequestError{
+			HTTPCode: response.StatusCode,
+			Body:     readErrorBody(response.Body),
+			Err:      ErrHTTPStatus,
+		}
+	}
+
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var streamedWord StreamedWord
+		if err := json.Unmarshal(line, &streamedWord); err != nil {
+			return RequestError{
+				HTTPCode: response.StatusCode,
+				Body:     errorBody(line),
+				Err:      fmt.Errorf("%w: stream: %w", ErrDecode, err),
+			}
+		}
+		if err := fn(streamedWord.Word); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: reading the stream: %w", ErrNetwork, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrNetwork, err)
+	}
+	return fmt.Errorf("%w: stream closed by the server", ErrNetwork)
+}
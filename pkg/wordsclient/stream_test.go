@@ -0,0 +1,81 @@
+package wordsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newStreamServer streams the words, and then keeps the connection open until the client leaves
+func newStreamServer(t *testing.T, lines ...string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/words/stream" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStreamWords(t *testing.T) {
+	server := newStreamServer(t, `{"word":"a"}`, ``, `{"word":"b"}`, `{"word":"c"}`)
+	client := New(Options{BaseURL: server.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var words []string
+	err := client.StreamWords(ctx, func(word string) error {
+		words = append(words, word)
+		if len(words) == 3 {
+			// the stream doesn't end, the words arrived while it was open
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrNetwork) {
+		t.Errorf("Expected the stream to end with context.Canceled, got %v", err)
+	}
+	if fmt.Sprint(words) != "[a b c]" {
+		t.Errorf("Expected words [a b c], got %v", words)
+	}
+}
+
+func TestStreamWordsStop(t *testing.T) {
+	server := newStreamServer(t, `{"word":"a"}`, `{"word":"b"}`)
+	client := New(Options{BaseURL: server.URL})
+
+	errStop := errors.New("stop")
+	err := client.StreamWords(context.Background(), func(word string) error {
+		return errStop
+	})
+	if err != errStop {
+		t.Errorf("Expected the error of fn, got %v", err)
+	}
+}
+
+func TestStreamWordsErrors(t *testing.T) {
+	server := newStreamServer(t, `{"word":"a"}`, `not json`)
+	client := New(Options{BaseURL: server.URL})
+
+	err := client.StreamWords(context.Background(), func(word string) error { return nil })
+	var reqErr RequestError
+	if !errors.As(err, &reqErr) || !errors.Is(err, ErrDecode) || reqErr.Body != "not json" {
+		t.Errorf("Expected a decode RequestError with the line, got %#v", err)
+	}
+
+	client = New(Options{BaseURL: server.URL + "/nope"})
+	err = client.StreamWords(context.Background(), func(word string) error { return nil })
+	if !errors.As(err, &reqErr) || reqErr.HTTPCode != http.StatusNotFound || !errors.Is(err, ErrHTTPStatus) {
+		t.Errorf("Expected a 404 RequestError, got %#v", err)
+	}
+}
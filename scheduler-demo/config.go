@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"scheduler"
+)
+
+// Config configures the scheduler-demo. It's read from the -config YAML file,
+// config.yaml by default, the SCHEDULER_ environment variables override the file,
+// and the flags override both. The jobs are only in the file:
+//
+//	results: results.jsonl
+//	timeZone: Europe/Paris
+//	jobs:
+//	  - name: words
+//	    schedule: "*/5 * * * *"
+//	    url: http://localhost:8080/words
+//	    timeout: 2s
+//	    jitter: 10s
+type Config struct {
+	Results  string      `yaml:"results" env:"RESULTS" flag:"results" usage:"JSON lines file the results of the runs are appended to, none when empty"`
+	TimeZone string      `yaml:"timeZone" env:"TIME_ZONE" flag:"time-zone" usage:"time zone of the cron schedules, e.g. Europe/Paris, the local one when empty"`
+	Jobs     []JobConfig `yaml:"jobs"`
+}
+
+// JobConfig is a job calling an HTTP endpoint
+type JobConfig struct {
+	Name string `yaml:"name"`
+	// Schedule is a cron expression, a macro like @hourly, or @every and a duration
+	Schedule string `yaml:"schedule"`
+	// Method is GET when empty
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+	// Status are the statuses of a successful run, any 2xx when empty
+	Status  []int         `yaml:"status"`
+	Timeout time.Duration `yaml:"timeout"`
+	Jitter  time.Duration `yaml:"jitter"`
+	// Overlap is skip (default), allow or queue
+	Overlap string `yaml:"overlap"`
+}
+
+// Validate checks the time zone and the jobs
+func (c Config) Validate() error {
+	if _, err := time.LoadLocation(c.TimeZone); err != nil {
+		return fmt.Errorf("invalid timeZone: %s", err)
+	}
+	if len(c.Jobs) == 0 {
+		return errors.New("no jobs")
+	}
+	_, err := c.jobs()
+	return err
+}
+
+// jobs returns the jobs of the config
+func (c Config) jobs() ([]scheduler.Job, error) {
+	jobs := make([]scheduler.Job, 0, len(c.Jobs))
+	for _, j := range c.Jobs {
+		schedule, err := scheduler.Parse(j.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %s", j.Name, err)
+		}
+		if u, err := url.Parse(j.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("job %s: invalid url %q", j.Name, j.URL)
+		}
+		header := http.Header{}
+		for name, value := range j.Headers {
+			header.Set(name, value)
+		}
+		target := scheduler.HTTPTarget{Method: j.Method, URL: j.URL, Header: header, Body: j.Body, Status: j.Status}
+		jobs = append(jobs, scheduler.Job{
+			Name:     j.Name,
+			Schedule: schedule,
+			Run:      target.Run,
+			Timeout:  j.Timeout,
+			Jitter:   j.Jitter,
+			Overlap:  scheduler.Overlap(j.Overlap),
+		})
+	}
+	return jobs, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"config"
+	"scheduler"
+)
+
+func TestConfig(t *testing.T) {
+	c, err := config.Load(Config{}, []string{"-config", "config.yaml"}, config.Options{Name: "scheduler-demo", EnvPrefix: "SCHEDULER_TEST_"})
+	if err != nil {
+		t.Fatalf("Load error: %s", err)
+	}
+	jobs, err := c.jobs()
+	if err != nil || len(jobs) != 4 {
+		t.Fatalf("Expected the 4 jobs of config.yaml, got %d, %v", len(jobs), err)
+	}
+	if jobs[3].Name != "assignment1" || jobs[3].Overlap != scheduler.OverlapQueue || jobs[3].Timeout != 5*time.Second {
+		t.Errorf("Unexpected job %+v", jobs[3])
+	}
+
+	for _, job := range []JobConfig{
+		{Name: "schedule", Schedule: "every minute", URL: "http://localhost:8080"},
+		{Name: "url", Schedule: "@hourly", URL: "localhost:8080"},
+	} {
+		err := Config{Jobs: []JobConfig{job}}.Validate()
+		if err == nil || !strings.HasPrefix(err.Error(), "job "+job.Name) {
+			t.Errorf("Expected an error for the job %s, got %v", job.Name, err)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "results.jsonl")
+	r, err := newRecorder(file, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduled := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	r.record(scheduler.Result{Job: "words", Scheduled: scheduled, Started: scheduled.Add(time.Second), Duration: 1500 * time.Microsecond})
+	r.record(scheduler.Result{Job: "words", Scheduled: scheduled, Skipped: true})
+	r.record(scheduler.Result{Job: "words", Scheduled: scheduled, Started: scheduled, Err: errors.New("503 Service Unavailable")})
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	var records []record
+	for _, line := range lines {
+		var rec record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("Invalid line %s: %s", line, err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 3 || !records[0].OK || records[0].Duration != "2ms" || records[1].OK || !records[1].Skipped || records[2].Error != "503 Service Unavailable" {
+		t.Errorf("Unexpected results:\n%s", b)
+	}
+	if strings.Contains(lines[1], "started") {
+		t.Errorf("Expected no start time for a skipped run, got %s", lines[1])
+	}
+}
@@ -0,0 +1,24 @@
+module scheduler-demo
+
+go 1.24.2
+
+require (
+	config v0.0.0-00010101000000-000000000000
+	lifecycle v0.0.0-00010101000000-000000000000
+	logging v0.0.0-00010101000000-000000000000
+	scheduler v0.0.0-00010101000000-000000000000
+)
+
+require (
+	go.opentelemetry.io/otel v1.35.0 // indirect
+	go.opentelemetry.io/otel/trace v1.35.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace config => ../pkg/config
+
+replace lifecycle => ../pkg/lifecycle
+
+replace logging => ../pkg/logging
+
+replace scheduler => ../pkg/scheduler
@@ -0,0 +1,62 @@
+// Command scheduler-demo calls HTTP endpoints on cron schedules, like the ones of
+// the test-server, and records the results of the calls
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+
+	"config"
+	"lifecycle"
+	"logging"
+	"scheduler"
+)
+
+func main() {
+	logger, err := logging.Setup()
+	if err != nil {
+		logger.Error("Invalid logging configuration", "error", err)
+		os.Exit(2)
+	}
+	c, err := config.Load(Config{Results: "results.jsonl"}, os.Args[1:], config.Options{Name: "scheduler-demo", EnvPrefix: "SCHEDULER_", File: "config.yaml"})
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(2)
+	}
+
+	results, err := newRecorder(c.Results, logger)
+	if err != nil {
+		logger.Error("Can't open the results file", "error", err)
+		os.Exit(1)
+	}
+	// validated by Load
+	location, _ := time.LoadLocation(c.TimeZone)
+	jobs, _ := c.jobs()
+	s := &scheduler.Scheduler{Location: location, OnResult: results.record, Logger: logger}
+	for _, job := range jobs {
+		if err := s.Add(job); err != nil {
+			logger.Error("Invalid job", "error", err)
+			os.Exit(2)
+		}
+		logger.Info("Scheduled", "job", job.Name, "next", job.Schedule.Next(time.Now().In(location)))
+	}
+
+	// the running calls finish before the results file is closed
+	var group lifecycle.Group
+	group.Add(
+		lifecycle.Component{Name: "results", Stop: func(ctx context.Context) error { return results.Close() }},
+		lifecycle.Func("scheduler", s.Run),
+	)
+	ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+	if err := group.Run(ctx); err != nil {
+		logger.Error("Stopped with errors", "error", err)
+		stop()
+		os.Exit(1)
+	}
+}
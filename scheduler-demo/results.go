@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"scheduler"
+)
+
+// record is a line of the results file
+type record struct {
+	Job       string    `json:"job"`
+	Scheduled time.Time `json:"scheduled"`
+	Started   time.Time `json:"started,omitzero"`
+	Duration  string    `json:"duration,omitempty"`
+	OK        bool      `json:"ok"`
+	Error     string    `json:"error,omitempty"`
+	Skipped   bool      `json:"skipped,omitempty"`
+}
+
+// recorder logs the results of the runs, and appends them to a file as JSON lines
+type recorder struct {
+	logger *slog.Logger
+
+	mu sync.Mutex
+	// w is the results file, nil when there's none
+	w io.WriteCloser
+}
+
+// newRecorder returns a recorder appending to file, only logging when it's empty
+func newRecorder(file string, logger *slog.Logger) (*recorder, error) {
+	r := &recorder{logger: logger}
+	if file == "" {
+		return r, nil
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	r.w = f
+	return r, nil
+}
+
+// record records the result of a run, the runs that failed or were skipped are
+// already logged by the scheduler
+func (r *recorder) record(result scheduler.Result) {
+	line := record{Job: result.Job, Scheduled: result.Scheduled, OK: result.Err == nil && !result.Skipped, Skipped: result.Skipped}
+	if !result.Skipped {
+		line.Started = result.Started
+		line.Duration = result.Duration.Round(time.Millisecond).String()
+	}
+	if result.Err != nil {
+		line.Error = result.Err.Error()
+	}
+	if line.OK {
+		r.logger.Info("Run succeeded", "job", result.Job, "duration", line.Duration)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.w == nil {
+		return
+	}
+	b, _ := json.Marshal(line)
+	if _, err := r.w.Write(append(b, '\n')); err != nil {
+		r.logger.Error("Can't write the result", "job", result.Job, "error", err)
+	}
+}
+
+// Close closes the results file
+func (r *recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.w == nil {
+		return nil
+	}
+	return r.w.Close()
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ssh-demo/pkg/sshclient"
+	"ssh-demo/pkg/transfer"
+)
+
+const usage = `Usage: %s [flags] <command> <source> <destination>
+
+Commands:
+  upload    copy a local file to the host
+  download  copy a file of the host to a local file
+  sync      copy a local directory to the host, only the changed files
+
+The password is read from SSH_PASSWORD.
+
+`
+
+func main() {
+	home, _ := os.UserHomeDir()
+	host := flag.String("host", "", "[user@]host[:port] to copy the files to or from")
+	defaultUser := flag.String("user", currentUser(), "user when -host has none")
+	port := flag.Int("port", 22, "port when -host has none")
+	keyFile := flag.String("key", "", "private key to log in with, the passphrase is read from SSH_PASSPHRASE")
+	knownHosts := flag.String("known-hosts", filepath.Join(home, ".ssh", "known_hosts"), "known_hosts file to verify the host key")
+	insecure := flag.Bool("insecure-ignore-host-key", false, "don't verify the host key, only for throwaway test machines")
+	connectTimeout := flag.Duration("connect-timeout", 10*time.Second, "time to connect and log in")
+	bwLimit := flag.String("bwlimit", "", "maximum bandwidth in bytes per second, with K, M or G, like 512K")
+	quiet := flag.Bool("quiet", false, "don't print the progress")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 3 || *host == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command, source, destination := flag.Arg(0), flag.Arg(1), flag.Arg(2)
+	if command != "upload" && command != "download" && command != "sync" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	target, err := sshclient.ParseTarget(*host, *defaultUser, *port)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid host: %s\n", err)
+		os.Exit(2)
+	}
+	limit, err := parseBandwidth(*bwLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid -bwlimit: %s\n", err)
+		os.Exit(2)
+	}
+	auth, err := sshclient.NewAuth(sshclient.AuthOptions{
+		KeyFile:               *keyFile,
+		Passphrase:            os.Getenv("SSH_PASSPHRASE"),
+		Password:              os.Getenv("SSH_PASSWORD"),
+		KnownHostsFile:        *knownHosts,
+		InsecureIgnoreHostKey: *insecure,
+		Timeout:               *connectTimeout,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid options: %s\n", err)
+		os.Exit(2)
+	}
+
+	// Ctrl-C stops the transfer and removes the partial file, a second Ctrl-C
+	// ends the program right away when the host doesn't answer
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+
+	options := transfer.Options{BandwidthLimit: limit}
+	if !*quiet {
+		options.Progress = os.Stderr
+	}
+	if err := run(ctx, auth, target, options, command, source, destination); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, auth *sshclient.Auth, target sshclient.Target, options transfer.Options, command, source, destination string) error {
+	client, err := auth.Dial(ctx, target)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	files, err := transfer.New(client, options)
+	if err != nil {
+		return err
+	}
+	defer files.Close()
+
+	switch command {
+	case "upload":
+		return files.Upload(ctx, source, destination)
+	case "download":
+		return files.Download(ctx, source, destination)
+	}
+	start := time.Now()
+	stats, err := files.Sync(ctx, source, destination)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Uploaded %d files (%d bytes), %d unchanged, in %s\n", stats.Uploaded, stats.Bytes, stats.Unchanged, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// parseBandwidth parses a number of bytes, with an optional K, M or G suffix of 1024.
+// An empty string is no limit.
+func parseBandwidth(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	units := map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30}
+	number, multiplier := s, int64(1)
+	if unit, ok := units[strings.ToUpper(s[len(s)-1:])]; ok {
+		number, multiplier = s[:len(s)-1], unit
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not a positive number of bytes", s)
+	}
+	return n * multiplier, nil
+}
+
+// currentUser is the default user, like the ssh command uses
+func currentUser() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"ssh-demo/pkg/sshkeys"
+)
+
+const usage = `Usage: ssh-keys <command> [flags]
+
+Commands:
+  generate   generate a key pair, the passphrase is read from SSH_PASSPHRASE
+  authorize  add public keys to an authorized_keys file
+  revoke     remove public keys from an authorized_keys file
+  list       list the keys of an authorized_keys file
+
+Run ssh-keys <command> -h for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	home, _ := os.UserHomeDir()
+	defaultAuthorizedKeys := filepath.Join(home, ".ssh", "authorized_keys")
+
+	var err error
+	switch os.Args[1] {
+	case "generate":
+		err = generate(os.Args[2:])
+	case "authorize":
+		err = authorize(os.Args[2:], defaultAuthorizedKeys)
+	case "revoke":
+		err = revoke(os.Args[2:], defaultAuthorizedKeys)
+	case "list":
+		err = list(os.Args[2:], defaultAuthorizedKeys)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func generate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	keyType := flags.String("type", sshkeys.ED25519, "key type: ed25519 or rsa")
+	bits := flags.Int("bits", 4096, "size of an rsa key")
+	comment := flags.String("comment", "", "comment of the key, like user@host")
+	out := flags.String("out", "id_demo", "file of the private key, the public key is written to the same file with .pub")
+	force := flags.Bool("force", false, "overwrite existing keys")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	pair, err := sshkeys.Generate(*keyType, *bits, *comment, os.Getenv("SSH_PASSPHRASE"))
+	if err != nil {
+		return err
+	}
+	if err := sshkeys.WriteKeyPair(*out, pair, *force); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s and %s.pub\n%s\n", *out, *out, pair.Fingerprint)
+	return nil
+}
+
+// readPublicKeys reads the public keys of the .pub files
+func readPublicKeys(files []string) ([]sshkeys.AuthorizedKey, error) {
+	var keys []sshkeys.AuthorizedKey
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := sshkeys.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a public key: %w", file, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func authorize(args []string, defaultFile string) error {
+	flags := flag.NewFlagSet("authorize", flag.ContinueOnError)
+	file := flags.String("file", defaultFile, "authorized_keys file")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ssh-keys authorize [-file authorized_keys] key.pub...\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	keys, err := readPublicKeys(flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no public key files")
+	}
+
+	for _, key := range keys {
+		added, err := sshkeys.AddAuthorizedKey(*file, key)
+		if err != nil {
+			return err
+		}
+		if added {
+			fmt.Printf("Added %s %s\n", key.Fingerprint(), key.Comment)
+		} else {
+			fmt.Printf("Already authorized: %s %s\n", key.Fingerprint(), key.Comment)
+		}
+	}
+	return nil
+}
+
+func revoke(args []string, defaultFile string) error {
+	flags := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	file := flags.String("file", defaultFile, "authorized_keys file")
+	comment := flags.String("comment", "", "remove the keys with this comment, like user@host")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: ssh-keys revoke [-file authorized_keys] [-comment user@host] [key.pub...]\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	keys, err := readPublicKeys(flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 && *comment == "" {
+		return fmt.Errorf("no public key files or -comment")
+	}
+
+	var matches []func(sshkeys.AuthorizedKey) bool
+	for _, key := range keys {
+		matches = append(matches, sshkeys.MatchKey(key.Key))
+	}
+	if *comment != "" {
+		matches = append(matches, sshkeys.MatchComment(*comment))
+	}
+	var removed []sshkeys.AuthorizedKey
+	_, err = sshkeys.RemoveAuthorizedKeys(*file, func(key sshkeys.AuthorizedKey) bool {
+		for _, match := range matches {
+			if match(key) {
+				removed = append(removed, key)
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range removed {
+		fmt.Printf("Removed %s %s\n", key.Fingerprint(), key.Comment)
+	}
+	if len(removed) == 0 {
+		fmt.Printf("No matching keys in %s\n", *file)
+	}
+	return nil
+}
+
+func list(args []string, defaultFile string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	file := flags.String("file", defaultFile, "authorized_keys file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	keys, err := sshkeys.ReadAuthorizedKeys(*file)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		fmt.Printf("%s %s %s\n", key.Key.Type(), key.Fingerprint(), key.Comment)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+module ssh-demo
+
+go 1.24.2
+
+require (
+	github.com/pkg/sftp v1.13.9
+	golang.org/x/crypto v0.39.0
+)
+
+require (
+	github.com/kr/fs v0.1.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+)
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"ssh-demo/pkg/sshclient"
+)
+
+// readHostsFile reads one [user@]host[:port] per line. Empty lines and lines
+// starting with # are skipped:
+//
+//	# web servers
+//	web1.example.com
+//	ubuntu@10.0.0.5:2222
+func readHostsFile(name, defaultUser string, defaultPort int) ([]sshclient.Target, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseHosts(file, defaultUser, defaultPort)
+}
+
+func parseHosts(r io.Reader, defaultUser string, defaultPort int) ([]sshclient.Target, error) {
+	var targets []sshclient.Target
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		target, err := sshclient.ParseTarget(text, defaultUser, defaultPort)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		targets = append(targets, target)
+	}
+	return targets, scanner.Err()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ssh-demo/pkg/sshclient"
+)
+
+// hostFlags collects every -host flag
+type hostFlags []string
+
+func (h *hostFlags) String() string {
+	return strings.Join(*h, ",")
+}
+
+func (h *hostFlags) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+func main() {
+	home, _ := os.UserHomeDir()
+	var hosts hostFlags
+	flag.Var(&hosts, "host", "[user@]host[:port] to run the command on, can be repeated")
+	hostsFile := flag.String("hosts-file", "", "file with one [user@]host[:port] per line")
+	defaultUser := flag.String("user", currentUser(), "user of the hosts without a user")
+	port := flag.Int("port", 22, "port of the hosts without a port")
+	keyFile := flag.String("key", "", "private key to log in with, the passphrase is read from SSH_PASSPHRASE")
+	knownHosts := flag.String("known-hosts", filepath.Join(home, ".ssh", "known_hosts"), "known_hosts file to verify the host keys")
+	insecure := flag.Bool("insecure-ignore-host-key", false, "don't verify the host keys, only for throwaway test machines")
+	concurrency := flag.Int("concurrency", 10, "number of hosts to run the command on at the same time")
+	connectTimeout := flag.Duration("connect-timeout", 10*time.Second, "time to connect and log in to a host")
+	timeout := flag.Duration("timeout", time.Minute, "time the command may take on a host, including the login")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] command\n\nThe password is read from SSH_PASSWORD.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := strings.Join(flag.Args(), " ")
+
+	var targets []sshclient.Target
+	if *hostsFile != "" {
+		fileTargets, err := readHostsFile(*hostsFile, *defaultUser, *port)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Can't read the hosts file: %s\n", err)
+			os.Exit(2)
+		}
+		targets = append(targets, fileTargets...)
+	}
+	for _, host := range hosts {
+		target, err := sshclient.ParseTarget(host, *defaultUser, *port)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid host: %s\n", err)
+			os.Exit(2)
+		}
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(os.Stderr, "No hosts, set -host or -hosts-file\n")
+		os.Exit(2)
+	}
+
+	auth, err := sshclient.NewAuth(sshclient.AuthOptions{
+		KeyFile:               *keyFile,
+		Passphrase:            os.Getenv("SSH_PASSPHRASE"),
+		Password:              os.Getenv("SSH_PASSWORD"),
+		KnownHostsFile:        *knownHosts,
+		InsecureIgnoreHostKey: *insecure,
+		Timeout:               *connectTimeout,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid options: %s\n", err)
+		os.Exit(2)
+	}
+	runner := Runner{Auth: auth, Concurrency: *concurrency, Timeout: *timeout}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results := runner.RunAll(ctx, targets, command)
+	if !printResults(os.Stdout, results) {
+		os.Exit(1)
+	}
+}
+
+// currentUser is the default user, like the ssh command uses
+func currentUser() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+// printResults prints the output of every host, and reports whether the command
+// succeeded everywhere
+func printResults(w io.Writer, results []Result) bool {
+	ok := true
+	for _, result := range results {
+		if result.Err != nil {
+			ok = false
+			fmt.Fprintf(w, "== %s: %s (%s)\n", result.Target, result.Err, result.Duration.Round(time.Millisecond))
+		} else {
+			if result.ExitCode != 0 {
+				ok = false
+			}
+			fmt.Fprintf(w, "== %s: exit code %d (%s)\n", result.Target, result.ExitCode, result.Duration.Round(time.Millisecond))
+		}
+		w.Write(result.Stdout)
+		if len(result.Stderr) > 0 {
+			fmt.Fprintf(w, "-- stderr:\n")
+			w.Write(result.Stderr)
+		}
+	}
+	return ok
+}
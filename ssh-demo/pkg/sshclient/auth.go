@@ -0,0 +1,91 @@
+package sshclient
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// AuthOptions are the ways to log in and to verify the hosts
+type AuthOptions struct {
+	// KeyFile is a private key in OpenSSH or PEM format. An encrypted key needs
+	// the Passphrase.
+	KeyFile    string
+	Passphrase string
+	Password   string
+	// KnownHostsFile verifies the host keys, unless InsecureIgnoreHostKey is set
+	KnownHostsFile        string
+	InsecureIgnoreHostKey bool
+	// Timeout is the time to connect and log in
+	Timeout time.Duration
+}
+
+// Auth is the loaded key and known hosts, shared by the connections to all hosts
+type Auth struct {
+	methods         []ssh.AuthMethod
+	hostKeyCallback ssh.HostKeyCallback
+	timeout         time.Duration
+}
+
+// NewAuth loads the key and the known hosts. The key is tried before the password.
+func NewAuth(o AuthOptions) (*Auth, error) {
+	var methods []ssh.AuthMethod
+	if o.KeyFile != "" {
+		signer, err := loadKey(o.KeyFile, o.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if o.Password != "" {
+		methods = append(methods, ssh.Password(o.Password))
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("no key or password to log in with")
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !o.InsecureIgnoreHostKey {
+		var err error
+		if hostKeyCallback, err = knownhosts.New(o.KnownHostsFile); err != nil {
+			return nil, fmt.Errorf("can't read the known hosts: %w", err)
+		}
+	}
+
+	return &Auth{methods: methods, hostKeyCallback: hostKeyCallback, timeout: o.Timeout}, nil
+}
+
+// clientConfig returns the ssh config to log in as user
+func (a *Auth) clientConfig(user string) *ssh.ClientConfig {
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            a.methods,
+		HostKeyCallback: a.hostKeyCallback,
+		Timeout:         a.timeout,
+	}
+}
+
+func loadKey(keyFile, passphrase string) (ssh.Signer, error) {
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't read the key: %w", err)
+	}
+	var signer ssh.Signer
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, fmt.Errorf("%s is encrypted, set the passphrase in SSH_PASSPHRASE", keyFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't parse the key %s: %w", keyFile, err)
+	}
+	return signer, nil
+}
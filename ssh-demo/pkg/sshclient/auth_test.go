@@ -0,0 +1,250 @@
+package sshclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"ssh-demo/pkg/sshkeys"
+)
+
+// writeKey generates a key pair and writes the private key, encrypted with the
+// passphrase if there is one
+func writeKey(t *testing.T, passphrase string) (string, ssh.PublicKey) {
+	t.Helper()
+	pair, err := sshkeys.Generate(sshkeys.ED25519, 0, "demo", passphrase)
+	if err != nil {
+		t.Fatalf("Generate error: %s", err)
+	}
+	public, _, _, _, err := ssh.ParseAuthorizedKey(pair.Public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(file, pair.Private, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file, public
+}
+
+// serveLogins is an ssh server in the same process that logs in the authorized key
+// or the password secret, and rejects the sessions. It returns the target and the
+// host key.
+func serveLogins(t *testing.T, authorized ssh.PublicKey) (Target, ssh.PublicKey) {
+	t.Helper()
+	keyFile, _ := writeKey(t, "")
+	signer, err := loadKey(keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if authorized == nil || string(key.Marshal()) != string(authorized.Marshal()) {
+				return nil, errors.New("unknown key")
+			}
+			return nil, nil
+		},
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) != "secret" {
+				return nil, errors.New("wrong password")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sshConn, channels, requests, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(requests)
+				go func() {
+					for newChannel := range channels {
+						newChannel.Reject(ssh.Prohibited, "logins only")
+					}
+				}()
+				sshConn.Wait()
+			}()
+		}
+	}()
+	return Target{User: "demo", Host: "127.0.0.1", Port: listener.Addr().(*net.TCPAddr).Port}, signer.PublicKey()
+}
+
+// writeKnownHosts writes a known_hosts file with the key of target
+func writeKnownHosts(t *testing.T, target Target, key ssh.PublicKey) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(file, []byte(knownhosts.Line([]string{target.Address()}, key)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestNewAuthKeys(t *testing.T) {
+	plain, _ := writeKey(t, "")
+	encrypted, _ := writeKey(t, "passphrase")
+	notAKey := filepath.Join(t.TempDir(), "not_a_key")
+	if err := os.WriteFile(notAKey, []byte("not a key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options AuthOptions
+		err     string
+	}{
+		{"plain key", AuthOptions{KeyFile: plain}, ""},
+		{"encrypted key", AuthOptions{KeyFile: encrypted, Passphrase: "passphrase"}, ""},
+		{"password", AuthOptions{Password: "secret"}, ""},
+		{"no passphrase", AuthOptions{KeyFile: encrypted}, "is encrypted, set the passphrase in SSH_PASSPHRASE"},
+		{"wrong passphrase", AuthOptions{KeyFile: encrypted, Passphrase: "wrong"}, "can't parse the key"},
+		{"not a key", AuthOptions{KeyFile: notAKey}, "can't parse the key"},
+		{"missing key", AuthOptions{KeyFile: filepath.Join(t.TempDir(), "missing")}, "can't read the key"},
+		{"nothing", AuthOptions{}, "no key or password to log in with"},
+	}
+	for _, test := range tests {
+		test.options.InsecureIgnoreHostKey = true
+		_, err := NewAuth(test.options)
+		if test.err == "" && err != nil {
+			t.Errorf("%s: expected no error, got %s", test.name, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: expected %q, got %v", test.name, test.err, err)
+		}
+	}
+
+	_, err := NewAuth(AuthOptions{Password: "secret", KnownHostsFile: filepath.Join(t.TempDir(), "missing")})
+	if err == nil || !strings.Contains(err.Error(), "can't read the known hosts") {
+		t.Errorf("expected an error for a missing known_hosts file, got %v", err)
+	}
+}
+
+func TestDialKnownHosts(t *testing.T) {
+	keyFile, public := writeKey(t, "")
+	target, hostKey := serveLogins(t, public)
+	_, otherKey := writeKey(t, "")
+
+	tests := []struct {
+		name string
+		// hostKey is the key of the target in known_hosts, none if nil
+		hostKey ssh.PublicKey
+		want    []ssh.PublicKey
+	}{
+		{"known host", hostKey, nil},
+		{"changed host key", otherKey, []ssh.PublicKey{otherKey}},
+		{"unknown host", nil, []ssh.PublicKey{}},
+	}
+	for _, test := range tests {
+		knownHostsFile := filepath.Join(t.TempDir(), "known_hosts")
+		os.WriteFile(knownHostsFile, nil, 0o600)
+		if test.hostKey != nil {
+			knownHostsFile = writeKnownHosts(t, target, test.hostKey)
+		}
+		auth, err := NewAuth(AuthOptions{KeyFile: keyFile, KnownHostsFile: knownHostsFile, Timeout: time.Second})
+		if err != nil {
+			t.Fatalf("%s: NewAuth error: %s", test.name, err)
+		}
+		client, err := auth.Dial(context.Background(), target)
+		if test.want == nil {
+			if err != nil {
+				t.Errorf("%s: expected to log in, got %s", test.name, err)
+			} else {
+				client.Close()
+			}
+			continue
+		}
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) || len(keyErr.Want) != len(test.want) {
+			t.Errorf("%s: expected a host key error with %d known keys, got %v", test.name, len(test.want), err)
+		}
+	}
+}
+
+func TestDialLogin(t *testing.T) {
+	_, authorized := writeKey(t, "")
+	target, _ := serveLogins(t, authorized)
+	otherKey, _ := writeKey(t, "")
+
+	// the key is tried first, then the password
+	tests := []struct {
+		name    string
+		options AuthOptions
+		ok      bool
+	}{
+		{"unknown key", AuthOptions{KeyFile: otherKey}, false},
+		{"unknown key and password", AuthOptions{KeyFile: otherKey, Password: "secret"}, true},
+		{"wrong password", AuthOptions{Password: "wrong"}, false},
+	}
+	for _, test := range tests {
+		test.options.InsecureIgnoreHostKey = true
+		auth, err := NewAuth(test.options)
+		if err != nil {
+			t.Fatalf("%s: NewAuth error: %s", test.name, err)
+		}
+		client, err := auth.Dial(context.Background(), target)
+		if test.ok && err != nil {
+			t.Errorf("%s: expected to log in, got %s", test.name, err)
+		}
+		if !test.ok && (err == nil || !strings.Contains(err.Error(), "ssh handshake failed")) {
+			t.Errorf("%s: expected the login to fail, got %v", test.name, err)
+		}
+		if client != nil {
+			client.Close()
+		}
+	}
+}
+
+func TestDialCanceled(t *testing.T) {
+	// the server accepts the connection but never answers the handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(io.Discard, conn)
+		}
+	}()
+	target := Target{User: "demo", Host: "127.0.0.1", Port: listener.Addr().(*net.TCPAddr).Port}
+
+	auth, err := NewAuth(AuthOptions{Password: "secret", InsecureIgnoreHostKey: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := auth.Dial(ctx, target); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the handshake to be given up, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected Dial to return when ctx is done, took %s", elapsed)
+	}
+}
@@ -0,0 +1,38 @@
+package sshclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Dial connects to the target and logs in. ssh.Dial has no context, so the tcp
+// connection is made with one and the handshake is given up when ctx is done.
+func (a *Auth) Dial(ctx context.Context, target Target) (*ssh.Client, error) {
+	config := a.clientConfig(target.User)
+	dialer := net.Dialer{Timeout: a.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", target.Address())
+	if err != nil {
+		return nil, fmt.Errorf("can't connect: %w", err)
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	if a.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(a.timeout))
+	}
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, target.Address(), config)
+	if err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("ssh handshake failed: %w", err)
+	}
+	// the deadline was for the handshake only, the session may take longer
+	conn.SetDeadline(time.Time{})
+	return ssh.NewClient(sshConn, channels, requests), nil
+}
@@ -0,0 +1,44 @@
+package sshclient
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Target is a host to connect to
+type Target struct {
+	User string
+	Host string
+	Port int
+}
+
+// Address returns host:port, as used to dial and to look up the host key
+func (t Target) Address() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+func (t Target) String() string {
+	return t.User + "@" + t.Address()
+}
+
+// ParseTarget parses [user@]host[:port], using the default user and port when they're left out
+func ParseTarget(s, defaultUser string, defaultPort int) (Target, error) {
+	target := Target{User: defaultUser, Host: s, Port: defaultPort}
+	if user, host, ok := strings.Cut(s, "@"); ok {
+		target.User, target.Host = user, host
+	}
+	if host, port, err := net.SplitHostPort(target.Host); err == nil {
+		target.Host = host
+		if target.Port, err = strconv.Atoi(port); err != nil || target.Port < 1 || target.Port > 65535 {
+			return target, fmt.Errorf("invalid port in %q", s)
+		}
+	}
+	// an IPv6 address without a port comes in brackets
+	target.Host = strings.TrimSuffix(strings.TrimPrefix(target.Host, "["), "]")
+	if target.Host == "" || target.User == "" {
+		return target, fmt.Errorf("%q has no host or user", s)
+	}
+	return target, nil
+}
@@ -0,0 +1,145 @@
+package sshkeys
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// AuthorizedKey is an entry of an authorized_keys file
+type AuthorizedKey struct {
+	Key     ssh.PublicKey
+	Comment string
+	Options []string
+	// Line is the entry as it is in the file, without the newline
+	Line []byte
+}
+
+// Fingerprint returns the SHA256 fingerprint of the key, as printed by ssh-keygen -l
+func (k AuthorizedKey) Fingerprint() string {
+	return ssh.FingerprintSHA256(k.Key)
+}
+
+// ParseAuthorizedKey parses one authorized_keys line, like the content of a .pub file
+func ParseAuthorizedKey(line []byte) (AuthorizedKey, error) {
+	key, comment, options, _, err := ssh.ParseAuthorizedKey(line)
+	if err != nil {
+		return AuthorizedKey{}, err
+	}
+	return AuthorizedKey{Key: key, Comment: comment, Options: options, Line: bytes.TrimSpace(line)}, nil
+}
+
+// ReadAuthorizedKeys returns the keys in the file. Comments, empty lines and lines
+// that aren't keys are skipped. A file that doesn't exist has no keys.
+func ReadAuthorizedKeys(file string) ([]AuthorizedKey, error) {
+	lines, err := readLines(file)
+	if err != nil {
+		return nil, err
+	}
+	var keys []AuthorizedKey
+	for _, line := range lines {
+		if key, err := ParseAuthorizedKey(line); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// AddAuthorizedKey appends the key to the file, unless the file has that key already,
+// with other options or another comment. It reports whether the key was added. The
+// file and its directory are created with the permissions sshd asks for.
+func AddAuthorizedKey(file string, key AuthorizedKey) (bool, error) {
+	lines, err := readLines(file)
+	if err != nil {
+		return false, err
+	}
+	for _, line := range lines {
+		if existing, err := ParseAuthorizedKey(line); err == nil && sameKey(existing.Key, key.Key) {
+			return false, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return false, err
+	}
+	return true, writeLines(file, append(lines, key.Line))
+}
+
+// RemoveAuthorizedKeys removes the keys for which match returns true, and keeps the
+// other lines as they are. It returns the number of removed keys, and leaves the file
+// untouched when there's none.
+func RemoveAuthorizedKeys(file string, match func(AuthorizedKey) bool) (int, error) {
+	lines, err := readLines(file)
+	if err != nil {
+		return 0, err
+	}
+	var kept [][]byte
+	for _, line := range lines {
+		if key, err := ParseAuthorizedKey(line); err == nil && match(key) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	removed := len(lines) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, writeLines(file, kept)
+}
+
+// MatchKey matches the entries with the same public key, whatever their options and comment
+func MatchKey(key ssh.PublicKey) func(AuthorizedKey) bool {
+	return func(k AuthorizedKey) bool { return sameKey(k.Key, key) }
+}
+
+// MatchComment matches the entries with the comment, like user@host
+func MatchComment(comment string) func(AuthorizedKey) bool {
+	return func(k AuthorizedKey) bool { return k.Comment == comment }
+}
+
+func sameKey(a, b ssh.PublicKey) bool {
+	return bytes.Equal(a.Marshal(), b.Marshal())
+}
+
+func readLines(file string) ([][]byte, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return bytes.Split(data, []byte("\n")), nil
+}
+
+// writeLines replaces the file through a temporary file in the same directory, so
+// sshd never reads a half written file
+func writeLines(file string, lines [][]byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".authorized_keys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	data := append(bytes.Join(lines, []byte("\n")), '\n')
+	if len(lines) == 0 {
+		data = nil
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,111 @@
+package sshkeys
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func generateKey(t *testing.T, comment string) AuthorizedKey {
+	t.Helper()
+	pair, err := Generate(ED25519, 0, comment, "")
+	if err != nil {
+		t.Fatalf("Generate error: %s", err)
+	}
+	key, err := ParseAuthorizedKey(pair.Public)
+	if err != nil {
+		t.Fatalf("ParseAuthorizedKey error: %s", err)
+	}
+	return key
+}
+
+func TestAddAuthorizedKeyIdempotent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".ssh", "authorized_keys")
+	alice := generateKey(t, "alice@demo")
+
+	for i, want := range []bool{true, false} {
+		added, err := AddAuthorizedKey(file, alice)
+		if err != nil {
+			t.Fatalf("AddAuthorizedKey error: %s", err)
+		}
+		if added != want {
+			t.Errorf("call %d: expected added %v, got %v", i+1, want, added)
+		}
+	}
+
+	keys, err := ReadAuthorizedKeys(file)
+	if err != nil {
+		t.Fatalf("ReadAuthorizedKeys error: %s", err)
+	}
+	if len(keys) != 1 || keys[0].Comment != "alice@demo" {
+		t.Errorf("expected the key of alice once, got %v", keys)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("Stat error: %s", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %s", info.Mode().Perm())
+	}
+}
+
+func TestAddAuthorizedKeyWithOptions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "authorized_keys")
+	alice := generateKey(t, "alice@demo")
+	if err := os.WriteFile(file, []byte(`from="10.0.0.1" `+string(alice.Line)+" old comment\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := AddAuthorizedKey(file, alice)
+	if err != nil {
+		t.Fatalf("AddAuthorizedKey error: %s", err)
+	}
+	if added {
+		t.Errorf("expected the key with options and another comment to count as authorized")
+	}
+}
+
+func TestRemoveAuthorizedKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "authorized_keys")
+	alice := generateKey(t, "alice@demo")
+	bob := generateKey(t, "bob@demo")
+	content := "# managed by ssh-keys\n" + string(alice.Line) + "\nnot a key\n" + string(bob.Line) + "\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := RemoveAuthorizedKeys(file, MatchKey(alice.Key))
+	if err != nil {
+		t.Fatalf("RemoveAuthorizedKeys error: %s", err)
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 removed key, got %d", removed)
+	}
+	removed, err = RemoveAuthorizedKeys(file, MatchKey(alice.Key))
+	if err != nil || removed != 0 {
+		t.Errorf("expected nothing to remove the second time, got %d (%v)", removed, err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# managed by ssh-keys\nnot a key\n" + string(bob.Line) + "\n"
+	if string(data) != want {
+		t.Errorf("expected the other lines to be kept:\n%s\ngot:\n%s", want, data)
+	}
+
+	if removed, _ := RemoveAuthorizedKeys(file, MatchComment("bob@demo")); removed != 1 {
+		t.Errorf("expected to remove the key of bob by comment, got %d", removed)
+	}
+	if data, _ := os.ReadFile(file); strings.Contains(string(data), "bob@demo") {
+		t.Errorf("expected the key of bob to be gone:\n%s", data)
+	}
+}
+
+func TestGenerateRejectsSmallRSAKeys(t *testing.T) {
+	if _, err := Generate(RSA, 1024, "", ""); err == nil {
+		t.Errorf("expected an error for a 1024 bit RSA key")
+	}
+}
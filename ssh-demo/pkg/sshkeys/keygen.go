@@ -0,0 +1,125 @@
+// Package sshkeys generates SSH key pairs and manages authorized_keys files
+package sshkeys
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// the supported key types
+const (
+	ED25519 = "ed25519"
+	RSA     = "rsa"
+)
+
+// ErrKeyExists is returned by WriteKeyPair when it would overwrite a key
+var ErrKeyExists = errors.New("key file exists already")
+
+// KeyPair is a private key in the OpenSSH format, and its public key as an
+// authorized_keys line
+type KeyPair struct {
+	Private     []byte
+	Public      []byte
+	Fingerprint string
+}
+
+// Generate creates a key pair of keyType. bits is the size of an RSA key, ed25519
+// keys have a fixed size. The comment is added to both keys, and a passphrase
+// encrypts the private key.
+func Generate(keyType string, bits int, comment, passphrase string) (KeyPair, error) {
+	var private crypto.PrivateKey
+	var public crypto.PublicKey
+	switch keyType {
+	case ED25519:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return KeyPair{}, err
+		}
+		private, public = priv, pub
+	case RSA:
+		if bits < 2048 {
+			return KeyPair{}, fmt.Errorf("RSA keys need at least 2048 bits, not %d", bits)
+		}
+		priv, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return KeyPair{}, err
+		}
+		private, public = priv, &priv.PublicKey
+	default:
+		return KeyPair{}, fmt.Errorf("unknown key type %q, use %s or %s", keyType, ED25519, RSA)
+	}
+
+	var block *pem.Block
+	var err error
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(private, comment, []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(private, comment)
+	}
+	if err != nil {
+		return KeyPair{}, err
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return KeyPair{}, err
+	}
+
+	return KeyPair{
+		Private:     pem.EncodeToMemory(block),
+		Public:      authorizedKeyLine(sshPublic, comment),
+		Fingerprint: ssh.FingerprintSHA256(sshPublic),
+	}, nil
+}
+
+// authorizedKeyLine formats the key as "type base64 comment\n"
+func authorizedKeyLine(key ssh.PublicKey, comment string) []byte {
+	line := bytes.TrimSuffix(ssh.MarshalAuthorizedKey(key), []byte("\n"))
+	if comment != "" {
+		line = append(line, ' ')
+		line = append(line, comment...)
+	}
+	return append(line, '\n')
+}
+
+// WriteKeyPair writes the private key to path and the public key to path.pub, like
+// ssh-keygen. It doesn't overwrite existing keys unless force is set.
+func WriteKeyPair(path string, pair KeyPair, force bool) error {
+	if !force {
+		for _, name := range []string{path, path + ".pub"} {
+			if _, err := os.Stat(name); err == nil {
+				return fmt.Errorf("%w: %s", ErrKeyExists, name)
+			}
+		}
+	}
+	// the private key is written with mode 0600 before it has any content
+	if err := writeFile(path, pair.Private, 0o600); err != nil {
+		return err
+	}
+	return writeFile(path+".pub", pair.Public, 0o644)
+}
+
+// writeFile writes data to name, setting the mode of an existing file as well
+func writeFile(name string, data []byte, mode os.FileMode) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,140 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// reader counts the bytes of a transfer for the progress line and the bandwidth
+// limit, and stops when ctx is done. Without a name, it prints no progress.
+type reader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiter  *limiter
+	progress *progress
+}
+
+func (c *Client) reader(ctx context.Context, r io.Reader, name string, size int64) io.Reader {
+	reader := &reader{ctx: ctx, r: r, limiter: c.limiter}
+	if c.options.Progress != nil && name != "" {
+		reader.progress = &progress{w: c.options.Progress, name: name, total: size, start: time.Now()}
+	}
+	return reader
+}
+
+func (r *reader) Read(b []byte) (int, error) {
+	n, err := 0, r.ctx.Err()
+	if err == nil {
+		if r.limiter != nil {
+			b = b[:min(len(b), r.limiter.chunk())]
+		}
+		n, err = r.r.Read(b)
+	}
+	if r.limiter != nil && n > 0 {
+		if waitErr := r.limiter.wait(r.ctx, n); waitErr != nil {
+			err = waitErr
+		}
+	}
+	if r.progress != nil {
+		r.progress.add(n, err != nil)
+	}
+	return n, err
+}
+
+// limiter spreads the bytes over time, to rate bytes per second on average. It's
+// shared by the transfers, so the limit holds for all of them together.
+type limiter struct {
+	rate int64
+
+	mu    sync.Mutex
+	start time.Time
+	sent  int64
+}
+
+// chunk is the most bytes to read at once, a tenth of a second of the limit, so the
+// transfer doesn't go in bursts
+func (l *limiter) chunk() int {
+	return int(max(l.rate/10, 1))
+}
+
+// wait sleeps until the n bytes fit in the limit, or ctx is done
+func (l *limiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	if l.start.IsZero() {
+		l.start = time.Now()
+	}
+	l.sent += int64(n)
+	delay := time.Duration(float64(l.sent)/float64(l.rate)*float64(time.Second)) - time.Since(l.start)
+	if delay < -time.Second {
+		// the time between the transfers, like the checksums, doesn't add up to a burst
+		l.start, l.sent = time.Now(), int64(n)
+		delay = time.Duration(float64(n) / float64(l.rate) * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// progress prints the progress of a file on one line, at most every 100ms:
+//
+//	etc/nginx.conf  45% 1.2 MiB/2.6 MiB 850.0 KiB/s
+type progress struct {
+	w           io.Writer
+	name        string
+	done, total int64
+	start       time.Time
+	lastPrint   time.Time
+	ended       bool
+}
+
+func (p *progress) add(n int, end bool) {
+	if p.ended {
+		return
+	}
+	p.done += int64(n)
+	switch {
+	case end:
+		p.ended = true
+		p.print()
+		fmt.Fprintln(p.w)
+	case time.Since(p.lastPrint) >= 100*time.Millisecond:
+		p.print()
+	}
+}
+
+func (p *progress) print() {
+	p.lastPrint = time.Now()
+	percent := int64(100)
+	if p.total > 0 {
+		percent = min(100*p.done/p.total, 100)
+	}
+	speed := float64(p.done) / max(time.Since(p.start).Seconds(), 0.001)
+	fmt.Fprintf(p.w, "\r%s %3d%% %s/%s %s/s", p.name, percent, formatBytes(p.done), formatBytes(p.total), formatBytes(int64(speed)))
+}
+
+// formatBytes formats a size in B, KiB, MiB or GiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exponent := float64(n)/unit, 0
+	for value >= unit && exponent < 2 {
+		value /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMG"[exponent])
+}
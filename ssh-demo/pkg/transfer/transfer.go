@@ -0,0 +1,301 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Options of the transfers
+type Options struct {
+	// Progress receives a progress line for every copied file, nil prints nothing
+	Progress io.Writer
+	// BandwidthLimit is the maximum number of bytes per second of all the transfers
+	// together, 0 is no limit
+	BandwidthLimit int64
+}
+
+// Client copies files to and from a host over sftp
+type Client struct {
+	sftp    *sftp.Client
+	ssh     *ssh.Client
+	options Options
+	limiter *limiter
+}
+
+// SyncStats counts the files of a Sync
+type SyncStats struct {
+	Uploaded  int
+	Unchanged int
+	// Bytes is the size of the uploaded files
+	Bytes int64
+}
+
+// New starts an sftp session on the connection. Closing the Client leaves the
+// connection open.
+func New(client *ssh.Client, options Options) (*Client, error) {
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		return nil, fmt.Errorf("can't start sftp: %w", err)
+	}
+	return newClient(sftpClient, client, options), nil
+}
+
+// newClient is New without ssh connection when ssh is nil, the checksums are then
+// computed by reading the remote files
+func newClient(sftpClient *sftp.Client, sshClient *ssh.Client, options Options) *Client {
+	c := &Client{sftp: sftpClient, ssh: sshClient, options: options}
+	if options.BandwidthLimit > 0 {
+		c.limiter = &limiter{rate: options.BandwidthLimit}
+	}
+	return c
+}
+
+// Close ends the sftp session
+func (c *Client) Close() error {
+	return c.sftp.Close()
+}
+
+// Upload copies the local file to the remote path, with the same permissions. The
+// file is written next to the remote path first and renamed when it's complete, so
+// a service never reads a half written config.
+func (c *Client) Upload(ctx context.Context, local, remote string) error {
+	file, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory, use Sync", local)
+	}
+
+	tmp := path.Join(path.Dir(remote), "."+path.Base(remote)+".tmp")
+	dst, err := c.sftp.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("can't create %s: %w", tmp, err)
+	}
+	_, err = dst.ReadFrom(c.reader(ctx, file, remote, info.Size()))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = c.sftp.Chmod(tmp, info.Mode().Perm())
+	}
+	if err == nil {
+		err = c.rename(tmp, remote)
+	}
+	if err != nil {
+		c.sftp.Remove(tmp)
+		return fmt.Errorf("can't upload %s: %w", local, err)
+	}
+	return nil
+}
+
+// rename replaces newname. Without the posix-rename extension, sftp can't rename
+// over an existing file, so it's removed first.
+func (c *Client) rename(oldname, newname string) error {
+	if err := c.sftp.PosixRename(oldname, newname); err == nil {
+		return nil
+	}
+	if err := c.sftp.Remove(newname); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return c.sftp.Rename(oldname, newname)
+}
+
+// Download copies the remote file to the local path, with the same permissions.
+// Like Upload, the local file is replaced when the download is complete.
+func (c *Client) Download(ctx context.Context, remote, local string) error {
+	file, err := c.sftp.Open(remote)
+	if err != nil {
+		return fmt.Errorf("can't open %s: %w", remote, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", remote)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(local), "."+filepath.Base(local)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, c.reader(ctx, file, remote, info.Size()))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), local)
+	}
+	if err != nil {
+		return fmt.Errorf("can't download %s: %w", remote, err)
+	}
+	return nil
+}
+
+// Sync copies the local directory to the remote directory, recursively. Files with
+// the same size and SHA-256 checksum on both sides are left as they are, so only the
+// changes are uploaded. Remote files that aren't in the local directory are kept,
+// and only regular files are copied: symlinks and devices are skipped.
+func (c *Client) Sync(ctx context.Context, localDir, remoteDir string) (SyncStats, error) {
+	var stats SyncStats
+	err := filepath.WalkDir(localDir, func(local string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, local)
+		if err != nil {
+			return err
+		}
+		remote := path.Join(remoteDir, filepath.ToSlash(rel))
+
+		switch {
+		case entry.IsDir():
+			if err := c.sftp.MkdirAll(remote); err != nil {
+				return fmt.Errorf("can't create %s: %w", remote, err)
+			}
+			return nil
+		case !entry.Type().IsRegular():
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		changed, err := c.changed(ctx, local, remote, info)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			stats.Unchanged++
+			return nil
+		}
+		if err := c.Upload(ctx, local, remote); err != nil {
+			return err
+		}
+		stats.Uploaded++
+		stats.Bytes += info.Size()
+		return nil
+	})
+	return stats, err
+}
+
+// changed reports whether the remote file is missing or has another content than
+// the local file. When only the permissions differ, they're fixed right away.
+func (c *Client) changed(ctx context.Context, local, remote string, info fs.FileInfo) (bool, error) {
+	remoteInfo, err := c.sftp.Stat(remote)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("can't stat %s: %w", remote, err)
+	}
+	if remoteInfo.IsDir() {
+		return false, fmt.Errorf("%s is a directory on the host", remote)
+	}
+	if remoteInfo.Size() != info.Size() {
+		return true, nil
+	}
+
+	localSum, err := localChecksum(local)
+	if err != nil {
+		return false, err
+	}
+	remoteSum, err := c.remoteChecksum(ctx, remote)
+	if err != nil {
+		return false, err
+	}
+	if localSum != remoteSum {
+		return true, nil
+	}
+	if remoteInfo.Mode().Perm() != info.Mode().Perm() {
+		if err := c.sftp.Chmod(remote, info.Mode().Perm()); err != nil {
+			return false, fmt.Errorf("can't chmod %s: %w", remote, err)
+		}
+	}
+	return false, nil
+}
+
+func localChecksum(name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	checksum := sha256.New()
+	if _, err := io.Copy(checksum, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(checksum.Sum(nil)), nil
+}
+
+// remoteChecksum runs sha256sum on the host, so the file doesn't have to be
+// downloaded. Hosts without sha256sum, like Windows, get the file read over sftp.
+func (c *Client) remoteChecksum(ctx context.Context, remote string) (string, error) {
+	if sum, ok := c.sha256sum(remote); ok {
+		return sum, nil
+	}
+	file, err := c.sftp.Open(remote)
+	if err != nil {
+		return "", fmt.Errorf("can't open %s: %w", remote, err)
+	}
+	defer file.Close()
+	checksum := sha256.New()
+	if _, err := io.Copy(checksum, c.reader(ctx, file, "", 0)); err != nil {
+		return "", fmt.Errorf("can't read %s: %w", remote, err)
+	}
+	return hex.EncodeToString(checksum.Sum(nil)), nil
+}
+
+// sha256sum returns the checksum printed by sha256sum, and false when the command
+// failed or printed something else
+func (c *Client) sha256sum(remote string) (string, bool) {
+	if c.ssh == nil {
+		return "", false
+	}
+	session, err := c.ssh.NewSession()
+	if err != nil {
+		return "", false
+	}
+	defer session.Close()
+	output, err := session.Output("sha256sum -- " + shellQuote(remote))
+	if err != nil {
+		return "", false
+	}
+	sum, _, _ := strings.Cut(string(output), " ")
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+		return "", false
+	}
+	return sum, true
+}
+
+// shellQuote quotes s for a POSIX shell, which runs the commands of the session
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
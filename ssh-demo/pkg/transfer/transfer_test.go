@@ -0,0 +1,127 @@
+package transfer
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// newTestClient connects to an sftp server in the same process, on the local files
+func newTestClient(t *testing.T, options Options) *Client {
+	t.Helper()
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	server, err := sftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverReader, serverWriter})
+	if err != nil {
+		t.Fatalf("NewServer error: %s", err)
+	}
+	go server.Serve()
+
+	sftpClient, err := sftp.NewClientPipe(clientReader, clientWriter)
+	if err != nil {
+		t.Fatalf("NewClientPipe error: %s", err)
+	}
+	client := newClient(sftpClient, nil, options)
+	t.Cleanup(func() {
+		// closing the server ends the pipe the client reads from
+		server.Close()
+		client.Close()
+	})
+	return client
+}
+
+func writeFile(t *testing.T, name, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	// WriteFile leaves the mode of an existing file and applies the umask
+	if err := os.Chmod(name, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSync(t *testing.T) {
+	client := newTestClient(t, Options{})
+	local, remote := t.TempDir(), filepath.Join(t.TempDir(), "etc")
+	writeFile(t, filepath.Join(local, "app.yaml"), "port: 8080\n", 0o644)
+	writeFile(t, filepath.Join(local, "nginx", "site.conf"), "listen 80;\n", 0o600)
+
+	steps := []struct {
+		name   string
+		change func()
+		want   SyncStats
+	}{
+		{"first sync", func() {}, SyncStats{Uploaded: 2, Bytes: 22}},
+		{"no changes", func() {}, SyncStats{Unchanged: 2}},
+		// the same size, only the checksum tells the files apart
+		{"same size", func() { writeFile(t, filepath.Join(local, "app.yaml"), "port: 9090\n", 0o644) }, SyncStats{Uploaded: 1, Unchanged: 1, Bytes: 11}},
+	}
+	for _, step := range steps {
+		step.change()
+		stats, err := client.Sync(context.Background(), local, remote)
+		if err != nil {
+			t.Fatalf("%s: Sync error: %s", step.name, err)
+		}
+		if stats != step.want {
+			t.Errorf("%s: expected %+v, got %+v", step.name, step.want, stats)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(remote, "app.yaml"))
+	if err != nil || string(data) != "port: 9090\n" {
+		t.Errorf("expected the changed app.yaml on the host, got %q (%v)", data, err)
+	}
+	info, err := os.Stat(filepath.Join(remote, "nginx", "site.conf"))
+	if err != nil {
+		t.Fatalf("Stat error: %s", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %s", info.Mode().Perm())
+	}
+}
+
+func TestDownload(t *testing.T) {
+	client := newTestClient(t, Options{})
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "remote.txt"), "hello\n", 0o640)
+	writeFile(t, filepath.Join(dir, "local.txt"), "old content\n", 0o644)
+
+	if err := client.Download(context.Background(), filepath.Join(dir, "remote.txt"), filepath.Join(dir, "local.txt")); err != nil {
+		t.Fatalf("Download error: %s", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "local.txt"))
+	if err != nil || string(data) != "hello\n" {
+		t.Errorf("expected the remote content, got %q (%v)", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("expected no temporary files to be left, got %d files", len(entries))
+	}
+}
+
+func TestBandwidthLimit(t *testing.T) {
+	client := newTestClient(t, Options{BandwidthLimit: 64 * 1024})
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "big"), string(make([]byte, 32*1024)), 0o644)
+
+	start := time.Now()
+	if err := client.Upload(context.Background(), filepath.Join(dir, "big"), filepath.Join(dir, "copy")); err != nil {
+		t.Fatalf("Upload error: %s", err)
+	}
+	// 32 KiB at 64 KiB/s
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected the upload to take about 500ms, took %s", elapsed)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"ssh-demo/pkg/sshclient"
+)
+
+// Result is the outcome of the command on one host. Err is set when the command
+// didn't run to the end: the connection or the login failed, or it timed out.
+type Result struct {
+	Target   sshclient.Target
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+	Duration time.Duration
+	Err      error
+}
+
+// Runner runs a command over ssh
+type Runner struct {
+	Auth *sshclient.Auth
+	// Concurrency is the number of hosts connected at the same time
+	Concurrency int
+	// Timeout is the time the command may take on a host, including the login
+	Timeout time.Duration
+}
+
+// RunAll runs the command on every target, Concurrency at a time, and returns the
+// results in the order of the targets
+func (r Runner) RunAll(ctx context.Context, targets []sshclient.Target, command string) []Result {
+	results := make([]Result, len(targets))
+	concurrency := r.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target sshclient.Target) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = r.Run(ctx, target, command)
+		}(i, target)
+	}
+	wg.Wait()
+	return results
+}
+
+// Run connects to the target and runs the command, until it exits or ctx is done
+func (r Runner) Run(ctx context.Context, target sshclient.Target, command string) (result Result) {
+	start := time.Now()
+	result = Result{Target: target, ExitCode: -1}
+	defer func() { result.Duration = time.Since(start) }()
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	client, err := r.Auth.Dial(ctx, target)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		result.Err = fmt.Errorf("can't open a session: %w", err)
+		return result
+	}
+	defer session.Close()
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(command)
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// closing the connection ends the command on the host as well, and Run
+		// returns once the output is copied, so the buffers can be read
+		client.Close()
+		<-done
+		err = ctx.Err()
+	}
+	result.Stdout, result.Stderr = stdout.Bytes(), stderr.Bytes()
+
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		result.ExitCode = 0
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitStatus()
+	default:
+		result.Err = fmt.Errorf("command didn't finish: %w", err)
+	}
+	return result
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"ssh-demo/pkg/sshclient"
+)
+
+// testServer is an ssh server in the same process. It logs in demo with the
+// password secret, and runs three commands: echo prints its arguments, exit
+// exits with its argument and sleep blocks until the connection is closed.
+type testServer struct {
+	target sshclient.Target
+	// sleeping receives when sleep started, hungUp when its connection was closed
+	sleeping chan struct{}
+	hungUp   chan struct{}
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() != "demo" || string(password) != "secret" {
+				return nil, errors.New("access denied")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	s := &testServer{
+		target:   sshclient.Target{User: "demo", Host: "127.0.0.1", Port: listener.Addr().(*net.TCPAddr).Port},
+		sleeping: make(chan struct{}, 1),
+		hungUp:   make(chan struct{}, 1),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+	sshConn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	defer sshConn.Close()
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(sshConn, channel, requests)
+	}
+}
+
+// session runs the command of the exec request, and sends its exit status
+func (s *testServer) session(conn ssh.Conn, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	for request := range requests {
+		if request.Type != "exec" {
+			request.Reply(false, nil)
+			continue
+		}
+		var exec struct{ Command string }
+		if err := ssh.Unmarshal(request.Payload, &exec); err != nil {
+			request.Reply(false, nil)
+			return
+		}
+		request.Reply(true, nil)
+
+		name, args, _ := strings.Cut(exec.Command, " ")
+		status := 0
+		switch name {
+		case "echo":
+			fmt.Fprintln(channel, args)
+		case "exit":
+			fmt.Fprintf(channel.Stderr(), "exiting with %s\n", args)
+			status, _ = strconv.Atoi(args)
+		case "sleep":
+			fmt.Fprintln(channel, "sleeping")
+			s.sleeping <- struct{}{}
+			conn.Wait()
+			s.hungUp <- struct{}{}
+			return
+		default:
+			fmt.Fprintf(channel.Stderr(), "%s: command not found\n", name)
+			status = 127
+		}
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+		return
+	}
+}
+
+func newTestRunner(t *testing.T, timeout time.Duration) Runner {
+	t.Helper()
+	auth, err := sshclient.NewAuth(sshclient.AuthOptions{Password: "secret", InsecureIgnoreHostKey: true, Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewAuth error: %s", err)
+	}
+	return Runner{Auth: auth, Concurrency: 2, Timeout: timeout}
+}
+
+// waitHungUp fails the test unless the server saw the connection close
+func waitHungUp(t *testing.T, server *testServer) {
+	t.Helper()
+	select {
+	case <-server.hungUp:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the connection to be closed on the host")
+	}
+}
+
+func TestRun(t *testing.T) {
+	server := newTestServer(t)
+	runner := newTestRunner(t, 5*time.Second)
+
+	tests := []struct {
+		command  string
+		exitCode int
+		stdout   string
+		stderr   string
+	}{
+		{"echo hello", 0, "hello\n", ""},
+		{"exit 3", 3, "", "exiting with 3\n"},
+		{"uptime", 127, "", "uptime: command not found\n"},
+	}
+	for _, test := range tests {
+		result := runner.Run(context.Background(), server.target, test.command)
+		if result.Err != nil {
+			t.Errorf("%s: expected no error, got %s", test.command, result.Err)
+		}
+		if result.ExitCode != test.exitCode || string(result.Stdout) != test.stdout || string(result.Stderr) != test.stderr {
+			t.Errorf("%s: expected exit code %d, %q and %q, got %d, %q and %q", test.command, test.exitCode, test.stdout, test.stderr, result.ExitCode, result.Stdout, result.Stderr)
+		}
+		if result.Target != server.target || result.Duration <= 0 {
+			t.Errorf("%s: expected the target and the duration, got %s and %s", test.command, result.Target, result.Duration)
+		}
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	server := newTestServer(t)
+	runner := newTestRunner(t, 200*time.Millisecond)
+
+	result := runner.Run(context.Background(), server.target, "sleep")
+	if !errors.Is(result.Err, context.DeadlineExceeded) || result.ExitCode != -1 {
+		t.Errorf("expected a timeout without an exit code, got %d, %v", result.ExitCode, result.Err)
+	}
+	// the output before the timeout is kept
+	if string(result.Stdout) != "sleeping\n" {
+		t.Errorf("expected the output of the command, got %q", result.Stdout)
+	}
+	waitHungUp(t, server)
+}
+
+func TestRunCancel(t *testing.T) {
+	server := newTestServer(t)
+	runner := newTestRunner(t, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-server.sleeping
+		cancel()
+	}()
+	result := runner.Run(ctx, server.target, "sleep")
+	if !errors.Is(result.Err, context.Canceled) || result.ExitCode != -1 {
+		t.Errorf("expected the command to be canceled, got %d, %v", result.ExitCode, result.Err)
+	}
+	waitHungUp(t, server)
+}
+
+func TestRunAll(t *testing.T) {
+	server := newTestServer(t)
+	runner := newTestRunner(t, 5*time.Second)
+
+	// nothing listens on the port of a closed listener
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := sshclient.Target{User: "demo", Host: "127.0.0.1", Port: listener.Addr().(*net.TCPAddr).Port}
+	listener.Close()
+	wrongUser := server.target
+	wrongUser.User = "root"
+
+	targets := []sshclient.Target{server.target, closed, wrongUser, server.target}
+	results := runner.RunAll(context.Background(), targets, "echo hello")
+	if len(results) != len(targets) {
+		t.Fatalf("expected %d results, got %d", len(targets), len(results))
+	}
+	for i, result := range results {
+		if result.Target != targets[i] {
+			t.Errorf("result %d: expected %s, got %s", i, targets[i], result.Target)
+		}
+	}
+	for _, i := range []int{0, 3} {
+		if results[i].Err != nil || results[i].ExitCode != 0 || string(results[i].Stdout) != "hello\n" {
+			t.Errorf("result %d: expected hello, got %d, %q, %v", i, results[i].ExitCode, results[i].Stdout, results[i].Err)
+		}
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "can't connect") {
+		t.Errorf("expected a connection error, got %v", results[1].Err)
+	}
+	if results[2].Err == nil || !strings.Contains(results[2].Err.Error(), "ssh handshake failed") {
+		t.Errorf("expected a login error, got %v", results[2].Err)
+	}
+
+	var out bytes.Buffer
+	if printResults(&out, results) {
+		t.Error("expected the failed hosts to be reported")
+	}
+	if expected := "== " + server.target.String() + ": exit code 0 ("; !strings.HasPrefix(out.String(), expected) {
+		t.Errorf("expected the output to start with %q, got\n%s", expected, out.String())
+	}
+	out.Reset()
+	if !printResults(&out, results[:1]) {
+		t.Errorf("expected success, got\n%s", out.String())
+	}
+}
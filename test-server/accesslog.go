@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"logging"
+)
+
+// the formats of the access log
+const (
+	accessLogCommon   = "common"
+	accessLogCombined = "combined"
+	accessLogJSON     = "json"
+	accessLogNone     = "none"
+)
+
+// AccessLogEntry is a line of the access log in the json format
+type AccessLogEntry struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id"`
+	RemoteAddr string    `json:"remote_addr"`
+	Method     string    `json:"method"`
+	URI        string    `json:"uri"`
+	Proto      string    `json:"proto"`
+	Status     int       `json:"status"`
+	Bytes      int       `json:"bytes"`
+	DurationMS float64   `json:"duration_ms"`
+	Referer    string    `json:"referer,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+}
+
+// accessLog writes a line for every finished request, in the common or combined log
+// format of Apache and nginx followed by the request id and the duration, or as JSON.
+// The requests handled by one of the skipped mux patterns aren't logged.
+type accessLog struct {
+	mu     sync.Mutex
+	out    io.Writer
+	format string
+	mux    *http.ServeMux
+	skip   map[string]bool
+}
+
+func newAccessLog(out io.Writer, format string, mux *http.ServeMux, skip []string) *accessLog {
+	a := &accessLog{out: out, format: format, mux: mux, skip: make(map[string]bool)}
+	for _, pattern := range skip {
+		a.skip[pattern] = true
+	}
+	return a
+}
+
+// handler sets the X-Request-ID of every request, generating one if the client didn't
+// send it, and logs the request once it's done. The id is returned in the response
+// headers, so the client can match its logs with the access log, and is in the
+// context of the request for the logs of the handlers.
+func (a *accessLog) handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID = logging.NewRequestID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+		r = r.WithContext(logging.WithRequestID(r.Context(), requestID))
+
+		if _, pattern := a.mux.Handler(r); a.format == accessLogNone || a.skip[pattern] {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			err := recover()
+			if err != nil {
+				// a dropped connection of the chaos handler has no status
+				status = 0
+			}
+			a.write(AccessLogEntry{
+				Time:       start,
+				RequestID:  requestID,
+				RemoteAddr: r.RemoteAddr,
+				Method:     r.Method,
+				URI:        r.URL.RequestURI(),
+				Proto:      r.Proto,
+				Status:     status,
+				Bytes:      recorder.bytes,
+				DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+				Referer:    r.Referer(),
+				UserAgent:  r.UserAgent(),
+			})
+			if err != nil {
+				panic(err)
+			}
+		}()
+		h.ServeHTTP(recorder, r)
+	})
+}
+
+// write formats the entry and writes it in one go, so concurrent lines don't mix
+func (a *accessLog) write(entry AccessLogEntry) {
+	var line string
+	switch a.format {
+	case accessLogJSON:
+		out, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		line = string(out) + "\n"
+	default:
+		host, _, err := net.SplitHostPort(entry.RemoteAddr)
+		if err != nil {
+			host = entry.RemoteAddr
+		}
+		size := "-"
+		if entry.Bytes > 0 {
+			size = fmt.Sprint(entry.Bytes)
+		}
+		// host ident authuser [time] "request" status bytes
+		line = fmt.Sprintf("%s - - [%s] %q %d %s", host, entry.Time.Format("02/Jan/2006:15:04:05 -0700"),
+			entry.Method+" "+entry.URI+" "+entry.Proto, entry.Status, size)
+		if a.format == accessLogCombined {
+			line += fmt.Sprintf(" %q %q", orDash(entry.Referer), orDash(entry.UserAgent))
+		}
+		line += fmt.Sprintf(" %s %.3fms\n", entry.RequestID, entry.DurationMS)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	io.WriteString(a.out, line)
+}
+
+// orDash returns the value, or "-" for an empty value as in the log formats of Apache
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// adminAuth protects the /admin endpoints with the -admin-token, sent as a bearer
+// token. They change the behavior of the server for every client, so they don't
+// depend on -password: without an admin token, they're disabled.
+func adminAuth(token string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, "the admin API is disabled, start the test-server with -admin-token")
+			return
+		}
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="test-server admin"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, "missing or invalid admin token")
+			return
+		}
+		next(w, r)
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		status        int
+	}{
+		{name: "disabled", token: "", authorization: "Bearer ", status: http.StatusUnauthorized},
+		{name: "missing", token: "secret", status: http.StatusUnauthorized},
+		{name: "wrong", token: "secret", authorization: "Bearer wrong", status: http.StatusUnauthorized},
+		{name: "not bearer", token: "secret", authorization: "Basic secret", status: http.StatusUnauthorized},
+		{name: "valid", token: "secret", authorization: "Bearer secret", status: http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := adminAuth(test.token, func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "admin")
+			})
+			req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != test.status {
+				t.Errorf("Expected status %d, got %d", test.status, w.Code)
+			}
+		})
+	}
+}
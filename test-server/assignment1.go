@@ -1,31 +1,50 @@
 package main
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"math/rand"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
+const assignment1PageSize = 4
+
 type assignment1 struct {
-	Page         string             `json:"page"`
-	Words        []string           `json:"words"`
-	Percentages  map[string]float64 `json:"percentages"`
-	Special      []*string          `json:"special"`
-	ExtraSpecial []any              `json:"extraSpecial"`
+	Page         string             `json:"page" yaml:"page"`
+	Words        []string           `json:"words" yaml:"words"`
+	Percentages  map[string]float64 `json:"percentages" yaml:"percentages"`
+	Special      []*string          `json:"special" yaml:"special"`
+	ExtraSpecial []any              `json:"extraSpecial" yaml:"extraSpecial"`
+	Pagination   *pagination        `json:"pagination,omitempty" yaml:"pagination,omitempty"`
+}
+
+type pagination struct {
+	Page       int    `json:"page" yaml:"page" xml:"page,attr"`
+	TotalPages int    `json:"totalPages" yaml:"totalPages" xml:"totalPages,attr"`
+	NextCursor string `json:"nextCursor,omitempty" yaml:"nextCursor,omitempty" xml:"nextCursor,attr,omitempty"`
 }
 
+var assignment1Words = []string{"one", "two", "three", "four", "five", "six", "seven", "eigth", "nine", "ten"}
+var assignment1Numbers = []float64{0.33, 0.66, 0.1, 0, 1, 0.99, 0.88, 0.5, 0.1, 0.2}
+
 func (ct *WordsHandler) assignment1(w http.ResponseWriter, r *http.Request) {
 	one := "one"
 	two := "two"
-	words := []string{"one", "two", "three", "four", "five", "six", "seven", "eigth", "nine", "ten"}
-	numbers := []float64{0.33, 0.66, 0.1, 0, 1, 0.99, 0.88, 0.5, 0.1, 0.2}
+	config := ct.runtime.load()
+	words := config.Words
+	numbers := config.Percentages
 	rand.Seed(time.Now().UnixNano())
 	percentages := make(map[string]float64)
 	wordsRand := make([]string, 5)
 	for i := 0; i < 5; i++ {
-		randomInt := rand.Intn(9)
+		randomInt := rand.Intn(len(words))
 		wordsRand[i] = words[randomInt]
 		percentages[words[randomInt]] = numbers[randomInt]
 	}
@@ -36,10 +55,85 @@ func (ct *WordsHandler) assignment1(w http.ResponseWriter, r *http.Request) {
 		Special:      []*string{&one, &two, nil},
 		ExtraSpecial: []any{1, 2, "3"},
 	}
-	out, err := json.Marshal(wordsOutput)
+	writeAssignment1(w, r, wordsOutput)
+}
+
+// assignment1Pages serves the assignment1 words in pages of assignment1PageSize.
+// The page is selected with ?page=N (starting at 1) or with the nextCursor of the previous page.
+func (ct *WordsHandler) assignment1Pages(w http.ResponseWriter, r *http.Request) {
+	config := ct.runtime.load()
+	totalPages := (len(config.Words) + assignment1PageSize - 1) / assignment1PageSize
+	page := 1
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err == nil {
+			page, err = strconv.Atoi(strings.TrimPrefix(string(decoded), "page:"))
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "invalid cursor")
+			return
+		}
+	} else if pageParam := r.URL.Query().Get("page"); pageParam != "" {
+		var err error
+		if page, err = strconv.Atoi(pageParam); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "invalid page")
+			return
+		}
+	}
+	if page < 1 || page > totalPages {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "page %d not found", page)
+		return
+	}
+
+	start := (page - 1) * assignment1PageSize
+	end := start + assignment1PageSize
+	if end > len(config.Words) {
+		end = len(config.Words)
+	}
+	one := "one"
+	two := "two"
+	output := assignment1{
+		Page:         "assignment1",
+		Words:        config.Words[start:end],
+		Percentages:  make(map[string]float64),
+		Special:      []*string{&one, &two, nil},
+		ExtraSpecial: []any{1, 2, "3"},
+		Pagination: &pagination{
+			Page:       page,
+			TotalPages: totalPages,
+		},
+	}
+	for i := start; i < end; i++ {
+		output.Percentages[config.Words[i]] = config.Percentages[i]
+	}
+	if page < totalPages {
+		output.Pagination.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("page:%d", page+1)))
+	}
+	writeAssignment1(w, r, output)
+}
+
+// writeAssignment1 writes the output in the format negotiated with the Accept header
+func writeAssignment1(w http.ResponseWriter, r *http.Request, wordsOutput assignment1) {
+	var (
+		out []byte
+		err error
+	)
+	format := negotiate(r.Header.Get("Accept"))
+	switch format {
+	case formatYAML:
+		out, err = yaml.Marshal(wordsOutput)
+	case formatXML:
+		out, err = xml.MarshalIndent(wordsOutput.toXML(), "", "  ")
+	default:
+		out, err = json.Marshal(wordsOutput)
+	}
 	if err != nil {
 		fmt.Fprintf(w, "marshal error")
 		return
 	}
+	w.Header().Set("Content-Type", format)
 	fmt.Fprint(w, string(out))
 }
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault configures the faults injected into the responses of an endpoint. Every
+// rate is a fraction between 0 and 1 of the requests.
+type Fault struct {
+	// ErrorRate of the requests are answered with ErrorStatus, 500 by default
+	ErrorRate   float64 `json:"errorRate,omitempty"`
+	ErrorStatus int     `json:"errorStatus,omitempty"`
+	// SpikeRate of the requests are delayed by SpikeLatency
+	SpikeRate    float64  `json:"spikeRate,omitempty"`
+	SpikeLatency duration `json:"spikeLatency,omitempty"`
+	// DropRate of the connections are closed without a response
+	DropRate float64 `json:"dropRate,omitempty"`
+	// TruncateRate of the responses are cut off halfway through the body
+	TruncateRate float64 `json:"truncateRate,omitempty"`
+}
+
+func (f Fault) validate() error {
+	for name, rate := range map[string]float64{"errorRate": f.ErrorRate, "spikeRate": f.SpikeRate, "dropRate": f.DropRate, "truncateRate": f.TruncateRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s %g is not between 0 and 1", name, rate)
+		}
+	}
+	if f.ErrorStatus != 0 && (f.ErrorStatus < 400 || f.ErrorStatus > 599) {
+		return fmt.Errorf("errorStatus %d is not an error status code", f.ErrorStatus)
+	}
+	if f.SpikeLatency < 0 {
+		return fmt.Errorf("spikeLatency can't be negative")
+	}
+	return nil
+}
+
+// duration is a time.Duration written as a string like "2s" in JSON
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"2s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// Chaos holds the faults per endpoint path, changed at runtime with the admin API
+type Chaos struct {
+	mu     sync.RWMutex
+	faults map[string]Fault
+}
+
+func newChaos() *Chaos {
+	return &Chaos{faults: make(map[string]Fault)}
+}
+
+func (c *Chaos) fault(path string) (Fault, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	fault, ok := c.faults[path]
+	return fault, ok
+}
+
+// admin is the admin API of the faults:
+//
+//	curl localhost:8080/admin/chaos
+//	curl -X PUT 'localhost:8080/admin/chaos?path=/words' -d '{"errorRate":0.3,"spikeRate":0.1,"spikeLatency":"2s"}'
+//	curl -X DELETE 'localhost:8080/admin/chaos?path=/words'
+//	curl -X DELETE localhost:8080/admin/chaos
+func (c *Chaos) admin(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if !strings.HasPrefix(path, "/") || isFaultExempt(path) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "path parameter must be an endpoint path like /words, not /admin/, the probes or /metrics")
+			return
+		}
+		var fault Fault
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&fault); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Unmarshal error: %s", err)
+			return
+		}
+		if err := fault.validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Invalid fault: %s", err)
+			return
+		}
+		c.mu.Lock()
+		c.faults[path] = fault
+		c.mu.Unlock()
+	case http.MethodDelete:
+		c.mu.Lock()
+		if path == "" {
+			c.faults = make(map[string]Fault)
+		} else {
+			delete(c.faults, path)
+		}
+		c.mu.Unlock()
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, "Use GET, PUT or DELETE")
+		return
+	}
+
+	// every method replies with the faults that are active now
+	c.mu.RLock()
+	out, err := json.Marshal(c.faults)
+	c.mu.RUnlock()
+	if err != nil {
+		fmt.Fprintf(w, "marshal error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+// chaosHandler injects the faults configured for the path of the request
+func chaosHandler(c *Chaos, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fault, ok := c.fault(r.URL.Path)
+		if !ok || isFaultExempt(r.URL.Path) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		logger := slog.With("path", r.URL.Path)
+		ctx := r.Context()
+
+		if fault.SpikeRate > 0 && rand.Float64() < fault.SpikeRate {
+			logger.InfoContext(ctx, "injected latency spike", "latency", time.Duration(fault.SpikeLatency))
+			select {
+			case <-time.After(time.Duration(fault.SpikeLatency)):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.DropRate > 0 && rand.Float64() < fault.DropRate {
+			logger.InfoContext(ctx, "injected dropped connection")
+			// aborts the response: the server closes the connection without writing anything
+			panic(http.ErrAbortHandler)
+		}
+		if fault.ErrorRate > 0 && rand.Float64() < fault.ErrorRate {
+			status := fault.ErrorStatus
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			logger.InfoContext(ctx, "injected error", "status", status)
+			w.WriteHeader(status)
+			fmt.Fprintf(w, "injected error")
+			return
+		}
+		if fault.TruncateRate > 0 && !isStreaming(r) && rand.Float64() < fault.TruncateRate {
+			logger.InfoContext(ctx, "injected truncated body")
+			truncate(w, r, h)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// streamingPaths are the endpoints streaming their response until the client
+// disconnects
+var streamingPaths = map[string]bool{"/words/stream": true, "/events": true, "/ws/words": true}
+
+// isStreaming reports whether the response is a stream or a websocket. It never
+// ends, so it can't be buffered to be truncated, and the buffer can't be flushed.
+func isStreaming(r *http.Request) bool {
+	return streamingPaths[r.URL.Path] || r.Header.Get("Upgrade") != "" ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// bufferedResponseWriter keeps the response in memory, so its length is known
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header { return b.header }
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// truncate announces the full Content-Length of the response, but sends only half
+// of the body before closing the connection. Streamed responses are never
+// truncated, see isStreaming.
+func truncate(w http.ResponseWriter, r *http.Request, h http.Handler) {
+	buffered := &bufferedResponseWriter{header: w.Header()}
+	h.ServeHTTP(buffered, r)
+	if buffered.status == 0 {
+		buffered.status = http.StatusOK
+	}
+
+	body := buffered.body.Bytes()
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(buffered.status)
+	w.Write(body[:len(body)/2])
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	panic(http.ErrAbortHandler)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newChaosServer serves a body of 100 bytes on every path, with the fault on /words
+func newChaosServer(t *testing.T, fault Fault) *httptest.Server {
+	chaos := newChaos()
+	chaos.faults["/words"] = fault
+	server := httptest.NewServer(chaosHandler(chaos, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("x", 100))
+	})))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChaosHandler(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault Fault
+		path  string
+		// status is the expected status code, 0 when the request fails
+		status  int
+		body    int
+		minTime time.Duration
+	}{
+		{name: "no fault", fault: Fault{ErrorRate: 1}, path: "/occurrence", status: http.StatusOK, body: 100},
+		{name: "zero rates", fault: Fault{ErrorStatus: 503}, path: "/words", status: http.StatusOK, body: 100},
+		{name: "error", fault: Fault{ErrorRate: 1}, path: "/words", status: http.StatusInternalServerError, body: len("injected error")},
+		{name: "error status", fault: Fault{ErrorRate: 1, ErrorStatus: 503}, path: "/words", status: http.StatusServiceUnavailable, body: len("injected error")},
+		{name: "spike", fault: Fault{SpikeRate: 1, SpikeLatency: duration(100 * time.Millisecond)}, path: "/words", status: http.StatusOK, body: 100, minTime: 100 * time.Millisecond},
+		{name: "drop", fault: Fault{DropRate: 1}, path: "/words"},
+		{name: "truncate", fault: Fault{TruncateRate: 1}, path: "/words", status: http.StatusOK, body: 50},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newChaosServer(t, test.fault)
+			start := time.Now()
+			res, err := http.Get(server.URL + test.path)
+			if test.status == 0 {
+				if err == nil {
+					res.Body.Close()
+					t.Fatalf("Expected the connection to be dropped, got status %d", res.StatusCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get error: %s", err)
+			}
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			if res.StatusCode != test.status || len(body) != test.body {
+				t.Errorf("Expected status %d with %d bytes, got %d with %d bytes", test.status, test.body, res.StatusCode, len(body))
+			}
+			if test.fault.TruncateRate > 0 {
+				// the full length is announced, so the client notices the truncation
+				if err != io.ErrUnexpectedEOF || res.ContentLength != 100 {
+					t.Errorf("Expected an unexpected EOF after 50 of 100 bytes, got %v after %d of %d", err, len(body), res.ContentLength)
+				}
+			} else if err != nil {
+				t.Errorf("ReadAll error: %s", err)
+			}
+			if elapsed := time.Since(start); elapsed < test.minTime {
+				t.Errorf("Expected a delay of at least %s, got %s", test.minTime, elapsed)
+			}
+		})
+	}
+}
+
+func TestChaosAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		faults string
+	}{
+		{name: "get", method: http.MethodGet, target: "/admin/chaos", status: http.StatusOK, faults: `{"/occurrence":{"errorRate":0.5}}`},
+		{name: "put", method: http.MethodPut, target: "/admin/chaos?path=/words", body: `{"spikeRate":0.1,"spikeLatency":"2s"}`, status: http.StatusOK,
+			faults: `{"/occurrence":{"errorRate":0.5},"/words":{"spikeRate":0.1,"spikeLatency":"2s"}}`},
+		{name: "delete a path", method: http.MethodDelete, target: "/admin/chaos?path=/occurrence", status: http.StatusOK, faults: `{}`},
+		{name: "delete all", method: http.MethodDelete, target: "/admin/chaos", status: http.StatusOK, faults: `{}`},
+		{name: "admin path", method: http.MethodPut, target: "/admin/chaos?path=/admin/config", body: `{"errorRate":1}`, status: http.StatusBadRequest},
+		{name: "probe", method: http.MethodPut, target: "/admin/chaos?path=/healthz", body: `{"errorRate":1}`, status: http.StatusBadRequest},
+		{name: "no path", method: http.MethodPut, target: "/admin/chaos", body: `{"errorRate":1}`, status: http.StatusBadRequest},
+		{name: "invalid rate", method: http.MethodPut, target: "/admin/chaos?path=/words", body: `{"errorRate":1.5}`, status: http.StatusBadRequest},
+		{name: "invalid status", method: http.MethodPut, target: "/admin/chaos?path=/words", body: `{"errorRate":1,"errorStatus":200}`, status: http.StatusBadRequest},
+		{name: "unknown field", method: http.MethodPut, target: "/admin/chaos?path=/words", body: `{"errorRates":1}`, status: http.StatusBadRequest},
+		{name: "method", method: http.MethodPost, target: "/admin/chaos", status: http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chaos := newChaos()
+			chaos.faults["/occurrence"] = Fault{ErrorRate: 0.5}
+			w := httptest.NewRecorder()
+			chaos.admin(w, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+			if w.Code != test.status {
+				t.Fatalf("Expected status %d, got %d (%s)", test.status, w.Code, w.Body)
+			}
+			if test.faults != "" && w.Body.String() != test.faults {
+				t.Errorf("Expected faults %s, got %s", test.faults, w.Body)
+			}
+		})
+	}
+}
+
+func TestChaosHandlerSkips(t *testing.T) {
+	chaos := newChaos()
+	for _, path := range []string{"/healthz", "/readyz", "/metrics", "/admin/config"} {
+		chaos.faults[path] = Fault{ErrorRate: 1}
+	}
+	chaos.faults["/words/stream"] = Fault{TruncateRate: 1}
+	chaos.faults["/words"] = Fault{TruncateRate: 1}
+	server := httptest.NewServer(chaosHandler(chaos, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a stream of a few lines, flushed one by one
+		for i := 0; i < 3; i++ {
+			io.WriteString(w, "line\n")
+			w.(http.Flusher).Flush()
+		}
+	})))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+	}{
+		{name: "liveness probe", path: "/healthz"},
+		{name: "readiness probe", path: "/readyz"},
+		{name: "metrics", path: "/metrics"},
+		{name: "admin", path: "/admin/config"},
+		{name: "stream", path: "/words/stream"},
+		{name: "server-sent events", path: "/words", accept: "text/event-stream"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+test.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Get error: %s", err)
+			}
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			if err != nil || res.StatusCode != http.StatusOK || string(body) != "line\nline\nline\n" {
+				t.Errorf("Expected the full response, got status %d, %q and %v", res.StatusCode, body, err)
+			}
+		})
+	}
+}
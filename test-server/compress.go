@@ -0,0 +1,61 @@
+package main
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+	writer io.Writer
+}
+
+func (c compressResponseWriter) Write(b []byte) (int, error) {
+	return c.writer.Write(b)
+}
+
+// Flush sends the data compressed so far, so streamed responses arrive while they are written
+func (c compressResponseWriter) Flush() {
+	if flusher, ok := c.writer.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// compressHandler compresses responses with gzip or deflate when the client asks for it
+// in the Accept-Encoding header
+func compressHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var encoding string
+		for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+			name := strings.TrimSpace(strings.Split(part, ";")[0])
+			if name == "gzip" || name == "deflate" {
+				encoding = name
+				break
+			}
+		}
+		// websocket upgrades take the connection over, there's no body to compress
+		if encoding == "" || r.Header.Get("Upgrade") != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		var writer io.WriteCloser
+		if encoding == "gzip" {
+			writer = gzip.NewWriter(w)
+		} else {
+			writer = zlib.NewWriter(w)
+		}
+		defer writer.Close()
+
+		w.Header().Set("Content-Encoding", encoding)
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.Header().Del("Content-Length")
+		h.ServeHTTP(compressResponseWriter{ResponseWriter: w, writer: writer}, r)
+	})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"config"
+	"watcher"
+)
+
+// Config configures the test-server. It's read from the -config YAML file, the
+// TEST_SERVER_ environment variables override the file, and the flags override
+// both:
+//
+//	port: 8080
+//	password: secret
+//	adminToken: change-me
+//	latency: 200ms
+//	jitter: 100ms
+//	failureRate: 0.1
+//	failureStatus: 503
+//	jwksURL: http://localhost:8081/jwks.json
+//	oidcAudience: 1-2-3-4
+//	wsInterval: 500ms
+//	accessLogFormat: json
+//	accessLogSkip: [/healthz, /readyz, /metrics]
+//	tlsCert: server.pem
+//	tlsKey: server-key.pem
+//	mtlsCA: ca.pem
+type Config struct {
+	Port     int    `yaml:"port" env:"PORT" flag:"port" usage:"port to listen on"`
+	Password string `yaml:"password" env:"PASSWORD" flag:"password" usage:"password protect our API"`
+	// AdminToken is the bearer token of the /admin endpoints, which are disabled
+	// without one
+	AdminToken string `yaml:"adminToken" env:"ADMIN_TOKEN" flag:"admin-token" usage:"bearer token of the /admin endpoints, disabled without one"`
+	// JWKSURL is the jwks of an oidc provider, like the oidc-demo, whose tokens
+	// the /protected endpoints accept. OIDCAudience limits them to a client id.
+	JWKSURL      string `yaml:"jwksURL" env:"JWKS_URL" flag:"jwks-url" usage:"jwks url of an oidc provider whose tokens the /protected endpoints accept"`
+	OIDCAudience string `yaml:"oidcAudience" env:"OIDC_AUDIENCE" flag:"oidc-audience" usage:"only accept oidc tokens with this audience (client id)"`
+	// Latency delays every response, plus a random duration up to Jitter
+	Latency time.Duration `yaml:"latency" env:"LATENCY" flag:"latency" usage:"delay every response"`
+	Jitter  time.Duration `yaml:"jitter" env:"JITTER" flag:"jitter" usage:"add a random delay up to this duration to the latency"`
+	// FailureRate is the fraction of requests, between 0 and 1, answered with FailureStatus
+	FailureRate   float64 `yaml:"failureRate" env:"FAILURE_RATE" flag:"failure-rate" usage:"fraction of requests, between 0 and 1, that fail"`
+	FailureStatus int     `yaml:"failureStatus" env:"FAILURE_STATUS" flag:"failure-status" usage:"status code of the failed requests"`
+	// ShutdownDelay is the time /readyz reports the shutdown before the connections
+	// are drained, ShutdownTimeout the time the draining may take
+	ShutdownDelay   time.Duration `yaml:"shutdownDelay" env:"SHUTDOWN_DELAY" flag:"shutdown-delay" usage:"on SIGTERM, report not ready on /readyz this long before draining the connections"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"on SIGTERM, wait this long for the connections to drain"`
+	// WSInterval is the default time between the words pushed over /ws/words
+	WSInterval time.Duration `yaml:"wsInterval" env:"WS_INTERVAL" flag:"ws-interval" usage:"default time between the words pushed over /ws/words"`
+	// AccessLogFormat is common, combined, json or none. The requests handled by the
+	// mux patterns in AccessLogSkip aren't logged.
+	AccessLogFormat string   `yaml:"accessLogFormat" env:"ACCESS_LOG_FORMAT" flag:"access-log-format" usage:"format of the access log: common, combined, json or none"`
+	AccessLogSkip   []string `yaml:"accessLogSkip" env:"ACCESS_LOG_SKIP" flag:"access-log-skip" usage:"comma separated mux patterns that aren't logged"`
+	// TLSCert and TLSKey serve HTTPS, and MTLSCA requires client certificates signed
+	// by it. DevCerts is a directory where a dev CA and certificates are generated on
+	// the first run, used when TLSCert isn't set.
+	TLSCert  string `yaml:"tlsCert" env:"TLS_CERT" flag:"tls-cert" usage:"certificate file (PEM) to serve HTTPS"`
+	TLSKey   string `yaml:"tlsKey" env:"TLS_KEY" flag:"tls-key" usage:"key file (PEM) of the -tls-cert certificate"`
+	MTLSCA   string `yaml:"mtlsCA" env:"MTLS_CA" flag:"mtls-ca" usage:"CA file (PEM): require client certificates signed by it"`
+	DevCerts string `yaml:"devCerts" env:"DEV_CERTS" flag:"dev-certs" usage:"directory with a dev CA and certificates, generated on the first run, to serve HTTPS without -tls-cert"`
+}
+
+func defaultConfig() Config {
+	return Config{Port: 8080, FailureStatus: 503, ShutdownTimeout: 10 * time.Second, WSInterval: time.Second,
+		AccessLogFormat: accessLogCommon, AccessLogSkip: []string{"/healthz", "/readyz", "/metrics"}}
+}
+
+var configOptions = config.Options{Name: "test-server", EnvPrefix: "TEST_SERVER_"}
+
+// loadConfig loads the config of the -config file, the environment and the flags
+// in args
+func loadConfig(args []string) (Config, error) {
+	return config.Load(defaultConfig(), args, configOptions)
+}
+
+// reloadConfig applies the faults of the config to the runtime config on SIGHUP,
+// and when the config file changes, keeping the changes of /admin/config to the
+// other fields. The rest of the config needs a restart.
+func reloadConfig(ctx context.Context, args []string, rt *Runtime) error {
+	load := func() (Config, error) {
+		return loadConfig(args)
+	}
+	path, err := config.File(defaultConfig(), args, configOptions)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		config.ReloadOnSIGHUP(ctx, load, rt.reload)
+		return nil
+	}
+	go config.ReloadOnSIGHUP(ctx, load, rt.reload)
+	w := &watcher.Watcher{}
+	if err := w.Add(path); err != nil {
+		return err
+	}
+	reload := config.Reloader(ctx, load, rt.reload)
+	w.OnChange(func([]string) { reload() })
+	return w.Run(ctx)
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port %d is not between 1 and 65535", c.Port))
+	}
+	if c.Latency < 0 || c.Jitter < 0 {
+		errs = append(errs, errors.New("latency and jitter can't be negative"))
+	}
+	if c.ShutdownDelay < 0 || c.ShutdownTimeout < 0 {
+		errs = append(errs, errors.New("shutdown delay and timeout can't be negative"))
+	}
+	if c.WSInterval < wsMinInterval {
+		errs = append(errs, fmt.Errorf("websocket interval %s is shorter than %s", c.WSInterval, wsMinInterval))
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, errors.New("the TLS certificate and key must be set together"))
+	}
+	if c.MTLSCA != "" && !c.TLS() {
+		errs = append(errs, errors.New("mTLS needs a TLS certificate or the dev certificates"))
+	}
+	switch c.AccessLogFormat {
+	case accessLogCommon, accessLogCombined, accessLogJSON, accessLogNone:
+	default:
+		errs = append(errs, fmt.Errorf("access log format %q is not common, combined, json or none", c.AccessLogFormat))
+	}
+	if c.FailureRate < 0 || c.FailureRate > 1 {
+		errs = append(errs, fmt.Errorf("failure rate %g is not between 0 and 1", c.FailureRate))
+	}
+	if c.FailureStatus < 400 || c.FailureStatus > 599 {
+		errs = append(errs, fmt.Errorf("failure status %d is not an error status code", c.FailureStatus))
+	}
+	return errors.Join(errs...)
+}
+
+// TLS reports whether the server serves HTTPS
+func (c Config) TLS() bool {
+	return c.TLSCert != "" || c.DevCerts != ""
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte("port: 9090\npassword: secret\nlatency: 200ms\nfailureRate: 0.1\naccessLogSkip: [/healthz]\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidFile := filepath.Join(t.TempDir(), "invalid.yaml")
+	if err := os.WriteFile(invalidFile, []byte("port: [8080]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		check   func(c Config) error
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			check: func(c Config) error {
+				if c.Port != 8080 || c.FailureStatus != 503 || c.AccessLogFormat != accessLogCommon || c.AdminToken != "" {
+					return fmt.Errorf("expected the defaults, got %+v", c)
+				}
+				return nil
+			},
+		},
+		{
+			name: "config file",
+			args: []string{"-config", configFile},
+			check: func(c Config) error {
+				if c.Port != 9090 || c.Password != "secret" || c.Latency != 200*time.Millisecond || c.FailureRate != 0.1 {
+					return fmt.Errorf("expected the values of the file, got %+v", c)
+				}
+				if c.FailureStatus != 503 || fmt.Sprint(c.AccessLogSkip) != "[/healthz]" {
+					return fmt.Errorf("expected the defaults for the rest, got %+v", c)
+				}
+				return nil
+			},
+		},
+		{
+			name: "flags override the file",
+			args: []string{"-port", "7070", "-config", configFile, "-latency", "0s", "-access-log-skip", "/metrics,/readyz"},
+			check: func(c Config) error {
+				if c.Port != 7070 || c.Latency != 0 || fmt.Sprint(c.AccessLogSkip) != "[/metrics /readyz]" {
+					return fmt.Errorf("expected the values of the flags, got %+v", c)
+				}
+				if c.Password != "secret" || c.FailureRate != 0.1 {
+					return fmt.Errorf("expected the file for the flags that aren't set, got %+v", c)
+				}
+				return nil
+			},
+		},
+		{
+			name: "environment overrides the file",
+			args: []string{"-config", configFile, "-port", "7070"},
+			env:  map[string]string{"TEST_SERVER_PORT": "6060", "TEST_SERVER_PASSWORD": "env", "TEST_SERVER_ACCESS_LOG_SKIP": "/metrics"},
+			check: func(c Config) error {
+				if c.Password != "env" || fmt.Sprint(c.AccessLogSkip) != "[/metrics]" {
+					return fmt.Errorf("expected the values of the environment, got %+v", c)
+				}
+				if c.Port != 7070 || c.Latency != 200*time.Millisecond {
+					return fmt.Errorf("expected the flags and the file for the rest, got %+v", c)
+				}
+				return nil
+			},
+		},
+		{
+			name: "flag set to the default",
+			args: []string{"-config", configFile, "-port", "8080"},
+			check: func(c Config) error {
+				if c.Port != 8080 {
+					return fmt.Errorf("expected port 8080, got %d", c.Port)
+				}
+				return nil
+			},
+		},
+		{name: "missing file", args: []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, wantErr: true},
+		{name: "invalid file", args: []string{"-config", invalidFile}, wantErr: true},
+		{name: "invalid port", args: []string{"-port", "70000"}, wantErr: true},
+		{name: "invalid failure rate", args: []string{"-failure-rate", "1.5"}, wantErr: true},
+		{name: "tls key without certificate", args: []string{"-tls-key", "key.pem"}, wantErr: true},
+		{name: "mtls without tls", args: []string{"-mtls-ca", "ca.pem"}, wantErr: true},
+		{name: "access log format", args: []string{"-access-log-format", "xml"}, wantErr: true},
+		{name: "unknown flag", args: []string{"-unknown"}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			config, err := loadConfig(test.args)
+			if test.wantErr {
+				if err == nil || err == flag.ErrHelp {
+					t.Fatalf("Expected an error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfig error: %s", err)
+			}
+			if err := test.check(config); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
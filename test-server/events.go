@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// eventHistory is the number of events kept to replay after a reconnect
+	eventHistory = 100
+	// eventRetry is the reconnection delay the clients are told to use
+	eventRetry = 3 * time.Second
+	// eventKeepAlive is the time between the comments that keep idle connections open
+	eventKeepAlive = 15 * time.Second
+)
+
+// OccurrenceUpdate is the data of an occurrence event: the new count of a word
+type OccurrenceUpdate struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// serverEvent is a server-sent event
+type serverEvent struct {
+	id   uint64
+	name string
+	data []byte
+}
+
+// occurrenceEvents counts the words added with /words, and sends the updated
+// counts to the clients of /events
+type occurrenceEvents struct {
+	mu          sync.Mutex
+	counts      map[string]int
+	lastID      uint64
+	history     []serverEvent
+	subscribers map[chan serverEvent]struct{}
+	closed      bool
+}
+
+func newOccurrenceEvents() *occurrenceEvents {
+	return &occurrenceEvents{
+		counts:      make(map[string]int),
+		subscribers: make(map[chan serverEvent]struct{}),
+	}
+}
+
+// publish counts the word and sends an occurrence event to every subscriber.
+// Subscribers that can't keep up miss events.
+func (e *occurrenceEvents) publish(word string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.counts[word]++
+	data, err := json.Marshal(OccurrenceUpdate{Word: word, Count: e.counts[word]})
+	if err != nil {
+		return
+	}
+	e.lastID++
+	event := serverEvent{id: e.lastID, name: "occurrence", data: data}
+	e.history = append(e.history, event)
+	if len(e.history) > eventHistory {
+		e.history = e.history[len(e.history)-eventHistory:]
+	}
+	for subscriber := range e.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// subscribe returns the events to send first, a channel receiving the new events,
+// and a function to unsubscribe. The first events are the ones after lastEventID
+// when they're still in the history, or else a snapshot of all the counts.
+func (e *occurrenceEvents) subscribe(lastEventID string) ([]serverEvent, chan serverEvent, func()) {
+	events := make(chan serverEvent, 100)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var first []serverEvent
+	if id, err := strconv.ParseUint(lastEventID, 10, 64); err == nil && e.inHistory(id) {
+		for _, event := range e.history {
+			if event.id > id {
+				first = append(first, event)
+			}
+		}
+	} else {
+		data, err := json.Marshal(OccurrenceOutput{Page: "occurrence", Words: e.counts})
+		if err == nil {
+			first = append(first, serverEvent{id: e.lastID, name: "snapshot", data: data})
+		}
+	}
+
+	if e.closed {
+		close(events)
+		return first, events, func() {}
+	}
+	e.subscribers[events] = struct{}{}
+	return first, events, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if _, ok := e.subscribers[events]; ok {
+			delete(e.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+// inHistory reports whether all the events after id can be replayed. An id newer
+// than the last event comes from before a restart of the server.
+func (e *occurrenceEvents) inHistory(id uint64) bool {
+	if id > e.lastID {
+		return false
+	}
+	if len(e.history) == 0 {
+		return id == e.lastID
+	}
+	return id+1 >= e.history[0].id
+}
+
+// close ends the streams of all subscribers, and of the ones subscribing later
+func (e *occurrenceEvents) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	for subscriber := range e.subscribers {
+		delete(e.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+// writeEvent writes the event in the text/event-stream format
+func writeEvent(w http.ResponseWriter, event serverEvent) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.id, event.name, event.data)
+	return err
+}
+
+// serve streams the occurrence counts as server-sent events. A new client first gets
+// a snapshot of all the counts, then an occurrence event for every word added with
+// /words. A client reconnecting with the Last-Event-ID header gets the events it
+// missed, or a new snapshot when they're no longer in the history:
+//
+//	curl -N localhost:8080/events
+//	retry: 3000
+//
+//	id: 0
+//	event: snapshot
+//	data: {"page":"occurrence","words":{}}
+//
+//	id: 1
+//	event: occurrence
+//	data: {"word":"word1","count":1}
+func (e *occurrenceEvents) serve(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "streaming not supported")
+		return
+	}
+
+	first, events, unsubscribe := e.subscribe(r.Header.Get("Last-Event-ID"))
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetry.Milliseconds())
+	for _, event := range first {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// the server shuts down, the clients reconnect after the retry delay
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprintf(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eventNames returns the id and name of the events, e.g. "3:occurrence"
+func eventNames(events []serverEvent) []string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = fmt.Sprintf("%d:%s", event.id, event.name)
+	}
+	return names
+}
+
+func TestOccurrenceEventsSubscribe(t *testing.T) {
+	tests := []struct {
+		name        string
+		published   int
+		lastEventID string
+		first       string
+	}{
+		{name: "new client", published: 3, lastEventID: "", first: "[3:snapshot]"},
+		{name: "new client before any word", published: 0, lastEventID: "", first: "[0:snapshot]"},
+		{name: "replay", published: 3, lastEventID: "1", first: "[2:occurrence 3:occurrence]"},
+		{name: "up to date", published: 3, lastEventID: "3", first: "[]"},
+		{name: "from the start", published: 3, lastEventID: "0", first: "[1:occurrence 2:occurrence 3:occurrence]"},
+		{name: "restarted server", published: 3, lastEventID: "7", first: "[3:snapshot]"},
+		{name: "invalid id", published: 3, lastEventID: "abc", first: "[3:snapshot]"},
+		{name: "oldest in history", published: eventHistory + 10, lastEventID: "10", first: fmt.Sprintf("[%d events]", eventHistory)},
+		{name: "out of history", published: eventHistory + 10, lastEventID: "9", first: fmt.Sprintf("[%d:snapshot]", eventHistory+10)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := newOccurrenceEvents()
+			for i := 0; i < test.published; i++ {
+				e.publish(fmt.Sprintf("word%d", i%3))
+			}
+			first, _, unsubscribe := e.subscribe(test.lastEventID)
+			defer unsubscribe()
+
+			got := fmt.Sprint(eventNames(first))
+			if len(first) > 10 {
+				got = fmt.Sprintf("[%d events]", len(first))
+			}
+			if got != test.first {
+				t.Errorf("Expected first events %s, got %s", test.first, got)
+			}
+		})
+	}
+}
+
+func TestOccurrenceEventsSnapshot(t *testing.T) {
+	e := newOccurrenceEvents()
+	e.publish("a")
+	e.publish("b")
+	e.publish("a")
+	first, _, unsubscribe := e.subscribe("")
+	defer unsubscribe()
+	if len(first) != 1 || string(first[0].data) != `{"page":"occurrence","words":{"a":2,"b":1}}` {
+		t.Errorf("Unexpected snapshot: %v", eventNames(first))
+	}
+}
+
+func TestOccurrenceEventsInHistory(t *testing.T) {
+	e := newOccurrenceEvents()
+	if !e.inHistory(0) || e.inHistory(1) {
+		t.Errorf("Without events, only id 0 is in the history")
+	}
+	for i := 0; i < eventHistory+5; i++ {
+		e.publish("word")
+	}
+	tests := []struct {
+		id uint64
+		ok bool
+	}{
+		{id: 0, ok: false},
+		{id: 4, ok: false},
+		{id: 5, ok: true},
+		{id: eventHistory + 5, ok: true},
+		{id: eventHistory + 6, ok: false},
+	}
+	for _, test := range tests {
+		if ok := e.inHistory(test.id); ok != test.ok {
+			t.Errorf("inHistory(%d): expected %t, got %t", test.id, test.ok, ok)
+		}
+	}
+}
+
+func TestOccurrenceEventsPublish(t *testing.T) {
+	e := newOccurrenceEvents()
+	_, events, unsubscribe := e.subscribe("")
+	e.publish("a")
+	e.publish("a")
+	for _, expected := range []string{`{"word":"a","count":1}`, `{"word":"a","count":2}`} {
+		if event := <-events; string(event.data) != expected {
+			t.Errorf("Expected %s, got %s", expected, event.data)
+		}
+	}
+
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Errorf("Expected the channel to be closed after unsubscribe")
+	}
+	unsubscribe()
+	e.close()
+	if _, events, _ := e.subscribe(""); !isClosed(events) {
+		t.Errorf("Expected the channel of a subscriber after close to be closed")
+	}
+}
+
+func isClosed(events chan serverEvent) bool {
+	select {
+	case _, ok := <-events:
+		return !ok
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func TestOccurrenceEventsServe(t *testing.T) {
+	e := newOccurrenceEvents()
+	e.publish("a")
+	e.publish("b")
+	server := httptest.NewServer(http.HandlerFunc(e.serve))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "1")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected text/event-stream, got %s", contentType)
+	}
+
+	e.publish("c")
+	scanner := bufio.NewScanner(res.Body)
+	var lines []string
+	for len(lines) < 7 && scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	expected := "retry: 3000|id: 2|event: occurrence|data: {\"word\":\"b\",\"count\":1}|id: 3|event: occurrence|data: {\"word\":\"c\",\"count\":1}"
+	if got := strings.Join(lines, "|"); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// isFaultExempt reports whether the faults skip the path. The admin API stays
+// reliable, or a failure rate of 1 could never be undone, and the probes and the
+// metrics report on the server rather than fail with it, or an orchestrator would
+// restart it in the middle of a test.
+func isFaultExempt(path string) bool {
+	switch path {
+	case "/healthz", "/readyz", "/metrics":
+		return true
+	}
+	return strings.HasPrefix(path, "/admin/")
+}
+
+// faultHandler delays the responses by the configured latency and jitter, and fails
+// the configured fraction of the requests, so clients can be tested against a slow
+// and unreliable server. The faults can be changed at runtime with /admin/config.
+func faultHandler(rt *Runtime, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config := rt.load()
+		if isFaultExempt(r.URL.Path) || config.Latency == 0 && config.Jitter == 0 && config.FailureRate == 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+		delay := time.Duration(config.Latency)
+		if config.Jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(config.Jitter)))
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if rand.Float64() < config.FailureRate {
+			slog.InfoContext(r.Context(), "injected failure", "status", config.FailureStatus)
+			w.WriteHeader(config.FailureStatus)
+			fmt.Fprintf(w, "injected failure")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
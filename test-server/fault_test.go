@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFaultHandler(t *testing.T) {
+	rt := newRuntime(Config{Latency: 50 * time.Millisecond, FailureRate: 1, FailureStatus: http.StatusServiceUnavailable})
+	handler := faultHandler(rt, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+
+	tests := []struct {
+		path   string
+		status int
+		delay  bool
+	}{
+		{path: "/words", status: http.StatusServiceUnavailable, delay: true},
+		{path: "/occurrence", status: http.StatusServiceUnavailable, delay: true},
+		{path: "/healthz", status: http.StatusOK},
+		{path: "/readyz", status: http.StatusOK},
+		{path: "/metrics", status: http.StatusOK},
+		{path: "/admin/config", status: http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			start := time.Now()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+			elapsed := time.Since(start)
+			if w.Code != test.status {
+				t.Errorf("Expected status %d, got %d", test.status, w.Code)
+			}
+			if delayed := elapsed >= 50*time.Millisecond; delayed != test.delay {
+				t.Errorf("Expected a delay: %t, got %s", test.delay, elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// Health reports whether the server is alive and ready for traffic. It stops
+// being ready when the server shuts down, so load balancers stop sending requests.
+type Health struct {
+	ready atomic.Bool
+}
+
+// healthz is the liveness probe: the server answers, so it's alive
+func (h *Health) healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, "ok")
+}
+
+// readyz is the readiness probe: 503 once the shutdown started
+func (h *Health) readyz(w http.ResponseWriter, r *http.Request) {
+	if !h.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, "shutting down")
+		return
+	}
+	fmt.Fprint(w, "ready")
+}
@@ -1,19 +1,29 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+
+	"lifecycle"
+	"logging"
+	"telemetry"
+	"words"
 )
 
 type WordsOutput struct {
@@ -35,28 +45,41 @@ type LoginResponse struct {
 }
 
 type WordsHandler struct {
-	words       []string
+	// words holds the words added with /words, and streams them to /words/stream
+	words       *words.Store
 	password    string
 	tokenSecret []byte
+	events      *occurrenceEvents
+	// jwks verifies the oidc tokens of the protected endpoints, nil without -jwks-url
+	jwks         *jwksKeys
+	oidcAudience string
+	// runtime holds the words of /assignment1
+	runtime *Runtime
 }
 
 func (ct *WordsHandler) wordsHandler(w http.ResponseWriter, r *http.Request) {
 	input := r.URL.Query().Get("input")
 	if input != "" {
-		ct.words = append(ct.words, input)
+		ct.words.Add(input)
+		ct.events.publish(input)
 	}
 
 	wordsOutput := WordsOutput{
 		Page:  "words",
 		Input: input,
-		Words: ct.words,
+		Words: ct.words.Words(),
 	}
 	out, err := json.Marshal(wordsOutput)
 	if err != nil {
 		fmt.Fprintf(w, "marshal error")
 		return
 	}
-	fmt.Fprint(w, string(out))
+	if input != "" {
+		// adding a word is never answered from a cache
+		fmt.Fprint(w, string(out))
+		return
+	}
+	writeWithETag(w, r, out)
 }
 
 func (ct *WordsHandler) indexHandler(w http.ResponseWriter, r *http.Request) {
@@ -70,24 +93,16 @@ func (ct *WordsHandler) indexHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ct *WordsHandler) occurrenceHandler(w http.ResponseWriter, r *http.Request) {
-	words := make(map[string]int)
-	for _, v := range ct.words {
-		if _, ok := words[v]; ok {
-			words[v]++
-		} else {
-			words[v] = 1
-		}
-	}
 	occurrenceOutput := OccurrenceOutput{
 		Page:  "occurrence",
-		Words: words,
+		Words: ct.words.Occurrence(),
 	}
 	out, err := json.Marshal(occurrenceOutput)
 	if err != nil {
 		fmt.Fprintf(w, "marshal error")
 		return
 	}
-	fmt.Fprint(w, string(out))
+	writeWithETag(w, r, out)
 }
 
 func (ct *WordsHandler) login(w http.ResponseWriter, r *http.Request) {
@@ -114,7 +129,7 @@ func (ct *WordsHandler) login(w http.ResponseWriter, r *http.Request) {
 	if ct.password == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintf(w, "start the test-server with a password first")
-		fmt.Printf("Returned HTTP 400 error to client: server has no password set\n")
+		slog.WarnContext(r.Context(), "Login without a server password: start the test-server with -password")
 		return
 	}
 
@@ -168,58 +183,180 @@ func (ct *WordsHandler) authMiddleware(next http.HandlerFunc) http.Handler {
 	})
 }
 
-func (wh *WordsHandler) loggingHandler(h http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if wh.password == "" {
-			log.Println(r.Method, r.URL.Path)
-		} else {
-			log.Println(r.Method, r.URL.Path, "Auth:"+r.Header.Get("Authorization"))
-		}
-
-		h.ServeHTTP(w, r)
-	})
-}
-
 func getRandomSecret() []byte {
 	b := make([]byte, 30)
-	_, err := rand.Read(b)
-	if err != nil {
-		log.Fatal(err)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
 	}
 	return b
 }
 
 func main() {
-	port := "8080"
-	testListener, err := net.Listen("tcp", ":"+port)
+	// the server logs go to stderr, the access log to stdout
+	logger, err := logging.Setup()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't start server on port %q: %s\n", port, err)
-		os.Exit(1)
+		logger.Error("Invalid logging configuration", "error", err)
+		os.Exit(2)
+	}
+	config, err := loadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(2)
+	}
+
+	if config.DevCerts != "" && config.TLSCert == "" {
+		if err := generateDevCerts(config.DevCerts); err != nil {
+			logger.Error("Can't generate the dev certificates", "error", err)
+			os.Exit(1)
+		}
+		config.TLSCert = filepath.Join(config.DevCerts, devServerFile)
+		config.TLSKey = filepath.Join(config.DevCerts, devServerKeyFile)
+		logger.Info("Using the dev certificates, trust the CA in the clients", "dir", config.DevCerts, "ca", filepath.Join(config.DevCerts, devCAFile))
+	}
+	var serverTLS *tls.Config
+	if config.TLS() {
+		if serverTLS, err = tlsConfig(config); err != nil {
+			logger.Error("Invalid TLS configuration", "error", err)
+			os.Exit(2)
+		}
 	}
-	testListener.Close()
 
-	password := flag.String("password", "", "password protect our API")
+	// exports to the collector of OTEL_EXPORTER_OTLP_ENDPOINT, if set
+	shutdownTelemetry, err := telemetry.Setup(context.Background(), "test-server")
+	if err != nil {
+		logger.Error("Can't set up the telemetry", "error", err)
+		os.Exit(1)
+	}
 
-	flag.Parse()
+	port := strconv.Itoa(config.Port)
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		logger.Error("Can't start the server", "port", port, "error", err)
+		os.Exit(1)
+	}
 
 	wh := &WordsHandler{
-		words:       []string{},
-		password:    *password,
+		words:       words.NewStore(),
+		password:    config.Password,
 		tokenSecret: getRandomSecret(),
+		events:      newOccurrenceEvents(),
+		runtime:     newRuntime(config),
+	}
+	if config.JWKSURL != "" {
+		wh.jwks = &jwksKeys{url: config.JWKSURL}
+		wh.oidcAudience = config.OIDCAudience
 	}
 
+	socket := newWordSocket(config.WSInterval, wh.runtime)
+	chaos := newChaos()
+	metrics := newMetrics()
+	health := &Health{}
+
 	rl := &RateLimit{
-		hits: make(map[string]uint64),
+		runtime: wh.runtime,
+		hits:    make(map[string]uint64),
 	}
 
 	mux := http.NewServeMux()
+	accessLog := newAccessLog(os.Stdout, config.AccessLogFormat, mux, config.AccessLogSkip)
 
 	mux.Handle("/words", wh.authMiddleware(wh.wordsHandler))
+	mux.Handle("/words/stream", wh.authMiddleware(wh.wordsStream))
+	mux.Handle("/events", wh.authMiddleware(wh.events.serve))
+	mux.Handle("/ws/words", wh.authMiddleware(socket.serve))
 	mux.Handle("/occurrence", wh.authMiddleware(wh.occurrenceHandler))
+	mux.Handle("/protected/words", wh.requireToken(wh.wordsHandler))
+	mux.Handle("/protected/occurrence", wh.requireToken(wh.occurrenceHandler))
 	mux.HandleFunc("/assignment1", wh.assignment1)
+	mux.HandleFunc("/assignment1/pages", wh.assignment1Pages)
 	mux.HandleFunc("/ratelimit", rl.ratelimit)
 	mux.HandleFunc("/", wh.indexHandler)
 	mux.HandleFunc("/login", wh.login)
-	fmt.Printf("Starting server on port %v...\n", port)
-	http.ListenAndServe(":"+port, wh.loggingHandler(mux))
+	mux.Handle("/admin/chaos", adminAuth(config.AdminToken, chaos.admin))
+	mux.Handle("/admin/config", adminAuth(config.AdminToken, wh.runtime.admin))
+	mux.HandleFunc("/metrics", metrics.serveMetrics)
+	mux.HandleFunc("/healthz", health.healthz)
+	mux.HandleFunc("/readyz", health.readyz)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	scheme := "HTTP"
+	if config.TLS() {
+		scheme = "HTTPS"
+		if config.MTLSCA != "" {
+			scheme = "HTTPS with client certificates"
+		}
+	}
+	logger.Info("Starting the server", "port", port, "scheme", scheme)
+	if config.Latency > 0 || config.Jitter > 0 || config.FailureRate > 0 {
+		logger.Info("Injecting faults", "latency", config.Latency, "jitter", config.Jitter,
+			"failure_rate", config.FailureRate, "failure_status", config.FailureStatus)
+	}
+
+	// the span of a request is outermost, so it includes the faults and the
+	// access log has its trace context
+	route := func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+	server := &http.Server{
+		Handler:   telemetry.Handler(metricsHandler(metrics, mux, accessLog.handler(faultHandler(wh.runtime, chaosHandler(chaos, compressHandler(mux))))), route),
+		TLSConfig: serverTLS,
+	}
+	// streams never end by themselves, end them so the shutdown doesn't wait for them
+	server.RegisterOnShutdown(wh.words.Close)
+	server.RegisterOnShutdown(wh.events.close)
+	// hijacked websocket connections aren't tracked by Shutdown at all
+	server.RegisterOnShutdown(socket.close)
+
+	// started in this order, and stopped in the reverse order on SIGTERM: not
+	// ready, drain the connections, then export the telemetry left
+	httpServer := lifecycle.HTTPServer("http", server, listener)
+	shutdownServer := httpServer.Stop
+	httpServer.Stop = func(ctx context.Context) error {
+		err := shutdownServer(ctx)
+		socket.wait()
+		return err
+	}
+	httpServer.StopTimeout = config.ShutdownTimeout
+	var group lifecycle.Group
+	group.Add(
+		lifecycle.Component{Name: "telemetry", Stop: shutdownTelemetry},
+		// kill -HUP and the changes of the config file change the faults to the ones of
+		// the config file and the environment
+		lifecycle.Func("config", func(ctx context.Context) error {
+			return reloadConfig(ctx, os.Args[1:], wh.runtime)
+		}),
+		httpServer,
+		// stop being ready first, so load balancers stop sending requests, then drain
+		// the connections
+		lifecycle.Component{
+			Name: "readiness",
+			Start: func(ctx context.Context) error {
+				health.ready.Store(true)
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				health.ready.Store(false)
+				logger.Info("Shutting down, waiting before draining the connections", "delay", config.ShutdownDelay)
+				time.Sleep(config.ShutdownDelay)
+				return nil
+			},
+			StopTimeout: config.ShutdownDelay + time.Second,
+		},
+	)
+
+	ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+	if err := group.Run(ctx); err != nil {
+		logger.Error("Server stopped with errors", "error", err)
+		stop()
+		os.Exit(1)
+	}
+	logger.Info("Server stopped")
 }
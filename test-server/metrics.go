@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the upper bounds in seconds of the latency histogram, like
+// the default buckets of the Prometheus client
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// handlerMetrics are the metrics of one handler
+type handlerMetrics struct {
+	requests map[string]uint64 // per status code
+	buckets  []uint64          // per duration bucket, not cumulative
+	sum      float64
+	count    uint64
+	inFlight int64
+}
+
+// Metrics counts the requests per handler, and serves them in the Prometheus text format
+type Metrics struct {
+	mu       sync.Mutex
+	handlers map[string]*handlerMetrics
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{handlers: make(map[string]*handlerMetrics)}
+}
+
+// get returns the metrics of the handler, creating them if needed. mu must be held.
+func (m *Metrics) get(handler string) *handlerMetrics {
+	metrics, ok := m.handlers[handler]
+	if !ok {
+		metrics = &handlerMetrics{requests: make(map[string]uint64), buckets: make([]uint64, len(durationBuckets))}
+		m.handlers[handler] = metrics
+	}
+	return metrics
+}
+
+func (m *Metrics) start(handler string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(handler).inFlight++
+}
+
+func (m *Metrics) finish(handler, code string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metrics := m.get(handler)
+	metrics.inFlight--
+	metrics.requests[code]++
+	seconds := duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			metrics.buckets[i]++
+			break
+		}
+	}
+	metrics.sum += seconds
+	metrics.count++
+}
+
+// statusRecorder remembers the status code and the size of the body written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+// Flush passes flushes through, so the streamed responses keep working
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes the connection on for the websocket upgrade, which answers with
+// 101 Switching Protocols on the connection itself
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T doesn't support hijacking", s.ResponseWriter)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && s.status == 0 {
+		s.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// metricsHandler records the requests under the pattern of the mux that handles
+// them, so unknown paths don't create new handlers
+func metricsHandler(m *Metrics, mux *http.ServeMux, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, handler := mux.Handler(r)
+		m.start(handler)
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			code := strconv.Itoa(recorder.status)
+			if err := recover(); err != nil {
+				// a dropped connection of the chaos handler
+				m.finish(handler, "aborted", time.Since(start))
+				panic(err)
+			}
+			if recorder.status == 0 {
+				code = "200"
+			}
+			m.finish(handler, code, time.Since(start))
+		}()
+		h.ServeHTTP(recorder, r)
+	})
+}
+
+// serveMetrics writes the metrics in the Prometheus text format:
+//
+//	curl localhost:8080/metrics
+//	http_requests_total{handler="/words",code="200"} 3
+func (m *Metrics) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	handlers := make([]string, 0, len(m.handlers))
+	for handler := range m.handlers {
+		handlers = append(handlers, handler)
+	}
+	sort.Strings(handlers)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintln(w, "# HELP http_requests_total Number of HTTP requests by handler and status code.")
+	fmt.Fprintln(w, "# TYPE http_requests_total counter")
+	for _, handler := range handlers {
+		codes := make([]string, 0, len(m.handlers[handler].requests))
+		for code := range m.handlers[handler].requests {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Fprintf(w, "http_requests_total{handler=%q,code=%q} %d\n", handler, code, m.handlers[handler].requests[code])
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP http_request_duration_seconds Latency of the HTTP requests by handler.")
+	fmt.Fprintln(w, "# TYPE http_request_duration_seconds histogram")
+	for _, handler := range handlers {
+		metrics := m.handlers[handler]
+		var cumulative uint64
+		for i, bound := range durationBuckets {
+			cumulative += metrics.buckets[i]
+			fmt.Fprintf(w, "http_request_duration_seconds_bucket{handler=%q,le=%q} %d\n", handler, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(w, "http_request_duration_seconds_bucket{handler=%q,le=\"+Inf\"} %d\n", handler, metrics.count)
+		fmt.Fprintf(w, "http_request_duration_seconds_sum{handler=%q} %g\n", handler, metrics.sum)
+		fmt.Fprintf(w, "http_request_duration_seconds_count{handler=%q} %d\n", handler, metrics.count)
+	}
+
+	fmt.Fprintln(w, "# HELP http_requests_in_flight Number of HTTP requests being served by handler.")
+	fmt.Fprintln(w, "# TYPE http_requests_in_flight gauge")
+	for _, handler := range handlers {
+		fmt.Fprintf(w, "http_requests_in_flight{handler=%q} %d\n", handler, m.handlers[handler].inFlight)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+const (
+	formatJSON = "application/json"
+	formatYAML = "application/yaml"
+	formatXML  = "application/xml"
+)
+
+// negotiate returns the supported media type with the highest quality in the Accept header.
+// JSON is returned when nothing matches.
+func negotiate(accept string) string {
+	best, bestQuality := formatJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		var format string
+		switch mediaType {
+		case "application/json", "*/*", "application/*":
+			format = formatJSON
+		case "application/yaml", "application/x-yaml", "text/yaml":
+			format = formatYAML
+		case "application/xml", "text/xml":
+			format = formatXML
+		default:
+			continue
+		}
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	return best
+}
+
+type xmlAssignment1 struct {
+	XMLName      xml.Name        `xml:"assignment1"`
+	Page         string          `xml:"page"`
+	Words        []string        `xml:"words>word"`
+	Percentages  []xmlPercentage `xml:"percentages>percentage"`
+	Special      []xmlItem       `xml:"special>item"`
+	ExtraSpecial []xmlValue      `xml:"extraSpecial>value"`
+	Pagination   *pagination     `xml:"pagination"`
+}
+
+type xmlPercentage struct {
+	Word  string  `xml:"word,attr"`
+	Value float64 `xml:",chardata"`
+}
+
+type xmlItem struct {
+	Null  bool   `xml:"null,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type xmlValue struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// toXML converts the assignment1 output to its XML representation
+func (a assignment1) toXML() xmlAssignment1 {
+	out := xmlAssignment1{
+		Page:       a.Page,
+		Words:      a.Words,
+		Pagination: a.Pagination,
+	}
+	for word, percentage := range a.Percentages {
+		out.Percentages = append(out.Percentages, xmlPercentage{Word: word, Value: percentage})
+	}
+	for _, item := range a.Special {
+		if item == nil {
+			out.Special = append(out.Special, xmlItem{Null: true})
+		} else {
+			out.Special = append(out.Special, xmlItem{Value: *item})
+		}
+	}
+	for _, value := range a.ExtraSpecial {
+		switch v := value.(type) {
+		case nil:
+			out.ExtraSpecial = append(out.ExtraSpecial, xmlValue{Type: "null"})
+		case int:
+			out.ExtraSpecial = append(out.ExtraSpecial, xmlValue{Type: "int", Value: strconv.Itoa(v)})
+		default:
+			out.ExtraSpecial = append(out.ExtraSpecial, xmlValue{Type: "string", Value: fmt.Sprint(v)})
+		}
+	}
+	return out
+}
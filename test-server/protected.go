@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// errAudience is returned for a valid oidc token issued for another client
+var errAudience = errors.New("token audience not allowed")
+
+// jwksKeys fetches the public keys of an oidc provider, like the oidc-demo, to
+// verify the tokens it signed
+type jwksKeys struct {
+	url  string
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey
+}
+
+type jwks struct {
+	Keys []struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// key returns the public key with the kid, fetching the keys again if it's unknown
+func (j *jwksKeys) key(kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+
+	client := http.Client{Timeout: 5 * time.Second}
+	response, err := client.Get(j.url)
+	if err != nil {
+		return nil, fmt.Errorf("can't fetch the jwks: %w", err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read the jwks: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("can't fetch the jwks: HTTP %d", response.StatusCode)
+	}
+	var set jwks
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, fmt.Errorf("can't parse the jwks: %w", err)
+	}
+
+	j.keys = make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		n, err := decodeBase64(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid modulus: %w", key.Kid, err)
+		}
+		e, err := decodeBase64(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: invalid exponent: %w", key.Kid, err)
+		}
+		j.keys[key.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("kid %q not found in the jwks", kid)
+}
+
+// decodeBase64 decodes the base64url encoding of the JWK spec, and the standard
+// encoding the oidc-demo uses
+func decodeBase64(s string) ([]byte, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "=")); err == nil {
+		return b, nil
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// verifyToken verifies the tokens issued by /login, signed with the token secret,
+// and, with -jwks-url, the RS256 tokens of the oidc provider
+func (ct *WordsHandler) verifyToken(tokenString string) error {
+	var oidcToken bool
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			return ct.tokenSecret, nil
+		case *jwt.SigningMethodRSA:
+			if ct.jwks == nil {
+				return nil, fmt.Errorf("start the test-server with -jwks-url to accept oidc tokens")
+			}
+			oidcToken = true
+			kid, _ := token.Header["kid"].(string)
+			return ct.jwks.key(kid)
+		}
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	})
+	if err != nil {
+		return err
+	}
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && oidcToken && ct.oidcAudience != "" {
+		if !claims.VerifyAudience(ct.oidcAudience, true) {
+			return errAudience
+		}
+	}
+	return nil
+}
+
+// requireToken protects the handler with a bearer token, whether or not the server
+// has a password. A missing or invalid token is a 401 with a WWW-Authenticate header,
+// a valid token of another client a 403.
+func (ct *WordsHandler) requireToken(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if authorization == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="test-server"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, "Authorization header not set, get a token with /login")
+			return
+		}
+		tokenString, ok := strings.CutPrefix(authorization, "Bearer ")
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="test-server"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, "Authorization header is not a bearer token")
+			return
+		}
+
+		err := ct.verifyToken(tokenString)
+		if errors.Is(err, errAudience) {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, "Forbidden: %s", err)
+			return
+		}
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="test-server", error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, "Authorization token invalid: %s", err)
+			return
+		}
+		next(w, r)
+	})
+}
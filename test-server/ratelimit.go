@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -10,6 +11,7 @@ import (
 const DATE_FORMAT = "2006-01-02T15:04:05"
 
 type RateLimit struct {
+	runtime       *Runtime
 	mu            sync.Mutex
 	hits          map[string]uint64
 	limitExceeded bool
@@ -17,6 +19,8 @@ type RateLimit struct {
 }
 
 func (rl *RateLimit) ratelimit(w http.ResponseWriter, r *http.Request) {
+	config := rl.runtime.load()
+	limit := uint64(config.RateLimit)
 	if rl.limitExceeded && time.Now().Before(rl.limitLifted) {
 		w.WriteHeader(429)
 		w.Write([]byte("Rate Limited"))
@@ -29,9 +33,9 @@ func (rl *RateLimit) ratelimit(w http.ResponseWriter, r *http.Request) {
 	timestamp := time.Now()
 	strTimestamp := timestamp.Format(DATE_FORMAT)
 	if val, ok := rl.hits[strTimestamp]; ok {
-		if val == 5 {
+		if val >= limit {
 			rl.limitExceeded = true
-			rl.limitLifted = time.Now().Add(time.Second * 10)
+			rl.limitLifted = time.Now().Add(time.Duration(config.RateLimitBlock))
 		} else {
 			rl.hits[strTimestamp] = val + 1
 		}
@@ -40,14 +44,14 @@ func (rl *RateLimit) ratelimit(w http.ResponseWriter, r *http.Request) {
 	}
 	rl.mu.Unlock()
 	timestampOneSecondEarlier := time.Now().Add(time.Duration(-1) * time.Second)
-	if rl.hits[timestampOneSecondEarlier.Format(DATE_FORMAT)] == 5 {
+	if rl.hits[timestampOneSecondEarlier.Format(DATE_FORMAT)] == limit {
 		w.Write([]byte(fmt.Sprintf("DONE! You did it! Hitting API at %d requests in a given second\n", rl.hits[timestampOneSecondEarlier.Format(DATE_FORMAT)])))
 	} else {
 		w.Write([]byte(fmt.Sprintf("Hitting API at %d requests in a given second\n", rl.hits[timestampOneSecondEarlier.Format(DATE_FORMAT)])))
 	}
 	if len(rl.hits) > 100000 {
 		rl.mu.Lock()
-		fmt.Printf("Map is getting big, resetting...\n")
+		slog.WarnContext(r.Context(), "Rate limit map is getting big, resetting it", "entries", len(rl.hits))
 		oldVal := rl.hits[strTimestamp]
 		rl.hits = make(map[string]uint64)
 		rl.hits[strTimestamp] = oldVal
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RuntimeConfig is the part of the configuration /admin/config changes without a
+// restart. It's replaced as a whole, so a request never sees half of a change.
+type RuntimeConfig struct {
+	// Words are the words of /assignment1 and /ws/words, with a percentage per word
+	Words       []string  `json:"words"`
+	Percentages []float64 `json:"percentages"`
+	// RateLimit is the number of requests per second /ratelimit allows, after which
+	// it answers 429 for RateLimitBlock
+	RateLimit      int      `json:"rateLimit"`
+	RateLimitBlock duration `json:"rateLimitBlock"`
+	// Latency, Jitter, FailureRate and FailureStatus are the faults of every request,
+	// as set with the flags of the same name
+	Latency       duration `json:"latency"`
+	Jitter        duration `json:"jitter"`
+	FailureRate   float64  `json:"failureRate"`
+	FailureStatus int      `json:"failureStatus"`
+}
+
+func (c RuntimeConfig) validate() error {
+	var errs []error
+	if len(c.Words) == 0 {
+		errs = append(errs, errors.New("words can't be empty"))
+	}
+	if len(c.Percentages) != len(c.Words) {
+		errs = append(errs, fmt.Errorf("%d percentages for %d words, there must be one per word", len(c.Percentages), len(c.Words)))
+	}
+	if c.RateLimit < 1 {
+		errs = append(errs, fmt.Errorf("rateLimit %d must be at least 1", c.RateLimit))
+	}
+	if c.RateLimitBlock < 0 || c.Latency < 0 || c.Jitter < 0 {
+		errs = append(errs, errors.New("rateLimitBlock, latency and jitter can't be negative"))
+	}
+	if c.FailureRate < 0 || c.FailureRate > 1 {
+		errs = append(errs, fmt.Errorf("failureRate %g is not between 0 and 1", c.FailureRate))
+	}
+	if c.FailureStatus < 400 || c.FailureStatus > 599 {
+		errs = append(errs, fmt.Errorf("failureStatus %d is not an error status code", c.FailureStatus))
+	}
+	return errors.Join(errs...)
+}
+
+// clone copies the slices as well, so decoding a change into the clone can't touch
+// the config the requests are reading
+func (c RuntimeConfig) clone() RuntimeConfig {
+	c.Words = slices.Clone(c.Words)
+	c.Percentages = slices.Clone(c.Percentages)
+	return c
+}
+
+// Runtime holds the current RuntimeConfig. Handlers load it once per request.
+type Runtime struct {
+	current atomic.Pointer[RuntimeConfig]
+	initial RuntimeConfig
+	// mu makes the updates one at a time, so none of them gets lost
+	mu sync.Mutex
+}
+
+func newRuntime(config Config) *Runtime {
+	rt := &Runtime{initial: RuntimeConfig{
+		Words:          assignment1Words,
+		Percentages:    assignment1Numbers,
+		RateLimit:      5,
+		RateLimitBlock: duration(10 * time.Second),
+		Latency:        duration(config.Latency),
+		Jitter:         duration(config.Jitter),
+		FailureRate:    config.FailureRate,
+		FailureStatus:  config.FailureStatus,
+	}}
+	initial := rt.initial.clone()
+	rt.current.Store(&initial)
+	return rt
+}
+
+func (rt *Runtime) load() *RuntimeConfig {
+	return rt.current.Load()
+}
+
+// reload changes the faults of the config the server started with to the ones of
+// config, for DELETE /admin/config, and of the current config. The other fields of
+// the current config keep the changes of the admin API.
+func (rt *Runtime) reload(config Config) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	withFaults := func(c RuntimeConfig) *RuntimeConfig {
+		c.Latency = duration(config.Latency)
+		c.Jitter = duration(config.Jitter)
+		c.FailureRate = config.FailureRate
+		c.FailureStatus = config.FailureStatus
+		return &c
+	}
+	rt.initial = *withFaults(rt.initial)
+	rt.current.Store(withFaults(rt.load().clone()))
+}
+
+// admin is the admin API of the runtime config. PUT changes the fields in the body
+// and keeps the others, DELETE goes back to the config the server started with:
+//
+//	curl localhost:8080/admin/config
+//	curl -X PUT localhost:8080/admin/config -d '{"rateLimit":10,"latency":"100ms"}'
+//	curl -X PUT localhost:8080/admin/config -d '{"words":["a","b"],"percentages":[0.5,0.5]}'
+//	curl -X DELETE localhost:8080/admin/config
+func (rt *Runtime) admin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		rt.mu.Lock()
+		defer rt.mu.Unlock()
+		config := rt.load().clone()
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Unmarshal error: %s", err)
+			return
+		}
+		if err := config.validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Invalid config: %s", err)
+			return
+		}
+		rt.current.Store(&config)
+	case http.MethodDelete:
+		rt.mu.Lock()
+		defer rt.mu.Unlock()
+		initial := rt.initial.clone()
+		rt.current.Store(&initial)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, "Use GET, PUT or DELETE")
+		return
+	}
+
+	// every method replies with the config that is active now
+	out, err := json.Marshal(rt.load())
+	if err != nil {
+		fmt.Fprintf(w, "marshal error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// adminRequest sends a request to the admin API of the runtime, and returns the
+// status code and the config in the response
+func adminRequest(t *testing.T, rt *Runtime, method, body string) (int, RuntimeConfig) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/admin/config", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	rt.admin(w, req)
+	var config RuntimeConfig
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &config); err != nil {
+			t.Fatalf("Unmarshal error: %s (%s)", err, w.Body)
+		}
+	}
+	return w.Code, config
+}
+
+func TestRuntimeAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		// check verifies the active config after the request
+		check func(c RuntimeConfig) error
+	}{
+		{
+			name: "get", method: http.MethodGet, status: http.StatusOK,
+			check: func(c RuntimeConfig) error {
+				if c.RateLimit != 5 || len(c.Words) != len(assignment1Words) {
+					return fmt.Errorf("expected the initial config, got %+v", c)
+				}
+				return nil
+			},
+		},
+		{
+			name: "put keeps the other fields", method: http.MethodPut, body: `{"rateLimit":10,"latency":"100ms"}`, status: http.StatusOK,
+			check: func(c RuntimeConfig) error {
+				if c.RateLimit != 10 || c.Latency != duration(100*time.Millisecond) || len(c.Words) != len(assignment1Words) {
+					return fmt.Errorf("expected rateLimit 10 and latency 100ms only, got %+v", c)
+				}
+				return nil
+			},
+		},
+		{
+			name: "put words", method: http.MethodPut, body: `{"words":["a","b"],"percentages":[0.5,0.5]}`, status: http.StatusOK,
+			check: func(c RuntimeConfig) error {
+				if fmt.Sprint(c.Words, c.Percentages) != "[a b] [0.5 0.5]" {
+					return fmt.Errorf("expected the new words, got %v %v", c.Words, c.Percentages)
+				}
+				return nil
+			},
+		},
+		{name: "words without percentages", method: http.MethodPut, body: `{"words":["a","b"]}`, status: http.StatusBadRequest},
+		{name: "invalid failure rate", method: http.MethodPut, body: `{"failureRate":2}`, status: http.StatusBadRequest},
+		{name: "invalid failure status", method: http.MethodPut, body: `{"failureStatus":200}`, status: http.StatusBadRequest},
+		{name: "negative latency", method: http.MethodPut, body: `{"latency":"-1s"}`, status: http.StatusBadRequest},
+		{name: "unknown field", method: http.MethodPut, body: `{"rateLimits":10}`, status: http.StatusBadRequest},
+		{name: "invalid json", method: http.MethodPut, body: `{"rateLimit":`, status: http.StatusBadRequest},
+		{name: "method", method: http.MethodPost, body: `{}`, status: http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rt := newRuntime(defaultConfig())
+			before := rt.load()
+
+			status, config := adminRequest(t, rt, test.method, test.body)
+			if status != test.status {
+				t.Fatalf("Expected status %d, got %d", test.status, status)
+			}
+			if test.status != http.StatusOK {
+				// a rejected change leaves the config as it was
+				if rt.load() != before {
+					t.Errorf("Expected the config to be unchanged, got %+v", rt.load())
+				}
+				return
+			}
+			if err := test.check(config); err != nil {
+				t.Errorf("response: %s", err)
+			}
+			if err := test.check(*rt.load()); err != nil {
+				t.Errorf("active config: %s", err)
+			}
+		})
+	}
+}
+
+func TestRuntimeAdminDelete(t *testing.T) {
+	rt := newRuntime(defaultConfig())
+	if status, _ := adminRequest(t, rt, http.MethodPut, `{"words":["a"],"percentages":[1],"rateLimit":1}`); status != http.StatusOK {
+		t.Fatalf("PUT status %d", status)
+	}
+	status, config := adminRequest(t, rt, http.MethodDelete, "")
+	if status != http.StatusOK {
+		t.Fatalf("DELETE status %d", status)
+	}
+	if config.RateLimit != 5 || fmt.Sprint(config.Words) != fmt.Sprint(assignment1Words) {
+		t.Errorf("Expected the initial config, got %+v", config)
+	}
+
+	// the initial config is cloned, so changing the active words doesn't change it
+	rt.load().Words[0] = "changed"
+	if _, config := adminRequest(t, rt, http.MethodDelete, ""); config.Words[0] != assignment1Words[0] {
+		t.Errorf("Expected the initial words to be unchanged, got %v", config.Words)
+	}
+}
+
+// TestRuntimeAdminConcurrent changes the words while they're read: every request
+// must see the words and percentages of one and the same change
+func TestRuntimeAdminConcurrent(t *testing.T) {
+	rt := newRuntime(defaultConfig())
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			words := make([]string, i)
+			percentages := make([]float64, i)
+			for j := range words {
+				words[j] = fmt.Sprintf("word%d", i)
+				percentages[j] = float64(i)
+			}
+			body, _ := json.Marshal(RuntimeConfig{Words: words, Percentages: percentages, RateLimit: i, FailureStatus: 503})
+			if status, _ := adminRequest(t, rt, http.MethodPut, string(body)); status != http.StatusOK {
+				t.Errorf("PUT status %d", status)
+			}
+		}(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config := rt.load()
+			if len(config.Words) != len(config.Percentages) {
+				t.Errorf("Read %d words and %d percentages", len(config.Words), len(config.Percentages))
+			}
+			if config.RateLimit != 5 && len(config.Words) != config.RateLimit {
+				t.Errorf("Read %d words of the change with rateLimit %d", len(config.Words), config.RateLimit)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRuntimeReload(t *testing.T) {
+	rt := newRuntime(defaultConfig())
+	adminRequest(t, rt, http.MethodPut, `{"rateLimit":10,"latency":"100ms"}`)
+
+	config := defaultConfig()
+	config.Latency = 200 * time.Millisecond
+	config.FailureRate = 0.5
+	rt.reload(config)
+
+	_, current := adminRequest(t, rt, http.MethodGet, "")
+	if current.RateLimit != 10 || current.Latency != duration(200*time.Millisecond) || current.FailureRate != 0.5 {
+		t.Errorf("Expected the faults of the config and the rate limit of the admin API, got %+v", current)
+	}
+	_, initial := adminRequest(t, rt, http.MethodDelete, "")
+	if initial.RateLimit != 5 || initial.Latency != duration(200*time.Millisecond) {
+		t.Errorf("Expected the initial config with the faults of the config, got %+v", initial)
+	}
+}
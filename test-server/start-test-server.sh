@@ -2,4 +2,5 @@
 # ./start-test-server.sh
 # You can use this script to start the server
 go get "github.com/golang-jwt/jwt/v4"
-go run assignment1.go main.go ratelimit.go
+go get "gopkg.in/yaml.v3"
+go run accesslog.go admin.go assignment1.go chaos.go compress.go config.go etag.go events.go fault.go health.go main.go metrics.go negotiate.go protected.go ratelimit.go runtime.go stream.go tls.go ws.go "$@"
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type StreamedWord struct {
+	Word string `json:"word"`
+}
+
+// wordsStream streams the words as newline delimited JSON, one word per line.
+// It starts with the words added so far, and then sends every word added with
+// /words as it arrives, until the client disconnects:
+//
+//	curl -N 'http://localhost:8080/words/stream'
+//	{"word":"word1"}
+//	{"word":"word2"}
+func (ct *WordsHandler) wordsStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "streaming not supported")
+		return
+	}
+
+	existing, words, unsubscribe := ct.words.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	for _, word := range existing {
+		if err := encoder.Encode(StreamedWord{Word: word}); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case word, ok := <-words:
+			if !ok {
+				// the server shuts down
+				return
+			}
+			if err := encoder.Encode(StreamedWord{Word: word}); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
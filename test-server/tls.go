@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"certs"
+)
+
+// the files written by generateDevCerts
+const (
+	devCAFile        = "ca.pem"
+	devCAKeyFile     = "ca-key.pem"
+	devServerFile    = "server.pem"
+	devServerKeyFile = "server-key.pem"
+	devClientFile    = "client.pem"
+	devClientKeyFile = "client-key.pem"
+)
+
+// the dev certificates are valid for a year, and renewed when they expire within a month
+const (
+	devCertsValidity    = 365 * 24 * time.Hour
+	devCertsRenewBefore = 30 * 24 * time.Hour
+)
+
+// generateDevCerts writes a dev CA, a server certificate for localhost, and a client
+// certificate for mTLS to dir. When they're already there from an earlier run, it
+// renews the ones that expire soon instead. They're for trying out TLS, never for
+// production.
+func generateDevCerts(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, devCAFile)); err == nil {
+		return renewDevCerts(dir)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	ca, err := certs.NewCA("test-server dev CA", devCertsValidity)
+	if err != nil {
+		return err
+	}
+	server, err := certs.NewServer(ca, "localhost", []string{"localhost", "127.0.0.1", "::1"}, devCertsValidity)
+	if err != nil {
+		return err
+	}
+	client, err := certs.NewClient(ca, "test-client", devCertsValidity)
+	if err != nil {
+		return err
+	}
+	for _, cert := range []struct {
+		cert              *certs.Cert
+		certFile, keyFile string
+	}{
+		{ca, devCAFile, devCAKeyFile},
+		{server, devServerFile, devServerKeyFile},
+		{client, devClientFile, devClientKeyFile},
+	} {
+		if err := cert.cert.WriteFiles(filepath.Join(dir, cert.certFile), filepath.Join(dir, cert.keyFile)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renewDevCerts renews the CA first, so the other certificates are signed by the
+// renewed one. The CA keeps its key, so clients trusting ca.pem keep working.
+func renewDevCerts(dir string) error {
+	caFile, caKeyFile := filepath.Join(dir, devCAFile), filepath.Join(dir, devCAKeyFile)
+	if _, err := certs.RenewFiles(caFile, caKeyFile, nil, devCertsRenewBefore, devCertsValidity); err != nil {
+		return fmt.Errorf("can't renew the dev CA: %w", err)
+	}
+	ca, err := certs.Load(caFile, caKeyFile)
+	if err != nil {
+		return err
+	}
+	for _, files := range [][2]string{{devServerFile, devServerKeyFile}, {devClientFile, devClientKeyFile}} {
+		if _, err := certs.RenewFiles(filepath.Join(dir, files[0]), filepath.Join(dir, files[1]), ca, devCertsRenewBefore, devCertsValidity); err != nil {
+			return fmt.Errorf("can't renew %s: %w", files[0], err)
+		}
+	}
+	return nil
+}
+
+// tlsConfig loads the server certificate, and with an mTLS CA requires the clients
+// to present a certificate signed by it
+func tlsConfig(config Config) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("can't load the TLS certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if config.MTLSCA == "" {
+		return tlsConfig, nil
+	}
+
+	caPEM, err := os.ReadFile(config.MTLSCA)
+	if err != nil {
+		return nil, fmt.Errorf("can't read the mTLS CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("the mTLS CA file has no PEM certificates")
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
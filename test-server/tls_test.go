@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"certs"
+)
+
+func TestGenerateDevCertsRenews(t *testing.T) {
+	dir := t.TempDir()
+	if err := generateDevCerts(dir); err != nil {
+		t.Fatalf("generateDevCerts error: %s", err)
+	}
+	ca, err := certs.Load(filepath.Join(dir, devCAFile), filepath.Join(dir, devCAKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a server certificate from an earlier run that expires tomorrow
+	serverFile, serverKeyFile := filepath.Join(dir, devServerFile), filepath.Join(dir, devServerKeyFile)
+	expiring, err := certs.NewServer(ca, "localhost", []string{"localhost"}, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := expiring.WriteFiles(serverFile, serverKeyFile); err != nil {
+		t.Fatal(err)
+	}
+	client, err := certs.Load(filepath.Join(dir, devClientFile), filepath.Join(dir, devClientKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := generateDevCerts(dir); err != nil {
+		t.Fatalf("generateDevCerts error: %s", err)
+	}
+	server, err := certs.Load(serverFile, serverKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.ExpiresWithin(devCertsRenewBefore) {
+		t.Errorf("Expected the server certificate to be renewed, it expires at %s", server.Cert.NotAfter)
+	}
+	if !server.Key.Equal(expiring.Key) {
+		t.Errorf("Expected the renewed server certificate to keep its key")
+	}
+	reloaded, err := certs.Load(filepath.Join(dir, devClientFile), filepath.Join(dir, devClientKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Cert.Equal(client.Cert) {
+		t.Errorf("Expected the client certificate that doesn't expire soon to stay")
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// the client must answer a ping within wsPongWait, pings are sent every wsPingPeriod
+	wsPongWait   = 30 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	wsWriteWait  = 5 * time.Second
+	// wsMinInterval stops a client from asking for a flood of words
+	wsMinInterval = 10 * time.Millisecond
+)
+
+// WSMessage is sent by the server: a generated word, the acknowledgement of a
+// new interval, or an error about a command of the client
+type WSMessage struct {
+	Word     string `json:"word,omitempty"`
+	Interval string `json:"interval,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// WSCommand is sent by the client to change the interval between the words
+type WSCommand struct {
+	Interval string `json:"interval"`
+}
+
+// wordSocket pushes generated words over a WebSocket
+type wordSocket struct {
+	upgrader websocket.Upgrader
+	interval time.Duration
+	runtime  *Runtime
+	done     chan struct{}
+	once     sync.Once
+	conns    sync.WaitGroup
+}
+
+func newWordSocket(interval time.Duration, runtime *Runtime) *wordSocket {
+	return &wordSocket{
+		upgrader: websocket.Upgrader{
+			// it's a test server, accept the connections of every web page
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		interval: interval,
+		runtime:  runtime,
+		done:     make(chan struct{}),
+	}
+}
+
+// close ends all connections with a going away close message
+func (s *wordSocket) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// wait returns when the connections have sent their close messages
+func (s *wordSocket) wait() {
+	s.conns.Wait()
+}
+
+// parseWSInterval parses an interval sent by the client or in the query string
+func parseWSInterval(value string) (time.Duration, error) {
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q: %s", value, err)
+	}
+	if interval < wsMinInterval {
+		return 0, fmt.Errorf("interval %s is shorter than %s", interval, wsMinInterval)
+	}
+	return interval, nil
+}
+
+// serve upgrades the connection and pushes a random word of the runtime config every interval, until the
+// client or the server closes the connection. The interval defaults to -ws-interval,
+// can be set with ?interval=, and the client changes it by sending a WSCommand, or
+// just the duration as text. The server acknowledges the new interval:
+//
+//	websocat 'ws://localhost:8080/ws/words?interval=500ms'
+//	{"word":"three"}
+//	> {"interval":"100ms"}
+//	{"interval":"100ms"}
+//	{"word":"one"}
+func (s *wordSocket) serve(w http.ResponseWriter, r *http.Request) {
+	interval := s.interval
+	if value := r.URL.Query().Get("interval"); value != "" {
+		var err error
+		if interval, err = parseWSInterval(value); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "%s", err)
+			return
+		}
+	}
+
+	// counted before the upgrade, while Shutdown still tracks the connection
+	s.conns.Add(1)
+	defer s.conns.Done()
+	// Upgrade writes the error response itself
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// gorilla/websocket allows one reader and one writer at a time: the reader
+	// goroutine hands the commands over to the writing loop below
+	commands := make(chan WSCommand)
+	readErr := make(chan error, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		readErr <- readWSCommands(conn, commands, stop)
+	}()
+
+	words := time.NewTicker(interval)
+	defer words.Stop()
+	pings := time.NewTicker(wsPingPeriod)
+	defer pings.Stop()
+
+	for {
+		var message WSMessage
+		select {
+		case <-words.C:
+			words := s.runtime.load().Words
+			message = WSMessage{Word: words[rand.Intn(len(words))]}
+		case command := <-commands:
+			newInterval, err := parseWSInterval(command.Interval)
+			if err != nil {
+				message = WSMessage{Error: err.Error()}
+				break
+			}
+			interval = newInterval
+			words.Reset(interval)
+			message = WSMessage{Interval: interval.String()}
+		case <-pings.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			continue
+		case <-readErr:
+			// the client closed the connection or stopped answering the pings
+			return
+		case <-s.done:
+			closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+			conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(wsWriteWait))
+			return
+		}
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := conn.WriteJSON(message); err != nil {
+			return
+		}
+	}
+}
+
+// readWSCommands passes the commands of the client on until the connection fails. Every pong
+// extends the read deadline, so a client that stops answering the pings times out.
+func readWSCommands(conn *websocket.Conn, commands chan<- WSCommand, stop <-chan struct{}) error {
+	conn.SetReadLimit(1024)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var command WSCommand
+		if err := json.Unmarshal(data, &command); err != nil {
+			command = WSCommand{Interval: string(data)}
+		}
+		select {
+		case commands <- command:
+		case <-stop:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// deliveries remembers the IDs of the deliveries for ttl, as GitHub and GitLab send a
+// delivery again when they didn't get a response in time, or on a redelivery from
+// their UI
+type deliveries struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+func newDeliveries(ttl time.Duration) *deliveries {
+	return &deliveries{ttl: ttl, now: time.Now, seen: make(map[string]time.Time)}
+}
+
+// begin returns true for a new delivery, which is remembered, and false when id was
+// already seen within the ttl
+func (d *deliveries) begin(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	if now.Sub(d.lastPrune) > d.ttl {
+		for seenID, at := range d.seen {
+			if now.Sub(at) > d.ttl {
+				delete(d.seen, seenID)
+			}
+		}
+		d.lastPrune = now
+	}
+	if at, ok := d.seen[id]; ok && now.Sub(at) <= d.ttl {
+		return false
+	}
+	d.seen[id] = now
+	return true
+}
+
+// forget forgets id, so the delivery is handled again when it's sent again, like
+// after a handler failed
+func (d *deliveries) forget(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, id)
+}
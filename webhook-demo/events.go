@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Delivery is a webhook request that passed the verification
+type Delivery struct {
+	// ID is the X-GitHub-Delivery or X-Gitlab-Event-UUID header
+	ID string
+	// Event is the name of the event, like push. The GitLab events are renamed to
+	// their GitHub names: Push Hook is push, Merge Request Hook is pull_request.
+	Event string
+	// Source is github or gitlab
+	Source  string
+	Payload json.RawMessage
+}
+
+// errNoHandler is returned by dispatch for the events without a handler
+var errNoHandler = errors.New("no handler")
+
+// dispatcher calls the handlers of the events
+type dispatcher struct {
+	handlers map[string]func(ctx context.Context, delivery Delivery) error
+}
+
+func newDispatcher() *dispatcher {
+	return &dispatcher{handlers: make(map[string]func(ctx context.Context, delivery Delivery) error)}
+}
+
+// on registers the handler of event, which gets the payload decoded into T
+func on[T any](d *dispatcher, event string, handler func(ctx context.Context, delivery Delivery, payload T) error) {
+	d.handlers[event] = func(ctx context.Context, delivery Delivery) error {
+		var payload T
+		if err := json.Unmarshal(delivery.Payload, &payload); err != nil {
+			return fmt.Errorf("invalid %s payload: %s", event, err)
+		}
+		return handler(ctx, delivery, payload)
+	}
+}
+
+// dispatch calls the handler of the event of delivery, errNoHandler when there's none
+func (d *dispatcher) dispatch(ctx context.Context, delivery Delivery) error {
+	handler, ok := d.handlers[delivery.Event]
+	if !ok {
+		return errNoHandler
+	}
+	return handler(ctx, delivery)
+}
+
+// gitlabEvents are the GitHub names of the X-Gitlab-Event headers
+var gitlabEvents = map[string]string{
+	"Push Hook":          "push",
+	"Tag Push Hook":      "push",
+	"Merge Request Hook": "pull_request",
+	"Issue Hook":         "issues",
+}
+
+// Repository is the repository of an event, or the project of GitLab
+type Repository struct {
+	FullName          string `json:"full_name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+}
+
+// Name is the owner/name of the repository
+func (r Repository) Name() string {
+	if r.FullName != "" {
+		return r.FullName
+	}
+	return r.PathWithNamespace
+}
+
+// PingEvent is sent when a webhook is created
+type PingEvent struct {
+	Zen    string `json:"zen"`
+	HookID int64  `json:"hook_id"`
+}
+
+// PushEvent is sent for the pushed commits and tags
+type PushEvent struct {
+	Ref        string     `json:"ref"`
+	Before     string     `json:"before"`
+	After      string     `json:"after"`
+	Repository Repository `json:"repository"`
+	// Project is the repository of GitLab
+	Project Repository `json:"project"`
+	Pusher  struct {
+		Name string `json:"name"`
+	} `json:"pusher"`
+	// UserUsername is the pusher of GitLab
+	UserUsername string `json:"user_username"`
+	Commits      []struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	} `json:"commits"`
+}
+
+// PullRequestEvent is sent when a pull request, or a GitLab merge request, is
+// opened, closed, merged...
+type PullRequestEvent struct {
+	Action      string     `json:"action"`
+	Number      int        `json:"number"`
+	Repository  Repository `json:"repository"`
+	PullRequest struct {
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		Merged  bool   `json:"merged"`
+	} `json:"pull_request"`
+	// Project and ObjectAttributes are the repository and the merge request of GitLab
+	Project          Repository `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		URL    string `json:"url"`
+		Action string `json:"action"`
+	} `json:"object_attributes"`
+}
+
+// IssuesEvent is sent when an issue is opened, closed, labeled...
+type IssuesEvent struct {
+	Action     string     `json:"action"`
+	Repository Repository `json:"repository"`
+	Issue      struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+	} `json:"issue"`
+	Project          Repository `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		URL    string `json:"url"`
+		Action string `json:"action"`
+	} `json:"object_attributes"`
+}
+
+// logHandlers registers handlers logging the ping, push, pull_request and issues
+// events
+func logHandlers(d *dispatcher, logger *slog.Logger) {
+	on(d, "ping", func(ctx context.Context, delivery Delivery, e PingEvent) error {
+		logger.InfoContext(ctx, "Ping", "delivery", delivery.ID, "hook", e.HookID, "zen", e.Zen)
+		return nil
+	})
+	on(d, "push", func(ctx context.Context, delivery Delivery, e PushEvent) error {
+		repository, pusher := e.Repository.Name(), e.Pusher.Name
+		if delivery.Source == sourceGitLab {
+			repository, pusher = e.Project.Name(), e.UserUsername
+		}
+		logger.InfoContext(ctx, "Push", "delivery", delivery.ID, "repository", repository,
+			"ref", strings.TrimPrefix(e.Ref, "refs/heads/"), "commits", len(e.Commits), "pusher", pusher, "after", e.After)
+		return nil
+	})
+	on(d, "pull_request", func(ctx context.Context, delivery Delivery, e PullRequestEvent) error {
+		if delivery.Source == sourceGitLab {
+			logger.InfoContext(ctx, "Merge request", "delivery", delivery.ID, "repository", e.Project.Name(),
+				"action", e.ObjectAttributes.Action, "number", e.ObjectAttributes.IID, "title", e.ObjectAttributes.Title, "url", e.ObjectAttributes.URL)
+			return nil
+		}
+		logger.InfoContext(ctx, "Pull request", "delivery", delivery.ID, "repository", e.Repository.Name(),
+			"action", e.Action, "number", e.Number, "title", e.PullRequest.Title, "url", e.PullRequest.HTMLURL)
+		return nil
+	})
+	on(d, "issues", func(ctx context.Context, delivery Delivery, e IssuesEvent) error {
+		if delivery.Source == sourceGitLab {
+			logger.InfoContext(ctx, "Issue", "delivery", delivery.ID, "repository", e.Project.Name(),
+				"action", e.ObjectAttributes.Action, "number", e.ObjectAttributes.IID, "title", e.ObjectAttributes.Title, "url", e.ObjectAttributes.URL)
+			return nil
+		}
+		logger.InfoContext(ctx, "Issue", "delivery", delivery.ID, "repository", e.Repository.Name(),
+			"action", e.Action, "number", e.Issue.Number, "title", e.Issue.Title, "url", e.Issue.HTMLURL)
+		return nil
+	})
+}
@@ -0,0 +1,128 @@
+// Command webhook-demo receives GitHub and GitLab webhooks, verifies their
+// signatures, skips the duplicate deliveries and dispatches the events to typed
+// handlers, and sends signed webhooks to test it
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"lifecycle"
+	"logging"
+	"secrets"
+)
+
+const usage = `Usage: %s [flags] command [args]
+
+Commands:
+  serve                        receive the webhooks on -listen, at /webhook
+  send event [file]            send the JSON payload of file, or of stdin, as event to -url
+
+The secret of the signatures is WEBHOOK_SECRET, or the secret key in Vault or the
+secret file with SECRETS_BACKEND=vault or file.
+
+`
+
+func main() {
+	listen := flag.String("listen", ":8090", "address of the server")
+	dedupeTTL := flag.Duration("dedupe-ttl", time.Hour, "skip the deliveries received again within this long")
+	url := flag.String("url", "http://localhost:8090/webhook", "url the webhooks are sent to")
+	retries := flag.Int("retries", 3, "send a delivery again this many times after a network error or a 5xx")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+
+	store, err := secrets.FromEnv("WEBHOOK_")
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+	secret, err := store.Get(ctx, "secret")
+	if err != nil {
+		fmt.Printf("Error: no webhook secret: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+
+	switch command, args := flag.Arg(0), flag.Args()[1:]; command {
+	case "serve":
+		err = runServe(ctx, *listen, []byte(secret), *dedupeTTL)
+	case "send":
+		err = runSend(ctx, sender{client: http.DefaultClient, secret: []byte(secret), retries: *retries, backoff: time.Second}, *url, args)
+	default:
+		flag.Usage()
+		stop()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+func runServe(ctx context.Context, listen string, secret []byte, dedupeTTL time.Duration) error {
+	logger, err := logging.Setup()
+	if err != nil {
+		return fmt.Errorf("invalid logging configuration: %s", err)
+	}
+	dispatcher := newDispatcher()
+	logHandlers(dispatcher, logger)
+
+	mux := http.NewServeMux()
+	mux.Handle("/webhook", &receiver{
+		secret:     secret,
+		deliveries: newDeliveries(dedupeTTL),
+		dispatcher: dispatcher,
+		logger:     logger,
+	})
+	server := &http.Server{Addr: listen, Handler: logging.Middleware(mux), ReadHeaderTimeout: 10 * time.Second}
+
+	var group lifecycle.Group
+	group.Add(lifecycle.HTTPServer("http", server, nil))
+	logger.Info("Receiving the webhooks", "addr", listen, "path", "/webhook")
+	return group.Run(ctx)
+}
+
+func runSend(ctx context.Context, s sender, url string, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("send takes an event and a file")
+	}
+	var (
+		payload []byte
+		err     error
+	)
+	if len(args) == 2 && args[1] != "-" {
+		payload, err = os.ReadFile(args[1])
+	} else {
+		payload, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+	id, status, err := s.send(ctx, url, args[0], payload)
+	if err != nil {
+		return fmt.Errorf("delivery %s: %s", id, err)
+	}
+	fmt.Printf("Delivery %s: %d %s\n", id, status, http.StatusText(status))
+	if status >= 400 {
+		return fmt.Errorf("delivery %s failed", id)
+	}
+	return nil
+}
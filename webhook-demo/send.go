@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// sender sends webhooks like GitHub does, signed with the secret
+type sender struct {
+	client *http.Client
+	secret []byte
+	// retries is the number of times a delivery is sent again, with the same ID,
+	// after a network error or a 5xx
+	retries int
+	// backoff is the wait before the first retry, doubled for every other one
+	backoff time.Duration
+}
+
+// send posts payload as event to url, and returns the ID of the delivery and the
+// status code of the response
+func (s sender) send(ctx context.Context, url, event string, payload []byte) (string, int, error) {
+	id, err := newDeliveryID()
+	if err != nil {
+		return "", 0, err
+	}
+	signature := sign(s.secret, payload)
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		status, err := s.post(ctx, url, event, id, signature, payload)
+		retry := err != nil || status >= 500
+		if !retry || attempt >= s.retries {
+			return id, status, err
+		}
+		select {
+		case <-ctx.Done():
+			return id, status, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s sender) post(ctx context.Context, url, event, id, signature string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "webhook-demo")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-GitHub-Delivery", id)
+	req.Header.Set("X-Hub-Signature-256", signature)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+	return res.StatusCode, nil
+}
+
+// newDeliveryID returns a random UUID, like the delivery IDs of GitHub
+func newDeliveryID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
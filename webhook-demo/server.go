@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// Sources of the deliveries
+const (
+	sourceGitHub = "github"
+	sourceGitLab = "gitlab"
+)
+
+// maxPayload is the largest payload GitHub sends, 25 MB
+const maxPayload = 25 << 20
+
+// receiver verifies the webhook requests, skips the deliveries it already got, and
+// dispatches the others
+type receiver struct {
+	secret     []byte
+	deliveries *deliveries
+	dispatcher *dispatcher
+	logger     *slog.Logger
+}
+
+// ServeHTTP replies:
+//   - 401 to the requests without a valid signature, or GitLab token
+//   - 400 to the requests without an event or a delivery ID
+//   - 200 to a delivery already handled, without handling it again
+//   - 202 to the events without a handler, which are ignored
+//   - 500 when the handler fails, after which a redelivery is handled again
+//   - 204 when the handler succeeded
+func (rv *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayload))
+	if err != nil {
+		http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	delivery := Delivery{Payload: body}
+	if gitlabEvent := r.Header.Get("X-Gitlab-Event"); gitlabEvent != "" {
+		delivery.Source = sourceGitLab
+		err = verifyToken(rv.secret, r.Header.Get("X-Gitlab-Token"))
+		delivery.Event = gitlabEvents[gitlabEvent]
+		if delivery.Event == "" {
+			delivery.Event = gitlabEvent
+		}
+		delivery.ID = r.Header.Get("X-Gitlab-Event-UUID")
+	} else {
+		delivery.Source = sourceGitHub
+		err = verifySignature(rv.secret, body, r.Header.Get("X-Hub-Signature-256"))
+		delivery.Event = r.Header.Get("X-GitHub-Event")
+		delivery.ID = r.Header.Get("X-GitHub-Delivery")
+	}
+	if err != nil {
+		rv.logger.WarnContext(r.Context(), "Rejected a webhook", "error", err, "remote", r.RemoteAddr)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if delivery.Event == "" || delivery.ID == "" {
+		http.Error(w, "no event or delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	logger := rv.logger.With("delivery", delivery.ID, "event", delivery.Event, "source", delivery.Source)
+	if !rv.deliveries.begin(delivery.ID) {
+		logger.InfoContext(r.Context(), "Skipped a duplicate delivery")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	err = rv.dispatcher.dispatch(r.Context(), delivery)
+	switch {
+	case errors.Is(err, errNoHandler):
+		logger.DebugContext(r.Context(), "Ignored an event without a handler")
+		w.WriteHeader(http.StatusAccepted)
+	case err != nil:
+		rv.deliveries.forget(delivery.ID)
+		logger.ErrorContext(r.Context(), "Handler failed", "error", err)
+		http.Error(w, "handler failed", http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("secret")
+
+// newTestReceiver returns a receiver counting the push events, failing them while
+// fail is set
+func newTestReceiver(pushes *atomic.Int32, fail *atomic.Bool) *receiver {
+	d := newDispatcher()
+	on(d, "push", func(ctx context.Context, delivery Delivery, e PushEvent) error {
+		if fail.Load() {
+			return errors.New("database down")
+		}
+		if e.Ref != "refs/heads/main" {
+			return errors.New("unexpected ref " + e.Ref)
+		}
+		pushes.Add(1)
+		return nil
+	})
+	return &receiver{
+		secret:     testSecret,
+		deliveries: newDeliveries(time.Hour),
+		dispatcher: d,
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestReceiver(t *testing.T) {
+	var pushes atomic.Int32
+	var fail atomic.Bool
+	rv := newTestReceiver(&pushes, &fail)
+	payload, err := os.ReadFile("testdata/push.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(headers map[string]string, body []byte) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		rv.ServeHTTP(w, req)
+		return w.Code
+	}
+	github := func(event, id string) map[string]string {
+		return map[string]string{"X-GitHub-Event": event, "X-GitHub-Delivery": id, "X-Hub-Signature-256": sign(testSecret, payload)}
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    []byte
+		status  int
+		pushes  int32
+	}{
+		{name: "push", headers: github("push", "1"), body: payload, status: http.StatusNoContent, pushes: 1},
+		{name: "duplicate", headers: github("push", "1"), body: payload, status: http.StatusOK, pushes: 1},
+		{name: "no handler", headers: github("star", "2"), body: payload, status: http.StatusAccepted, pushes: 1},
+		{name: "tampered", headers: github("push", "3"), body: append([]byte(" "), payload...), status: http.StatusUnauthorized, pushes: 1},
+		{name: "unsigned", headers: map[string]string{"X-GitHub-Event": "push", "X-GitHub-Delivery": "4"}, body: payload, status: http.StatusUnauthorized, pushes: 1},
+		{name: "no delivery", headers: github("push", ""), body: payload, status: http.StatusBadRequest, pushes: 1},
+		{name: "gitlab", headers: map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Event-UUID": "5", "X-Gitlab-Token": "secret"}, body: payload, status: http.StatusNoContent, pushes: 2},
+		{name: "gitlab invalid token", headers: map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Event-UUID": "6", "X-Gitlab-Token": "guess"}, body: payload, status: http.StatusUnauthorized, pushes: 2},
+		{name: "invalid payload", headers: map[string]string{"X-GitHub-Event": "push", "X-GitHub-Delivery": "7", "X-Hub-Signature-256": sign(testSecret, []byte("{"))}, body: []byte("{"), status: http.StatusInternalServerError, pushes: 2},
+	}
+	for _, test := range tests {
+		if status := post(test.headers, test.body); status != test.status {
+			t.Errorf("%s: expected %d, got %d", test.name, test.status, status)
+		}
+		if n := pushes.Load(); n != test.pushes {
+			t.Errorf("%s: expected %d pushes, got %d", test.name, test.pushes, n)
+		}
+	}
+
+	// a failed delivery is handled again when it's sent again
+	fail.Store(true)
+	if status := post(github("push", "8"), payload); status != http.StatusInternalServerError {
+		t.Errorf("Expected 500 when the handler fails, got %d", status)
+	}
+	fail.Store(false)
+	if status := post(github("push", "8"), payload); status != http.StatusNoContent {
+		t.Errorf("Expected the redelivery to be handled, got %d", status)
+	}
+}
+
+func TestDeliveriesExpiry(t *testing.T) {
+	d := newDeliveries(time.Minute)
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	if !d.begin("1") || d.begin("1") {
+		t.Fatal("Expected only the first delivery to begin")
+	}
+	now = now.Add(2 * time.Minute)
+	if !d.begin("1") {
+		t.Error("Expected the delivery to begin again after the ttl")
+	}
+	if len(d.seen) != 1 {
+		t.Errorf("Expected the expired deliveries to be pruned, got %d", len(d.seen))
+	}
+}
+
+func TestSender(t *testing.T) {
+	var pushes atomic.Int32
+	var fail atomic.Bool
+	rv := newTestReceiver(&pushes, &fail)
+	// the first request fails, the retry is handled once
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		rv.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	payload, err := os.ReadFile("testdata/push.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := sender{client: server.Client(), secret: testSecret, retries: 2, backoff: time.Millisecond}
+	id, status, err := s.send(context.Background(), server.URL, "push", payload)
+	if err != nil {
+		t.Fatalf("send error: %s", err)
+	}
+	if status != http.StatusNoContent || requests.Load() != 2 || pushes.Load() != 1 {
+		t.Errorf("Expected a push after a retry, got %d after %d requests and %d pushes", status, requests.Load(), pushes.Load())
+	}
+	if len(id) != 36 {
+		t.Errorf("Expected a UUID, got %q", id)
+	}
+
+	s.secret = []byte("other")
+	if _, status, _ := s.send(context.Background(), server.URL, "push", payload); status != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with another secret, got %d", status)
+	}
+}
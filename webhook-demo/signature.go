@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// signaturePrefix is the algorithm before the hex HMAC of X-Hub-Signature-256
+const signaturePrefix = "sha256="
+
+var (
+	errNoSignature      = errors.New("no signature")
+	errInvalidSignature = errors.New("invalid signature")
+)
+
+// sign returns the X-Hub-Signature-256 of body: the HMAC-SHA256 of the body with the
+// secret, in hex, after sha256=
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature checks the X-Hub-Signature-256 of body, in constant time
+func verifySignature(secret, body []byte, signature string) error {
+	if signature == "" {
+		return errNoSignature
+	}
+	hexMAC, ok := strings.CutPrefix(signature, signaturePrefix)
+	if !ok {
+		return errInvalidSignature
+	}
+	mac, err := hex.DecodeString(hexMAC)
+	if err != nil {
+		return errInvalidSignature
+	}
+	expected := hmac.New(sha256.New, secret)
+	expected.Write(body)
+	if !hmac.Equal(mac, expected.Sum(nil)) {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// verifyToken checks the X-Gitlab-Token of GitLab, the secret itself, in constant
+// time
+func verifyToken(secret []byte, token string) error {
+	if token == "" {
+		return errNoSignature
+	}
+	if subtle.ConstantTimeCompare(secret, []byte(token)) != 1 {
+		return errInvalidSignature
+	}
+	return nil
+}
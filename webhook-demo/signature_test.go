@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	// the example of the GitHub documentation
+	signature := sign([]byte("It's a Secret to Everybody"), []byte("Hello, World!"))
+	expected := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+	if signature != expected {
+		t.Errorf("Expected %s, got %s", expected, signature)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	secret, body := []byte("secret"), []byte(`{"zen":"Keep it logically awesome."}`)
+	tests := map[string]struct {
+		signature string
+		err       error
+	}{
+		"valid":          {signature: sign(secret, body)},
+		"missing":        {err: errNoSignature},
+		"other secret":   {signature: sign([]byte("other"), body), err: errInvalidSignature},
+		"other body":     {signature: sign(secret, []byte("{}")), err: errInvalidSignature},
+		"sha1":           {signature: "sha1=0123456789abcdef", err: errInvalidSignature},
+		"not hex":        {signature: "sha256=zz", err: errInvalidSignature},
+		"without prefix": {signature: sign(secret, body)[len(signaturePrefix):], err: errInvalidSignature},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := verifySignature(secret, body, test.signature); !errors.Is(err, test.err) {
+				t.Errorf("Expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
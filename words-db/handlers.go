@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WordsOutput and OccurrenceOutput are the responses of the test-server, so its
+// clients work with the words-db too
+type WordsOutput struct {
+	Page  string   `json:"page"`
+	Input string   `json:"input"`
+	Words []string `json:"words"`
+}
+
+type OccurrenceOutput struct {
+	Page  string         `json:"page"`
+	Words map[string]int `json:"words"`
+}
+
+// PoolStats are the statistics of the connection pool of /dbstats
+type PoolStats struct {
+	MaxOpenConnections int `json:"maxOpenConnections"`
+	OpenConnections    int `json:"openConnections"`
+	InUse              int `json:"inUse"`
+	Idle               int `json:"idle"`
+	// WaitCount is how many times a query waited for a connection, all the
+	// MaxOpenConnections being in use, and WaitDuration how long in total
+	WaitCount         int64  `json:"waitCount"`
+	WaitDuration      string `json:"waitDuration"`
+	MaxIdleClosed     int64  `json:"maxIdleClosed"`
+	MaxIdleTimeClosed int64  `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed int64  `json:"maxLifetimeClosed"`
+}
+
+// wordStore is where the words are
+type wordStore interface {
+	Add(ctx context.Context, word string) error
+	Words(ctx context.Context) ([]string, error)
+	Occurrence(ctx context.Context) (map[string]int, error)
+}
+
+type handlers struct {
+	store wordStore
+	// db is pinged by /readyz, and its pool is reported by /dbstats
+	db           *sql.DB
+	queryTimeout time.Duration
+	logger       *slog.Logger
+}
+
+func (h *handlers) register(mux *http.ServeMux) {
+	mux.HandleFunc("/words", h.wordsHandler)
+	mux.HandleFunc("/occurrence", h.occurrenceHandler)
+	mux.HandleFunc("/healthz", h.healthz)
+	mux.HandleFunc("/readyz", h.readyz)
+	mux.HandleFunc("/dbstats", h.dbStats)
+}
+
+// wordsHandler adds the word of ?input=, and returns the words
+func (h *handlers) wordsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.queryTimeout)
+	defer cancel()
+	input := r.URL.Query().Get("input")
+	if input != "" {
+		if err := h.store.Add(ctx, input); err != nil {
+			h.fail(w, r, "add the word", err)
+			return
+		}
+	}
+	words, err := h.store.Words(ctx)
+	if err != nil {
+		h.fail(w, r, "list the words", err)
+		return
+	}
+	writeJSON(w, WordsOutput{Page: "words", Input: input, Words: words})
+}
+
+func (h *handlers) occurrenceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.queryTimeout)
+	defer cancel()
+	occurrence, err := h.store.Occurrence(ctx)
+	if err != nil {
+		h.fail(w, r, "count the words", err)
+		return
+	}
+	writeJSON(w, OccurrenceOutput{Page: "occurrence", Words: occurrence})
+}
+
+// healthz is the liveness probe: the server answers, so it's alive
+func (h *handlers) healthz(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+// readyz is the readiness probe: 503 when the database doesn't answer
+func (h *handlers) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.queryTimeout)
+	defer cancel()
+	if err := h.db.PingContext(ctx); err != nil {
+		h.logger.WarnContext(ctx, "The database doesn't answer", "error", err)
+		http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ready"))
+}
+
+// dbStats returns the statistics of the connection pool, to tune it
+func (h *handlers) dbStats(w http.ResponseWriter, r *http.Request) {
+	stats := h.db.Stats()
+	writeJSON(w, PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.String(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	})
+}
+
+// fail logs the error of a query, and answers 500 without its details
+func (h *handlers) fail(w http.ResponseWriter, r *http.Request, action string, err error) {
+	h.logger.ErrorContext(r.Context(), "Can't "+action, "error", err)
+	http.Error(w, "database error", http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
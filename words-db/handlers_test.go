@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"migrate"
+)
+
+// memoryStore is a wordStore in memory
+type memoryStore struct {
+	mu    sync.Mutex
+	words []string
+	err   error
+}
+
+func (s *memoryStore) Add(ctx context.Context, word string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.words = append(s.words, word)
+	return s.err
+}
+
+func (s *memoryStore) Words(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.words...), s.err
+}
+
+func (s *memoryStore) Occurrence(ctx context.Context) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	occurrence := make(map[string]int)
+	for _, word := range s.words {
+		occurrence[word]++
+	}
+	return occurrence, s.err
+}
+
+func TestHandlers(t *testing.T) {
+	store := &memoryStore{}
+	mux := http.NewServeMux()
+	h := &handlers{store: store, queryTimeout: time.Second, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	h.register(mux)
+
+	get := func(url string, v any) int {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+		if v != nil && recorder.Code == http.StatusOK {
+			if err := json.Unmarshal(recorder.Body.Bytes(), v); err != nil {
+				t.Fatalf("Invalid JSON %s: %s", recorder.Body, err)
+			}
+		}
+		return recorder.Code
+	}
+
+	var words WordsOutput
+	for _, word := range []string{"a", "b", "a"} {
+		get("/words?input="+word, &words)
+	}
+	expected := WordsOutput{Page: "words", Input: "a", Words: []string{"a", "b", "a"}}
+	if !reflect.DeepEqual(words, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, words)
+	}
+	var occurrence OccurrenceOutput
+	get("/occurrence", &occurrence)
+	if !reflect.DeepEqual(occurrence.Words, map[string]int{"a": 2, "b": 1}) {
+		t.Errorf("Expected a:2 b:1, got %v", occurrence.Words)
+	}
+
+	store.err = errors.New("connection refused")
+	if code := get("/words", nil); code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 when the database fails, got %d", code)
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	// the dialects have the same versions, so the status of a database doesn't
+	// depend on it
+	sqlite, err := migrations(migrate.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := migrations(migrate.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqlite) == 0 || len(sqlite) != len(postgres) {
+		t.Fatalf("Expected the same migrations, got %v and %v", sqlite, postgres)
+	}
+	for i := range sqlite {
+		if sqlite[i].String() != postgres[i].String() {
+			t.Errorf("Expected the same migration %d, got %s and %s", i, sqlite[i], postgres[i])
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := defaultConfig().Validate(); err != nil {
+		t.Errorf("Expected the default config to be valid, got %s", err)
+	}
+	c := defaultConfig()
+	c.Driver = "mysql"
+	c.MaxIdleConns = 20
+	if err := c.Validate(); err == nil {
+		t.Error("Expected an invalid driver and too many idle connections")
+	}
+}
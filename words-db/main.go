@@ -0,0 +1,178 @@
+// Command words-db serves the words and occurrence endpoints of the test-server from
+// a SQLite or PostgreSQL database, whose tables are created by the migrations
+// embedded in the binary
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // -driver postgres
+	_ "modernc.org/sqlite"             // -driver sqlite, pure Go, without cgo
+
+	"config"
+	"lifecycle"
+	"logging"
+	"migrate"
+)
+
+func main() {
+	logger, err := logging.Setup()
+	if err != nil {
+		logger.Error("Invalid logging configuration", "error", err)
+		os.Exit(2)
+	}
+	// serve, migrate or status, before the flags
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	if command != "serve" && command != "migrate" && command != "status" {
+		logger.Error("Invalid command: serve, migrate or status expected", "command", command)
+		os.Exit(2)
+	}
+	c, err := config.Load(defaultConfig(), args, config.Options{Name: "words-db " + command, EnvPrefix: "WORDS_DB_"})
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(2)
+	}
+
+	ctx, stop := lifecycle.SignalContext(context.Background())
+	defer stop()
+	db, err := openDB(ctx, c)
+	if err != nil {
+		logger.Error("Can't connect to the database", "driver", c.Driver, "error", err)
+		stop()
+		os.Exit(1)
+	}
+	defer db.Close()
+	switch command {
+	case "serve":
+		err = serve(ctx, c, db, logger)
+	case "migrate":
+		err = migrateUp(ctx, c, db, logger)
+	case "status":
+		err = printStatus(ctx, c, db, os.Stdout)
+	}
+	if err != nil {
+		logger.Error("Stopped with errors", "command", command, "error", err)
+		db.Close()
+		stop()
+		os.Exit(1)
+	}
+}
+
+// openDB opens the database of the config with its pool settings, and checks it
+// answers
+func openDB(ctx context.Context, c Config) (*sql.DB, error) {
+	db, err := sql.Open(c.driverName(), c.DSN)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrateUp applies the pending migrations
+func migrateUp(ctx context.Context, c Config, db *sql.DB, logger *slog.Logger) error {
+	migrations, err := migrations(c.dialect())
+	if err != nil {
+		return err
+	}
+	applied, err := migrate.Up(ctx, db, c.dialect(), migrations)
+	for _, m := range applied {
+		logger.Info("Migration applied", "migration", m.String())
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		logger.Info("The database is up to date", "version", migrations[len(migrations)-1].Version)
+	}
+	return nil
+}
+
+// printStatus prints the migrations and when they were applied
+func printStatus(ctx context.Context, c Config, db *sql.DB, w io.Writer) error {
+	migrations, err := migrations(c.dialect())
+	if err != nil {
+		return err
+	}
+	statuses, err := migrate.Statuses(ctx, db, c.dialect(), migrations)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+	for _, s := range statuses {
+		applied := "pending"
+		if !s.AppliedAt.IsZero() {
+			applied = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	return tw.Flush()
+}
+
+// serve serves the endpoints until ctx is done, after applying the pending
+// migrations with -auto-migrate, else only when none is pending
+func serve(ctx context.Context, c Config, db *sql.DB, logger *slog.Logger) error {
+	if c.AutoMigrate {
+		if err := migrateUp(ctx, c, db, logger); err != nil {
+			return err
+		}
+	} else {
+		migrations, err := migrations(c.dialect())
+		if err != nil {
+			return err
+		}
+		statuses, err := migrate.Statuses(ctx, db, c.dialect(), migrations)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if s.AppliedAt.IsZero() {
+				return fmt.Errorf("migration %s is pending, run words-db migrate first", s.Migration)
+			}
+		}
+	}
+	store, err := newStore(ctx, db, c.dialect())
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	h := &handlers{store: store, db: db, queryTimeout: c.QueryTimeout, logger: logger}
+	h.register(mux)
+	server := &http.Server{Addr: c.Listen, Handler: logging.Middleware(mux)}
+	logger.Info("Starting the server", "addr", c.Listen, "driver", c.Driver)
+
+	// the statements are closed once the requests are drained
+	var group lifecycle.Group
+	group.Add(
+		lifecycle.Component{Name: "store", Stop: func(ctx context.Context) error { return store.Close() }},
+		lifecycle.HTTPServer("http", server, nil),
+	)
+	return group.Run(ctx)
+}
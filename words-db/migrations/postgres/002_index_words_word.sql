@@ -0,0 +1,2 @@
+-- the occurrence counts group by word
+CREATE INDEX words_word ON words (word);
@@ -0,0 +1,6 @@
+-- the words in the order they're added, added_at is in Unix milliseconds
+CREATE TABLE words (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	word TEXT NOT NULL,
+	added_at BIGINT NOT NULL
+);
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"migrate"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrations returns the migrations of the dialect, in migrations/<dialect>
+func migrations(dialect migrate.Dialect) ([]migrate.Migration, error) {
+	return migrate.Load(migrationFiles, "migrations/"+string(dialect))
+}
+
+// store holds the words in the words table. The queries are prepared once: the
+// database parses and plans them on every connection of the pool the first time
+// they're used, then only gets the arguments.
+type store struct {
+	add, words, occurrence *sql.Stmt
+}
+
+// newStore prepares the queries of the store in db, whose migrations are applied
+func newStore(ctx context.Context, db *sql.DB, dialect migrate.Dialect) (*store, error) {
+	insert := "INSERT INTO words (word, added_at) VALUES (?, ?)"
+	if dialect == migrate.Postgres {
+		insert = "INSERT INTO words (word, added_at) VALUES ($1, $2)"
+	}
+	s := &store{}
+	for _, statement := range []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&s.add, insert},
+		{&s.words, "SELECT word FROM words ORDER BY id"},
+		{&s.occurrence, "SELECT word, COUNT(*) FROM words GROUP BY word"},
+	} {
+		stmt, err := db.PrepareContext(ctx, statement.query)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("prepare %q: %w", statement.query, err)
+		}
+		*statement.stmt = stmt
+	}
+	return s, nil
+}
+
+// Add adds a word
+func (s *store) Add(ctx context.Context, word string) error {
+	_, err := s.add.ExecContext(ctx, word, time.Now().UnixMilli())
+	return err
+}
+
+// Words returns the words in the order they were added
+func (s *store) Words(ctx context.Context) ([]string, error) {
+	rows, err := s.words.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	words := []string{}
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// Occurrence returns how many times every word was added, counted by the database
+func (s *store) Occurrence(ctx context.Context) (map[string]int, error) {
+	rows, err := s.occurrence.QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	occurrence := make(map[string]int)
+	for rows.Next() {
+		var word string
+		var count int
+		if err := rows.Scan(&word, &count); err != nil {
+			return nil, err
+		}
+		occurrence[word] = count
+	}
+	return occurrence, rows.Err()
+}
+
+// Close closes the prepared statements
+func (s *store) Close() error {
+	var errs []error
+	for _, stmt := range []*sql.Stmt{s.add, s.words, s.occurrence} {
+		if stmt != nil {
+			errs = append(errs, stmt.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
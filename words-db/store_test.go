@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openTestDB opens a SQLite database in memory. Every connection to :memory: has
+// its own database, so the pool keeps a single one.
+func openTestDB(t *testing.T) (Config, *sql.DB) {
+	t.Helper()
+	c := defaultConfig()
+	c.DSN = ":memory:"
+	c.MaxOpenConns = 1
+	c.MaxIdleConns = 1
+	c.ConnMaxLifetime = 0
+	c.ConnMaxIdleTime = 0
+	db, err := openDB(t.Context(), c)
+	if err != nil {
+		t.Fatalf("openDB error: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return c, db
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	c, db := openTestDB(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var status bytes.Buffer
+	if err := printStatus(t.Context(), c, db, &status); err != nil {
+		t.Fatalf("printStatus error: %s", err)
+	}
+	if strings.Count(status.String(), "pending") != 2 {
+		t.Errorf("Expected 2 pending migrations, got\n%s", status.String())
+	}
+
+	if err := migrateUp(t.Context(), c, db, logger); err != nil {
+		t.Fatalf("migrateUp error: %s", err)
+	}
+	status.Reset()
+	if err := printStatus(t.Context(), c, db, &status); err != nil {
+		t.Fatalf("printStatus error: %s", err)
+	}
+	if strings.Contains(status.String(), "pending") || !strings.Contains(status.String(), "index_words_word") {
+		t.Errorf("Expected the migrations to be applied, got\n%s", status.String())
+	}
+	var index string
+	if err := db.QueryRowContext(t.Context(), "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'words'").Scan(&index); err != nil {
+		t.Errorf("Expected the index of 002_index_words_word: %s", err)
+	}
+
+	// applying them again does nothing
+	if err := migrateUp(t.Context(), c, db, logger); err != nil {
+		t.Fatalf("second migrateUp error: %s", err)
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	c, db := openTestDB(t)
+	if err := migrateUp(t.Context(), c, db, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatal(err)
+	}
+	s, err := newStore(t.Context(), db, c.dialect())
+	if err != nil {
+		t.Fatalf("newStore error: %s", err)
+	}
+	defer s.Close()
+
+	words, err := s.Words(t.Context())
+	if err != nil || words == nil || len(words) != 0 {
+		t.Errorf("Expected no words, got %v, %v", words, err)
+	}
+	// the arguments aren't part of the SQL
+	added := []string{"b", "a", "'; DROP TABLE words; --", "a"}
+	for _, word := range added {
+		if err := s.Add(t.Context(), word); err != nil {
+			t.Fatalf("Add error: %s", err)
+		}
+	}
+	words, err = s.Words(t.Context())
+	if err != nil || !reflect.DeepEqual(words, added) {
+		t.Errorf("Expected %v in order, got %v, %v", added, words, err)
+	}
+	occurrence, err := s.Occurrence(t.Context())
+	expected := map[string]int{"a": 2, "b": 1, "'; DROP TABLE words; --": 1}
+	if err != nil || !reflect.DeepEqual(occurrence, expected) {
+		t.Errorf("Expected %v, got %v, %v", expected, occurrence, err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if err := s.Add(ctx, "late"); err == nil {
+		t.Error("Expected the query to fail after the timeout")
+	}
+}
+
+func TestServeWithoutMigrations(t *testing.T) {
+	c, db := openTestDB(t)
+	c.AutoMigrate = false
+	err := serve(t.Context(), c, db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err == nil || !strings.Contains(err.Error(), "is pending, run words-db migrate first") {
+		t.Errorf("Expected serve to refuse pending migrations, got %v", err)
+	}
+}